@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/events"
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// deliveryLease is how long a queued change set is claimed for its delivery, it's claimed again once the
+// lease expired if it wasn't marked delivered, see sqlite.OutboxRepository.
+const deliveryLease = 10 * time.Minute
+
+// startDelivery runs the delivery loop in the background and returns the function waiting for it to
+// stop once the context is canceled.
+func (a *app) startDelivery(ctx context.Context, interval time.Duration) func() {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.runDelivery(ctx, interval)
+	}()
+
+	return func() { <-done }
+}
+
+// runDelivery delivers the changes queued by the checks of a tenant until the context is canceled. The
+// outbox is polled every interval for the changes queued by another process, and right away when a
+// check of this process queued changes.
+func (a *app) runDelivery(ctx context.Context, interval time.Duration) {
+	a.deliverPending(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			a.deliverPending(ctx)
+
+		case <-a.queued:
+			a.deliverPending(ctx)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// wakeDelivery tells the delivery loop that a check queued changes. It never blocks, a wake-up that is
+// already pending covers the new changes too.
+func (a *app) wakeDelivery() {
+	select {
+	case a.queued <- struct{}{}:
+	default:
+	}
+}
+
+// deliverPending notifies the subscribers of the change sets queued in the outbox, oldest first. The hooks
+// only evaluate the changes of the main page. A change set is claimed for the delivery lease before it's
+// sent and marked delivered once sent, so it's sent at least once: one whose delivery didn't finish, e.g.
+// as the process stopped, is sent again once its lease expired. One claimed by another bot process, or
+// replaying the previous one, is skipped.
+func (a *app) deliverPending(ctx context.Context) {
+	entries, err := a.outbox.GetPendingChanges(ctx)
+	if err != nil {
+		a.log.ErrorContext(ctx, "failed to get queued changes", "error", err)
+		return
+	}
+
+	for _, entry := range entries {
+		log := a.log.With("run_id", entry.RunID, "target", entry.Target)
+
+		claimed, err := a.outbox.ClaimChanges(ctx, entry.ID, time.Now(), deliveryLease)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to claim queued changes", "error", err)
+			return
+		}
+		if !claimed {
+			log.InfoContext(ctx, "Skipping queued changes claimed before", "detected_at", entry.DetectedAt)
+			continue
+		}
+
+		if a.duplicate(ctx, log, entry) {
+			log.InfoContext(ctx, "Suppressing queued changes identical to ones delivered lately",
+				"detected_at", entry.DetectedAt, "window", a.duplicateWindow)
+			a.markDelivered(ctx, log, entry)
+			continue
+		}
+
+		log.InfoContext(ctx, "Delivering queued changes", "detected_at", entry.DetectedAt)
+		var hooks []checkHook
+		if entry.Target == models.MainTarget {
+			hooks = a.afterCheck
+		}
+		// The run ID lets the notification link the full diff of the run.
+		a.notify(events.WithRunID(ctx, entry.RunID), log, entry.Changes, hooks)
+
+		// The prices of the regions aren't compared, the targets are compared in their default profiles.
+		if !strings.Contains(entry.Target, models.RegionSeparator) {
+			if err = a.notifier.SendPriceComparisons(ctx, entry.Target, entry.Changes); err != nil {
+				log.ErrorContext(ctx, "failed to compare prices across targets", "error", err)
+			}
+		}
+
+		a.markDelivered(ctx, log, entry)
+	}
+}
+
+// markDelivered marks the queued change set delivered. If it can't be, the change set is sent again once
+// its lease expired.
+func (a *app) markDelivered(ctx context.Context, log *slog.Logger, entry models.OutboxEntry) {
+	if err := a.outbox.MarkChangesDelivered(ctx, entry.ID, time.Now()); err != nil {
+		log.ErrorContext(ctx, "failed to mark queued changes delivered", "error", err)
+	}
+}
+
+// duplicate reports whether the queued change set is identical to one of its target delivered within the
+// duplicate window, e.g. by a manual check overlapping a scheduled one. The change set is delivered if
+// the fingerprints can't be compared.
+func (a *app) duplicate(ctx context.Context, log *slog.Logger, entry models.OutboxEntry) bool {
+	if a.duplicateWindow <= 0 {
+		return false
+	}
+
+	claimed, err := a.outbox.ClaimDiff(ctx, entry.Target, entry.Changes.Fingerprint(), time.Now(), a.duplicateWindow)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to compare queued changes with the ones delivered lately", "error", err)
+		return false
+	}
+
+	return !claimed
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+)
+
+// Commands which switch the binary into dumping or loading the application state.
+const (
+	dumpCommand = "dump"
+	loadCommand = "load"
+)
+
+// dumpUsage explains the dump and load commands.
+const dumpUsage = `Usage: chrono-flow dump -file <archive.tar.gz>
+       chrono-flow load -file <archive.tar.gz>
+dump writes the state, history, subscriptions, settings, targets, tenants and API tokens of all
+tenants to a portable archive. load restores it into an empty database created by the same release.
+Stop chrono-flow before loading, the archive contains bot tokens and token hashes, keep it private.`
+
+// controlDump runs the dump or load command and returns the process exit code.
+func controlDump(command string, args []string) int {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	path := flags.String("file", "", "path of the archive")
+	if err := flags.Parse(args); err != nil || *path == "" || flags.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, dumpUsage)
+		return 2 //nolint:mnd // exit code 2 is the convention for invalid usage.
+	}
+
+	ctx := context.Background()
+	repo, err := openCommandRepository(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer repo.Close()
+
+	if command == dumpCommand {
+		err = dumpState(ctx, repo, *path)
+	} else {
+		err = loadState(ctx, repo, *path)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to %s state: %v\n", command, err)
+		return 1
+	}
+
+	return 0
+}
+
+// dumpState writes the archive of the repository to the path, the file is only readable by the owner.
+func dumpState(ctx context.Context, repo *sqlite.Repository, path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) //nolint:mnd // owner only.
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+
+	if err = repo.Dump(ctx, file); err != nil {
+		_ = file.Close()
+		_ = os.Remove(path)
+		return err //nolint:wrapcheck // the repository error names the operation.
+	}
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	fmt.Printf("state dumped to %s\n", path) //nolint:forbidigo // the result is the command output.
+
+	return nil
+}
+
+// loadState restores the archive at the path into the repository.
+func loadState(ctx context.Context, repo *sqlite.Repository, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	manifest, err := repo.Load(ctx, file)
+	if err != nil {
+		return err //nolint:wrapcheck // the repository error names the operation.
+	}
+
+	var rows int64
+	for _, count := range manifest.Tables {
+		rows += count
+	}
+	//nolint:forbidigo // the result is the command output.
+	fmt.Printf("loaded %d rows from the dump created at %s\n", rows, manifest.CreatedAt.Local().Format(time.DateTime))
+
+	return nil
+}
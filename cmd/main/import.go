@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+)
+
+// importCommand is the first argument which switches the binary into baseline import mode.
+const importCommand = "import"
+
+// importUsage explains the baseline import.
+const importUsage = `Usage: chrono-flow import -file <baseline.csv|baseline.json> [-tenant <id>] [-target <name>]
+The baseline becomes the stored product list, so the first check reports only the differences to it.
+Entries with an "at" time pre-populate the change and price history. The format follows the file
+extension, CSV files need a header with a model column and optional type, price, quantity, image_url,
+category, url and at columns. A baseline can only be imported before the first check.`
+
+// errStateExists is returned when importing a baseline for a target that was already checked.
+var errStateExists = errors.New("the target already has stored products")
+
+// controlImport imports a product baseline and returns the process exit code.
+func controlImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	file := flags.String("file", "", "CSV or JSON file with the baseline")
+	tenant := flags.String("tenant", "", "tenant the baseline belongs to, empty for the default one")
+	target := flags.String("target", models.MainTarget, "target the baseline belongs to")
+	if err := flags.Parse(args); err != nil || *file == "" || flags.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, importUsage)
+		return 2 //nolint:mnd // exit code 2 is the convention for invalid usage.
+	}
+
+	ctx := context.Background()
+	repo, err := openCommandRepository(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer repo.Close()
+
+	var skipped []models.SkippedProduct
+	baseline, err := readBaselineFile(*file)
+	if err == nil {
+		skipped, err = importBaseline(ctx, repo.ForTenant(*tenant).ForTarget(*target), baseline)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to import baseline: %v\n", err)
+		return 1
+	}
+	for _, product := range skipped {
+		fmt.Fprintf(os.Stderr, "skipped product %s: %s\n", product.Product.Model, product.Reason)
+	}
+
+	//nolint:forbidigo // the result is the command output.
+	fmt.Printf("imported %d products with %d history entries\n",
+		len(baseline.Products)-len(skipped), len(baseline.History))
+
+	return 0
+}
+
+// readBaselineFile reads the baseline from a file in the format of its extension.
+func readBaselineFile(path string) (*export.Baseline, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open baseline: %w", err)
+	}
+	defer file.Close()
+
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	entries, err := export.ReadBaseline(file, format)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the baseline error is descriptive on its own.
+	}
+
+	return export.NewBaseline(entries, time.Now()) //nolint:wrapcheck // the baseline error names the entry.
+}
+
+// importBaseline records the history of the baseline and stores its products as the state of the target.
+// The page hash is left empty, so the first check parses the page and reports the differences. It returns
+// the products that failed to be stored.
+func importBaseline(
+	ctx context.Context,
+	repo *sqlite.Repository,
+	baseline *export.Baseline,
+) ([]models.SkippedProduct, error) {
+	_, err := repo.GetState(ctx)
+	if err == nil {
+		return nil, errStateExists
+	}
+	if !errors.Is(err, repository.ErrStateNotFound) {
+		return nil, err //nolint:wrapcheck // the repository error names the operation.
+	}
+
+	for _, group := range baseline.History {
+		if err = repo.RecordChanges(ctx, group.At, group.Changes); err != nil {
+			return nil, err //nolint:wrapcheck // the repository error names the operation.
+		}
+	}
+
+	state := &models.State{Products: baseline.Products}
+	if err = repo.UpdateState(ctx, state); err != nil {
+		return nil, err //nolint:wrapcheck // the repository error names the operation.
+	}
+
+	return state.Skipped, nil
+}
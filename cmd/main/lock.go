@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/config"
+	"github.com/Houeta/chrono-flow/internal/instance"
+)
+
+// lockInstance takes the lock of every role the process runs on the database and returns the function
+// releasing them. Separate checker and bot processes share the database, so each role has its own lock,
+// and the all role takes both. With --force a lock held by another instance is only logged.
+func lockInstance(ctx context.Context, logger *slog.Logger, cfg *config.Config) (func(), error) {
+	if strings.Contains(cfg.StoragePath, ":memory:") {
+		return func() {}, nil
+	}
+
+	roles := []string{cfg.Role}
+	if cfg.Role == config.RoleAll {
+		roles = []string{config.RoleChecker, config.RoleBot}
+	}
+
+	var locks []*instance.Lock
+	release := func() {
+		for _, lock := range locks {
+			if err := lock.Release(); err != nil {
+				logger.WarnContext(ctx, "Failed to release the instance lock", "error", err)
+			}
+		}
+	}
+
+	for _, role := range roles {
+		lock, err := instance.Acquire(fmt.Sprintf("%s.%s.lock", cfg.StoragePath, role))
+		if errors.Is(err, instance.ErrLocked) && cfg.Force {
+			logger.WarnContext(ctx, "Another instance runs the role, starting anyway as forced",
+				"role", role, "error", err)
+			continue
+		}
+		if err != nil {
+			release()
+			if errors.Is(err, instance.ErrLocked) {
+				return nil, fmt.Errorf("another instance runs the %s role against %s (%w), stop it or start "+
+					"with --force if it's gone", role, cfg.StoragePath, err)
+			}
+			return nil, fmt.Errorf("failed to lock the database: %w", err)
+		}
+		locks = append(locks, lock)
+	}
+
+	return release, nil
+}
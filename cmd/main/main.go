@@ -2,19 +2,44 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
+	"github.com/Houeta/chrono-flow/internal/api"
+	"github.com/Houeta/chrono-flow/internal/auth"
+	"github.com/Houeta/chrono-flow/internal/blob"
 	"github.com/Houeta/chrono-flow/internal/bot"
+	"github.com/Houeta/chrono-flow/internal/breaker"
+	"github.com/Houeta/chrono-flow/internal/broker"
+	"github.com/Houeta/chrono-flow/internal/compute"
 	"github.com/Houeta/chrono-flow/internal/config"
+	"github.com/Houeta/chrono-flow/internal/daemon"
+	"github.com/Houeta/chrono-flow/internal/dedup"
+	"github.com/Houeta/chrono-flow/internal/events"
+	"github.com/Houeta/chrono-flow/internal/har"
+	"github.com/Houeta/chrono-flow/internal/httpclient"
+	"github.com/Houeta/chrono-flow/internal/logging"
+	"github.com/Houeta/chrono-flow/internal/metrics"
 	"github.com/Houeta/chrono-flow/internal/parser"
+	"github.com/Houeta/chrono-flow/internal/pipeline"
 	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+	"github.com/Houeta/chrono-flow/internal/rpc"
+	"github.com/Houeta/chrono-flow/internal/services/alerting"
 	"github.com/Houeta/chrono-flow/internal/services/checker"
+	"github.com/Houeta/chrono-flow/internal/services/enrich"
+	"github.com/Houeta/chrono-flow/internal/services/heartbeat"
+	"github.com/Houeta/chrono-flow/internal/services/maintenance"
+	"github.com/Houeta/chrono-flow/internal/services/uptime"
+	"github.com/kardianos/service"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -27,154 +52,642 @@ const (
 
 // main is the entry point of the application.
 func main() {
+	// "chrono-flow service <action>" manages the OS service instead of running the monitor.
+	if len(os.Args) > 1 && os.Args[1] == serviceCommand {
+		os.Exit(controlService(os.Args[2:]))
+	}
+
+	// "chrono-flow tenant <action>" manages the tenants stored in the database.
+	if len(os.Args) > 1 && os.Args[1] == tenantCommand {
+		os.Exit(controlTenant(os.Args[2:]))
+	}
+
+	// "chrono-flow token <action>" manages the API tokens stored in the database.
+	if len(os.Args) > 1 && os.Args[1] == tokenCommand {
+		os.Exit(controlToken(os.Args[2:]))
+	}
+
+	// "chrono-flow target <action>" manages the pages monitored by a tenant.
+	if len(os.Args) > 1 && os.Args[1] == targetCommand {
+		os.Exit(controlTarget(os.Args[2:]))
+	}
+
+	// "chrono-flow import -file <baseline>" imports the initial product list.
+	if len(os.Args) > 1 && os.Args[1] == importCommand {
+		os.Exit(controlImport(os.Args[2:]))
+	}
+
+	// "chrono-flow dump|load -file <archive>" moves the application state between databases and hosts.
+	if len(os.Args) > 1 && (os.Args[1] == dumpCommand || os.Args[1] == loadCommand) {
+		os.Exit(controlDump(os.Args[1], os.Args[2:]))
+	}
+
+	// "chrono-flow [--role=all|checker|bot] [--simulate=kinds] [--force]" runs the monitor, the flags override
+	// CF_ROLE, CF_SIMULATE and CF_FORCE.
+	if err := parseRunFlags(os.Args[1:]); err != nil {
+		os.Exit(2) //nolint:mnd // exit code 2 is the convention for invalid usage.
+	}
+
+	// When started by the service manager (Windows SCM, launchd), the service wrapper
+	// controls the lifetime of the application instead of OS signals.
+	if !service.Interactive() {
+		runService()
+		return
+	}
+
 	// Create a context that will be canceled when an interrupt signal is received.
 	// This allows for graceful shutdown.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	err := run(ctx)
+	stop()
+
+	if err != nil {
+		log.Fatalf("chrono-flow failed: %v", err)
+	}
+}
+
+// run runs the monitor until the context is canceled, it's started again whenever the configuration
+// directory changes, see watchConfigDir.
+func run(ctx context.Context) error {
+	for {
+		if err := runMonitor(ctx); !errors.Is(err, errConfigChanged) {
+			return err
+		}
+	}
+}
 
+// runMonitor initializes the dependencies and runs the scheduler loop until the context is canceled or
+// the configuration directory changed, errConfigChanged is returned then.
+func runMonitor(ctx context.Context) error {
 	// Load application configuration.
 	cfg, err := config.MustLoad()
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
+	// Restart with the configuration of the directory once it changed.
+	ctx, restart := context.WithCancelCause(ctx)
+	defer restart(nil)
+
 	// Set up the logger based on the environment.
-	logger := setupLogger(ctx, cfg.Env)
+	logs, err := setupLogger(ctx, cfg.Env, cfg.Log)
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+	logger := logs.Logger()
+	podLabels := cfg.Pod.Labels()
+	for _, key := range slices.Sorted(maps.Keys(podLabels)) {
+		logger = logger.With(key, podLabels[key])
+	}
 
 	logger.InfoContext(ctx, "Initializing dependencies...")
+	if cfg.ConfigDir != "" {
+		go watchConfigDir(ctx, logger, cfg.ConfigDir, restart)
+	}
 
-	// Create a new parser
-	parser := parser.NewParser(logger, cfg.URL)
+	// Create the application metrics, they are exposed only if an address is configured.
+	appMetrics := metrics.New(metrics.WithLabels(podLabels))
+
+	// Keep a second instance of the role from running against the same database.
+	unlock, err := lockInstance(ctx, logger, cfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	// Initialize the database connection.
-	repo, err := sqlite.NewRepository(ctx, logger, cfg.StoragePath)
+	repo, err := sqlite.NewRepository(
+		ctx, logger.With(logging.ComponentKey, logging.ComponentRepository), cfg.StoragePath,
+		sqlite.WithQueryTimeout(cfg.QueryTimeout), sqlite.WithQueryObserver(appMetrics.ObserveQuery),
+		sqlite.WithEncryptionKey(cfg.EncryptionKey),
+	)
 	if err != nil {
-		logger.ErrorContext(ctx, "repository initialization failed", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("repository initialization failed: %w", err)
 	}
+	defer repo.Close()
 
-	// Create a service which detects changes using repository and parser.
-	updateChecker := checker.NewChecker(logger, parser, repo)
+	// The main page is defined by the targets table, the configuration is imported on first boot.
+	if cfg, err = loadMainTarget(ctx, logger, cfg, repo); err != nil {
+		return err
+	}
 
-	// Create a telegram bot service
-	notifier, err := bot.NewBot(logger, cfg.Tg.Token, cfg.Tg.Timeout, repo, cfg.AllowedIDs)
+	// Create a logger which emits every detected change as a structured event.
+	eventsOutput, err := openEventsOutput(cfg.EventsLogFile)
 	if err != nil {
-		logger.ErrorContext(ctx, "bot initialization failed", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to open events log: %w", err)
+	}
+	defer eventsOutput.Close()
+
+	// Create a publisher which sends change and run events to the message broker.
+	publisher, err := newPublisher(ctx, logger, cfg.Broker)
+	if err != nil {
+		return fmt.Errorf("event publisher initialization failed: %w", err)
+	}
+	defer publisher.Close()
+
+	shared := sharedServices{
+		metrics:       appMetrics,
+		publisher:     publisher,
+		events:        events.NewLogger(eventsOutput),
+		authenticator: newAuthenticator(logger, cfg, repo),
+		dedup:         dedup.New(cfg.DedupWindow),
+		logLevels:     logs.Levels(),
+		hooks:         pipeline.New(),
+	}
+	// Annotate the changes of every target with the data of the enrichment webhook.
+	if cfg.EnrichURL != "" {
+		shared.hooks.OnPreNotify(enrich.New(logger, cfg.EnrichURL, cfg.EnrichTimeout).Enrich)
+	}
+
+	// Create the services of the default tenant configured from the environment.
+	scheduler, err := newApp(ctx, logger, cfg, repo, shared)
+	if err != nil {
+		return err
+	}
+
+	// Create a service which keeps the database compact, it runs for all tenants.
+	scheduler.maintainer = maintenance.NewMaintainer(
+		logger, repo, shared.metrics, maintenance.WithRetention(cfg.HistoryRetention),
+	)
+	scheduler.systemd = daemon.NewNotifier(logger)
+
+	// Connect to the object storage change exports are archived to.
+	if scheduler.archive, err = newArchive(ctx, cfg.S3); err != nil {
+		return fmt.Errorf("object storage initialization failed: %w", err)
 	}
-	defer repo.Close()
-	defer stop()
 
 	// Log that the application has started.
-	logger.InfoContext(
-		ctx,
-		"Starting main application loop. Press Ctrl+C to stop.",
-		"interval",
-		fmt.Sprintf("%dm", int(cfg.Interval.Minutes())),
+	logger.InfoContext(ctx, "Starting main application loop. Press Ctrl+C to stop.",
+		"interval", fmt.Sprintf("%dm", int(cfg.Interval.Minutes())))
+
+	// Start the scheduler loops of the targets added from the bot and the bot's command handlers.
+	stopRole, err := scheduler.startRole(cfg)
+	if err != nil {
+		return err
+	}
+	defer stopRole()
+
+	// Send the synthetic changes of CF_SIMULATE through the notifications of the running bot.
+	if cfg.Role != config.RoleChecker && len(cfg.Simulate) > 0 {
+		go scheduler.simulate(ctx, cfg.Simulate)
+	}
+
+	// Start the scheduler loops of the tenants stored in the database.
+	stopTenants, err := startTenants(ctx, logger, cfg, repo, shared)
+	if err != nil {
+		return err
+	}
+	defer stopTenants()
+
+	// The servers stream and serve the checks, so the bot process doesn't run them.
+	if cfg.Role != config.RoleBot {
+		if err = startServers(ctx, logger, cfg, shared, repo, scheduler.stream); err != nil {
+			return err
+		}
+	}
+	scheduler.run(ctx, cfg)
+
+	if errors.Is(context.Cause(ctx), errConfigChanged) {
+		return errConfigChanged
+	}
+
+	return nil
+}
+
+// sharedServices are created once and used by the schedulers of all tenants.
+type sharedServices struct {
+	metrics   *metrics.Metrics
+	publisher *broker.Publisher
+	events    *events.Logger
+	// authenticator checks the API tokens, nil leaves the APIs open.
+	authenticator *auth.Authenticator
+	// dedup suppresses notifications a chat already got from the bot of another tenant.
+	dedup *dedup.Deduplicator
+	// logLevels are changed at runtime with the bot and the API, nil disables it.
+	logLevels *logging.Levels
+	// hooks are the callbacks taking part in the checks of every target.
+	hooks *pipeline.Hooks
+}
+
+// newAuthenticator creates the authenticator of the API tokens, nil if authentication is disabled.
+func newAuthenticator(logger *slog.Logger, cfg *config.Config, repo sqlite.TokenRepository) *auth.Authenticator {
+	if !cfg.APIAuth {
+		return nil
+	}
+
+	return auth.NewAuthenticator(logger, repo)
+}
+
+// newApp creates the services checking the target of a tenant and notifying its subscribers.
+// The repository is scoped by the tenant, cfg is its configuration. The bot isn't started.
+func newApp(
+	ctx context.Context,
+	logger *slog.Logger,
+	cfg *config.Config,
+	repo *sqlite.Repository,
+	shared sharedServices,
+) (*app, error) {
+	// Create a service which records the availability of the target.
+	tracker := uptime.NewTracker(logger, repo, shared.metrics)
+
+	// Create the manager of the additional targets, they're added from the bot.
+	targets := newTargetManager(ctx, logger, cfg, repo, shared)
+
+	// Create a telegram bot service.
+	notifier, err := newNotifier(ctx, logger, cfg, repo, shared, targets)
+	if err != nil {
+		return nil, fmt.Errorf("bot initialization failed: %w", err)
+	}
+
+	// Create the dead-man's switch, it alerts admins when no check succeeds for a number of intervals.
+	monitor := heartbeat.NewMonitor(
+		logger, repo, notifier, cfg.URL, time.Duration(cfg.HeartbeatIntervals)*cfg.Interval,
+		heartbeat.WithPingURL(cfg.HeartbeatPingURL),
 	)
 
-	// Start the bot's command handlers in a goroutine.
-	go notifier.Start()
-	defer notifier.Stop()
+	scheduler := &app{
+		log:       logger,
+		checker:   newChecker(logger, cfg, newParser(ctx, logger, cfg, shared.hooks), repo, tracker, shared.hooks),
+		notifier:  notifier,
+		history:   repo,
+		artifacts: repo,
+		publisher: shared.publisher.ForTenant(repo.Tenant()),
+		breaker:   breaker.New(cfg.BreakerThreshold, cfg.Interval, cfg.BreakerMaxBackoff),
+		alerter:   newAlerter(logger, notifier, cfg),
+		heartbeat: monitor,
+		events:    shared.events,
 
-	// Run the first check immediately on startup without waiting for the first tick.
-	runCheck(ctx, logger, updateChecker, notifier)
+		windows:    cfg.MaintenanceWindows,
+		windowMode: cfg.MaintenanceWindowMode,
+		summary:    cfg.Tg.Channel.Summary,
+		targets:    targets,
+		// Wishlists hold products of the main page, the checks of other targets don't change them.
+		afterCheck: []checkHook{notifier.CheckWishlists},
+		outbox:     repo,
+		queued:     make(chan struct{}, 1),
 
-	// Run the main scheduler loop.
-	ticker := time.NewTicker(cfg.Interval)
-	defer ticker.Stop()
+		duplicateWindow: cfg.DuplicateRunWindow,
+		checkErrors:     repo,
 
-	for {
-		select {
-		case <-ticker.C:
-			// Triggered by the ticker for a scheduled check.
-			runCheck(ctx, logger, updateChecker, notifier)
-
-		case <-ctx.Done():
-			// Triggered by Ctrl+C or another shutdown signal.
-			logger.InfoContext(ctx, "Shutdown signal received. Stopping application...")
-			return // Exit the loop and allow deferred functions to run.
+		checkRequests: make(chan checkRequest),
+	}
+	targets.base = scheduler
+	scheduler.stream = rpc.NewServer(logger, repo, scheduler.CheckNow, rpc.WithAuthenticator(shared.authenticator))
+
+	return scheduler, nil
+}
+
+// startServers serves the metrics, the GraphQL API with the change feeds and the gRPC API in the
+// background, each only if its address is configured.
+func startServers(
+	ctx context.Context,
+	logger *slog.Logger,
+	cfg *config.Config,
+	shared sharedServices,
+	repo api.Repository,
+	stream *rpc.Server,
+) error {
+	if cfg.MetricsAddr != "" {
+		shared.metrics.RegisterSubscribers(repo)
+		go func() {
+			if serveErr := shared.metrics.Serve(ctx, logger, cfg.MetricsAddr); serveErr != nil {
+				logger.ErrorContext(ctx, "metrics server stopped", "error", serveErr)
+			}
+		}()
+	}
+
+	if cfg.APIAddr != "" {
+		server, err := api.NewServer(
+			logger, repo, api.WithAuthenticator(shared.authenticator), api.WithLogLevels(shared.logLevels),
+			api.WithFeedLink(cfg.URL), api.WithFilterGroups(cfg.FilterGroups), api.WithCheckInterval(cfg.Interval),
+		)
+		if err != nil {
+			return fmt.Errorf("API initialization failed: %w", err)
+		}
+
+		go func() {
+			if serveErr := server.Serve(ctx, cfg.APIAddr); serveErr != nil {
+				logger.ErrorContext(ctx, "API server stopped", "error", serveErr)
+			}
+		}()
+	}
+
+	if cfg.GRPCAddr != "" {
+		go func() {
+			if serveErr := stream.Serve(ctx, cfg.GRPCAddr); serveErr != nil {
+				logger.ErrorContext(ctx, "gRPC server stopped", "error", serveErr)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// newChecker creates the service which detects changes using repository and parser.
+func newChecker(
+	logger *slog.Logger,
+	cfg *config.Config,
+	htmlParser *parser.Parser,
+	repo *sqlite.Repository,
+	tracker *uptime.Tracker,
+	hooks *pipeline.Hooks,
+) *checker.Checker {
+	opts := []checker.Option{
+		checker.WithFuzzyThreshold(cfg.FuzzyThreshold),
+		checker.WithMaxInvalidRatio(cfg.MaxInvalidRatio),
+		checker.WithFetchObserver(tracker.Observe),
+		checker.WithParseObserver(tracker.ObserveParse),
+		checker.WithRunObserver(tracker.ObserveRun),
+		checker.WithFailureObserver(tracker.ObserveFailure),
+		checker.WithOutbox(),
+		checker.WithNormalizer(cfg.Normalizer),
+		checker.WithPageAssertions(cfg.PageAssertions),
+		checker.WithPageDiffs(repo, cfg.PageDiffRuns),
+		checker.WithHooks(hooks),
+	}
+	if cfg.BoundedMemory {
+		opts = append(opts, checker.WithBoundedMemory(repo, htmlParser))
+	}
+
+	return checker.NewChecker(logger, htmlParser, repo, opts...)
+}
+
+// newArchive connects to the object storage change exports are archived to, nil if it's not configured.
+func newArchive(ctx context.Context, cfg config.S3) (blob.Store, error) {
+	if cfg.Endpoint == "" {
+		return nil, nil //nolint:nilnil // A nil store disables archiving.
+	}
+
+	store, err := blob.NewS3(ctx, blob.S3Config{
+		Endpoint:  cfg.Endpoint,
+		Bucket:    cfg.Bucket,
+		AccessKey: cfg.AccessKey,
+		SecretKey: cfg.SecretKey,
+		Region:    cfg.Region,
+		UseSSL:    cfg.UseSSL,
+		Prefix:    cfg.Prefix,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to object storage: %w", err)
+	}
+
+	return store, nil
+}
+
+// newPublisher creates the event publisher, events are discarded if no broker is configured.
+func newPublisher(ctx context.Context, logger *slog.Logger, cfg config.Broker) (*broker.Publisher, error) {
+	transport := broker.Discard
+	if cfg.URL != "" {
+		nats, err := broker.NewNATS(ctx, cfg.URL, cfg.Stream, cfg.SubjectPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to the broker: %w", err)
+		}
+		transport = nats
+	}
+
+	publisher, err := broker.NewPublisher(logger, transport, cfg.SubjectPrefix, cfg.Format)
+	if err != nil {
+		_ = transport.Close()
+		return nil, fmt.Errorf("failed to create event publisher: %w", err)
+	}
+
+	return publisher, nil
+}
+
+// newAlerter creates the alerter of the failing checks of the target, the alerts name the class of the
+// last error.
+func newAlerter(logger *slog.Logger, notifier alerting.AdminNotifier, cfg *config.Config) *alerting.Alerter {
+	return alerting.NewAlerter(logger, notifier, cfg.URL, cfg.AlertThreshold,
+		alerting.WithClassifier(checker.ErrorClass))
+}
+
+// newParser creates the page parser configured with the table layout options.
+func newParser(ctx context.Context, logger *slog.Logger, cfg *config.Config, hooks *pipeline.Hooks) *parser.Parser {
+	logger = logger.With(logging.ComponentKey, logging.ComponentParser)
+	client := httpclient.New(cfg.HTTPTimeout, cfg.DNSCacheTTL, cfg.Network, cfg.ClientTLS)
+	if cfg.HARDir != "" {
+		logger.WarnContext(ctx, "Recording fetches for debugging", "dir", cfg.HARDir)
+		client.Transport = har.NewRecorder(logger, client.Transport, cfg.HARDir, cfg.HARMaxEntries)
+	}
+	// The headers are set outside of the recorder and the hooks, so the archive shows the requests as they
+	// were sent and the hooks can override the headers.
+	client.Transport = pipeline.Transport(client.Transport, hooks)
+	client.Transport = httpclient.WithHeaders(client.Transport, cfg.RequestHeaders)
+
+	opts := []parser.Option{
+		parser.WithClient(client),
+		parser.WithTables(parserTables(cfg.Tables)),
+		parser.WithColumnSynonyms(cfg.ColumnSynonyms),
+		parser.WithExtraFields(cfg.ExtraFields),
+		parser.WithComputedFields(compute.NewComputer(cfg.ComputedFields, cfg.ProductData)),
+		parser.WithColumnSelectors(cfg.ColumnSelectors),
+	}
+	if !cfg.FetchRequest.IsZero() {
+		opts = append(opts, parser.WithRequest(cfg.FetchRequest))
+	}
+	if len(cfg.FetchSteps) > 0 {
+		opts = append(opts, parser.WithSteps(cfg.FetchSteps))
+	}
+	if cfg.IframeSelector != "" {
+		opts = append(opts, parser.WithIframe(cfg.IframeSelector))
+	}
+	if cfg.StreamingParser || cfg.BoundedMemory {
+		opts = append(opts, parser.WithStreaming())
+	}
+
+	return parser.NewParser(logger, cfg.URL, opts...)
+}
+
+// notificationButtons converts the configured rows of link buttons into bot buttons.
+func notificationButtons(rows [][]config.Button) [][]bot.Button {
+	result := make([][]bot.Button, 0, len(rows))
+	for _, row := range rows {
+		buttons := make([]bot.Button, 0, len(row))
+		for _, button := range row {
+			buttons = append(buttons, bot.Button{Text: button.Text, URL: button.URL})
 		}
+		result = append(result, buttons)
 	}
+
+	return result
 }
 
-// runCheck encapsulates the logic for a single update check.
-func runCheck(ctx context.Context, log *slog.Logger, ch *checker.Checker, botNotifier *bot.Bot) {
-	log.InfoContext(ctx, "Running scheduled check for updates...")
+// parserTables converts the configured tables into parser tables.
+func parserTables(tables []config.Table) []parser.Table {
+	result := make([]parser.Table, 0, len(tables))
+	for _, table := range tables {
+		result = append(result, parser.Table{Name: table.Name, Selector: table.Selector})
+	}
+
+	return result
+}
 
-	// Perform the check.
-	changes, err := ch.CheckForUpdates(ctx)
+// newNotifier creates the Telegram bot and merges the chats allowed at runtime with the ones from configuration.
+func newNotifier(
+	ctx context.Context,
+	logger *slog.Logger,
+	cfg *config.Config,
+	repo bot.Repository,
+	shared sharedServices,
+	targets bot.TargetManager,
+) (*bot.Bot, error) {
+	templates, variant, err := notificationTemplates(cfg.Tg)
 	if err != nil {
-		log.ErrorContext(ctx, "failed to check for updates", "error", err)
-		return
+		return nil, err
+	}
+
+	opts := []bot.Option{
+		bot.WithAdminChats(cfg.AdminIDs),
+		bot.WithFilterGroups(cfg.FilterGroups),
+		bot.WithSummaryThreshold(cfg.SummaryThreshold),
+		bot.WithTarget(cfg.URL),
+		bot.WithTemplates(templates),
+		bot.WithDeduplicator(shared.dedup),
+		bot.WithTargetManager(targets),
+		bot.WithMetrics(shared.metrics),
+		bot.WithRateLimit(cfg.Tg.RateLimit),
+		bot.WithBroadcastWorkers(cfg.Tg.BroadcastWorkers, cfg.Tg.BroadcastQueue),
+		bot.WithAlertRules(cfg.AlertRules),
+		bot.WithChannel(bot.Channel{
+			ID:         cfg.Tg.Channel.ID,
+			Silent:     cfg.Tg.Channel.Silent,
+			PinSummary: cfg.Tg.Channel.PinSummary,
+		}),
+	}
+	if shared.logLevels != nil {
+		opts = append(opts, bot.WithLogLevels(shared.logLevels))
+	}
+	if variant != nil {
+		opts = append(opts, bot.WithTemplateVariant(variant))
+	}
+	if cfg.Tg.Feedback {
+		opts = append(opts, bot.WithFeedback())
+	}
+	if cfg.Tg.Photos {
+		opts = append(opts, bot.WithProductPhotos())
+	}
+	if cfg.Tg.MaxLines > 0 {
+		opts = append(opts, bot.WithRanking(cfg.Tg.MaxLines))
+	}
+	if len(cfg.Tg.Buttons) > 0 {
+		opts = append(opts, bot.WithButtons(notificationButtons(cfg.Tg.Buttons)))
+	}
+	if cfg.Tg.TemplatesFile != "" {
+		opts = append(opts, bot.WithTemplateFile(cfg.Tg.TemplatesFile, cfg.Tg.Templates))
+	}
+
+	notifier, err := bot.NewBot(
+		logger.With(logging.ComponentKey, logging.ComponentBot),
+		cfg.Tg.Token,
+		cfg.Tg.Timeout,
+		repo,
+		cfg.AllowedIDs,
+		opts...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bot: %w", err)
+	}
+
+	if err = notifier.LoadAllowedChats(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load allowed chats: %w", err)
 	}
 
-	// If changes are found, send a notification.
-	if changes.HasChanges() {
-		log.InfoContext(ctx, "Changes detected, sending notification")
-		if err = botNotifier.SendChangesNotification(ctx, changes); err != nil {
-			log.ErrorContext(ctx, "failed to send notification", "error", err)
+	// The bot may be promoted later, so a channel it can't post to yet only gets a warning.
+	if err = notifier.CheckChannel(); err != nil {
+		logger.WarnContext(ctx, "Telegram channel is unavailable", "channelID", cfg.Tg.Channel.ID, "error", err)
+	}
+
+	return notifier, nil
+}
+
+// notificationTemplates parses the notification templates, overridden by the ones of the template file, and
+// the ones of variant b, nil without variant templates. Variant b keeps the templates of the change kinds
+// it doesn't override.
+func notificationTemplates(cfg config.Telegram) (*bot.Templates, *bot.Templates, error) {
+	base := make(map[string]string, len(cfg.Templates))
+	maps.Copy(base, cfg.Templates)
+	if cfg.TemplatesFile != "" {
+		fileTemplates, err := bot.ReadTemplateFile(cfg.TemplatesFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid CF_TELEGRAM_TEMPLATES_FILE: %w", err)
 		}
-	} else {
-		log.InfoContext(ctx, "No new changes found")
+		maps.Copy(base, fileTemplates)
+	}
+
+	templates, err := bot.NewTemplates(base)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid notification templates: %w", err)
+	}
+	if len(cfg.VariantTemplates) == 0 {
+		return templates, nil, nil
 	}
+
+	sources := make(map[string]string, len(base)+len(cfg.VariantTemplates))
+	maps.Copy(sources, base)
+	maps.Copy(sources, cfg.VariantTemplates)
+	variant, err := bot.NewTemplates(sources)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid variant notification templates: %w", err)
+	}
+
+	return templates, variant, nil
+}
+
+// openEventsOutput opens the file change events are appended to, or stdout if no file is configured.
+func openEventsOutput(path string) (io.WriteCloser, error) {
+	const eventsFileMode = 0o600
+	if path == "" {
+		return nopCloser{os.Stdout}, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, eventsFileMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	return file, nil
 }
 
-// setupLogger initializes and returns a logger based on the environment provided.
-func setupLogger(ctx context.Context, env string) *slog.Logger {
-	var log *slog.Logger
+// nopCloser keeps stdout open when the events output is closed on shutdown.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
 
+// setupLogger creates the logger: the format and the default level follow the environment, the sinks and
+// the levels of the components come from the log configuration.
+func setupLogger(ctx context.Context, env string, cfg config.Log) (*logging.Logs, error) {
+	opts := logging.Options{
+		Levels:     cfg.Levels,
+		File:       cfg.File,
+		MaxSize:    cfg.MaxSize,
+		MaxBackups: cfg.MaxBackups,
+		MaxAge:     cfg.MaxAge,
+		Journald:   cfg.Journald,
+	}
 	switch env {
 	case envLocal:
-		log = slog.New(
-			slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-				Level:     slog.LevelDebug,
-				AddSource: true,
-				ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
-					return a
-				},
-			}),
-		)
+		opts.Level, opts.AddSource = slog.LevelDebug, true
 	case envDev:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-				Level:     slog.LevelInfo,
-				AddSource: false,
-				ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
-					return a
-				},
-			}),
-		)
+		opts.Level, opts.JSON = slog.LevelInfo, true
 	case envProd:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-				Level:     slog.LevelWarn,
-				AddSource: false,
-				ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
-					if a.Key == slog.TimeKey {
-						return slog.Attr{}
-					}
-					return a
-				},
-			}),
-		)
+		opts.Level, opts.JSON, opts.OmitTime = slog.LevelWarn, true, true
 	default:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-				Level:     slog.LevelError,
-				AddSource: false,
-				ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
-					if a.Key == slog.TimeKey {
-						return slog.Attr{}
-					}
-					return a
-				},
-			}),
-		)
+		opts.Level, opts.JSON, opts.OmitTime = slog.LevelError, true, true
+	}
+
+	logs, err := logging.New(os.Stdout, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up logging: %w", err)
+	}
 
-		log.ErrorContext(ctx,
+	if env != envLocal && env != envDev && env != envProd {
+		logs.Logger().ErrorContext(ctx,
 			"The env parameter was not specified	 or was invalid. Logging will be minimal, by default.",
 			slog.String("available_envs", "local, development, production"))
 	}
 
-	return log
+	return logs, nil
 }
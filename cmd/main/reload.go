@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/config"
+)
+
+// configDirPoll is how often the configuration directory is checked for changes. The kubelet updates a
+// mounted ConfigMap about a minute after it changed, so checking more often gains nothing.
+const configDirPoll = 30 * time.Second
+
+// errConfigChanged stops the monitor to start it again with the changed configuration directory.
+var errConfigChanged = errors.New("configuration directory changed")
+
+// watchConfigDir checks the configuration directory for changes until the context is canceled. A change
+// cancels the monitor with errConfigChanged, so it's started again with the new configuration, unless the
+// new configuration is invalid, which is logged and ignored until the directory changes again.
+func watchConfigDir(ctx context.Context, logger *slog.Logger, dir string, restart context.CancelCauseFunc) {
+	loaded, err := config.ReadDir(dir)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to read the configuration directory", "dir", dir, "error", err)
+	}
+
+	ticker := time.NewTicker(configDirPoll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		values, err := config.ReadDir(dir)
+		if err != nil {
+			logger.WarnContext(ctx, "Failed to read the configuration directory", "dir", dir, "error", err)
+			continue
+		}
+		if maps.Equal(values, loaded) {
+			continue
+		}
+		loaded = values
+
+		if _, err = config.MustLoad(); err != nil {
+			logger.ErrorContext(ctx, "The changed configuration is invalid, keeping the current one",
+				"dir", dir, "error", err)
+			continue
+		}
+		logger.WarnContext(ctx, "The configuration directory changed, restarting", "dir", dir)
+		restart(errConfigChanged)
+
+		return
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Houeta/chrono-flow/internal/config"
+)
+
+// parseRunFlags parses the flags of the monitor itself. The role, simulate and force flags override CF_ROLE,
+// CF_SIMULATE and CF_FORCE, so they can be set either way. Parse errors are printed by the flag set with the usage.
+func parseRunFlags(args []string) error {
+	flags := flag.NewFlagSet("chrono-flow", flag.ContinueOnError)
+	role := flags.String("role", "", "part of the application to run: all, checker or bot (default CF_ROLE or all)")
+	simulate := flags.String("simulate", "",
+		"send synthetic changes of the kinds, e.g. added,changed, or all, once the bot started (default CF_SIMULATE)")
+	force := flags.Bool("force", false,
+		"start even if another instance runs the role against the database, to recover a stale lock (default CF_FORCE)")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("invalid flags: %w", err)
+	}
+
+	if *role != "" {
+		if err := os.Setenv("CF_ROLE", *role); err != nil {
+			return fmt.Errorf("failed to set the role: %w", err)
+		}
+	}
+	if *simulate != "" {
+		if err := os.Setenv("CF_SIMULATE", *simulate); err != nil {
+			return fmt.Errorf("failed to set the simulated changes: %w", err)
+		}
+	}
+
+	if *force {
+		if err := os.Setenv("CF_FORCE", "true"); err != nil {
+			return fmt.Errorf("failed to set the force start: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// startRole starts the scheduler loops of the targets added from the bot and the bot's command handlers
+// in the background, as far as the role of the process runs them, and returns the function stopping them.
+// The bot process doesn't check the targets, the checker process only sends with the bot.
+func (a *app) startRole(cfg *config.Config) (func(), error) {
+	if cfg.Role != config.RoleBot {
+		if err := a.targets.start(); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Role != config.RoleChecker {
+		a.startBot()
+	}
+
+	return func() {
+		if cfg.Role != config.RoleChecker {
+			a.notifier.Stop()
+		}
+		a.targets.wait()
+	}, nil
+}
+
+// startBot registers the command menus of the bot and starts its command handlers in the background. The
+// menus only help users find the commands, so the bot starts without them if Telegram refuses them.
+func (a *app) startBot() {
+	if err := a.notifier.RegisterCommands(); err != nil {
+		a.log.Warn("failed to register the command menus", "error", err)
+	}
+	go a.notifier.Start()
+}
+
+// simulate sends synthetic changes of the kinds to the subscribers through the notifications of the bot,
+// see bot.Bot.Simulate.
+func (a *app) simulate(ctx context.Context, kinds []string) {
+	changes, err := a.notifier.Simulate(ctx, kinds)
+	if err != nil {
+		a.log.ErrorContext(ctx, "failed to simulate changes", "error", err)
+		return
+	}
+	a.log.InfoContext(ctx, "Simulated changes sent", "changes", changes.Count())
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/blob"
+	"github.com/Houeta/chrono-flow/internal/bot"
+	"github.com/Houeta/chrono-flow/internal/breaker"
+	"github.com/Houeta/chrono-flow/internal/broker"
+	"github.com/Houeta/chrono-flow/internal/config"
+	"github.com/Houeta/chrono-flow/internal/daemon"
+	"github.com/Houeta/chrono-flow/internal/events"
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+	"github.com/Houeta/chrono-flow/internal/rpc"
+	"github.com/Houeta/chrono-flow/internal/schedule"
+	"github.com/Houeta/chrono-flow/internal/services/alerting"
+	"github.com/Houeta/chrono-flow/internal/services/checker"
+	"github.com/Houeta/chrono-flow/internal/services/heartbeat"
+	"github.com/Houeta/chrono-flow/internal/services/maintenance"
+)
+
+// app holds the services driven by the main scheduler loop.
+type app struct {
+	log        *slog.Logger
+	checker    *checker.Checker
+	notifier   *bot.Bot
+	maintainer *maintenance.Maintainer
+	history    sqlite.HistoryRepository
+	stream     *rpc.Server
+	publisher  *broker.Publisher
+	// archive keeps a CSV export of every change set, nil disables archiving.
+	archive blob.Store
+	// artifacts keeps the full diff of every change set for the bot and the API, in the tenant's scope.
+	artifacts sqlite.RunArtifactRepository
+	breaker   *breaker.Breaker
+	alerter   *alerting.Alerter
+	// checkErrors keeps the last failed check of the target, for /status, /targets and the API.
+	checkErrors sqlite.CheckErrorRepository
+	// heartbeat is the dead-man's switch of the target, nil for the additional targets.
+	heartbeat *heartbeat.Monitor
+	// windows are the maintenance windows of the target, windowMode is how scheduled checks are
+	// handled during them: config.WindowModeSkip or config.WindowModeIgnore.
+	windows    []schedule.Window
+	windowMode string
+	events     *events.Logger
+	systemd    *daemon.Notifier
+	// targets runs the loops of the tenant's additional targets.
+	targets *targetManager
+	// afterCheck are evaluated after every check that detected changes, once they were notified.
+	afterCheck []checkHook
+	// outbox holds the changes the checks queued with the state until they are delivered, see
+	// deliverPending. queued wakes the delivery loop once a check queued changes, so they don't wait
+	// for the next poll of the outbox. The targets share both with the app of the tenant's main page.
+	outbox sqlite.OutboxRepository
+	queued chan struct{}
+	// duplicateWindow is how long a change set identical to one delivered before is suppressed, 0 disables
+	// the suppression.
+	duplicateWindow time.Duration
+	// checkRequests carries checks requested out of schedule, they run in the scheduler loop
+	// so they never overlap with scheduled ones.
+	checkRequests chan checkRequest
+
+	// summary is when the summary of the last 24 hours is posted to the channel, nil disables it.
+	// lastSummary is the minute it was last posted in.
+	summary     *schedule.Cron
+	lastSummary time.Time
+}
+
+// checkHook evaluates the stored state after a check, e.g. the wishlists of the chats.
+type checkHook func(ctx context.Context) error
+
+// checkRequest asks the scheduler loop to run a check and send its result back.
+type checkRequest struct {
+	result chan<- checkResult
+}
+
+// checkResult is the outcome of a requested check.
+type checkResult struct {
+	changes *models.Changes
+	err     error
+}
+
+// run executes the scheduler loop until the context is canceled.
+func (a *app) run(ctx context.Context, cfg *config.Config) {
+	a.systemd.Ready(ctx)
+	defer a.systemd.Stopping(ctx)
+
+	// The changes queued by the checks are delivered apart from the loop, by the bot process when the
+	// roles run as separate processes.
+	if cfg.Role != config.RoleChecker {
+		defer a.startDelivery(ctx, cfg.OutboxPollInterval)()
+	}
+
+	// The bot process doesn't check. A nil channel never fires, so the checks are simply skipped then.
+	var checkTick <-chan time.Time
+	if cfg.Role != config.RoleBot {
+		// The dead-man's switch runs apart from the loop, so it notices the loop hanging too.
+		go a.heartbeat.Run(ctx, cfg.Interval)
+
+		// Run the first check immediately on startup without waiting for the first tick.
+		a.runGuardedCheck(ctx)
+		checkTicker := time.NewTicker(cfg.Interval)
+		defer checkTicker.Stop()
+		checkTick = checkTicker.C
+	}
+
+	// Maintenance is left to the checker when the roles run as separate processes.
+	var maintenanceTick <-chan time.Time
+	if cfg.MaintenanceInterval > 0 && cfg.Role != config.RoleBot {
+		maintenanceTicker := time.NewTicker(cfg.MaintenanceInterval)
+		defer maintenanceTicker.Stop()
+		maintenanceTick = maintenanceTicker.C
+	}
+
+	// The bot records the chats it can't reach, so it prunes them, on the schedule of the maintenance.
+	var pruneTick <-chan time.Time
+	if cfg.MaintenanceInterval > 0 && cfg.PruneUnreachableAfter > 0 && cfg.Role != config.RoleChecker {
+		pruneTicker := time.NewTicker(cfg.MaintenanceInterval)
+		defer pruneTicker.Stop()
+		pruneTick = pruneTicker.C
+	}
+
+	// The summary schedule is checked every minute, as cron expressions have a minute resolution.
+	var summaryTick <-chan time.Time
+	if a.summary != nil && cfg.Role != config.RoleChecker {
+		summaryTicker := time.NewTicker(time.Minute)
+		defer summaryTicker.Stop()
+		summaryTick = summaryTicker.C
+	}
+
+	// Keepalives are sent from the loop itself, so systemd restarts the service if the loop hangs.
+	var watchdogTick <-chan time.Time
+	if interval := a.systemd.WatchdogInterval(ctx); interval > 0 {
+		watchdogTicker := time.NewTicker(interval)
+		defer watchdogTicker.Stop()
+		watchdogTick = watchdogTicker.C
+	}
+
+	for {
+		select {
+		case <-checkTick:
+			// Triggered by the ticker for a scheduled check.
+			a.runGuardedCheck(ctx)
+
+		case req := <-a.checkRequests:
+			// Triggered by a check requested out of schedule, it ignores the circuit breaker.
+			changes, err := a.runTrackedCheck(ctx)
+			req.result <- checkResult{changes: changes, err: err}
+
+		case <-maintenanceTick:
+			// Triggered by the maintenance ticker to compact the database.
+			if _, err := a.maintainer.Run(ctx); err != nil {
+				a.log.ErrorContext(ctx, "database maintenance failed", "error", err)
+			}
+
+		case <-pruneTick:
+			// Triggered with the maintenance to unsubscribe the chats the bot can't reach anymore.
+			if _, err := a.notifier.PruneSubscribers(ctx, cfg.PruneUnreachableAfter); err != nil {
+				a.log.ErrorContext(ctx, "subscriber pruning failed", "error", err)
+			}
+
+		case now := <-summaryTick:
+			// Triggered every minute to post the channel summary when it's due.
+			a.postSummary(ctx, now)
+
+		case <-watchdogTick:
+			// Triggered by the watchdog ticker to tell systemd the loop is alive.
+			a.systemd.Watchdog(ctx)
+
+		case <-ctx.Done():
+			// Triggered by Ctrl+C or another shutdown signal.
+			a.log.InfoContext(ctx, "Shutdown signal received. Stopping application...")
+			return // Exit the loop and allow deferred functions to run.
+		}
+	}
+}
+
+// runTenant executes the scheduler loop of a tenant until the context is canceled. Maintenance and
+// systemd notifications are left to the loop of the default tenant.
+func (a *app) runTenant(ctx context.Context, interval time.Duration) {
+	go a.heartbeat.Run(ctx, interval)
+	a.runGuardedCheck(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			a.runGuardedCheck(ctx)
+
+		case req := <-a.checkRequests:
+			changes, err := a.runTrackedCheck(ctx)
+			req.result <- checkResult{changes: changes, err: err}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// runGuardedCheck runs a check unless the target is in a maintenance window or backed off by
+// the circuit breaker, and feeds the result back into the breaker.
+func (a *app) runGuardedCheck(ctx context.Context) {
+	if until, ok := schedule.Active(a.windows, time.Now()); ok {
+		a.runMaintenanceCheck(ctx, until)
+		return
+	}
+
+	if !a.breaker.Allow(time.Now()) {
+		a.log.DebugContext(ctx, "Target is backed off, skipping check", "retry_at", a.breaker.RetryAt())
+		return
+	}
+
+	_, _ = a.runTrackedCheck(ctx)
+}
+
+// runMaintenanceCheck handles a scheduled check during a maintenance window of the target. The
+// page is only probed for availability in the ignore mode. Neither the circuit breaker nor the
+// alerter are fed, since failures are expected during maintenance. The heartbeat goes on, as the
+// checks are skipped on purpose.
+func (a *app) runMaintenanceCheck(ctx context.Context, until time.Time) {
+	a.heartbeat.Success(ctx)
+
+	if a.windowMode != config.WindowModeIgnore {
+		a.log.InfoContext(ctx, "Target is in a maintenance window, skipping check", "until", until)
+		return
+	}
+
+	a.log.InfoContext(ctx, "Target is in a maintenance window, ignoring its content", "until", until)
+	if err := a.checker.Probe(ctx); err != nil {
+		a.log.InfoContext(ctx, "Target is unavailable during maintenance", "error", err)
+	}
+}
+
+// postSummary posts the changes of the last 24 hours to the channel if the summary is due at the
+// given time and wasn't posted in the same minute yet.
+func (a *app) postSummary(ctx context.Context, now time.Time) {
+	minute := now.Truncate(time.Minute)
+	if !a.summary.Matches(now) || minute.Equal(a.lastSummary) {
+		return
+	}
+	a.lastSummary = minute
+
+	records, err := a.history.GetChanges(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		a.log.ErrorContext(ctx, "failed to get changes for the channel summary", "error", err)
+		return
+	}
+
+	err = a.notifier.PostChannelSummary(ctx, records)
+	if errors.Is(err, bot.ErrNotifyThrottled) {
+		a.log.WarnContext(ctx, "Channel summary throttled by Telegram, skipping it", "error", err)
+	} else if err != nil {
+		a.log.ErrorContext(ctx, "failed to post channel summary", "error", err)
+	}
+}
+
+// runTrackedCheck runs a check and feeds the result into the circuit breaker, the alerter and the last
+// error of the target.
+func (a *app) runTrackedCheck(ctx context.Context) (*models.Changes, error) {
+	now := time.Now()
+	changes, err := a.runCheck(ctx)
+	if err != nil {
+		a.trackFailure(ctx, now, err)
+		return nil, err
+	}
+
+	if a.breaker.Success() {
+		a.log.InfoContext(ctx, "Target recovered, circuit breaker closed")
+	}
+	a.alerter.Success(ctx)
+	a.heartbeat.Success(ctx)
+	if err = a.checkErrors.ResetCheckErrors(ctx); err != nil {
+		a.log.ErrorContext(ctx, "failed to reset the check errors", "error", err)
+	}
+
+	return changes, nil
+}
+
+// trackFailure feeds a failed check into the circuit breaker, the alerter and the last error of the target
+// by the class of its error.
+func (a *app) trackFailure(ctx context.Context, now time.Time, err error) {
+	class := checker.ErrorClass(err)
+	switch class {
+	case checker.ClassVeto, checker.ClassAssertion:
+		// The check was canceled on purpose or its page can't be trusted, like the ones skipped during
+		// maintenance.
+		a.heartbeat.Success(ctx)
+		return
+	case checker.ClassState:
+		// The page was fetched, it's the database that failed, so the target isn't backed off from.
+		a.alerter.Failure(ctx, err)
+	default:
+		if a.breaker.Failure(now) {
+			a.log.WarnContext(ctx, "Target keeps failing, backing off",
+				"failures", a.breaker.Failures(), "retry_at", a.breaker.RetryAt())
+		}
+		// Admins are alerted once per outage, not on every failed probe.
+		a.alerter.Failure(ctx, err)
+	}
+
+	if recordErr := a.checkErrors.RecordCheckError(ctx, class, err.Error(), now); recordErr != nil {
+		a.log.ErrorContext(ctx, "failed to record the check error", "error", recordErr)
+	}
+}
+
+// CheckNow runs a check in the scheduler loop out of schedule and returns the detected changes.
+func (a *app) CheckNow(ctx context.Context) (*models.Changes, error) {
+	result := make(chan checkResult, 1)
+	select {
+	case a.checkRequests <- checkRequest{result: result}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("check was not started: %w", ctx.Err())
+	}
+
+	select {
+	case res := <-result:
+		return res.changes, res.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("check did not finish: %w", ctx.Err())
+	}
+}
+
+// runCheck encapsulates the logic for a single update check.
+// It returns an error only if the check itself failed.
+func (a *app) runCheck(ctx context.Context) (*models.Changes, error) {
+	runID := events.NewRunID()
+	ctx = events.WithRunID(ctx, runID)
+	log := a.log.With("run_id", runID)
+	log.InfoContext(ctx, "Running scheduled check for updates...")
+	a.publisher.PublishRunStarted(ctx, runID, time.Now())
+
+	// Perform the check.
+	changes, err := a.checker.CheckForUpdates(ctx)
+	if err != nil {
+		a.publisher.PublishRunFinished(ctx, runID, time.Now(), 0, err)
+		switch class := checker.ErrorClass(err); class {
+		case checker.ClassVeto:
+			log.InfoContext(ctx, "Check canceled by a pipeline hook", "error", err)
+			return nil, fmt.Errorf("check canceled: %w", err)
+		case checker.ClassAssertion:
+			log.WarnContext(ctx, "Check skipped, the page failed its assertions", "error", err)
+			return nil, fmt.Errorf("check skipped: %w", err)
+		default:
+			log.ErrorContext(ctx, "failed to check for updates", "class", class, "error", err)
+			return nil, fmt.Errorf("failed to check for updates: %w", err)
+		}
+	}
+	detectedAt := time.Now()
+	defer a.publisher.PublishRunFinished(ctx, runID, detectedAt, changes.Count(), nil)
+
+	// If changes are found, they were queued for delivery with the state.
+	if changes.HasChanges() {
+		log.InfoContext(ctx, "Changes detected, queued for delivery")
+		// The full diff is stored before the delivery is woken up, so the button of the notifications finds it.
+		if err = a.recordDiff(ctx, runID, detectedAt, changes); err != nil {
+			log.ErrorContext(ctx, "failed to record the full diff", "error", err)
+		}
+		a.wakeDelivery()
+		a.events.LogChanges(ctx, runID, changes)
+		if err = a.history.RecordChanges(ctx, detectedAt, changes); err != nil {
+			log.ErrorContext(ctx, "failed to record change history", "error", err)
+		}
+		a.stream.Publish(ctx, runID, detectedAt, changes)
+		a.publisher.PublishChanges(ctx, runID, detectedAt, changes)
+		if a.archive != nil {
+			if err = export.Archive(ctx, a.archive, runID, detectedAt, changes); err != nil {
+				log.ErrorContext(ctx, "failed to archive changes", "error", err)
+			}
+		}
+	} else {
+		log.InfoContext(ctx, "No new changes found")
+	}
+
+	return changes, nil
+}
+
+// recordDiff stores the changes rendered in every diff format, so the full diff can be downloaded however
+// long the notification had to be cut.
+func (a *app) recordDiff(ctx context.Context, runID string, detectedAt time.Time, changes *models.Changes) error {
+	title := fmt.Sprintf("Changes detected at %s (run %s)", detectedAt.Format(time.DateTime), runID)
+	artifacts := make([]models.DiffArtifact, 0, len(export.DiffFormats()))
+	for _, format := range export.DiffFormats() {
+		content, err := export.RenderDiff(format, title, changes)
+		if err != nil {
+			return fmt.Errorf("failed to render the %s diff: %w", format, err)
+		}
+		artifacts = append(artifacts, models.DiffArtifact{
+			RunID: runID, Format: format, CreatedAt: detectedAt, Content: content,
+		})
+	}
+
+	if err := a.artifacts.RecordDiffArtifacts(ctx, artifacts); err != nil {
+		return fmt.Errorf("failed to store the diff: %w", err)
+	}
+
+	return nil
+}
+
+// notify sends the changes to the subscribers and evaluates the hooks once they were notified.
+func (a *app) notify(ctx context.Context, log *slog.Logger, changes *models.Changes, hooks []checkHook) {
+	if err := a.notifier.SendChangesNotification(ctx, changes); err != nil {
+		log.ErrorContext(ctx, "failed to send notification", "error", err)
+	}
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			log.ErrorContext(ctx, "post-check evaluation failed", "error", err)
+		}
+	}
+}
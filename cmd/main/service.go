@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/kardianos/service"
+)
+
+// serviceCommand is the first argument which switches the binary into service management mode.
+const serviceCommand = "service"
+
+// serviceConfig describes chrono-flow for the OS service manager.
+func serviceConfig() *service.Config {
+	return &service.Config{
+		Name:        "chrono-flow",
+		DisplayName: "chrono-flow",
+		Description: "Monitors a product table and sends changes to Telegram.",
+		Arguments:   []string{},
+		EnvVars:     configEnv(),
+	}
+}
+
+// configEnv returns the CF_* variables of the current environment. They are stored in the
+// service definition on install, since services don't inherit the environment of the shell.
+func configEnv() map[string]string {
+	env := make(map[string]string)
+	for _, entry := range os.Environ() {
+		if key, value, found := strings.Cut(entry, "="); found && strings.HasPrefix(key, "CF_") {
+			env[key] = value
+		}
+	}
+
+	return env
+}
+
+// program adapts the application to the service.Interface lifecycle.
+type program struct {
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+	logger service.Logger
+}
+
+// Start launches the application in the background, as the service manager expects Start to return quickly.
+func (p *program) Start(_ service.Service) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	p.done.Add(1)
+	go func() {
+		defer p.done.Done()
+		if err := run(ctx); err != nil {
+			_ = p.logger.Error(fmt.Sprintf("chrono-flow failed: %v", err))
+			os.Exit(1)
+		}
+	}()
+
+	return nil
+}
+
+// Stop cancels the application and waits for a graceful shutdown.
+func (p *program) Stop(_ service.Service) error {
+	p.cancel()
+	p.done.Wait()
+
+	return nil
+}
+
+// runService runs the application under the OS service manager.
+func runService() {
+	prg := &program{}
+	svc, err := service.New(prg, serviceConfig())
+	if err != nil {
+		log.Fatalf("failed to create service: %v", err)
+	}
+
+	if prg.logger, err = svc.Logger(nil); err != nil {
+		log.Fatalf("failed to open service logger: %v", err)
+	}
+
+	if err = svc.Run(); err != nil {
+		_ = prg.logger.Error(fmt.Sprintf("service failed: %v", err))
+		os.Exit(1)
+	}
+}
+
+// controlService runs a service management action and returns the process exit code.
+func controlService(args []string) int {
+	const usage = "Usage: chrono-flow service <install|uninstall|start|stop|restart|status>"
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2 //nolint:mnd // exit code 2 is the convention for invalid usage.
+	}
+
+	svc, err := service.New(&program{}, serviceConfig())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create service: %v\n", err)
+		return 1
+	}
+
+	action := args[0]
+	if action == "status" {
+		status, statusErr := svc.Status()
+		if statusErr != nil {
+			fmt.Fprintf(os.Stderr, "failed to get service status: %v\n", statusErr)
+			return 1
+		}
+		fmt.Println(serviceStatusName(status)) //nolint:forbidigo // the status is the command output.
+		return 0
+	}
+
+	if err = service.Control(svc, action); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to %s service: %v\n%s\n", action, err, usage)
+		return 1
+	}
+	fmt.Printf("service %s: ok\n", action) //nolint:forbidigo // the result is the command output.
+
+	return 0
+}
+
+// serviceStatusName returns a human-readable service status.
+func serviceStatusName(status service.Status) string {
+	switch status {
+	case service.StatusRunning:
+		return "running"
+	case service.StatusStopped:
+		return "stopped"
+	case service.StatusUnknown:
+		return "unknown"
+	default:
+		return "unknown"
+	}
+}
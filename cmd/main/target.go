@@ -0,0 +1,473 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/breaker"
+	"github.com/Houeta/chrono-flow/internal/config"
+	"github.com/Houeta/chrono-flow/internal/httpclient"
+	"github.com/Houeta/chrono-flow/internal/metrics"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+	"github.com/Houeta/chrono-flow/internal/services/uptime"
+)
+
+// targetManager runs the scheduler loops of the additional targets of a tenant. Target loops notify
+// through the tenant's bot, their products and changes are kept apart in the repository scoped by the target.
+type targetManager struct {
+	// ctx bounds the lifetime of the target loops, which are also scheduled from bot callbacks.
+	ctx    context.Context
+	log    *slog.Logger
+	cfg    *config.Config
+	repo   *sqlite.Repository
+	shared sharedServices
+	// base is the app of the tenant's main page, it's set once the app is created.
+	base *app
+
+	// mu guards cancels, which stop the loops of the scheduled targets by name.
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// newTargetManager creates the manager of the tenant's targets, their loops stop when ctx is canceled.
+func newTargetManager(
+	ctx context.Context,
+	logger *slog.Logger,
+	cfg *config.Config,
+	repo *sqlite.Repository,
+	shared sharedServices,
+) *targetManager {
+	return &targetManager{
+		ctx:     ctx,
+		log:     logger,
+		cfg:     cfg,
+		repo:    repo,
+		shared:  shared,
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// Probe fetches the page of the target and returns the products parsed from it.
+func (m *targetManager) Probe(ctx context.Context, target models.Target) ([]models.Product, error) {
+	products, err := newParser(ctx, m.log, m.cfg.ForTarget(target), m.shared.hooks).ParseProducts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse target %s: %w", target.Name, err)
+	}
+
+	return products, nil
+}
+
+// Schedule starts the scheduler loops of a stored target and of its regions, replacing the loops they
+// already have. The main target runs in the loop of the app, only its regions are scheduled. The bot
+// process doesn't check, the checker process starts the loops once it's restarted.
+func (m *targetManager) Schedule(target models.Target) {
+	if m.cfg.Role == config.RoleBot {
+		m.log.InfoContext(m.ctx, "Target is checked by the checker process after its restart", "target", target.Name)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unschedule(target.Name)
+	if target.Name != models.MainTarget {
+		m.schedule(target)
+	}
+	for _, region := range target.Regions {
+		m.schedule(target.ForRegion(region))
+	}
+}
+
+// schedule starts the scheduler loop of a target, m.mu must be held.
+func (m *targetManager) schedule(target models.Target) {
+	targetApp := m.newTargetApp(target)
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.cancels[target.Name] = cancel
+	m.log.InfoContext(ctx, "Target scheduled", "target", target.Name, "interval", target.Interval)
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		targetApp.runTenant(ctx, target.Interval)
+	}()
+}
+
+// Unschedule stops the scheduler loops of the target with the name and of its regions.
+func (m *targetManager) Unschedule(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.unschedule(name) {
+		m.log.InfoContext(m.ctx, "Target unscheduled", "target", name)
+	}
+}
+
+// unschedule stops the loops of the target with the name and of its regions, m.mu must be held. It
+// reports whether any were running.
+func (m *targetManager) unschedule(name string) bool {
+	stopped := false
+	for scheduled, cancel := range m.cancels {
+		if scheduled == name || strings.HasPrefix(scheduled, name+models.RegionSeparator) {
+			cancel()
+			delete(m.cancels, scheduled)
+			stopped = true
+		}
+	}
+
+	return stopped
+}
+
+// start schedules the enabled additional targets stored for the tenant and the regions of all of them,
+// the main target itself runs in the loop of the app.
+func (m *targetManager) start() error {
+	targets, err := m.repo.GetTargets(m.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load targets: %w", err)
+	}
+
+	for _, target := range targets {
+		if !target.Disabled {
+			m.Schedule(target)
+		}
+	}
+
+	return nil
+}
+
+// wait blocks until the target loops stop after the context is canceled.
+func (m *targetManager) wait() {
+	m.wg.Wait()
+}
+
+// loadMainTarget returns the configuration of the tenant's main page stored in the targets table. On first
+// boot the target is imported from cfg, later the stored definition is used, so it can be edited at runtime.
+func loadMainTarget(ctx context.Context, logger *slog.Logger, cfg *config.Config, repo *sqlite.Repository) (
+	*config.Config, error,
+) {
+	target, err := repo.GetTarget(ctx, models.MainTarget)
+	if errors.Is(err, repository.ErrTargetNotFound) {
+		imported := cfg.MainTarget()
+		imported.CreatedAt = time.Now()
+		if err = repo.CreateTarget(ctx, imported); err != nil {
+			return nil, fmt.Errorf("failed to import the main target: %w", err)
+		}
+		logger.InfoContext(ctx, "Main target imported from the configuration", "url", imported.URL)
+		target = &imported
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the main target: %w", err)
+	}
+
+	return cfg.ForTarget(*target), nil
+}
+
+// newTargetApp creates the services checking a target. The bot, the streams, the archive and the diff
+// artifacts are shared with the tenant's main page, metrics are kept for the main page only.
+func (m *targetManager) newTargetApp(target models.Target) *app {
+	logger := m.log.With("target", target.Name)
+	cfg := m.cfg.ForTarget(target)
+	repo := m.repo.ForTarget(target.Name)
+	tracker := uptime.NewTracker(logger, repo, metrics.New())
+	htmlParser := newParser(m.ctx, logger, cfg, m.shared.hooks)
+
+	return &app{
+		log:       logger,
+		checker:   newChecker(logger, cfg, htmlParser, repo, tracker, m.shared.hooks),
+		notifier:  m.base.notifier,
+		history:   repo,
+		stream:    m.base.stream,
+		publisher: m.base.publisher,
+		archive:   m.base.archive,
+		artifacts: m.base.artifacts,
+		breaker:   breaker.New(cfg.BreakerThreshold, cfg.Interval, cfg.BreakerMaxBackoff),
+		alerter:   newAlerter(logger, m.base.notifier, cfg),
+		events:    m.shared.events,
+		queued:    m.base.queued,
+
+		checkErrors:   repo,
+		checkRequests: make(chan checkRequest),
+	}
+}
+
+// targetCommand is the first argument which switches the binary into target management mode.
+const targetCommand = "target"
+
+// targetUsage explains the target management actions.
+const targetUsage = `Usage: chrono-flow target <action>
+  add -url <url> [-tables <tables>] [-columns <selectors>] [-synonyms <synonyms>] [-iframe <selector>]
+      [-request <request>] [-steps <steps>] [-assert <assertions>] [-headers <headers>] [-regions <regions>]
+      [-ip-version <version>] [-hosts <hosts>] [-resolver <address>] [-cert <certificate> -key <key>]
+      [-interval <duration>] [-tenant <id>] <name>
+  edit [-url <url>] [-tables <tables>] [-columns <selectors>] [-synonyms <synonyms>] [-iframe <selector>]
+      [-request <request>] [-steps <steps>] [-assert <assertions>] [-headers <headers>] [-regions <regions>]
+      [-ip-version <version>] [-hosts <hosts>] [-resolver <address>] [-cert <certificate> -key <key>]
+      [-interval <duration>] [-tenant <id>] <name>
+  list [-tenant <id>]
+  remove [-tenant <id>] <name>
+Options use the formats of CF_TABLES, CF_COLUMN_SELECTORS, CF_COLUMN_SYNONYMS, CF_FETCH_REQUEST,
+CF_FETCH_STEPS, CF_PAGE_ASSERTIONS, CF_REQUEST_HEADERS, CF_REGIONS, CF_IP_VERSION, CF_DNS_HOSTS,
+CF_DNS_RESOLVER, CF_TLS_CLIENT_CERT and CF_TLS_CLIENT_KEY, edit changes only the given ones. Targets are
+loaded on startup, restart chrono-flow to apply the changes.`
+
+// controlTarget runs a target management action and returns the process exit code.
+func controlTarget(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, targetUsage)
+		return 2 //nolint:mnd // exit code 2 is the convention for invalid usage.
+	}
+
+	ctx := context.Background()
+	repo, err := openCommandRepository(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer repo.Close()
+
+	action := args[0]
+	switch action {
+	case "add", "edit":
+		err = saveTarget(ctx, repo, action, args[1:])
+	case "list":
+		err = listTargets(ctx, repo, args[1:], os.Stdout)
+	case "remove":
+		err = removeTarget(ctx, repo, args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, targetUsage)
+		return 2 //nolint:mnd // exit code 2 is the convention for invalid usage.
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to %s target: %v\n", action, err)
+		return 1
+	}
+	if action != "list" {
+		fmt.Printf("target %s: ok\n", action) //nolint:forbidigo // the result is the command output.
+	}
+
+	return 0
+}
+
+// targetFlags are the options of a target given on the command line.
+type targetFlags struct {
+	url, tables, columns, synonyms, iframe, request, steps, assertions string
+	headers, regions, ipVersion, hosts, resolver, cert, key            string
+	interval                                                           time.Duration
+	// set holds the names of the options given explicitly.
+	set map[string]bool
+}
+
+// saveTarget parses the arguments of the add and edit actions and stores the target.
+func saveTarget(ctx context.Context, repo *sqlite.Repository, action string, args []string) error {
+	var options targetFlags
+	flags := flag.NewFlagSet("target "+action, flag.ContinueOnError)
+	tenant := flags.String("tenant", "", "tenant the target belongs to, empty for the default one")
+	flags.StringVar(&options.url, "url", "",
+		`page of the target, may be a template, e.g. .../{{now.Format "2006-01-02"}}.html`)
+	flags.StringVar(&options.tables, "tables", "", "product tables, a single selector or \"name=selector;...\"")
+	flags.StringVar(&options.columns, "columns", "", "column selectors in the \"field=selector@attr;...\" format")
+	flags.StringVar(&options.synonyms, "synonyms", "", "header synonyms in the \"field=Header1,Header2;...\" format")
+	flags.StringVar(&options.iframe, "iframe", "", "selector of the iframe embedding the tables")
+	flags.StringVar(&options.request, "request", "",
+		`request of the page in the {"method": "POST", "form": {"field": "value"}} format`)
+	flags.StringVar(&options.steps, "steps", "", `fetch steps in the [{"selector": "a@href"}, ...] format`)
+	flags.StringVar(&options.assertions, "assert", "",
+		`page assertions in the {"contains": ["..."], "not_contains": ["..."], "min_rows": 10} format`)
+	flags.StringVar(&options.headers, "headers", "", `request headers in the {"Header": "value"} format`)
+	flags.StringVar(&options.regions, "regions", "", `regional profiles in the {"region": {"Header": "value"}} format`)
+	flags.StringVar(&options.ipVersion, "ip-version", "", "IP version of the connections, ipv4 or ipv6")
+	flags.StringVar(&options.hosts, "hosts", "", "static host addresses in the \"host=ip;...\" format")
+	flags.StringVar(&options.resolver, "resolver", "", "DNS server the hosts are resolved with, \"ip[:port]\"")
+	flags.StringVar(&options.cert, "cert", "", "client certificate, PEM or the path of a PEM file")
+	flags.StringVar(&options.key, "key", "", "key of the client certificate, PEM or the path of a PEM file")
+	flags.DurationVar(&options.interval, "interval", time.Hour, "how often the target is checked")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected a single target name, got %d arguments", flags.NArg())
+	}
+	options.set = make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { options.set[f.Name] = true })
+
+	scoped := repo.ForTenant(*tenant)
+	target := &models.Target{Name: flags.Arg(0), CreatedAt: time.Now()}
+	if action == "edit" {
+		var err error
+		if target, err = scoped.GetTarget(ctx, flags.Arg(0)); err != nil {
+			return err //nolint:wrapcheck // the repository error names the operation.
+		}
+	}
+
+	if err := options.apply(target, action == "add"); err != nil {
+		return err
+	}
+	if err := target.Validate(); err != nil {
+		return err //nolint:wrapcheck // the validation error is descriptive on its own.
+	}
+
+	if action == "add" {
+		return scoped.CreateTarget(ctx, *target) //nolint:wrapcheck // the repository error names the operation.
+	}
+
+	return scoped.UpdateTarget(ctx, *target) //nolint:wrapcheck // the repository error names the operation.
+}
+
+// apply sets the options on the target. Unless all is set, only the options given explicitly are applied.
+func (o targetFlags) apply(target *models.Target, all bool) error {
+	var err error
+	if all || o.set["url"] {
+		target.URL = o.url
+	}
+	if all || o.set["interval"] {
+		target.Interval = o.interval
+	}
+	if all || o.set["iframe"] {
+		target.IframeSelector = o.iframe
+	}
+	if all || o.set["request"] {
+		if target.Request, err = config.ParseFetchRequest(o.request); err != nil {
+			return fmt.Errorf("invalid request: %w", err)
+		}
+	}
+	if all || o.set["steps"] {
+		if target.Steps, err = config.ParseFetchSteps(o.steps); err != nil {
+			return fmt.Errorf("invalid fetch steps: %w", err)
+		}
+	}
+	if all || o.set["assert"] {
+		if target.Assertions, err = config.ParsePageAssertions(o.assertions); err != nil {
+			return fmt.Errorf("invalid page assertions: %w", err)
+		}
+	}
+	if all || o.set["tables"] {
+		if target.Tables, err = config.ParseTargetTables(o.tables); err != nil {
+			return fmt.Errorf("invalid tables: %w", err)
+		}
+	}
+	if all || o.set["columns"] {
+		if target.ColumnSelectors, err = config.ParseColumnSelectors(o.columns); err != nil {
+			return fmt.Errorf("invalid column selectors: %w", err)
+		}
+	}
+	if all || o.set["synonyms"] {
+		if target.ColumnSynonyms, err = config.ParseColumnSynonyms(o.synonyms); err != nil {
+			return fmt.Errorf("invalid column synonyms: %w", err)
+		}
+	}
+	if all || o.set["headers"] {
+		if target.Headers, err = config.ParseRequestHeaders(o.headers); err != nil {
+			return fmt.Errorf("invalid headers: %w", err)
+		}
+	}
+	if all || o.set["regions"] {
+		if target.Regions, err = config.ParseRegions(o.regions); err != nil {
+			return fmt.Errorf("invalid regions: %w", err)
+		}
+	}
+	if all || o.set["ip-version"] {
+		target.Network.IPVersion = strings.ToLower(o.ipVersion)
+	}
+	if all || o.set["hosts"] {
+		if target.Network.Hosts, err = config.ParseDNSHosts(o.hosts); err != nil {
+			return fmt.Errorf("invalid hosts: %w", err)
+		}
+	}
+	if all || o.set["resolver"] {
+		target.Network.Resolver = o.resolver
+	}
+	if all || o.set["cert"] || o.set["key"] {
+		target.TLS = models.TargetTLS{Cert: o.cert, Key: o.key}
+		if !target.TLS.IsZero() {
+			if _, err = httpclient.ClientCertificate(target.TLS); err != nil {
+				return fmt.Errorf("invalid client certificate: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// listTargets writes the targets of the tenant as a table.
+func listTargets(ctx context.Context, repo *sqlite.Repository, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("target list", flag.ContinueOnError)
+	tenant := flags.String("tenant", "", "tenant the targets belong to, empty for the default one")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	targets, err := repo.ForTenant(*tenant).GetTargets(ctx)
+	if err != nil {
+		return err //nolint:wrapcheck // the repository error names the operation.
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0) //nolint:mnd // two spaces between columns.
+	fmt.Fprintln(writer, "NAME\tURL\tTABLES\tREGIONS\tINTERVAL\tENABLED\tCREATED")
+	for _, target := range targets {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%t\t%s\n", target.Name, target.URL,
+			formatTargetTables(target.Tables), formatTargetRegions(target.Regions), target.Interval, !target.Disabled,
+			target.CreatedAt.Format(time.DateTime))
+	}
+
+	if err = writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write targets: %w", err)
+	}
+
+	return nil
+}
+
+// removeTarget removes a target of the tenant with its products and changes.
+func removeTarget(ctx context.Context, repo *sqlite.Repository, args []string) error {
+	flags := flag.NewFlagSet("target remove", flag.ContinueOnError)
+	tenant := flags.String("tenant", "", "tenant the target belongs to, empty for the default one")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected a single target name, got %d arguments", flags.NArg())
+	}
+
+	return repo.ForTenant(*tenant).DeleteTarget(ctx, flags.Arg(0)) //nolint:wrapcheck // the error names the operation.
+}
+
+// formatTargetTables describes the tables of a target, "-" for the parser default.
+func formatTargetTables(tables []models.TargetTable) string {
+	if len(tables) == 0 {
+		return "-"
+	}
+
+	parts := make([]string, 0, len(tables))
+	for _, table := range tables {
+		if table.Name == "" {
+			parts = append(parts, table.Selector)
+			continue
+		}
+		parts = append(parts, table.Name+"="+table.Selector)
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// formatTargetRegions lists the regions of a target, "-" if it has none.
+func formatTargetRegions(regions []models.TargetRegion) string {
+	if len(regions) == 0 {
+		return "-"
+	}
+
+	names := make([]string, 0, len(regions))
+	for _, region := range regions {
+		names = append(names, region.Name)
+	}
+
+	return strings.Join(names, ",")
+}
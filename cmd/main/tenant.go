@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/config"
+	"github.com/Houeta/chrono-flow/internal/metrics"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+	"github.com/Houeta/chrono-flow/internal/schedule"
+)
+
+// tenantCommand is the first argument which switches the binary into tenant management mode.
+const tenantCommand = "tenant"
+
+// tenantUsage explains the tenant management actions.
+const tenantUsage = `Usage: chrono-flow tenant <action>
+  add -url <url> -token <bot token> [-name <name>] [-allowed <ids>] [-admins <ids>] [-windows <windows>] <id>
+  list
+  remove <id>
+Tenants are loaded on startup, restart chrono-flow to apply the changes.`
+
+// startTenants starts a bot and a scheduler loop for every tenant stored in the database. A tenant
+// that can't be initialized is logged and skipped, so it doesn't take the other tenants down.
+// The returned function stops the loops and the bots.
+func startTenants(
+	ctx context.Context,
+	logger *slog.Logger,
+	cfg *config.Config,
+	repo *sqlite.Repository,
+	shared sharedServices,
+) (func(), error) {
+	tenants, err := repo.GetTenants(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tenants: %w", err)
+	}
+
+	// Metrics are exposed for the default tenant only.
+	tenantShared := shared
+	tenantShared.metrics = metrics.New()
+	// Log levels apply to the whole process, so only the admins of the default tenant change them.
+	tenantShared.logLevels = nil
+
+	ctx, cancel := context.WithCancel(ctx)
+	var (
+		wg   sync.WaitGroup
+		apps []*app
+	)
+	for _, tenant := range tenants {
+		tenantLog := logger.With("tenant", tenant.ID)
+		tenantApp, appErr := newTenantApp(ctx, tenantLog, cfg, repo, tenant, tenantShared)
+		if appErr != nil {
+			tenantLog.ErrorContext(ctx, "tenant initialization failed", "error", appErr)
+			continue
+		}
+		apps = append(apps, tenantApp)
+
+		if cfg.Role != config.RoleChecker {
+			tenantApp.startBot()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runTenantRole(ctx, tenantLog, cfg, tenantApp)
+		}()
+	}
+	logger.InfoContext(ctx, "Tenants started", "count", len(apps))
+
+	return func() {
+		cancel()
+		wg.Wait()
+		for _, tenantApp := range apps {
+			tenantApp.targets.wait()
+			tenantApp.notifier.Stop()
+		}
+	}, nil
+}
+
+// runTenantRole runs the loops of a tenant the role of the process is responsible for until the context
+// is canceled: the bot delivers the queued changes, the checker checks the main page and the targets.
+func runTenantRole(ctx context.Context, logger *slog.Logger, cfg *config.Config, tenantApp *app) {
+	switch cfg.Role {
+	case config.RoleBot:
+		tenantApp.runDelivery(ctx, cfg.OutboxPollInterval)
+		return
+	case config.RoleAll:
+		defer tenantApp.startDelivery(ctx, cfg.OutboxPollInterval)()
+	}
+
+	if err := tenantApp.targets.start(); err != nil {
+		logger.ErrorContext(ctx, "tenant targets failed to start", "error", err)
+	}
+	tenantApp.runTenant(ctx, cfg.Interval)
+}
+
+// newTenantApp creates the services of a tenant, see newApp.
+func newTenantApp(
+	ctx context.Context,
+	logger *slog.Logger,
+	cfg *config.Config,
+	repo *sqlite.Repository,
+	tenant models.Tenant,
+	shared sharedServices,
+) (*app, error) {
+	tenantCfg, err := cfg.ForTenant(tenant)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the configuration error names the tenant.
+	}
+
+	return newApp(ctx, logger, tenantCfg, repo.ForTenant(tenant.ID), shared)
+}
+
+// controlTenant runs a tenant management action and returns the process exit code.
+func controlTenant(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, tenantUsage)
+		return 2 //nolint:mnd // exit code 2 is the convention for invalid usage.
+	}
+
+	ctx := context.Background()
+	repo, err := openCommandRepository(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer repo.Close()
+
+	action := args[0]
+	switch {
+	case action == "add":
+		err = addTenant(ctx, repo, args[1:])
+	case action == "list" && len(args) == 1:
+		err = listTenants(ctx, repo, os.Stdout)
+	case action == "remove" && len(args) == 2: //nolint:mnd // the action and the tenant ID.
+		err = repo.DeleteTenant(ctx, args[1])
+	default:
+		fmt.Fprintln(os.Stderr, tenantUsage)
+		return 2 //nolint:mnd // exit code 2 is the convention for invalid usage.
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to %s tenant: %v\n", action, err)
+		return 1
+	}
+	if action != "list" {
+		fmt.Printf("tenant %s: ok\n", action) //nolint:forbidigo // the result is the command output.
+	}
+
+	return 0
+}
+
+// openCommandRepository opens the database configured for the service, so management commands
+// change the data the running service uses.
+func openCommandRepository(ctx context.Context) (*sqlite.Repository, error) {
+	cfg, err := config.MustLoad()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo, err := sqlite.NewRepository(ctx, logger, cfg.StoragePath, sqlite.WithEncryptionKey(cfg.EncryptionKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the database: %w", err)
+	}
+
+	return repo, nil
+}
+
+// addTenant parses the arguments of the add action and stores the tenant.
+func addTenant(ctx context.Context, repo sqlite.TenantRepository, args []string) error {
+	flags := flag.NewFlagSet("tenant add", flag.ContinueOnError)
+	name := flags.String("name", "", "human-readable name of the tenant")
+	targetURL := flags.String("url", "", "target page the tenant tracks")
+	token := flags.String("token", "", "token of the tenant's Telegram bot")
+	allowed := flags.String("allowed", "", "comma separated chats allowed to use the bot")
+	admins := flags.String("admins", "", "comma separated chats allowed to run admin commands")
+	windows := flags.String("windows", "", "maintenance windows of the target, e.g. \"0 2 * * * 1h\"")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected a single tenant ID, got %d arguments", flags.NArg())
+	}
+
+	tenant := models.Tenant{
+		ID:        flags.Arg(0),
+		Name:      *name,
+		URL:       *targetURL,
+		Token:     *token,
+		CreatedAt: time.Now(),
+
+		MaintenanceWindows: *windows,
+	}
+
+	var err error
+	if tenant.AllowedIDs, err = parseChatIDs(*allowed); err != nil {
+		return fmt.Errorf("invalid allowed chats: %w", err)
+	}
+	if tenant.AdminIDs, err = parseChatIDs(*admins); err != nil {
+		return fmt.Errorf("invalid admin chats: %w", err)
+	}
+	if err = tenant.Validate(); err != nil {
+		return err //nolint:wrapcheck // the validation error is descriptive on its own.
+	}
+	if _, err = schedule.ParseWindows(tenant.MaintenanceWindows); err != nil {
+		return fmt.Errorf("invalid maintenance windows: %w", err)
+	}
+
+	return repo.CreateTenant(ctx, tenant) //nolint:wrapcheck // the repository error names the operation.
+}
+
+// listTenants writes the stored tenants as a table. Bot tokens are secrets and aren't printed.
+func listTenants(ctx context.Context, repo sqlite.TenantRepository, out io.Writer) error {
+	tenants, err := repo.GetTenants(ctx)
+	if err != nil {
+		return err //nolint:wrapcheck // the repository error names the operation.
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0) //nolint:mnd // two spaces between columns.
+	fmt.Fprintln(writer, "ID\tNAME\tURL\tALLOWED\tADMINS\tCREATED")
+	for _, tenant := range tenants {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", tenant.ID, tenant.Name, tenant.URL,
+			formatChatIDs(tenant.AllowedIDs), formatChatIDs(tenant.AdminIDs), tenant.CreatedAt.Format(time.DateTime))
+	}
+
+	if err = writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write tenants: %w", err)
+	}
+
+	return nil
+}
+
+// parseChatIDs parses a comma separated list of chat IDs.
+func parseChatIDs(raw string) ([]int64, error) {
+	var ids []int64
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse chat ID %q: %w", part, err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// formatChatIDs joins chat IDs with commas, "-" stands for none.
+func formatChatIDs(ids []int64) string {
+	if len(ids) == 0 {
+		return "-"
+	}
+
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatInt(id, 10))
+	}
+
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/auth"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+)
+
+// tokenCommand is the first argument which switches the binary into API token management mode.
+const tokenCommand = "token"
+
+// tokenUsage explains the API token management actions.
+const tokenUsage = `Usage: chrono-flow token <action>
+  create -scopes <scopes> [-name <name>] [-chat <id>]
+  list
+  revoke <id>
+Scopes: read:products, read:changes, trigger:check, admin.
+The feeds read with a token created for a chat are filtered by the settings of the chat.
+Tokens are checked when CF_API_AUTH is enabled.`
+
+// controlToken runs an API token management action and returns the process exit code.
+func controlToken(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, tokenUsage)
+		return 2 //nolint:mnd // exit code 2 is the convention for invalid usage.
+	}
+
+	ctx := context.Background()
+	repo, err := openCommandRepository(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer repo.Close()
+
+	action := args[0]
+	switch {
+	case action == "create":
+		err = createToken(ctx, repo, args[1:], os.Stdout)
+	case action == "list" && len(args) == 1:
+		err = listTokens(ctx, repo, os.Stdout)
+	case action == "revoke" && len(args) == 2: //nolint:mnd // the action and the token ID.
+		err = revokeToken(ctx, repo, args[1])
+	default:
+		fmt.Fprintln(os.Stderr, tokenUsage)
+		return 2 //nolint:mnd // exit code 2 is the convention for invalid usage.
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to %s token: %v\n", action, err)
+		return 1
+	}
+
+	return 0
+}
+
+// createToken parses the arguments of the create action, stores the hash of a new token and
+// writes the token to out. The token can't be shown again later.
+func createToken(ctx context.Context, repo sqlite.TokenRepository, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("token create", flag.ContinueOnError)
+	name := flags.String("name", "", "human-readable name of the token")
+	rawScopes := flags.String("scopes", "", "comma separated scopes the token grants")
+	chatID := flags.Int64("chat", 0, "chat whose settings filter the feeds read with the token")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	scopes, err := auth.ParseScopes(*rawScopes)
+	if err != nil {
+		return err //nolint:wrapcheck // the scope error is descriptive on its own.
+	}
+
+	secret, err := auth.GenerateToken()
+	if err != nil {
+		return err //nolint:wrapcheck // the generation error is descriptive on its own.
+	}
+
+	token := models.APIToken{Name: *name, Scopes: scopes, CreatedAt: time.Now(), ChatID: *chatID}
+	id, err := repo.CreateToken(ctx, token, auth.HashToken(secret))
+	if err != nil {
+		return err //nolint:wrapcheck // the repository error names the operation.
+	}
+
+	fmt.Fprintf(out, "token %d created, it is shown only once:\n%s\n", id, secret)
+
+	return nil
+}
+
+// listTokens writes the stored tokens as a table.
+func listTokens(ctx context.Context, repo sqlite.TokenRepository, out io.Writer) error {
+	tokens, err := repo.GetTokens(ctx)
+	if err != nil {
+		return err //nolint:wrapcheck // the repository error names the operation.
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0) //nolint:mnd // two spaces between columns.
+	fmt.Fprintln(writer, "ID\tNAME\tSCOPES\tCHAT\tCREATED\tREVOKED")
+	for _, token := range tokens {
+		revoked := "-"
+		if token.Revoked() {
+			revoked = token.RevokedAt.Format(time.DateTime)
+		}
+		chat := "-"
+		if token.ChatID != 0 {
+			chat = strconv.FormatInt(token.ChatID, 10)
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\t%s\n", token.ID, token.Name, strings.Join(token.Scopes, ","), chat,
+			token.CreatedAt.Format(time.DateTime), revoked)
+	}
+
+	if err = writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write tokens: %w", err)
+	}
+
+	return nil
+}
+
+// revokeToken revokes the token with the ID given as a string.
+func revokeToken(ctx context.Context, repo sqlite.TokenRepository, rawID string) error {
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid token ID %q: %w", rawID, err)
+	}
+
+	if err = repo.RevokeToken(ctx, id, time.Now()); err != nil {
+		return err //nolint:wrapcheck // the repository error names the operation.
+	}
+	fmt.Printf("token %d revoked\n", id) //nolint:forbidigo // the result is the command output.
+
+	return nil
+}
@@ -0,0 +1,184 @@
+// Package api serves the monitored products and their history over GraphQL, the changes as feeds
+// and a calendar, the products listed at a past time, the full diffs of the runs, and a public status
+// page of the monitor.
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/auth"
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+// Repository is a set of storage methods used by the API.
+type Repository interface {
+	sqlite.StateRepository
+	sqlite.ProductRepository
+	sqlite.HistoryRepository
+	sqlite.TargetRepository
+	sqlite.SubscriberStatsRepository
+	sqlite.ChatSettingsRepository
+	sqlite.UptimeRepository
+	sqlite.PrivacyRepository
+	sqlite.PageDiffRepository
+	sqlite.RunArtifactRepository
+	sqlite.CheckErrorRepository
+}
+
+// LogLevels reads and changes the levels of the loggers of the components at runtime.
+type LogLevels interface {
+	// All returns the current level of every component.
+	All() map[string]slog.Level
+	// Set changes the level of the component.
+	Set(component string, level slog.Level) error
+	// Reset restores the configured level of the component.
+	Reset(component string) error
+}
+
+// ErrLogLevelsUnavailable is returned by the log level fields when the server has no LogLevels.
+var ErrLogLevelsUnavailable = errors.New("log levels aren't available")
+
+// Server serves the GraphQL API.
+type Server struct {
+	log    *slog.Logger
+	repo   Repository
+	schema *graphql.Schema
+	// authenticator checks the token of every request, nil leaves the API open.
+	authenticator *auth.Authenticator
+	// logLevels are read and changed with the logLevels and setLogLevel fields, nil disables them.
+	logLevels LogLevels
+	// feedLink is the page the changes of the feeds were detected on.
+	feedLink string
+	// filterGroups map the filter group of a chat to the product types in the feeds read with its tokens.
+	filterGroups map[string][]string
+	// checkInterval is how often the target is checked, the status page estimates the next check with it.
+	checkInterval time.Duration
+}
+
+// Option configures the Server.
+type Option func(*Server)
+
+// WithAuthenticator requires every request to carry an API token. Products need the read:products
+// scope, changes, runs and product history need the read:changes scope, targets, log levels, page diffs
+// and forgetting chats need the admin scope.
+func WithAuthenticator(authenticator *auth.Authenticator) Option {
+	return func(s *Server) {
+		s.authenticator = authenticator
+	}
+}
+
+// WithLogLevels enables the logLevels query and the setLogLevel mutation, both need the admin scope.
+func WithLogLevels(levels LogLevels) Option {
+	return func(s *Server) {
+		s.logLevels = levels
+	}
+}
+
+// WithFeedLink sets the page the changes of the feeds were detected on.
+func WithFeedLink(link string) Option {
+	return func(s *Server) {
+		s.feedLink = link
+	}
+}
+
+// WithFilterGroups sets the filter groups of the chats, the feeds read with a token created for a chat
+// only list the product types of its group.
+func WithFilterGroups(groups map[string][]string) Option {
+	return func(s *Server) {
+		s.filterGroups = groups
+	}
+}
+
+// WithCheckInterval sets how often the target is checked, so the status page shows when the next check
+// is due and tells the monitor is down once two checks were missed.
+func WithCheckInterval(interval time.Duration) Option {
+	return func(s *Server) {
+		s.checkInterval = interval
+	}
+}
+
+// NewServer creates the API server reading from repo.
+func NewServer(log *slog.Logger, repo Repository, opts ...Option) (*Server, error) {
+	const maxDepth = 5
+
+	server := &Server{log: log, repo: repo}
+	for _, opt := range opts {
+		opt(server)
+	}
+
+	root := &resolver{repo: repo, log: log, logLevels: server.logLevels}
+	parsed, err := graphql.ParseSchema(schema, root, graphql.MaxDepth(maxDepth))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GraphQL schema: %w", err)
+	}
+	server.schema = parsed
+
+	return server, nil
+}
+
+// Handler returns the HTTP handler serving GraphQL queries at /graphql, the changes as Atom and RSS
+// feeds at /feed.atom and /feed.rss, see feedHandler, and as a calendar at /calendar.ics, see
+// calendarHandler. The products listed at a past time are served at /state.csv and /state.json, see
+// stateHandler. The full diff of a run is served at /runs/{run}/diff.md, .html and .csv, see
+// diffHandler. The public status page is served at /status and /status.json, see statusHandler.
+func (s *Server) Handler() http.Handler {
+	var handler http.Handler = &relay.Handler{Schema: s.schema}
+	feeds := map[string]http.Handler{
+		"GET /feed.atom":    s.feedHandler(export.FeedAtom),
+		"GET /feed.rss":     s.feedHandler(export.FeedRSS),
+		"GET /calendar.ics": s.calendarHandler(),
+		"GET /state.csv":    s.stateHandler(stateCSV),
+		"GET /state.json":   s.stateHandler(stateJSON),
+	}
+	for _, format := range export.DiffFormats() {
+		feeds["GET /runs/{run}/diff."+format] = s.diffHandler(format)
+	}
+	if s.authenticator != nil {
+		handler = s.authenticator.Middleware(handler)
+		for pattern, feed := range feeds {
+			feeds[pattern] = s.authenticator.URLMiddleware(feed)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/graphql", handler)
+	for pattern, feed := range feeds {
+		mux.Handle(pattern, feed)
+	}
+	mux.Handle("GET /status", s.statusHandler(false))
+	mux.Handle("GET /status.json", s.statusHandler(true))
+
+	return mux
+}
+
+// Serve exposes the API on addr until the context is canceled.
+func (s *Server) Serve(ctx context.Context, addr string) error {
+	const shutdownTimeout = 5 * time.Second
+	const readHeaderTimeout = 10 * time.Second
+
+	server := &http.Server{Addr: addr, Handler: s.Handler(), ReadHeaderTimeout: readHeaderTimeout}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			s.log.ErrorContext(ctx, "failed to shut down API server", "error", err)
+		}
+	}()
+
+	s.log.InfoContext(ctx, "Serving GraphQL API", "addr", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("API server failed: %w", err)
+	}
+
+	return nil
+}
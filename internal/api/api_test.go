@@ -0,0 +1,634 @@
+package api_test
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/api"
+	"github.com/Houeta/chrono-flow/internal/auth"
+	"github.com/Houeta/chrono-flow/internal/events"
+	"github.com/Houeta/chrono-flow/internal/logging"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) (*sqlite.Repository, http.Handler) {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo, err := sqlite.NewRepository(t.Context(), logger, filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = repo.Close() })
+
+	server, err := api.NewServer(logger, repo)
+	require.NoError(t, err)
+
+	return repo, server.Handler()
+}
+
+// query posts a GraphQL query and decodes the data of the response.
+func query(t *testing.T, handler http.Handler, body string, data any) {
+	t.Helper()
+
+	payload, err := json.Marshal(map[string]string{"query": body})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(payload))))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var response struct {
+		Data   json.RawMessage  `json:"data"`
+		Errors []map[string]any `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Empty(t, response.Errors)
+	require.NoError(t, json.Unmarshal(response.Data, data))
+}
+
+func TestServer_Products(t *testing.T) {
+	repo, handler := newTestServer(t)
+	ctx := t.Context()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	var empty struct{ Products []map[string]any }
+	query(t, handler, `{ products { model } }`, &empty)
+	assert.Empty(t, empty.Products)
+
+	require.NoError(t, repo.UpdateState(ctx, &models.State{PageHash: "hash", Products: []models.Product{
+		{Model: "A1", Category: "new", Type: "Diver", Quantity: "1", Price: "90", ProductURL: "https://example.com/a1"},
+		{
+			Model: "B1", Category: "used", Type: "Dress", Quantity: "2", Price: "50",
+			Extras: map[string]string{"warranty": "1 year", "margin_flag": "true"},
+		},
+	}}))
+	require.NoError(t, repo.RecordChanges(ctx, now.Add(-2*time.Hour), &models.Changes{
+		Added: []models.Product{{Model: "A1", Category: "new", Price: "100", Quantity: "2"}},
+	}))
+	require.NoError(t, repo.RecordChanges(ctx, now.Add(-time.Hour), &models.Changes{
+		Changed: []models.ChangeInfo{{
+			Old: models.Product{Model: "A1", Category: "new", Price: "100", Quantity: "2"},
+			New: models.Product{Model: "A1", Category: "new", Price: "100", Quantity: "1"},
+		}},
+	}))
+	require.NoError(t, repo.RecordChanges(ctx, now, &models.Changes{
+		Changed: []models.ChangeInfo{{
+			Old: models.Product{Model: "A1", Category: "new", Price: "100", Quantity: "1"},
+			New: models.Product{Model: "A1", Category: "new", Price: "90", Quantity: "1"},
+		}},
+	}))
+
+	var filtered struct {
+		Products []struct{ Model, ProductURL string }
+	}
+	query(t, handler, `{ products(category: "new") { model productUrl } }`, &filtered)
+	require.Len(t, filtered.Products, 1)
+	assert.Equal(t, "A1", filtered.Products[0].Model)
+	assert.Equal(t, "https://example.com/a1", filtered.Products[0].ProductURL)
+
+	var page struct {
+		Products []struct{ Model string }
+	}
+	query(t, handler, `{ products(maxPrice: 95, inStock: true, sort: "price", offset: 1, limit: 1) { model } }`, &page)
+	require.Len(t, page.Products, 1)
+	assert.Equal(t, "A1", page.Products[0].Model, "B1 is cheaper and comes first")
+
+	var extras struct {
+		Products []struct {
+			Model  string
+			Extras []struct{ Name, Value string }
+		}
+	}
+	query(
+		t, handler, `{ products(extras: [{name: "margin_flag", value: "true"}]) { model extras { name value } } }`, &extras,
+	)
+	require.Len(t, extras.Products, 1)
+	assert.Equal(t, "B1", extras.Products[0].Model)
+	assert.Equal(t, []struct{ Name, Value string }{
+		{"margin_flag", "true"}, {"warranty", "1 year"},
+	}, extras.Products[0].Extras)
+
+	var single struct {
+		Product struct {
+			Price        string
+			PriceHistory []struct{ At, Price string }
+		}
+	}
+	query(t, handler, `{ product(model: "A1") { price priceHistory { at price } } }`, &single)
+	assert.Equal(t, "90", single.Product.Price)
+	require.Len(t, single.Product.PriceHistory, 2)
+	assert.Equal(t, "100", single.Product.PriceHistory[0].Price)
+	assert.Equal(t, now.Add(-2*time.Hour).Format(time.RFC3339), single.Product.PriceHistory[0].At)
+	assert.Equal(t, "90", single.Product.PriceHistory[1].Price)
+
+	var missing struct{ Product *struct{ Model string } }
+	query(t, handler, `{ product(model: "A1", category: "used") { model } }`, &missing)
+	assert.Nil(t, missing.Product)
+}
+
+func TestServer_ChangesAndRuns(t *testing.T) {
+	repo, handler := newTestServer(t)
+	ctx := t.Context()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	require.NoError(t, repo.RecordChanges(ctx, now.Add(-48*time.Hour), &models.Changes{
+		Added: []models.Product{{Model: "OLD"}},
+	}))
+	require.NoError(t, repo.RecordChanges(events.WithRunID(ctx, "run1"), now, &models.Changes{
+		Removed: []models.Product{{Model: "B1", Category: "used", Price: "50"}},
+	}))
+	_, err := repo.AnnotateChange(ctx, "run1", "B1", "sold out for good")
+	require.NoError(t, err)
+	require.NoError(t, repo.RecordFetch(ctx, models.FetchRecord{FetchedAt: now, Latency: 1500 * time.Millisecond}))
+
+	since := now.Add(-time.Hour).Format(time.RFC3339)
+	var data struct {
+		Changes []struct{ Kind, OldModel, OldPrice, RunID, Note string }
+		Runs    []struct {
+			LatencyMs int
+			Success   bool
+		}
+	}
+	query(t, handler, `{
+		changes(since: "`+since+`") { kind oldModel oldPrice runId note }
+		runs(since: "`+since+`") { latencyMs success }
+	}`, &data)
+
+	require.Len(t, data.Changes, 1)
+	assert.Equal(t, models.KindRemoved, data.Changes[0].Kind)
+	assert.Equal(t, "B1", data.Changes[0].OldModel)
+	assert.Equal(t, "50", data.Changes[0].OldPrice)
+	assert.Equal(t, "run1", data.Changes[0].RunID)
+	assert.Equal(t, "sold out for good", data.Changes[0].Note)
+	require.Len(t, data.Runs, 1)
+	assert.Equal(t, 1500, data.Runs[0].LatencyMs)
+	assert.False(t, data.Runs[0].Success)
+}
+
+func TestServer_Targets(t *testing.T) {
+	repo, handler := newTestServer(t)
+	created := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, repo.CreateTarget(t.Context(), models.Target{
+		Name:     "outlet",
+		URL:      "https://example.com/outlet",
+		Tables:   []models.TargetTable{{Name: "new", Selector: "table.new"}, {Name: "sale", Selector: "table.sale"}},
+		Interval: time.Hour,
+
+		CreatedAt: created,
+	}))
+
+	var result struct {
+		Targets []struct {
+			Name            string
+			URL             string
+			Tables          []string
+			IntervalSeconds int
+			Enabled         bool
+			CreatedAt       time.Time
+			LastError       *struct {
+				Class        string
+				Message      string
+				FailingSince *time.Time
+				Failures     int
+			}
+		}
+	}
+	require.NoError(t, repo.ForTarget("outlet").RecordCheckError(t.Context(), "parse", "no products found", created))
+	query(t, handler, `{ targets { name url tables intervalSeconds enabled createdAt
+		lastError { class message failingSince failures } } }`, &result)
+
+	require.Len(t, result.Targets, 1)
+	assert.Equal(t, "outlet", result.Targets[0].Name)
+	assert.Equal(t, "https://example.com/outlet", result.Targets[0].URL)
+	assert.Equal(t, []string{"new=table.new", "sale=table.sale"}, result.Targets[0].Tables)
+	assert.Equal(t, 3600, result.Targets[0].IntervalSeconds)
+	assert.True(t, result.Targets[0].Enabled)
+	assert.True(t, created.Equal(result.Targets[0].CreatedAt))
+	require.NotNil(t, result.Targets[0].LastError)
+	assert.Equal(t, "parse", result.Targets[0].LastError.Class)
+	assert.Equal(t, "no products found", result.Targets[0].LastError.Message)
+	require.NotNil(t, result.Targets[0].LastError.FailingSince)
+	assert.True(t, created.Equal(*result.Targets[0].LastError.FailingSince))
+	assert.Equal(t, 1, result.Targets[0].LastError.Failures)
+}
+
+func TestServer_LogLevels(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo, err := sqlite.NewRepository(t.Context(), logger, filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = repo.Close() })
+
+	levels := logging.NewLevels(slog.LevelWarn, nil)
+	server, err := api.NewServer(logger, repo, api.WithLogLevels(levels))
+	require.NoError(t, err)
+	handler := server.Handler()
+
+	var changed struct {
+		SetLogLevel struct{ Component, Level string }
+	}
+	query(t, handler, `mutation { setLogLevel(component: "parser", level: "debug") { component level } }`, &changed)
+	assert.Equal(t, "DEBUG", changed.SetLogLevel.Level)
+	assert.Equal(t, slog.LevelDebug, levels.Level(logging.ComponentParser))
+
+	var result struct {
+		LogLevels []struct{ Component, Level string }
+	}
+	query(t, handler, `{ logLevels { component level } }`, &result)
+	require.Len(t, result.LogLevels, 4)
+	assert.Equal(t, "default", result.LogLevels[1].Component)
+	assert.Equal(t, "WARN", result.LogLevels[1].Level)
+	assert.Equal(t, "parser", result.LogLevels[2].Component)
+	assert.Equal(t, "DEBUG", result.LogLevels[2].Level)
+
+	query(t, handler, `mutation { setLogLevel(component: "parser", level: "reset") { level } }`, &changed)
+	assert.Equal(t, "WARN", changed.SetLogLevel.Level)
+
+	recorder := httptest.NewRecorder()
+	body := `{"query": "mutation { setLogLevel(component: \"scheduler\", level: \"debug\") { level } }"}`
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body)))
+	assert.Contains(t, recorder.Body.String(), logging.ErrUnknownComponent.Error())
+}
+
+func TestServer_SubscriberStats(t *testing.T) {
+	repo, handler := newTestServer(t)
+	require.NoError(t, repo.SubscribeChat(t.Context(), 1))
+	require.NoError(t, repo.RecordChatActivity(t.Context(), 1, time.Now()))
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	var result struct {
+		SubscriberStats []struct {
+			Day                                                time.Time
+			Subscribers, Subscribed, Unsubscribed, ActiveChats int
+		}
+	}
+	query(t, handler, `{ subscriberStats(since: "`+today.Add(-24*time.Hour).Format(time.RFC3339)+`") {
+		day subscribers subscribed unsubscribed activeChats } }`, &result)
+
+	require.Len(t, result.SubscriberStats, 2)
+	assert.True(t, today.Equal(result.SubscriberStats[1].Day))
+	assert.Equal(t, 0, result.SubscriberStats[0].Subscribers)
+	assert.Equal(t, 1, result.SubscriberStats[1].Subscribers)
+	assert.Equal(t, 1, result.SubscriberStats[1].Subscribed)
+	assert.Equal(t, 1, result.SubscriberStats[1].ActiveChats)
+}
+
+func TestServer_PageDiffs(t *testing.T) {
+	repo, handler := newTestServer(t)
+	checkedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	diff := models.PageDiff{RunID: "run-1", CheckedAt: checkedAt, OldHash: "a", NewHash: "b", Diff: "-x\n+y\n"}
+	require.NoError(t, repo.RecordPageDiff(t.Context(), diff, 5))
+
+	var result struct {
+		PageDiffs []struct {
+			RunID                  string `json:"runId"`
+			CheckedAt              time.Time
+			OldHash, NewHash, Diff string
+		}
+	}
+	query(t, handler, `{ pageDiffs { runId checkedAt oldHash newHash diff } }`, &result)
+
+	require.Len(t, result.PageDiffs, 1)
+	assert.Equal(t, "run-1", result.PageDiffs[0].RunID)
+	assert.True(t, checkedAt.Equal(result.PageDiffs[0].CheckedAt))
+	assert.Equal(t, "a", result.PageDiffs[0].OldHash)
+	assert.Equal(t, "b", result.PageDiffs[0].NewHash)
+	assert.Equal(t, "-x\n+y\n", result.PageDiffs[0].Diff)
+}
+
+func TestServer_ForgetChat(t *testing.T) {
+	repo, handler := newTestServer(t)
+	require.NoError(t, repo.SubscribeChat(t.Context(), -100))
+	require.NoError(t, repo.SubscribeChat(t.Context(), -200))
+
+	var result struct {
+		ForgetChat []struct {
+			Kind    string
+			Deleted int
+		}
+	}
+	query(t, handler, `mutation { forgetChat(chatId: "-100") { kind deleted } }`, &result)
+
+	require.NotEmpty(t, result.ForgetChat)
+	assert.Equal(t, "subscription", result.ForgetChat[0].Kind)
+	assert.Equal(t, 1, result.ForgetChat[0].Deleted)
+	subscribers, err := repo.GetSubscribedChats(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []int64{-200}, subscribers)
+}
+
+func TestServer_Auth(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo, err := sqlite.NewRepository(t.Context(), logger, filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = repo.Close() })
+
+	server, err := api.NewServer(logger, repo, api.WithAuthenticator(auth.NewAuthenticator(logger, repo)))
+	require.NoError(t, err)
+	handler := server.Handler()
+
+	secret, err := auth.GenerateToken()
+	require.NoError(t, err)
+	token := models.APIToken{Scopes: []string{auth.ScopeReadProducts}}
+	_, err = repo.CreateToken(t.Context(), token, auth.HashToken(secret))
+	require.NoError(t, err)
+
+	post := func(token, body string) *httptest.ResponseRecorder {
+		payload, marshalErr := json.Marshal(map[string]string{"query": body})
+		require.NoError(t, marshalErr)
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(payload)))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, post("", "{ products { model } }").Code)
+	assert.Equal(t, http.StatusUnauthorized, post("cf_unknown", "{ products { model } }").Code)
+
+	allowed := post(secret, "{ products { model } }")
+	require.Equal(t, http.StatusOK, allowed.Code)
+	assert.NotContains(t, allowed.Body.String(), "errors")
+
+	// The token lacks the read:changes scope, so the field resolves to an error.
+	forbidden := post(secret, `{ changes(since: "2025-01-01T00:00:00Z") { model } }`)
+	require.Equal(t, http.StatusOK, forbidden.Code)
+	assert.Contains(t, forbidden.Body.String(), auth.ErrForbidden.Error())
+
+	// Feed readers pass the token in the URL.
+	feed := func(target string) int {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+
+		return recorder.Code
+	}
+	assert.Equal(t, http.StatusUnauthorized, feed("/feed.atom"))
+	assert.Equal(t, http.StatusForbidden, feed("/feed.atom?token="+secret))
+}
+
+func TestServer_Feeds(t *testing.T) {
+	repo, handler := newTestServer(t)
+	ctx := t.Context()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	require.NoError(t, repo.RecordChanges(ctx, now.Add(-30*24*time.Hour), &models.Changes{
+		Added: []models.Product{{Model: "OLD"}},
+	}))
+	require.NoError(t, repo.RecordChanges(ctx, now.Add(-time.Hour), &models.Changes{
+		Added: []models.Product{{Model: "A1", Price: "100"}, {Model: "B2", Price: "200"}},
+	}))
+	require.NoError(t, repo.RecordChanges(ctx, now, &models.Changes{
+		Removed: []models.Product{{Model: "A1", Price: "100"}},
+	}))
+
+	get := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		return recorder
+	}
+
+	atom := get("/feed.atom")
+	assert.Equal(t, "application/atom+xml; charset=utf-8", atom.Header().Get("Content-Type"))
+	assert.Equal(t, 3, strings.Count(atom.Body.String(), "<entry>"), "changes older than a week aren't listed")
+	assert.Less(t, strings.Index(atom.Body.String(), "A1 removed"), strings.Index(atom.Body.String(), "B2 added"))
+
+	rss := get("/feed.rss?group=run")
+	assert.Equal(t, "application/rss+xml; charset=utf-8", rss.Header().Get("Content-Type"))
+	assert.Equal(t, 2, strings.Count(rss.Body.String(), "<item>"))
+	assert.Contains(t, rss.Body.String(), "<title>2 changes</title>")
+}
+
+func TestServer_State(t *testing.T) {
+	repo, handler := newTestServer(t)
+	ctx := t.Context()
+	march3 := time.Date(2026, time.March, 3, 12, 0, 0, 0, time.Local)
+
+	require.NoError(t, repo.RecordChanges(ctx, march3, &models.Changes{
+		Added: []models.Product{{Model: "A1", Category: "new", Price: "100"}},
+	}))
+	require.NoError(t, repo.RecordChanges(ctx, march3.AddDate(0, 0, 2), &models.Changes{
+		Changed: []models.ChangeInfo{{
+			Old: models.Product{Model: "A1", Category: "new", Price: "100"},
+			New: models.Product{Model: "A1", Category: "new", Price: "90"},
+		}},
+	}))
+	require.NoError(t, repo.UpdateState(ctx, &models.State{PageHash: "hash", Products: []models.Product{
+		{Model: "A1", Category: "new", Price: "90"},
+	}}))
+
+	get := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+
+		return recorder
+	}
+
+	csvState := get("/state.csv?at=2026-03-03")
+	require.Equal(t, http.StatusOK, csvState.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", csvState.Header().Get("Content-Type"))
+	assert.Equal(t, "category,model,type,price,quantity,image_url,url\nnew,A1,,100,,,\n", csvState.Body.String())
+
+	jsonState := get("/state.json?at=2026-03-06")
+	require.Equal(t, http.StatusOK, jsonState.Code)
+	var state struct {
+		Products []struct {
+			Price string `json:"price"`
+		} `json:"products"`
+	}
+	require.NoError(t, json.Unmarshal(jsonState.Body.Bytes(), &state))
+	require.Len(t, state.Products, 1)
+	assert.Equal(t, "90", state.Products[0].Price)
+
+	assert.Equal(t, http.StatusNotFound, get("/state.csv?at=2026-03-02").Code, "the history doesn't reach back")
+	assert.Equal(t, http.StatusBadRequest, get("/state.csv?at=yesterday").Code)
+}
+
+func TestServer_Diff(t *testing.T) {
+	repo, handler := newTestServer(t)
+	require.NoError(t, repo.RecordDiffArtifacts(t.Context(), []models.DiffArtifact{
+		{RunID: "run-1", Format: "md", CreatedAt: time.Now(), Content: []byte("# Changes")},
+		{RunID: "run-1", Format: "html", CreatedAt: time.Now(), Content: []byte("<h1>Changes</h1>")},
+	}))
+
+	get := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+
+		return recorder
+	}
+
+	markdown := get("/runs/run-1/diff.md")
+	require.Equal(t, http.StatusOK, markdown.Code)
+	assert.Equal(t, "text/markdown; charset=utf-8", markdown.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="diff-run-1.md"`, markdown.Header().Get("Content-Disposition"))
+	assert.Equal(t, "# Changes", markdown.Body.String())
+
+	page := get("/runs/run-1/diff.html")
+	require.Equal(t, http.StatusOK, page.Code)
+	assert.Empty(t, page.Header().Get("Content-Disposition"), "the page is shown in the browser")
+
+	assert.Equal(t, http.StatusNotFound, get("/runs/run-1/diff.csv").Code)
+	assert.Equal(t, http.StatusNotFound, get("/runs/run-2/diff.md").Code)
+}
+
+func TestServer_Calendar(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo, err := sqlite.NewRepository(t.Context(), logger, filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = repo.Close() })
+	ctx := t.Context()
+
+	server, err := api.NewServer(logger, repo,
+		api.WithAuthenticator(auth.NewAuthenticator(logger, repo)),
+		api.WithFilterGroups(map[string][]string{"divers": {"Diver"}}),
+	)
+	require.NoError(t, err)
+	handler := server.Handler()
+
+	require.NoError(t, repo.SetFilterGroup(ctx, 42, "divers"))
+	createToken := func(chatID int64) string {
+		secret, genErr := auth.GenerateToken()
+		require.NoError(t, genErr)
+		token := models.APIToken{Scopes: []string{auth.ScopeReadChanges}, ChatID: chatID}
+		_, genErr = repo.CreateToken(ctx, token, auth.HashToken(secret))
+		require.NoError(t, genErr)
+
+		return secret
+	}
+	chatToken, fullToken := createToken(42), createToken(0)
+
+	require.NoError(t, repo.RecordChanges(ctx, time.Now().Add(-time.Hour), &models.Changes{
+		Removed: []models.Product{
+			{Model: "A1", Type: "Diver", Price: "100"},
+			{Model: "B2", Type: "Dress", Price: "200"},
+		},
+		Changed: []models.ChangeInfo{{
+			Old: models.Product{Model: "C3", Type: "Diver", Price: "600"},
+			New: models.Product{Model: "C3", Type: "Diver", Price: "450"},
+		}},
+	}))
+
+	get := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+
+		return recorder
+	}
+
+	chat := get("/calendar.ics?below=500&token=" + chatToken)
+	require.Equal(t, http.StatusOK, chat.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", chat.Header().Get("Content-Type"))
+	assert.Contains(t, chat.Body.String(), "SUMMARY:A1 removed\\, was 100")
+	assert.Contains(t, chat.Body.String(), "SUMMARY:C3 below 500: 450")
+	assert.NotContains(t, chat.Body.String(), "B2", "the filter group of the chat applies")
+
+	full := get("/calendar.ics?token=" + fullToken)
+	require.Equal(t, http.StatusOK, full.Code)
+	assert.Contains(t, full.Body.String(), "B2")
+	assert.NotContains(t, full.Body.String(), "C3", "no price drops without a threshold")
+
+	assert.Equal(t, http.StatusBadRequest, get("/calendar.ics?below=cheap&token="+fullToken).Code)
+}
+
+func TestServer_Status(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo, err := sqlite.NewRepository(t.Context(), logger, filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = repo.Close() })
+	ctx := t.Context()
+
+	server, err := api.NewServer(logger, repo,
+		api.WithAuthenticator(auth.NewAuthenticator(logger, repo)),
+		api.WithCheckInterval(time.Hour),
+	)
+	require.NoError(t, err)
+	handler := server.Handler()
+
+	get := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(t, http.StatusOK, recorder.Code, "the status page needs no token")
+
+		return recorder
+	}
+
+	t.Run("no checks yet", func(t *testing.T) {
+		var status api.Status
+		require.NoError(t, json.Unmarshal(get("/status.json").Body.Bytes(), &status))
+
+		assert.False(t, status.Healthy)
+		assert.Nil(t, status.LastSuccess)
+		assert.Nil(t, status.NextCheck)
+		assert.Contains(t, get("/status").Body.String(), "chrono-flow is down")
+	})
+
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, repo.RecordFetch(ctx, models.FetchRecord{FetchedAt: now.Add(-2 * time.Hour), Error: "timeout"}))
+	require.NoError(t, repo.RecordFetch(ctx, models.FetchRecord{FetchedAt: now.Add(-30 * time.Minute), Success: true}))
+	require.NoError(t, repo.RecordChanges(ctx, now, &models.Changes{
+		Added: []models.Product{{Model: "SECRET-MODEL", Price: "100"}},
+	}))
+
+	t.Run("checked recently", func(t *testing.T) {
+		var status api.Status
+		require.NoError(t, json.Unmarshal(get("/status.json").Body.Bytes(), &status))
+
+		assert.True(t, status.Healthy)
+		require.NotNil(t, status.LastSuccess)
+		assert.True(t, now.Add(-30*time.Minute).Equal(*status.LastSuccess))
+		assert.InDelta(t, 50, status.UptimeDay, 0.01)
+		require.NotNil(t, status.NextCheck)
+		assert.True(t, now.Add(30*time.Minute).Equal(*status.NextCheck), "the next check is an interval after the last")
+
+		page := get("/status")
+		assert.Equal(t, "text/html; charset=utf-8", page.Header().Get("Content-Type"))
+		assert.Contains(t, page.Body.String(), "chrono-flow is up")
+		assert.Contains(t, page.Body.String(), "50.0%")
+		assert.NotContains(t, page.Body.String(), "SECRET-MODEL", "no product data is exposed")
+	})
+
+	require.NoError(t, repo.RecordCheckError(ctx, "fetch", "dial tcp 10.0.0.1:443: i/o timeout", now.Add(-time.Hour)))
+	require.NoError(t, repo.RecordCheckError(ctx, "fetch", "dial tcp 10.0.0.1:443: i/o timeout", now))
+
+	t.Run("failing", func(t *testing.T) {
+		var status api.Status
+		require.NoError(t, json.Unmarshal(get("/status.json").Body.Bytes(), &status))
+
+		require.NotNil(t, status.LastError)
+		assert.Equal(t, "fetch", status.LastError.Class)
+		assert.Equal(t, 2, status.LastError.Failures)
+		require.NotNil(t, status.LastError.FailingSince)
+		assert.True(t, now.Add(-time.Hour).Equal(*status.LastError.FailingSince))
+
+		page := get("/status").Body.String()
+		assert.Contains(t, page, "fetch error since "+now.Add(-time.Hour).Format("2006-01-02 15:04:05 MST")+
+			", 2 checks in a row")
+		assert.NotContains(t, page, "10.0.0.1", "the message of the error isn't exposed")
+	})
+
+	require.NoError(t, repo.ResetCheckErrors(ctx))
+
+	t.Run("recovered", func(t *testing.T) {
+		var status api.Status
+		require.NoError(t, json.Unmarshal(get("/status.json").Body.Bytes(), &status))
+
+		require.NotNil(t, status.LastError)
+		assert.Nil(t, status.LastError.FailingSince)
+		assert.True(t, now.Equal(status.LastError.LastFailure))
+		assert.Contains(t, get("/status").Body.String(), "recovered since")
+	})
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Houeta/chrono-flow/internal/auth"
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/repository"
+)
+
+// diffHandler serves the full diff of the run of the path in the format, e.g. /runs/{run}/diff.html,
+// as it was rendered when the changes were detected. The diffs are pruned with the history. It needs
+// the read:changes scope. The diffs list every product type, so a token created for a chat with a
+// filter group is refused.
+func (s *Server) diffHandler(format string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if err := auth.Require(ctx, auth.ScopeReadChanges); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		types, err := s.chatTypes(ctx)
+		if err != nil {
+			s.log.ErrorContext(ctx, "failed to get the chat settings of the token", "error", err)
+			http.Error(w, "failed to get chat settings", http.StatusInternalServerError)
+			return
+		}
+		if len(types) > 0 {
+			http.Error(w, "the full diff isn't filtered by the product types of the chat", http.StatusForbidden)
+			return
+		}
+
+		runID := r.PathValue("run")
+		artifact, err := s.repo.GetDiffArtifact(ctx, runID, format)
+		if errors.Is(err, repository.ErrArtifactNotFound) {
+			http.Error(w, "no diff of run "+runID, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			s.log.ErrorContext(ctx, "failed to get the diff", "run_id", runID, "error", err)
+			http.Error(w, "failed to get the diff", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", export.DiffContentType(format))
+		if format != export.DiffHTML {
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="diff-%s.%s"`, runID, format))
+		}
+		if _, err = w.Write(artifact.Content); err != nil {
+			s.log.ErrorContext(ctx, "failed to write the diff", "error", err)
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/auth"
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+const (
+	// feedPeriod is how far back the feeds reach.
+	feedPeriod = 7 * 24 * time.Hour
+	// maxFeedEntries limits the number of changes a feed lists.
+	maxFeedEntries = 200
+	// calendarPeriod is how far back the calendar reaches, a removal is needed to tell a product is back
+	// in stock.
+	calendarPeriod = 30 * 24 * time.Hour
+)
+
+// feedHandler serves the changes of the last week, newest first, in the format. The entries are
+// product changes, or checks with ?group=run. It needs the read:changes scope.
+func (s *Server) feedHandler(format string) http.Handler {
+	contentTypes := map[string]string{
+		export.FeedAtom: "application/atom+xml; charset=utf-8",
+		export.FeedRSS:  "application/rss+xml; charset=utf-8",
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		records, ok := s.feedChanges(w, r, time.Now().Add(-feedPeriod))
+		if !ok {
+			return
+		}
+		slices.Reverse(records)
+		if len(records) > maxFeedEntries {
+			records = records[:maxFeedEntries]
+		}
+
+		feed := export.Feed{
+			Title:  "chrono-flow: " + s.feedLink,
+			Link:   s.feedLink,
+			PerRun: r.URL.Query().Get("group") == "run",
+		}
+		w.Header().Set("Content-Type", contentTypes[format])
+		if err := export.WriteFeed(w, format, feed, records); err != nil {
+			s.log.ErrorContext(ctx, "failed to write the feed", "error", err)
+		}
+	})
+}
+
+// calendarHandler serves the notable changes of the last month as an iCalendar feed, see
+// export.CalendarEvents. Prices dropping below the ?below= threshold are included. It needs the
+// read:changes scope.
+func (s *Server) calendarHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		var threshold float64
+		if raw := r.URL.Query().Get("below"); raw != "" {
+			var err error
+			if threshold, err = strconv.ParseFloat(raw, 64); err != nil {
+				http.Error(w, fmt.Sprintf("invalid price threshold %q", raw), http.StatusBadRequest)
+				return
+			}
+		}
+
+		records, ok := s.feedChanges(w, r, time.Now().Add(-calendarPeriod))
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		events := export.CalendarEvents(records, threshold)
+		if err := export.WriteCalendar(w, "chrono-flow: "+s.feedLink, events); err != nil {
+			s.log.ErrorContext(ctx, "failed to write the calendar", "error", err)
+		}
+	})
+}
+
+// feedChanges returns the changes detected since the given time, oldest first, filtered by the chat
+// of the request token. It writes the error response and returns false if they can't be read.
+func (s *Server) feedChanges(w http.ResponseWriter, r *http.Request, since time.Time) ([]models.ChangeRecord, bool) {
+	ctx := r.Context()
+	if err := auth.Require(ctx, auth.ScopeReadChanges); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return nil, false
+	}
+
+	types, err := s.chatTypes(ctx)
+	if err != nil {
+		s.log.ErrorContext(ctx, "failed to get the chat settings of the token", "error", err)
+		http.Error(w, "failed to get chat settings", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	records, err := s.repo.GetChanges(ctx, since)
+	if err != nil {
+		s.log.ErrorContext(ctx, "failed to get changes for the feed", "error", err)
+		http.Error(w, "failed to get changes", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return filterByTypes(records, types), true
+}
+
+// chatTypes returns the product types of the filter group of the chat the request token was created
+// for, nil if the feed isn't filtered.
+func (s *Server) chatTypes(ctx context.Context) ([]string, error) {
+	token, ok := auth.TokenFromContext(ctx)
+	if !ok || token.ChatID == 0 {
+		return nil, nil
+	}
+
+	settings, err := s.repo.GetChatSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat settings: %w", err)
+	}
+
+	return s.filterGroups[settings[token.ChatID].FilterGroup], nil
+}
+
+// filterByTypes returns the records of products with one of the types (case-insensitive), all
+// records if there are no types.
+func filterByTypes(records []models.ChangeRecord, types []string) []models.ChangeRecord {
+	if len(types) == 0 {
+		return records
+	}
+
+	filtered := make([]models.ChangeRecord, 0, len(records))
+	for _, record := range records {
+		if slices.ContainsFunc(types, func(t string) bool { return strings.EqualFold(t, record.Type) }) {
+			filtered = append(filtered, record)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,548 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/auth"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/graph-gophers/graphql-go"
+)
+
+// resolver resolves the root query and mutation fields.
+type resolver struct {
+	repo      Repository
+	log       *slog.Logger
+	logLevels LogLevels
+}
+
+// currentProducts returns the current products, an empty list if the page wasn't parsed yet.
+func (r *resolver) currentProducts(ctx context.Context) ([]models.Product, error) {
+	if err := auth.Require(ctx, auth.ScopeReadProducts); err != nil {
+		return nil, err //nolint:wrapcheck // the scope error is reported to the client as is.
+	}
+
+	state, err := r.repo.GetState(ctx)
+	if errors.Is(err, repository.ErrStateNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	return state.Products, nil
+}
+
+type productsArgs struct {
+	Category *string
+	Type     *string
+	MinPrice *float64
+	MaxPrice *float64
+	InStock  *bool
+	Extras   *[]extraFieldInput
+	Sort     *string
+	Offset   *int32
+	Limit    *int32
+}
+
+// filter returns the product filter of the arguments.
+func (a productsArgs) filter() models.ProductFilter {
+	var filter models.ProductFilter
+	if a.Category != nil {
+		filter.Category = *a.Category
+	}
+	if a.Type != nil {
+		filter.Type = *a.Type
+	}
+	filter.MinPrice, filter.MaxPrice = a.MinPrice, a.MaxPrice
+	filter.InStock = a.InStock != nil && *a.InStock
+	if a.Extras != nil {
+		filter.Extras = make(map[string]string, len(*a.Extras))
+		for _, field := range *a.Extras {
+			filter.Extras[field.Name] = field.Value
+		}
+	}
+
+	return filter
+}
+
+// Products resolves the products query.
+func (r *resolver) Products(ctx context.Context, args productsArgs) ([]*productResolver, error) {
+	if err := auth.Require(ctx, auth.ScopeReadProducts); err != nil {
+		return nil, err //nolint:wrapcheck // the scope error is reported to the client as is.
+	}
+
+	var name string
+	if args.Sort != nil {
+		name = *args.Sort
+	}
+	sort, err := models.ParseProductSort(name)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the sort error is reported to the client as is.
+	}
+
+	var offset, limit int
+	if args.Offset != nil {
+		offset = int(*args.Offset)
+	}
+	if args.Limit != nil {
+		limit = int(*args.Limit)
+	}
+
+	page, err := r.repo.ListProducts(ctx, args.filter(), sort, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	result := make([]*productResolver, 0, len(page.Products))
+	for _, product := range page.Products {
+		result = append(result, &productResolver{repo: r.repo, product: product})
+	}
+
+	return result, nil
+}
+
+type productArgs struct {
+	Model    string
+	Category *string
+}
+
+// Product resolves the product query.
+func (r *resolver) Product(ctx context.Context, args productArgs) (*productResolver, error) {
+	products, err := r.currentProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, product := range products {
+		if product.Model == args.Model && (args.Category == nil || product.Category == *args.Category) {
+			return &productResolver{repo: r.repo, product: product}, nil
+		}
+	}
+
+	return nil, nil //nolint:nilnil // A missing product resolves to null.
+}
+
+type sinceArgs struct {
+	Since graphql.Time
+}
+
+// Changes resolves the changes query.
+func (r *resolver) Changes(ctx context.Context, args sinceArgs) ([]*changeResolver, error) {
+	if err := auth.Require(ctx, auth.ScopeReadChanges); err != nil {
+		return nil, err //nolint:wrapcheck // the scope error is reported to the client as is.
+	}
+
+	records, err := r.repo.GetChanges(ctx, args.Since.Time)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changes: %w", err)
+	}
+
+	return changeResolvers(records), nil
+}
+
+// Runs resolves the runs query.
+func (r *resolver) Runs(ctx context.Context, args sinceArgs) ([]*runResolver, error) {
+	if err := auth.Require(ctx, auth.ScopeReadChanges); err != nil {
+		return nil, err //nolint:wrapcheck // the scope error is reported to the client as is.
+	}
+
+	records, err := r.repo.GetFetches(ctx, args.Since.Time)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get runs: %w", err)
+	}
+
+	result := make([]*runResolver, 0, len(records))
+	for _, record := range records {
+		result = append(result, &runResolver{record: record})
+	}
+
+	return result, nil
+}
+
+// Targets resolves the targets query.
+func (r *resolver) Targets(ctx context.Context) ([]*targetResolver, error) {
+	if err := auth.Require(ctx, auth.ScopeAdmin); err != nil {
+		return nil, err //nolint:wrapcheck // the scope error is reported to the client as is.
+	}
+
+	statuses, err := r.repo.GetTargetStatuses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get targets: %w", err)
+	}
+
+	result := make([]*targetResolver, 0, len(statuses))
+	for _, status := range statuses {
+		result = append(result, &targetResolver{target: status.Target, lastError: status.LastError})
+	}
+
+	return result, nil
+}
+
+// LogLevels resolves the logLevels query.
+func (r *resolver) LogLevels(ctx context.Context) ([]*logLevelResolver, error) {
+	if err := auth.Require(ctx, auth.ScopeAdmin); err != nil {
+		return nil, err //nolint:wrapcheck // the scope error is reported to the client as is.
+	}
+	if r.logLevels == nil {
+		return nil, ErrLogLevelsUnavailable
+	}
+
+	levels := r.logLevels.All()
+	result := make([]*logLevelResolver, 0, len(levels))
+	for _, component := range slices.Sorted(maps.Keys(levels)) {
+		result = append(result, &logLevelResolver{component: component, level: levels[component]})
+	}
+
+	return result, nil
+}
+
+// SubscriberStats resolves the subscriberStats query.
+func (r *resolver) SubscriberStats(ctx context.Context, args sinceArgs) ([]*subscriberDayResolver, error) {
+	if err := auth.Require(ctx, auth.ScopeAdmin); err != nil {
+		return nil, err //nolint:wrapcheck // the scope error is reported to the client as is.
+	}
+
+	days, err := r.repo.GetSubscriberStats(ctx, args.Since.Time)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscriber statistics: %w", err)
+	}
+
+	result := make([]*subscriberDayResolver, 0, len(days))
+	for _, day := range days {
+		result = append(result, &subscriberDayResolver{day: day})
+	}
+
+	return result, nil
+}
+
+// PageDiffs resolves the pageDiffs query.
+func (r *resolver) PageDiffs(ctx context.Context) ([]*pageDiffResolver, error) {
+	if err := auth.Require(ctx, auth.ScopeAdmin); err != nil {
+		return nil, err //nolint:wrapcheck // the scope error is reported to the client as is.
+	}
+
+	diffs, err := r.repo.GetPageDiffs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page diffs: %w", err)
+	}
+
+	result := make([]*pageDiffResolver, 0, len(diffs))
+	for _, diff := range diffs {
+		result = append(result, &pageDiffResolver{diff: diff})
+	}
+
+	return result, nil
+}
+
+type setLogLevelArgs struct {
+	Component string
+	Level     string
+}
+
+// SetLogLevel resolves the setLogLevel mutation.
+func (r *resolver) SetLogLevel(ctx context.Context, args setLogLevelArgs) (*logLevelResolver, error) {
+	if err := auth.Require(ctx, auth.ScopeAdmin); err != nil {
+		return nil, err //nolint:wrapcheck // the scope error is reported to the client as is.
+	}
+	if r.logLevels == nil {
+		return nil, ErrLogLevelsUnavailable
+	}
+
+	var err error
+	if strings.EqualFold(args.Level, "reset") {
+		err = r.logLevels.Reset(args.Component)
+	} else {
+		var level slog.Level
+		if err = level.UnmarshalText([]byte(args.Level)); err != nil {
+			return nil, fmt.Errorf("invalid level: %w", err)
+		}
+		err = r.logLevels.Set(args.Component, level)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to change log level: %w", err)
+	}
+
+	level := r.logLevels.All()[args.Component]
+	r.log.WarnContext(ctx, "Log level changed over the API", "logComponent", args.Component, "level", level)
+
+	return &logLevelResolver{component: args.Component, level: level}, nil
+}
+
+type forgetChatArgs struct {
+	ChatID graphql.ID
+}
+
+// ForgetChat resolves the forgetChat mutation.
+func (r *resolver) ForgetChat(ctx context.Context, args forgetChatArgs) ([]*forgottenDataResolver, error) {
+	if err := auth.Require(ctx, auth.ScopeAdmin); err != nil {
+		return nil, err //nolint:wrapcheck // the scope error is reported to the client as is.
+	}
+
+	chatID, err := strconv.ParseInt(string(args.ChatID), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chat ID: %w", err)
+	}
+	forgotten, err := r.repo.ForgetChat(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to forget chat: %w", err)
+	}
+	r.log.WarnContext(ctx, "Chat data deleted over the API", "chatID", chatID)
+
+	result := make([]*forgottenDataResolver, 0, len(forgotten))
+	for _, data := range forgotten {
+		result = append(result, &forgottenDataResolver{data: data})
+	}
+
+	return result, nil
+}
+
+// forgottenDataResolver resolves the fields of a kind of data deleted about a chat.
+type forgottenDataResolver struct {
+	data models.ForgottenData
+}
+
+func (f *forgottenDataResolver) Kind() string   { return f.data.Kind }
+func (f *forgottenDataResolver) Deleted() int32 { return count32(f.data.Deleted) }
+
+// logLevelResolver resolves the fields of a log level.
+type logLevelResolver struct {
+	component string
+	level     slog.Level
+}
+
+func (l *logLevelResolver) Component() string { return l.component }
+func (l *logLevelResolver) Level() string     { return l.level.String() }
+
+// subscriberDayResolver resolves the fields of the subscriber statistics of a day.
+type subscriberDayResolver struct {
+	day models.SubscriberDay
+}
+
+func (s *subscriberDayResolver) Day() graphql.Time   { return graphql.Time{Time: s.day.Day} }
+func (s *subscriberDayResolver) Subscribers() int32  { return count32(s.day.Subscribers) }
+func (s *subscriberDayResolver) Subscribed() int32   { return count32(s.day.Subscribed) }
+func (s *subscriberDayResolver) Unsubscribed() int32 { return count32(s.day.Unsubscribed) }
+func (s *subscriberDayResolver) ActiveChats() int32  { return count32(s.day.ActiveChats) }
+
+// count32 converts a count to a GraphQL Int.
+func count32(count int) int32 {
+	return int32(min(count, math.MaxInt32))
+}
+
+// productResolver resolves the fields of a product.
+type productResolver struct {
+	repo    Repository
+	product models.Product
+}
+
+func (p *productResolver) Model() string      { return p.product.Model }
+func (p *productResolver) Category() string   { return p.product.Category }
+func (p *productResolver) Type() string       { return p.product.Type }
+func (p *productResolver) Quantity() string   { return p.product.Quantity }
+func (p *productResolver) Price() string      { return p.product.Price }
+func (p *productResolver) ImageURL() string   { return p.product.ImageURL }
+func (p *productResolver) ProductURL() string { return p.product.ProductURL }
+
+// Extras returns the extra and computed fields of the product ordered by name.
+func (p *productResolver) Extras() []*extraFieldResolver {
+	fields := make([]*extraFieldResolver, 0, len(p.product.Extras))
+	for _, name := range slices.Sorted(maps.Keys(p.product.Extras)) {
+		fields = append(fields, &extraFieldResolver{name: name, value: p.product.Extras[name]})
+	}
+
+	return fields
+}
+
+// extraFieldInput is a value of an extra or computed field the products are filtered by.
+type extraFieldInput struct {
+	Name  string
+	Value string
+}
+
+type extraFieldResolver struct {
+	name, value string
+}
+
+func (f *extraFieldResolver) Name() string  { return f.name }
+func (f *extraFieldResolver) Value() string { return f.value }
+
+// History returns every recorded change of the product.
+func (p *productResolver) History(ctx context.Context) ([]*changeResolver, error) {
+	if err := auth.Require(ctx, auth.ScopeReadChanges); err != nil {
+		return nil, err //nolint:wrapcheck // the scope error is reported to the client as is.
+	}
+
+	records, err := p.repo.GetProductHistory(ctx, p.product.Category, p.product.Model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product history: %w", err)
+	}
+
+	return changeResolvers(records), nil
+}
+
+// PriceHistory returns the prices the product had. Consecutive records with the same price, like
+// quantity-only changes, are collapsed into a single point.
+func (p *productResolver) PriceHistory(ctx context.Context) ([]*pricePointResolver, error) {
+	if err := auth.Require(ctx, auth.ScopeReadChanges); err != nil {
+		return nil, err //nolint:wrapcheck // the scope error is reported to the client as is.
+	}
+
+	records, err := p.repo.GetProductHistory(ctx, p.product.Category, p.product.Model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product history: %w", err)
+	}
+
+	var points []*pricePointResolver
+	for _, record := range records {
+		if record.Kind == models.KindRemoved {
+			continue
+		}
+		if len(points) > 0 && points[len(points)-1].price == record.Price {
+			continue
+		}
+		points = append(points, &pricePointResolver{at: record.DetectedAt, price: record.Price})
+	}
+
+	return points, nil
+}
+
+// pricePointResolver resolves a price the product had since the given time.
+type pricePointResolver struct {
+	at    time.Time
+	price string
+}
+
+func (p *pricePointResolver) At() graphql.Time { return graphql.Time{Time: p.at} }
+func (p *pricePointResolver) Price() string    { return p.price }
+
+// changeResolver resolves the fields of a recorded change.
+type changeResolver struct {
+	record models.ChangeRecord
+}
+
+// changeResolvers wraps the change records into resolvers.
+func changeResolvers(records []models.ChangeRecord) []*changeResolver {
+	result := make([]*changeResolver, 0, len(records))
+	for _, record := range records {
+		result = append(result, &changeResolver{record: record})
+	}
+
+	return result
+}
+
+func (c *changeResolver) DetectedAt() graphql.Time { return graphql.Time{Time: c.record.DetectedAt} }
+func (c *changeResolver) Kind() string             { return c.record.Kind }
+func (c *changeResolver) Model() string            { return c.record.Model }
+func (c *changeResolver) OldModel() string         { return c.record.OldModel }
+func (c *changeResolver) Category() string         { return c.record.Category }
+func (c *changeResolver) Type() string             { return c.record.Type }
+func (c *changeResolver) OldPrice() string         { return c.record.OldPrice }
+func (c *changeResolver) Price() string            { return c.record.Price }
+func (c *changeResolver) OldQuantity() string      { return c.record.OldQuantity }
+func (c *changeResolver) Quantity() string         { return c.record.Quantity }
+func (c *changeResolver) RunID() string            { return c.record.RunID }
+func (c *changeResolver) Note() string             { return c.record.Note }
+
+// runResolver resolves the fields of a fetch of the target page.
+type runResolver struct {
+	record models.FetchRecord
+}
+
+func (r *runResolver) FetchedAt() graphql.Time { return graphql.Time{Time: r.record.FetchedAt} }
+func (r *runResolver) Success() bool           { return r.record.Success }
+func (r *runResolver) Error() string           { return r.record.Error }
+
+// LatencyMs returns the fetch latency in milliseconds, GraphQL integers are 32-bit.
+func (r *runResolver) LatencyMs() int32 {
+	return int32(min(r.record.Latency.Milliseconds(), math.MaxInt32))
+}
+
+// pageDiffResolver resolves the fields of a diff of the pages of two runs.
+type pageDiffResolver struct {
+	diff models.PageDiff
+}
+
+func (d *pageDiffResolver) RunID() string   { return d.diff.RunID }
+func (d *pageDiffResolver) OldHash() string { return d.diff.OldHash }
+func (d *pageDiffResolver) NewHash() string { return d.diff.NewHash }
+func (d *pageDiffResolver) Diff() string    { return d.diff.Diff }
+func (d *pageDiffResolver) CheckedAt() graphql.Time {
+	return graphql.Time{Time: d.diff.CheckedAt}
+}
+
+// targetResolver resolves the fields of a monitored page.
+type targetResolver struct {
+	target    models.Target
+	lastError *models.CheckError
+}
+
+func (t *targetResolver) Name() string           { return t.target.Name }
+func (t *targetResolver) URL() string            { return t.target.URL }
+func (t *targetResolver) IframeSelector() string { return t.target.IframeSelector }
+func (t *targetResolver) Enabled() bool          { return !t.target.Disabled }
+func (t *targetResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: t.target.CreatedAt}
+}
+
+// Tables returns the product tables of the target.
+func (t *targetResolver) Tables() []string {
+	tables := make([]string, 0, len(t.target.Tables))
+	for _, table := range t.target.Tables {
+		if table.Name == "" {
+			tables = append(tables, table.Selector)
+			continue
+		}
+		tables = append(tables, table.Name+"="+table.Selector)
+	}
+
+	return tables
+}
+
+// IntervalSeconds returns the check interval of the target in seconds.
+func (t *targetResolver) IntervalSeconds() int32 {
+	return int32(min(t.target.Interval.Seconds(), math.MaxInt32))
+}
+
+// LastError returns the last failed check of the target, nil if none failed yet.
+func (t *targetResolver) LastError() *checkErrorResolver {
+	if t.lastError == nil {
+		return nil
+	}
+
+	return &checkErrorResolver{checkErr: *t.lastError}
+}
+
+// checkErrorResolver resolves the fields of the last failed check of a target.
+type checkErrorResolver struct {
+	checkErr models.CheckError
+}
+
+func (c *checkErrorResolver) Class() string   { return c.checkErr.Class }
+func (c *checkErrorResolver) Message() string { return c.checkErr.Message }
+func (c *checkErrorResolver) LastFailure() graphql.Time {
+	return graphql.Time{Time: c.checkErr.LastFailure}
+}
+
+// FailingSince returns when the failed checks in a row started, nil once a check succeeded again.
+func (c *checkErrorResolver) FailingSince() *graphql.Time {
+	if !c.checkErr.Failing() {
+		return nil
+	}
+
+	return &graphql.Time{Time: c.checkErr.FailingSince}
+}
+
+// Failures returns the number of checks failed in a row, GraphQL integers are 32-bit.
+func (c *checkErrorResolver) Failures() int32 {
+	return int32(min(c.checkErr.Failures, math.MaxInt32))
+}
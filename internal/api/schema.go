@@ -0,0 +1,167 @@
+package api
+
+// schema describes the GraphQL API. Products are the current state of the target page, changes
+// and runs come from the history kept for the configured retention period.
+const schema = `
+schema {
+	query: Query
+	mutation: Mutation
+}
+
+scalar Time
+
+type Query {
+	# Current products matching the filters. Products are sorted by "model" (category and model, the default),
+	# "type", "price" or "-price" (the most expensive first), the ones without a numeric price come last.
+	# A page of products starts at the offset and holds up to limit products, all of them without a limit.
+	# The extras filter matches the values of the extra and computed fields.
+	products(
+		category: String
+		type: String
+		minPrice: Float
+		maxPrice: Float
+		inStock: Boolean
+		extras: [ExtraFieldInput!]
+		sort: String
+		offset: Int
+		limit: Int
+	): [Product!]!
+	# A current product by model, the category may be omitted if the model is unique.
+	product(model: String!, category: String): Product
+	# Changes detected since the given time, oldest first.
+	changes(since: Time!): [Change!]!
+	# Fetches of the target page made since the given time, oldest first.
+	runs(since: Time!): [Run!]!
+	# Pages monitored by the tenant, ordered by name.
+	targets: [Target!]!
+	# Current log level of every component, ordered by component.
+	logLevels: [LogLevel!]!
+	# Subscribers and active chats of every UTC day since the given time, oldest first.
+	subscriberStats(since: Time!): [SubscriberDay!]!
+	# Diffs of the pages that changed without a product changing, newest first, kept for the last
+	# CF_PAGE_DIFF_RUNS such runs.
+	pageDiffs: [PageDiff!]!
+}
+
+type Mutation {
+	# Changes the log level of a component until the restart, the "reset" level restores the configured one.
+	setLogLevel(component: String!, level: String!): LogLevel!
+	# Deletes everything stored about a chat, like the /forgetme command of the bot does.
+	forgetChat(chatId: ID!): [ForgottenData!]!
+}
+
+type ForgottenData {
+	# The kind of data, e.g. "subscription" or "wishlist items".
+	kind: String!
+	# The number of records deleted.
+	deleted: Int!
+}
+
+type Product {
+	model: String!
+	category: String!
+	type: String!
+	quantity: String!
+	price: String!
+	imageUrl: String!
+	productUrl: String!
+	# The extra and computed fields of the product, ordered by name.
+	extras: [ExtraField!]!
+	# Every recorded change of the product, oldest first.
+	history: [Change!]!
+	# The prices the product had, oldest first.
+	priceHistory: [PricePoint!]!
+}
+
+type ExtraField {
+	name: String!
+	value: String!
+}
+
+input ExtraFieldInput {
+	name: String!
+	value: String!
+}
+
+type PricePoint {
+	at: Time!
+	price: String!
+}
+
+type Change {
+	detectedAt: Time!
+	kind: String!
+	model: String!
+	oldModel: String!
+	category: String!
+	type: String!
+	oldPrice: String!
+	price: String!
+	oldQuantity: String!
+	quantity: String!
+	# The check run that detected the change, empty for changes recorded before the runs were.
+	runId: String!
+	# The context an admin attached to the change with /annotate, empty if none.
+	note: String!
+}
+
+type Target {
+	name: String!
+	url: String!
+	# Product tables as "name=selector", the selector alone for a single unnamed table.
+	tables: [String!]!
+	iframeSelector: String!
+	intervalSeconds: Int!
+	# Disabled targets are kept but not checked.
+	enabled: Boolean!
+	createdAt: Time!
+	# The last failed check of the target, null if none failed yet.
+	lastError: CheckError
+}
+
+type CheckError {
+	# The kind of failure: fetch, parse, state or other.
+	class: String!
+	message: String!
+	# When the first of the failed checks in a row happened, null once a check succeeded again.
+	failingSince: Time
+	lastFailure: Time!
+	# The checks failed in a row, 0 once a check succeeded again.
+	failures: Int!
+}
+
+type LogLevel {
+	# The bot, parser or repository, "default" for the rest of the application.
+	component: String!
+	# DEBUG, INFO, WARN or ERROR, optionally with an offset, e.g. DEBUG-4.
+	level: String!
+}
+
+type SubscriberDay {
+	# The start of the UTC day.
+	day: Time!
+	# Chats subscribed at the end of the day.
+	subscribers: Int!
+	subscribed: Int!
+	unsubscribed: Int!
+	# Chats that used the bot on the day.
+	activeChats: Int!
+}
+
+type PageDiff {
+	runId: String!
+	checkedAt: Time!
+	# The fingerprints of the previous and the current page.
+	oldHash: String!
+	newHash: String!
+	# The unified diff of the pages, split into lines between adjacent tags.
+	diff: String!
+}
+
+type Run {
+	fetchedAt: Time!
+	latencyMs: Int!
+	success: Boolean!
+	error: String!
+}
+`
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/auth"
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+)
+
+// State formats served by stateHandler.
+const (
+	stateCSV  = "csv"
+	stateJSON = "json"
+)
+
+// stateHandler serves the products the catalog listed at the time of ?at=, see export.ParseStateTime,
+// in the format: a date is the end of the day, e.g. /state.csv?at=2026-03-03. The state is
+// reconstructed from the change history, so it reaches back as far as the history does. It needs the
+// read:changes scope, a token created for a chat only gets the product types of its filter group.
+func (s *Server) stateHandler(format string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if err := auth.Require(ctx, auth.ScopeReadChanges); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		at, err := export.ParseStateTime(r.URL.Query().Get("at"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		types, err := s.chatTypes(ctx)
+		if err != nil {
+			s.log.ErrorContext(ctx, "failed to get the chat settings of the token", "error", err)
+			http.Error(w, "failed to get chat settings", http.StatusInternalServerError)
+			return
+		}
+
+		products, err := s.repo.GetStateAt(ctx, at)
+		if errors.Is(err, repository.ErrStateNotFound) {
+			http.Error(w, "no history at "+at.Format(time.RFC3339), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			s.log.ErrorContext(ctx, "failed to get the state", "at", at, "error", err)
+			http.Error(w, "failed to get the state", http.StatusInternalServerError)
+			return
+		}
+		products = filterProductsByTypes(products, types)
+
+		if format == stateCSV {
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Header().Set("Content-Disposition", `attachment; filename="state-`+at.Format(time.DateOnly)+`.csv"`)
+			err = export.StateCSV(w, products)
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+			err = export.StateJSON(w, at, products)
+		}
+		if err != nil {
+			s.log.ErrorContext(ctx, "failed to write the state", "error", err)
+		}
+	})
+}
+
+// filterProductsByTypes returns the products with one of the types (case-insensitive), all products if
+// there are no types.
+func filterProductsByTypes(products []models.Product, types []string) []models.Product {
+	if len(types) == 0 {
+		return products
+	}
+
+	filtered := make([]models.Product, 0, len(products))
+	for _, p := range products {
+		if slices.ContainsFunc(types, func(t string) bool { return strings.EqualFold(t, p.Type) }) {
+			filtered = append(filtered, p)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+)
+
+// statusPage renders the public status page, see statusHandler.
+var statusPage = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>chrono-flow status</title>
+</head>
+<body>
+<h1>chrono-flow is {{if .Healthy}}up{{else}}down{{end}}</h1>
+<dl>
+<dt>Last successful check</dt>
+<dd>{{with .LastSuccess}}{{.Format "2006-01-02 15:04:05 MST"}}{{else}}never{{end}}</dd>
+<dt>Uptime (24 hours)</dt>
+<dd>{{printf "%.1f" .UptimeDay}}%</dd>
+<dt>Uptime (7 days)</dt>
+<dd>{{printf "%.1f" .UptimeWeek}}%</dd>
+<dt>Next scheduled check</dt>
+<dd>{{with .NextCheck}}{{.Format "2006-01-02 15:04:05 MST"}}{{else}}unknown{{end}}</dd>
+{{with .LastError}}<dt>Last error</dt>
+<dd>{{.Class}} error
+{{- if .FailingSince}} since {{.FailingSince.Format "2006-01-02 15:04:05 MST"}}, {{.Failures}} checks in a row
+{{- else}} at {{.LastFailure.Format "2006-01-02 15:04:05 MST"}}, recovered since{{end}}</dd>
+{{end}}</dl>
+</body>
+</html>
+`))
+
+// Status summarizes the health of the monitor without exposing any product data.
+type Status struct {
+	// Healthy is set if the last check succeeded no longer than two check intervals ago, or if a
+	// check succeeded within the last day when the interval isn't known.
+	Healthy bool `json:"healthy"`
+	// LastSuccess is the time of the last successful check, nil if there was none.
+	LastSuccess *time.Time `json:"lastSuccess"`
+	// UptimeDay and UptimeWeek are the shares of successful checks in percent.
+	UptimeDay  float64 `json:"uptimeDay"`
+	UptimeWeek float64 `json:"uptimeWeek"`
+	// NextCheck is when the next check is due, nil if it isn't known.
+	NextCheck *time.Time `json:"nextCheck"`
+	// LastError is the last failed check, nil if none failed yet.
+	LastError *StatusError `json:"lastError"`
+}
+
+// StatusError is the last failed check on the status page. Its message is left out, as it may name the
+// target page or the hosts it resolves to, the admins get it from the targets query.
+type StatusError struct {
+	// Class is the kind of failure, e.g. "fetch" or "parse".
+	Class string `json:"class"`
+	// FailingSince is when the first of the failed checks in a row happened, nil once a check succeeded
+	// again.
+	FailingSince *time.Time `json:"failingSince"`
+	LastFailure  time.Time  `json:"lastFailure"`
+	// Failures is the number of checks failed in a row.
+	Failures int `json:"failures"`
+}
+
+// statusHandler serves the public status page, as HTML or with asJSON as JSON. It needs no token, so
+// stakeholders can tell the monitor is alive.
+func (s *Server) statusHandler(asJSON bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		status, err := s.status(ctx, time.Now())
+		if err != nil {
+			s.log.ErrorContext(ctx, "failed to get the status", "error", err)
+			http.Error(w, "failed to get status", http.StatusInternalServerError)
+			return
+		}
+
+		if asJSON {
+			w.Header().Set("Content-Type", "application/json")
+			err = json.NewEncoder(w).Encode(status)
+		} else {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			err = statusPage.Execute(w, status)
+		}
+		if err != nil {
+			s.log.ErrorContext(ctx, "failed to write the status", "error", err)
+		}
+	})
+}
+
+// status returns the health of the monitor at the given time. The next check is due an interval after
+// the last fetch, or right away if the checker is late.
+func (s *Server) status(ctx context.Context, now time.Time) (*Status, error) {
+	const (
+		day  = 24 * time.Hour
+		week = 7 * day
+	)
+
+	dayStats, err := s.repo.GetUptimeStats(ctx, now.Add(-day))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get uptime of the day: %w", err)
+	}
+	weekStats, err := s.repo.GetUptimeStats(ctx, now.Add(-week))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get uptime of the week: %w", err)
+	}
+
+	status := &Status{UptimeDay: dayStats.Uptime(), UptimeWeek: weekStats.Uptime()}
+	healthyWithin := day
+	if s.checkInterval > 0 {
+		healthyWithin = 2 * s.checkInterval //nolint:mnd // A single failed check doesn't make it down.
+	}
+	if lastSuccess := dayStats.LastSuccess; !lastSuccess.IsZero() {
+		status.LastSuccess = &lastSuccess
+		status.Healthy = now.Sub(lastSuccess) <= healthyWithin
+	}
+
+	if s.checkInterval > 0 {
+		fetches, fetchErr := s.repo.GetFetches(ctx, now.Add(-day))
+		if fetchErr != nil {
+			return nil, fmt.Errorf("failed to get fetches: %w", fetchErr)
+		}
+		if len(fetches) > 0 {
+			next := fetches[len(fetches)-1].FetchedAt.Add(s.checkInterval)
+			if next.Before(now) {
+				next = now
+			}
+			status.NextCheck = &next
+		}
+	}
+
+	checkErr, err := s.repo.GetCheckError(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the last check error: %w", err)
+	}
+	if checkErr != nil {
+		status.LastError = &StatusError{
+			Class: checkErr.Class, LastFailure: checkErr.LastFailure, Failures: checkErr.Failures,
+		}
+		if checkErr.Failing() {
+			status.LastError.FailingSince = &checkErr.FailingSince
+		}
+	}
+
+	return status, nil
+}
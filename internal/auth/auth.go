@@ -0,0 +1,178 @@
+// Package auth authenticates API requests with bearer tokens limited to scopes.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+)
+
+// Scopes a token can be granted.
+const (
+	ScopeReadProducts = "read:products"
+	ScopeReadChanges  = "read:changes"
+	ScopeTriggerCheck = "trigger:check"
+	ScopeAdmin        = models.ScopeAdmin
+)
+
+// TokenPrefix starts every token, so leaked tokens are easy to recognize.
+const TokenPrefix = "cf_"
+
+var (
+	// ErrMissingToken is returned for a request without a bearer token.
+	ErrMissingToken = errors.New("missing API token")
+	// ErrInvalidToken is returned for a token that doesn't exist or was revoked.
+	ErrInvalidToken = errors.New("invalid API token")
+	// ErrForbidden is returned when the token of a request lacks the required scope.
+	ErrForbidden = errors.New("API token lacks the required scope")
+	// ErrUnknownScope is returned when parsing a scope that doesn't exist.
+	ErrUnknownScope = errors.New("unknown scope")
+)
+
+// Scopes returns all scopes a token can be granted.
+func Scopes() []string {
+	return []string{ScopeReadProducts, ScopeReadChanges, ScopeTriggerCheck, ScopeAdmin}
+}
+
+// ParseScopes parses a comma separated list of scopes.
+func ParseScopes(raw string) ([]string, error) {
+	var scopes []string
+	for _, scope := range strings.Split(raw, ",") {
+		if scope = strings.TrimSpace(scope); scope == "" {
+			continue
+		}
+		if !slices.Contains(Scopes(), scope) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownScope, scope)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one of %s is required", ErrUnknownScope, strings.Join(Scopes(), ", "))
+	}
+
+	return scopes, nil
+}
+
+// GenerateToken returns a new random token.
+func GenerateToken() (string, error) {
+	const secretSize = 32
+
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return TokenPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// HashToken returns the hash a token is stored and looked up by. Tokens are random,
+// so a plain SHA-256 is enough and no salt is needed.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticator resolves the tokens of API requests.
+type Authenticator struct {
+	log  *slog.Logger
+	repo sqlite.TokenRepository
+}
+
+// NewAuthenticator creates an authenticator looking tokens up in repo.
+func NewAuthenticator(log *slog.Logger, repo sqlite.TokenRepository) *Authenticator {
+	return &Authenticator{log: log, repo: repo}
+}
+
+// Authenticate returns the active token of an Authorization header value in the "Bearer <token>" form.
+func (a *Authenticator) Authenticate(ctx context.Context, header string) (*models.APIToken, error) {
+	scheme, secret, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(secret) == "" {
+		return nil, ErrMissingToken
+	}
+
+	token, err := a.repo.GetTokenByHash(ctx, HashToken(strings.TrimSpace(secret)))
+	if errors.Is(err, repository.ErrTokenNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up token: %w", err)
+	}
+
+	return token, nil
+}
+
+// Middleware rejects requests without an active token with 401 Unauthorized and stores the token
+// of the others in the request context, where Require checks its scopes.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := a.Authenticate(r.Context(), r.Header.Get("Authorization"))
+		switch {
+		case errors.Is(err, ErrMissingToken), errors.Is(err, ErrInvalidToken):
+			w.Header().Set("WWW-Authenticate", `Bearer realm="chrono-flow"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		case err != nil:
+			a.log.ErrorContext(r.Context(), "failed to authenticate request", "error", err)
+			http.Error(w, "failed to authenticate request", http.StatusInternalServerError)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithToken(r.Context(), token)))
+	})
+}
+
+// URLMiddleware is Middleware also accepting the token in the token query parameter, for clients that
+// can't send headers, like feed readers. The token ends up in URLs and access logs, so it should only
+// be granted read scopes.
+func (a *Authenticator) URLMiddleware(next http.Handler) http.Handler {
+	authenticated := a.Middleware(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("token"); token != "" && r.Header.Get("Authorization") == "" {
+			r = r.Clone(r.Context())
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		authenticated.ServeHTTP(w, r)
+	})
+}
+
+// tokenKey is the context key of the request token.
+type tokenKey struct{}
+
+// WithToken returns a context carrying the token of the request.
+func WithToken(ctx context.Context, token *models.APIToken) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// TokenFromContext returns the token of the request, if it was authenticated.
+func TokenFromContext(ctx context.Context) (*models.APIToken, bool) {
+	token, ok := ctx.Value(tokenKey{}).(*models.APIToken)
+	return token, ok
+}
+
+// Require returns ErrForbidden unless the token of the request grants the scope. A context without
+// a token belongs to a request that wasn't authenticated because authentication is disabled.
+func Require(ctx context.Context, scope string) error {
+	token, ok := TokenFromContext(ctx)
+	if !ok || token.HasScope(scope) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrForbidden, scope)
+}
@@ -0,0 +1,179 @@
+package auth_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/auth"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := auth.ParseScopes(" read:products, trigger:check,read:products ")
+	require.NoError(t, err)
+	assert.Equal(t, []string{auth.ScopeReadProducts, auth.ScopeTriggerCheck}, scopes)
+
+	_, err = auth.ParseScopes("read:products,write:products")
+	require.ErrorIs(t, err, auth.ErrUnknownScope)
+
+	_, err = auth.ParseScopes("")
+	require.ErrorIs(t, err, auth.ErrUnknownScope)
+}
+
+func TestGenerateToken(t *testing.T) {
+	first, err := auth.GenerateToken()
+	require.NoError(t, err)
+	second, err := auth.GenerateToken()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(first, auth.TokenPrefix))
+	assert.NotEqual(t, first, second)
+	assert.Len(t, auth.HashToken(first), 64)
+	assert.NotEqual(t, auth.HashToken(first), auth.HashToken(second))
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	token := &models.APIToken{ID: 1, Scopes: []string{auth.ScopeReadChanges}}
+
+	testCases := []struct {
+		name        string
+		header      string
+		setupMock   func(repo *mocks.TokenRepository)
+		expected    *models.APIToken
+		expectedErr error
+	}{
+		{
+			name:        "missing header",
+			header:      "",
+			setupMock:   func(*mocks.TokenRepository) {},
+			expectedErr: auth.ErrMissingToken,
+		},
+		{
+			name:        "wrong scheme",
+			header:      "Basic dXNlcjpwYXNz",
+			setupMock:   func(*mocks.TokenRepository) {},
+			expectedErr: auth.ErrMissingToken,
+		},
+		{
+			name:   "unknown token",
+			header: "Bearer cf_unknown",
+			setupMock: func(repo *mocks.TokenRepository) {
+				repo.On("GetTokenByHash", mock.Anything, auth.HashToken("cf_unknown")).
+					Return(nil, repository.ErrTokenNotFound)
+			},
+			expectedErr: auth.ErrInvalidToken,
+		},
+		{
+			name:   "repository failure",
+			header: "Bearer cf_valid",
+			setupMock: func(repo *mocks.TokenRepository) {
+				repo.On("GetTokenByHash", mock.Anything, auth.HashToken("cf_valid")).Return(nil, assert.AnError)
+			},
+			expectedErr: assert.AnError,
+		},
+		{
+			name:   "active token, the scheme is case-insensitive",
+			header: "bearer cf_valid",
+			setupMock: func(repo *mocks.TokenRepository) {
+				repo.On("GetTokenByHash", mock.Anything, auth.HashToken("cf_valid")).Return(token, nil)
+			},
+			expected: token,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := mocks.NewTokenRepository(t)
+			tc.setupMock(repo)
+
+			actual, err := auth.NewAuthenticator(logger, repo).Authenticate(t.Context(), tc.header)
+
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestAuthenticator_Middleware(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := mocks.NewTokenRepository(t)
+	token := &models.APIToken{ID: 1, Scopes: []string{auth.ScopeReadChanges}}
+	repo.On("GetTokenByHash", mock.Anything, auth.HashToken("cf_valid")).Return(token, nil)
+	repo.On("GetTokenByHash", mock.Anything, auth.HashToken("cf_broken")).Return(nil, assert.AnError)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, auth.Require(r.Context(), auth.ScopeReadChanges))
+		assert.ErrorIs(t, auth.Require(r.Context(), auth.ScopeTriggerCheck), auth.ErrForbidden)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := auth.NewAuthenticator(logger, repo).Middleware(next)
+
+	serve := func(header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		return recorder
+	}
+
+	unauthorized := serve("")
+	assert.Equal(t, http.StatusUnauthorized, unauthorized.Code)
+	assert.NotEmpty(t, unauthorized.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, http.StatusInternalServerError, serve("Bearer cf_broken").Code)
+	assert.Equal(t, http.StatusNoContent, serve("Bearer cf_valid").Code)
+}
+
+func TestAuthenticator_URLMiddleware(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := mocks.NewTokenRepository(t)
+	token := &models.APIToken{ID: 1, Scopes: []string{auth.ScopeReadChanges}}
+	repo.On("GetTokenByHash", mock.Anything, auth.HashToken("cf_valid")).Return(token, nil)
+	repo.On("GetTokenByHash", mock.Anything, auth.HashToken("cf_unknown")).Return(nil, repository.ErrTokenNotFound)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	handler := auth.NewAuthenticator(logger, repo).URLMiddleware(next)
+
+	serve := func(target, header string) int {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve("/feed", ""))
+	assert.Equal(t, http.StatusNoContent, serve("/feed?token=cf_valid", ""))
+	assert.Equal(t, http.StatusUnauthorized, serve("/feed?token=cf_unknown", ""))
+	assert.Equal(t, http.StatusUnauthorized, serve("/feed?token=cf_valid", "Bearer cf_unknown"),
+		"the header takes precedence")
+}
+
+func TestRequire(t *testing.T) {
+	// Without a token in the context authentication is disabled and every scope is granted.
+	require.NoError(t, auth.Require(t.Context(), auth.ScopeAdmin))
+
+	admin := auth.WithToken(t.Context(), &models.APIToken{Scopes: []string{auth.ScopeAdmin}})
+	for _, scope := range auth.Scopes() {
+		require.NoError(t, auth.Require(admin, scope))
+	}
+}
@@ -0,0 +1,26 @@
+// Package blob stores files like exports in object storage.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when an object doesn't exist.
+var ErrNotFound = errors.New("object not found")
+
+// Store keeps objects by key, keys are slash-separated paths like "exports/2025/07/01/run.csv".
+type Store interface {
+	// Put stores the object read from body, size is the body length or -1 if it's unknown.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+
+	// Get returns the content of the object, the caller must close it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the object, deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// List returns the keys of all objects starting with prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
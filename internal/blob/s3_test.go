@@ -0,0 +1,168 @@
+package blob_test
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/blob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 implements the subset of the S3 API used by the driver for a single bucket.
+type fakeS3 struct {
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case key == "" && r.Method == http.MethodGet:
+		f.list(w, r.URL.Query().Get("prefix"))
+	case r.Method == http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+			body = decodeChunked(body)
+		}
+		f.objects[key] = body
+		w.Header().Set("ETag", `"etag"`)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		body, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>missing</Message></Error>`)
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		_, _ = w.Write(body)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// decodeChunked strips the chunk headers of a body uploaded with streaming signatures.
+func decodeChunked(body []byte) []byte {
+	var decoded []byte
+	for len(body) > 0 {
+		header, rest, _ := strings.Cut(string(body), "\r\n")
+		sizeHex, _, _ := strings.Cut(header, ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil || size == 0 {
+			break
+		}
+		decoded = append(decoded, rest[:size]...)
+		body = []byte(rest[size+2:])
+	}
+
+	return decoded
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, prefix string) {
+	type content struct {
+		Key  string
+		Size int
+	}
+	result := struct {
+		XMLName  xml.Name `xml:"ListBucketResult"`
+		Name     string
+		Prefix   string
+		KeyCount int
+		Contents []content
+	}{Name: f.bucket, Prefix: prefix}
+
+	for key, body := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			result.Contents = append(result.Contents, content{Key: key, Size: len(body)})
+		}
+	}
+	sort.Slice(result.Contents, func(i, j int) bool { return result.Contents[i].Key < result.Contents[j].Key })
+	result.KeyCount = len(result.Contents)
+
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+func newTestS3(t *testing.T, prefix string) (*blob.S3, *fakeS3) {
+	t.Helper()
+
+	fake := &fakeS3{bucket: "archive", objects: make(map[string][]byte)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	store, err := blob.NewS3(t.Context(), blob.S3Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Bucket:    "archive",
+		AccessKey: "access",
+		SecretKey: "secret",
+		Region:    "us-east-1",
+		Prefix:    prefix,
+	})
+	require.NoError(t, err)
+
+	return store, fake
+}
+
+func TestS3(t *testing.T) {
+	store, fake := newTestS3(t, "/chrono-flow/")
+	ctx := t.Context()
+
+	require.NoError(t, store.Put(ctx, "exports/a.csv", strings.NewReader("model\nA1\n"), 9, "text/csv"))
+	require.NoError(t, store.Put(ctx, "exports/b.csv", strings.NewReader("model\nB1\n"), 9, "text/csv"))
+	require.NoError(t, store.Put(ctx, "backups/db", strings.NewReader("sqlite"), 6, ""))
+	assert.Equal(t, []byte("model\nA1\n"), fake.objects["chrono-flow/exports/a.csv"])
+
+	object, err := store.Get(ctx, "exports/b.csv")
+	require.NoError(t, err)
+	body, err := io.ReadAll(object)
+	require.NoError(t, err)
+	require.NoError(t, object.Close())
+	assert.Equal(t, "model\nB1\n", string(body))
+
+	keys, err := store.List(ctx, "exports/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"exports/a.csv", "exports/b.csv"}, keys)
+
+	require.NoError(t, store.Delete(ctx, "exports/a.csv"))
+	_, err = store.Get(ctx, "exports/a.csv")
+	require.ErrorIs(t, err, blob.ErrNotFound)
+}
+
+func TestNewS3_BucketNotFound(t *testing.T) {
+	server := httptest.NewServer(&fakeS3{bucket: "other"})
+	t.Cleanup(server.Close)
+
+	_, err := blob.NewS3(t.Context(), blob.S3Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Bucket:    "archive",
+		AccessKey: "access",
+		SecretKey: "secret",
+		Region:    "us-east-1",
+	})
+
+	require.ErrorIs(t, err, blob.ErrBucketNotFound)
+}
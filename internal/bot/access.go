@@ -0,0 +1,140 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/telebot.v4"
+)
+
+// ErrNotMember is returned when the bot was removed from a chat or has never joined it.
+var ErrNotMember = errors.New("the bot is not a member of the chat")
+
+// LoadAllowedChats merges the chats allowed at runtime with the ones from configuration.
+func (b *Bot) LoadAllowedChats(ctx context.Context) error {
+	chatIDs, err := b.repo.GetAllowedChats(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load allowed chats: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, id := range chatIDs {
+		b.allowedChats[id] = true
+	}
+	b.log.InfoContext(ctx, "Loaded allowed chats", "runtime", len(chatIDs), "total", len(b.allowedChats))
+
+	return nil
+}
+
+// isAllowed reports whether the chat may use the bot.
+func (b *Bot) isAllowed(chatID int64) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.allowedChats[chatID]
+}
+
+// Usages of the /allow and /disallow commands.
+const (
+	allowUsage    = "Usage: /allow <chat_id>"
+	disallowUsage = "Usage: /disallow <chat_id>"
+)
+
+// allowHandler handles the admin /allow <chat_id> command.
+func (b *Bot) allowHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+	repoCtx := context.Background()
+
+	targetID, err := strconv.ParseInt(strings.TrimSpace(ctx.Data()), 10, 64)
+	if err != nil {
+		b.sendMessage(ctx, chatID, allowUsage)
+		return nil
+	}
+
+	if err = b.checkMembership(targetID); err != nil {
+		b.log.Warn("Chat membership validation failed", "chatID", targetID, "err", err)
+		b.sendMessage(ctx, chatID, fmt.Sprintf("⚠️ Cannot allow chat %d: %v", targetID, err))
+		return nil
+	}
+
+	if err = b.repo.AllowChat(repoCtx, targetID); err != nil {
+		b.log.Error("Failed to allow chat", "chatID", targetID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to allow the chat.")
+		return nil
+	}
+
+	b.mu.Lock()
+	b.allowedChats[targetID] = true
+	b.mu.Unlock()
+
+	b.log.Info("Chat allowed", "chatID", targetID, "by", chatID)
+	b.sendMessage(ctx, chatID, fmt.Sprintf("✅ Chat %d is now allowed to subscribe.", targetID))
+
+	return nil
+}
+
+// disallowHandler handles the admin /disallow <chat_id> command and unsubscribes the chat.
+func (b *Bot) disallowHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+	repoCtx := context.Background()
+
+	targetID, err := strconv.ParseInt(strings.TrimSpace(ctx.Data()), 10, 64)
+	if err != nil {
+		b.sendMessage(ctx, chatID, disallowUsage)
+		return nil
+	}
+
+	if b.configChats[targetID] {
+		b.sendMessage(ctx, chatID, fmt.Sprintf(
+			"⚠️ Chat %d is allowed by CF_ALLOWED_CHAT_IDS and can't be revoked at runtime.", targetID,
+		))
+		return nil
+	}
+
+	if err = b.repo.DisallowChat(repoCtx, targetID); err != nil {
+		b.log.Error("Failed to disallow chat", "chatID", targetID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to disallow the chat.")
+		return nil
+	}
+
+	b.mu.Lock()
+	delete(b.allowedChats, targetID)
+	b.mu.Unlock()
+
+	if err = b.repo.UnsubscribeChat(repoCtx, targetID); err != nil {
+		b.log.Error("Failed to unsubscribe disallowed chat", "chatID", targetID, "err", err)
+	}
+
+	b.log.Info("Chat disallowed", "chatID", targetID, "by", chatID)
+	b.sendMessage(ctx, chatID, fmt.Sprintf("🚫 Chat %d is no longer allowed and was unsubscribed.", targetID))
+
+	return nil
+}
+
+// checkMembership verifies that the bot can reach the chat and is still a member of it.
+func (b *Bot) checkMembership(chatID int64) error {
+	chat, err := b.bot.ChatByID(chatID)
+	if err != nil {
+		return fmt.Errorf("failed to get chat: %w", err)
+	}
+
+	// Private chats have no members list, reaching the chat is enough.
+	if chat.Type == telebot.ChatPrivate {
+		return nil
+	}
+
+	member, err := b.bot.ChatMemberOf(chat, b.me)
+	if err != nil {
+		return fmt.Errorf("failed to get chat member: %w", err)
+	}
+
+	if member.Role == telebot.Left || member.Role == telebot.Kicked {
+		return ErrNotMember
+	}
+
+	return nil
+}
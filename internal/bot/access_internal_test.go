@@ -0,0 +1,144 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+const testAdminID = int64(42)
+
+func newAccessTestBot(mockBot *mocks.API, mockRepo *mocks.Repository) *Bot {
+	return &Bot{
+		bot:          mockBot,
+		log:          slog.Default(),
+		repo:         mockRepo,
+		me:           &telebot.User{ID: 7, Username: "chrono_bot"},
+		adminChats:   map[int64]bool{testAdminID: true},
+		allowedChats: map[int64]bool{-1: true},
+		configChats:  map[int64]bool{-1: true},
+	}
+}
+
+func TestLoadAllowedChats(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetAllowedChats", mock.Anything).Return([]int64{-2}, nil).Once()
+		testBot := newAccessTestBot(nil, mockRepo)
+
+		require.NoError(t, testBot.LoadAllowedChats(t.Context()))
+		assert.True(t, testBot.isAllowed(-1))
+		assert.True(t, testBot.isAllowed(-2))
+	})
+
+	t.Run("error: repository failure", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetAllowedChats", mock.Anything).Return(nil, assert.AnError).Once()
+		testBot := newAccessTestBot(nil, mockRepo)
+
+		require.ErrorIs(t, testBot.LoadAllowedChats(t.Context()), assert.AnError)
+	})
+}
+
+func TestAllowHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not an admin", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := newAccessTestBot(nil, nil)
+		ctx, api := newTestContext(1, "-5")
+
+		require.NoError(t, testBot.route("/allow", accessAdmin, testBot.allowHandler)(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "administrators only")
+	})
+
+	t.Run("invalid chat id", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := newAccessTestBot(nil, nil)
+		ctx, api := newTestContext(testAdminID, "abc")
+
+		require.NoError(t, testBot.allowHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "Usage: /allow")
+	})
+
+	t.Run("bot is not a member", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		chat := &telebot.Chat{ID: -5, Type: telebot.ChatSuperGroup}
+		mockBot.On("ChatByID", int64(-5)).Return(chat, nil).Once()
+		mockBot.On("ChatMemberOf", chat, mock.Anything).Return(&telebot.ChatMember{Role: telebot.Left}, nil).Once()
+		testBot := newAccessTestBot(mockBot, nil)
+		ctx, api := newTestContext(testAdminID, "-5")
+
+		require.NoError(t, testBot.allowHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], ErrNotMember.Error())
+		assert.False(t, testBot.isAllowed(-5))
+	})
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockRepo := mocks.NewRepository(t)
+		chat := &telebot.Chat{ID: -5, Type: telebot.ChatSuperGroup}
+		mockBot.On("ChatByID", int64(-5)).Return(chat, nil).Once()
+		mockBot.On("ChatMemberOf", chat, mock.Anything).Return(&telebot.ChatMember{Role: telebot.Member}, nil).Once()
+		mockRepo.On("AllowChat", mock.Anything, int64(-5)).Return(nil).Once()
+		testBot := newAccessTestBot(mockBot, mockRepo)
+		ctx, api := newTestContext(testAdminID, "-5")
+
+		require.NoError(t, testBot.allowHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "is now allowed")
+		assert.True(t, testBot.isAllowed(-5))
+	})
+}
+
+func TestDisallowHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("configured chat cannot be revoked", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := newAccessTestBot(nil, nil)
+		ctx, api := newTestContext(testAdminID, "-1")
+
+		require.NoError(t, testBot.disallowHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "can't be revoked")
+		assert.True(t, testBot.isAllowed(-1))
+	})
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("DisallowChat", mock.Anything, int64(-5)).Return(nil).Once()
+		mockRepo.On("UnsubscribeChat", mock.Anything, int64(-5)).Return(nil).Once()
+		testBot := newAccessTestBot(nil, mockRepo)
+		testBot.allowedChats[-5] = true
+		ctx, api := newTestContext(testAdminID, "-5")
+
+		require.NoError(t, testBot.disallowHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "no longer allowed")
+		assert.False(t, testBot.isAllowed(-5))
+	})
+}
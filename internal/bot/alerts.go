@@ -0,0 +1,16 @@
+package bot
+
+import (
+	"context"
+
+	"gopkg.in/telebot.v4"
+)
+
+// NotifyAdmins sends an operational message to every admin chat.
+func (b *Bot) NotifyAdmins(ctx context.Context, text string) {
+	for chatID := range b.adminChats {
+		if _, err := b.bot.Send(&telebot.Chat{ID: chatID}, text); err != nil {
+			b.log.ErrorContext(ctx, "Failed to notify admin chat", "chatID", chatID, "err", err)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/telebot.v4"
+)
+
+func TestNotifyAdmins(t *testing.T) {
+	t.Parallel()
+
+	mockBot := mocks.NewAPI(t)
+	mockBot.On("Send", &telebot.Chat{ID: 1}, "alert").Return(&telebot.Message{}, nil).Once()
+	mockBot.On("Send", &telebot.Chat{ID: 2}, "alert").Return(nil, assert.AnError).Once()
+	testBot := Bot{bot: mockBot, log: slog.Default(), adminChats: map[int64]bool{1: true, 2: true}}
+
+	testBot.NotifyAdmins(t.Context(), "alert")
+}
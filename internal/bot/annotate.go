@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gopkg.in/telebot.v4"
+)
+
+// annotateUsage explains the /annotate command.
+const annotateUsage = `Usage: /annotate <run_id> <model> "note"`
+
+// annotateHandler handles the admin /annotate command: it attaches a note to the changes of a model
+// detected by a check run, e.g. a confirmed pricing error, shown with the history of the product.
+func (b *Bot) annotateHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+
+	runID, model, note, ok := parseAnnotation(ctx.Data())
+	if !ok {
+		b.sendMessage(ctx, chatID, annotateUsage)
+		return nil
+	}
+
+	annotated, err := b.repo.AnnotateChange(context.Background(), runID, model, note)
+	if err != nil {
+		b.log.Error("Failed to annotate changes", "chatID", chatID, "run", runID, "model", model, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to annotate the changes.")
+		return nil
+	}
+	if annotated == 0 {
+		b.sendMessage(ctx, chatID, fmt.Sprintf("🤷 The run %s didn't change %s.", runID, model))
+		return nil
+	}
+
+	b.log.Info("Changes annotated", "run", runID, "model", model, "changes", annotated, "by", chatID)
+	b.sendMessage(ctx, chatID, fmt.Sprintf("📝 Annotated %d changes of %s in the run %s.", annotated, model, runID))
+
+	return nil
+}
+
+// parseAnnotation splits the arguments of /annotate into the run ID, the model and the note. The note
+// may be quoted, then the model is everything before it, so models with spaces can be annotated.
+func parseAnnotation(data string) (string, string, string, bool) {
+	runID, rest, _ := strings.Cut(strings.TrimSpace(data), " ")
+	rest = strings.TrimSpace(rest)
+
+	var model, note string
+	if before, quoted, found := strings.Cut(rest, `"`); found {
+		model, note = strings.TrimSpace(before), strings.TrimSuffix(strings.TrimSpace(quoted), `"`)
+	} else {
+		model, note, _ = strings.Cut(rest, " ")
+	}
+	note = strings.TrimSpace(note)
+
+	return runID, model, note, runID != "" && model != "" && note != ""
+}
@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotateHandler(t *testing.T) {
+	t.Parallel()
+
+	const adminID = int64(42)
+
+	t.Run("changes are annotated", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("AnnotateChange", mock.Anything, "0a1b2c3d4e5f6789", "Seiko SRPD55",
+			"supplier confirmed pricing error").Return(2, nil).Once()
+		testBot := &Bot{log: slog.Default(), repo: mockRepo, adminChats: map[int64]bool{adminID: true}}
+		ctx, api := newTestContext(adminID, `0a1b2c3d4e5f6789 Seiko SRPD55 "supplier confirmed pricing error"`)
+
+		require.NoError(t, testBot.annotateHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Equal(t, "📝 Annotated 2 changes of Seiko SRPD55 in the run 0a1b2c3d4e5f6789.", api.sent[0])
+	})
+
+	t.Run("the run didn't change the model", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("AnnotateChange", mock.Anything, "run1", "A1", "typo").Return(0, nil).Once()
+		testBot := &Bot{log: slog.Default(), repo: mockRepo, adminChats: map[int64]bool{adminID: true}}
+		ctx, api := newTestContext(adminID, "run1 A1 typo")
+
+		require.NoError(t, testBot.annotateHandler(ctx))
+		assert.Equal(t, "🤷 The run run1 didn't change A1.", api.sent[0])
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := &Bot{log: slog.Default(), repo: mocks.NewRepository(t), adminChats: map[int64]bool{adminID: true}}
+		for _, payload := range []string{"", "run1", "run1 A1", `run1 A1 ""`, `run1 "note"`} {
+			ctx, api := newTestContext(adminID, payload)
+			require.NoError(t, testBot.annotateHandler(ctx))
+			assert.Equal(t, annotateUsage, api.sent[0], payload)
+		}
+	})
+
+	t.Run("error: annotate", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("AnnotateChange", mock.Anything, "run1", "A1", "typo").Return(0, assert.AnError).Once()
+		testBot := &Bot{log: slog.Default(), repo: mockRepo, adminChats: map[int64]bool{adminID: true}}
+		ctx, api := newTestContext(adminID, "run1 A1 typo")
+
+		require.NoError(t, testBot.annotateHandler(ctx))
+		assert.Contains(t, api.sent[0], "internal error")
+	})
+}
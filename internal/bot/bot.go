@@ -1,32 +1,159 @@
 package bot
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"sync"
 	"time"
 
-	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+	"github.com/Houeta/chrono-flow/internal/dedup"
+	"github.com/Houeta/chrono-flow/internal/metrics"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/rules"
 	"gopkg.in/telebot.v4"
 )
 
 // Bot contains the bot API instance and other information.
 type Bot struct {
-	bot          API
-	log          *slog.Logger
-	repo         sqlite.SubscribeRepository
+	bot        API
+	log        *slog.Logger
+	repo       Repository
+	adminChats map[int64]bool
+	me         *telebot.User
+
+	// mu guards allowedChats, which admins can change at runtime.
+	mu           sync.RWMutex
 	allowedChats map[int64]bool
+	// configChats are the chats allowed by configuration, they can't be revoked at runtime.
+	configChats map[int64]bool
+
+	// filterGroups maps a deep-link payload to the product types delivered to the chats that used it.
+	filterGroups map[string][]string
+
+	// target is the monitored page shown in status messages.
+	target string
+
+	// templateMu guards templates, which an admin replaces by approving a template file, and lastChanges.
+	templateMu sync.Mutex
+	// templates render the notification lines, nil uses the built-in ones.
+	templates *Templates
+	// templateFile is the watched file of templates, nil if there is none.
+	templateFile *templateFile
+	// lastChanges are the last changes sent to the subscribers, a changed template file is previewed with them.
+	lastChanges *models.Changes
+	// variantTemplates render the notification lines of the chats getting variant b, nil disables it.
+	variantTemplates *Templates
+	// feedback adds buttons rating the notifications to them.
+	feedback bool
+	// buttons are the rows of link buttons added to every notification.
+	buttons [][]Button
+	// photos sends the images of the added products after the notifications.
+	photos bool
+
+	// summaryThreshold is the number of changes above which a short summary with
+	// a CSV attachment is sent instead of the full list. Zero disables summaries.
+	summaryThreshold int
+	// maxLines is the number of lines of a notification ranked by significance, see WithRanking. Zero
+	// groups the lines by product type instead.
+	maxLines int
+
+	// dedup suppresses notifications the chat already got from another notifier, nil disables it.
+	dedup *dedup.Deduplicator
+
+	// alertRules are the alert rules of the deployment, alerting every subscribed chat.
+	alertRules []rules.Rule
+
+	// targets tests and schedules the targets added with /addtarget, nil disables the wizard.
+	targets TargetManager
+	// wizardMu guards wizards, the /addtarget conversations running in chats.
+	wizardMu sync.Mutex
+	wizards  map[int64]*targetWizard
+
+	// logLevels are changed with /loglevel, nil disables the command.
+	logLevels LogLevels
+
+	// channel also gets the notifications and the daily summary, a zero ID disables it.
+	channel Channel
+	// pinnedSummary is the message ID of the daily summary pinned in the channel, 0 if none is.
+	pinnedSummary int
+
+	// metrics count the handled updates, nil disables them.
+	metrics *metrics.Metrics
+	// reachMu guards unreachable, the chats with recorded send failures, see trackDelivery.
+	reachMu     sync.Mutex
+	unreachable map[int64]bool
+	// transport observes the requests to the Telegram Bot API, nil if the bot isn't connected to it.
+	transport *apiTransport
+	// limiter limits the updates of every chat, nil disables the limit.
+	limiter *rateLimiter
+	// broadcaster sends the broadcasts in the background, nil sends them one message after the other.
+	broadcaster *broadcaster
+}
+
+// Option configures optional Bot behavior.
+type Option func(*Bot)
+
+// WithAdminChats allows the given chats to run administrative commands.
+func WithAdminChats(adminIDs []int64) Option {
+	return func(b *Bot) {
+		for _, id := range adminIDs {
+			b.adminChats[id] = true
+		}
+	}
+}
+
+// WithFilterGroups sets the predefined filter groups that can be applied with deep links.
+func WithFilterGroups(groups map[string][]string) Option {
+	return func(b *Bot) {
+		b.filterGroups = groups
+	}
+}
+
+// WithTarget sets the monitored page shown in status messages.
+func WithTarget(target string) Option {
+	return func(b *Bot) {
+		b.target = target
+	}
+}
+
+// WithTemplates sets the templates notification lines are rendered with.
+func WithTemplates(templates *Templates) Option {
+	return func(b *Bot) {
+		b.templates = templates
+	}
+}
+
+// WithSummaryThreshold replaces item-by-item notifications with a summary once
+// a single check yields more than threshold changes.
+func WithSummaryThreshold(threshold int) Option {
+	return func(b *Bot) {
+		b.summaryThreshold = threshold
+	}
+}
+
+// WithDeduplicator skips change notifications the chat already received within the deduplication
+// window, e.g. from the bot of another tenant watching the same page.
+func WithDeduplicator(deduplicator *dedup.Deduplicator) Option {
+	return func(b *Bot) {
+		b.dedup = deduplicator
+	}
 }
 
 func NewBot(
 	log *slog.Logger,
 	token string,
 	poller time.Duration,
-	repo sqlite.SubscribeRepository,
+	repo Repository,
 	allowedIDs []int64,
+	opts ...Option,
 ) (*Bot, error) {
+	transport := newAPITransport()
 	bot, err := telebot.NewBot(telebot.Settings{
 		Token:  token,
 		Poller: &telebot.LongPoller{Timeout: poller},
+		Client: &http.Client{Transport: transport, Timeout: apiTimeout},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize Telegram bot: %w", err)
@@ -34,11 +161,30 @@ func NewBot(
 	log.Info("Authorized on account", "account", bot.Me.Username)
 
 	allowedMap := make(map[int64]bool)
+	configMap := make(map[int64]bool)
 	for _, id := range allowedIDs {
 		allowedMap[id] = true
+		configMap[id] = true
 	}
 
-	botInstance := &Bot{bot: bot, log: log, allowedChats: allowedMap, repo: repo}
+	botInstance := &Bot{
+		bot:          bot,
+		log:          log,
+		allowedChats: allowedMap,
+		configChats:  configMap,
+		adminChats:   make(map[int64]bool),
+		me:           bot.Me,
+		repo:         repo,
+		transport:    transport,
+	}
+	for _, opt := range opts {
+		opt(botInstance)
+	}
+	transport.metrics = botInstance.metrics
+	botInstance.loadUnreachableChats(context.Background())
+	if botInstance.broadcaster != nil {
+		botInstance.broadcaster.start(log, botInstance.metrics)
+	}
 	botInstance.registerRoutes()
 
 	return botInstance, nil
@@ -47,19 +193,49 @@ func NewBot(
 // Start launches the bot to listen for updates.
 func (b *Bot) Start() {
 	b.log.Info("Telegram bot is starting...")
+	if b.templateFile != nil {
+		go b.watchTemplateFile()
+	}
 	b.bot.Start()
 }
 
-// Stop gracefully stops the Telegram bot and logs the action.
+// Stop gracefully stops the Telegram bot and logs the action. The messages queued for the broadcast
+// workers are sent first.
 func (b *Bot) Stop() {
 	b.log.Info("Telegram bot is stopped...")
 	b.bot.Stop()
+	if b.templateFile != nil {
+		close(b.templateFile.done)
+	}
+	if b.broadcaster != nil {
+		b.broadcaster.stop()
+	}
 }
 
-// registerRoutes configures all routes (commands).
+// registerRoutes configures all routes: the commands of the registry, see Bot.commands, then the buttons
+// and the other updates. Every update passes the middleware of route, which also refuses the chats that
+// may not use the route.
 func (b *Bot) registerRoutes() {
-	// Public routes.
-	b.bot.Handle("/start", b.subscribeHandler)
-	b.bot.Handle("/subscribe", b.subscribeHandler)
-	b.bot.Handle("/unsubscribe", b.unsubscribeHandler)
+	handle := func(endpoint any, level access, handler telebot.HandlerFunc) {
+		b.bot.Handle(endpoint, b.route(endpoint, level, handler))
+	}
+
+	for _, command := range b.commands() {
+		handle("/"+command.text, command.level, command.handler)
+	}
+
+	handle(&telebot.Btn{Unique: listUnique}, accessAllowed, b.listCallback)
+	handle(&telebot.Btn{Unique: settingsUnique}, accessAllowed, b.settingsCallback)
+	// Notifications are also rated in direct messages, the handler only records known notifications.
+	handle(&telebot.Btn{Unique: feedbackUnique}, accessPublic, b.feedbackCallback)
+	// Full diffs are also requested from direct messages, the run IDs are only known from notifications.
+	handle(&telebot.Btn{Unique: diffUnique}, accessPublic, b.diffCallback)
+	handle(&telebot.Btn{Unique: templateUnique}, accessAdmin, b.templateCallback)
+	handle(&telebot.Btn{Unique: addTargetUnique}, accessAdmin, b.addTargetCallback)
+	// Inline queries come from users, the handler only answers the allowed ones.
+	handle(telebot.OnQuery, accessPublic, b.inlineQueryHandler)
+	// The handler moves the data of the groups upgraded to supergroups, it moves nothing for the others.
+	handle(telebot.OnMigration, accessPublic, b.migrationHandler)
+	// Any text may answer a wizard, the handler ignores the chats not running one.
+	handle(telebot.OnText, accessPublic, b.wizardTextHandler)
 }
@@ -2,10 +2,17 @@ package bot
 
 import (
 	"log/slog"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Houeta/chrono-flow/internal/dedup"
+	"github.com/Houeta/chrono-flow/internal/models"
 	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
 )
 
 func TestStart(t *testing.T) {
@@ -44,6 +51,42 @@ func TestRegisterRoutes(t *testing.T) {
 	mockBot.On("Handle", "/start", mock.AnythingOfType("telebot.HandlerFunc")).Once()
 	mockBot.On("Handle", "/subscribe", mock.AnythingOfType("telebot.HandlerFunc")).Once()
 	mockBot.On("Handle", "/unsubscribe", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/forgetme", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/help", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/settopic", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/silent", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/status", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/settings", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/targets", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/price", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/best", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/stateat", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/list", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", &telebot.Btn{Unique: "list"}, mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/search", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", telebot.OnQuery, mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/wishlist", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/rule", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/dmme", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", &telebot.Btn{Unique: "settings"}, mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", &telebot.Btn{Unique: "feedback"}, mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", &telebot.Btn{Unique: "diff"}, mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/cancel", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", telebot.OnMigration, mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", telebot.OnText, mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/invite", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/allow", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/disallow", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/previewtemplate", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", &telebot.Btn{Unique: "templates"}, mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/addtarget", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", &telebot.Btn{Unique: "addtarget"}, mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/removetarget", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/target", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/loglevel", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/stats", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/annotate", mock.AnythingOfType("telebot.HandlerFunc")).Once()
+	mockBot.On("Handle", "/simulate", mock.AnythingOfType("telebot.HandlerFunc")).Once()
 
 	logger := slog.Default()
 	testBot := Bot{bot: mockBot, log: logger}
@@ -52,3 +95,274 @@ func TestRegisterRoutes(t *testing.T) {
 
 	mockBot.AssertExpectations(t)
 }
+
+func TestSendChangesNotification(t *testing.T) {
+	t.Parallel()
+
+	changes := &models.Changes{
+		Changed: []models.ChangeInfo{
+			{Old: models.Product{Model: "A1", Price: "100"}, New: models.Product{Model: "A1", Price: "110"}},
+			{Old: models.Product{Model: "B2", Price: "200"}, New: models.Product{Model: "B2", Price: "190"}},
+		},
+		Added: []models.Product{{Model: "C3", Price: "300"}},
+	}
+
+	t.Run("full list below threshold", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockRepo := mocks.NewRepository(t)
+		expectNoPriceHistory(mockRepo)
+		mockRepo.On("GetSubscribedChats", mock.Anything).Return([]int64{1}, nil).Once()
+		expectNoUserSubscriptions(mockRepo)
+		expectNoAlertRules(mockRepo)
+		mockRepo.On("GetChatSettings", mock.Anything).Return(map[int64]models.ChatSettings{}, nil).Once()
+		mockBot.On("Send", &telebot.Chat{ID: 1}, mock.MatchedBy(func(text string) bool {
+			return strings.Contains(text, "`A1`")
+		}), markdownOpts(0)).Return(&telebot.Message{ID: 5}, nil).Once()
+		mockRepo.On("RecordNotificationProducts", mock.Anything, int64(1), 5, mock.Anything, []models.ProductRef{
+			{Model: "C3"}, {Model: "A1"}, {Model: "B2"},
+		}).Return(assert.AnError).Once()
+
+		testBot := Bot{bot: mockBot, log: slog.Default(), repo: mockRepo, summaryThreshold: 3}
+
+		require.NoError(t, testBot.SendChangesNotification(t.Context(), changes))
+	})
+
+	t.Run("summary with attachment above threshold", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockRepo := mocks.NewRepository(t)
+		expectNoPriceHistory(mockRepo)
+		expectRecordedProducts(mockRepo)
+		mockRepo.On("GetSubscribedChats", mock.Anything).Return([]int64{1}, nil).Once()
+		expectNoUserSubscriptions(mockRepo)
+		expectNoAlertRules(mockRepo)
+		mockRepo.On("GetChatSettings", mock.Anything).Return(map[int64]models.ChatSettings{}, nil).Once()
+		mockBot.On("Send", &telebot.Chat{ID: 1}, mock.MatchedBy(func(text string) bool {
+			return strings.Contains(text, "2 products repriced, average +2.5%") && !strings.Contains(text, "`A1`")
+		}), markdownOpts(0)).Return(&telebot.Message{}, nil).Once()
+		mockBot.On("Send", &telebot.Chat{ID: 1}, mock.AnythingOfType("*telebot.Document"), &telebot.SendOptions{}).
+			Return(&telebot.Message{}, nil).Once()
+
+		testBot := Bot{bot: mockBot, log: slog.Default(), repo: mockRepo, summaryThreshold: 2}
+
+		require.NoError(t, testBot.SendChangesNotification(t.Context(), changes))
+	})
+
+	t.Run("filter groups limit delivered changes", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockRepo := mocks.NewRepository(t)
+		expectNoPriceHistory(mockRepo)
+		expectRecordedProducts(mockRepo)
+		mockRepo.On("GetSubscribedChats", mock.Anything).Return([]int64{1, 2}, nil).Once()
+		expectNoUserSubscriptions(mockRepo)
+		expectNoAlertRules(mockRepo)
+		mockRepo.On("GetChatSettings", mock.Anything).Return(map[int64]models.ChatSettings{
+			1: {FilterGroup: "diver"}, 2: {FilterGroup: "dress"},
+		}, nil).Once()
+		mockBot.On("Send", &telebot.Chat{ID: 1}, mock.MatchedBy(func(text string) bool {
+			return strings.Contains(text, "`C3`") && !strings.Contains(text, "`A1`")
+		}), markdownOpts(0)).Return(&telebot.Message{}, nil).Once()
+
+		testBot := Bot{
+			bot:          mockBot,
+			log:          slog.Default(),
+			repo:         mockRepo,
+			filterGroups: map[string][]string{"diver": {"Diver"}, "dress": {"Dress"}},
+		}
+
+		typedChanges := &models.Changes{
+			Added:   []models.Product{{Model: "C3", Type: "Diver"}},
+			Removed: []models.Product{{Model: "A1", Type: "Chrono"}},
+		}
+		require.NoError(t, testBot.SendChangesNotification(t.Context(), typedChanges))
+	})
+
+	t.Run("notification is sent to the chat topic", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockRepo := mocks.NewRepository(t)
+		expectNoPriceHistory(mockRepo)
+		expectRecordedProducts(mockRepo)
+		mockRepo.On("GetSubscribedChats", mock.Anything).Return([]int64{1}, nil).Once()
+		expectNoUserSubscriptions(mockRepo)
+		expectNoAlertRules(mockRepo)
+		mockRepo.On("GetChatSettings", mock.Anything).Return(map[int64]models.ChatSettings{
+			1: {ThreadID: 15},
+		}, nil).Once()
+		mockBot.On("Send", &telebot.Chat{ID: 1}, mock.AnythingOfType("string"), markdownOpts(15)).
+			Return(&telebot.Message{}, nil).Once()
+
+		testBot := Bot{bot: mockBot, log: slog.Default(), repo: mockRepo}
+
+		require.NoError(t, testBot.SendChangesNotification(t.Context(), changes))
+	})
+
+	t.Run("silenced categories are sent without sound", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockRepo := mocks.NewRepository(t)
+		expectNoPriceHistory(mockRepo)
+		expectRecordedProducts(mockRepo)
+		mockRepo.On("GetSubscribedChats", mock.Anything).Return([]int64{1, 2}, nil).Once()
+		expectNoUserSubscriptions(mockRepo)
+		expectNoAlertRules(mockRepo)
+		mockRepo.On("GetChatSettings", mock.Anything).Return(map[int64]models.ChatSettings{
+			1: {Silent: []models.ChangeCategory{models.CategoryQuantity}},
+			2: {Silent: []models.ChangeCategory{models.CategoryPriceDrop}},
+		}, nil).Once()
+		silentOpts := markdownOpts(0)
+		silentOpts.DisableNotification = true
+		mockBot.On("Send", &telebot.Chat{ID: 1}, mock.AnythingOfType("string"), silentOpts).
+			Return(&telebot.Message{}, nil).Once()
+		mockBot.On("Send", &telebot.Chat{ID: 2}, mock.AnythingOfType("string"), markdownOpts(0)).
+			Return(&telebot.Message{}, nil).Once()
+
+		testBot := Bot{bot: mockBot, log: slog.Default(), repo: mockRepo}
+
+		quantityOnly := &models.Changes{Changed: []models.ChangeInfo{{
+			Old: models.Product{Model: "A1", Price: "100", Quantity: "1"},
+			New: models.Product{Model: "A1", Price: "100", Quantity: "5"},
+		}}}
+		require.NoError(t, testBot.SendChangesNotification(t.Context(), quantityOnly))
+	})
+
+	t.Run("price trends are shown and can be limited to sustained ones", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockRepo := mocks.NewRepository(t)
+		expectRecordedProducts(mockRepo)
+		mockRepo.On("GetProductHistory", mock.Anything, "", "A1").Return([]models.ChangeRecord{
+			{DetectedAt: time.Now().Add(-time.Hour), Kind: models.KindChanged, OldPrice: "90", Price: "100"},
+		}, nil).Once()
+		mockRepo.On("GetProductHistory", mock.Anything, "", "B2").Return(nil, nil).Once()
+		mockRepo.On("GetSubscribedChats", mock.Anything).Return([]int64{1, 2}, nil).Once()
+		expectNoUserSubscriptions(mockRepo)
+		expectNoAlertRules(mockRepo)
+		mockRepo.On("GetChatSettings", mock.Anything).Return(map[int64]models.ChatSettings{
+			1: {SustainedTrends: true},
+		}, nil).Once()
+		mockBot.On("Send", &telebot.Chat{ID: 1}, mock.MatchedBy(func(text string) bool {
+			return strings.Contains(text, "↗️ second consecutive increase, +22.2% over 1 day") &&
+				!strings.Contains(text, "`B2`")
+		}), markdownOpts(0)).Return(&telebot.Message{}, nil).Once()
+		mockBot.On("Send", &telebot.Chat{ID: 2}, mock.MatchedBy(func(text string) bool {
+			return strings.Contains(text, "`B2`") && strings.Contains(text, "↘️ -5% over 1 day")
+		}), markdownOpts(0)).Return(&telebot.Message{}, nil).Once()
+
+		testBot := Bot{bot: mockBot, log: slog.Default(), repo: mockRepo}
+
+		require.NoError(t, testBot.SendChangesNotification(t.Context(), changes))
+	})
+
+	t.Run("price watermarks are alerted with sound to the chats that opted in", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockRepo := mocks.NewRepository(t)
+		expectRecordedProducts(mockRepo)
+		mockRepo.On("GetProductHistory", mock.Anything, "", "A1").Return([]models.ChangeRecord{
+			{DetectedAt: time.Now().Add(-60 * 24 * time.Hour), Kind: models.KindAdded, Price: "120"},
+			{DetectedAt: time.Now().Add(-time.Hour), Kind: models.KindChanged, OldPrice: "120", Price: "100"},
+		}, nil).Once()
+		mockRepo.On("GetSubscribedChats", mock.Anything).Return([]int64{1, 2}, nil).Once()
+		expectNoUserSubscriptions(mockRepo)
+		expectNoAlertRules(mockRepo)
+		mockRepo.On("GetChatSettings", mock.Anything).Return(map[int64]models.ChatSettings{
+			1: {Watermarks: true, ThreadID: 15, Silent: []models.ChangeCategory{models.CategoryPriceDrop}},
+		}, nil).Once()
+		silentOpts := markdownOpts(15)
+		silentOpts.DisableNotification = true
+		mockBot.On("Send", &telebot.Chat{ID: 1}, mock.AnythingOfType("string"), silentOpts).
+			Return(&telebot.Message{}, nil).Once()
+		mockBot.On("Send", &telebot.Chat{ID: 1},
+			"🚨 *Price watermarks*\n• 📉 `A1` hit an all-time low: *80* (lowest before: 100)", markdownOpts(15)).
+			Return(&telebot.Message{}, nil).Once()
+		mockBot.On("Send", &telebot.Chat{ID: 2}, mock.AnythingOfType("string"), markdownOpts(0)).
+			Return(&telebot.Message{}, nil).Once()
+
+		testBot := Bot{bot: mockBot, log: slog.Default(), repo: mockRepo}
+
+		priceDrop := &models.Changes{Changed: []models.ChangeInfo{{
+			Old: models.Product{Model: "A1", Price: "100", Quantity: "1"},
+			New: models.Product{Model: "A1", Price: "80", Quantity: "1"},
+		}}}
+		require.NoError(t, testBot.SendChangesNotification(t.Context(), priceDrop))
+	})
+
+	t.Run("duplicates from another notifier are skipped", func(t *testing.T) {
+		t.Parallel()
+
+		deduplicator := dedup.New(time.Hour)
+		newBot := func(chats []int64) (*Bot, *mocks.API) {
+			mockBot := mocks.NewAPI(t)
+			mockRepo := mocks.NewRepository(t)
+			expectNoPriceHistory(mockRepo)
+			expectRecordedProducts(mockRepo)
+			mockRepo.On("GetSubscribedChats", mock.Anything).Return(chats, nil).Once()
+			expectNoUserSubscriptions(mockRepo)
+			expectNoAlertRules(mockRepo)
+			mockRepo.On("GetChatSettings", mock.Anything).Return(map[int64]models.ChatSettings{}, nil).Once()
+
+			return &Bot{bot: mockBot, log: slog.Default(), repo: mockRepo, dedup: deduplicator}, mockBot
+		}
+
+		first, firstAPI := newBot([]int64{1})
+		firstAPI.On("Send", &telebot.Chat{ID: 1}, mock.AnythingOfType("string"), markdownOpts(0)).
+			Return(&telebot.Message{}, nil).Once()
+		require.NoError(t, first.SendChangesNotification(t.Context(), changes))
+
+		second, secondAPI := newBot([]int64{1, 2})
+		secondAPI.On("Send", &telebot.Chat{ID: 2}, mock.AnythingOfType("string"), markdownOpts(0)).
+			Return(&telebot.Message{}, nil).Once()
+		require.NoError(t, second.SendChangesNotification(t.Context(), changes))
+	})
+
+	t.Run("error: cannot get subscribers", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		expectNoPriceHistory(mockRepo)
+		expectRecordedProducts(mockRepo)
+		mockRepo.On("GetSubscribedChats", mock.Anything).Return(nil, assert.AnError).Once()
+		expectNoUserSubscriptions(mockRepo)
+		expectNoAlertRules(mockRepo)
+
+		testBot := Bot{bot: mocks.NewAPI(t), log: slog.Default(), repo: mockRepo}
+
+		require.ErrorIs(t, testBot.SendChangesNotification(t.Context(), changes), assert.AnError)
+	})
+}
+
+// expectRecordedProducts accepts recording the products of any notification sent.
+func expectRecordedProducts(repo *mocks.Repository) {
+	repo.On("RecordNotificationProducts", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil).Maybe()
+}
+
+// expectNoPriceHistory sets up the repository to return no history for the price trends of the products.
+func expectNoPriceHistory(repo *mocks.Repository) {
+	repo.On("GetProductHistory", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+}
+
+// expectNoUserSubscriptions sets up the repository to return no users getting direct messages.
+func expectNoUserSubscriptions(repo *mocks.Repository) {
+	repo.On("GetUserSubscriptions", mock.Anything).Return(nil, nil).Maybe()
+}
+
+// expectNoAlertRules sets up the repository to return no alert rules of the chats.
+func expectNoAlertRules(repo *mocks.Repository) {
+	repo.On("GetAlertRules", mock.Anything).Return(nil, nil).Maybe()
+}
+
+// markdownOpts returns the send options of a notification sent to the given forum topic.
+func markdownOpts(threadID int) *telebot.SendOptions {
+	return &telebot.SendOptions{ParseMode: telebot.ModeMarkdown, ThreadID: threadID}
+}
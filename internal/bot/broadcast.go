@@ -0,0 +1,175 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/metrics"
+)
+
+// broadcastPace is the pause after every message of a broadcast, a worker sends at most 10 messages a
+// second. Telegram allows a bot about 30 messages a second overall.
+const broadcastPace = 100 * time.Millisecond
+
+// WithBroadcastWorkers sends the messages of the broadcasts to the subscribers with a pool of workers, so
+// a long broadcast doesn't hold up the next check. Up to queueSize messages wait for the workers, queueing
+// more blocks until a worker is free. The messages of a chat are sent by the same worker, in the order
+// they were queued. Without workers the messages are sent one after the other.
+func WithBroadcastWorkers(workers, queueSize int) Option {
+	return func(b *Bot) {
+		if workers > 0 {
+			b.broadcaster = &broadcaster{workers: workers, queueSize: queueSize}
+		}
+	}
+}
+
+// broadcaster is the pool of workers sending the messages of the broadcasts.
+type broadcaster struct {
+	workers   int
+	queueSize int
+	log       *slog.Logger
+	// metrics observe the queue and the broadcasts, nil disables them.
+	metrics *metrics.Metrics
+
+	// mu guards closed, a message queued once the workers stopped is sent right away.
+	mu     sync.RWMutex
+	closed bool
+	// queues hold the messages of every worker.
+	queues []chan broadcastMessage
+	wg     sync.WaitGroup
+}
+
+// broadcastMessage is a message of a broadcast waiting for a worker.
+type broadcastMessage struct {
+	ctx       context.Context //nolint:containedctx // the context of the broadcast the message belongs to.
+	send      func(ctx context.Context)
+	broadcast *broadcast
+}
+
+// start starts the workers, the queue is split between them.
+func (p *broadcaster) start(log *slog.Logger, appMetrics *metrics.Metrics) {
+	p.log, p.metrics = log, appMetrics
+	queueSize := max(p.queueSize/p.workers, 1)
+	p.queues = make([]chan broadcastMessage, p.workers)
+	for idx := range p.queues {
+		p.queues[idx] = make(chan broadcastMessage, queueSize)
+		p.wg.Add(1)
+		go p.work(p.queues[idx])
+	}
+}
+
+// work sends the messages of the queue until it's closed.
+func (p *broadcaster) work(queue <-chan broadcastMessage) {
+	defer p.wg.Done()
+
+	for message := range queue {
+		if p.metrics != nil {
+			p.metrics.BroadcastQueue.Dec()
+		}
+		message.broadcast.deliver(message.ctx, message.send)
+	}
+}
+
+// enqueue queues the message for the worker of the chat, blocking while its queue is full. It reports
+// false if the workers stopped already.
+func (p *broadcaster) enqueue(chatID int64, message broadcastMessage) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return false
+	}
+	if p.metrics != nil {
+		p.metrics.BroadcastQueue.Inc()
+	}
+	// The IDs of groups are negative, the modulo of the unsigned ID still picks the same worker every time.
+	p.queues[uint64(chatID)%uint64(len(p.queues))] <- message //nolint:gosec // wrapping is fine for a hash.
+
+	return true
+}
+
+// stop sends the queued messages and stops the workers.
+func (p *broadcaster) stop() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		for _, queue := range p.queues {
+			close(queue)
+		}
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
+
+// broadcast is the batch of messages sent to the chats for a change set, e.g. the notifications and the
+// alerts. The messages are sent in the background by the workers if there are any, the next broadcast may
+// start before it's done.
+type broadcast struct {
+	bot     *Bot
+	name    string
+	started time.Time
+	// pending counts the messages queued and not sent yet.
+	pending sync.WaitGroup
+	sent    atomic.Int64
+}
+
+// newBroadcast starts a broadcast, it has to be finished once every message is queued.
+func (b *Bot) newBroadcast(name string) *broadcast {
+	if b.metrics != nil {
+		b.metrics.BroadcastsInFlight.Inc()
+	}
+
+	return &broadcast{bot: b, name: name, started: time.Now()}
+}
+
+// send sends a message to the chat with send. The message is queued for a worker if there are any, so
+// send gets a context that isn't canceled with ctx: a message queued is sent even if the broadcast was
+// canceled meanwhile.
+func (br *broadcast) send(ctx context.Context, chatID int64, send func(ctx context.Context)) {
+	ctx = context.WithoutCancel(ctx)
+	br.pending.Add(1)
+
+	pool := br.bot.broadcaster
+	if pool == nil || !pool.enqueue(chatID, broadcastMessage{ctx: ctx, send: send, broadcast: br}) {
+		br.deliver(ctx, send)
+	}
+}
+
+// deliver sends a message of the broadcast and paces the messages.
+func (br *broadcast) deliver(ctx context.Context, send func(ctx context.Context)) {
+	defer br.pending.Done()
+
+	send(ctx)
+	br.sent.Add(1)
+	if br.bot.metrics != nil {
+		br.bot.metrics.BroadcastMessages.Inc()
+	}
+	time.Sleep(broadcastPace)
+}
+
+// finish logs the broadcast and observes its duration once its messages were sent. It doesn't wait for
+// them, the workers send them in the background.
+func (br *broadcast) finish(ctx context.Context) {
+	done := func() {
+		took := time.Since(br.started)
+		br.bot.log.InfoContext(ctx, "Broadcast finished",
+			"broadcast", br.name, "messages", br.sent.Load(), "took", took)
+		if br.bot.metrics != nil {
+			br.bot.metrics.BroadcastsInFlight.Dec()
+			br.bot.metrics.BroadcastDuration.Observe(took.Seconds())
+		}
+	}
+	if br.bot.broadcaster == nil {
+		done()
+		return
+	}
+
+	go func() {
+		br.pending.Wait()
+		done()
+	}()
+}
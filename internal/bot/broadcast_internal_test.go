@@ -0,0 +1,83 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroadcast(t *testing.T) {
+	t.Parallel()
+
+	appMetrics := metrics.New()
+	testBot := &Bot{log: slog.Default(), metrics: appMetrics}
+	WithBroadcastWorkers(2, 4)(testBot)
+	require.NotNil(t, testBot.broadcaster)
+	testBot.broadcaster.start(testBot.log, appMetrics)
+
+	var mu sync.Mutex
+	sent := make(map[int64][]int)
+	br := testBot.newBroadcast("test")
+	for idx := range 3 {
+		for _, chatID := range []int64{-100, 7, 8} {
+			br.send(t.Context(), chatID, func(context.Context) {
+				mu.Lock()
+				defer mu.Unlock()
+				sent[chatID] = append(sent[chatID], idx)
+			})
+		}
+	}
+	br.finish(t.Context())
+	testBot.broadcaster.stop()
+
+	assert.Equal(t, map[int64][]int{-100: {0, 1, 2}, 7: {0, 1, 2}, 8: {0, 1, 2}}, sent,
+		"every message is sent, the ones of a chat in order")
+	assert.InDelta(t, 9, testutil.ToFloat64(appMetrics.BroadcastMessages), 0)
+	assert.InDelta(t, 0, testutil.ToFloat64(appMetrics.BroadcastQueue), 0)
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(appMetrics.BroadcastsInFlight) == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, testutil.CollectAndCount(appMetrics.BroadcastDuration))
+
+	t.Run("messages queued once the workers stopped are sent right away", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		var sendErr error
+		called := false
+
+		late := testBot.newBroadcast("late")
+		late.send(ctx, 7, func(ctx context.Context) {
+			called, sendErr = true, ctx.Err()
+		})
+		late.finish(ctx)
+
+		assert.True(t, called)
+		assert.NoError(t, sendErr, "a message is sent even if the broadcast was canceled")
+	})
+}
+
+func TestBroadcast_WithoutWorkers(t *testing.T) {
+	t.Parallel()
+
+	testBot := &Bot{log: slog.Default()}
+	WithBroadcastWorkers(0, 100)(testBot)
+	require.Nil(t, testBot.broadcaster)
+
+	var sent []int64
+	br := testBot.newBroadcast("test")
+	for _, chatID := range []int64{3, 1, 2} {
+		br.send(t.Context(), chatID, func(context.Context) { sent = append(sent, chatID) })
+	}
+	br.finish(t.Context())
+
+	assert.Equal(t, []int64{3, 1, 2}, sent, "the messages are sent one after the other")
+}
@@ -0,0 +1,44 @@
+package bot
+
+import "gopkg.in/telebot.v4"
+
+// Button is a link button added to every notification, e.g. to an order form.
+type Button struct {
+	Text string
+	URL  string
+}
+
+// WithButtons adds the rows of link buttons to every notification, below the feedback buttons.
+func WithButtons(rows [][]Button) Option {
+	return func(b *Bot) {
+		b.buttons = rows
+	}
+}
+
+// notificationMarkup returns the inline keyboard of the notifications: the feedback buttons, the full diff
+// button of the run, then the link buttons. It's nil without any. The full diff button needs the run ID,
+// e.g. simulated changes have none.
+func (b *Bot) notificationMarkup(runID string) *telebot.ReplyMarkup {
+	if !b.feedback && runID == "" && len(b.buttons) == 0 {
+		return nil
+	}
+
+	markup := &telebot.ReplyMarkup{}
+	rows := make([]telebot.Row, 0, len(b.buttons)+2)
+	if b.feedback {
+		rows = append(rows, feedbackRow(markup))
+	}
+	if runID != "" {
+		rows = append(rows, diffRow(markup, runID))
+	}
+	for _, buttons := range b.buttons {
+		row := make(telebot.Row, 0, len(buttons))
+		for _, button := range buttons {
+			row = append(row, markup.URL(button.Text, button.URL))
+		}
+		rows = append(rows, row)
+	}
+	markup.Inline(rows...)
+
+	return markup
+}
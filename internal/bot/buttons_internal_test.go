@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationMarkup(t *testing.T) {
+	t.Parallel()
+
+	buttons := [][]Button{
+		{{Text: "Order form", URL: "https://example.com/order"}, {Text: "Catalog", URL: "https://example.com"}},
+		{{Text: "Contact sales", URL: "tg://resolve?domain=sales"}},
+	}
+
+	t.Run("none without feedback and buttons", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Nil(t, (&Bot{}).notificationMarkup(""))
+	})
+
+	t.Run("link buttons", func(t *testing.T) {
+		t.Parallel()
+
+		markup := (&Bot{buttons: buttons}).notificationMarkup("")
+
+		require.NotNil(t, markup)
+		require.Len(t, markup.InlineKeyboard, 2)
+		require.Len(t, markup.InlineKeyboard[0], 2)
+		assert.Equal(t, "Order form", markup.InlineKeyboard[0][0].Text)
+		assert.Equal(t, "https://example.com/order", markup.InlineKeyboard[0][0].URL)
+		assert.Equal(t, "tg://resolve?domain=sales", markup.InlineKeyboard[1][0].URL)
+	})
+
+	t.Run("link buttons below the feedback buttons", func(t *testing.T) {
+		t.Parallel()
+
+		markup := (&Bot{buttons: buttons, feedback: true}).notificationMarkup("")
+
+		require.NotNil(t, markup)
+		require.Len(t, markup.InlineKeyboard, 3)
+		assert.Equal(t, "👍 Useful", markup.InlineKeyboard[0][0].Text)
+		assert.Equal(t, "Order form", markup.InlineKeyboard[1][0].Text)
+	})
+
+	t.Run("full diff button of the run", func(t *testing.T) {
+		t.Parallel()
+
+		markup := (&Bot{buttons: buttons, feedback: true}).notificationMarkup("run-1")
+
+		require.NotNil(t, markup)
+		require.Len(t, markup.InlineKeyboard, 4)
+		assert.Equal(t, "📄 Full diff", markup.InlineKeyboard[1][0].Text)
+		assert.Equal(t, diffUnique, markup.InlineKeyboard[1][0].Unique)
+		assert.Equal(t, "run-1", markup.InlineKeyboard[1][0].Data)
+		assert.Equal(t, "Order form", markup.InlineKeyboard[2][0].Text)
+	})
+}
@@ -0,0 +1,174 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/dedup"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+// ErrCannotPost is returned when the bot isn't an administrator allowed to post in the channel.
+var ErrCannotPost = errors.New("the bot is not allowed to post in the channel")
+
+// Channel configures posting to a Telegram channel the bot is an administrator of. Unlike chats,
+// the channel gets every change unfiltered, has no topics and doesn't need to subscribe.
+type Channel struct {
+	ID int64 // ID of the channel, e.g. -1001234567890.
+	// Silent posts without a notification sound.
+	Silent bool
+	// PinSummary pins the daily summary in place of the previously pinned one.
+	PinSummary bool
+}
+
+// WithChannel also posts change notifications and the daily summary to the channel.
+func WithChannel(channel Channel) Option {
+	return func(b *Bot) {
+		b.channel = channel
+	}
+}
+
+// CheckChannel verifies that the bot can post to the configured channel.
+func (b *Bot) CheckChannel() error {
+	if b.channel.ID == 0 {
+		return nil
+	}
+
+	member, err := b.bot.ChatMemberOf(&telebot.Chat{ID: b.channel.ID}, b.me)
+	if err != nil {
+		return fmt.Errorf("failed to get channel member: %w", err)
+	}
+
+	if member.Role != telebot.Creator && (member.Role != telebot.Administrator || !member.CanPostMessages) {
+		return ErrCannotPost
+	}
+
+	return nil
+}
+
+// sendChannelNotification posts the changes to the channel, if there is one.
+func (b *Bot) sendChannelNotification(
+	ctx context.Context,
+	changes *models.Changes,
+	trends map[models.ProductRef]models.PriceTrend,
+	watchers map[string]int,
+) error {
+	if b.channel.ID == 0 {
+		return nil
+	}
+
+	notif, err := b.buildNotification(changes, trends, watchers, b.templateVariant(b.channel.ID))
+	if err != nil {
+		return err
+	}
+
+	if !b.dedup.Claim(dedup.ChatRecipient(b.channel.ID), notif.fingerprint) {
+		b.log.DebugContext(ctx, "Skipping duplicate channel notification", "channelID", b.channel.ID)
+		return nil
+	}
+
+	b.deliver(ctx, b.channel.ID, notif, 0, b.channel.Silent)
+
+	return nil
+}
+
+// PostChannelSummary posts the summary of the changes detected over the last day to the channel and
+// pins it if configured. It's called from a single goroutine, the scheduler loop.
+func (b *Bot) PostChannelSummary(ctx context.Context, records []models.ChangeRecord) error {
+	if b.channel.ID == 0 {
+		return nil
+	}
+
+	channel := &telebot.Chat{ID: b.channel.ID}
+	opts := &telebot.SendOptions{ParseMode: telebot.ModeMarkdown, DisableNotification: b.channel.Silent}
+	msg, err := b.bot.Send(channel, formatDailySummary(records, time.Now()), opts)
+	if err != nil {
+		return fmt.Errorf("failed to post channel summary: %w", throttled(err))
+	}
+
+	if !b.channel.PinSummary {
+		return nil
+	}
+
+	// Only the latest summary stays pinned, the previous one is unknown after a restart.
+	if b.pinnedSummary != 0 {
+		if err = b.bot.Unpin(channel, b.pinnedSummary); err != nil {
+			b.log.WarnContext(ctx, "Failed to unpin previous channel summary", "messageID", b.pinnedSummary, "err", err)
+		}
+	}
+
+	var pinOpts []any
+	if b.channel.Silent {
+		pinOpts = append(pinOpts, telebot.Silent)
+	}
+	if err = b.bot.Pin(msg, pinOpts...); err != nil {
+		return fmt.Errorf("failed to pin channel summary: %w", err)
+	}
+	b.pinnedSummary = msg.ID
+
+	return nil
+}
+
+// formatDailySummary describes the changes detected over the day ending at the given time.
+func formatDailySummary(records []models.ChangeRecord, now time.Time) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "📌 *Daily summary (%s)*\n", now.Format("02.01.2006"))
+
+	if len(records) == 0 {
+		builder.WriteString("No changes in the last 24 hours.")
+		return builder.String()
+	}
+
+	kinds := []string{models.KindAdded, models.KindChanged, models.KindRenamed, models.KindRemoved}
+	counts := make(map[string]int, len(kinds))
+	for _, record := range records {
+		counts[record.Kind]++
+	}
+	parts := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		if counts[kind] > 0 {
+			emoji, title := kindLabel(kind)
+			parts = append(parts, fmt.Sprintf("%s %s: %d", emoji, title, counts[kind]))
+		}
+	}
+	builder.WriteString(strings.Join(parts, " · "))
+	builder.WriteString("\n")
+
+	for _, record := range records {
+		emoji, _ := kindLabel(record.Kind)
+		switch {
+		case record.Kind == models.KindRenamed:
+			fmt.Fprintf(&builder, "\n%s `%s` -> `%s`", emoji, record.OldModel, record.Model)
+		case record.Kind == models.KindChanged && record.OldPrice != record.Price:
+			fmt.Fprintf(&builder, "\n%s `%s`: %s -> *%s*", emoji, record.Model, record.OldPrice, record.Price)
+		default:
+			fmt.Fprintf(&builder, "\n%s `%s`", emoji, record.Model)
+		}
+	}
+
+	// Truncate the message if it exceeds Telegram's limit.
+	if builder.Len() > maxMessageLength {
+		trimmedString := builder.String()[:maxMessageLength-50] // Leave space for the warning.
+		return trimmedString + "\n\n... (the message was truncated)"
+	}
+
+	return builder.String()
+}
+
+// kindLabel returns the emoji and the title of a change kind.
+func kindLabel(kind string) (string, string) {
+	switch kind {
+	case models.KindAdded:
+		return "✅", "Added"
+	case models.KindChanged:
+		return "🔄", "Changed"
+	case models.KindRenamed:
+		return "✏️", "Renamed"
+	default:
+		return "❌", "Removed"
+	}
+}
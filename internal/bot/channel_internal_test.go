@@ -0,0 +1,171 @@
+package bot
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+const channelID = int64(-1001234567890)
+
+func TestCheckChannel(t *testing.T) {
+	t.Parallel()
+
+	channel := &telebot.Chat{ID: channelID}
+	tests := []struct {
+		name    string
+		member  *telebot.ChatMember
+		wantErr error
+	}{
+		{"creator", &telebot.ChatMember{Role: telebot.Creator}, nil},
+		{
+			"administrator allowed to post",
+			&telebot.ChatMember{Role: telebot.Administrator, Rights: telebot.Rights{CanPostMessages: true}},
+			nil,
+		},
+		{"administrator not allowed to post", &telebot.ChatMember{Role: telebot.Administrator}, ErrCannotPost},
+		{"member", &telebot.ChatMember{Role: telebot.Member}, ErrCannotPost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockBot := mocks.NewAPI(t)
+			mockBot.On("ChatMemberOf", channel, mock.Anything).Return(tt.member, nil).Once()
+			testBot := Bot{bot: mockBot, log: slog.Default(), channel: Channel{ID: channelID}}
+
+			err := testBot.CheckChannel()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("no channel", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{bot: mocks.NewAPI(t), log: slog.Default()}
+		require.NoError(t, testBot.CheckChannel())
+	})
+}
+
+func TestSendChangesNotification_Channel(t *testing.T) {
+	t.Parallel()
+
+	changes := &models.Changes{Added: []models.Product{{Model: "C3", Type: "Diver", Price: "300"}}}
+
+	mockBot := mocks.NewAPI(t)
+	mockRepo := mocks.NewRepository(t)
+	mockRepo.On("GetSubscribedChats", mock.Anything).Return(nil, nil).Once()
+	expectRecordedProducts(mockRepo)
+	mockBot.On("Send", &telebot.Chat{ID: channelID}, mock.MatchedBy(func(text string) bool {
+		return strings.Contains(text, "`C3`")
+	}), &telebot.SendOptions{ParseMode: telebot.ModeMarkdown, DisableNotification: true}).
+		Return(&telebot.Message{}, nil).Once()
+
+	testBot := Bot{
+		bot:          mockBot,
+		log:          slog.Default(),
+		repo:         mockRepo,
+		filterGroups: map[string][]string{"": {"Chrono"}},
+		channel:      Channel{ID: channelID, Silent: true},
+	}
+
+	require.NoError(t, testBot.SendChangesNotification(t.Context(), changes),
+		"the channel gets every change, even without subscribers")
+}
+
+func TestPostChannelSummary(t *testing.T) {
+	t.Parallel()
+
+	channel := &telebot.Chat{ID: channelID}
+	records := []models.ChangeRecord{{Kind: models.KindAdded, Model: "A1"}}
+
+	t.Run("pinned in place of the previous summary", func(t *testing.T) {
+		t.Parallel()
+
+		posted := &telebot.Message{ID: 12, Chat: channel}
+		mockBot := mocks.NewAPI(t)
+		mockBot.On("Send", channel, mock.MatchedBy(func(text string) bool {
+			return strings.Contains(text, "Daily summary")
+		}), &telebot.SendOptions{ParseMode: telebot.ModeMarkdown}).Return(posted, nil).Once()
+		mockBot.On("Unpin", channel, 7).Return(assert.AnError).Once()
+		mockBot.On("Pin", posted).Return(nil).Once()
+		testBot := Bot{bot: mockBot, log: slog.Default(), channel: Channel{ID: channelID, PinSummary: true}}
+		testBot.pinnedSummary = 7
+
+		require.NoError(t, testBot.PostChannelSummary(t.Context(), records))
+		assert.Equal(t, 12, testBot.pinnedSummary)
+	})
+
+	t.Run("silent summary is pinned silently", func(t *testing.T) {
+		t.Parallel()
+
+		posted := &telebot.Message{ID: 12, Chat: channel}
+		mockBot := mocks.NewAPI(t)
+		mockBot.On("Send", channel, mock.AnythingOfType("string"), mock.Anything).Return(posted, nil).Once()
+		mockBot.On("Pin", posted, telebot.Silent).Return(nil).Once()
+		testBot := Bot{
+			bot: mockBot, log: slog.Default(), channel: Channel{ID: channelID, Silent: true, PinSummary: true},
+		}
+
+		require.NoError(t, testBot.PostChannelSummary(t.Context(), records))
+	})
+
+	t.Run("not pinned", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockBot.On("Send", channel, mock.AnythingOfType("string"), mock.Anything).
+			Return(&telebot.Message{ID: 12}, nil).Once()
+		testBot := Bot{bot: mockBot, log: slog.Default(), channel: Channel{ID: channelID}}
+
+		require.NoError(t, testBot.PostChannelSummary(t.Context(), records))
+		assert.Zero(t, testBot.pinnedSummary)
+	})
+
+	t.Run("error: cannot post", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockBot.On("Send", channel, mock.AnythingOfType("string"), mock.Anything).Return(nil, assert.AnError).Once()
+		testBot := Bot{bot: mockBot, log: slog.Default(), channel: Channel{ID: channelID, PinSummary: true}}
+
+		require.ErrorIs(t, testBot.PostChannelSummary(t.Context(), records), assert.AnError)
+	})
+}
+
+func TestFormatDailySummary(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	empty := formatDailySummary(nil, now)
+	assert.Equal(t, "📌 *Daily summary (01.03.2025)*\nNo changes in the last 24 hours.", empty)
+
+	text := formatDailySummary([]models.ChangeRecord{
+		{Kind: models.KindAdded, Model: "A1"},
+		{Kind: models.KindChanged, Model: "B2", OldPrice: "100", Price: "120"},
+		{Kind: models.KindChanged, Model: "C3", OldQuantity: "1", Quantity: "2"},
+		{Kind: models.KindRenamed, OldModel: "D4", Model: "D5"},
+		{Kind: models.KindRemoved, Model: "E6"},
+	}, now)
+
+	assert.Contains(t, text, "✅ Added: 1 · 🔄 Changed: 2 · ✏️ Renamed: 1 · ❌ Removed: 1\n")
+	assert.Contains(t, text, "\n✅ `A1`")
+	assert.Contains(t, text, "\n🔄 `B2`: 100 -> *120*")
+	assert.Contains(t, text, "\n🔄 `C3`\n")
+	assert.Contains(t, text, "\n✏️ `D4` -> `D5`")
+	assert.Contains(t, text, "\n❌ `E6`")
+}
@@ -0,0 +1,225 @@
+package bot
+
+import (
+	"fmt"
+
+	"gopkg.in/telebot.v4"
+)
+
+// botCommand is a command of the bot: its route, its entry in the native command menu of Telegram and
+// its help.
+type botCommand struct {
+	text        string
+	description string
+	handler     telebot.HandlerFunc
+	// level is who may use the command, accessAdmin commands are listed in the admin chats only.
+	level access
+	// topic groups the command in /help, see helpTopics.
+	topic string
+	// usage explains the arguments of the command in /help, empty if it takes none.
+	usage string
+	// examples are complete commands shown in /help.
+	examples []string
+	// hidden leaves the command out of the menus and /help, as users don't need to find it, e.g. /start.
+	hidden bool
+	// enabled reports whether the bot runs the feature of the command, nil if it always does. The commands
+	// of disabled features are routed, but not listed.
+	enabled func(b *Bot) bool
+}
+
+// commands returns the registry of the commands, in the order they are listed in the menus and /help.
+// The routes, the command menus and /help are all built from it, so a command is added here only.
+func (b *Bot) commands() []botCommand {
+	return []botCommand{
+		{text: "start", handler: b.subscribeHandler, level: accessJoin, topic: topicBasics, hidden: true},
+		{
+			text: "subscribe", description: "Get notified of the changes", handler: b.subscribeHandler,
+			level: accessJoin, topic: topicBasics,
+		},
+		{
+			text: "unsubscribe", description: "Stop the notifications", handler: b.unsubscribeHandler,
+			level: accessPublic, topic: topicBasics,
+		},
+		{
+			// The help lists the commands the chat may use, the admin ones in the admin chats only.
+			text: "help", description: "Show the commands and how to use them", handler: b.helpHandler,
+			level: accessPublic, topic: topicBasics,
+			usage: helpUsage, examples: []string{"/help filters", "/help list"},
+		},
+		{
+			text: "status", description: "Show the availability of the page", handler: b.statusHandler,
+			level: accessAllowed, topic: topicBasics,
+		},
+		{
+			text: "list", description: "List the current products", handler: b.listHandler,
+			level: accessAllowed, topic: topicProducts,
+			usage: listUsage, examples: []string{"/list Diver 100-500 instock", "/list sort:-price"},
+		},
+		{
+			text: "search", description: "Search the products", handler: b.searchHandler,
+			level: accessAllowed, topic: topicProducts,
+			usage: searchUsage, examples: []string{"/search casio diver"},
+		},
+		{
+			text: "price", description: "Show the price history of a product", handler: b.priceHandler,
+			level: accessAllowed, topic: topicWatches,
+			usage: priceUsage,
+		},
+		{
+			text: "best", description: "Compare the offers of a product across targets", handler: b.bestHandler,
+			level: accessAllowed, topic: topicProducts,
+			usage: bestUsage, examples: []string{"/best SRPD55"},
+		},
+		{
+			text: "stateat", description: "Export the products listed at a past date", handler: b.stateAtHandler,
+			level: accessAllowed, topic: topicProducts,
+			usage: stateAtUsage, examples: []string{"/stateat 2026-03-03"},
+		},
+		{
+			text: "wishlist", description: "Watch models and a budget", handler: b.wishlistHandler,
+			level: accessAllowed, topic: topicWatches,
+			usage: wishlistUsage, examples: []string{"/wishlist add SRPD55", "/wishlist budget 500"},
+		},
+		{
+			text: "rule", description: "Get alerted of the changes matching a condition", handler: b.ruleHandler,
+			level: accessAllowed, topic: topicWatches,
+			usage: ruleUsage(), examples: []string{`/rule add divers product.type == "Diver"`, "/rule remove divers"},
+		},
+		{
+			text: "dmme", description: "Get changes of this group as direct messages", handler: b.dmHandler,
+			level: accessAllowed, topic: topicFilters,
+			usage: dmUsage(), examples: []string{"/dmme price", "/dmme off"},
+		},
+		{
+			text: "settings", description: "Change the notification settings", handler: b.settingsHandler,
+			level: accessAllowed, topic: topicFilters,
+		},
+		{
+			text: "silent", description: "Deliver change categories silently", handler: b.silentHandler,
+			level: accessAllowed, topic: topicFilters,
+			usage: silentUsage(), examples: []string{"/silent quantity", "/silent off"},
+		},
+		{
+			text: "settopic", description: "Send the notifications to this topic", handler: b.setTopicHandler,
+			level: accessAllowed, topic: topicFilters,
+		},
+		{
+			text: "targets", description: "List the monitored targets", handler: b.targetsHandler,
+			level: accessAllowed, topic: topicProducts,
+		},
+		{
+			text: "forgetme", description: "Delete everything stored about this chat", handler: b.forgetHandler,
+			level: accessPublic, topic: topicBasics,
+		},
+		{
+			// The wizards run in the admin chats, the handler cancels nothing in the others.
+			text: "cancel", description: "Cancel adding a target", handler: b.cancelHandler,
+			level: accessPublic, topic: topicBasics, enabled: hasTargets,
+		},
+		{
+			text: "invite", description: "Invite a chat to a filter group", handler: b.inviteHandler,
+			level: accessAdmin, topic: topicAdmin,
+			usage: inviteUsage, enabled: hasFilterGroups,
+		},
+		{
+			text: "allow", description: "Allow a chat to use the bot", handler: b.allowHandler,
+			level: accessAdmin, topic: topicAdmin,
+			usage: allowUsage, examples: []string{"/allow -1001234567890"},
+		},
+		{
+			text: "disallow", description: "Revoke the access of a chat", handler: b.disallowHandler,
+			level: accessAdmin, topic: topicAdmin,
+			usage: disallowUsage,
+		},
+		{
+			text: "previewtemplate", description: "Preview the notification templates",
+			handler: b.previewTemplateHandler, level: accessAdmin, topic: topicAdmin,
+			usage: previewTemplateUsage,
+		},
+		{
+			text: "addtarget", description: "Add a target to monitor", handler: b.addTargetHandler,
+			level: accessAdmin, topic: topicAdmin,
+			usage: addTargetUsage, examples: []string{"/addtarget outlet"}, enabled: hasTargets,
+		},
+		{
+			text: "removetarget", description: "Stop monitoring a target", handler: b.removeTargetHandler,
+			level: accessAdmin, topic: topicAdmin,
+			usage: removeTargetUsage, enabled: hasTargets,
+		},
+		{
+			text: "target", description: "Pause or resume a target", handler: b.targetHandler,
+			level: accessAdmin, topic: topicAdmin,
+			usage: targetUsage, examples: []string{"/target disable outlet"}, enabled: hasTargets,
+		},
+		{
+			text: "loglevel", description: "Show or change the log levels", handler: b.logLevelHandler,
+			level: accessAdmin, topic: topicAdmin,
+			usage: logLevelUsage, examples: []string{"/loglevel parser debug"}, enabled: hasLogLevels,
+		},
+		{
+			text: "stats", description: "Show the subscriber statistics", handler: b.statsHandler,
+			level: accessAdmin, topic: topicAdmin,
+		},
+		{
+			text: "annotate", description: "Attach a note to a detected change", handler: b.annotateHandler,
+			level: accessAdmin, topic: topicAdmin,
+			usage: annotateUsage,
+		},
+		{
+			text: "simulate", description: "Send synthetic changes to check the notifications",
+			handler: b.simulateHandler, level: accessAdmin, topic: topicAdmin,
+			usage: simulateUsage, examples: []string{"/simulate changed removed"},
+		},
+	}
+}
+
+func hasTargets(b *Bot) bool      { return b.targets != nil }
+func hasFilterGroups(b *Bot) bool { return len(b.filterGroups) > 0 }
+func hasLogLevels(b *Bot) bool    { return b.logLevels != nil }
+
+// availableCommands returns the listed commands of the enabled features the chats may use, with admin
+// of the admin chats.
+func (b *Bot) availableCommands(admin bool) []botCommand {
+	var commands []botCommand
+	for _, command := range b.commands() {
+		if command.hidden || (command.level == accessAdmin && !admin) ||
+			(command.enabled != nil && !command.enabled(b)) {
+			continue
+		}
+		commands = append(commands, command)
+	}
+
+	return commands
+}
+
+// commandMenu returns the commands of the enabled features listed in the menu of the chats, with admin
+// of the admin chats.
+func (b *Bot) commandMenu(admin bool) []telebot.Command {
+	var commands []telebot.Command
+	for _, command := range b.availableCommands(admin) {
+		commands = append(commands, telebot.Command{Text: command.text, Description: command.description})
+	}
+
+	return commands
+}
+
+// RegisterCommands sets the command menus of the bot: the default one lists the public commands, the
+// one of every admin chat the admin commands too. The menus are replaced on every start, so they follow
+// the features enabled by the configuration.
+func (b *Bot) RegisterCommands() error {
+	defaultScope := telebot.CommandScope{Type: telebot.CommandScopeDefault}
+	if err := b.bot.SetCommands(b.commandMenu(false), defaultScope); err != nil {
+		return fmt.Errorf("failed to set the default command menu: %w", err)
+	}
+
+	adminMenu := b.commandMenu(true)
+	for chatID := range b.adminChats {
+		scope := telebot.CommandScope{Type: telebot.CommandScopeChat, ChatID: chatID}
+		if err := b.bot.SetCommands(adminMenu, scope); err != nil {
+			return fmt.Errorf("failed to set the command menu of admin chat %d: %w", chatID, err)
+		}
+	}
+	b.log.Info("Registered command menus", "admin_chats", len(b.adminChats))
+
+	return nil
+}
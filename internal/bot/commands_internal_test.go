@@ -0,0 +1,144 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/logging"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+// menuTexts returns the commands of the menu without their descriptions.
+func menuTexts(commands []telebot.Command) []string {
+	texts := make([]string, 0, len(commands))
+	for _, command := range commands {
+		texts = append(texts, command.Text)
+	}
+
+	return texts
+}
+
+func TestCommandMenu(t *testing.T) {
+	t.Parallel()
+
+	t.Run("features disabled", func(t *testing.T) {
+		t.Parallel()
+		testBot := Bot{}
+
+		public := menuTexts(testBot.commandMenu(false))
+		admin := menuTexts(testBot.commandMenu(true))
+
+		assert.Contains(t, public, "subscribe")
+		assert.NotContains(t, public, "stats", "admin commands are listed in admin chats only")
+		assert.NotContains(t, public, "cancel")
+		assert.Contains(t, admin, "stats")
+		assert.NotContains(t, admin, "addtarget", "commands of disabled features are not listed")
+		assert.NotContains(t, admin, "loglevel")
+		assert.NotContains(t, admin, "invite")
+	})
+
+	t.Run("features enabled", func(t *testing.T) {
+		t.Parallel()
+		testBot := Bot{
+			targets:      mocks.NewTargetManager(t),
+			logLevels:    logging.NewLevels(slog.LevelInfo, nil),
+			filterGroups: map[string][]string{"divers": {"Diver"}},
+		}
+
+		public := menuTexts(testBot.commandMenu(false))
+		admin := menuTexts(testBot.commandMenu(true))
+
+		assert.Contains(t, public, "cancel")
+		assert.NotContains(t, public, "addtarget")
+		assert.Subset(t, admin, []string{"addtarget", "removetarget", "target", "loglevel", "invite"})
+	})
+
+	t.Run("every listed command has a route", func(t *testing.T) {
+		t.Parallel()
+		mockBot := mocks.NewAPI(t)
+		routes := make(map[any]bool)
+		mockBot.On("Handle", mock.Anything, mock.AnythingOfType("telebot.HandlerFunc")).
+			Run(func(args mock.Arguments) { routes[args.Get(0)] = true })
+		testBot := Bot{
+			bot:       mockBot,
+			log:       slog.Default(),
+			targets:   mocks.NewTargetManager(t),
+			logLevels: logging.NewLevels(slog.LevelInfo, nil),
+		}
+		testBot.registerRoutes()
+
+		for _, command := range testBot.commandMenu(true) {
+			assert.True(t, routes["/"+command.Text], "/%s has no route", command.Text)
+		}
+	})
+}
+
+func TestCommands(t *testing.T) {
+	t.Parallel()
+
+	topics := make(map[string]bool)
+	for _, topic := range helpTopics {
+		topics[topic.name] = true
+	}
+	names := make(map[string]bool)
+	for _, command := range (&Bot{}).commands() {
+		assert.False(t, names[command.text], "/%s is registered twice", command.text)
+		names[command.text] = true
+		assert.NotNil(t, command.handler, "/%s has no handler", command.text)
+		assert.True(t, topics[command.topic], "/%s has no help topic", command.text)
+		assert.Equal(t, command.level == accessAdmin, command.topic == topicAdmin,
+			"/%s is in the wrong topic", command.text)
+		assert.True(t, command.hidden || command.description != "", "/%s has no description", command.text)
+	}
+
+	assert.NotContains(t, menuTexts((&Bot{}).commandMenu(true)), "start", "hidden commands aren't listed")
+}
+
+func TestRegisterRoutes_CommandLevels(t *testing.T) {
+	t.Parallel()
+
+	mockBot := mocks.NewAPI(t)
+	routes := make(map[any]telebot.HandlerFunc)
+	mockBot.On("Handle", mock.Anything, mock.AnythingOfType("telebot.HandlerFunc")).
+		Run(func(args mock.Arguments) { routes[args.Get(0)] = args.Get(1).(telebot.HandlerFunc) })
+	testBot := Bot{bot: mockBot, log: slog.Default(), allowedChats: map[int64]bool{-100: true}}
+	testBot.registerRoutes()
+
+	ctx, api := newTestContext(-100, "")
+	require.NoError(t, routes["/stats"](ctx))
+
+	require.Len(t, api.sent, 1)
+	assert.Contains(t, api.sent[0], "available to administrators only", "the level of the registry is enforced")
+}
+
+func TestRegisterCommands(t *testing.T) {
+	t.Parallel()
+
+	t.Run("default and admin menus", func(t *testing.T) {
+		t.Parallel()
+		mockBot := mocks.NewAPI(t)
+		testBot := Bot{bot: mockBot, log: slog.Default(), adminChats: map[int64]bool{7: true}}
+		mockBot.On("SetCommands", testBot.commandMenu(false), telebot.CommandScope{Type: telebot.CommandScopeDefault}).
+			Return(nil).Once()
+		adminScope := telebot.CommandScope{Type: telebot.CommandScopeChat, ChatID: 7}
+		mockBot.On("SetCommands", testBot.commandMenu(true), adminScope).Return(nil).Once()
+
+		require.NoError(t, testBot.RegisterCommands())
+	})
+
+	t.Run("error: set commands", func(t *testing.T) {
+		t.Parallel()
+		mockBot := mocks.NewAPI(t)
+		testBot := Bot{bot: mockBot, log: slog.Default(), adminChats: map[int64]bool{7: true}}
+		mockBot.On("SetCommands", mock.Anything, mock.Anything).Return(assert.AnError).Once()
+
+		err := testBot.RegisterCommands()
+
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to set the default command menu")
+	})
+}
@@ -0,0 +1,217 @@
+package bot
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+// bestUsage explains the /best command.
+const bestUsage = "Usage: /best <model or EAN>"
+
+// bestHandler handles the /best command: it compares the offers of the product with the model or the
+// EAN across the targets, the cheapest first.
+func (b *Bot) bestHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+
+	model := strings.TrimSpace(ctx.Data())
+	if model == "" {
+		b.sendMessage(ctx, chatID, bestUsage)
+		return nil
+	}
+
+	product := models.Product{Model: model}
+	if strings.Trim(model, "0123456789") == "" {
+		product.Extras = map[string]string{models.EANField: model}
+	}
+	offers, err := b.repo.GetOffers(context.Background(), product)
+	if err != nil {
+		b.log.Error("Failed to get offers", "chatID", chatID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to compare the offers.")
+		return nil
+	}
+	if len(offers) == 0 {
+		b.sendMessage(ctx, chatID, fmt.Sprintf("🤷 No target lists %s.", model))
+		return nil
+	}
+
+	if err = ctx.Send(formatOffers(model, offers), telebot.ModeMarkdown); err != nil {
+		b.log.Error("Failed to send offers", "chatID", chatID, "err", err)
+	}
+
+	return nil
+}
+
+// formatOffers lists the offers of the product, the cheapest first and the ones without a numeric
+// price last.
+func formatOffers(model string, offers []models.Offer) string {
+	sorted := slices.Clone(offers)
+	slices.SortStableFunc(sorted, func(a, b models.Offer) int {
+		priceA, errA := a.Product.PriceValue()
+		priceB, errB := b.Product.PriceValue()
+		switch {
+		case errA != nil || errB != nil:
+			return cmp.Compare(boolRank(errA != nil), boolRank(errB != nil))
+		default:
+			return cmp.Compare(priceA, priceB)
+		}
+	})
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "⚖️ *Offers for* `%s`", model)
+	for idx, offer := range sorted {
+		price := offer.Product.Price
+		if _, err := offer.Product.PriceValue(); err != nil {
+			price = "no price"
+		}
+		fmt.Fprintf(&builder, "\n%d. *%s*: %s `%s`", idx+1, offer.Target, price, offer.Product.Model)
+	}
+
+	return builder.String()
+}
+
+// boolRank orders false before true.
+func boolRank(value bool) int {
+	if value {
+		return 1
+	}
+
+	return 0
+}
+
+// cheapestChange is a product whose cheapest offer moved to another target.
+type cheapestChange struct {
+	productType string
+	text        string
+}
+
+// SendPriceComparisons tells the subscribers when the changes detected on the target made another target
+// the cheapest one for a product, e.g. "b is now cheapest for X (1,199 vs 1,250 at main)". It happens on
+// a price drop, a price rise, an added or a removed product of the target. The chats get the products of
+// their filter groups.
+func (b *Bot) SendPriceComparisons(ctx context.Context, target string, changes *models.Changes) error {
+	const opn = "bot.SendPriceComparisons"
+
+	var cheapest []cheapestChange
+	seen := make(map[string]bool)
+	collect := func(oldProduct, newProduct *models.Product) error {
+		change, err := b.cheapestChange(ctx, target, oldProduct, newProduct)
+		if err != nil {
+			return fmt.Errorf("%s: %w", opn, err)
+		}
+		if change != nil && !seen[change.text] {
+			seen[change.text] = true
+			cheapest = append(cheapest, *change)
+		}
+		return nil
+	}
+	for _, p := range changes.Added {
+		if err := collect(nil, &p); err != nil {
+			return err
+		}
+	}
+	for _, p := range changes.Removed {
+		if err := collect(&p, nil); err != nil {
+			return err
+		}
+	}
+	for _, change := range changes.Changed {
+		if change.Old.Price == change.New.Price {
+			continue
+		}
+		if err := collect(&change.Old, &change.New); err != nil {
+			return err
+		}
+	}
+	if len(cheapest) == 0 {
+		return nil
+	}
+
+	subscribers, err := b.repo.GetSubscribedChats(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to get subscribers: %w", opn, err)
+	}
+	chatSettings, err := b.repo.GetChatSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to get chat settings: %w", opn, err)
+	}
+
+	for _, chatID := range subscribers {
+		settings := chatSettings[chatID]
+		text := formatCheapestChanges(cheapest, b.filterGroups[settings.FilterGroup])
+		if text == "" {
+			continue
+		}
+		opts := &telebot.SendOptions{ParseMode: telebot.ModeMarkdown, ThreadID: settings.ThreadID}
+		if _, err = b.sendTo(ctx, chatID, text, opts); err != nil {
+			b.log.ErrorContext(ctx, "Failed to send price comparison", "chatID", chatID, "err", err)
+		}
+	}
+
+	return nil
+}
+
+// cheapestChange compares the cheapest offer of the product before and after its change on the target,
+// a nil product is one the target didn't list. It returns nil if the cheapest target stayed the same.
+func (b *Bot) cheapestChange(
+	ctx context.Context, target string, oldProduct, newProduct *models.Product,
+) (*cheapestChange, error) {
+	product := newProduct
+	if product == nil {
+		product = oldProduct
+	}
+	offers, err := b.repo.GetOffers(ctx, *product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get offers of %s: %w", product.Model, err)
+	}
+
+	// The stored offers are the ones after the change, the ones before have the old product instead.
+	before := slices.DeleteFunc(slices.Clone(offers), func(offer models.Offer) bool {
+		return offer.Target == target && offer.Product.Category == product.Category &&
+			offer.Product.Model == product.Model
+	})
+	if oldProduct != nil {
+		before = append(before, models.Offer{Target: target, Product: *oldProduct})
+	}
+
+	cheapestBefore, okBefore := models.CheapestOffer(before)
+	cheapestAfter, okAfter := models.CheapestOffer(offers)
+	if !okBefore || !okAfter || cheapestBefore.Target == cheapestAfter.Target {
+		return nil, nil //nolint:nilnil // The cheapest target didn't change.
+	}
+	runnerUp, ok := models.CheapestOffer(slices.DeleteFunc(slices.Clone(offers), func(offer models.Offer) bool {
+		return offer.Target == cheapestAfter.Target
+	}))
+	if !ok {
+		return nil, nil //nolint:nilnil // A single target has a price, there's nothing to compare.
+	}
+
+	return &cheapestChange{
+		productType: cheapestAfter.Product.Type,
+		text: fmt.Sprintf("*%s* is now cheapest for `%s` (%s vs %s at %s)", cheapestAfter.Target,
+			cheapestAfter.Product.Model, cheapestAfter.Product.Price, runnerUp.Product.Price, runnerUp.Target),
+	}, nil
+}
+
+// formatCheapestChanges describes the changes of the cheapest targets of the products with the types,
+// all of them without types. It's empty if none has one of the types.
+func formatCheapestChanges(changes []cheapestChange, types []string) string {
+	var lines []string
+	for _, change := range changes {
+		if len(types) == 0 || slices.ContainsFunc(types, func(t string) bool {
+			return strings.EqualFold(t, change.productType)
+		}) {
+			lines = append(lines, "• "+change.text)
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return "⚖️ *Cheapest offers changed*\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,169 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+func TestBestHandler(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(-100)
+
+	t.Run("offers are listed cheapest first", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetOffers", mock.Anything, models.Product{Model: "GA-2100"}).Return([]models.Offer{
+			{Target: "main", Product: models.Product{Model: "GA-2100", Price: "1,250"}},
+			{Target: "c", Product: models.Product{Model: "GA 2100", Price: "on request"}},
+			{Target: "b", Product: models.Product{Model: "ga2100", Price: "1,199"}},
+		}, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo}
+		ctx, api := newTestContext(chatID, "GA-2100")
+
+		require.NoError(t, testBot.bestHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Equal(t, "⚖️ *Offers for* `GA-2100`\n1. *b*: 1,199 `ga2100`\n2. *main*: 1,250 `GA-2100`\n"+
+			"3. *c*: no price `GA 2100`", api.sent[0])
+	})
+
+	t.Run("digits are matched as an EAN too", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetOffers", mock.Anything, models.Product{
+			Model: "4971850000017", Extras: map[string]string{models.EANField: "4971850000017"},
+		}).Return(nil, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo}
+		ctx, api := newTestContext(chatID, "4971850000017")
+
+		require.NoError(t, testBot.bestHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Equal(t, "🤷 No target lists 4971850000017.", api.sent[0])
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), repo: mocks.NewRepository(t)}
+		ctx, api := newTestContext(chatID, " ")
+
+		require.NoError(t, testBot.bestHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Equal(t, bestUsage, api.sent[0])
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetOffers", mock.Anything, mock.Anything).Return(nil, assert.AnError).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo}
+		ctx, api := newTestContext(chatID, "GA-2100")
+
+		require.NoError(t, testBot.bestHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "internal error")
+	})
+}
+
+func TestSendPriceComparisons(t *testing.T) {
+	t.Parallel()
+
+	offer := func(target, price string) models.Offer {
+		return models.Offer{Target: target, Product: models.Product{Model: "GA-2100", Type: "Sport", Price: price}}
+	}
+
+	t.Run("chats are told when another target becomes the cheapest", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockRepo := mocks.NewRepository(t)
+		oldProduct, newProduct := offer("b", "1,300").Product, offer("b", "1,199").Product
+		mockRepo.On("GetOffers", mock.Anything, newProduct).Return([]models.Offer{
+			offer("main", "1,250"), offer("b", "1,199"), offer("c", "1,280"),
+		}, nil).Once()
+		mockRepo.On("GetSubscribedChats", mock.Anything).Return([]int64{1, 2}, nil).Once()
+		mockRepo.On("GetChatSettings", mock.Anything).Return(map[int64]models.ChatSettings{
+			1: {ThreadID: 7}, 2: {FilterGroup: "dress"},
+		}, nil).Once()
+		mockBot.On("Send", &telebot.Chat{ID: 1},
+			"⚖️ *Cheapest offers changed*\n• *b* is now cheapest for `GA-2100` (1,199 vs 1,250 at main)",
+			markdownOpts(7),
+		).Return(&telebot.Message{}, nil).Once()
+		testBot := Bot{
+			bot: mockBot, log: slog.Default(), repo: mockRepo,
+			filterGroups: map[string][]string{"dress": {"Dress"}},
+		}
+
+		err := testBot.SendPriceComparisons(t.Context(), "b", &models.Changes{
+			Changed: []models.ChangeInfo{{Old: oldProduct, New: newProduct}},
+		})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("removed cheapest offer", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetOffers", mock.Anything, offer("b", "1,199").Product).Return([]models.Offer{
+			offer("main", "1,250"), offer("c", "1,280"),
+		}, nil).Once()
+		mockRepo.On("GetSubscribedChats", mock.Anything).Return([]int64{1}, nil).Once()
+		mockRepo.On("GetChatSettings", mock.Anything).Return(map[int64]models.ChatSettings{}, nil).Once()
+		mockBot.On("Send", &telebot.Chat{ID: 1},
+			"⚖️ *Cheapest offers changed*\n• *main* is now cheapest for `GA-2100` (1,250 vs 1,280 at c)",
+			markdownOpts(0),
+		).Return(nil, assert.AnError).Once()
+		testBot := Bot{bot: mockBot, log: slog.Default(), repo: mockRepo}
+
+		err := testBot.SendPriceComparisons(t.Context(), "b", &models.Changes{
+			Removed: []models.Product{offer("b", "1,199").Product},
+		})
+
+		require.NoError(t, err, "a chat failing to get the message doesn't stop the others")
+	})
+
+	t.Run("nothing is sent while the cheapest target stays", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetOffers", mock.Anything, mock.Anything).Return([]models.Offer{
+			offer("main", "1,250"), offer("b", "1,100"),
+		}, nil).Once()
+		testBot := Bot{bot: mocks.NewAPI(t), log: slog.Default(), repo: mockRepo}
+
+		err := testBot.SendPriceComparisons(t.Context(), "b", &models.Changes{
+			Changed: []models.ChangeInfo{
+				{Old: offer("b", "1,199").Product, New: offer("b", "1,100").Product},
+				{Old: models.Product{Model: "Other", Quantity: "1"}, New: models.Product{Model: "Other"}},
+			},
+		})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("error: cannot get offers", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetOffers", mock.Anything, mock.Anything).Return(nil, assert.AnError).Once()
+		testBot := Bot{bot: mocks.NewAPI(t), log: slog.Default(), repo: mockRepo}
+
+		err := testBot.SendPriceComparisons(t.Context(), "b", &models.Changes{
+			Added: []models.Product{offer("b", "1,199").Product},
+		})
+
+		require.ErrorIs(t, err, assert.AnError)
+	})
+}
@@ -0,0 +1,161 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/dedup"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+// dmUsage describes the /dmme command.
+func dmUsage() string {
+	return "Usage: /dmme <category> [<category>...], /dmme all or /dmme off\nCategories: " +
+		joinCategories(models.ChangeCategories())
+}
+
+// dmHandler handles the /dmme command in a group chat: the member sending it also gets the changes of
+// the listed categories as direct messages, e.g. "/dmme price-drops", while the group keeps getting its
+// own notifications. "/dmme off" stops them, without arguments it shows the current subscription.
+func (b *Bot) dmHandler(ctx telebot.Context) error {
+	chat := ctx.Chat()
+	sender := ctx.Sender()
+	if sender == nil || (chat.Type != telebot.ChatGroup && chat.Type != telebot.ChatSuperGroup) {
+		b.sendMessage(ctx, chat.ID, "👥 Send /dmme in a group chat to get some of its changes as direct messages.")
+		return nil
+	}
+	repoCtx := context.Background()
+
+	args := ctx.Args()
+	switch {
+	case len(args) == 0:
+		b.sendMessage(ctx, chat.ID, b.describeUserSubscription(repoCtx, sender.ID))
+		return nil
+	case len(args) == 1 && strings.EqualFold(args[0], "off"):
+		return b.stopDirectMessages(ctx, sender.ID)
+	}
+
+	categories := make([]models.ChangeCategory, 0, len(args))
+	if len(args) == 1 && strings.EqualFold(args[0], "all") {
+		categories = models.ChangeCategories()
+	} else {
+		for _, arg := range args {
+			category, ok := models.ParseChangeCategory(arg)
+			if !ok {
+				b.sendMessage(ctx, chat.ID, dmUsage())
+				return nil
+			}
+			categories = append(categories, category)
+		}
+	}
+
+	subscription := models.UserSubscription{UserID: sender.ID, ChatID: chat.ID, Categories: categories}
+	if err := b.repo.SetUserSubscription(repoCtx, subscription); err != nil {
+		b.log.Error("Failed to subscribe user", "chatID", chat.ID, "userID", sender.ID, "err", err)
+		b.sendMessage(ctx, chat.ID, "⛔ An internal error occurred. Failed to set up direct messages.")
+		return nil
+	}
+
+	b.log.Info("User subscribed to direct messages", "chatID", chat.ID, "userID", sender.ID, "categories", categories)
+	b.sendMessage(ctx, chat.ID, fmt.Sprintf(
+		"📬 %s, you'll get these changes as direct messages: %s\n"+
+			"Telegram doesn't let bots write first, so open a private chat with @%s and press Start if you haven't.",
+		userName(sender), joinCategories(categories), b.me.Username,
+	))
+
+	return nil
+}
+
+// stopDirectMessages handles "/dmme off".
+func (b *Bot) stopDirectMessages(ctx telebot.Context, userID int64) error {
+	chatID := ctx.Chat().ID
+	deleted, err := b.repo.DeleteUserSubscription(context.Background(), userID)
+	if err != nil {
+		b.log.Error("Failed to unsubscribe user", "chatID", chatID, "userID", userID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to stop direct messages.")
+		return nil
+	}
+
+	if !deleted {
+		b.sendMessage(ctx, chatID, "You don't get direct messages.")
+		return nil
+	}
+	b.log.Info("User unsubscribed from direct messages", "chatID", chatID, "userID", userID)
+	b.sendMessage(ctx, chatID, "📭 You'll no longer get direct messages.")
+
+	return nil
+}
+
+// describeUserSubscription returns the reply to /dmme without arguments.
+func (b *Bot) describeUserSubscription(ctx context.Context, userID int64) string {
+	subscriptions, err := b.repo.GetUserSubscriptions(ctx)
+	if err != nil {
+		b.log.Error("Failed to get user subscriptions", "userID", userID, "err", err)
+		return "⛔ An internal error occurred. Failed to get your direct messages."
+	}
+
+	for _, subscription := range subscriptions {
+		if subscription.UserID == userID {
+			return "📬 You get these changes as direct messages: " + joinCategories(subscription.Categories)
+		}
+	}
+
+	return dmUsage()
+}
+
+// sendDirectMessages sends the users subscribed with /dmme the changes of their categories, filtered like
+// the notifications of the group they subscribed in. A user who blocked the bot doesn't stop the others.
+func (b *Bot) sendDirectMessages(
+	ctx context.Context,
+	br *broadcast,
+	changes *models.Changes,
+	trends map[models.ProductRef]models.PriceTrend,
+	watchers map[string]int,
+	chatSettings map[int64]models.ChatSettings,
+) error {
+	subscriptions, err := b.repo.GetUserSubscriptions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get user subscriptions: %w", err)
+	}
+
+	for _, subscription := range subscriptions {
+		// The direct messages stop with the access of the group.
+		if !b.isAllowed(subscription.ChatID) {
+			continue
+		}
+
+		settings := chatSettings[subscription.ChatID]
+		filtered := changes.FilterByTypes(b.filterGroups[settings.FilterGroup]).
+			FilterByCategories(subscription.Categories)
+		if settings.SustainedTrends {
+			filtered = sustainedTrendsOnly(filtered, trends)
+		}
+		notif, buildErr := b.buildNotification(filtered, trends, watchers, b.templateVariant(subscription.UserID))
+		if buildErr != nil {
+			return buildErr
+		}
+		if notif == nil {
+			continue
+		}
+
+		if !b.dedup.Claim(dedup.ChatRecipient(subscription.UserID), notif.fingerprint) {
+			b.log.DebugContext(ctx, "Skipping duplicate direct message", "userID", subscription.UserID)
+			continue
+		}
+		userID := subscription.UserID
+		br.send(ctx, userID, func(ctx context.Context) { b.deliver(ctx, userID, notif, 0, false) })
+	}
+
+	return nil
+}
+
+// userName returns how the user is mentioned in replies.
+func userName(user *telebot.User) string {
+	if user.Username != "" {
+		return "@" + user.Username
+	}
+
+	return user.FirstName
+}
@@ -0,0 +1,202 @@
+package bot
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+// newGroupContext creates a handler context for a command sent by the user to the group chat.
+func newGroupContext(chatID, userID int64, payload string) (telebot.Context, *recordingAPI) {
+	api := &recordingAPI{}
+	update := telebot.Update{Message: &telebot.Message{
+		Chat:    &telebot.Chat{ID: chatID, Type: telebot.ChatGroup},
+		Sender:  &telebot.User{ID: userID, Username: "alice"},
+		Payload: payload,
+	}}
+
+	return telebot.NewContext(api, update), api
+}
+
+func TestDMHandler(t *testing.T) {
+	t.Parallel()
+
+	const (
+		chatID = int64(-100)
+		userID = int64(7)
+	)
+	me := &telebot.User{Username: "chrono_bot"}
+
+	t.Run("private chat is refused", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default()}
+		ctx, api := newTestContext(userID, "price-drops")
+
+		require.NoError(t, testBot.dmHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "in a group chat")
+	})
+
+	t.Run("categories are subscribed", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("SetUserSubscription", mock.Anything, models.UserSubscription{
+			UserID:     userID,
+			ChatID:     chatID,
+			Categories: []models.ChangeCategory{models.CategoryPriceDrop, models.CategoryAdded},
+		}).Return(nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, me: me}
+		ctx, api := newGroupContext(chatID, userID, "price-drops added")
+
+		require.NoError(t, testBot.dmHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "@alice")
+		assert.Contains(t, api.sent[0], "@chrono_bot")
+	})
+
+	t.Run("all categories are subscribed", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("SetUserSubscription", mock.Anything, models.UserSubscription{
+			UserID: userID, ChatID: chatID, Categories: models.ChangeCategories(),
+		}).Return(nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, me: me}
+		ctx, _ := newGroupContext(chatID, userID, "all")
+
+		require.NoError(t, testBot.dmHandler(ctx))
+	})
+
+	t.Run("unknown category shows the usage", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), repo: mocks.NewRepository(t)}
+		ctx, api := newGroupContext(chatID, userID, "price-drops bargains")
+
+		require.NoError(t, testBot.dmHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "Usage: /dmme")
+	})
+
+	t.Run("current subscription is shown", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetUserSubscriptions", mock.Anything).Return([]models.UserSubscription{
+			{UserID: userID, ChatID: chatID, Categories: []models.ChangeCategory{models.CategoryPriceDrop}},
+		}, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo}
+		ctx, api := newGroupContext(chatID, userID, "")
+
+		require.NoError(t, testBot.dmHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "price_drop")
+	})
+
+	t.Run("direct messages are stopped", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("DeleteUserSubscription", mock.Anything, userID).Return(true, nil).Once()
+		mockRepo.On("DeleteUserSubscription", mock.Anything, userID).Return(false, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo}
+		ctx, api := newGroupContext(chatID, userID, "off")
+
+		require.NoError(t, testBot.dmHandler(ctx))
+		require.NoError(t, testBot.dmHandler(ctx))
+		require.Len(t, api.sent, 2)
+		assert.Contains(t, api.sent[0], "no longer")
+		assert.Contains(t, api.sent[1], "don't get direct messages")
+	})
+
+	t.Run("error: set subscription", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("SetUserSubscription", mock.Anything, mock.Anything).Return(assert.AnError).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, me: me}
+		ctx, api := newGroupContext(chatID, userID, "added")
+
+		require.NoError(t, testBot.dmHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "internal error")
+	})
+}
+
+func TestSendDirectMessages(t *testing.T) {
+	t.Parallel()
+
+	changes := &models.Changes{
+		Changed: []models.ChangeInfo{
+			{Old: models.Product{Model: "A1", Price: "100"}, New: models.Product{Model: "A1", Price: "90"}},
+			{Old: models.Product{Model: "B2", Price: "200"}, New: models.Product{Model: "B2", Price: "210"}},
+		},
+		Added: []models.Product{{Model: "C3", Price: "300"}},
+	}
+
+	t.Run("users get the changes of their categories", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockRepo := mocks.NewRepository(t)
+		expectRecordedProducts(mockRepo)
+		mockRepo.On("GetUserSubscriptions", mock.Anything).Return([]models.UserSubscription{
+			{UserID: 7, ChatID: -100, Categories: []models.ChangeCategory{models.CategoryPriceDrop}},
+			{UserID: 8, ChatID: -100, Categories: []models.ChangeCategory{models.CategoryRemoved}},
+			{UserID: 9, ChatID: -200},
+		}, nil).Once()
+		mockBot.On("Send", &telebot.Chat{ID: 7}, mock.MatchedBy(func(text string) bool {
+			return strings.Contains(text, "`A1`") && !strings.Contains(text, "`B2`") && !strings.Contains(text, "`C3`")
+		}), markdownOpts(0)).Return(&telebot.Message{}, nil).Once()
+		testBot := Bot{
+			bot:          mockBot,
+			log:          slog.Default(),
+			repo:         mockRepo,
+			allowedChats: map[int64]bool{-100: true},
+		}
+
+		br := testBot.newBroadcast("test")
+		require.NoError(t, testBot.sendDirectMessages(t.Context(), br, changes, nil, nil, nil),
+			"nothing is sent without matching changes or to users of disallowed groups")
+	})
+
+	t.Run("filter group of the chat applies", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetUserSubscriptions", mock.Anything).Return([]models.UserSubscription{
+			{UserID: 7, ChatID: -100, Categories: []models.ChangeCategory{models.CategoryAdded}},
+		}, nil).Once()
+		testBot := Bot{
+			bot:          mocks.NewAPI(t),
+			log:          slog.Default(),
+			repo:         mockRepo,
+			allowedChats: map[int64]bool{-100: true},
+			filterGroups: map[string][]string{"divers": {"Diver"}},
+		}
+		settings := map[int64]models.ChatSettings{-100: {FilterGroup: "divers"}}
+
+		br := testBot.newBroadcast("test")
+		require.NoError(t, testBot.sendDirectMessages(t.Context(), br, changes, nil, nil, settings))
+	})
+
+	t.Run("error: get subscriptions", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetUserSubscriptions", mock.Anything).Return(nil, assert.AnError).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo}
+
+		br := testBot.newBroadcast("test")
+		require.ErrorIs(t, testBot.sendDirectMessages(t.Context(), br, changes, nil, nil, nil), assert.AnError)
+	})
+}
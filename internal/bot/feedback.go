@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+// Template variants of the notifications, see templateVariant.
+const (
+	variantA = "a"
+	variantB = "b"
+	// variantSummary is the variant of the summaries sent above the summary threshold, they aren't
+	// rendered with templates.
+	variantSummary = "summary"
+)
+
+// feedbackUnique routes the callbacks of the feedback buttons of the notifications.
+const feedbackUnique = "feedback"
+
+// Data of the feedback buttons.
+const (
+	feedbackUseful    = "up"
+	feedbackNotUseful = "down"
+)
+
+// WithTemplateVariant renders the notifications of half of the chats with the variant templates, so
+// their feedback can be compared with the one of the chats getting the default templates.
+func WithTemplateVariant(templates *Templates) Option {
+	return func(b *Bot) {
+		b.variantTemplates = templates
+	}
+}
+
+// WithFeedback adds buttons rating whether a notification was useful to the notifications. The ratings
+// are aggregated per template variant in /stats.
+func WithFeedback() Option {
+	return func(b *Bot) {
+		b.feedback = true
+	}
+}
+
+// templateVariant returns the template variant of the chat. Without variant templates every chat gets
+// variant a, otherwise chats with an odd ID get variant b, so a chat always sees the same format.
+func (b *Bot) templateVariant(chatID int64) string {
+	if b.variantTemplates == nil || chatID&1 == 0 {
+		return variantA
+	}
+
+	return variantB
+}
+
+// templatesOf returns the templates notification lines of the variant are rendered with.
+func (b *Bot) templatesOf(variant string) *Templates {
+	b.templateMu.Lock()
+	templates := b.templates
+	b.templateMu.Unlock()
+	if variant == variantB {
+		templates = b.variantTemplates
+	}
+	if templates == nil {
+		return DefaultTemplates()
+	}
+
+	return templates
+}
+
+// feedbackRow returns the row of feedback buttons added to the notifications.
+func feedbackRow(markup *telebot.ReplyMarkup) telebot.Row {
+	return markup.Row(
+		markup.Data("👍 Useful", feedbackUnique, feedbackUseful),
+		markup.Data("👎 Not useful", feedbackUnique, feedbackNotUseful),
+	)
+}
+
+// feedbackCallback handles a press of a feedback button: it records the rating of the user for the
+// notification. The route is public, as direct messages come from chats that aren't allowed, only the
+// ratings of recorded notifications are stored.
+func (b *Bot) feedbackCallback(ctx telebot.Context) error {
+	callback := ctx.Callback()
+	if callback.Message == nil {
+		return b.respond(ctx, "")
+	}
+	chatID, userID := updateIDs(ctx)
+
+	useful := callback.Data == feedbackUseful
+	recorded, err := b.repo.RecordFeedback(context.Background(), chatID, callback.Message.ID, userID, useful,
+		time.Now())
+	if err != nil {
+		b.log.Error("Failed to record feedback", "chatID", chatID, "userID", userID, "err", err)
+		return b.respond(ctx, "⛔ An internal error occurred. Failed to record the feedback.")
+	}
+	if !recorded {
+		return b.respond(ctx, "This notification can no longer be rated.")
+	}
+	b.log.Debug("Recorded feedback", "chatID", chatID, "userID", userID, "useful", useful)
+
+	return b.respond(ctx, "🙏 Thanks for the feedback!")
+}
+
+// formatFeedbackStats describes the ratings of the notifications of every template variant.
+func formatFeedbackStats(stats []models.VariantFeedback) string {
+	if len(stats) == 0 {
+		return "👍 Feedback: no notifications were sent."
+	}
+
+	var builder strings.Builder
+	builder.WriteString("👍 Feedback by template variant:")
+	for _, variant := range stats {
+		fmt.Fprintf(&builder, "\n%s: %d sent, %d 👍 / %d 👎, %.1f%% rated, %.1f%% useful",
+			variant.Variant, variant.Sent, variant.Useful, variant.NotUseful, variant.EngagementRate(),
+			variant.UsefulRate())
+	}
+
+	return builder.String()
+}
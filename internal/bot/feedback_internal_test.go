@@ -0,0 +1,127 @@
+package bot
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+func TestTemplateVariant(t *testing.T) {
+	t.Parallel()
+
+	variant, err := NewTemplates(map[string]string{"added": "🆕 {{ref .}}"})
+	require.NoError(t, err)
+
+	withoutVariant := Bot{}
+	assert.Equal(t, variantA, withoutVariant.templateVariant(-101))
+
+	testBot := Bot{variantTemplates: variant}
+	assert.Equal(t, variantA, testBot.templateVariant(-100))
+	assert.Equal(t, variantB, testBot.templateVariant(-101))
+	assert.Same(t, variant, testBot.templatesOf(variantB))
+	assert.NotNil(t, testBot.templatesOf(variantA), "variant a falls back to the built-in templates")
+}
+
+func TestSendChangesNotification_Feedback(t *testing.T) {
+	t.Parallel()
+
+	changes := &models.Changes{Added: []models.Product{{Model: "C3", Price: "300"}}}
+	variant, err := NewTemplates(map[string]string{"added": "🆕 {{ref .}}"})
+	require.NoError(t, err)
+
+	mockBot := mocks.NewAPI(t)
+	mockRepo := mocks.NewRepository(t)
+	expectNoPriceHistory(mockRepo)
+	expectRecordedProducts(mockRepo)
+	expectNoUserSubscriptions(mockRepo)
+	expectNoAlertRules(mockRepo)
+	mockRepo.On("GetSubscribedChats", mock.Anything).Return([]int64{2, 3}, nil).Once()
+	mockRepo.On("GetChatSettings", mock.Anything).Return(map[int64]models.ChatSettings{}, nil).Once()
+	withButtons := mock.MatchedBy(func(opts *telebot.SendOptions) bool {
+		return opts.ReplyMarkup != nil && len(opts.ReplyMarkup.InlineKeyboard) == 1
+	})
+	mockBot.On("Send", &telebot.Chat{ID: 2}, mock.MatchedBy(func(text string) bool {
+		return strings.Contains(text, "✅ `C3`")
+	}), withButtons).Return(&telebot.Message{ID: 5}, nil).Once()
+	mockBot.On("Send", &telebot.Chat{ID: 3}, mock.MatchedBy(func(text string) bool {
+		return strings.Contains(text, "🆕 `C3`")
+	}), withButtons).Return(&telebot.Message{ID: 6}, nil).Once()
+	mockRepo.On("RecordNotificationVariant", mock.Anything, int64(2), 5, variantA, mock.Anything).
+		Return(nil).Once()
+	mockRepo.On("RecordNotificationVariant", mock.Anything, int64(3), 6, variantB, mock.Anything).
+		Return(assert.AnError).Once()
+
+	testBot := Bot{bot: mockBot, log: slog.Default(), repo: mockRepo, variantTemplates: variant, feedback: true}
+
+	require.NoError(t, testBot.SendChangesNotification(t.Context(), changes),
+		"a variant that failed to be recorded doesn't fail the notification")
+}
+
+func TestFeedbackCallback(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(-100)
+
+	t.Run("rating is recorded", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("RecordFeedback", mock.Anything, chatID, 0, int64(0), true, mock.Anything).
+			Return(true, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo}
+		ctx, api := newCallbackContext(chatID, feedbackUseful)
+
+		require.NoError(t, testBot.feedbackCallback(ctx))
+		require.Len(t, api.answers, 1)
+		assert.Contains(t, api.answers[0].Text, "Thanks")
+	})
+
+	t.Run("unknown notification", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("RecordFeedback", mock.Anything, chatID, 0, int64(0), false, mock.Anything).
+			Return(false, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo}
+		ctx, api := newCallbackContext(chatID, feedbackNotUseful)
+
+		require.NoError(t, testBot.feedbackCallback(ctx))
+		require.Len(t, api.answers, 1)
+		assert.Contains(t, api.answers[0].Text, "no longer")
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("RecordFeedback", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+			mock.Anything).Return(false, assert.AnError).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo}
+		ctx, api := newCallbackContext(chatID, feedbackUseful)
+
+		require.NoError(t, testBot.feedbackCallback(ctx))
+		require.Len(t, api.answers, 1)
+		assert.Contains(t, api.answers[0].Text, "internal error")
+	})
+}
+
+func TestFormatFeedbackStats(t *testing.T) {
+	t.Parallel()
+
+	assert.Contains(t, formatFeedbackStats(nil), "no notifications")
+
+	text := formatFeedbackStats([]models.VariantFeedback{
+		{Variant: variantA, Sent: 10, Useful: 3, NotUseful: 1},
+		{Variant: variantB, Sent: 10, Useful: 1, NotUseful: 1},
+	})
+
+	assert.Contains(t, text, "a: 10 sent, 3 👍 / 1 👎, 40.0% rated, 75.0% useful")
+	assert.Contains(t, text, "b: 10 sent, 1 👍 / 1 👎, 20.0% rated, 50.0% useful")
+}
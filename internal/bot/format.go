@@ -0,0 +1,274 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+const (
+	maxMessageLength = 4096
+	unknownType      = "Other"
+)
+
+// typeGroup collects all changes that belong to a single product type.
+type typeGroup struct {
+	name    string
+	added   []models.Product
+	changed []models.ChangeInfo
+	renamed []models.ChangeInfo
+	removed []models.Product
+}
+
+// count returns the total number of changes in the group.
+func (g *typeGroup) count() int {
+	return len(g.added) + len(g.changed) + len(g.renamed) + len(g.removed)
+}
+
+// hasPriceChanges reports whether any product in the group changed its price.
+func (g *typeGroup) hasPriceChanges() bool {
+	for _, change := range g.changed {
+		if change.Old.Price != change.New.Price {
+			return true
+		}
+	}
+	for _, change := range g.renamed {
+		if change.Old.Price != change.New.Price {
+			return true
+		}
+	}
+
+	return false
+}
+
+// groupByType splits the changes by product category and type. Groups with price changes come first,
+// then groups with more changes, and finally groups are ordered by name.
+func groupByType(changes *models.Changes) []*typeGroup {
+	groups := make(map[string]*typeGroup)
+	group := func(category, productType string) *typeGroup {
+		name := groupName(category, productType)
+		g, ok := groups[name]
+		if !ok {
+			g = &typeGroup{name: name}
+			groups[name] = g
+		}
+		return g
+	}
+
+	for _, p := range changes.Added {
+		g := group(p.Category, p.Type)
+		g.added = append(g.added, p)
+	}
+	for _, change := range changes.Changed {
+		g := group(change.New.Category, changeType(change))
+		g.changed = append(g.changed, change)
+	}
+	for _, change := range changes.Renamed {
+		g := group(change.New.Category, changeType(change))
+		g.renamed = append(g.renamed, change)
+	}
+	for _, p := range changes.Removed {
+		g := group(p.Category, p.Type)
+		g.removed = append(g.removed, p)
+	}
+
+	sorted := make([]*typeGroup, 0, len(groups))
+	for _, g := range groups {
+		sorted = append(sorted, g)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if pi, pj := sorted[i].hasPriceChanges(), sorted[j].hasPriceChanges(); pi != pj {
+			return pi
+		}
+		if ci, cj := sorted[i].count(), sorted[j].count(); ci != cj {
+			return ci > cj
+		}
+		return sorted[i].name < sorted[j].name
+	})
+
+	return sorted
+}
+
+// groupName returns the section name of a product type, prefixed with the table category if there is one.
+func groupName(category, productType string) string {
+	if productType == "" {
+		productType = unknownType
+	}
+	if category == "" {
+		return productType
+	}
+
+	return category + " · " + productType
+}
+
+// changeType returns the product type of a change, preferring the new value.
+func changeType(change models.ChangeInfo) string {
+	if change.New.Type != "" {
+		return change.New.Type
+	}
+	return change.Old.Type
+}
+
+// formatChangesMessage builds the notification string from the changes with the templates of the variant.
+// The price trends of changed products, if known, are shown below their lines.
+func (b *Bot) formatChangesMessage(
+	changes *models.Changes,
+	trends map[models.ProductRef]models.PriceTrend,
+	variant string,
+) string {
+	return b.formatChanges(changes, trends, b.templatesOf(variant))
+}
+
+// formatChanges builds the notification string from the changes with the templates.
+func (b *Bot) formatChanges(
+	changes *models.Changes,
+	trends map[models.ProductRef]models.PriceTrend,
+	templates *Templates,
+) string {
+	var builder strings.Builder
+
+	// Add a title with the current date.
+	builder.WriteString(fmt.Sprintf("📅 *Product updates (%s)*\n", time.Now().Format("02.01.2006")))
+
+	// Add a short summary of all change kinds.
+	builder.WriteString(formatSummaryLine(changes))
+	builder.WriteString("\n\n")
+
+	// Format every product type as its own section.
+	for _, g := range groupByType(changes) {
+		builder.WriteString(fmt.Sprintf("🏷 *%s* (%d)\n", g.name, g.count()))
+		for _, p := range g.added {
+			builder.WriteString(b.renderLine(templates, export.KindAdded, p, p.Model))
+		}
+		for _, change := range g.changed {
+			builder.WriteString(b.renderLine(templates, export.KindChanged, change, change.New.Model))
+			if trend, ok := trends[models.ProductRef{Category: change.New.Category, Model: change.New.Model}]; ok {
+				builder.WriteString(formatTrend(trend))
+			}
+		}
+		for _, change := range g.renamed {
+			builder.WriteString(b.renderLine(templates, export.KindRenamed, change, change.New.Model))
+		}
+		for _, p := range g.removed {
+			builder.WriteString(b.renderLine(templates, export.KindRemoved, p, p.Model))
+		}
+		builder.WriteString("\n")
+	}
+
+	// Truncate the message if it exceeds Telegram's limit.
+	if builder.Len() > maxMessageLength {
+		trimmedString := builder.String()[:maxMessageLength-50] // Leave space for the warning.
+		return trimmedString + "\n\n... (the message was truncated)"
+	}
+
+	return builder.String()
+}
+
+// renderLine renders a single change with its template. If the template fails on this change,
+// the error is logged and only the model is shown.
+func (b *Bot) renderLine(templates *Templates, kind string, data any, model string) string {
+	line, err := templates.render(kind, data)
+	if err != nil {
+		b.log.Error("Failed to render notification line", "kind", kind, "model", model, "err", err)
+		return fmt.Sprintf("`%s`\n", model)
+	}
+
+	return line
+}
+
+// productRef returns the product model as a link to the product page, which may point to the product's
+// row on the catalog page, if there is one. Markdown can't escape the formatting characters inside a
+// link, a model with them is followed by a separate link instead.
+func productRef(p models.Product) string {
+	switch {
+	case p.ProductURL == "":
+		return fmt.Sprintf("`%s`", p.Model)
+	case strings.ContainsAny(p.Model, "_*`[]"):
+		return fmt.Sprintf("`%s` [🔗](%s)", p.Model, p.ProductURL)
+	default:
+		return fmt.Sprintf("[%s](%s)", p.Model, p.ProductURL)
+	}
+}
+
+// formatSummaryMessage builds a short notification for large change sets.
+func (b *Bot) formatSummaryMessage(changes *models.Changes) string {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("📅 *Product updates (%s)*\n", time.Now().Format("02.01.2006")))
+	builder.WriteString(formatSummaryLine(changes))
+	builder.WriteString("\n\n")
+
+	if repriced, average, ok := averagePriceChange(changes); ok {
+		builder.WriteString(fmt.Sprintf("💰 %d products repriced, average %+.1f%%\n", repriced, average))
+	}
+
+	builder.WriteString(fmt.Sprintf("📎 %d changes in total, full diff attached as CSV.", changes.Count()))
+
+	return builder.String()
+}
+
+// averagePriceChange returns the number of repriced products and their average price change in percent.
+// Products whose prices can't be parsed are not included in the average.
+func averagePriceChange(changes *models.Changes) (int, float64, bool) {
+	const percent = 100
+
+	var repriced, counted int
+	var total float64
+	for _, change := range slices.Concat(changes.Changed, changes.Renamed) {
+		if change.Old.Price == change.New.Price {
+			continue
+		}
+		repriced++
+
+		oldPrice, oldErr := change.Old.PriceValue()
+		newPrice, newErr := change.New.PriceValue()
+		if oldErr != nil || newErr != nil || oldPrice == 0 {
+			continue
+		}
+		total += (newPrice - oldPrice) / oldPrice * percent
+		counted++
+	}
+
+	if counted == 0 {
+		return repriced, 0, false
+	}
+
+	return repriced, total / float64(counted), true
+}
+
+// formatSummaryLine returns the per-kind counters, omitting the kinds without changes.
+func formatSummaryLine(changes *models.Changes) string {
+	var parts []string
+	if len(changes.Added) > 0 {
+		parts = append(parts, fmt.Sprintf("✅ Added: %d", len(changes.Added)))
+	}
+	if len(changes.Changed) > 0 {
+		parts = append(parts, fmt.Sprintf("🔄 Changed: %d", len(changes.Changed)))
+	}
+	if len(changes.Renamed) > 0 {
+		parts = append(parts, fmt.Sprintf("✏️ Renamed: %d", len(changes.Renamed)))
+	}
+	if len(changes.Removed) > 0 {
+		parts = append(parts, fmt.Sprintf("❌ Removed: %d", len(changes.Removed)))
+	}
+
+	return strings.Join(parts, " · ")
+}
+
+// writeDiffLines writes the price, quantity and extra field differences of a single change.
+func writeDiffLines(builder *strings.Builder, change models.ChangeInfo) {
+	if change.New.Price != change.Old.Price {
+		builder.WriteString(fmt.Sprintf("  *Price*: %s -> *%s*\n", change.Old.Price, change.New.Price))
+	}
+	if change.New.Quantity != change.Old.Quantity {
+		builder.WriteString(fmt.Sprintf("  *Quantity*: %s -> *%s*\n", change.Old.Quantity, change.New.Quantity))
+	}
+	for _, field := range change.ChangedExtras() {
+		fmt.Fprintf(builder, "  *%s*: %s -> *%s*\n", field, change.Old.Extras[field], change.New.Extras[field])
+	}
+}
@@ -0,0 +1,124 @@
+package bot
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupByType(t *testing.T) {
+	t.Parallel()
+
+	changes := &models.Changes{
+		Added: []models.Product{
+			{Model: "A1", Type: "Diver"},
+			{Model: "A2", Type: "Diver"},
+			{Model: "A3"},
+		},
+		Changed: []models.ChangeInfo{
+			{
+				Old: models.Product{Model: "C1", Type: "Chrono", Quantity: "1", Price: "100"},
+				New: models.Product{Model: "C1", Type: "Chrono", Quantity: "1", Price: "90"},
+			},
+			{
+				Old: models.Product{Model: "D1", Type: "Dress", Quantity: "1"},
+				New: models.Product{Model: "D1", Type: "Dress", Quantity: "2"},
+			},
+		},
+		Removed: []models.Product{{Model: "R1", Type: "Diver"}},
+	}
+
+	groups := groupByType(changes)
+
+	names := make([]string, 0, len(groups))
+	for _, g := range groups {
+		names = append(names, g.name)
+	}
+	// Chrono has a price change, Diver has the most changes, Dress and Other are ordered by name.
+	assert.Equal(t, []string{"Chrono", "Diver", "Dress", unknownType}, names)
+	assert.Equal(t, 3, groups[1].count())
+}
+
+func TestGroupByType_Categories(t *testing.T) {
+	t.Parallel()
+
+	changes := &models.Changes{
+		Added: []models.Product{
+			{Model: "A1", Type: "Diver", Category: "new"},
+			{Model: "A1", Type: "Diver", Category: "used"},
+			{Model: "A2", Type: "Diver", Category: "used"},
+		},
+		Removed: []models.Product{{Model: "R1", Category: "incoming"}},
+	}
+
+	groups := groupByType(changes)
+
+	names := make([]string, 0, len(groups))
+	for _, g := range groups {
+		names = append(names, g.name)
+	}
+	assert.Equal(t, []string{"used · Diver", "incoming · " + unknownType, "new · Diver"}, names)
+}
+
+func TestFormatChangesMessage(t *testing.T) {
+	t.Parallel()
+
+	testBot := Bot{log: slog.Default()}
+
+	t.Run("grouped sections", func(t *testing.T) {
+		t.Parallel()
+
+		changes := &models.Changes{
+			Added: []models.Product{{Model: "A1", Type: "Diver", Price: "100", Quantity: "2"}},
+			Changed: []models.ChangeInfo{{
+				Old: models.Product{Model: "C1", Type: "Chrono", Price: "100"},
+				New: models.Product{Model: "C1", Type: "Chrono", Price: "90"},
+			}},
+		}
+
+		msg := testBot.formatChangesMessage(changes, nil, variantA)
+
+		assert.Contains(t, msg, "✅ Added: 1 · 🔄 Changed: 1")
+		assert.Contains(t, msg, "🏷 *Chrono* (1)")
+		assert.Contains(t, msg, "*Price*: 100 -> *90*")
+		assert.Less(t, strings.Index(msg, "Chrono"), strings.Index(msg, "Diver"))
+	})
+
+	t.Run("product links", func(t *testing.T) {
+		t.Parallel()
+
+		changes := &models.Changes{
+			Added: []models.Product{
+				{Model: "A1", Type: "Diver", ProductURL: "https://example.com/catalog#a1"},
+				{Model: "B_2", Type: "Diver", ProductURL: "https://example.com/b2"},
+			},
+			Removed: []models.Product{{Model: "R1", Type: "Diver", ProductURL: "https://example.com/r1"}},
+		}
+
+		msg := testBot.formatChangesMessage(changes, nil, variantA)
+
+		assert.Contains(t, msg, "✅ [A1](https://example.com/catalog#a1)")
+		// Markdown can't escape the underscore inside the link text.
+		assert.Contains(t, msg, "✅ `B_2` [🔗](https://example.com/b2)")
+		// Removed products no longer have a page to link to.
+		assert.Contains(t, msg, "❌ `R1`\n")
+	})
+
+	t.Run("truncated message", func(t *testing.T) {
+		t.Parallel()
+
+		changes := &models.Changes{}
+		for range 500 {
+			changes.Removed = append(changes.Removed, models.Product{Model: "SomeVeryLongModelName", Type: "Diver"})
+		}
+
+		msg := testBot.formatChangesMessage(changes, nil, variantA)
+
+		require.LessOrEqual(t, len(msg), maxMessageLength)
+		assert.True(t, strings.HasSuffix(msg, "(the message was truncated)"))
+	})
+}
@@ -0,0 +1,50 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"gopkg.in/telebot.v4"
+)
+
+// diffUnique routes the callbacks of the full diff buttons of the notifications, their data is the run ID.
+const diffUnique = "diff"
+
+// diffRow returns the row with the button sending the full diff of the run.
+func diffRow(markup *telebot.ReplyMarkup, runID string) telebot.Row {
+	return markup.Row(markup.Data("📄 Full diff", diffUnique, runID))
+}
+
+// diffCallback handles a press of a full diff button: it sends the changes of the run as an HTML page,
+// however many there were. The route is public, as direct messages come from chats that aren't allowed,
+// the run IDs are random and only known from the notifications.
+func (b *Bot) diffCallback(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+	runID := ctx.Callback().Data
+
+	artifact, err := b.repo.GetDiffArtifact(context.Background(), runID, export.DiffHTML)
+	if errors.Is(err, repository.ErrArtifactNotFound) {
+		return b.respond(ctx, "The full diff of this notification is no longer kept.")
+	}
+	if err != nil {
+		b.log.Error("Failed to get the full diff", "chatID", chatID, "runID", runID, "err", err)
+		return b.respond(ctx, "⛔ An internal error occurred. Failed to get the full diff.")
+	}
+
+	document := &telebot.Document{
+		File:     telebot.FromReader(bytes.NewReader(artifact.Content)),
+		FileName: fmt.Sprintf("diff-%s.html", runID),
+		MIME:     "text/html",
+		Caption:  "📄 Changes detected at " + artifact.CreatedAt.Local().Format("2006-01-02 15:04"),
+	}
+	if err = ctx.Send(document); err != nil {
+		b.log.Error("Failed to send the full diff", "chatID", chatID, "runID", runID, "err", err)
+		return b.respond(ctx, "⛔ Failed to send the full diff.")
+	}
+
+	return b.respond(ctx, "")
+}
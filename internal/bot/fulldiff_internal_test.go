@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+func TestDiffCallback(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(-100)
+
+	t.Run("sends the HTML diff", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetDiffArtifact", mock.Anything, "run-1", "html").Return(&models.DiffArtifact{
+			RunID: "run-1", Format: "html", CreatedAt: time.Now(), Content: []byte("<html></html>"),
+		}, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo}
+		ctx, api := newCallbackContext(chatID, "run-1")
+
+		require.NoError(t, testBot.diffCallback(ctx))
+		require.Len(t, api.sent, 1)
+		document, ok := api.sent[0].(*telebot.Document)
+		require.True(t, ok)
+		assert.Equal(t, "diff-run-1.html", document.FileName)
+		require.Len(t, api.answers, 1)
+		assert.Empty(t, api.answers[0].Text)
+	})
+
+	t.Run("pruned diff", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetDiffArtifact", mock.Anything, "run-1", "html").
+			Return(nil, repository.ErrArtifactNotFound).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo}
+		ctx, api := newCallbackContext(chatID, "run-1")
+
+		require.NoError(t, testBot.diffCallback(ctx))
+		assert.Empty(t, api.sent)
+		require.Len(t, api.answers, 1)
+		assert.Contains(t, api.answers[0].Text, "no longer kept")
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetDiffArtifact", mock.Anything, "run-1", "html").Return(nil, assert.AnError).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo}
+		ctx, api := newCallbackContext(chatID, "run-1")
+
+		require.NoError(t, testBot.diffCallback(ctx))
+		require.Len(t, api.answers, 1)
+		assert.Contains(t, api.answers[0].Text, "internal error")
+	})
+}
@@ -1,30 +1,32 @@
 package bot
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/Houeta/chrono-flow/internal/dedup"
+	"github.com/Houeta/chrono-flow/internal/events"
+	"github.com/Houeta/chrono-flow/internal/export"
 	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/skip2/go-qrcode"
 	"gopkg.in/telebot.v4"
 )
 
-const maxMessageLength = 4096
-
 // subscribeHandler handles the /start or /subscribe command.
 func (b *Bot) subscribeHandler(ctx telebot.Context) error {
 	chatID := ctx.Chat().ID
 	ctxRepo := context.Background()
 
-	if !b.allowedChats[chatID] {
-		b.log.Warn("Unathorized attempt to subscribe", "chatID", chatID)
-		b.sendMessage(ctx, chatID, "👮 Sorry, this bot is private and cannot be used in this chat.")
-		if err := b.bot.Leave(ctx.Recipient()); err != nil {
-			return fmt.Errorf("failed to leave chat: %w", err)
-		}
-
-		return nil
+	// A deep-link payload (t.me/bot?start=<group>) selects a predefined filter group.
+	group := strings.TrimSpace(ctx.Data())
+	if _, ok := b.filterGroups[group]; group != "" && !ok {
+		b.log.Warn("Unknown filter group requested", "chatID", chatID, "group", group)
+		b.sendMessage(ctx, chatID, "🤷 Unknown filter group, the current filter is kept.")
+		group = ""
 	}
 
 	if err := b.repo.SubscribeChat(ctxRepo, chatID); err != nil {
@@ -34,8 +36,24 @@ func (b *Bot) subscribeHandler(ctx telebot.Context) error {
 		return nil
 	}
 
-	b.log.Info("Chat subscribed successfully", "chatID", chatID)
-	b.sendMessage(ctx, chatID, "✅ You have successfully subscribed to updates!")
+	if group != "" {
+		if err := b.repo.SetFilterGroup(ctxRepo, chatID, group); err != nil {
+			b.log.Error("Failed to set filter group", "chatID", chatID, "group", group, "err", err)
+			b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to apply the filter group.")
+
+			return nil
+		}
+	}
+
+	b.log.Info("Chat subscribed successfully", "chatID", chatID, "group", group)
+	if group != "" {
+		b.sendMessage(ctx, chatID, fmt.Sprintf(
+			"✅ You have successfully subscribed to updates!\n🏷 Filter group: %s (%s)\n%s",
+			group, strings.Join(b.filterGroups[group], ", "), helpHint,
+		))
+		return nil
+	}
+	b.sendMessage(ctx, chatID, "✅ You have successfully subscribed to updates!\n"+helpHint)
 
 	return nil
 }
@@ -56,16 +74,110 @@ func (b *Bot) unsubscribeHandler(ctx telebot.Context) error {
 	return nil
 }
 
+// setTopicHandler handles the /settopic command: notifications for the chat are sent
+// to the forum topic the command was issued in.
+func (b *Bot) setTopicHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+	repoCtx := context.Background()
+
+	// Messages in the General topic have no thread ID, which resets the topic.
+	threadID := 0
+	if msg := ctx.Message(); msg != nil && msg.TopicMessage {
+		threadID = msg.ThreadID
+	}
+
+	if err := b.repo.SetThreadID(repoCtx, chatID, threadID); err != nil {
+		b.log.Error("Failed to set topic", "chatID", chatID, "threadID", threadID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to set the topic.")
+		return nil
+	}
+
+	b.log.Info("Chat topic set", "chatID", chatID, "threadID", threadID)
+	if threadID == 0 {
+		b.sendMessage(ctx, chatID, "📌 Notifications will be sent to the General topic.")
+		return nil
+	}
+	b.sendMessage(ctx, chatID, "📌 Notifications will be sent to this topic.")
+
+	return nil
+}
+
+// silentHandler handles the /silent command: the listed change categories are delivered
+// without a notification sound, "/silent off" restores the sound for all of them.
+func (b *Bot) silentHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+	repoCtx := context.Background()
+
+	args := ctx.Args()
+	if len(args) == 0 {
+		b.sendMessage(ctx, chatID, silentUsage())
+		return nil
+	}
+
+	categories := make([]models.ChangeCategory, 0, len(args))
+	if !(len(args) == 1 && strings.EqualFold(args[0], "off")) {
+		for _, arg := range args {
+			category := models.ChangeCategory(strings.ToLower(arg))
+			if !category.IsValid() {
+				b.sendMessage(ctx, chatID, silentUsage())
+				return nil
+			}
+			categories = append(categories, category)
+		}
+	}
+
+	if err := b.repo.SetSilentCategories(repoCtx, chatID, categories); err != nil {
+		b.log.Error("Failed to set silent categories", "chatID", chatID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to update silent categories.")
+		return nil
+	}
+
+	b.log.Info("Chat silent categories set", "chatID", chatID, "categories", categories)
+	if len(categories) == 0 {
+		b.sendMessage(ctx, chatID, "🔔 All notifications will be sent with sound.")
+		return nil
+	}
+	b.sendMessage(ctx, chatID, fmt.Sprintf(
+		"🔕 Notifications with only these changes will be sent silently: %s", joinCategories(categories),
+	))
+
+	return nil
+}
+
+// silentUsage describes the /silent command.
+func silentUsage() string {
+	return "Usage: /silent <category> [<category>...] or /silent off\nCategories: " +
+		joinCategories(models.ChangeCategories())
+}
+
+// joinCategories returns a comma-separated list of categories.
+func joinCategories(categories []models.ChangeCategory) string {
+	names := make([]string, 0, len(categories))
+	for _, category := range categories {
+		names = append(names, string(category))
+	}
+
+	return strings.Join(names, ", ")
+}
+
 // SendChangesNotification formats and sends the notification to all subscribers.
 func (b *Bot) SendChangesNotification(ctx context.Context, changes *models.Changes) error {
 	const opn = "bot.sendChangesNotification"
-	const messageTimeout = 100
 	log := b.log.With("op", opn)
 
 	if !changes.HasChanges() {
 		return nil
 	}
 
+	b.rememberChanges(ctx, changes)
+	histories := b.priceHistories(ctx, changes)
+	trends := priceTrends(changes, histories, time.Now())
+	watchers := b.watchCounts(ctx)
+
+	if err := b.sendChannelNotification(ctx, changes, trends, watchers); err != nil {
+		return fmt.Errorf("%s: %w", opn, err)
+	}
+
 	subscribers, err := b.repo.GetSubscribedChats(ctx)
 	if err != nil {
 		return fmt.Errorf("%s: failed to get subscribers: %w", opn, err)
@@ -76,71 +188,254 @@ func (b *Bot) SendChangesNotification(ctx context.Context, changes *models.Chang
 		return nil
 	}
 
-	messageText := b.formatChangesMessage(changes)
+	chatSettings, err := b.repo.GetChatSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to get chat settings: %w", opn, err)
+	}
+
 	log.InfoContext(ctx, "Sending notification to subscribers", "count", len(subscribers))
 
+	// The messages are queued for the broadcast workers, the ones of a chat are sent in order: the
+	// notification, then the alerts.
+	br := b.newBroadcast("changes")
+	defer br.finish(ctx)
+
+	// Notifications are built once per filter group, trend filter and template variant, and shared by all
+	// chats with them.
+	type notificationKey struct {
+		group     string
+		sustained bool
+		variant   string
+	}
+	notifications := make(map[notificationKey]*notification)
 	for _, chatID := range subscribers {
-		recipient := &telebot.Chat{ID: chatID}
-		_, err = b.bot.Send(recipient, messageText, telebot.ModeMarkdown)
-		if err != nil {
-			log.ErrorContext(ctx, "Failed to send notification to a chat", "chatID", chatID, "err", err)
+		settings := chatSettings[chatID]
+		key := notificationKey{
+			group:     settings.FilterGroup,
+			sustained: settings.SustainedTrends,
+			variant:   b.templateVariant(chatID),
 		}
-		time.Sleep(messageTimeout * time.Millisecond)
+		notif, built := notifications[key]
+		if !built {
+			filtered := changes.FilterByTypes(b.filterGroups[key.group])
+			if key.sustained {
+				filtered = sustainedTrendsOnly(filtered, trends)
+			}
+			if notif, err = b.buildNotification(filtered, trends, watchers, key.variant); err != nil {
+				return fmt.Errorf("%s: %w", opn, err)
+			}
+			notifications[key] = notif
+		}
+
+		// Nothing matched the filter group of the chat.
+		if notif == nil {
+			continue
+		}
+
+		if !b.dedup.Claim(dedup.ChatRecipient(chatID), notif.fingerprint) {
+			log.DebugContext(ctx, "Skipping duplicate notification", "chatID", chatID)
+			continue
+		}
+
+		br.send(ctx, chatID, func(ctx context.Context) {
+			b.deliver(ctx, chatID, notif, settings.ThreadID, settings.IsSilent(notif.categories))
+		})
+	}
+
+	b.sendWatermarkAlerts(ctx, br, changes, histories, subscribers, chatSettings)
+	b.sendRuleAlerts(ctx, br, changes, histories, subscribers, chatSettings)
+
+	if err = b.sendDirectMessages(ctx, br, changes, trends, watchers, chatSettings); err != nil {
+		return fmt.Errorf("%s: %w", opn, err)
 	}
 
 	return nil
 }
 
-// formatChangesMessage builds the notification string from the changes.
-func (b *Bot) formatChangesMessage(changes *models.Changes) string {
-	var builder strings.Builder
-
-	// Add a title with the current date.
-	builder.WriteString(fmt.Sprintf("📅 *Product updates (%s)*\n\n", time.Now().Format("02.01.2006")))
+// deliver sends the notification, its attachment and the photos of the added products to the chat and
+// records the products it lists, the deduplication claim is released if the notification wasn't sent.
+func (b *Bot) deliver(ctx context.Context, chatID int64, notif *notification, threadID int, silent bool) {
+	opts := &telebot.SendOptions{
+		ParseMode:           telebot.ModeMarkdown,
+		ThreadID:            threadID,
+		DisableNotification: silent,
+		ReplyMarkup:         b.notificationMarkup(events.RunIDFromContext(ctx)),
+	}
+	msg, err := b.sendTo(ctx, chatID, notif.text, opts)
+	if err != nil {
+		b.log.ErrorContext(ctx, "Failed to send notification to a chat", "chatID", chatID, "err", err)
+		b.dedup.Release(dedup.ChatRecipient(chatID), notif.fingerprint)
+	} else {
+		// The chat has another ID if it was upgraded to a supergroup meanwhile.
+		if msg.Chat != nil {
+			chatID = msg.Chat.ID
+		}
+		b.recordNotification(ctx, chatID, msg.ID, notif)
+	}
 
-	// Format added products.
-	if len(changes.Added) > 0 {
-		builder.WriteString(fmt.Sprintf("✅ *Added (%d):*\n", len(changes.Added)))
-		for _, p := range changes.Added {
-			builder.WriteString(
-				fmt.Sprintf("• *Model*: `%s`\n  *Price*: %s, *Quantity*: %s\n", p.Model, p.Price, p.Quantity),
-			)
+	if notif.attachment != nil {
+		docOpts := &telebot.SendOptions{ThreadID: threadID, DisableNotification: silent}
+		if _, err = b.sendTo(ctx, chatID, changesDocument(notif.attachment), docOpts); err != nil {
+			b.log.ErrorContext(ctx, "Failed to send changes export to a chat", "chatID", chatID, "err", err)
 		}
-		builder.WriteString("\n")
 	}
 
-	// Format changed products.
-	if len(changes.Changed) > 0 {
-		builder.WriteString(fmt.Sprintf("🔄 *Changed (%d):*\n", len(changes.Changed)))
-		for _, change := range changes.Changed {
-			builder.WriteString(fmt.Sprintf("• *Model*: `%s`\n", change.New.Model))
-			if change.New.Price != change.Old.Price {
-				builder.WriteString(fmt.Sprintf("  *Price*: %s -> *%s*\n", change.Old.Price, change.New.Price))
-			}
-			if change.New.Quantity != change.Old.Quantity {
-				builder.WriteString(fmt.Sprintf("  *Quantity*: %s -> *%s*\n", change.Old.Quantity, change.New.Quantity))
-			}
-			builder.WriteString("\n")
+	// The photos illustrate the notification, they aren't sent without it.
+	if msg != nil {
+		b.sendPhotos(ctx, chatID, notif.photos, threadID, silent)
+	}
+}
+
+// recordNotification records the products of the notification sent to the chat as the message, and its
+// template variant if it can be rated. Only replies with /price and the ratings depend on them, so a
+// failure is logged, the notification itself was delivered.
+func (b *Bot) recordNotification(ctx context.Context, chatID int64, messageID int, notif *notification) {
+	sentAt := time.Now()
+	if err := b.repo.RecordNotificationProducts(ctx, chatID, messageID, sentAt, notif.products); err != nil {
+		b.log.WarnContext(ctx, "Failed to record notification products", "chatID", chatID, "err", err)
+	}
+
+	if !b.feedback {
+		return
+	}
+	if err := b.repo.RecordNotificationVariant(ctx, chatID, messageID, notif.variant, sentAt); err != nil {
+		b.log.WarnContext(ctx, "Failed to record notification variant", "chatID", chatID, "err", err)
+	}
+}
+
+// notification is a rendered change notification with an optional CSV attachment.
+type notification struct {
+	text       string
+	attachment []byte
+	// categories are the change categories in the notification, used to decide if it's silent.
+	categories map[models.ChangeCategory]bool
+	// fingerprint identifies the notified changes for deduplication.
+	fingerprint string
+	// products are the products the notification lists, a reply with /price refers to one of them.
+	products []models.ProductRef
+	// variant is the template variant the notification was rendered with.
+	variant string
+	// photos are the images of the added products sent after the notification, see WithProductPhotos.
+	photos []productPhoto
+}
+
+// buildNotification renders the changes with the templates of the variant, returning nil if there is
+// nothing to send. Large change sets are summarized and the full diff is attached as a CSV file. Ranked
+// notifications attach it if they leave minor changes out, the watchers are the watch counts they are
+// ranked with, see WithRanking.
+func (b *Bot) buildNotification(
+	changes *models.Changes,
+	trends map[models.ProductRef]models.PriceTrend,
+	watchers map[string]int,
+	variant string,
+) (*notification, error) {
+	if !changes.HasChanges() {
+		return nil, nil //nolint:nilnil // nil notification means there is nothing to send.
+	}
+
+	if b.summaryThreshold > 0 && changes.Count() > b.summaryThreshold {
+		var buf bytes.Buffer
+		if err := export.ChangesCSV(&buf, changes); err != nil {
+			return nil, fmt.Errorf("failed to export changes: %w", err)
 		}
-		builder.WriteString("\n")
+
+		return &notification{
+			text:        b.formatSummaryMessage(changes),
+			attachment:  buf.Bytes(),
+			categories:  changes.Categories(),
+			fingerprint: dedup.Fingerprint(changes),
+			products:    changes.ProductRefs(),
+			variant:     variantSummary,
+		}, nil
+	}
+
+	if b.maxLines > 0 {
+		return b.buildRankedNotification(changes, trends, watchers, variant)
+	}
+
+	return &notification{
+		text:        b.formatChangesMessage(changes, trends, variant),
+		categories:  changes.Categories(),
+		fingerprint: dedup.Fingerprint(changes),
+		products:    changes.ProductRefs(),
+		variant:     variant,
+		photos:      b.addedPhotos(changes),
+	}, nil
+}
+
+// buildRankedNotification renders the changes ranked by significance, the changes are attached as a CSV
+// file if minor ones were left out.
+func (b *Bot) buildRankedNotification(
+	changes *models.Changes,
+	trends map[models.ProductRef]models.PriceTrend,
+	watchers map[string]int,
+	variant string,
+) (*notification, error) {
+	text, minor := b.formatRankedMessage(changes, trends, watchers, variant)
+	notif := &notification{
+		text:        text,
+		categories:  changes.Categories(),
+		fingerprint: dedup.Fingerprint(changes),
+		products:    changes.ProductRefs(),
+		variant:     variant,
+		photos:      b.addedPhotos(changes),
+	}
+	if minor == 0 {
+		return notif, nil
+	}
+
+	var buf bytes.Buffer
+	if err := export.ChangesCSV(&buf, changes); err != nil {
+		return nil, fmt.Errorf("failed to export changes: %w", err)
 	}
+	notif.attachment = buf.Bytes()
 
-	// Format removed products.
-	if len(changes.Removed) > 0 {
-		builder.WriteString(fmt.Sprintf("❌ *Removed (%d):*\n", len(changes.Removed)))
-		for _, p := range changes.Removed {
-			builder.WriteString(fmt.Sprintf("• *Model*: `%s`\n", p.Model))
+	return notif, nil
+}
+
+// inviteUsage explains the /invite command.
+const inviteUsage = "Usage: /invite <group>"
+
+// inviteHandler handles the admin /invite <group> command: it replies with a deep link
+// that subscribes a chat with the given filter group, and a QR code of that link.
+func (b *Bot) inviteHandler(ctx telebot.Context) error {
+	const qrCodeSize = 512
+	chatID := ctx.Chat().ID
+
+	group := strings.TrimSpace(ctx.Data())
+	if _, ok := b.filterGroups[group]; !ok {
+		groups := make([]string, 0, len(b.filterGroups))
+		for name := range b.filterGroups {
+			groups = append(groups, name)
 		}
-		builder.WriteString("\n")
+		sort.Strings(groups)
+		b.sendMessage(ctx, chatID, inviteUsage+"\nAvailable groups: "+strings.Join(groups, ", "))
+		return nil
+	}
+
+	link := fmt.Sprintf("https://t.me/%s?start=%s", b.me.Username, group)
+	png, err := qrcode.Encode(link, qrcode.Medium, qrCodeSize)
+	if err != nil {
+		b.log.Error("Failed to generate QR code", "chatID", chatID, "link", link, "err", err)
+		b.sendMessage(ctx, chatID, link)
+		return nil
 	}
 
-	// Truncate the message if it exceeds Telegram's limit.
-	if builder.Len() > maxMessageLength {
-		trimmedString := builder.String()[:maxMessageLength-50] // Leave space for the warning.
-		return trimmedString + "\n\n... (the message was truncated)"
+	photo := &telebot.Photo{File: telebot.FromReader(bytes.NewReader(png)), Caption: link}
+	if err = ctx.Send(photo); err != nil {
+		b.log.Error("Failed to send invite QR code", "chatID", chatID, "err", err)
 	}
 
-	return builder.String()
+	return nil
+}
+
+// changesDocument wraps the exported CSV into a Telegram document.
+func changesDocument(data []byte) *telebot.Document {
+	return &telebot.Document{
+		File:     telebot.FromReader(bytes.NewReader(data)),
+		FileName: fmt.Sprintf("changes-%s.csv", time.Now().Format("2006-01-02-1504")),
+		MIME:     "text/csv",
+	}
 }
 
 // sendMessage - its a wrapper for sending a message.
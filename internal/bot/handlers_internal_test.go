@@ -0,0 +1,258 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+// recordingAPI is a minimal telebot.API which records everything sent through a handler context.
+type recordingAPI struct {
+	telebot.API
+
+	sent []interface{}
+}
+
+func (r *recordingAPI) Send(_ telebot.Recipient, what interface{}, _ ...interface{}) (*telebot.Message, error) {
+	r.sent = append(r.sent, what)
+	return &telebot.Message{}, nil
+}
+
+func (r *recordingAPI) Reply(_ *telebot.Message, what interface{}, _ ...interface{}) (*telebot.Message, error) {
+	r.sent = append(r.sent, what)
+	return &telebot.Message{}, nil
+}
+
+// newTestContext creates a handler context for a command sent from chatID with the given payload.
+func newTestContext(chatID int64, payload string) (telebot.Context, *recordingAPI) {
+	api := &recordingAPI{}
+	update := telebot.Update{Message: &telebot.Message{Chat: &telebot.Chat{ID: chatID}, Payload: payload}}
+
+	return telebot.NewContext(api, update), api
+}
+
+func TestSubscribeHandler(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(-100)
+	groups := map[string][]string{"warehouse": {"Diver", "Chrono"}}
+
+	t.Run("unauthorized chat leaves", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockBot.On("Leave", mock.Anything).Return(nil).Once()
+		testBot := Bot{bot: mockBot, log: slog.Default(), allowedChats: map[int64]bool{}}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.route("/subscribe", accessJoin, testBot.subscribeHandler)(ctx))
+		assert.Len(t, api.sent, 1)
+	})
+
+	t.Run("deep link applies filter group", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("SubscribeChat", mock.Anything, chatID).Return(nil).Once()
+		mockRepo.On("SetFilterGroup", mock.Anything, chatID, "warehouse").Return(nil).Once()
+		testBot := Bot{
+			log:          slog.Default(),
+			repo:         mockRepo,
+			allowedChats: map[int64]bool{chatID: true},
+			filterGroups: groups,
+		}
+		ctx, api := newTestContext(chatID, "warehouse")
+
+		require.NoError(t, testBot.subscribeHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "Filter group: warehouse (Diver, Chrono)")
+	})
+
+	t.Run("unknown group subscribes without filter", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("SubscribeChat", mock.Anything, chatID).Return(nil).Once()
+		testBot := Bot{
+			log:          slog.Default(),
+			repo:         mockRepo,
+			allowedChats: map[int64]bool{chatID: true},
+			filterGroups: groups,
+		}
+		ctx, api := newTestContext(chatID, "retail")
+
+		require.NoError(t, testBot.subscribeHandler(ctx))
+		require.Len(t, api.sent, 2)
+		assert.Contains(t, api.sent[0], "Unknown filter group")
+	})
+
+	t.Run("error: cannot set filter group", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("SubscribeChat", mock.Anything, chatID).Return(nil).Once()
+		mockRepo.On("SetFilterGroup", mock.Anything, chatID, "warehouse").Return(assert.AnError).Once()
+		testBot := Bot{
+			log:          slog.Default(),
+			repo:         mockRepo,
+			allowedChats: map[int64]bool{chatID: true},
+			filterGroups: groups,
+		}
+		ctx, api := newTestContext(chatID, "warehouse")
+
+		require.NoError(t, testBot.subscribeHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "Failed to apply the filter group")
+	})
+}
+
+func TestInviteHandler(t *testing.T) {
+	t.Parallel()
+
+	const adminID = testAdminID
+	testBot := Bot{
+		log:          slog.Default(),
+		adminChats:   map[int64]bool{adminID: true},
+		me:           &telebot.User{Username: "chrono_bot"},
+		filterGroups: map[string][]string{"warehouse": {"Diver"}, "retail": {"Dress"}},
+	}
+
+	t.Run("not an admin", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, api := newTestContext(1, "warehouse")
+
+		require.NoError(t, testBot.route("/invite", accessAdmin, testBot.inviteHandler)(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "administrators only")
+	})
+
+	t.Run("unknown group shows usage", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, api := newTestContext(adminID, "")
+
+		require.NoError(t, testBot.inviteHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "Available groups: retail, warehouse")
+	})
+
+	t.Run("sends deep link with QR code", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, api := newTestContext(adminID, "warehouse")
+
+		require.NoError(t, testBot.inviteHandler(ctx))
+		require.Len(t, api.sent, 1)
+		photo, ok := api.sent[0].(*telebot.Photo)
+		require.True(t, ok)
+		assert.Equal(t, "https://t.me/chrono_bot?start=warehouse", photo.Caption)
+	})
+}
+
+func TestSetTopicHandler(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(-100)
+
+	t.Run("unauthorized chat", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), allowedChats: map[int64]bool{}}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.route("/settopic", accessAllowed, testBot.setTopicHandler)(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "this bot is private")
+	})
+
+	t.Run("command inside a topic", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("SetThreadID", mock.Anything, chatID, 15).Return(nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		api := &recordingAPI{}
+		ctx := telebot.NewContext(api, telebot.Update{Message: &telebot.Message{
+			Chat: &telebot.Chat{ID: chatID}, ThreadID: 15, TopicMessage: true,
+		}})
+
+		require.NoError(t, testBot.setTopicHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "to this topic")
+	})
+
+	t.Run("command in General resets the topic", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("SetThreadID", mock.Anything, chatID, 0).Return(nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.setTopicHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "General topic")
+	})
+}
+
+func TestSilentHandler(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(-100)
+
+	t.Run("no arguments shows usage", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.silentHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "Categories: added, removed")
+	})
+
+	t.Run("unknown category shows usage", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, "quantity loud")
+
+		require.NoError(t, testBot.silentHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "Usage: /silent")
+	})
+
+	t.Run("sets categories", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("SetSilentCategories", mock.Anything, chatID,
+			[]models.ChangeCategory{models.CategoryQuantity, models.CategoryPriceRise}).Return(nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, "quantity Price_Rise")
+
+		require.NoError(t, testBot.silentHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "sent silently: quantity, price_rise")
+	})
+
+	t.Run("off clears categories", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("SetSilentCategories", mock.Anything, chatID, []models.ChangeCategory{}).Return(nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, "off")
+
+		require.NoError(t, testBot.silentHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "with sound")
+	})
+}
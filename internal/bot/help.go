@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/telebot.v4"
+)
+
+// Help topics grouping the commands, see helpTopics.
+const (
+	topicBasics   = "basics"
+	topicFilters  = "filters"
+	topicWatches  = "watches"
+	topicProducts = "products"
+	topicAdmin    = "admin"
+)
+
+// helpTopic is a group of commands listed together in /help.
+type helpTopic struct {
+	name  string
+	title string
+}
+
+// helpTopics are the topics of /help, in the order they are listed.
+//
+//nolint:gochecknoglobals // a read-only list of topics.
+var helpTopics = []helpTopic{
+	{name: topicBasics, title: "🚀 Getting started"},
+	{name: topicFilters, title: "🎛 Filters and delivery"},
+	{name: topicWatches, title: "👀 Watches and alerts"},
+	{name: topicProducts, title: "📦 Products"},
+	{name: topicAdmin, title: "👮 Administration"},
+}
+
+// helpHint points a chat which just subscribed to /help.
+const helpHint = "ℹ️ Send /help to see what else the bot can do."
+
+// helpUsage explains the /help command.
+const helpUsage = "Usage: /help [topic or command], e.g. /help filters or /help list"
+
+// helpHandler handles the /help command: it lists the commands the chat may use by topic, or explains a
+// topic or a command with their usage and examples. The help is generated from the command registry, see
+// Bot.commands, so it lists every command of the menu.
+func (b *Bot) helpHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+	b.sendMessage(ctx, chatID, b.helpText(b.adminChats[chatID], ctx.Data()))
+
+	return nil
+}
+
+// helpText returns the help on the query for a chat, with the admin commands for the admin chats. An empty
+// query lists every command, otherwise it names a command, with or without the slash, or a topic, which
+// may be shortened, e.g. "filter".
+func (b *Bot) helpText(admin bool, query string) string {
+	commands := b.availableCommands(admin)
+	query = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(query), "/"))
+	if query == "" {
+		return helpOverview(commands)
+	}
+
+	for _, command := range commands {
+		if command.text == query {
+			return commandHelp(command)
+		}
+	}
+
+	var topics []string
+	for _, topic := range helpTopics {
+		topicCommands := commandsOf(commands, topic.name)
+		if len(topicCommands) == 0 {
+			continue
+		}
+		if strings.HasPrefix(topic.name, query) {
+			return topicHelp(topic, topicCommands)
+		}
+		topics = append(topics, topic.name)
+	}
+
+	return fmt.Sprintf("🤷 No help on %q.\nTopics: %s\n%s", query, strings.Join(topics, ", "), helpUsage)
+}
+
+// helpOverview lists the commands by topic.
+func helpOverview(commands []botCommand) string {
+	var builder strings.Builder
+	builder.WriteString("ℹ️ Send /help <topic> or /help <command> for the details and examples.\n")
+	for _, topic := range helpTopics {
+		topicCommands := commandsOf(commands, topic.name)
+		if len(topicCommands) == 0 {
+			continue
+		}
+		fmt.Fprintf(&builder, "\n%s (%s)\n", topic.title, topic.name)
+		for _, command := range topicCommands {
+			fmt.Fprintf(&builder, "/%s — %s\n", command.text, command.description)
+		}
+	}
+
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// topicHelp explains every command of the topic.
+func topicHelp(topic helpTopic, commands []botCommand) string {
+	sections := make([]string, 0, len(commands))
+	for _, command := range commands {
+		sections = append(sections, commandHelp(command))
+	}
+
+	return topic.title + "\n\n" + strings.Join(sections, "\n\n")
+}
+
+// commandHelp explains the command with its usage and examples.
+func commandHelp(command botCommand) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "/%s — %s", command.text, command.description)
+	if command.usage != "" {
+		builder.WriteString("\n" + command.usage)
+	}
+	if len(command.examples) > 0 {
+		builder.WriteString("\nExamples:")
+		for _, example := range command.examples {
+			builder.WriteString("\n• " + example)
+		}
+	}
+
+	return builder.String()
+}
+
+// commandsOf returns the commands of the topic.
+func commandsOf(commands []botCommand, topic string) []botCommand {
+	var topicCommands []botCommand
+	for _, command := range commands {
+		if command.topic == topic {
+			topicCommands = append(topicCommands, command)
+		}
+	}
+
+	return topicCommands
+}
@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelpText(t *testing.T) {
+	t.Parallel()
+
+	testBot := Bot{}
+
+	t.Run("commands by topic", func(t *testing.T) {
+		t.Parallel()
+
+		text := testBot.helpText(false, "")
+
+		assert.Contains(t, text, "🎛 Filters and delivery (filters)\n/dmme — ")
+		assert.Contains(t, text, "/help — Show the commands")
+		assert.NotContains(t, text, "/stats", "admin commands are listed in admin chats only")
+		assert.NotContains(t, text, "/cancel", "commands of disabled features are not listed")
+		assert.Contains(t, testBot.helpText(true, ""), "👮 Administration (admin)\n")
+	})
+
+	t.Run("shortened topic", func(t *testing.T) {
+		t.Parallel()
+
+		text := testBot.helpText(false, "filter")
+
+		assert.Contains(t, text, "🎛 Filters and delivery\n\n")
+		assert.Contains(t, text, "/silent — Deliver change categories silently\nUsage: /silent")
+		assert.NotContains(t, text, "/list")
+	})
+
+	t.Run("command with usage and examples", func(t *testing.T) {
+		t.Parallel()
+
+		text := testBot.helpText(false, " /List ")
+
+		assert.Equal(t, "/list — List the current products\n"+listUsage+
+			"\nExamples:\n• /list Diver 100-500 instock\n• /list sort:-price", text)
+	})
+
+	t.Run("unknown query", func(t *testing.T) {
+		t.Parallel()
+
+		text := testBot.helpText(false, "admin")
+
+		assert.Contains(t, text, `No help on "admin"`)
+		assert.Contains(t, text, "Topics: basics, filters, watches, products\n")
+	})
+}
+
+func TestHelpHandler(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(7)
+	testBot := Bot{log: slog.Default(), adminChats: map[int64]bool{chatID: true}}
+	ctx, api := newTestContext(chatID, "stats")
+
+	require.NoError(t, testBot.helpHandler(ctx))
+
+	require.Len(t, api.sent, 1)
+	assert.Equal(t, "/stats — Show the subscriber statistics", api.sent[0])
+}
@@ -1,6 +1,32 @@
 package bot
 
-import "gopkg.in/telebot.v4"
+import (
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+	"gopkg.in/telebot.v4"
+)
+
+// Repository is a set of storage methods used by the bot.
+type Repository interface {
+	sqlite.SubscribeRepository
+	sqlite.AllowedChatsRepository
+	sqlite.ChatSettingsRepository
+	sqlite.UptimeRepository
+	sqlite.TargetRepository
+	sqlite.SubscriberStatsRepository
+	sqlite.StateRepository
+	sqlite.ProductRepository
+	sqlite.HistoryRepository
+	sqlite.NotificationRepository
+	sqlite.WishlistRepository
+	sqlite.AlertRuleRepository
+	sqlite.UserRepository
+	sqlite.FeedbackRepository
+	sqlite.PrivacyRepository
+	sqlite.ChatMigrationRepository
+	sqlite.ReachabilityRepository
+	sqlite.RunArtifactRepository
+	sqlite.CheckErrorRepository
+}
 
 type API interface {
 	// Handle lets you set the handler for some command name or one of the supported endpoints. It also applies middleware if such passed to the function.
@@ -15,4 +41,22 @@ type API interface {
 	NewContext(u telebot.Update) telebot.Context
 
 	Send(to telebot.Recipient, what interface{}, opts ...interface{}) (*telebot.Message, error)
+
+	// SendAlbum sends multiple instances of media as a single message.
+	SendAlbum(to telebot.Recipient, a telebot.Album, opts ...interface{}) ([]telebot.Message, error)
+
+	// ChatByID fetches chat info of its ID.
+	ChatByID(id int64) (*telebot.Chat, error)
+
+	// Pin pins a message in a supergroup or a channel.
+	Pin(msg telebot.Editable, opts ...interface{}) error
+
+	// Unpin unpins a message in a supergroup or a channel.
+	Unpin(chat telebot.Recipient, messageID ...int) error
+
+	// SetCommands changes the command menu of the bot for the scope passed in the options.
+	SetCommands(opts ...interface{}) error
+
+	// ChatMemberOf returns information about a member of the chat.
+	ChatMemberOf(chat telebot.Recipient, user telebot.Recipient) (*telebot.ChatMember, error)
 }
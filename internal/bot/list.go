@@ -0,0 +1,214 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+// listUnique routes the callbacks of the /list page buttons.
+const listUnique = "list"
+
+// listPageSize is the number of products shown on a page of /list.
+const listPageSize = 10
+
+// listMaxData is the size limit Telegram puts on the data of a callback button, the pages of a list
+// whose filters don't fit can't be turned.
+const listMaxData = 64
+
+// listUsage explains the /list command.
+const listUsage = "Usage: /list [type] [min-max] [instock] [field=value] [sort:price|-price|type|model]\n" +
+	"e.g. /list Diver 100-500 instock margin_flag=true sort:price"
+
+// listQuery is what /list shows: the products matching the filter in the given order.
+type listQuery struct {
+	filter models.ProductFilter
+	sort   models.ProductSort
+}
+
+// listHandler handles the /list command: it shows the first page of the current products matching
+// the filters of the argument. The pages are turned with buttons, see listCallback.
+func (b *Bot) listHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+
+	args := strings.Join(strings.Fields(ctx.Data()), " ")
+	query, err := parseListQuery(args)
+	if err != nil {
+		b.sendMessage(ctx, chatID, fmt.Sprintf("⚠️ %v.\n%s", err, listUsage))
+		return nil
+	}
+
+	text, markup, err := b.listPage(context.Background(), query, args, 0)
+	if err != nil {
+		b.log.Error("Failed to list products", "chatID", chatID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to list the products.")
+		return nil
+	}
+
+	if err = ctx.Send(text, markup, telebot.ModeMarkdown); err != nil {
+		b.log.Error("Failed to send product list", "chatID", chatID, "err", err)
+	}
+
+	return nil
+}
+
+// listCallback handles a press of a /list page button: it redraws the list with the requested page.
+func (b *Bot) listCallback(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+
+	rawOffset, args, _ := strings.Cut(ctx.Callback().Data, "|")
+	offset, err := strconv.Atoi(rawOffset)
+	if err != nil {
+		return b.respond(ctx, "")
+	}
+	query, err := parseListQuery(args)
+	if err != nil {
+		return b.respond(ctx, "")
+	}
+
+	text, markup, err := b.listPage(context.Background(), query, args, offset)
+	if err != nil {
+		b.log.Error("Failed to list products", "chatID", chatID, "err", err)
+		return b.respond(ctx, "⛔ An internal error occurred. Failed to list the products.")
+	}
+
+	if err = ctx.Edit(text, markup, telebot.ModeMarkdown); err != nil &&
+		!strings.Contains(err.Error(), "message is not modified") {
+		b.log.Warn("Failed to redraw product list", "chatID", chatID, "err", err)
+	}
+
+	return b.respond(ctx, "")
+}
+
+// listPage renders the page of the products starting at the offset with the buttons turning the pages.
+// The arguments of the command are kept in the buttons to list the same products.
+func (b *Bot) listPage(
+	ctx context.Context,
+	query listQuery,
+	args string,
+	offset int,
+) (string, *telebot.ReplyMarkup, error) {
+	page, err := b.repo.ListProducts(ctx, query.filter, query.sort, offset, listPageSize)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to list products: %w", err)
+	}
+
+	markup := &telebot.ReplyMarkup{}
+	var buttons []telebot.Btn
+	if offset > 0 {
+		buttons = append(buttons, listButton(markup, "« Prev", max(offset-listPageSize, 0), args))
+	}
+	if offset+len(page.Products) < page.Total {
+		buttons = append(buttons, listButton(markup, "Next »", offset+listPageSize, args))
+	}
+	text := formatProductList(page, offset)
+	if len(buttons) == 0 {
+		return text, nil, nil
+	}
+	for _, button := range buttons {
+		if len(button.CallbackUnique())+len(button.Data)+1 > listMaxData {
+			return text, nil, nil
+		}
+	}
+	markup.Inline(markup.Row(buttons...))
+
+	return text, markup, nil
+}
+
+// listButton creates a button showing the page of the listed products starting at the offset.
+func listButton(markup *telebot.ReplyMarkup, text string, offset int, args string) telebot.Btn {
+	return markup.Data(text, listUnique, strconv.Itoa(offset)+"|"+args)
+}
+
+// parseListQuery parses the arguments of /list. A price range, "instock", "<field>=<value>" of an extra
+// or computed field and "sort:<order>" are recognized, the rest of the words are the product type.
+func parseListQuery(args string) (listQuery, error) {
+	query := listQuery{sort: models.SortByModel}
+
+	var typeWords []string
+	for _, word := range strings.Fields(args) {
+		switch {
+		case strings.EqualFold(word, "instock"):
+			query.filter.InStock = true
+		case strings.HasPrefix(strings.ToLower(word), "sort:"):
+			sort, err := models.ParseProductSort(strings.ToLower(word[len("sort:"):]))
+			if err != nil {
+				return listQuery{}, fmt.Errorf("unknown sort order %q", word[len("sort:"):])
+			}
+			query.sort = sort
+		case strings.Contains(word, "="):
+			name, value, _ := strings.Cut(word, "=")
+			if name == "" || strings.Contains(name, `"`) {
+				return listQuery{}, fmt.Errorf("invalid field filter %q", word)
+			}
+			if query.filter.Extras == nil {
+				query.filter.Extras = make(map[string]string)
+			}
+			query.filter.Extras[name] = value
+		default:
+			minPrice, maxPrice, ok := parsePriceRange(word)
+			if !ok {
+				typeWords = append(typeWords, word)
+				continue
+			}
+			query.filter.MinPrice, query.filter.MaxPrice = minPrice, maxPrice
+		}
+	}
+	query.filter.Type = strings.Join(typeWords, " ")
+
+	return query, nil
+}
+
+// parsePriceRange parses a price range like "100-500", "100-" or "-500".
+func parsePriceRange(word string) (*float64, *float64, bool) {
+	rawMin, rawMax, found := strings.Cut(word, "-")
+	if !found || (rawMin == "" && rawMax == "") {
+		return nil, nil, false
+	}
+
+	bound := func(raw string) (*float64, bool) {
+		if raw == "" {
+			return nil, true
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, false
+		}
+		return &value, true
+	}
+	minPrice, minOK := bound(rawMin)
+	maxPrice, maxOK := bound(rawMax)
+	if !minOK || !maxOK {
+		return nil, nil, false
+	}
+
+	return minPrice, maxPrice, true
+}
+
+// formatProductList describes a page of the listed products.
+func formatProductList(page *models.ProductPage, offset int) string {
+	if page.Total == 0 {
+		return "🤷 No products match."
+	}
+	if len(page.Products) == 0 {
+		return fmt.Sprintf("🤷 There are only %d products.", page.Total)
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "📋 *Products %d–%d of %d*", offset+1, offset+len(page.Products), page.Total)
+	for _, product := range page.Products {
+		builder.WriteString("\n" + formatProductLine(product))
+	}
+
+	return builder.String()
+}
+
+// formatProductLine describes a listed product on a single line.
+func formatProductLine(product models.Product) string {
+	return fmt.Sprintf("`%s` (%s): *%s*, quantity: %s",
+		product.Model, groupName(product.Category, product.Type), product.Price, product.Quantity)
+}
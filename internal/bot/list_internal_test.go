@@ -0,0 +1,218 @@
+package bot
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListHandler(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(-100)
+	page := &models.ProductPage{Products: []models.Product{
+		{Model: "A1", Category: "new", Type: "Diver", Price: "120", Quantity: "3"},
+		{Model: "B2", Type: "Diver", Price: "200", Quantity: "1"},
+	}, Total: 12}
+
+	t.Run("first page of the filtered products", func(t *testing.T) {
+		t.Parallel()
+
+		minPrice := 100.0
+		filter := models.ProductFilter{Type: "Diver", MinPrice: &minPrice, InStock: true}
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("ListProducts", mock.Anything, filter, models.SortByPrice, 0, listPageSize).Return(page, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, " Diver  100- instock sort:price")
+
+		require.NoError(t, testBot.listHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Equal(t, "📋 *Products 1–2 of 12*\n`A1` (new · Diver): *120*, quantity: 3\n"+
+			"`B2` (Diver): *200*, quantity: 1", api.sent[0])
+	})
+
+	t.Run("unknown sort order", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), repo: mocks.NewRepository(t), allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, "sort:cheap")
+
+		require.NoError(t, testBot.listHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], `unknown sort order "cheap"`)
+		assert.Contains(t, api.sent[0], listUsage)
+	})
+
+	t.Run("unauthorized chat", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), repo: mocks.NewRepository(t), allowedChats: map[int64]bool{}}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.route("/list", accessAllowed, testBot.listHandler)(ctx))
+		assert.Contains(t, api.sent[0], "this bot is private")
+	})
+
+	t.Run("error: cannot list products", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("ListProducts", mock.Anything, models.ProductFilter{}, models.SortByModel, 0, listPageSize).
+			Return(nil, assert.AnError).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.listHandler(ctx))
+		assert.Contains(t, api.sent[0], "internal error")
+	})
+}
+
+func TestListCallback(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(-100)
+
+	t.Run("next page of the same products", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("ListProducts", mock.Anything, models.ProductFilter{Type: "Diver"}, models.SortByModel, 10, 10).
+			Return(&models.ProductPage{Products: []models.Product{{Model: "K11", Price: "1"}}, Total: 11}, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newCallbackContext(chatID, "10|Diver")
+
+		require.NoError(t, testBot.listCallback(ctx))
+		require.Len(t, api.edited, 1)
+		assert.Contains(t, api.edited[0], "Products 11–11 of 11")
+		assert.Len(t, api.answers, 1)
+	})
+
+	t.Run("malformed data is ignored", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), repo: mocks.NewRepository(t), allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newCallbackContext(chatID, "next|Diver")
+
+		require.NoError(t, testBot.listCallback(ctx))
+		assert.Empty(t, api.edited)
+		assert.Len(t, api.answers, 1)
+	})
+
+	t.Run("unauthorized chat", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), repo: mocks.NewRepository(t), allowedChats: map[int64]bool{}}
+		ctx, api := newCallbackContext(chatID, "10|")
+
+		require.NoError(t, testBot.route(listUnique, accessAllowed, testBot.listCallback)(ctx))
+		assert.Empty(t, api.edited)
+		require.Len(t, api.answers, 1)
+		assert.Contains(t, api.answers[0].Text, "private")
+	})
+}
+
+func TestListPage(t *testing.T) {
+	t.Parallel()
+
+	products := func(count int) []models.Product { return make([]models.Product, count) }
+	testCases := []struct {
+		name        string
+		offset      int
+		args        string
+		page        *models.ProductPage
+		wantButtons []string
+	}{
+		{"single page", 0, "", &models.ProductPage{Products: products(3), Total: 3}, nil},
+		{"first page", 0, "Diver", &models.ProductPage{Products: products(10), Total: 25}, []string{"10|Diver"}},
+		{
+			"middle page", 10, "Diver", &models.ProductPage{Products: products(10), Total: 25},
+			[]string{"0|Diver", "20|Diver"},
+		},
+		{"last page", 20, "", &models.ProductPage{Products: products(5), Total: 25}, []string{"10|"}},
+		{
+			"arguments too long for a button", 0, strings.Repeat("x", 60),
+			&models.ProductPage{Products: products(10), Total: 25}, nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := mocks.NewRepository(t)
+			mockRepo.On("ListProducts", mock.Anything, mock.Anything, models.SortByModel, tc.offset, listPageSize).
+				Return(tc.page, nil).Once()
+			testBot := Bot{log: slog.Default(), repo: mockRepo}
+
+			_, markup, err := testBot.listPage(t.Context(), listQuery{sort: models.SortByModel}, tc.args, tc.offset)
+
+			require.NoError(t, err)
+			if tc.wantButtons == nil {
+				assert.Nil(t, markup)
+				return
+			}
+			require.Len(t, markup.InlineKeyboard, 1)
+			var data []string
+			for _, button := range markup.InlineKeyboard[0] {
+				data = append(data, strings.TrimPrefix(button.Data, "\f"+listUnique+"|"))
+			}
+			assert.Equal(t, tc.wantButtons, data)
+		})
+	}
+}
+
+func TestParseListQuery(t *testing.T) {
+	t.Parallel()
+
+	price := func(value float64) *float64 { return &value }
+	testCases := []struct {
+		args string
+		want listQuery
+	}{
+		{"", listQuery{sort: models.SortByModel}},
+		{"Smart Watch", listQuery{filter: models.ProductFilter{Type: "Smart Watch"}, sort: models.SortByModel}},
+		{
+			"100-500.5 INSTOCK Sort:-price",
+			listQuery{
+				filter: models.ProductFilter{MinPrice: price(100), MaxPrice: price(500.5), InStock: true},
+				sort:   models.SortByPriceDesc,
+			},
+		},
+		{"-300", listQuery{filter: models.ProductFilter{MaxPrice: price(300)}, sort: models.SortByModel}},
+		{"G-Shock", listQuery{filter: models.ProductFilter{Type: "G-Shock"}, sort: models.SortByModel}},
+		{"- sort:type", listQuery{filter: models.ProductFilter{Type: "-"}, sort: models.SortByType}},
+		{
+			"Diver margin_flag=true ean=",
+			listQuery{
+				filter: models.ProductFilter{
+					Type: "Diver", Extras: map[string]string{"margin_flag": "true", "ean": ""},
+				},
+				sort: models.SortByModel,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		got, err := parseListQuery(tc.args)
+		require.NoError(t, err, tc.args)
+		assert.Equal(t, tc.want, got, tc.args)
+	}
+
+	_, err := parseListQuery("sort:random")
+	require.Error(t, err)
+	_, err = parseListQuery("=true")
+	require.Error(t, err)
+}
+
+func TestFormatProductList(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "🤷 No products match.", formatProductList(&models.ProductPage{}, 0))
+	assert.Equal(t, "🤷 There are only 3 products.", formatProductList(&models.ProductPage{Total: 3}, 10))
+}
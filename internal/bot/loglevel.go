@@ -0,0 +1,86 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"gopkg.in/telebot.v4"
+)
+
+// logLevelUsage explains the /loglevel command.
+const logLevelUsage = "Usage: /loglevel [<component> <debug|info|warn|error|reset>]"
+
+// LogLevels reads and changes the levels of the loggers of the components at runtime.
+type LogLevels interface {
+	// All returns the current level of every component.
+	All() map[string]slog.Level
+	// Set changes the level of the component.
+	Set(component string, level slog.Level) error
+	// Reset restores the configured level of the component.
+	Reset(component string) error
+}
+
+// WithLogLevels enables the /loglevel command, which shows and changes the log levels.
+func WithLogLevels(levels LogLevels) Option {
+	return func(b *Bot) {
+		b.logLevels = levels
+	}
+}
+
+// logLevelHandler handles the admin /loglevel command: without arguments it shows the level of every
+// component, "/loglevel parser debug" changes the level of the parser until the restart and
+// "/loglevel parser reset" restores the configured one.
+func (b *Bot) logLevelHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+
+	if b.logLevels == nil {
+		b.sendMessage(ctx, chatID, "🤷 Changing log levels from the bot isn't available.")
+		return nil
+	}
+
+	data := strings.TrimSpace(ctx.Data())
+	if data == "" {
+		b.sendMessage(ctx, chatID, formatLogLevels(b.logLevels.All()))
+		return nil
+	}
+
+	component, levelName, _ := strings.Cut(data, " ")
+	levelName = strings.TrimSpace(levelName)
+	var err error
+	if strings.EqualFold(levelName, "reset") {
+		err = b.logLevels.Reset(component)
+	} else {
+		var level slog.Level
+		if levelName == "" || level.UnmarshalText([]byte(levelName)) != nil {
+			b.sendMessage(ctx, chatID, logLevelUsage)
+			return nil
+		}
+		err = b.logLevels.Set(component, level)
+	}
+	if err != nil {
+		components := slices.Sorted(maps.Keys(b.logLevels.All()))
+		b.sendMessage(ctx, chatID, fmt.Sprintf("🤷 There is no component named %s, expected one of: %s.",
+			component, strings.Join(components, ", ")))
+		return nil
+	}
+
+	level := b.logLevels.All()[component]
+	b.log.Warn("Log level changed", "chatID", chatID, "logComponent", component, "level", level)
+	b.sendMessage(ctx, chatID, fmt.Sprintf("📝 The %s log level is %s until the restart.", component, level))
+
+	return nil
+}
+
+// formatLogLevels lists the levels of the components ordered by name.
+func formatLogLevels(levels map[string]slog.Level) string {
+	var builder strings.Builder
+	builder.WriteString("📝 Log levels")
+	for _, component := range slices.Sorted(maps.Keys(levels)) {
+		fmt.Fprintf(&builder, "\n%s: %s", component, levels[component])
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogLevelHandler(t *testing.T) {
+	t.Parallel()
+
+	const adminID = int64(42)
+
+	newTestBot := func() (*Bot, *logging.Levels) {
+		levels := logging.NewLevels(slog.LevelWarn, map[string]slog.Level{logging.ComponentBot: slog.LevelInfo})
+		return &Bot{log: slog.Default(), adminChats: map[int64]bool{adminID: true}, logLevels: levels}, levels
+	}
+
+	t.Run("levels are listed", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, _ := newTestBot()
+		ctx, api := newTestContext(adminID, "")
+
+		require.NoError(t, testBot.logLevelHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Equal(t, "📝 Log levels\nbot: INFO\ndefault: WARN\nparser: WARN\nrepository: WARN", api.sent[0])
+	})
+
+	t.Run("level is changed and reset", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, levels := newTestBot()
+		ctx, api := newTestContext(adminID, "parser debug")
+
+		require.NoError(t, testBot.logLevelHandler(ctx))
+		assert.Equal(t, slog.LevelDebug, levels.Level(logging.ComponentParser))
+		assert.Contains(t, api.sent[0], "The parser log level is DEBUG")
+
+		ctx, api = newTestContext(adminID, "parser reset")
+		require.NoError(t, testBot.logLevelHandler(ctx))
+		assert.Equal(t, slog.LevelWarn, levels.Level(logging.ComponentParser))
+		assert.Contains(t, api.sent[0], "The parser log level is WARN")
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, levels := newTestBot()
+		for _, payload := range []string{"parser", "parser loud"} {
+			ctx, api := newTestContext(adminID, payload)
+			require.NoError(t, testBot.logLevelHandler(ctx))
+			assert.Equal(t, logLevelUsage, api.sent[0])
+		}
+
+		ctx, api := newTestContext(adminID, "scheduler debug")
+		require.NoError(t, testBot.logLevelHandler(ctx))
+		assert.Contains(t, api.sent[0], "There is no component named scheduler")
+		assert.Equal(t, slog.LevelWarn, levels.Level(logging.ComponentParser))
+	})
+
+	t.Run("not available", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), adminChats: map[int64]bool{adminID: true}}
+		ctx, api := newTestContext(adminID, "")
+
+		require.NoError(t, testBot.logLevelHandler(ctx))
+		assert.Contains(t, api.sent[0], "isn't available")
+	})
+
+	t.Run("non-admin is refused", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, levels := newTestBot()
+		ctx, api := newTestContext(7, "parser debug")
+
+		require.NoError(t, testBot.route("/loglevel", accessAdmin, testBot.logLevelHandler)(ctx))
+		assert.Contains(t, api.sent[0], "administrators only")
+		assert.Equal(t, slog.LevelWarn, levels.Level(logging.ComponentParser))
+	})
+}
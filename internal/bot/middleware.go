@@ -0,0 +1,305 @@
+package bot
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/metrics"
+	"gopkg.in/telebot.v4"
+)
+
+// access is who may use a route.
+type access int
+
+const (
+	// accessPublic routes are used by anyone, their handlers check the chat where it matters, e.g.
+	// inline queries come from users rather than chats.
+	accessPublic access = iota
+	// accessAllowed routes are used in the allowed chats.
+	accessAllowed
+	// accessJoin routes are used in the allowed chats, the bot leaves the other chats using them.
+	accessJoin
+	// accessAdmin routes are used in the admin chats.
+	accessAdmin
+)
+
+// resultKey stores the result of an update the handler chain stopped early, see observe.
+const resultKey = "result"
+
+// Results of the updates the handler chain stopped early.
+const (
+	resultPanic   = "panic"
+	resultDenied  = "denied"
+	resultLimited = "limited"
+)
+
+// WithMetrics counts the updates handled by the bot and observes how long they took.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(b *Bot) {
+		b.metrics = m
+	}
+}
+
+// WithRateLimit limits the updates every chat may send to limit a minute, the others are dropped.
+// A limit of zero or less disables it.
+func WithRateLimit(limit int) Option {
+	return func(b *Bot) {
+		if limit > 0 {
+			b.limiter = newRateLimiter(limit, time.Minute)
+		}
+	}
+}
+
+// route wraps the handler of the endpoint with the middleware every update passes, outermost first:
+// logging and metrics, panic recovery, rate limiting, authorization and the activity tracking.
+func (b *Bot) route(endpoint any, level access, handler telebot.HandlerFunc) telebot.HandlerFunc {
+	name := routeName(endpoint)
+	middleware := []telebot.MiddlewareFunc{
+		b.observe(name),
+		b.recoverPanic(name),
+		b.limitRate(name),
+		b.authorize(name, level),
+		b.trackActivity,
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+
+	return handler
+}
+
+// routeName returns the name of the endpoint used in logs and metrics, e.g. "/list", "text" or
+// "callback:settings".
+func routeName(endpoint any) string {
+	switch endpoint := endpoint.(type) {
+	case string:
+		return strings.TrimPrefix(endpoint, "\a")
+	case *telebot.Btn:
+		return "callback:" + endpoint.Unique
+	default:
+		return fmt.Sprint(endpoint)
+	}
+}
+
+// updateIDs returns the chat and the user of the update, zero if it has none.
+func updateIDs(ctx telebot.Context) (int64, int64) {
+	var chatID, userID int64
+	if chat := ctx.Chat(); chat != nil {
+		chatID = chat.ID
+	}
+	if sender := ctx.Sender(); sender != nil {
+		userID = sender.ID
+	}
+
+	return chatID, userID
+}
+
+// observe logs every update of the route with its chat and user, and counts it in the metrics.
+func (b *Bot) observe(route string) telebot.MiddlewareFunc {
+	return func(next telebot.HandlerFunc) telebot.HandlerFunc {
+		return func(ctx telebot.Context) error {
+			start := time.Now()
+			err := next(ctx)
+			took := time.Since(start)
+
+			result := "ok"
+			if stopped, ok := ctx.Get(resultKey).(string); ok {
+				result = stopped
+			} else if err != nil {
+				result = "error"
+			}
+
+			chatID, userID := updateIDs(ctx)
+			b.log.Debug("Handled update", "route", route, "chatID", chatID, "userID", userID,
+				"result", result, "took", took, "err", err)
+			if b.metrics != nil {
+				b.metrics.BotUpdates.WithLabelValues(route, result).Inc()
+				b.metrics.BotUpdateDuration.WithLabelValues(route).Observe(took.Seconds())
+			}
+
+			return err
+		}
+	}
+}
+
+// recoverPanic stops a panic of the handler from crashing the bot: it's logged with the stack and the
+// chat is told the update failed.
+func (b *Bot) recoverPanic(route string) telebot.MiddlewareFunc {
+	return func(next telebot.HandlerFunc) telebot.HandlerFunc {
+		return func(ctx telebot.Context) (err error) {
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+
+				chatID, userID := updateIDs(ctx)
+				b.log.Error("Handler panicked", "route", route, "chatID", chatID, "userID", userID,
+					"panic", recovered, "stack", string(debug.Stack()))
+				ctx.Set(resultKey, resultPanic)
+				err = b.refuse(ctx, chatID, "⛔ An internal error occurred.")
+			}()
+
+			return next(ctx)
+		}
+	}
+}
+
+// limitRate drops the updates of a chat over the rate limit. The chat is told to slow down once, when
+// it reaches the limit.
+func (b *Bot) limitRate(route string) telebot.MiddlewareFunc {
+	return func(next telebot.HandlerFunc) telebot.HandlerFunc {
+		return func(ctx telebot.Context) error {
+			chatID, userID := updateIDs(ctx)
+			key := chatID
+			if key == 0 {
+				key = userID
+			}
+			if b.limiter == nil || key == 0 {
+				return next(ctx)
+			}
+
+			allowed, first := b.limiter.allow(key, time.Now())
+			if allowed {
+				return next(ctx)
+			}
+
+			ctx.Set(resultKey, resultLimited)
+			if !first {
+				return nil
+			}
+			b.log.Warn("Chat is rate limited", "route", route, "chatID", chatID, "userID", userID)
+			// Inline queries have no chat to answer in, they just get no results.
+			if chatID == 0 {
+				return nil
+			}
+
+			return b.refuse(ctx, chatID, "🐢 Too many requests, please slow down.")
+		}
+	}
+}
+
+// authorize refuses the updates of the chats that may not use a route of the level.
+func (b *Bot) authorize(route string, level access) telebot.MiddlewareFunc {
+	return func(next telebot.HandlerFunc) telebot.HandlerFunc {
+		return func(ctx telebot.Context) error {
+			chatID, _ := updateIDs(ctx)
+			switch {
+			case level == accessPublic:
+				return next(ctx)
+			case level == accessAdmin && b.adminChats[chatID]:
+				return next(ctx)
+			case level != accessAdmin && b.isAllowed(chatID):
+				return next(ctx)
+			}
+
+			b.log.Warn("Unauthorized update refused", "route", route, "chatID", chatID)
+			ctx.Set(resultKey, resultDenied)
+			switch level {
+			case accessAdmin:
+				if ctx.Callback() != nil {
+					return b.respond(ctx, "👮 This action is available to administrators only.")
+				}
+				b.sendMessage(ctx, chatID, "👮 This command is available to administrators only.")
+			case accessJoin:
+				b.sendMessage(ctx, chatID, "👮 Sorry, this bot is private and cannot be used in this chat.")
+				if err := b.bot.Leave(ctx.Recipient()); err != nil {
+					return fmt.Errorf("failed to leave chat: %w", err)
+				}
+			default:
+				if ctx.Callback() != nil {
+					return b.respond(ctx, "👮 This bot is private.")
+				}
+				b.sendMessage(ctx, chatID, "👮 Sorry, this bot is private and cannot be used in this chat.")
+			}
+
+			return nil
+		}
+	}
+}
+
+// refuse answers an update the bot didn't handle: a button press gets the text as its answer, other
+// updates as a message.
+func (b *Bot) refuse(ctx telebot.Context, chatID int64, text string) error {
+	if ctx.Callback() != nil {
+		return b.respond(ctx, text)
+	}
+	b.sendMessage(ctx, chatID, text)
+
+	return nil
+}
+
+// rateLimiter is a token bucket per chat: a chat may send burst updates at once, and one more every
+// interval/burst.
+type rateLimiter struct {
+	burst    float64
+	interval time.Duration
+
+	mu      sync.Mutex
+	buckets map[int64]*bucket
+}
+
+// bucket holds the updates a chat may still send.
+type bucket struct {
+	tokens float64
+	// updated is when the tokens were last refilled.
+	updated time.Time
+	// limited is set once the chat was told it's over the limit, until it may send again.
+	limited bool
+}
+
+// maxIdleBuckets is the number of buckets kept before the full ones are dropped.
+const maxIdleBuckets = 1024
+
+// newRateLimiter creates a limiter allowing limit updates every interval.
+func newRateLimiter(limit int, interval time.Duration) *rateLimiter {
+	return &rateLimiter{burst: float64(limit), interval: interval, buckets: make(map[int64]*bucket)}
+}
+
+// allow takes a token of the chat and reports whether it had one. first is set for the first update of
+// the chat refused since it last had a token.
+func (l *rateLimiter) allow(chatID int64, now time.Time) (bool, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.buckets) >= maxIdleBuckets {
+		l.dropFull(now)
+	}
+
+	chatBucket, ok := l.buckets[chatID]
+	if !ok {
+		chatBucket = &bucket{tokens: l.burst, updated: now}
+		l.buckets[chatID] = chatBucket
+	}
+	chatBucket.tokens = l.refilled(chatBucket, now)
+	chatBucket.updated = now
+
+	if chatBucket.tokens < 1 {
+		first := !chatBucket.limited
+		chatBucket.limited = true
+		return false, first
+	}
+	chatBucket.tokens--
+	chatBucket.limited = false
+
+	return true, false
+}
+
+// refilled returns the tokens of the bucket refilled up to now.
+func (l *rateLimiter) refilled(chatBucket *bucket, now time.Time) float64 {
+	elapsed := now.Sub(chatBucket.updated)
+
+	return min(l.burst, chatBucket.tokens+l.burst*elapsed.Seconds()/l.interval.Seconds())
+}
+
+// dropFull forgets the chats that would have a full bucket by now, they are the same as new ones.
+func (l *rateLimiter) dropFull(now time.Time) {
+	for chatID, chatBucket := range l.buckets {
+		if l.refilled(chatBucket, now) >= l.burst {
+			delete(l.buckets, chatID)
+		}
+	}
+}
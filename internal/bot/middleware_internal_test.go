@@ -0,0 +1,140 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+func TestRoute(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(-100)
+
+	t.Run("public route is handled", func(t *testing.T) {
+		t.Parallel()
+
+		appMetrics := metrics.New()
+		testBot := Bot{log: slog.Default(), metrics: appMetrics}
+		handled := false
+		handler := testBot.route("/status", accessPublic, func(telebot.Context) error {
+			handled = true
+			return nil
+		})
+		ctx, _ := newTestContext(chatID, "")
+
+		require.NoError(t, handler(ctx))
+		assert.True(t, handled)
+		assert.InDelta(t, 1, testutil.ToFloat64(appMetrics.BotUpdates.WithLabelValues("/status", "ok")), 0)
+	})
+
+	t.Run("admin route refuses other chats", func(t *testing.T) {
+		t.Parallel()
+
+		appMetrics := metrics.New()
+		testBot := Bot{log: slog.Default(), allowedChats: map[int64]bool{chatID: true}, metrics: appMetrics}
+		handler := testBot.route("/stats", accessAdmin, func(telebot.Context) error {
+			t.Fatal("the handler of an admin route ran in another chat")
+			return nil
+		})
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, handler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "administrators only")
+		assert.InDelta(t, 1, testutil.ToFloat64(appMetrics.BotUpdates.WithLabelValues("/stats", "denied")), 0)
+	})
+
+	t.Run("callback of a disallowed chat is answered", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), allowedChats: map[int64]bool{}}
+		handler := testBot.route(&telebot.Btn{Unique: settingsUnique}, accessAllowed, func(telebot.Context) error {
+			t.Fatal("the handler ran in a disallowed chat")
+			return nil
+		})
+		ctx, api := newCallbackContext(chatID, "close")
+
+		require.NoError(t, handler(ctx))
+		require.Len(t, api.answers, 1)
+		assert.Contains(t, api.answers[0].Text, "private")
+	})
+
+	t.Run("panic is recovered", func(t *testing.T) {
+		t.Parallel()
+
+		appMetrics := metrics.New()
+		testBot := Bot{log: slog.Default(), allowedChats: map[int64]bool{}, metrics: appMetrics}
+		handler := testBot.route("/cancel", accessPublic, func(telebot.Context) error {
+			panic("boom")
+		})
+		ctx, api := newTestContext(chatID, "")
+
+		require.NotPanics(t, func() { require.NoError(t, handler(ctx)) })
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "internal error")
+		assert.InDelta(t, 1, testutil.ToFloat64(appMetrics.BotUpdates.WithLabelValues("/cancel", "panic")), 0)
+	})
+
+	t.Run("chat over the rate limit is told once", func(t *testing.T) {
+		t.Parallel()
+
+		appMetrics := metrics.New()
+		testBot := Bot{log: slog.Default(), limiter: newRateLimiter(2, time.Minute), metrics: appMetrics}
+		handled := 0
+		handler := testBot.route("/cancel", accessPublic, func(telebot.Context) error {
+			handled++
+			return nil
+		})
+		ctx, api := newTestContext(chatID, "")
+
+		for range 4 {
+			require.NoError(t, handler(ctx))
+		}
+
+		assert.Equal(t, 2, handled)
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "slow down")
+		assert.InDelta(t, 2, testutil.ToFloat64(appMetrics.BotUpdates.WithLabelValues("/cancel", "limited")), 0)
+	})
+}
+
+func TestRouteName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "/list", routeName("/list"))
+	assert.Equal(t, "text", routeName(telebot.OnText))
+	assert.Equal(t, "callback:settings", routeName(&telebot.Btn{Unique: settingsUnique}))
+}
+
+func TestRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(2, time.Minute)
+
+	allowed, _ := limiter.allow(1, start)
+	assert.True(t, allowed)
+	allowed, _ = limiter.allow(1, start)
+	assert.True(t, allowed)
+	allowed, first := limiter.allow(1, start)
+	assert.False(t, allowed)
+	assert.True(t, first, "the first refused update is reported")
+	_, first = limiter.allow(1, start)
+	assert.False(t, first)
+
+	allowed, _ = limiter.allow(2, start)
+	assert.True(t, allowed, "the limit is per chat")
+
+	allowed, _ = limiter.allow(1, start.Add(30*time.Second))
+	assert.True(t, allowed, "a token is refilled every interval/limit")
+	allowed, first = limiter.allow(1, start.Add(30*time.Second))
+	assert.False(t, allowed)
+	assert.True(t, first, "the chat is told again once it sent an update")
+}
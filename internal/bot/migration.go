@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"context"
+	"errors"
+
+	"gopkg.in/telebot.v4"
+)
+
+// migrationHandler handles the service message Telegram sends a group upgraded to a supergroup, the
+// supergroup has an ID of its own.
+func (b *Bot) migrationHandler(ctx telebot.Context) error {
+	fromID, toID := ctx.Migration()
+	b.migrateChat(context.Background(), fromID, toID)
+
+	return nil
+}
+
+// migrateChat moves the subscription, the settings and filters and the other data of a group to the
+// supergroup it was upgraded to, and its access: a group allowed by configuration allows the supergroup
+// at runtime, as the configuration only knows the ID of the group.
+func (b *Bot) migrateChat(ctx context.Context, fromID, toID int64) {
+	allowed := b.isAllowed(fromID)
+	if allowed {
+		if err := b.repo.AllowChat(ctx, toID); err != nil {
+			b.log.ErrorContext(ctx, "Failed to allow the migrated chat", "from", fromID, "to", toID, "err", err)
+			return
+		}
+	}
+
+	moved, err := b.repo.MigrateChat(ctx, fromID, toID)
+	if err != nil {
+		b.log.ErrorContext(ctx, "Failed to migrate the chat to a supergroup", "from", fromID, "to", toID, "err", err)
+		return
+	}
+
+	b.mu.Lock()
+	if allowed {
+		b.allowedChats[toID] = true
+	}
+	if !b.configChats[fromID] {
+		delete(b.allowedChats, fromID)
+	}
+	b.mu.Unlock()
+
+	b.log.InfoContext(ctx, "Chat migrated to a supergroup", "from", fromID, "to", toID, "records", moved)
+	if b.configChats[fromID] {
+		b.log.WarnContext(ctx, "The migrated chat is allowed by CF_ALLOWED_CHAT_IDS, replace its ID there",
+			"from", fromID, "to", toID)
+	}
+}
+
+// sendTo sends the message to the chat. A group upgraded to a supergroup is migrated, see migrateChat,
+// and the message is sent to the supergroup instead. The text messages of a simulation are headed by a
+// banner. Whether the message reached the chat is tracked, see trackDelivery. The error of a message
+// Telegram throttled wraps ErrNotifyThrottled.
+func (b *Bot) sendTo(ctx context.Context, chatID int64, what any, opts ...any) (*telebot.Message, error) {
+	if text, ok := what.(string); ok && isSimulation(ctx) {
+		what = simulationBanner + text
+	}
+	msg, err := b.bot.Send(&telebot.Chat{ID: chatID}, what, opts...)
+	var upgraded telebot.GroupError
+	if !errors.As(err, &upgraded) || upgraded.MigratedTo == 0 {
+		b.trackDelivery(ctx, chatID, err)
+		return msg, throttled(err)
+	}
+
+	b.migrateChat(ctx, chatID, upgraded.MigratedTo)
+
+	msg, err = b.bot.Send(&telebot.Chat{ID: upgraded.MigratedTo}, what, opts...)
+	b.trackDelivery(ctx, upgraded.MigratedTo, err)
+
+	return msg, throttled(err)
+}
@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+// newMigrationContext creates the context of the service message of a group upgraded to a supergroup.
+func newMigrationContext(fromID, toID int64) telebot.Context {
+	return telebot.NewContext(&recordingAPI{}, telebot.Update{Message: &telebot.Message{
+		Chat: &telebot.Chat{ID: fromID}, MigrateFrom: fromID, MigrateTo: toID,
+	}})
+}
+
+func TestMigrationHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("access and data move to the supergroup", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("AllowChat", mock.Anything, int64(-1002)).Return(nil).Once()
+		mockRepo.On("MigrateChat", mock.Anything, int64(-2), int64(-1002)).Return(5, nil).Once()
+		testBot := newAccessTestBot(nil, mockRepo)
+		testBot.allowedChats[-2] = true
+
+		require.NoError(t, testBot.migrationHandler(newMigrationContext(-2, -1002)))
+		assert.True(t, testBot.isAllowed(-1002))
+		assert.False(t, testBot.isAllowed(-2))
+	})
+
+	t.Run("a group allowed by configuration stays allowed", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("AllowChat", mock.Anything, int64(-1001)).Return(nil).Once()
+		mockRepo.On("MigrateChat", mock.Anything, int64(-1), int64(-1001)).Return(3, nil).Once()
+		testBot := newAccessTestBot(nil, mockRepo)
+
+		require.NoError(t, testBot.migrationHandler(newMigrationContext(-1, -1001)))
+		assert.True(t, testBot.isAllowed(-1001))
+		assert.True(t, testBot.isAllowed(-1))
+	})
+
+	t.Run("a group without access moves its data only", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("MigrateChat", mock.Anything, int64(-3), int64(-1003)).Return(0, nil).Once()
+		testBot := newAccessTestBot(nil, mockRepo)
+
+		require.NoError(t, testBot.migrationHandler(newMigrationContext(-3, -1003)))
+		assert.False(t, testBot.isAllowed(-1003))
+	})
+
+	t.Run("error: migration keeps the access", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("AllowChat", mock.Anything, int64(-1002)).Return(nil).Once()
+		mockRepo.On("MigrateChat", mock.Anything, int64(-2), int64(-1002)).Return(0, assert.AnError).Once()
+		testBot := newAccessTestBot(nil, mockRepo)
+		testBot.allowedChats[-2] = true
+
+		require.NoError(t, testBot.migrationHandler(newMigrationContext(-2, -1002)))
+		assert.True(t, testBot.isAllowed(-2))
+		assert.False(t, testBot.isAllowed(-1002))
+	})
+}
+
+func TestSendTo(t *testing.T) {
+	t.Parallel()
+
+	t.Run("an upgraded group is migrated and sent to as the supergroup", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockBot.On("Send", &telebot.Chat{ID: -2}, "hello", mock.Anything).
+			Return(nil, telebot.GroupError{MigratedTo: -1002}).Once()
+		mockBot.On("Send", &telebot.Chat{ID: -1002}, "hello", mock.Anything).
+			Return(&telebot.Message{ID: 9, Chat: &telebot.Chat{ID: -1002}}, nil).Once()
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("AllowChat", mock.Anything, int64(-1002)).Return(nil).Once()
+		mockRepo.On("MigrateChat", mock.Anything, int64(-2), int64(-1002)).Return(4, nil).Once()
+		testBot := &Bot{
+			bot:          mockBot,
+			log:          slog.Default(),
+			repo:         mockRepo,
+			allowedChats: map[int64]bool{-2: true},
+		}
+
+		msg, err := testBot.sendTo(t.Context(), -2, "hello", &telebot.SendOptions{})
+
+		require.NoError(t, err)
+		assert.Equal(t, 9, msg.ID)
+		assert.True(t, testBot.isAllowed(-1002))
+	})
+
+	t.Run("other errors are returned", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockBot.On("Send", &telebot.Chat{ID: -2}, "hello").Return(nil, telebot.ErrBlockedByUser).Once()
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("RecordSendFailure", mock.Anything, int64(-2), mock.Anything,
+			telebot.ErrBlockedByUser.Error(), false).Return(nil).Once()
+		testBot := &Bot{bot: mockBot, log: slog.Default(), repo: mockRepo}
+
+		_, err := testBot.sendTo(t.Context(), -2, "hello")
+
+		require.ErrorIs(t, err, telebot.ErrBlockedByUser)
+		assert.True(t, testBot.unreachable[-2], "the failure is recorded")
+	})
+}
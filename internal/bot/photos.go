@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+// maxAlbumSize is the number of photos Telegram allows in a media group.
+const maxAlbumSize = 10
+
+// WithProductPhotos sends the images of the added products after the notifications, as albums with a
+// caption per photo.
+func WithProductPhotos() Option {
+	return func(b *Bot) {
+		b.photos = true
+	}
+}
+
+// productPhoto is the image of a product with its caption. The telebot photos are created for every
+// chat, as sending one overwrites it with the photo Telegram stored.
+type productPhoto struct {
+	url     string
+	caption string
+}
+
+// addedPhotos returns the images of the added products, nil if the photos aren't sent.
+func (b *Bot) addedPhotos(changes *models.Changes) []productPhoto {
+	if !b.photos {
+		return nil
+	}
+
+	var photos []productPhoto
+	for _, p := range changes.Added {
+		if p.ImageURL == "" {
+			continue
+		}
+		parts := []string{"🆕 " + p.Model}
+		for _, part := range []string{p.Type, p.Price} {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+		photos = append(photos, productPhoto{url: p.ImageURL, caption: strings.Join(parts, " · ")})
+	}
+
+	return photos
+}
+
+// sendPhotos sends the photos to the chat in albums of up to maxAlbumSize, a single photo left over is
+// sent on its own. An album that fails, e.g. as Telegram can't fetch one of its images, is sent photo by
+// photo, so only the broken images are left out.
+func (b *Bot) sendPhotos(ctx context.Context, chatID int64, photos []productPhoto, threadID int, silent bool) {
+	opts := &telebot.SendOptions{ThreadID: threadID, DisableNotification: silent}
+	for start := 0; start < len(photos); start += maxAlbumSize {
+		chunk := photos[start:min(start+maxAlbumSize, len(photos))]
+		if len(chunk) > 1 {
+			album := make(telebot.Album, 0, len(chunk))
+			for _, photo := range chunk {
+				album = append(album, &telebot.Photo{File: telebot.FromURL(photo.url), Caption: photo.caption})
+			}
+			_, err := b.bot.SendAlbum(&telebot.Chat{ID: chatID}, album, opts)
+			b.trackDelivery(ctx, chatID, err)
+			if err == nil {
+				continue
+			}
+			b.log.WarnContext(ctx, "Failed to send an album, sending its photos one by one",
+				"chatID", chatID, "photos", len(chunk), "err", err)
+		}
+
+		for _, photo := range chunk {
+			_, err := b.sendTo(ctx, chatID, &telebot.Photo{File: telebot.FromURL(photo.url), Caption: photo.caption},
+				opts)
+			if err != nil {
+				b.log.WarnContext(ctx, "Failed to send a product photo", "chatID", chatID, "url", photo.url,
+					"err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+func TestAddedPhotos(t *testing.T) {
+	t.Parallel()
+
+	changes := &models.Changes{Added: []models.Product{
+		{Model: "A1", Type: "Diver", Price: "100", ImageURL: "https://example.com/a1.jpg"},
+		{Model: "B2", Price: "200"},
+		{Model: "C3", ImageURL: "https://example.com/c3.jpg"},
+	}}
+
+	assert.Nil(t, (&Bot{}).addedPhotos(changes), "the photos are only sent if enabled")
+	assert.Equal(t, []productPhoto{
+		{url: "https://example.com/a1.jpg", caption: "🆕 A1 · Diver · 100"},
+		{url: "https://example.com/c3.jpg", caption: "🆕 C3"},
+	}, (&Bot{photos: true}).addedPhotos(changes))
+
+	notif, err := (&Bot{log: slog.Default(), photos: true}).buildNotification(changes, nil, nil, variantA)
+	require.NoError(t, err)
+	assert.Len(t, notif.photos, 2)
+	summary, err := (&Bot{photos: true, summaryThreshold: 1}).buildNotification(changes, nil, nil, variantA)
+	require.NoError(t, err)
+	assert.Empty(t, summary.photos, "the summaries of large change sets are sent without photos")
+}
+
+func TestSendPhotos(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(-100)
+	photos := func(n int) []productPhoto {
+		list := make([]productPhoto, 0, n)
+		for i := range n {
+			list = append(list, productPhoto{url: fmt.Sprintf("https://example.com/%d.jpg", i), caption: "🆕"})
+		}
+		return list
+	}
+	albumOf := func(size int) any {
+		return mock.MatchedBy(func(album telebot.Album) bool { return len(album) == size })
+	}
+
+	t.Run("albums of up to ten photos", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockBot.On("SendAlbum", &telebot.Chat{ID: chatID}, albumOf(maxAlbumSize), mock.Anything).
+			Return([]telebot.Message{}, nil).Twice()
+		mockBot.On("SendAlbum", &telebot.Chat{ID: chatID}, albumOf(2), mock.Anything).
+			Return([]telebot.Message{}, nil).Once()
+		testBot := Bot{bot: mockBot, log: slog.Default()}
+
+		testBot.sendPhotos(t.Context(), chatID, photos(22), 0, false)
+	})
+
+	t.Run("a single photo is sent on its own", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockBot.On("SendAlbum", &telebot.Chat{ID: chatID}, albumOf(maxAlbumSize), mock.Anything).
+			Return([]telebot.Message{}, nil).Once()
+		mockBot.On("Send", &telebot.Chat{ID: chatID}, mock.AnythingOfType("*telebot.Photo"), mock.Anything).
+			Return(&telebot.Message{}, nil).Once()
+		testBot := Bot{bot: mockBot, log: slog.Default()}
+
+		testBot.sendPhotos(t.Context(), chatID, photos(11), 0, false)
+	})
+
+	t.Run("a failed album is sent photo by photo", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockBot.On("SendAlbum", &telebot.Chat{ID: chatID}, albumOf(3), mock.Anything).
+			Return(nil, assert.AnError).Once()
+		mockBot.On("Send", &telebot.Chat{ID: chatID}, mock.AnythingOfType("*telebot.Photo"), mock.Anything).
+			Return(&telebot.Message{}, nil).Twice()
+		mockBot.On("Send", &telebot.Chat{ID: chatID}, mock.AnythingOfType("*telebot.Photo"), mock.Anything).
+			Return(nil, assert.AnError).Once()
+		testBot := Bot{bot: mockBot, log: slog.Default()}
+
+		testBot.sendPhotos(t.Context(), chatID, photos(3), 0, false)
+	})
+}
@@ -0,0 +1,215 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"gopkg.in/telebot.v4"
+)
+
+// priceUsage explains the /price command.
+const priceUsage = "Reply to a notification with /price, quote the product's line to pick it, " +
+	"or add its model: /price <model>"
+
+// Limits of the /price answer.
+const (
+	// priceMaxProducts is the number of products described at once, more have to be narrowed down.
+	priceMaxProducts = 3
+	// priceMaxChoices is the number of products listed when asking to narrow them down.
+	priceMaxChoices = 20
+	// pricePoints is the number of latest prices shown for a product.
+	pricePoints = 5
+	// priceNotes is the number of latest notes of the admins shown for a product.
+	priceNotes = 3
+)
+
+// priceHandler handles the /price command. Sent as a reply to a notification it describes the product
+// of the quoted segment, the one named by the argument or every product of the notification if there
+// are only a few. Otherwise it describes the current products with the given model.
+func (b *Bot) priceHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+	repoCtx := context.Background()
+
+	msg := ctx.Message()
+	model := strings.TrimSpace(ctx.Data())
+	if msg.ReplyTo == nil && model == "" {
+		b.sendMessage(ctx, chatID, priceUsage)
+		return nil
+	}
+
+	products, err := b.currentProducts(repoCtx)
+	if err != nil {
+		b.log.Error("Failed to get products", "chatID", chatID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to look up the price.")
+		return nil
+	}
+
+	var candidates []models.ProductRef
+	if msg.ReplyTo != nil {
+		refs, refsErr := b.repo.GetNotificationProducts(repoCtx, chatID, msg.ReplyTo.ID)
+		if refsErr != nil {
+			b.log.Error("Failed to get notification products", "chatID", chatID, "err", refsErr)
+			b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to look up the price.")
+			return nil
+		}
+		if len(refs) == 0 {
+			b.sendMessage(ctx, chatID, "🤷 This message isn't a recent notification.\n"+priceUsage)
+			return nil
+		}
+		candidates = pickProducts(refs, model, msg.Quote)
+	} else {
+		for ref := range products {
+			if strings.EqualFold(ref.Model, model) {
+				candidates = append(candidates, ref)
+			}
+		}
+		slices.SortFunc(candidates, func(a, b models.ProductRef) int { return strings.Compare(a.Category, b.Category) })
+	}
+
+	switch {
+	case len(candidates) == 0:
+		b.sendMessage(ctx, chatID, "🤷 No such product.\n"+priceUsage)
+	case len(candidates) > priceMaxProducts:
+		b.sendMessage(ctx, chatID, formatPriceChoices(candidates))
+	default:
+		b.sendPriceAnswer(ctx, chatID, candidates, products)
+	}
+
+	return nil
+}
+
+// currentProducts returns the current products by reference, none if the page wasn't parsed yet.
+func (b *Bot) currentProducts(ctx context.Context) (map[models.ProductRef]models.Product, error) {
+	state, err := b.repo.GetState(ctx)
+	if errors.Is(err, repository.ErrStateNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state: %w", err)
+	}
+
+	products := make(map[models.ProductRef]models.Product, len(state.Products))
+	for _, product := range state.Products {
+		products[models.ProductRef{Category: product.Category, Model: product.Model}] = product
+	}
+
+	return products, nil
+}
+
+// sendPriceAnswer describes the products with their recent prices as a reply to the command.
+func (b *Bot) sendPriceAnswer(
+	ctx telebot.Context,
+	chatID int64,
+	refs []models.ProductRef,
+	products map[models.ProductRef]models.Product,
+) {
+	parts := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		history, err := b.repo.GetProductHistory(context.Background(), ref.Category, ref.Model)
+		if err != nil {
+			b.log.Error("Failed to get product history", "chatID", chatID, "model", ref.Model, "err", err)
+			b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to look up the price.")
+			return
+		}
+		product, listed := products[ref]
+		parts = append(parts, formatPriceAnswer(ref, product, listed, history))
+	}
+
+	text := strings.Join(parts, "\n\n")
+	if err := ctx.Reply(text, &telebot.SendOptions{ParseMode: telebot.ModeMarkdown}); err != nil {
+		b.log.Error("Failed to send price", "chatID", chatID, "err", err)
+	}
+}
+
+// pickProducts narrows the products of a notification down to the one named by the model or, without
+// a model, to the ones whose model is in the quoted segment of the notification.
+func pickProducts(refs []models.ProductRef, model string, quote *telebot.TextQuote) []models.ProductRef {
+	var picked []models.ProductRef
+	for _, ref := range refs {
+		switch {
+		case model != "":
+			if strings.EqualFold(ref.Model, model) {
+				picked = append(picked, ref)
+			}
+		case quote != nil:
+			if strings.Contains(quote.Text, ref.Model) {
+				picked = append(picked, ref)
+			}
+		default:
+			picked = append(picked, ref)
+		}
+	}
+
+	return picked
+}
+
+// formatPriceChoices asks to narrow down the products of a notification.
+func formatPriceChoices(refs []models.ProductRef) string {
+	names := make([]string, 0, min(len(refs), priceMaxChoices))
+	for _, ref := range refs[:min(len(refs), priceMaxChoices)] {
+		names = append(names, ref.Model)
+	}
+	if len(refs) > priceMaxChoices {
+		names = append(names, fmt.Sprintf("and %d more", len(refs)-priceMaxChoices))
+	}
+
+	return fmt.Sprintf("🔎 The notification lists %d products: %s.\n%s",
+		len(refs), strings.Join(names, ", "), priceUsage)
+}
+
+// formatPriceAnswer describes the current price of a product, the latest prices it had and the latest notes
+// the admins attached to its changes.
+func formatPriceAnswer(
+	ref models.ProductRef,
+	product models.Product,
+	listed bool,
+	history []models.ChangeRecord,
+) string {
+	var builder strings.Builder
+	if listed {
+		fmt.Fprintf(&builder, "💰 `%s` (%s)\nPrice: *%s*, quantity: %s",
+			ref.Model, groupName(product.Category, product.Type), product.Price, product.Quantity)
+	} else {
+		fmt.Fprintf(&builder, "❌ `%s` is no longer listed", ref.Model)
+	}
+
+	// Consecutive records with the same price, like quantity-only changes, are a single price.
+	var points []models.ChangeRecord
+	for _, record := range history {
+		if record.Kind == models.KindRemoved || record.Price == "" {
+			continue
+		}
+		if len(points) > 0 && points[len(points)-1].Price == record.Price {
+			continue
+		}
+		points = append(points, record)
+	}
+	if len(points) > 0 {
+		builder.WriteString("\n📈 Price history:")
+		for _, point := range points[max(len(points)-pricePoints, 0):] {
+			fmt.Fprintf(&builder, "\n%s: %s", point.DetectedAt.Local().Format("02.01.2006"), point.Price)
+		}
+	}
+
+	var notes []models.ChangeRecord
+	for _, record := range history {
+		if record.Note != "" {
+			notes = append(notes, record)
+		}
+	}
+	if len(notes) > 0 {
+		builder.WriteString("\n📝 Notes:")
+		for _, record := range notes[max(len(notes)-priceNotes, 0):] {
+			// The note is quoted as code, so it can't break the Markdown of the answer.
+			fmt.Fprintf(&builder, "\n%s: `%s`",
+				record.DetectedAt.Local().Format("02.01.2006"), strings.ReplaceAll(record.Note, "`", "'"))
+		}
+	}
+
+	return builder.String()
+}
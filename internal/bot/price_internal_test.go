@@ -0,0 +1,209 @@
+package bot
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+// newReplyContext creates a handler context for a command sent from chatID as a reply to the message,
+// optionally quoting a part of it.
+func newReplyContext(chatID int64, payload string, replyTo int, quote string) (telebot.Context, *recordingAPI) {
+	api := &recordingAPI{}
+	msg := &telebot.Message{
+		Chat:    &telebot.Chat{ID: chatID},
+		Payload: payload,
+		ReplyTo: &telebot.Message{ID: replyTo},
+	}
+	if quote != "" {
+		msg.Quote = &telebot.TextQuote{Text: quote}
+	}
+
+	return telebot.NewContext(api, telebot.Update{Message: msg}), api
+}
+
+func TestPriceHandler(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(-100)
+	state := &models.State{Products: []models.Product{
+		{Model: "A1", Category: "new", Type: "Diver", Price: "120", Quantity: "3"},
+		{Model: "B2", Category: "new", Type: "Diver", Price: "200", Quantity: "1"},
+	}}
+	refs := []models.ProductRef{{Category: "new", Model: "A1"}, {Category: "new", Model: "B2"}}
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 12, 0, 0, 0, time.Local) }
+	history := []models.ChangeRecord{
+		{Kind: models.KindAdded, Model: "A1", Price: "100", DetectedAt: day(1)},
+		{Kind: models.KindChanged, Model: "A1", Price: "120", DetectedAt: day(5)},
+	}
+
+	t.Run("quoted product of the replied notification", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetState", mock.Anything).Return(state, nil).Once()
+		mockRepo.On("GetNotificationProducts", mock.Anything, chatID, 7).Return(refs, nil).Once()
+		mockRepo.On("GetProductHistory", mock.Anything, "new", "A1").Return(history, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newReplyContext(chatID, "", 7, "🔄 `A1` 100 -> 120")
+
+		require.NoError(t, testBot.priceHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Equal(t, "💰 `A1` (new · Diver)\nPrice: *120*, quantity: 3\n📈 Price history:\n"+
+			"01.03.2025: 100\n05.03.2025: 120", api.sent[0])
+	})
+
+	t.Run("product named in the reply", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetState", mock.Anything).Return(state, nil).Once()
+		mockRepo.On("GetNotificationProducts", mock.Anything, chatID, 7).Return(refs, nil).Once()
+		mockRepo.On("GetProductHistory", mock.Anything, "new", "B2").Return(nil, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newReplyContext(chatID, "b2", 7, "")
+
+		require.NoError(t, testBot.priceHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Equal(t, "💰 `B2` (new · Diver)\nPrice: *200*, quantity: 1", api.sent[0])
+	})
+
+	t.Run("removed product of a short notification", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetState", mock.Anything).Return(nil, repository.ErrStateNotFound).Once()
+		mockRepo.On("GetNotificationProducts", mock.Anything, chatID, 7).Return(refs[:1], nil).Once()
+		mockRepo.On("GetProductHistory", mock.Anything, "new", "A1").Return(history, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newReplyContext(chatID, "", 7, "")
+
+		require.NoError(t, testBot.priceHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "❌ `A1` is no longer listed\n📈 Price history:")
+	})
+
+	t.Run("large notification asks to narrow down", func(t *testing.T) {
+		t.Parallel()
+
+		many := make([]models.ProductRef, 0, 25)
+		for range 25 {
+			many = append(many, models.ProductRef{Model: "X"})
+		}
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetState", mock.Anything).Return(state, nil).Once()
+		mockRepo.On("GetNotificationProducts", mock.Anything, chatID, 7).Return(many, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newReplyContext(chatID, "", 7, "")
+
+		require.NoError(t, testBot.priceHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "lists 25 products")
+		assert.Contains(t, api.sent[0], "and 5 more")
+	})
+
+	t.Run("reply to another message", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetState", mock.Anything).Return(state, nil).Once()
+		mockRepo.On("GetNotificationProducts", mock.Anything, chatID, 7).Return(nil, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newReplyContext(chatID, "", 7, "")
+
+		require.NoError(t, testBot.priceHandler(ctx))
+		assert.Contains(t, api.sent[0], "isn't a recent notification")
+	})
+
+	t.Run("model without a reply", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetState", mock.Anything).Return(state, nil).Once()
+		mockRepo.On("GetProductHistory", mock.Anything, "new", "A1").Return(history, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, "A1")
+
+		require.NoError(t, testBot.priceHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "Price: *120*")
+	})
+
+	t.Run("usage without a reply or model", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), repo: mocks.NewRepository(t), allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.priceHandler(ctx))
+		assert.Equal(t, []interface{}{priceUsage}, api.sent)
+	})
+
+	t.Run("unauthorized chat", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), repo: mocks.NewRepository(t), allowedChats: map[int64]bool{}}
+		ctx, api := newTestContext(chatID, "A1")
+
+		require.NoError(t, testBot.route("/price", accessAllowed, testBot.priceHandler)(ctx))
+		assert.Contains(t, api.sent[0], "this bot is private")
+	})
+
+	t.Run("error: cannot get products", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetState", mock.Anything).Return(nil, assert.AnError).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, "A1")
+
+		require.NoError(t, testBot.priceHandler(ctx))
+		assert.Contains(t, api.sent[0], "internal error")
+	})
+}
+
+func TestFormatPriceAnswer(t *testing.T) {
+	t.Parallel()
+
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 12, 0, 0, 0, time.Local) }
+	history := []models.ChangeRecord{
+		{Kind: models.KindAdded, Price: "100", DetectedAt: day(1)},
+		{Kind: models.KindChanged, Price: "100", DetectedAt: day(2)},
+		{Kind: models.KindChanged, Price: "110", DetectedAt: day(3)},
+		{Kind: models.KindChanged, Price: "120", DetectedAt: day(4)},
+		{Kind: models.KindChanged, Price: "130", DetectedAt: day(5)},
+		{Kind: models.KindChanged, Price: "140", DetectedAt: day(6)},
+		{Kind: models.KindChanged, Price: "150", DetectedAt: day(7)},
+		{Kind: models.KindRemoved, DetectedAt: day(8)},
+	}
+
+	text := formatPriceAnswer(models.ProductRef{Model: "A1"}, models.Product{}, false, history)
+	assert.Equal(t, "❌ `A1` is no longer listed\n📈 Price history:\n"+
+		"03.03.2025: 110\n04.03.2025: 120\n05.03.2025: 130\n06.03.2025: 140\n07.03.2025: 150", text,
+		"only the latest price changes are shown")
+
+	history[4].Note = "supplier confirmed `pricing` error"
+	text = formatPriceAnswer(models.ProductRef{Model: "A1"}, models.Product{}, false, history)
+	assert.True(t, strings.HasSuffix(text, "\n📝 Notes:\n05.03.2025: `supplier confirmed 'pricing' error`"), text)
+}
+
+func TestPickProducts(t *testing.T) {
+	t.Parallel()
+
+	refs := []models.ProductRef{{Model: "A1"}, {Model: "B2"}, {Model: "C3"}}
+
+	assert.Equal(t, refs, pickProducts(refs, "", nil))
+	assert.Equal(t, refs[1:2], pickProducts(refs, "b2", &telebot.TextQuote{Text: "A1"}),
+		"the model wins over the quote")
+	assert.Equal(t, []models.ProductRef{refs[0], refs[2]}, pickProducts(refs, "", &telebot.TextQuote{Text: "A1 C3"}))
+	assert.Empty(t, pickProducts(refs, "D4", nil))
+}
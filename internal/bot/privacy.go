@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+// forgetHandler handles the /forgetme command: everything stored about the chat is deleted, and the reply
+// lists what was. The chat is no longer notified until it subscribes again.
+func (b *Bot) forgetHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+
+	forgotten, err := b.repo.ForgetChat(context.Background(), chatID)
+	if err != nil {
+		b.log.Error("Failed to forget chat", "chatID", chatID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to delete the data of this chat.")
+		return nil
+	}
+
+	b.log.Info("Chat data deleted on request", "chatID", chatID, "records", countForgotten(forgotten))
+	b.sendMessage(ctx, chatID, formatForgotten(forgotten))
+
+	return nil
+}
+
+// formatForgotten describes the data deleted about a chat.
+func formatForgotten(forgotten []models.ForgottenData) string {
+	if countForgotten(forgotten) == 0 {
+		return "🗑 Nothing was stored about this chat."
+	}
+
+	var text strings.Builder
+	text.WriteString("🗑 Everything stored about this chat was deleted:")
+	for _, data := range forgotten {
+		if data.Deleted > 0 {
+			fmt.Fprintf(&text, "\n• %s: %d", data.Kind, data.Deleted)
+		}
+	}
+	text.WriteString("\nTo get notified again, type /start or /subscribe.")
+
+	return text.String()
+}
+
+// countForgotten returns the number of records deleted.
+func countForgotten(forgotten []models.ForgottenData) int {
+	total := 0
+	for _, data := range forgotten {
+		total += data.Deleted
+	}
+
+	return total
+}
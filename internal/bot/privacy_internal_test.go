@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForgetHandler(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(-100)
+
+	t.Run("deleted data is listed", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("ForgetChat", mock.Anything, chatID).Return([]models.ForgottenData{
+			{Kind: "subscription", Deleted: 1},
+			{Kind: "settings and filters", Deleted: 0},
+			{Kind: "wishlist items", Deleted: 3},
+		}, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.forgetHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "was deleted")
+		assert.Contains(t, api.sent[0], "• subscription: 1\n• wishlist items: 3")
+		assert.NotContains(t, api.sent[0], "settings")
+	})
+
+	t.Run("nothing stored", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("ForgetChat", mock.Anything, chatID).
+			Return([]models.ForgottenData{{Kind: "subscription", Deleted: 0}}, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.forgetHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "Nothing was stored")
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("ForgetChat", mock.Anything, chatID).Return(nil, assert.AnError).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.forgetHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "internal error")
+	})
+}
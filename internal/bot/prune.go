@@ -0,0 +1,167 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+// unreachableError reports whether the error of a message sent to a chat won't go away by itself, e.g. the
+// bot was blocked, kicked or the chat was deleted, and whether the account of the chat is deactivated.
+func unreachableError(err error) (bool, bool) {
+	if errors.Is(err, telebot.ErrUserIsDeactivated) {
+		return true, true
+	}
+	if errors.Is(err, telebot.ErrChatNotFound) {
+		return true, false
+	}
+
+	var apiErr *telebot.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusForbidden {
+		return true, false
+	}
+
+	return false, false
+}
+
+// loadUnreachableChats loads the chats recorded as unreachable, a message reaching one of them again
+// clears its failures.
+func (b *Bot) loadUnreachableChats(ctx context.Context) {
+	chats, err := b.repo.GetUnreachableChats(ctx)
+	if err != nil {
+		b.log.WarnContext(ctx, "Failed to load the unreachable chats", "err", err)
+		return
+	}
+
+	b.reachMu.Lock()
+	defer b.reachMu.Unlock()
+	for _, chat := range chats {
+		b.markUnreachable(chat.ChatID)
+	}
+}
+
+// markUnreachable remembers the chat as unreachable, reachMu has to be held.
+func (b *Bot) markUnreachable(chatID int64) {
+	if b.unreachable == nil {
+		b.unreachable = make(map[int64]bool)
+	}
+	b.unreachable[chatID] = true
+}
+
+// trackDelivery records the result of a message sent to the chat: a persistent failure is recorded for
+// the pruning of the subscribers, see PruneSubscribers, and a success clears the failures of the chat.
+// Transient failures, e.g. a throttling or a network error, say nothing about the chat.
+func (b *Bot) trackDelivery(ctx context.Context, chatID int64, sendErr error) {
+	if sendErr == nil {
+		b.reachable(ctx, chatID)
+		return
+	}
+
+	unreachable, deactivated := unreachableError(sendErr)
+	if !unreachable {
+		return
+	}
+
+	err := b.repo.RecordSendFailure(ctx, chatID, time.Now(), sendErr.Error(), deactivated)
+	if err != nil {
+		b.log.WarnContext(ctx, "Failed to record the send failure", "chatID", chatID, "err", err)
+		return
+	}
+
+	b.reachMu.Lock()
+	b.markUnreachable(chatID)
+	b.reachMu.Unlock()
+}
+
+// reachable clears the failures of the chat if it was unreachable, a message reached it or it used the
+// bot.
+func (b *Bot) reachable(ctx context.Context, chatID int64) {
+	b.reachMu.Lock()
+	known := b.unreachable[chatID]
+	b.reachMu.Unlock()
+	if !known {
+		return
+	}
+
+	if err := b.repo.ClearSendFailure(ctx, chatID); err != nil {
+		b.log.WarnContext(ctx, "Failed to clear the send failures", "chatID", chatID, "err", err)
+		return
+	}
+
+	b.reachMu.Lock()
+	delete(b.unreachable, chatID)
+	b.reachMu.Unlock()
+	b.log.InfoContext(ctx, "Chat is reachable again", "chatID", chatID)
+}
+
+// PruneSubscribers unsubscribes the chats the messages of the bot failed to reach for longer than after,
+// and the ones of deactivated accounts, so the broadcasts don't waste time on them. The admin chats get
+// the list of the chats pruned. The failures of the chats that aren't subscribed are forgotten once they
+// are as old. It returns the chats pruned.
+func (b *Bot) PruneSubscribers(ctx context.Context, after time.Duration) ([]models.UnreachableChat, error) {
+	chats, err := b.repo.GetUnreachableChats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unreachable chats: %w", err)
+	}
+	subscribers, err := b.repo.GetSubscribedChats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscribers: %w", err)
+	}
+
+	deadline := time.Now().Add(-after)
+	var pruned []models.UnreachableChat
+	for _, chat := range chats {
+		if !chat.Deactivated && chat.FailingSince.After(deadline) {
+			continue
+		}
+
+		if slices.Contains(subscribers, chat.ChatID) {
+			if err = b.repo.UnsubscribeChat(ctx, chat.ChatID); err != nil {
+				return pruned, fmt.Errorf("failed to unsubscribe chat %d: %w", chat.ChatID, err)
+			}
+			pruned = append(pruned, chat)
+		}
+		if err = b.repo.ClearSendFailure(ctx, chat.ChatID); err != nil {
+			return pruned, fmt.Errorf("failed to clear the send failures of chat %d: %w", chat.ChatID, err)
+		}
+
+		b.reachMu.Lock()
+		delete(b.unreachable, chat.ChatID)
+		b.reachMu.Unlock()
+	}
+
+	if len(pruned) == 0 {
+		return nil, nil
+	}
+
+	b.log.InfoContext(ctx, "Pruned unreachable subscribers", "count", len(pruned))
+	report := formatPrunedChats(pruned)
+	for chatID := range b.adminChats {
+		b.sendAdminMessage(ctx, chatID, report)
+	}
+
+	return pruned, nil
+}
+
+// formatPrunedChats describes the pruned subscribers for the admins.
+func formatPrunedChats(chats []models.UnreachableChat) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "🧹 Unsubscribed %d unreachable chats:", len(chats))
+	for _, chat := range chats {
+		if chat.Deactivated {
+			fmt.Fprintf(&builder, "\n• %d: the account is deactivated", chat.ChatID)
+			continue
+		}
+		fmt.Fprintf(&builder, "\n• %d: failing since %s (%s)",
+			chat.ChatID, chat.FailingSince.Local().Format(time.DateOnly), chat.Reason)
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,160 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+func TestUnreachableError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err         error
+		unreachable bool
+		deactivated bool
+	}{
+		{err: telebot.ErrBlockedByUser, unreachable: true},
+		{err: fmt.Errorf("telegram: %w", telebot.ErrKickedFromSuperGroup), unreachable: true},
+		{err: telebot.NewError(403, "Forbidden: bot was kicked from the group"), unreachable: true},
+		{err: telebot.ErrChatNotFound, unreachable: true},
+		{err: telebot.ErrUserIsDeactivated, unreachable: true, deactivated: true},
+		{err: telebot.FloodError{RetryAfter: 5}},
+		{err: telebot.ErrTooLongMessage},
+		{err: errors.New("connection reset")},
+		{err: nil},
+	}
+	for _, test := range tests {
+		unreachable, deactivated := unreachableError(test.err)
+		assert.Equal(t, test.unreachable, unreachable, test.err)
+		assert.Equal(t, test.deactivated, deactivated, test.err)
+	}
+}
+
+func TestTrackDelivery(t *testing.T) {
+	t.Parallel()
+
+	t.Run("a chat reached again is cleared", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("ClearSendFailure", mock.Anything, int64(1)).Return(nil).Once()
+		testBot := &Bot{log: slog.Default(), repo: mockRepo, unreachable: map[int64]bool{1: true}}
+
+		testBot.trackDelivery(t.Context(), 1, nil)
+		testBot.trackDelivery(t.Context(), 1, nil)
+		testBot.trackDelivery(t.Context(), 2, nil)
+
+		assert.Empty(t, testBot.unreachable)
+	})
+
+	t.Run("transient failures aren't recorded", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := &Bot{log: slog.Default(), repo: mocks.NewRepository(t)}
+
+		testBot.trackDelivery(t.Context(), 1, telebot.FloodError{RetryAfter: 5})
+
+		assert.Empty(t, testBot.unreachable)
+	})
+
+	t.Run("error: record failure", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("RecordSendFailure", mock.Anything, int64(1), mock.Anything, mock.Anything, true).
+			Return(assert.AnError).Once()
+		testBot := &Bot{log: slog.Default(), repo: mockRepo}
+
+		testBot.trackDelivery(t.Context(), 1, telebot.ErrUserIsDeactivated)
+
+		assert.Empty(t, testBot.unreachable, "a chat isn't cleared unless it was recorded")
+	})
+}
+
+func TestPruneSubscribers(t *testing.T) {
+	t.Parallel()
+
+	const adminID = int64(42)
+	now := time.Now()
+
+	t.Run("long unreachable and deactivated subscribers are pruned", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetUnreachableChats", mock.Anything).Return([]models.UnreachableChat{
+			{ChatID: 1, FailingSince: now.Add(-40 * 24 * time.Hour), Reason: "Forbidden: bot was blocked by the user"},
+			{ChatID: 2, FailingSince: now.Add(-40 * 24 * time.Hour), Reason: "Bad Request: chat not found"},
+			{ChatID: 3, FailingSince: now.Add(-time.Hour), Reason: "Forbidden: user is deactivated", Deactivated: true},
+			{ChatID: 4, FailingSince: now.Add(-time.Hour), Reason: "Forbidden: bot was blocked by the user"},
+		}, nil).Once()
+		mockRepo.On("GetSubscribedChats", mock.Anything).Return([]int64{1, 3, 4}, nil).Once()
+		mockRepo.On("UnsubscribeChat", mock.Anything, int64(1)).Return(nil).Once()
+		mockRepo.On("UnsubscribeChat", mock.Anything, int64(3)).Return(nil).Once()
+		for _, chatID := range []int64{1, 2, 3} {
+			mockRepo.On("ClearSendFailure", mock.Anything, chatID).Return(nil).Once()
+		}
+		mockBot := mocks.NewAPI(t)
+		mockBot.On("Send", &telebot.Chat{ID: adminID}, mock.MatchedBy(func(text string) bool {
+			return strings.HasPrefix(text, "🧹 Unsubscribed 2 unreachable chats:") &&
+				strings.Contains(text, "• 1: failing since") &&
+				strings.Contains(text, "(Forbidden: bot was blocked by the user)") &&
+				strings.Contains(text, "• 3: the account is deactivated")
+		})).Return(&telebot.Message{}, nil).Once()
+		testBot := &Bot{
+			bot:         mockBot,
+			log:         slog.Default(),
+			repo:        mockRepo,
+			adminChats:  map[int64]bool{adminID: true},
+			unreachable: map[int64]bool{1: true, 2: true, 3: true, 4: true},
+		}
+
+		pruned, err := testBot.PruneSubscribers(t.Context(), 30*24*time.Hour)
+
+		require.NoError(t, err)
+		require.Len(t, pruned, 2)
+		assert.Equal(t, int64(1), pruned[0].ChatID)
+		assert.Equal(t, int64(3), pruned[1].ChatID)
+		assert.Equal(t, map[int64]bool{4: true}, testBot.unreachable)
+	})
+
+	t.Run("nothing to prune", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetUnreachableChats", mock.Anything).Return(nil, nil).Once()
+		mockRepo.On("GetSubscribedChats", mock.Anything).Return([]int64{1}, nil).Once()
+		testBot := &Bot{bot: mocks.NewAPI(t), log: slog.Default(), repo: mockRepo}
+
+		pruned, err := testBot.PruneSubscribers(t.Context(), time.Hour)
+
+		require.NoError(t, err)
+		assert.Empty(t, pruned)
+	})
+
+	t.Run("error: unsubscribe", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetUnreachableChats", mock.Anything).Return([]models.UnreachableChat{
+			{ChatID: 1, Deactivated: true},
+		}, nil).Once()
+		mockRepo.On("GetSubscribedChats", mock.Anything).Return([]int64{1}, nil).Once()
+		mockRepo.On("UnsubscribeChat", mock.Anything, int64(1)).Return(assert.AnError).Once()
+		testBot := &Bot{log: slog.Default(), repo: mockRepo}
+
+		_, err := testBot.PruneSubscribers(t.Context(), time.Hour)
+
+		require.ErrorIs(t, err, assert.AnError)
+	})
+}
@@ -0,0 +1,151 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// Scores of the significance of a change, see changeScore.
+const (
+	// stockScore is the score of a product coming into or going out of stock, e.g. added or removed.
+	stockScore = 50
+	// maxPriceScore caps the score of a price change, which is the change in percent.
+	maxPriceScore = 100
+	// watcherScore is the score of every chat with the product on its wishlist.
+	watcherScore = 10
+)
+
+// WithRanking orders the lines of the notifications by the significance of their changes, see
+// changeScore, and lists at most maxLines of them. The minor rest is counted and the changes are
+// attached as CSV, so the important changes are visible first however many there are.
+func WithRanking(maxLines int) Option {
+	return func(b *Bot) {
+		b.maxLines = maxLines
+	}
+}
+
+// rankedLine is a change of a notification with the significance of the change.
+type rankedLine struct {
+	kind string
+	// data is the models.Product of an added or removed product, the models.ChangeInfo otherwise.
+	data    any
+	product models.Product
+	score   float64
+}
+
+// watchCounts returns the number of chats watching every model, by the lower-case model, if the
+// notifications are ranked. Without them the changes are ranked without their popularity.
+func (b *Bot) watchCounts(ctx context.Context) map[string]int {
+	if b.maxLines <= 0 {
+		return nil
+	}
+
+	counts, err := b.repo.GetWatchCounts(ctx)
+	if err != nil {
+		b.log.WarnContext(ctx, "Failed to get watch counts", "err", err)
+		return nil
+	}
+
+	return counts
+}
+
+// rankChanges returns the changes ordered by their score, most significant first. Changes with the same
+// score keep the order of the kinds: added, changed, renamed, removed.
+func rankChanges(changes *models.Changes, watchers map[string]int) []rankedLine {
+	lines := make([]rankedLine, 0, changes.Count())
+	for _, p := range changes.Added {
+		lines = append(lines, rankedLine{export.KindAdded, p, p, productScore(p, watchers)})
+	}
+	for _, change := range changes.Changed {
+		lines = append(lines, rankedLine{export.KindChanged, change, change.New, changeScore(change, watchers)})
+	}
+	for _, change := range changes.Renamed {
+		lines = append(lines, rankedLine{export.KindRenamed, change, change.New, changeScore(change, watchers)})
+	}
+	for _, p := range changes.Removed {
+		lines = append(lines, rankedLine{export.KindRemoved, p, p, productScore(p, watchers)})
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].score > lines[j].score })
+
+	return lines
+}
+
+// productScore returns the score of an added or removed product: the stock it brings or takes, and the
+// chats watching it.
+func productScore(p models.Product, watchers map[string]int) float64 {
+	return stockScore + watcherScore*float64(watchers[strings.ToLower(p.Model)])
+}
+
+// changeScore returns the score of an updated product: the magnitude of its price change in percent,
+// capped at maxPriceScore, stockScore if it came into or went out of stock, and watcherScore for every
+// chat watching it.
+func changeScore(change models.ChangeInfo, watchers map[string]int) float64 {
+	const percent = 100
+
+	score := watcherScore * float64(watchers[strings.ToLower(change.New.Model)])
+	if change.Old.InStock() != change.New.InStock() {
+		score += stockScore
+	}
+
+	oldPrice, oldErr := change.Old.PriceValue()
+	newPrice, newErr := change.New.PriceValue()
+	if oldErr == nil && newErr == nil && oldPrice != 0 {
+		score += math.Min(math.Abs(newPrice-oldPrice)/oldPrice*percent, maxPriceScore)
+	}
+
+	return score
+}
+
+// formatRankedMessage builds the notification string from the changes ranked by significance with the
+// templates of the variant. It lists at most maxLines changes, and fewer if they don't fit in a message,
+// the number of the minor changes left out is returned.
+func (b *Bot) formatRankedMessage(
+	changes *models.Changes,
+	trends map[models.ProductRef]models.PriceTrend,
+	watchers map[string]int,
+	variant string,
+) (string, int) {
+	// tailReserve leaves space for the line counting the minor changes.
+	const tailReserve = 100
+	templates := b.templatesOf(variant)
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("📅 *Product updates (%s)*\n", time.Now().Format("02.01.2006")))
+	builder.WriteString(formatSummaryLine(changes))
+	builder.WriteString("\n\n")
+
+	lines := rankChanges(changes, watchers)
+	shown := 0
+	for _, line := range lines {
+		if shown == b.maxLines {
+			break
+		}
+		text := b.renderLine(templates, line.kind, line.data, line.product.Model)
+		if line.kind == export.KindChanged {
+			ref := models.ProductRef{Category: line.product.Category, Model: line.product.Model}
+			if trend, found := trends[ref]; found {
+				text += formatTrend(trend)
+			}
+		}
+		if builder.Len()+len(text) > maxMessageLength-tailReserve {
+			break
+		}
+		builder.WriteString(text)
+		shown++
+	}
+
+	minor := len(lines) - shown
+	if minor > 0 {
+		fmt.Fprintf(&builder, "\n…and %d minor changes (see attachment)", minor)
+	}
+
+	return builder.String(), minor
+}
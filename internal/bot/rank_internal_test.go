@@ -0,0 +1,152 @@
+package bot
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeScore(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		change   models.ChangeInfo
+		watchers map[string]int
+		want     float64
+	}{
+		{
+			name: "price delta in percent",
+			change: models.ChangeInfo{
+				Old: models.Product{Model: "A1", Price: "200", Quantity: "1"},
+				New: models.Product{Model: "A1", Price: "150", Quantity: "1"},
+			},
+			want: 25,
+		},
+		{
+			name: "price delta is capped",
+			change: models.ChangeInfo{
+				Old: models.Product{Model: "A1", Price: "100"},
+				New: models.Product{Model: "A1", Price: "500"},
+			},
+			want: maxPriceScore,
+		},
+		{
+			name: "stock transition",
+			change: models.ChangeInfo{
+				Old: models.Product{Model: "A1", Price: "on request", Quantity: "0"},
+				New: models.Product{Model: "A1", Price: "on request", Quantity: ">10"},
+			},
+			want: stockScore,
+		},
+		{
+			name: "watched product",
+			change: models.ChangeInfo{
+				Old: models.Product{Model: "A1", Price: "100", Quantity: "2"},
+				New: models.Product{Model: "A1", Price: "101", Quantity: "1"},
+			},
+			watchers: map[string]int{"a1": 3},
+			want:     31,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.InDelta(t, tt.want, changeScore(tt.change, tt.watchers), 0.001)
+		})
+	}
+}
+
+func TestRankChanges(t *testing.T) {
+	t.Parallel()
+
+	changes := &models.Changes{
+		Added: []models.Product{{Model: "N1", Price: "100"}},
+		Changed: []models.ChangeInfo{
+			{Old: models.Product{Model: "C1", Price: "100"}, New: models.Product{Model: "C1", Price: "99"}},
+			{Old: models.Product{Model: "C2", Price: "100"}, New: models.Product{Model: "C2", Price: "40"}},
+		},
+		Removed: []models.Product{{Model: "R1", Price: "100"}},
+	}
+
+	lines := rankChanges(changes, map[string]int{"r1": 1})
+
+	order := make([]string, 0, len(lines))
+	for _, line := range lines {
+		order = append(order, line.product.Model)
+	}
+	assert.Equal(t, []string{"C2", "R1", "N1", "C1"}, order)
+}
+
+func TestBuildNotification_Ranked(t *testing.T) {
+	t.Parallel()
+
+	changes := &models.Changes{Changed: []models.ChangeInfo{
+		{Old: models.Product{Model: "C1", Price: "100"}, New: models.Product{Model: "C1", Price: "99"}},
+		{Old: models.Product{Model: "C2", Price: "100"}, New: models.Product{Model: "C2", Price: "40"}},
+		{Old: models.Product{Model: "C3", Price: "100"}, New: models.Product{Model: "C3", Price: "98"}},
+	}}
+
+	t.Run("minor changes are attached", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), maxLines: 1}
+
+		notif, err := testBot.buildNotification(changes, nil, nil, variantA)
+
+		require.NoError(t, err)
+		assert.Contains(t, notif.text, "`C2`")
+		assert.NotContains(t, notif.text, "`C1`")
+		assert.Contains(t, notif.text, "…and 2 minor changes (see attachment)")
+		assert.NotEmpty(t, notif.attachment)
+		assert.Len(t, notif.products, 3, "a reply with /price may refer to any change")
+	})
+
+	t.Run("every change fits", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), maxLines: 5}
+
+		notif, err := testBot.buildNotification(changes, nil, nil, variantA)
+
+		require.NoError(t, err)
+		assert.Less(t, strings.Index(notif.text, "`C3`"), strings.Index(notif.text, "`C1`"))
+		assert.NotContains(t, notif.text, "minor changes")
+		assert.Nil(t, notif.attachment)
+	})
+
+	t.Run("lines are cut to fit the message", func(t *testing.T) {
+		t.Parallel()
+
+		many := &models.Changes{}
+		for range 200 {
+			many.Added = append(many.Added, models.Product{Model: strings.Repeat("M", 30), Price: "100"})
+		}
+		testBot := Bot{log: slog.Default(), maxLines: 200}
+
+		notif, err := testBot.buildNotification(many, nil, nil, variantA)
+
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(notif.text), maxMessageLength)
+		assert.Contains(t, notif.text, "minor changes (see attachment)")
+	})
+}
+
+func TestWatchCounts(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, (&Bot{}).watchCounts(t.Context()), "the watch counts are only read for ranking")
+
+	mockRepo := mocks.NewRepository(t)
+	mockRepo.On("GetWatchCounts", mock.Anything).Return(nil, assert.AnError).Once()
+	testBot := Bot{log: slog.Default(), repo: mockRepo, maxLines: 10}
+
+	assert.Nil(t, testBot.watchCounts(t.Context()), "the changes are ranked without their popularity")
+}
@@ -0,0 +1,226 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/rules"
+	"gopkg.in/telebot.v4"
+)
+
+// ruleUsage explains the /rule command.
+func ruleUsage() string {
+	return "Usage: /rule [add <name> <condition> | remove <name>]\n" +
+		"You are alerted of the changes matching a condition, e.g.\n" +
+		`/rule add cheap-gpu new.price < 0.8*avg(history, 30d) && product.type == "GPU"` + "\n" +
+		"Conditions read " + rules.Variables()
+}
+
+// WithAlertRules sets the alert rules of the deployment, they alert every subscribed chat.
+func WithAlertRules(alertRules []rules.Rule) Option {
+	return func(b *Bot) {
+		b.alertRules = alertRules
+	}
+}
+
+// ruleHandler handles the /rule command: it lists the alert rules of the chat and of the deployment, and
+// adds and removes the rules of the chat.
+func (b *Bot) ruleHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+	repoCtx := context.Background()
+
+	action, arg, _ := strings.Cut(strings.TrimSpace(ctx.Data()), " ")
+	arg = strings.TrimSpace(arg)
+
+	var err error
+	switch {
+	case action == "":
+	case strings.EqualFold(action, "add") && arg != "":
+		name, condition, _ := strings.Cut(arg, " ")
+		rule := models.AlertRule{ChatID: chatID, Name: name, Expression: strings.TrimSpace(condition)}
+		if _, compileErr := rules.Compile(rule); compileErr != nil {
+			b.sendMessage(ctx, chatID, fmt.Sprintf("⚠️ %v\n%s", compileErr, ruleUsage()))
+			return nil
+		}
+		err = b.repo.SetAlertRule(repoCtx, rule)
+	case strings.EqualFold(action, "remove") && arg != "":
+		var removed bool
+		if removed, err = b.repo.DeleteAlertRule(repoCtx, chatID, arg); err == nil && !removed {
+			b.sendMessage(ctx, chatID, fmt.Sprintf("🤷 There's no alert rule %s.", arg))
+			return nil
+		}
+	default:
+		b.sendMessage(ctx, chatID, ruleUsage())
+		return nil
+	}
+	if err != nil {
+		b.log.Error("Failed to update alert rules", "chatID", chatID, "action", action, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to update the alert rules.")
+		return nil
+	}
+
+	stored, err := b.repo.GetAlertRules(repoCtx)
+	if err != nil {
+		b.log.Error("Failed to get alert rules", "chatID", chatID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to load the alert rules.")
+		return nil
+	}
+	chatRules := slices.DeleteFunc(stored, func(rule models.AlertRule) bool { return rule.ChatID != chatID })
+
+	if err = ctx.Send(formatRules(chatRules, b.alertRules), telebot.ModeMarkdown); err != nil {
+		b.log.Error("Failed to send alert rules", "chatID", chatID, "err", err)
+	}
+
+	return nil
+}
+
+// formatRules lists the alert rules of the chat and of the deployment.
+func formatRules(chatRules []models.AlertRule, deployment []rules.Rule) string {
+	var builder strings.Builder
+	if len(chatRules) == 0 {
+		builder.WriteString("📏 No alert rules yet. Add one with /rule add <name> <condition>.")
+	} else {
+		builder.WriteString("📏 *Alert rules*")
+		for _, rule := range chatRules {
+			fmt.Fprintf(&builder, "\n`%s`: `%s`", rule.Name, rule.Expression)
+		}
+	}
+	if len(deployment) > 0 {
+		builder.WriteString("\n\n*Rules of every subscribed chat*")
+		for _, rule := range deployment {
+			fmt.Fprintf(&builder, "\n`%s`: `%s`", rule.Name, rule.Condition)
+		}
+	}
+
+	return builder.String()
+}
+
+// ruleMatch is an alert rule with the changes matching it.
+type ruleMatch struct {
+	name    string
+	changes []rules.Change
+}
+
+// sendRuleAlerts alerts the chats of the changes matching their alert rules, and the subscribed chats of
+// the ones matching the rules of the deployment, filtered by their filter groups. A chat gets a message of
+// its own with all the rules it matched, sent to its topic. A stored rule that doesn't compile anymore is
+// skipped.
+func (b *Bot) sendRuleAlerts(
+	ctx context.Context,
+	br *broadcast,
+	changes *models.Changes,
+	histories map[models.ProductRef][]models.ChangeRecord,
+	subscribers []int64,
+	chatSettings map[int64]models.ChatSettings,
+) {
+	stored, err := b.repo.GetAlertRules(ctx)
+	if err != nil {
+		b.log.WarnContext(ctx, "Failed to get alert rules", "err", err)
+	}
+	alertRules := slices.Clone(b.alertRules)
+	for _, rule := range stored {
+		if !b.isAllowed(rule.ChatID) {
+			continue
+		}
+		compiled, compileErr := rules.Compile(rule)
+		if compileErr != nil {
+			b.log.WarnContext(ctx, "Skipping invalid alert rule", "chatID", rule.ChatID, "rule", rule.Name,
+				"err", compileErr)
+			continue
+		}
+		alertRules = append(alertRules, compiled)
+	}
+	if len(alertRules) == 0 {
+		return
+	}
+
+	history := func(p models.Product) []models.ChangeRecord {
+		ref := models.ProductRef{Category: p.Category, Model: p.Model}
+		if records, ok := histories[ref]; ok {
+			return records
+		}
+		records, historyErr := b.repo.GetProductHistory(ctx, ref.Category, ref.Model)
+		if historyErr != nil {
+			b.log.WarnContext(ctx, "Failed to get product history", "model", ref.Model, "err", historyErr)
+		}
+		histories[ref] = records
+		return records
+	}
+	flat := rules.Changes(changes, history)
+	now := time.Now()
+
+	matches := make(map[int64][]ruleMatch)
+	for _, rule := range alertRules {
+		var matched []rules.Change
+		for _, change := range flat {
+			if rule.Match(change, now) {
+				matched = append(matched, change)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		if rule.ChatID != 0 {
+			matches[rule.ChatID] = append(matches[rule.ChatID], ruleMatch{name: rule.Name, changes: matched})
+			continue
+		}
+		for _, chatID := range subscribers {
+			types := b.filterGroups[chatSettings[chatID].FilterGroup]
+			filtered := slices.DeleteFunc(slices.Clone(matched), func(change rules.Change) bool {
+				return len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool {
+					return strings.EqualFold(t, change.Product().Type)
+				})
+			})
+			if len(filtered) > 0 {
+				matches[chatID] = append(matches[chatID], ruleMatch{name: rule.Name, changes: filtered})
+			}
+		}
+	}
+
+	for _, chatID := range slices.Sorted(maps.Keys(matches)) {
+		text := formatRuleMatches(matches[chatID])
+		opts := &telebot.SendOptions{ParseMode: telebot.ModeMarkdown, ThreadID: chatSettings[chatID].ThreadID}
+		br.send(ctx, chatID, func(ctx context.Context) {
+			if _, sendErr := b.sendTo(ctx, chatID, text, opts); sendErr != nil {
+				b.log.ErrorContext(ctx, "Failed to send alert rule matches", "chatID", chatID, "err", sendErr)
+			}
+		})
+	}
+}
+
+// formatRuleMatches describes the changes matching every alert rule.
+func formatRuleMatches(matches []ruleMatch) string {
+	var builder strings.Builder
+	builder.WriteString("🔔 *Alert rules matched*")
+	for _, match := range matches {
+		fmt.Fprintf(&builder, "\n\n*%s*", match.name)
+		for _, change := range match.changes {
+			builder.WriteString("\n• " + formatRuleChange(change))
+		}
+	}
+
+	return builder.String()
+}
+
+// formatRuleChange describes a change matching an alert rule on a line.
+func formatRuleChange(change rules.Change) string {
+	switch change.Kind {
+	case models.KindAdded:
+		return fmt.Sprintf("`%s`: added at %s", change.New.Model, change.New.Price)
+	case models.KindRemoved:
+		return fmt.Sprintf("`%s`: removed", change.Old.Model)
+	case models.KindRenamed:
+		return fmt.Sprintf("`%s` → `%s`", change.Old.Model, change.New.Model)
+	}
+	if change.Old.Price == change.New.Price {
+		return fmt.Sprintf("`%s`: quantity %s → %s", change.New.Model, change.Old.Quantity, change.New.Quantity)
+	}
+
+	return fmt.Sprintf("`%s`: %s → %s", change.New.Model, change.Old.Price, change.New.Price)
+}
@@ -0,0 +1,134 @@
+package bot
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/rules"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+// mustCompileRule compiles the alert rule of the deployment.
+func mustCompileRule(t *testing.T, name, expression string) rules.Rule {
+	t.Helper()
+
+	rule, err := rules.Compile(models.AlertRule{Name: name, Expression: expression})
+	require.NoError(t, err)
+
+	return rule
+}
+
+func TestRuleHandler(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(-100)
+	newRuleBot := func(t *testing.T) (*Bot, *mocks.Repository) {
+		t.Helper()
+
+		mockRepo := mocks.NewRepository(t)
+		return &Bot{
+			log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true},
+			alertRules: []rules.Rule{mustCompileRule(t, "gpu", `product.type == "GPU"`)},
+		}, mockRepo
+	}
+
+	t.Run("rule is added", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, mockRepo := newRuleBot(t)
+		rule := models.AlertRule{ChatID: chatID, Name: "cheap", Expression: "new.price < 0.8*avg(history, 30d)"}
+		mockRepo.On("SetAlertRule", mock.Anything, rule).Return(nil).Once()
+		mockRepo.On("GetAlertRules", mock.Anything).Return([]models.AlertRule{
+			rule, {ChatID: -200, Name: "other", Expression: "true"},
+		}, nil).Once()
+		ctx, api := newTestContext(chatID, "add cheap  new.price < 0.8*avg(history, 30d)")
+
+		require.NoError(t, testBot.ruleHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Equal(t, "📏 *Alert rules*\n`cheap`: `new.price < 0.8*avg(history, 30d)`\n\n"+
+			"*Rules of every subscribed chat*\n`gpu`: `product.type == \"GPU\"`", api.sent[0])
+	})
+
+	t.Run("invalid rule", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, _ := newRuleBot(t)
+		ctx, api := newTestContext(chatID, "add cheap new.price <")
+
+		require.NoError(t, testBot.ruleHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "⚠️ invalid alert rule cheap")
+		assert.Contains(t, api.sent[0], "Usage: /rule")
+	})
+
+	t.Run("unknown rule", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, mockRepo := newRuleBot(t)
+		mockRepo.On("DeleteAlertRule", mock.Anything, chatID, "cheap").Return(false, nil).Once()
+		ctx, api := newTestContext(chatID, "remove cheap")
+
+		require.NoError(t, testBot.ruleHandler(ctx))
+		assert.Equal(t, []interface{}{"🤷 There's no alert rule cheap."}, api.sent)
+	})
+
+	t.Run("error: rules cannot be loaded", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, mockRepo := newRuleBot(t)
+		mockRepo.On("GetAlertRules", mock.Anything).Return(nil, assert.AnError).Once()
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.ruleHandler(ctx))
+		assert.Contains(t, api.sent[0], "internal error")
+	})
+}
+
+func TestSendRuleAlerts(t *testing.T) {
+	t.Parallel()
+
+	changes := &models.Changes{
+		Added: []models.Product{{Model: "N1", Type: "CPU", Price: "90"}},
+		Changed: []models.ChangeInfo{{
+			Old: models.Product{Model: "A1", Type: "GPU", Price: "1 000"},
+			New: models.Product{Model: "A1", Type: "GPU", Price: "700"},
+		}},
+	}
+	mockBot := mocks.NewAPI(t)
+	mockRepo := mocks.NewRepository(t)
+	mockRepo.On("GetAlertRules", mock.Anything).Return([]models.AlertRule{
+		{ChatID: 1, Name: "cheap", Expression: "new.price < 0.8*avg(history)"},
+		{ChatID: 3, Name: "broken", Expression: "weight > 1"},
+		{ChatID: 4, Name: "disallowed", Expression: "true"},
+	}, nil).Once()
+	mockRepo.On("GetProductHistory", mock.Anything, "", "N1").Return(nil, nil).Once()
+	mockBot.On("Send", &telebot.Chat{ID: 1},
+		"🔔 *Alert rules matched*\n\n*gpu*\n• `A1`: 1 000 → 700\n\n*new*\n• `N1`: added at 90\n\n"+
+			"*cheap*\n• `A1`: 1 000 → 700", markdownOpts(15)).
+		Return(&telebot.Message{}, nil).Once()
+	mockBot.On("Send", &telebot.Chat{ID: 2}, mock.MatchedBy(func(text string) bool {
+		return !strings.Contains(text, "gpu") && strings.Contains(text, "*new*\n• `N1`: added at 90")
+	}), markdownOpts(0)).Return(&telebot.Message{}, nil).Once()
+	testBot := Bot{
+		bot: mockBot, log: slog.Default(), repo: mockRepo,
+		allowedChats: map[int64]bool{1: true, 2: true, 3: true},
+		filterGroups: map[string][]string{"cpu": {"cpu"}},
+		alertRules: []rules.Rule{
+			mustCompileRule(t, "gpu", `product.type == "GPU"`),
+			mustCompileRule(t, "new", `change.kind == "added"`),
+		},
+	}
+	histories := map[models.ProductRef][]models.ChangeRecord{{Model: "A1"}: nil}
+
+	settings := map[int64]models.ChatSettings{
+		1: {ThreadID: 15, FilterGroup: "unknown"},
+		2: {FilterGroup: "cpu"},
+	}
+	testBot.sendRuleAlerts(t.Context(), testBot.newBroadcast("test"), changes, histories, []int64{1, 2}, settings)
+}
@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+// Limits of the search answers.
+const (
+	// searchMaxProducts is the number of products shown in the answer to /search.
+	searchMaxProducts = 10
+	// inlineMaxResults is the number of results Telegram accepts in the answer to an inline query.
+	inlineMaxResults = 50
+	// inlineCacheSeconds is how long Telegram may reuse the answer to an inline query for the same user.
+	inlineCacheSeconds = 60
+)
+
+// searchUsage explains the /search command.
+const searchUsage = "Usage: /search <words>, e.g. /search casio diver\n" +
+	"Products whose model or type have words starting with every given word are found."
+
+// searchHandler handles the /search command: it shows the products most relevant to the words of the
+// argument. Every word matches the start of a word of the model or the type.
+func (b *Bot) searchHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+
+	text := strings.TrimSpace(ctx.Data())
+	if text == "" {
+		b.sendMessage(ctx, chatID, searchUsage)
+		return nil
+	}
+
+	products, err := b.repo.SearchProducts(context.Background(), text, searchMaxProducts)
+	if err != nil {
+		b.log.Error("Failed to search products", "chatID", chatID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to search the products.")
+		return nil
+	}
+
+	if err = ctx.Send(formatSearchResults(products), telebot.ModeMarkdown); err != nil {
+		b.log.Error("Failed to send search results", "chatID", chatID, "err", err)
+	}
+
+	return nil
+}
+
+// inlineQueryHandler answers the inline queries of the allowed users with the most relevant products,
+// so they can be shared in any chat. Inline mode has to be enabled for the bot with @BotFather.
+func (b *Bot) inlineQueryHandler(ctx telebot.Context) error {
+	userID := ctx.Sender().ID
+
+	var products []models.Product
+	if text := strings.TrimSpace(ctx.Query().Text); text != "" && b.isAllowed(userID) {
+		var err error
+		products, err = b.repo.SearchProducts(context.Background(), text, inlineMaxResults)
+		if err != nil {
+			b.log.Error("Failed to search products", "userID", userID, "err", err)
+		}
+	}
+
+	results := make(telebot.Results, 0, len(products))
+	for idx, product := range products {
+		result := &telebot.ArticleResult{
+			Title:       product.Model,
+			Description: groupName(product.Category, product.Type) + " · " + product.Price,
+			Text:        formatProductLine(product),
+			URL:         product.ProductURL,
+			ThumbURL:    product.ImageURL,
+		}
+		result.SetResultID(strconv.Itoa(idx))
+		result.ParseMode = telebot.ModeMarkdown
+		results = append(results, result)
+	}
+
+	err := ctx.Answer(&telebot.QueryResponse{Results: results, CacheTime: inlineCacheSeconds, IsPersonal: true})
+	if err != nil {
+		b.log.Error("Failed to answer inline query", "userID", userID, "err", err)
+	}
+
+	return nil
+}
+
+// formatSearchResults describes the found products.
+func formatSearchResults(products []models.Product) string {
+	if len(products) == 0 {
+		return "🤷 No products found."
+	}
+
+	lines := make([]string, 0, len(products)+1)
+	lines = append(lines, "🔎 *Found products*")
+	for _, product := range products {
+		lines = append(lines, formatProductLine(product))
+	}
+
+	return strings.Join(lines, "\n")
+}
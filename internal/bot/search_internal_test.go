@@ -0,0 +1,154 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+// queryAPI is a minimal telebot.API which records the answers to inline queries.
+type queryAPI struct {
+	recordingAPI
+
+	answers []*telebot.QueryResponse
+}
+
+func (q *queryAPI) Answer(_ *telebot.Query, resp *telebot.QueryResponse) error {
+	q.answers = append(q.answers, resp)
+	return nil
+}
+
+// newQueryContext creates a handler context for an inline query with the text sent by userID.
+func newQueryContext(userID int64, text string) (telebot.Context, *queryAPI) {
+	api := &queryAPI{}
+	update := telebot.Update{Query: &telebot.Query{Sender: &telebot.User{ID: userID}, Text: text}}
+
+	return telebot.NewContext(api, update), api
+}
+
+func TestSearchHandler(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(-100)
+
+	t.Run("most relevant products", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("SearchProducts", mock.Anything, "casio diver", searchMaxProducts).Return([]models.Product{
+			{Model: "A1", Category: "new", Type: "Diver", Price: "120", Quantity: "3"},
+			{Model: "B2", Type: "Diver", Price: "200", Quantity: "1"},
+		}, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, " casio diver ")
+
+		require.NoError(t, testBot.searchHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Equal(t, "🔎 *Found products*\n`A1` (new · Diver): *120*, quantity: 3\n"+
+			"`B2` (Diver): *200*, quantity: 1", api.sent[0])
+	})
+
+	t.Run("nothing found", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("SearchProducts", mock.Anything, "rolex", searchMaxProducts).Return(nil, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, "rolex")
+
+		require.NoError(t, testBot.searchHandler(ctx))
+		assert.Equal(t, []interface{}{"🤷 No products found."}, api.sent)
+	})
+
+	t.Run("usage without words", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), repo: mocks.NewRepository(t), allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.searchHandler(ctx))
+		assert.Equal(t, []interface{}{searchUsage}, api.sent)
+	})
+
+	t.Run("unauthorized chat", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), repo: mocks.NewRepository(t), allowedChats: map[int64]bool{}}
+		ctx, api := newTestContext(chatID, "casio")
+
+		require.NoError(t, testBot.route("/search", accessAllowed, testBot.searchHandler)(ctx))
+		assert.Contains(t, api.sent[0], "this bot is private")
+	})
+
+	t.Run("error: cannot search", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("SearchProducts", mock.Anything, "casio", searchMaxProducts).Return(nil, assert.AnError).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, "casio")
+
+		require.NoError(t, testBot.searchHandler(ctx))
+		assert.Contains(t, api.sent[0], "internal error")
+	})
+}
+
+func TestInlineQueryHandler(t *testing.T) {
+	t.Parallel()
+
+	const userID = int64(42)
+
+	t.Run("products of an allowed user", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("SearchProducts", mock.Anything, "casio", inlineMaxResults).Return([]models.Product{{
+			Model: "A1", Category: "new", Type: "Diver", Price: "120", Quantity: "3",
+			ProductURL: "https://example.com/a1", ImageURL: "https://example.com/a1.png",
+		}}, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{userID: true}}
+		ctx, api := newQueryContext(userID, "casio")
+
+		require.NoError(t, testBot.inlineQueryHandler(ctx))
+		require.Len(t, api.answers, 1)
+		assert.True(t, api.answers[0].IsPersonal)
+		require.Len(t, api.answers[0].Results, 1)
+		article, ok := api.answers[0].Results[0].(*telebot.ArticleResult)
+		require.True(t, ok)
+		assert.Equal(t, "A1", article.Title)
+		assert.Equal(t, "new · Diver · 120", article.Description)
+		assert.Equal(t, "`A1` (new · Diver): *120*, quantity: 3", article.Text)
+		assert.Equal(t, "https://example.com/a1", article.URL)
+		assert.Equal(t, "0", article.ResultID())
+	})
+
+	t.Run("unauthorized user gets no results", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), repo: mocks.NewRepository(t), allowedChats: map[int64]bool{}}
+		ctx, api := newQueryContext(userID, "casio")
+
+		require.NoError(t, testBot.inlineQueryHandler(ctx))
+		require.Len(t, api.answers, 1)
+		assert.Empty(t, api.answers[0].Results)
+	})
+
+	t.Run("error: cannot search", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("SearchProducts", mock.Anything, "casio", inlineMaxResults).Return(nil, assert.AnError).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{userID: true}}
+		ctx, api := newQueryContext(userID, "casio")
+
+		require.NoError(t, testBot.inlineQueryHandler(ctx))
+		require.Len(t, api.answers, 1)
+		assert.Empty(t, api.answers[0].Results)
+	})
+}
@@ -0,0 +1,268 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+// settingsUnique routes the callbacks of the /settings menu buttons.
+const settingsUnique = "settings"
+
+// Settings menu pages and actions carried in the callback data as "action|argument".
+const (
+	settingsMain        = "main"
+	settingsFilters     = "filters"
+	settingsFilter      = "filter"
+	settingsSilent      = "silent"
+	settingsToggle      = "toggle"
+	settingsTrends      = "trends"
+	settingsWatermarks  = "watermarks"
+	settingsSubscribe   = "subscribe"
+	settingsUnsubscribe = "unsubscribe"
+	settingsClose       = "close"
+)
+
+// chatState is what the settings menu shows for a chat.
+type chatState struct {
+	settings   models.ChatSettings
+	subscribed bool
+}
+
+// settingsHandler handles the /settings command: it opens an inline menu for the chat's notification
+// preferences. The menu is edited in place as buttons are pressed, see settingsCallback.
+func (b *Bot) settingsHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+
+	state, err := b.chatState(context.Background(), chatID)
+	if err != nil {
+		b.log.Error("Failed to load chat settings", "chatID", chatID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to load the settings.")
+		return nil
+	}
+
+	text, markup := b.settingsPage(settingsMain, state)
+	if err = ctx.Send(text, markup); err != nil {
+		b.log.Error("Failed to send settings menu", "chatID", chatID, "err", err)
+	}
+
+	return nil
+}
+
+// settingsCallback handles a press of a settings menu button: it applies the action
+// and redraws the menu with the updated settings.
+func (b *Bot) settingsCallback(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+	repoCtx := context.Background()
+
+	action, arg, _ := strings.Cut(ctx.Callback().Data, "|")
+	if action == settingsClose {
+		if err := ctx.Delete(); err != nil {
+			b.log.Warn("Failed to close settings menu", "chatID", chatID, "err", err)
+		}
+		return b.respond(ctx, "")
+	}
+
+	state, err := b.chatState(repoCtx, chatID)
+	if err == nil {
+		err = b.applySetting(repoCtx, chatID, action, arg, state)
+	}
+	if err == nil {
+		state, err = b.chatState(repoCtx, chatID)
+	}
+	if err != nil {
+		b.log.Error("Failed to update chat settings", "chatID", chatID, "action", action, "err", err)
+		return b.respond(ctx, "⛔ An internal error occurred. Failed to update the settings.")
+	}
+
+	text, markup := b.settingsPage(settingsPageOf(action), state)
+	if err = ctx.Edit(text, markup); err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		b.log.Warn("Failed to redraw settings menu", "chatID", chatID, "err", err)
+	}
+
+	return b.respond(ctx, "")
+}
+
+// respond answers a callback so Telegram stops showing the button as pressed.
+func (b *Bot) respond(ctx telebot.Context, text string) error {
+	if err := ctx.Respond(&telebot.CallbackResponse{Text: text}); err != nil {
+		b.log.Warn("Failed to answer callback", "chatID", ctx.Chat().ID, "err", err)
+	}
+
+	return nil
+}
+
+// chatState loads the settings and the subscription of the chat.
+func (b *Bot) chatState(ctx context.Context, chatID int64) (chatState, error) {
+	subscribers, err := b.repo.GetSubscribedChats(ctx)
+	if err != nil {
+		return chatState{}, fmt.Errorf("failed to get subscribers: %w", err)
+	}
+
+	settings, err := b.repo.GetChatSettings(ctx)
+	if err != nil {
+		return chatState{}, fmt.Errorf("failed to get chat settings: %w", err)
+	}
+
+	return chatState{settings: settings[chatID], subscribed: slices.Contains(subscribers, chatID)}, nil
+}
+
+// applySetting changes the chat settings according to the pressed button. Navigation buttons change nothing.
+func (b *Bot) applySetting(ctx context.Context, chatID int64, action, arg string, state chatState) error {
+	switch action {
+	case settingsFilter:
+		if _, ok := b.filterGroups[arg]; arg != "" && !ok {
+			return nil
+		}
+		if err := b.repo.SetFilterGroup(ctx, chatID, arg); err != nil {
+			return fmt.Errorf("failed to set filter group: %w", err)
+		}
+	case settingsToggle:
+		category := models.ChangeCategory(arg)
+		if !category.IsValid() {
+			return nil
+		}
+		silent := slices.DeleteFunc(slices.Clone(state.settings.Silent), func(c models.ChangeCategory) bool {
+			return c == category
+		})
+		if len(silent) == len(state.settings.Silent) {
+			silent = append(silent, category)
+		}
+		if err := b.repo.SetSilentCategories(ctx, chatID, silent); err != nil {
+			return fmt.Errorf("failed to set silent categories: %w", err)
+		}
+	case settingsTrends:
+		if err := b.repo.SetSustainedTrends(ctx, chatID, !state.settings.SustainedTrends); err != nil {
+			return fmt.Errorf("failed to set sustained trends: %w", err)
+		}
+	case settingsWatermarks:
+		enabled, days := nextWatermarkPeriod(state.settings)
+		if err := b.repo.SetWatermarks(ctx, chatID, enabled, days); err != nil {
+			return fmt.Errorf("failed to set watermarks: %w", err)
+		}
+	case settingsSubscribe:
+		if err := b.repo.SubscribeChat(ctx, chatID); err != nil {
+			return fmt.Errorf("failed to subscribe chat: %w", err)
+		}
+	case settingsUnsubscribe:
+		if err := b.repo.UnsubscribeChat(ctx, chatID); err != nil {
+			return fmt.Errorf("failed to unsubscribe chat: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// settingsPageOf returns the menu page shown after the action.
+func settingsPageOf(action string) string {
+	switch action {
+	case settingsFilters, settingsFilter:
+		return settingsFilters
+	case settingsSilent, settingsToggle:
+		return settingsSilent
+	default:
+		return settingsMain
+	}
+}
+
+// settingsPage renders a page of the settings menu for the chat state.
+func (b *Bot) settingsPage(page string, state chatState) (string, *telebot.ReplyMarkup) {
+	markup := &telebot.ReplyMarkup{}
+	back := markup.Data("« Back", settingsUnique, settingsMain)
+
+	switch page {
+	case settingsFilters:
+		groups := make([]string, 0, len(b.filterGroups))
+		for group := range b.filterGroups {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+
+		rows := make([]telebot.Row, 0, len(groups)+2)
+		rows = append(rows, markup.Row(markup.Data(checked(state.settings.FilterGroup == "", "All products"),
+			settingsUnique, settingsFilter, "")))
+		for _, group := range groups {
+			label := fmt.Sprintf("%s (%s)", group, strings.Join(b.filterGroups[group], ", "))
+			rows = append(rows, markup.Row(markup.Data(checked(state.settings.FilterGroup == group, label),
+				settingsUnique, settingsFilter, group)))
+		}
+		markup.Inline(append(rows, markup.Row(back))...)
+
+		return "🏷 Choose the products you want to be notified about.", markup
+	case settingsSilent:
+		categories := models.ChangeCategories()
+		rows := make([]telebot.Row, 0, len(categories)+1)
+		for _, category := range categories {
+			icon := "🔔"
+			if slices.Contains(state.settings.Silent, category) {
+				icon = "🔕"
+			}
+			rows = append(rows, markup.Row(markup.Data(icon+" "+string(category),
+				settingsUnique, settingsToggle, string(category))))
+		}
+		markup.Inline(append(rows, markup.Row(back))...)
+
+		return "🔕 Tap a category to toggle whether its notifications are sent without sound.", markup
+	default:
+		subscription := markup.Data("💔 Unsubscribe", settingsUnique, settingsUnsubscribe)
+		if !state.subscribed {
+			subscription = markup.Data("✅ Subscribe", settingsUnique, settingsSubscribe)
+		}
+		markup.Inline(
+			markup.Row(markup.Data("🏷 Filter group", settingsUnique, settingsFilters)),
+			markup.Row(markup.Data("🔕 Silent categories", settingsUnique, settingsSilent)),
+			markup.Row(markup.Data(checked(state.settings.SustainedTrends, "📈 Sustained price trends only"),
+				settingsUnique, settingsTrends)),
+			markup.Row(markup.Data("🚨 Price lows and highs: "+describeWatermarks(state.settings),
+				settingsUnique, settingsWatermarks)),
+			markup.Row(subscription),
+			markup.Row(markup.Data("✖️ Close", settingsUnique, settingsClose)),
+		)
+
+		return formatSettings(state), markup
+	}
+}
+
+// formatSettings describes the current settings of the chat.
+func formatSettings(state chatState) string {
+	subscription := "not subscribed"
+	if state.subscribed {
+		subscription = "subscribed"
+	}
+	group := state.settings.FilterGroup
+	if group == "" {
+		group = "all products"
+	}
+	silent := "none"
+	if len(state.settings.Silent) > 0 {
+		silent = joinCategories(state.settings.Silent)
+	}
+	topic := "General"
+	if state.settings.ThreadID != 0 {
+		topic = fmt.Sprintf("#%d (change it with /settopic)", state.settings.ThreadID)
+	}
+	prices := "every change"
+	if state.settings.SustainedTrends {
+		prices = "sustained trends only"
+	}
+
+	return fmt.Sprintf(
+		"⚙️ Notification settings\n\n📬 Subscription: %s\n🏷 Filter group: %s\n🔕 Silent: %s\n"+
+			"📈 Price changes: %s\n🚨 Price lows and highs: %s\n📌 Topic: %s",
+		subscription, group, silent, prices, describeWatermarks(state.settings), topic,
+	)
+}
+
+// checked marks the label of the selected option.
+func checked(selected bool, label string) string {
+	if selected {
+		return "✅ " + label
+	}
+
+	return label
+}
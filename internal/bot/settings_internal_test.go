@@ -0,0 +1,200 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+// callbackAPI is a minimal telebot.API which records the menu a callback handler redraws.
+type callbackAPI struct {
+	recordingAPI
+
+	edited  []interface{}
+	answers []*telebot.CallbackResponse
+}
+
+func (c *callbackAPI) Edit(_ telebot.Editable, what interface{}, _ ...interface{}) (*telebot.Message, error) {
+	c.edited = append(c.edited, what)
+	return &telebot.Message{}, nil
+}
+
+func (c *callbackAPI) Respond(_ *telebot.Callback, resp ...*telebot.CallbackResponse) error {
+	c.answers = append(c.answers, resp...)
+	return nil
+}
+
+// newCallbackContext creates a handler context for a settings button with the given data pressed in chatID.
+func newCallbackContext(chatID int64, data string) (telebot.Context, *callbackAPI) {
+	api := &callbackAPI{}
+	message := &telebot.Message{Chat: &telebot.Chat{ID: chatID}}
+	update := telebot.Update{Callback: &telebot.Callback{Message: message, Data: data}}
+
+	return telebot.NewContext(api, update), api
+}
+
+func TestSettingsHandler(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(-100)
+
+	t.Run("unauthorized chat is refused", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), allowedChats: map[int64]bool{}}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.route("/settings", accessAllowed, testBot.settingsHandler)(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "private")
+	})
+
+	t.Run("menu shows the current settings", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetSubscribedChats", mock.Anything).Return([]int64{chatID}, nil).Once()
+		mockRepo.On("GetChatSettings", mock.Anything).Return(map[int64]models.ChatSettings{
+			chatID: {FilterGroup: "diver", Silent: []models.ChangeCategory{models.CategoryQuantity}},
+		}, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.settingsHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "Subscription: subscribed")
+		assert.Contains(t, api.sent[0], "Filter group: diver")
+		assert.Contains(t, api.sent[0], "Silent: quantity")
+	})
+}
+
+func TestSettingsCallback(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(-100)
+	groups := map[string][]string{"diver": {"Diver"}}
+
+	newSettingsBot := func(t *testing.T, settings models.ChatSettings) (*Bot, *mocks.Repository) {
+		t.Helper()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetSubscribedChats", mock.Anything).Return([]int64{chatID}, nil)
+		mockRepo.On("GetChatSettings", mock.Anything).Return(map[int64]models.ChatSettings{chatID: settings}, nil)
+
+		return &Bot{
+			log:          slog.Default(),
+			repo:         mockRepo,
+			allowedChats: map[int64]bool{chatID: true},
+			filterGroups: groups,
+		}, mockRepo
+	}
+
+	t.Run("filter group is selected", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, mockRepo := newSettingsBot(t, models.ChatSettings{})
+		mockRepo.On("SetFilterGroup", mock.Anything, chatID, "diver").Return(nil).Once()
+		ctx, api := newCallbackContext(chatID, "filter|diver")
+
+		require.NoError(t, testBot.settingsCallback(ctx))
+		require.Len(t, api.edited, 1)
+		assert.Contains(t, api.edited[0], "Choose the products")
+		assert.Len(t, api.answers, 1)
+	})
+
+	t.Run("unknown filter group is ignored", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, _ := newSettingsBot(t, models.ChatSettings{})
+		ctx, api := newCallbackContext(chatID, "filter|unknown")
+
+		require.NoError(t, testBot.settingsCallback(ctx))
+		assert.Len(t, api.edited, 1)
+	})
+
+	t.Run("silent category is toggled", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, mockRepo := newSettingsBot(t, models.ChatSettings{
+			Silent: []models.ChangeCategory{models.CategoryQuantity, models.CategoryAdded},
+		})
+		mockRepo.On("SetSilentCategories", mock.Anything, chatID, []models.ChangeCategory{models.CategoryAdded}).
+			Return(nil).Once()
+		ctx, api := newCallbackContext(chatID, "toggle|quantity")
+
+		require.NoError(t, testBot.settingsCallback(ctx))
+		require.Len(t, api.edited, 1)
+		assert.Contains(t, api.edited[0], "toggle")
+	})
+
+	t.Run("sustained trends are toggled", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, mockRepo := newSettingsBot(t, models.ChatSettings{SustainedTrends: true})
+		mockRepo.On("SetSustainedTrends", mock.Anything, chatID, false).Return(nil).Once()
+		ctx, api := newCallbackContext(chatID, "trends")
+
+		require.NoError(t, testBot.settingsCallback(ctx))
+		require.Len(t, api.edited, 1)
+		assert.Contains(t, api.edited[0], "Price changes: sustained trends only")
+	})
+
+	t.Run("watermarks cycle through the periods", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, mockRepo := newSettingsBot(t, models.ChatSettings{Watermarks: true})
+		mockRepo.On("SetWatermarks", mock.Anything, chatID, true, 30).Return(nil).Once()
+		ctx, api := newCallbackContext(chatID, "watermarks")
+
+		require.NoError(t, testBot.settingsCallback(ctx))
+		require.Len(t, api.edited, 1)
+		assert.Contains(t, api.edited[0], "Price lows and highs: all-time lows and highs")
+	})
+
+	t.Run("chat is unsubscribed", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, mockRepo := newSettingsBot(t, models.ChatSettings{})
+		mockRepo.On("UnsubscribeChat", mock.Anything, chatID).Return(nil).Once()
+		ctx, api := newCallbackContext(chatID, "unsubscribe")
+
+		require.NoError(t, testBot.settingsCallback(ctx))
+		require.Len(t, api.edited, 1)
+		assert.Contains(t, api.edited[0], "Notification settings")
+	})
+
+	t.Run("error: settings cannot be saved", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, mockRepo := newSettingsBot(t, models.ChatSettings{})
+		mockRepo.On("SetFilterGroup", mock.Anything, chatID, "").Return(assert.AnError).Once()
+		ctx, api := newCallbackContext(chatID, "filter|")
+
+		require.NoError(t, testBot.settingsCallback(ctx))
+		assert.Empty(t, api.edited)
+		require.Len(t, api.answers, 1)
+		assert.Contains(t, api.answers[0].Text, "internal error")
+	})
+}
+
+func TestSettingsPage_Markup(t *testing.T) {
+	t.Parallel()
+
+	testBot := Bot{filterGroups: map[string][]string{"diver": {"Diver"}, "dress": {"Dress"}}}
+	state := chatState{settings: models.ChatSettings{FilterGroup: "dress"}}
+
+	_, markup := testBot.settingsPage(settingsFilters, state)
+
+	require.Len(t, markup.InlineKeyboard, 4)
+	assert.Equal(t, "All products", markup.InlineKeyboard[0][0].Text)
+	assert.Equal(t, "diver (Diver)", markup.InlineKeyboard[1][0].Text)
+	assert.Equal(t, "✅ dress (Dress)", markup.InlineKeyboard[2][0].Text)
+	assert.Equal(t, settingsUnique, markup.InlineKeyboard[2][0].Unique)
+	assert.Equal(t, "filter|dress", markup.InlineKeyboard[2][0].Data)
+}
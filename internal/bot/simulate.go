@@ -0,0 +1,153 @@
+package bot
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+// ErrUnknownChangeKind is returned for a change kind to simulate that doesn't exist.
+var ErrUnknownChangeKind = errors.New("unknown change kind")
+
+// simulateUsage explains the /simulate command.
+const simulateUsage = "Usage: /simulate [added] [changed] [renamed] [removed], every kind without arguments"
+
+const (
+	// simulationBanner heads the messages sent for a simulation, so the chats can tell them from real changes.
+	simulationBanner = "🧪 Simulation, not a real change\n\n"
+	// simulatedSuffix marks the models of the synthetic products a simulation adds or renames.
+	simulatedSuffix = "-SIM"
+	// simulatedDrop is the share of the price a changed product keeps in a simulation.
+	simulatedDrop = 0.9
+)
+
+// simulationKey is the context key marking the notifications of a simulation.
+type simulationKey struct{}
+
+// withSimulation marks the messages sent with the context as the ones of a simulation.
+func withSimulation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, simulationKey{}, true)
+}
+
+// isSimulation reports whether the messages sent with the context are the ones of a simulation.
+func isSimulation(ctx context.Context) bool {
+	simulated, _ := ctx.Value(simulationKey{}).(bool)
+	return simulated
+}
+
+// Simulate sends synthetic changes of the kinds, every kind if none are given, to the subscribers through
+// the whole notification pipeline: the templates, the filter groups, the topics, the alerts and the pacing
+// of the broadcasts. The changes are made up of the current products, so they match the filter groups like
+// real ones would. They aren't recorded in the history, and every message sent for them is headed by a
+// banner. It returns the changes sent.
+func (b *Bot) Simulate(ctx context.Context, kinds []string) (*models.Changes, error) {
+	for _, kind := range kinds {
+		if !slices.Contains(TemplateKinds(), kind) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownChangeKind, kind)
+		}
+	}
+
+	products, err := b.currentProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := simulatedChanges(products, kinds)
+	b.log.InfoContext(ctx, "Simulating changes", "count", changes.Count())
+	if err = b.SendChangesNotification(withSimulation(ctx), changes); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// simulatedChanges makes a change of every kind out of the current products, the kinds take different
+// products as long as there are enough. Without products the sample changes of the templates are used.
+func simulatedChanges(products map[models.ProductRef]models.Product, kinds []string) *models.Changes {
+	if len(kinds) == 0 {
+		kinds = TemplateKinds()
+	}
+
+	refs := make([]models.ProductRef, 0, len(products))
+	for ref := range products {
+		refs = append(refs, ref)
+	}
+	slices.SortFunc(refs, func(a, b models.ProductRef) int {
+		return cmp.Or(strings.Compare(a.Category, b.Category), strings.Compare(a.Model, b.Model))
+	})
+	sample := sampleChanges()
+	pick := func(idx int, fallback models.Product) models.Product {
+		if len(refs) == 0 {
+			return fallback
+		}
+
+		return products[refs[idx%len(refs)]]
+	}
+
+	changes := &models.Changes{}
+	for idx, kind := range TemplateKinds() {
+		if !slices.Contains(kinds, kind) {
+			continue
+		}
+
+		switch kind {
+		case export.KindAdded:
+			product := pick(idx, sample.Added[0])
+			product.Model += simulatedSuffix
+			changes.Added = append(changes.Added, product)
+		case export.KindChanged:
+			old := pick(idx, sample.Changed[0].Old)
+			changes.Changed = append(changes.Changed, models.ChangeInfo{Old: old, New: droppedPrice(old)})
+		case export.KindRenamed:
+			old := pick(idx, sample.Renamed[0].Old)
+			renamed := old
+			renamed.Model += simulatedSuffix
+			changes.Renamed = append(changes.Renamed, models.ChangeInfo{Old: old, New: renamed})
+		default:
+			changes.Removed = append(changes.Removed, pick(idx, sample.Removed[0]))
+		}
+	}
+
+	return changes
+}
+
+// droppedPrice returns the product with a lower price, or out of stock if its price isn't a number.
+func droppedPrice(product models.Product) models.Product {
+	price, err := models.ParsePrice(product.Price)
+	if err != nil {
+		product.Quantity = "0"
+		return product
+	}
+	product.Price = strconv.FormatFloat(math.Round(price*simulatedDrop), 'f', -1, 64)
+
+	return product
+}
+
+// simulateHandler handles the admin /simulate command: it sends synthetic changes to the subscribers, see
+// Simulate, to check the notifications without waiting for real changes.
+func (b *Bot) simulateHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+
+	changes, err := b.Simulate(context.Background(), strings.Fields(ctx.Data()))
+	switch {
+	case errors.Is(err, ErrUnknownChangeKind):
+		b.sendMessage(ctx, chatID, simulateUsage)
+	case err != nil:
+		b.log.Error("Failed to simulate changes", "chatID", chatID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to simulate the changes.")
+	default:
+		b.log.Info("Changes simulated", "changes", changes.Count(), "by", chatID)
+		b.sendMessage(ctx, chatID, fmt.Sprintf("🧪 Sent %d simulated changes to the subscribers.", changes.Count()))
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+func TestSimulatedChanges(t *testing.T) {
+	t.Parallel()
+
+	t.Run("changes are made up of the current products", func(t *testing.T) {
+		t.Parallel()
+
+		products := map[models.ProductRef]models.Product{
+			{Category: "new", Model: "B2"}:  {Model: "B2", Category: "new", Type: "GPU", Price: "1 000"},
+			{Category: "new", Model: "A1"}:  {Model: "A1", Category: "new", Type: "CPU", Price: "on request"},
+			{Category: "used", Model: "A1"}: {Model: "A1", Category: "used", Type: "CPU", Price: "50"},
+		}
+
+		changes := simulatedChanges(products, nil)
+
+		require.Equal(t, 4, changes.Count())
+		assert.Equal(t, "A1-SIM", changes.Added[0].Model)
+		assert.Equal(t, "new", changes.Added[0].Category)
+		assert.Equal(t, "B2", changes.Changed[0].New.Model)
+		assert.Equal(t, "900", changes.Changed[0].New.Price)
+		assert.Equal(t, "50", changes.Renamed[0].Old.Price)
+		assert.Equal(t, "A1-SIM", changes.Renamed[0].New.Model)
+		assert.Equal(t, "A1", changes.Removed[0].Model)
+		assert.Equal(t, "new", changes.Removed[0].Category, "the products are reused once every one was picked")
+	})
+
+	t.Run("sample changes without products", func(t *testing.T) {
+		t.Parallel()
+
+		changes := simulatedChanges(nil, []string{models.KindChanged})
+
+		require.Equal(t, 1, changes.Count())
+		assert.Equal(t, "SRPD55K1", changes.Changed[0].Old.Model)
+		assert.Equal(t, "11250", changes.Changed[0].New.Price)
+	})
+}
+
+func TestDroppedPrice(t *testing.T) {
+	t.Parallel()
+
+	product := droppedPrice(models.Product{Price: "on request", Quantity: "3"})
+	assert.Equal(t, models.Product{Price: "on request", Quantity: "0"}, product)
+}
+
+func TestSimulateHandler(t *testing.T) {
+	t.Parallel()
+
+	const adminID = int64(42)
+
+	t.Run("synthetic changes are sent with a banner", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetState", mock.Anything).Return(nil, repository.ErrStateNotFound).Once()
+		expectNoPriceHistory(mockRepo)
+		expectRecordedProducts(mockRepo)
+		expectNoUserSubscriptions(mockRepo)
+		expectNoAlertRules(mockRepo)
+		mockRepo.On("GetSubscribedChats", mock.Anything).Return([]int64{1}, nil).Once()
+		mockRepo.On("GetChatSettings", mock.Anything).Return(map[int64]models.ChatSettings{}, nil).Once()
+		mockBot.On("Send", &telebot.Chat{ID: 1}, mock.MatchedBy(func(text string) bool {
+			return strings.HasPrefix(text, simulationBanner) && strings.Contains(text, "GA-2100-1A1-SIM")
+		}), markdownOpts(0)).Return(&telebot.Message{ID: 5}, nil).Once()
+		testBot := &Bot{bot: mockBot, log: slog.Default(), repo: mockRepo, adminChats: map[int64]bool{adminID: true}}
+		ctx, api := newTestContext(adminID, "added")
+
+		require.NoError(t, testBot.simulateHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Equal(t, "🧪 Sent 1 simulated changes to the subscribers.", api.sent[0])
+	})
+
+	t.Run("usage for an unknown kind", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := &Bot{log: slog.Default(), repo: mocks.NewRepository(t), adminChats: map[int64]bool{adminID: true}}
+		ctx, api := newTestContext(adminID, "added moved")
+
+		require.NoError(t, testBot.simulateHandler(ctx))
+		assert.Equal(t, simulateUsage, api.sent[0])
+	})
+
+	t.Run("error: cannot get products", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetState", mock.Anything).Return(nil, assert.AnError).Once()
+		testBot := &Bot{log: slog.Default(), repo: mockRepo, adminChats: map[int64]bool{adminID: true}}
+		ctx, api := newTestContext(adminID, "")
+
+		require.NoError(t, testBot.simulateHandler(ctx))
+		assert.Contains(t, api.sent[0], "internal error")
+	})
+}
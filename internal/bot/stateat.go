@@ -0,0 +1,65 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"gopkg.in/telebot.v4"
+)
+
+// stateAtUsage explains the /stateat command.
+const stateAtUsage = "Usage: /stateat <date>, e.g. /stateat 2026-03-03 or /stateat 2026-03-03 14:00:00"
+
+// stateAtHandler handles the /stateat command: it replies with the products the catalog listed at the
+// time as a CSV file, e.g. to settle a dispute about a past price. A date stands for the end of the
+// day. The state is reconstructed from the change history, so it reaches back as far as the history.
+func (b *Bot) stateAtHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+
+	label := strings.TrimSpace(ctx.Data())
+	at, err := export.ParseStateTime(label)
+	if err != nil {
+		b.sendMessage(ctx, chatID, stateAtUsage)
+		return nil
+	}
+
+	products, err := b.repo.GetStateAt(context.Background(), at)
+	if errors.Is(err, repository.ErrStateNotFound) {
+		b.sendMessage(ctx, chatID, fmt.Sprintf("🤷 The history doesn't reach back to %s.", label))
+		return nil
+	}
+	if err != nil {
+		b.log.Error("Failed to get the state", "chatID", chatID, "at", at, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to get the state.")
+		return nil
+	}
+	if len(products) == 0 {
+		b.sendMessage(ctx, chatID, fmt.Sprintf("🕳 The catalog listed no products on %s.", label))
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err = export.StateCSV(&buf, products); err != nil {
+		b.log.Error("Failed to export the state", "chatID", chatID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to export the state.")
+		return nil
+	}
+
+	document := &telebot.Document{
+		File:     telebot.FromReader(bytes.NewReader(buf.Bytes())),
+		FileName: fmt.Sprintf("state-%s.csv", at.Format("2006-01-02-1504")),
+		MIME:     "text/csv",
+		Caption: fmt.Sprintf("🕰 The catalog listed %d products on %s. Prices and quantities are as they were, "+
+			"the other fields are the current ones.", len(products), label),
+	}
+	if err = ctx.Send(document); err != nil {
+		b.log.Error("Failed to send the state", "chatID", chatID, "err", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+func TestStateAtHandler(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(42)
+	endOfMarch3 := time.Date(2026, time.March, 3, 23, 59, 59, 999999999, time.Local)
+
+	t.Run("the state is sent as a CSV file", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetStateAt", mock.Anything, endOfMarch3).Return([]models.Product{
+			{Model: "A1", Category: "new", Price: "100"},
+			{Model: "B1", Category: "new", Price: "50"},
+		}, nil).Once()
+		testBot := &Bot{log: slog.Default(), repo: mockRepo}
+		ctx, api := newTestContext(chatID, "2026-03-03")
+
+		require.NoError(t, testBot.stateAtHandler(ctx))
+		require.Len(t, api.sent, 1)
+		document, ok := api.sent[0].(*telebot.Document)
+		require.True(t, ok)
+		assert.Equal(t, "state-2026-03-03-2359.csv", document.FileName)
+		assert.Contains(t, document.Caption, "listed 2 products on 2026-03-03")
+		content, err := io.ReadAll(document.FileReader)
+		require.NoError(t, err)
+		assert.Equal(t, "category,model,type,price,quantity,image_url,url\nnew,A1,,100,,,\nnew,B1,,50,,,\n",
+			string(content))
+	})
+
+	t.Run("the history doesn't reach back", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetStateAt", mock.Anything, endOfMarch3).Return(nil, repository.ErrStateNotFound).Once()
+		testBot := &Bot{log: slog.Default(), repo: mockRepo}
+		ctx, api := newTestContext(chatID, "2026-03-03")
+
+		require.NoError(t, testBot.stateAtHandler(ctx))
+		assert.Equal(t, "🤷 The history doesn't reach back to 2026-03-03.", api.sent[0])
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := &Bot{log: slog.Default(), repo: mocks.NewRepository(t)}
+		for _, payload := range []string{"", "March 3rd", "03.03.2026"} {
+			ctx, api := newTestContext(chatID, payload)
+			require.NoError(t, testBot.stateAtHandler(ctx))
+			assert.Equal(t, stateAtUsage, api.sent[0], payload)
+		}
+	})
+
+	t.Run("error: get state", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetStateAt", mock.Anything, mock.Anything).Return(nil, assert.AnError).Once()
+		testBot := &Bot{log: slog.Default(), repo: mockRepo}
+		ctx, api := newTestContext(chatID, "2026-03-03T12:00:00Z")
+
+		require.NoError(t, testBot.stateAtHandler(ctx))
+		assert.Contains(t, api.sent[0], "internal error")
+	})
+}
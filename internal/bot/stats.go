@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+// Periods of the subscriber statistics.
+const (
+	statsDays     = 30
+	statsWeekDays = 7
+)
+
+// statsHandler handles the admin /stats command: it shows how the subscribers changed over the last
+// 30 days and how many chats used the bot on each of the last 7 days. With feedback enabled, the
+// ratings of the notifications of the same 30 days follow per template variant.
+func (b *Bot) statsHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+
+	since := time.Now().UTC().AddDate(0, 0, 1-statsDays)
+	days, err := b.repo.GetSubscriberStats(context.Background(), since)
+	if err != nil || len(days) == 0 {
+		b.log.Error("Failed to get subscriber statistics", "chatID", chatID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to get the statistics.")
+		return nil
+	}
+
+	text := formatSubscriberStats(days)
+	if b.feedback {
+		feedback, feedbackErr := b.repo.GetFeedbackStats(context.Background(), since)
+		if feedbackErr != nil {
+			b.log.Error("Failed to get feedback statistics", "chatID", chatID, "err", feedbackErr)
+		} else {
+			text += "\n\n" + formatFeedbackStats(feedback)
+		}
+	}
+	b.sendMessage(ctx, chatID, text)
+
+	return nil
+}
+
+// trackActivity wraps a handler to record that the allowed chat it handles used the bot today, a chat using
+// the bot is reachable again.
+func (b *Bot) trackActivity(handler telebot.HandlerFunc) telebot.HandlerFunc {
+	return func(ctx telebot.Context) error {
+		if chat := ctx.Chat(); chat != nil && b.isAllowed(chat.ID) {
+			if err := b.repo.RecordChatActivity(context.Background(), chat.ID, time.Now()); err != nil {
+				b.log.Warn("Failed to record chat activity", "chatID", chat.ID, "err", err)
+			}
+			b.reachable(context.Background(), chat.ID)
+		}
+
+		return handler(ctx)
+	}
+}
+
+// formatSubscriberStats describes the subscriber statistics of the days, oldest first: the totals of
+// the whole period and of its last week, followed by the last week day by day.
+func formatSubscriberStats(days []models.SubscriberDay) string {
+	week := days[max(len(days)-statsWeekDays, 0):]
+	today := days[len(days)-1]
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "📈 Subscribers: %d\n", today.Subscribers)
+	for _, period := range []struct {
+		name string
+		days []models.SubscriberDay
+	}{{fmt.Sprintf("%d days", len(days)), days}, {fmt.Sprintf("%d days", len(week)), week}} {
+		var subscribed, unsubscribed, active int
+		for _, day := range period.days {
+			subscribed += day.Subscribed
+			unsubscribed += day.Unsubscribed
+			active += day.ActiveChats
+		}
+		fmt.Fprintf(&builder, "\nLast %s: +%d / -%d, churn %.1f%%, %.1f active chats a day",
+			period.name, subscribed, unsubscribed, models.Churn(period.days),
+			float64(active)/float64(len(period.days)))
+	}
+
+	builder.WriteString("\n")
+	for _, day := range week {
+		fmt.Fprintf(&builder, "\n%s: %d subscribers, +%d / -%d, %d active",
+			day.Day.Format("Jan 02"), day.Subscribers, day.Subscribed, day.Unsubscribed, day.ActiveChats)
+	}
+
+	return builder.String()
+}
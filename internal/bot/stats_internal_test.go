@@ -0,0 +1,119 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+func TestStatsHandler(t *testing.T) {
+	t.Parallel()
+
+	const adminID = int64(42)
+
+	t.Run("statistics are shown", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetSubscriberStats", mock.Anything, mock.AnythingOfType("time.Time")).
+			Return([]models.SubscriberDay{{Subscribers: 3, Subscribed: 3, ActiveChats: 2}}, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, adminChats: map[int64]bool{adminID: true}}
+		ctx, api := newTestContext(adminID, "")
+
+		require.NoError(t, testBot.statsHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "📈 Subscribers: 3")
+	})
+
+	t.Run("feedback follows the statistics", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetSubscriberStats", mock.Anything, mock.AnythingOfType("time.Time")).
+			Return([]models.SubscriberDay{{Subscribers: 3, Subscribed: 3, ActiveChats: 2}}, nil).Once()
+		mockRepo.On("GetFeedbackStats", mock.Anything, mock.AnythingOfType("time.Time")).
+			Return([]models.VariantFeedback{{Variant: variantA, Sent: 4, Useful: 1}}, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, feedback: true}
+		ctx, api := newTestContext(adminID, "")
+
+		require.NoError(t, testBot.statsHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "📈 Subscribers: 3")
+		assert.Contains(t, api.sent[0], "a: 4 sent, 1 👍 / 0 👎")
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetSubscriberStats", mock.Anything, mock.Anything).Return(nil, assert.AnError).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, adminChats: map[int64]bool{adminID: true}}
+		ctx, api := newTestContext(adminID, "")
+
+		require.NoError(t, testBot.statsHandler(ctx))
+		assert.Contains(t, api.sent[0], "internal error")
+	})
+
+	t.Run("non-admin is refused", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), repo: mocks.NewRepository(t)}
+		ctx, api := newTestContext(7, "")
+
+		require.NoError(t, testBot.route("/stats", accessAdmin, testBot.statsHandler)(ctx))
+		assert.Contains(t, api.sent[0], "administrators only")
+	})
+}
+
+func TestTrackActivity(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(7)
+
+	mockRepo := mocks.NewRepository(t)
+	mockRepo.On("RecordChatActivity", mock.Anything, chatID, mock.AnythingOfType("time.Time")).
+		Return(assert.AnError).Once()
+	testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+
+	var handled int
+	handler := testBot.trackActivity(func(telebot.Context) error {
+		handled++
+		return nil
+	})
+
+	ctx, _ := newTestContext(chatID, "")
+	require.NoError(t, handler(ctx), "a failure to record the activity doesn't fail the update")
+	ctx, _ = newTestContext(8, "")
+	require.NoError(t, handler(ctx), "the activity of chats that aren't allowed isn't recorded")
+	assert.Equal(t, 2, handled)
+}
+
+func TestFormatSubscriberStats(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	days := make([]models.SubscriberDay, 0, statsDays)
+	for idx := range statsDays {
+		days = append(days, models.SubscriberDay{Day: start.AddDate(0, 0, idx), Subscribers: 10, ActiveChats: 2})
+	}
+	days[0] = models.SubscriberDay{Day: start, Subscribers: 10, Subscribed: 10}
+	days[statsDays-1] = models.SubscriberDay{
+		Day: days[statsDays-1].Day, Subscribers: 9, Subscribed: 1, Unsubscribed: 2, ActiveChats: 4,
+	}
+
+	text := formatSubscriberStats(days)
+
+	assert.Contains(t, text, "📈 Subscribers: 9")
+	assert.Contains(t, text, "Last 30 days: +11 / -2, churn 0.0%, 2.0 active chats a day")
+	assert.Contains(t, text, "Last 7 days: +1 / -2, churn 20.0%, 2.3 active chats a day")
+	assert.Contains(t, text, "Mar 24: 10 subscribers, +0 / -0, 2 active")
+	assert.Contains(t, text, "Mar 30: 9 subscribers, +1 / -2, 4 active")
+	assert.NotContains(t, text, "Mar 23:")
+}
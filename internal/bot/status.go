@@ -0,0 +1,96 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+// statusHandler handles the /status command: it reports the availability of the target with its last
+// failed check and how the requests to the Telegram Bot API went, to tell a slow target from Telegram
+// throttling the bot.
+func (b *Bot) statusHandler(ctx telebot.Context) error {
+	const (
+		day   = 24 * time.Hour
+		month = 30 * day
+	)
+	chatID := ctx.Chat().ID
+	repoCtx := context.Background()
+
+	now := time.Now()
+	monthly, err := b.repo.GetUptimeStats(repoCtx, now.Add(-month))
+	if err != nil {
+		b.log.Error("Failed to get uptime stats", "chatID", chatID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to get the status.")
+		return nil
+	}
+
+	daily, err := b.repo.GetUptimeStats(repoCtx, now.Add(-day))
+	if err != nil {
+		b.log.Error("Failed to get uptime stats", "chatID", chatID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to get the status.")
+		return nil
+	}
+
+	var builder strings.Builder
+	builder.WriteString("📊 Status")
+	if b.target != "" {
+		builder.WriteString(" of " + b.target)
+	}
+	builder.WriteString("\n")
+
+	if monthly.Fetches == 0 {
+		builder.WriteString("No checks were recorded yet.")
+		b.sendMessage(ctx, chatID, builder.String()+b.apiStatus())
+		return nil
+	}
+
+	fmt.Fprintf(&builder, "Uptime: %.1f%% over 30 days, %.1f%% over 24 hours\n", monthly.Uptime(), daily.Uptime())
+	fmt.Fprintf(&builder, "Average fetch: %s\n", monthly.AvgLatency.Round(time.Millisecond))
+	if monthly.LastSuccess.IsZero() {
+		builder.WriteString("Last successful check: never")
+	} else {
+		fmt.Fprintf(&builder, "Last successful check: %s (%s ago)",
+			monthly.LastSuccess.Local().Format(time.DateTime), now.Sub(monthly.LastSuccess).Round(time.Second))
+	}
+
+	checkErr, err := b.repo.GetCheckError(repoCtx)
+	if err != nil {
+		b.log.Error("Failed to get the last check error", "chatID", chatID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to get the status.")
+		return nil
+	}
+	if checkErr != nil {
+		builder.WriteString("\n" + formatCheckError(checkErr))
+	}
+	b.sendMessage(ctx, chatID, builder.String()+b.apiStatus())
+
+	return nil
+}
+
+// formatCheckError describes the last failed check of a target: how long it has been failing, or when it
+// last failed if it recovered since.
+func formatCheckError(checkErr *models.CheckError) string {
+	const layout = "2006-01-02 15:04"
+	if checkErr.Failing() {
+		return fmt.Sprintf("🔴 Failing since %s (%d checks in a row), %s error: %s",
+			checkErr.FailingSince.Local().Format(layout), checkErr.Failures, checkErr.Class, checkErr.Message)
+	}
+
+	return fmt.Sprintf("Last error: %s, %s error: %s",
+		checkErr.LastFailure.Local().Format(layout), checkErr.Class, checkErr.Message)
+}
+
+// apiStatus returns the line of /status about the requests to the Telegram Bot API, empty if the bot
+// isn't connected to it.
+func (b *Bot) apiStatus() string {
+	if b.transport == nil {
+		return ""
+	}
+
+	return "\n" + b.transport.stats.summary()
+}
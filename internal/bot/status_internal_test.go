@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusHandler(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(-100)
+
+	t.Run("reports uptime", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetUptimeStats", mock.Anything, mock.Anything).Return(&models.UptimeStats{
+			Fetches:     1000,
+			Successful:  992,
+			AvgLatency:  840 * time.Millisecond,
+			LastSuccess: time.Now().Add(-5 * time.Minute),
+		}, nil).Once()
+		mockRepo.On("GetUptimeStats", mock.Anything, mock.Anything).Return(&models.UptimeStats{
+			Fetches:    10,
+			Successful: 10,
+		}, nil).Once()
+		mockRepo.On("GetCheckError", mock.Anything).Return(&models.CheckError{
+			Class:        "fetch",
+			Message:      "TLS handshake timeout",
+			FailingSince: time.Date(2026, 10, 16, 2, 14, 0, 0, time.Local),
+			LastFailure:  time.Date(2026, 10, 16, 8, 59, 0, 0, time.Local),
+			Failures:     3,
+		}, nil).Once()
+		transport := newAPITransport()
+		transport.observe("sendMessage", apiResultOK, 100*time.Millisecond)
+		transport.observe("sendMessage", apiResultThrottled, 20*time.Millisecond)
+		transport.stats.retries.Add(1)
+		testBot := Bot{
+			log:          slog.Default(),
+			repo:         mockRepo,
+			target:       "https://example.com",
+			allowedChats: map[int64]bool{chatID: true},
+			transport:    transport,
+		}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.statusHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "Status of https://example.com")
+		assert.Contains(t, api.sent[0], "Uptime: 99.2% over 30 days, 100.0% over 24 hours")
+		assert.Contains(t, api.sent[0], "Average fetch: 840ms")
+		assert.Contains(t, api.sent[0], "(5m0s ago)")
+		assert.Contains(t, api.sent[0],
+			"🔴 Failing since 2026-10-16 02:14 (3 checks in a row), fetch error: TLS handshake timeout")
+		assert.Contains(t, api.sent[0],
+			"Telegram API: 2 requests, average 60ms, 0 failed, 1 throttled (429), 1 retries")
+	})
+
+	t.Run("no checks yet", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetUptimeStats", mock.Anything, mock.Anything).Return(&models.UptimeStats{}, nil).Twice()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.statusHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "No checks were recorded yet.")
+	})
+
+	t.Run("error: repository failure", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetUptimeStats", mock.Anything, mock.Anything).Return(nil, assert.AnError).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.statusHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "Failed to get the status")
+	})
+}
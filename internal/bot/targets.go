@@ -0,0 +1,114 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"gopkg.in/telebot.v4"
+)
+
+// targetUsage explains the /target command.
+const targetUsage = "Usage: /target enable|disable <name>"
+
+// targetsHandler handles the /targets command: it lists the targets of the tenant with
+// whether they are checked, the outcome of their last check and their last failed check.
+func (b *Bot) targetsHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+
+	statuses, err := b.repo.GetTargetStatuses(context.Background())
+	if err != nil {
+		b.log.Error("Failed to get targets", "chatID", chatID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to get the targets.")
+		return nil
+	}
+
+	b.sendMessage(ctx, chatID, formatTargets(statuses, time.Now()))
+
+	return nil
+}
+
+// targetHandler handles the admin /target enable|disable <name> command: it resumes or pauses
+// the checks of an additional target without touching its data.
+func (b *Bot) targetHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+	repoCtx := context.Background()
+
+	if b.targets == nil {
+		b.sendMessage(ctx, chatID, "🤷 Managing targets from the bot isn't available.")
+		return nil
+	}
+
+	action, name, _ := strings.Cut(strings.TrimSpace(ctx.Data()), " ")
+	name = strings.TrimSpace(name)
+	if (action != "enable" && action != "disable") || name == "" {
+		b.sendMessage(ctx, chatID, targetUsage)
+		return nil
+	}
+
+	disabled := action == "disable"
+	err := b.repo.SetTargetDisabled(repoCtx, name, disabled)
+	var target *models.Target
+	if err == nil && !disabled {
+		target, err = b.repo.GetTarget(repoCtx, name)
+	}
+
+	switch {
+	case errors.Is(err, models.ErrMainTarget):
+		b.sendMessage(ctx, chatID, "⚠️ The main target can't be disabled.")
+	case errors.Is(err, repository.ErrTargetNotFound):
+		b.sendMessage(ctx, chatID, fmt.Sprintf("🤷 There is no target named %s.", name))
+	case err != nil:
+		b.log.Error("Failed to toggle target", "chatID", chatID, "target", name, "action", action, "err", err)
+		b.sendMessage(ctx, chatID, fmt.Sprintf("⛔ An internal error occurred. Failed to %s the target.", action))
+	case disabled:
+		b.targets.Unschedule(name)
+		b.log.Info("Target disabled", "chatID", chatID, "target", name)
+		b.sendMessage(ctx, chatID, fmt.Sprintf("⏸ Target %s disabled, its data is kept.", name))
+	default:
+		b.targets.Schedule(*target)
+		b.log.Info("Target enabled", "chatID", chatID, "target", name)
+		b.sendMessage(ctx, chatID, fmt.Sprintf("▶️ Target %s enabled, it's checked every %s.",
+			name, target.Interval))
+	}
+
+	return nil
+}
+
+// formatTargets describes the targets with the outcome of their last check and their last failed check.
+func formatTargets(statuses []models.TargetStatus, now time.Time) string {
+	if len(statuses) == 0 {
+		return "🎯 No targets are stored yet."
+	}
+
+	var builder strings.Builder
+	builder.WriteString("🎯 Targets")
+	for _, status := range statuses {
+		state := fmt.Sprintf("▶️ every %s", status.Interval)
+		if status.Disabled {
+			state = "⏸ disabled"
+		}
+		fmt.Fprintf(&builder, "\n\n%s (%s)\n🔗 %s\n", status.Name, state, status.URL)
+
+		check := status.LastCheck
+		switch {
+		case check.FetchedAt.IsZero():
+			builder.WriteString("Last check: never")
+		case check.Success:
+			fmt.Fprintf(&builder, "Last check: ✅ %s ago in %s",
+				now.Sub(check.FetchedAt).Round(time.Second), check.Latency.Round(time.Millisecond))
+		default:
+			fmt.Fprintf(&builder, "Last check: ⚠️ %s ago, %s",
+				now.Sub(check.FetchedAt).Round(time.Second), check.Error)
+		}
+		if status.LastError != nil {
+			builder.WriteString("\n" + formatCheckError(status.LastError))
+		}
+	}
+
+	return builder.String()
+}
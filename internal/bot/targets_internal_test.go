@@ -0,0 +1,161 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargetsHandler(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(7)
+
+	t.Run("targets are listed with their last check", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetTargetStatuses", mock.Anything).Return([]models.TargetStatus{
+			{
+				Target:    models.Target{Name: "main", URL: "https://example.com", Interval: time.Hour},
+				LastCheck: models.FetchRecord{FetchedAt: time.Now(), Latency: time.Second, Success: true},
+				LastError: &models.CheckError{
+					Class:       "parse",
+					Message:     "no products found",
+					LastFailure: time.Date(2026, 10, 15, 23, 5, 0, 0, time.Local),
+				},
+			},
+			{
+				Target:    models.Target{Name: "outlet", URL: "https://example.com/outlet", Disabled: true},
+				LastCheck: models.FetchRecord{FetchedAt: time.Now(), Error: "status 503"},
+				LastError: &models.CheckError{
+					Class:        "fetch",
+					Message:      "status 503",
+					FailingSince: time.Date(2026, 10, 16, 2, 14, 0, 0, time.Local),
+					Failures:     2,
+				},
+			},
+		}, nil).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.targetsHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Contains(t, api.sent[0], "main (▶️ every 1h0m0s)")
+		assert.Contains(t, api.sent[0], "Last check: ✅")
+		assert.Contains(t, api.sent[0], "outlet (⏸ disabled)")
+		assert.Contains(t, api.sent[0], "status 503")
+		assert.Contains(t, api.sent[0], "Last error: 2026-10-15 23:05, parse error: no products found")
+		assert.Contains(t, api.sent[0],
+			"🔴 Failing since 2026-10-16 02:14 (2 checks in a row), fetch error: status 503")
+	})
+
+	t.Run("unauthorized chat is refused", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), repo: mocks.NewRepository(t)}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.route("/targets", accessAllowed, testBot.targetsHandler)(ctx))
+		assert.Contains(t, api.sent[0], "private")
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetTargetStatuses", mock.Anything).Return(nil, assert.AnError).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.targetsHandler(ctx))
+		assert.Contains(t, api.sent[0], "internal error")
+	})
+}
+
+func TestTargetHandler(t *testing.T) {
+	t.Parallel()
+
+	const adminID = int64(42)
+	outlet := models.Target{Name: "outlet", URL: "https://example.com/outlet", Interval: time.Hour}
+
+	t.Run("target is disabled", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("SetTargetDisabled", mock.Anything, "outlet", true).Return(nil).Once()
+		manager := mocks.NewTargetManager(t)
+		manager.On("Unschedule", "outlet").Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, adminChats: map[int64]bool{adminID: true}, targets: manager}
+		ctx, api := newTestContext(adminID, "disable outlet")
+
+		require.NoError(t, testBot.targetHandler(ctx))
+		assert.Contains(t, api.sent[0], "Target outlet disabled")
+	})
+
+	t.Run("target is enabled", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("SetTargetDisabled", mock.Anything, "outlet", false).Return(nil).Once()
+		mockRepo.On("GetTarget", mock.Anything, "outlet").Return(&outlet, nil).Once()
+		manager := mocks.NewTargetManager(t)
+		manager.On("Schedule", outlet).Once()
+		testBot := Bot{log: slog.Default(), repo: mockRepo, adminChats: map[int64]bool{adminID: true}, targets: manager}
+		ctx, api := newTestContext(adminID, "enable outlet")
+
+		require.NoError(t, testBot.targetHandler(ctx))
+		assert.Contains(t, api.sent[0], "Target outlet enabled")
+	})
+
+	testCases := []struct {
+		name     string
+		payload  string
+		err      error
+		expected string
+	}{
+		{
+			name: "main target is kept", payload: "disable main", err: models.ErrMainTarget,
+			expected: "can't be disabled",
+		},
+		{
+			name: "unknown target", payload: "disable missing", err: repository.ErrTargetNotFound,
+			expected: "no target named missing",
+		},
+		{name: "internal error", payload: "enable outlet", err: assert.AnError, expected: "Failed to enable"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := mocks.NewRepository(t)
+			mockRepo.On("SetTargetDisabled", mock.Anything, mock.Anything, mock.Anything).Return(tc.err).Once()
+			testBot := Bot{
+				log: slog.Default(), repo: mockRepo, adminChats: map[int64]bool{adminID: true},
+				targets: mocks.NewTargetManager(t),
+			}
+			ctx, api := newTestContext(adminID, tc.payload)
+
+			require.NoError(t, testBot.targetHandler(ctx))
+			assert.Contains(t, api.sent[0], tc.expected)
+		})
+	}
+
+	for _, payload := range []string{"", "pause outlet", "disable"} {
+		testBot := Bot{
+			log: slog.Default(), repo: mocks.NewRepository(t), adminChats: map[int64]bool{adminID: true},
+			targets: mocks.NewTargetManager(t),
+		}
+		ctx, api := newTestContext(adminID, payload)
+
+		require.NoError(t, testBot.targetHandler(ctx))
+		assert.Equal(t, targetUsage, api.sent[0], "payload %q", payload)
+	}
+}
@@ -0,0 +1,219 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/metrics"
+	"gopkg.in/telebot.v4"
+)
+
+const (
+	// apiTimeout limits a request to the Telegram Bot API with its retries, like the default client of
+	// telebot does.
+	apiTimeout = time.Minute
+	// apiRetries is how many times a request throttled by Telegram is retried.
+	apiRetries = 3
+	// apiMaxRetryWait is the longest wait a throttled request is retried after, a request told to wait
+	// longer fails with the flood error of telebot.
+	apiMaxRetryWait = 30 * time.Second
+	// pollMethod is the method of the long polls of the updates, they take as long as the poll timeout.
+	pollMethod = "getUpdates"
+)
+
+// ErrNotifyThrottled is returned for a message Telegram kept throttling after the retries of apiTransport,
+// it wraps the flood error of telebot. The message wasn't sent, it may be sent again later.
+var ErrNotifyThrottled = errors.New("throttled by Telegram")
+
+// Results of the requests to the Telegram Bot API in the metrics.
+const (
+	apiResultOK        = "ok"
+	apiResultRejected  = "rejected"
+	apiResultThrottled = "throttled"
+	apiResultError     = "error"
+)
+
+// apiTransport observes every request the bot makes to the Telegram Bot API: the sends, the edits, the
+// callback answers and the polls of the updates. A request Telegram throttles with a 429 is retried once
+// the time it asks for passed, so a burst doesn't lose messages.
+type apiTransport struct {
+	next http.RoundTripper
+	// metrics observe the requests, nil disables them. It's set before the bot starts.
+	metrics *metrics.Metrics
+	// wait pauses before a retry, it reports false if the request was canceled meanwhile.
+	wait func(req *http.Request, delay time.Duration) bool
+
+	stats apiStats
+}
+
+// apiStats are the totals of the requests since the start shown by /status. The long polls of the
+// updates aren't counted, their latency is the poll timeout.
+type apiStats struct {
+	requests  atomic.Int64
+	failed    atomic.Int64
+	throttled atomic.Int64
+	retries   atomic.Int64
+	latency   atomic.Int64
+}
+
+// newAPITransport returns the transport of the requests to the Telegram Bot API.
+func newAPITransport() *apiTransport {
+	return &apiTransport{next: http.DefaultTransport, wait: waitRetry}
+}
+
+// RoundTrip sends the request and retries it while Telegram throttles it.
+func (t *apiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := apiMethod(req.URL.Path)
+
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		resp, err := t.next.RoundTrip(req)
+		took := time.Since(start)
+
+		result := apiResultOK
+		switch {
+		case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+			result = apiResultError
+		case resp.StatusCode == http.StatusTooManyRequests:
+			result = apiResultThrottled
+		case resp.StatusCode >= http.StatusBadRequest:
+			result = apiResultRejected
+		}
+		t.observe(method, result, took)
+
+		if result != apiResultThrottled || attempt == apiRetries || (req.Body != nil && req.GetBody == nil) {
+			return resp, err //nolint:wrapcheck // telebot wraps the errors of the client.
+		}
+
+		delay, resp := retryAfter(resp)
+		if delay > apiMaxRetryWait {
+			return resp, nil
+		}
+		if req, err = rewind(req); err != nil {
+			return resp, nil //nolint:nilerr // the throttled response is returned as it is.
+		}
+		if !t.wait(req, delay) {
+			return resp, nil
+		}
+
+		_ = resp.Body.Close()
+		t.stats.retries.Add(1)
+		if t.metrics != nil {
+			t.metrics.TelegramRetries.WithLabelValues(method).Inc()
+		}
+	}
+}
+
+// observe counts the request in the metrics and, unless it's a poll of the updates, in the stats.
+func (t *apiTransport) observe(method, result string, took time.Duration) {
+	if t.metrics != nil {
+		t.metrics.TelegramRequests.WithLabelValues(method, result).Inc()
+		t.metrics.TelegramRequestDuration.WithLabelValues(method).Observe(took.Seconds())
+	}
+	if method == pollMethod {
+		return
+	}
+
+	t.stats.requests.Add(1)
+	t.stats.latency.Add(int64(took))
+	switch result {
+	case apiResultThrottled:
+		t.stats.throttled.Add(1)
+	case apiResultError:
+		t.stats.failed.Add(1)
+	}
+}
+
+// summary describes the requests to the Telegram Bot API since the start for /status.
+func (s *apiStats) summary() string {
+	requests := s.requests.Load()
+	if requests == 0 {
+		return "Telegram API: no requests yet"
+	}
+
+	return fmt.Sprintf("Telegram API: %d requests, average %s, %d failed, %d throttled (429), %d retries",
+		requests, (time.Duration(s.latency.Load()) / time.Duration(requests)).Round(time.Millisecond),
+		s.failed.Load(), s.throttled.Load(), s.retries.Load())
+}
+
+// throttled wraps the error of a message Telegram throttled with ErrNotifyThrottled, other errors are
+// returned as they are.
+func throttled(err error) error {
+	var flood telebot.FloodError
+	if !errors.As(err, &flood) {
+		return err
+	}
+
+	return fmt.Errorf("%w, retry after %ds: %w", ErrNotifyThrottled, flood.RetryAfter, err)
+}
+
+// apiMethod returns the method of the Bot API the URL path calls, e.g. "sendMessage" for
+// /bot<token>/sendMessage. The downloads of files are "file", the path never leaks the token.
+func apiMethod(path string) string {
+	if strings.HasPrefix(path, "/file/") {
+		return "file"
+	}
+	if idx := strings.LastIndexByte(path, '/'); idx >= 0 && idx < len(path)-1 {
+		return path[idx+1:]
+	}
+
+	return "unknown"
+}
+
+// retryAfter reads the seconds Telegram asks to wait for from the throttled response. The body is read,
+// so the response returned has a copy of it for telebot.
+func retryAfter(resp *http.Response) (time.Duration, *http.Response) {
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return apiMaxRetryWait + 1, resp
+	}
+
+	var answer struct {
+		Parameters struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+	}
+	if err = json.Unmarshal(body, &answer); err != nil {
+		return apiMaxRetryWait + 1, resp
+	}
+
+	return time.Duration(answer.Parameters.RetryAfter) * time.Second, resp
+}
+
+// rewind returns a copy of the request with a fresh body to send it again.
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.GetBody == nil {
+		return req, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewind the request body: %w", err)
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+
+	return clone, nil
+}
+
+// waitRetry waits for the delay unless the request is canceled first.
+func waitRetry(req *http.Request, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-req.Context().Done():
+		return false
+	}
+}
@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+// newAPIServer answers the requests with the statuses and bodies in turn, and records the request bodies.
+func newAPIServer(t *testing.T, answers ...string) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		answer := answers[len(bodies)-1]
+		if strings.Contains(answer, `"error_code":429`) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+		_, _ = w.Write([]byte(answer))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &bodies
+}
+
+func TestAPITransport(t *testing.T) {
+	t.Parallel()
+
+	throttled := func(retryAfter int) string {
+		return fmt.Sprintf(`{"ok":false,"error_code":429,"description":"Too Many Requests",`+
+			`"parameters":{"retry_after":%d}}`, retryAfter)
+	}
+
+	newTransport := func(appMetrics *metrics.Metrics, waited *[]time.Duration) *apiTransport {
+		transport := newAPITransport()
+		transport.metrics = appMetrics
+		transport.wait = func(_ *http.Request, delay time.Duration) bool {
+			*waited = append(*waited, delay)
+			return true
+		}
+
+		return transport
+	}
+
+	t.Run("throttled requests are retried", func(t *testing.T) {
+		t.Parallel()
+
+		server, bodies := newAPIServer(t, throttled(2), `{"ok":true}`)
+		appMetrics := metrics.New()
+		var waited []time.Duration
+		client := &http.Client{Transport: newTransport(appMetrics, &waited)}
+
+		resp, err := client.Post(server.URL+"/bot123:secret/sendMessage", "application/json",
+			strings.NewReader(`{"text":"hi"}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, []string{`{"text":"hi"}`, `{"text":"hi"}`}, *bodies, "the retry sends the same body")
+		assert.Equal(t, []time.Duration{2 * time.Second}, waited)
+		requests := appMetrics.TelegramRequests
+		assert.InDelta(t, 1, testutil.ToFloat64(requests.WithLabelValues("sendMessage", apiResultThrottled)), 0)
+		assert.InDelta(t, 1, testutil.ToFloat64(requests.WithLabelValues("sendMessage", apiResultOK)), 0)
+		assert.InDelta(t, 1, testutil.ToFloat64(appMetrics.TelegramRetries.WithLabelValues("sendMessage")), 0)
+	})
+
+	t.Run("long waits aren't retried", func(t *testing.T) {
+		t.Parallel()
+
+		answer := throttled(120)
+		server, bodies := newAPIServer(t, answer)
+		var waited []time.Duration
+		transport := newTransport(nil, &waited)
+		client := &http.Client{Transport: transport}
+
+		resp, err := client.Post(server.URL+"/bot123:secret/editMessageText", "application/json",
+			strings.NewReader(`{}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, answer, string(body), "telebot gets the throttled answer")
+		assert.Len(t, *bodies, 1)
+		assert.Empty(t, waited)
+		assert.Contains(t, transport.stats.summary(), "1 requests")
+		assert.Contains(t, transport.stats.summary(), "1 throttled (429), 0 retries")
+	})
+
+	t.Run("the polls of the updates aren't in the stats", func(t *testing.T) {
+		t.Parallel()
+
+		server, _ := newAPIServer(t, `{"ok":true,"result":[]}`)
+		appMetrics := metrics.New()
+		var waited []time.Duration
+		transport := newTransport(appMetrics, &waited)
+		client := &http.Client{Transport: transport}
+
+		resp, err := client.Post(server.URL+"/bot123:secret/getUpdates", "application/json", strings.NewReader(`{}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, "Telegram API: no requests yet", transport.stats.summary())
+		assert.InDelta(t, 1,
+			testutil.ToFloat64(appMetrics.TelegramRequests.WithLabelValues("getUpdates", apiResultOK)), 0)
+	})
+}
+
+func TestAPIMethod(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "sendMessage", apiMethod("/bot123:secret/sendMessage"))
+	assert.Equal(t, "file", apiMethod("/file/bot123:secret/photos/file_1.jpg"))
+	assert.Equal(t, "unknown", apiMethod("/"))
+}
+
+func TestThrottled(t *testing.T) {
+	t.Parallel()
+
+	err := throttled(fmt.Errorf("send: %w", telebot.FloodError{RetryAfter: 30}))
+	require.ErrorIs(t, err, ErrNotifyThrottled)
+	assert.ErrorContains(t, err, "retry after 30s")
+
+	require.NotErrorIs(t, throttled(assert.AnError), ErrNotifyThrottled)
+	assert.NoError(t, throttled(nil))
+}
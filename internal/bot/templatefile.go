@@ -0,0 +1,248 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+// templateUnique routes the callbacks of the buttons approving the templates of the template file.
+const templateUnique = "templates"
+
+// Data of the buttons of a template preview, followed by the version of the templates it shows.
+const (
+	templateApprove = "approve"
+	templateReject  = "reject"
+)
+
+// templateFilePoll is how often the template file is checked for changes.
+const templateFilePoll = 30 * time.Second
+
+// templateFile is the watched file of templates, see WithTemplateFile.
+type templateFile struct {
+	path string
+	// base are the templates the ones of the file override.
+	base map[string]string
+	// done stops watching the file.
+	done chan struct{}
+
+	// The fields below are guarded by the templateMu of the bot.
+
+	// modTime is the modification time of the file when it was last loaded.
+	modTime time.Time
+	// pending are the templates of the file waiting for the approval of an admin, nil if none are.
+	pending *Templates
+	// version counts the versions of the file previewed, a preview approves the version it shows only.
+	version int
+}
+
+// ReadTemplateFile reads the templates by change kind from the JSON file, e.g. {"added": "✅ {{ref .}}\n"}.
+func ReadTemplateFile(path string) (map[string]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the template file: %w", err)
+	}
+
+	var sources map[string]string
+	if err = json.Unmarshal(raw, &sources); err != nil {
+		return nil, fmt.Errorf("failed to parse the template file: %w", err)
+	}
+
+	return sources, nil
+}
+
+// WithTemplateFile watches the template file, see ReadTemplateFile, whose templates override the base ones.
+// A changed file is rendered with the last changes sent and previewed in the admin chats, its templates are
+// used for the notifications once an admin approved them. A file whose templates fail to parse or to render
+// is rejected. The templates of the file at the start are set with WithTemplates.
+func WithTemplateFile(path string, base map[string]string) Option {
+	return func(b *Bot) {
+		file := &templateFile{path: path, base: base, done: make(chan struct{})}
+		if info, err := os.Stat(path); err == nil {
+			file.modTime = info.ModTime()
+		}
+		b.templateFile = file
+	}
+}
+
+// watchTemplateFile checks the template file for changes until it's stopped.
+func (b *Bot) watchTemplateFile() {
+	ticker := time.NewTicker(templateFilePoll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.checkTemplateFile(context.Background())
+		case <-b.templateFile.done:
+			return
+		}
+	}
+}
+
+// checkTemplateFile previews the templates of the template file in the admin chats if the file changed.
+func (b *Bot) checkTemplateFile(ctx context.Context) {
+	file := b.templateFile
+	info, err := os.Stat(file.path)
+	if err != nil {
+		b.log.WarnContext(ctx, "Failed to check the template file", "path", file.path, "err", err)
+		return
+	}
+
+	b.templateMu.Lock()
+	changed := !info.ModTime().Equal(file.modTime)
+	file.modTime = info.ModTime()
+	changes := b.lastChanges
+	b.templateMu.Unlock()
+	if !changed {
+		return
+	}
+
+	header := "🆕 The template file changed. The notifications use the new templates once approved, " +
+		"this is the last notification rendered with them:\n\n"
+	if changes == nil {
+		header = "🆕 The template file changed. The notifications use the new templates once approved, " +
+			"no changes were sent since the start, so sample ones are rendered with them:\n\n"
+		changes = sampleChanges()
+	}
+
+	templates, err := b.loadTemplateFile(changes)
+	if err != nil {
+		b.log.WarnContext(ctx, "Rejected the templates of the template file", "path", file.path, "err", err)
+		for chatID := range b.adminChats {
+			b.sendAdminMessage(ctx, chatID, fmt.Sprintf("⚠️ The changed template file was rejected: %v", err))
+		}
+		return
+	}
+
+	b.templateMu.Lock()
+	file.pending = templates
+	file.version++
+	version := strconv.Itoa(file.version)
+	b.templateMu.Unlock()
+
+	b.log.InfoContext(ctx, "Previewing the templates of the template file", "path", file.path, "version", version)
+	markup := &telebot.ReplyMarkup{}
+	markup.Inline(markup.Row(
+		markup.Data("✅ Approve", templateUnique, templateApprove+"|"+version),
+		markup.Data("❌ Reject", templateUnique, templateReject+"|"+version),
+	))
+	preview := header + b.formatChanges(changes, nil, templates)
+	for chatID := range b.adminChats {
+		opts := &telebot.SendOptions{ParseMode: telebot.ModeMarkdown, ReplyMarkup: markup}
+		if _, err = b.sendTo(ctx, chatID, preview, opts); err != nil {
+			b.log.WarnContext(ctx, "Failed to send the template preview", "chatID", chatID, "err", err)
+			b.sendAdminMessage(ctx, chatID,
+				fmt.Sprintf("⚠️ The changed template file can't be previewed, Telegram refused it: %v", err))
+		}
+	}
+}
+
+// loadTemplateFile parses the templates of the template file and renders every change with them.
+func (b *Bot) loadTemplateFile(changes *models.Changes) (*Templates, error) {
+	sources, err := ReadTemplateFile(b.templateFile.path)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := maps.Clone(b.templateFile.base)
+	if merged == nil {
+		merged = make(map[string]string, len(sources))
+	}
+	maps.Copy(merged, sources)
+	templates, err := NewTemplates(merged)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = templates.check(changes); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// sendAdminMessage sends a plain message to the admin chat, a failure is logged.
+func (b *Bot) sendAdminMessage(ctx context.Context, chatID int64, text string) {
+	if _, err := b.sendTo(ctx, chatID, text); err != nil {
+		b.log.ErrorContext(ctx, "Failed to send a message to an admin chat", "chatID", chatID, "err", err)
+	}
+}
+
+// templateCallback handles a press of a button of a template preview: an approval replaces the templates
+// of the notifications with the previewed ones, a rejection drops them. An outdated preview does neither.
+func (b *Bot) templateCallback(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+	action, arg, _ := strings.Cut(ctx.Callback().Data, "|")
+
+	b.templateMu.Lock()
+	file := b.templateFile
+	if file == nil || file.pending == nil || arg != strconv.Itoa(file.version) {
+		b.templateMu.Unlock()
+		return b.respond(ctx, "These templates are outdated or were decided on already.")
+	}
+	approved := action == templateApprove
+	if approved {
+		b.templates = file.pending
+	}
+	file.pending = nil
+	b.templateMu.Unlock()
+
+	if !approved {
+		b.log.Info("Template file rejected", "by", chatID)
+		b.sendMessage(ctx, chatID, "❌ The new templates were rejected, the notifications keep the current ones.")
+		return b.respond(ctx, "")
+	}
+
+	b.log.Info("Template file approved", "by", chatID)
+	b.sendMessage(ctx, chatID, "✅ The new templates were approved, the next notifications use them.")
+
+	return b.respond(ctx, "")
+}
+
+// rememberChanges keeps the changes sent to the subscribers, the changed template files are previewed with
+// them. Simulated changes aren't real, so they aren't kept.
+func (b *Bot) rememberChanges(ctx context.Context, changes *models.Changes) {
+	if b.templateFile == nil || isSimulation(ctx) {
+		return
+	}
+
+	b.templateMu.Lock()
+	b.lastChanges = changes
+	b.templateMu.Unlock()
+}
+
+// check renders every change with the template of its kind and returns the first error.
+func (t *Templates) check(changes *models.Changes) error {
+	for _, product := range changes.Added {
+		if _, err := t.render(export.KindAdded, product); err != nil {
+			return err
+		}
+	}
+	for _, change := range changes.Changed {
+		if _, err := t.render(export.KindChanged, change); err != nil {
+			return err
+		}
+	}
+	for _, change := range changes.Renamed {
+		if _, err := t.render(export.KindRenamed, change); err != nil {
+			return err
+		}
+	}
+	for _, product := range changes.Removed {
+		if _, err := t.render(export.KindRemoved, product); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package bot
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+// writeTemplateFile writes the template file and moves its modification time forward, so every write is
+// seen as a change.
+func writeTemplateFile(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestReadTemplateFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "templates.json")
+	writeTemplateFile(t, path, `{"removed": "🗑 {{.Model}}"}`, time.Now())
+
+	sources, err := ReadTemplateFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"removed": "🗑 {{.Model}}"}, sources)
+
+	writeTemplateFile(t, path, `["removed"]`, time.Now())
+	_, err = ReadTemplateFile(path)
+	require.ErrorContains(t, err, "failed to parse the template file")
+
+	_, err = ReadTemplateFile(filepath.Join(t.TempDir(), "missing.json"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestCheckTemplateFile(t *testing.T) {
+	t.Parallel()
+
+	const adminID = int64(42)
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	changes := &models.Changes{Removed: []models.Product{{Model: "A1", Type: "Diver"}}}
+
+	newTestBot := func(t *testing.T, api API) (*Bot, string) {
+		t.Helper()
+
+		path := filepath.Join(t.TempDir(), "templates.json")
+		writeTemplateFile(t, path, `{}`, start)
+		testBot := &Bot{bot: api, log: slog.Default(), adminChats: map[int64]bool{adminID: true}}
+		WithTemplateFile(path, map[string]string{"added": "🆕 {{ref .}}"})(testBot)
+		testBot.rememberChanges(t.Context(), changes)
+
+		return testBot, path
+	}
+
+	t.Run("an unchanged file is left alone", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, _ := newTestBot(t, mocks.NewAPI(t))
+
+		testBot.checkTemplateFile(t.Context())
+		assert.Nil(t, testBot.templateFile.pending)
+	})
+
+	t.Run("changed templates are used once approved", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockBot.On("Send", &telebot.Chat{ID: adminID}, mock.MatchedBy(func(text string) bool {
+			return strings.HasPrefix(text, "🆕 The template file changed") && strings.Contains(text, "🗑 A1")
+		}), mock.MatchedBy(func(opts *telebot.SendOptions) bool {
+			return opts.ReplyMarkup != nil && len(opts.ReplyMarkup.InlineKeyboard) == 1
+		})).Return(&telebot.Message{}, nil).Once()
+		testBot, path := newTestBot(t, mockBot)
+		writeTemplateFile(t, path, `{"removed": "🗑 {{.Model}}"}`, start.Add(time.Minute))
+
+		testBot.checkTemplateFile(t.Context())
+		require.NotNil(t, testBot.templateFile.pending)
+		assert.NotContains(t, testBot.formatChangesMessage(changes, nil, variantA), "🗑 A1",
+			"the templates aren't used before they're approved")
+
+		ctx, api := newCallbackContext(adminID, "approve|1")
+		require.NoError(t, testBot.templateCallback(ctx))
+		assert.Contains(t, api.sent[0], "were approved")
+		assert.Contains(t, testBot.formatChangesMessage(changes, nil, variantA), "🗑 A1")
+		assert.Contains(t, testBot.formatChangesMessage(sampleChanges(), nil, variantA),
+			"🆕 [GA-2100-1A1](https://example.com/ga-2100-1a1)", "the templates of the file override the base ones")
+
+		ctx, api = newCallbackContext(adminID, "reject|1")
+		require.NoError(t, testBot.templateCallback(ctx))
+		assert.Empty(t, api.sent)
+		assert.Contains(t, api.answers[0].Text, "outdated")
+	})
+
+	t.Run("rejected templates are dropped", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockBot.On("Send", &telebot.Chat{ID: adminID}, mock.Anything, mock.Anything).
+			Return(&telebot.Message{}, nil).Once()
+		testBot, path := newTestBot(t, mockBot)
+		writeTemplateFile(t, path, `{"removed": "🗑 {{.Model}}"}`, start.Add(time.Minute))
+		testBot.checkTemplateFile(t.Context())
+
+		ctx, api := newCallbackContext(adminID, "reject|1")
+		require.NoError(t, testBot.templateCallback(ctx))
+		assert.Contains(t, api.sent[0], "were rejected")
+		assert.Nil(t, testBot.templateFile.pending)
+		assert.NotContains(t, testBot.formatChangesMessage(changes, nil, variantA), "🗑 A1")
+	})
+
+	t.Run("templates failing on the last changes are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockBot.On("Send", &telebot.Chat{ID: adminID}, mock.MatchedBy(func(text string) bool {
+			return strings.HasPrefix(text, "⚠️ The changed template file was rejected")
+		})).Return(&telebot.Message{}, nil).Once()
+		testBot, path := newTestBot(t, mockBot)
+		// The sample changes render, the last changes sent don't.
+		writeTemplateFile(t, path, `{"removed": "{{if eq .Model \"SNK809K2\"}}ok{{else}}{{.Missing}}{{end}}"}`,
+			start.Add(time.Minute))
+
+		testBot.checkTemplateFile(t.Context())
+		assert.Nil(t, testBot.templateFile.pending)
+	})
+
+	t.Run("invalid templates are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockBot.On("Send", &telebot.Chat{ID: adminID}, mock.MatchedBy(func(text string) bool {
+			return strings.HasPrefix(text, "⚠️ The changed template file was rejected")
+		})).Return(&telebot.Message{}, nil).Once()
+		testBot, path := newTestBot(t, mockBot)
+		writeTemplateFile(t, path, `{"removed": "{{.Model"}`, start.Add(time.Minute))
+
+		testBot.checkTemplateFile(t.Context())
+		assert.Nil(t, testBot.templateFile.pending)
+	})
+}
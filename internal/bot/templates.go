@@ -0,0 +1,209 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+// ErrUnknownTemplateKind is returned for a template of a change kind that doesn't exist.
+var ErrUnknownTemplateKind = errors.New("unknown template kind")
+
+// Templates render the notification line of every change kind. Added and removed templates get
+// a models.Product, changed and renamed ones get a models.ChangeInfo. The "ref" function renders
+// a product model with its link, "diff" renders the price, quantity and extra field changes and
+// "extra" returns an extra field of a product, e.g. {{extra . "warranty"}}. The price trend of a changed
+// product is added below its line.
+type Templates struct {
+	kinds map[string]*template.Template
+}
+
+// TemplateKinds returns the change kinds a template can be set for.
+func TemplateKinds() []string {
+	return []string{export.KindAdded, export.KindChanged, export.KindRenamed, export.KindRemoved}
+}
+
+// defaultTemplateSources returns the built-in template of every change kind.
+func defaultTemplateSources() map[string]string {
+	return map[string]string{
+		export.KindAdded:   "✅ {{ref .}}\n  *Price*: {{.Price}}, *Quantity*: {{.Quantity}}\n",
+		export.KindChanged: "🔄 {{ref .New}}\n{{diff .}}",
+		export.KindRenamed: "✏️ `{{.Old.Model}}` -> {{ref .New}}\n{{diff .}}",
+		export.KindRemoved: "❌ `{{.Model}}`\n",
+	}
+}
+
+// DefaultTemplates returns the built-in templates.
+func DefaultTemplates() *Templates {
+	sources := defaultTemplateSources()
+	templates := &Templates{kinds: make(map[string]*template.Template, len(sources))}
+	for kind, source := range sources {
+		templates.kinds[kind] = template.Must(newTemplate(kind, source))
+	}
+
+	return templates
+}
+
+// NewTemplates parses the templates overriding the built-in ones, keyed by change kind.
+// Every template is rendered with sample data, so mistakes are reported up front instead of
+// breaking a real notification.
+func NewTemplates(overrides map[string]string) (*Templates, error) {
+	sources := defaultTemplateSources()
+	for kind, source := range overrides {
+		if _, ok := sources[kind]; !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownTemplateKind, kind)
+		}
+		sources[kind] = source
+	}
+
+	templates := &Templates{kinds: make(map[string]*template.Template, len(sources))}
+	for kind, source := range sources {
+		tmpl, err := newTemplate(kind, source)
+		if err != nil {
+			return nil, err
+		}
+		templates.kinds[kind] = tmpl
+	}
+
+	sample := sampleChanges()
+	for _, kind := range TemplateKinds() {
+		if _, err := templates.render(kind, sampleData(sample, kind)); err != nil {
+			return nil, err
+		}
+	}
+
+	return templates, nil
+}
+
+// newTemplate parses the template of a change kind.
+func newTemplate(kind, source string) (*template.Template, error) {
+	tmpl, err := template.New(kind).Funcs(templateFuncs()).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s template: %w", kind, err)
+	}
+
+	return tmpl, nil
+}
+
+// templateFuncs returns the helper functions available in templates.
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"ref":   productRef,
+		"diff":  diffLines,
+		"extra": extraField,
+	}
+}
+
+// render executes the template of the change kind, the result always ends with a line break.
+func (t *Templates) render(kind string, data any) (string, error) {
+	var builder strings.Builder
+	if err := t.kinds[kind].Execute(&builder, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", kind, err)
+	}
+
+	line := builder.String()
+	if !strings.HasSuffix(line, "\n") {
+		line += "\n"
+	}
+
+	return line, nil
+}
+
+// extraField returns the value of the extra field of the product, empty if it has none.
+func extraField(product models.Product, field string) string {
+	return product.Extras[field]
+}
+
+// diffLines returns the price, quantity and extra field changes of a product, one per line.
+func diffLines(change models.ChangeInfo) string {
+	var builder strings.Builder
+	writeDiffLines(&builder, change)
+
+	return builder.String()
+}
+
+// sampleChanges returns changes of every kind used to preview and validate templates.
+func sampleChanges() *models.Changes {
+	return &models.Changes{
+		Added: []models.Product{{
+			Model: "GA-2100-1A1", Type: "Sport", Quantity: "3", Price: "4 299", Category: "new",
+			ProductURL: "https://example.com/ga-2100-1a1",
+		}},
+		Changed: []models.ChangeInfo{{
+			Old: models.Product{Model: "SRPD55K1", Type: "Diver", Quantity: "2", Price: "12 500"},
+			New: models.Product{Model: "SRPD55K1", Type: "Diver", Quantity: "1", Price: "11 900"},
+		}},
+		Renamed: []models.ChangeInfo{{
+			Old: models.Product{Model: "RA-AC0002S", Type: "Dress", Quantity: "1", Price: "9 800"},
+			New: models.Product{Model: "RA-AC0002S10B", Type: "Dress", Quantity: "1", Price: "9 800"},
+		}},
+		Removed: []models.Product{{Model: "SNK809K2", Type: "Field", Quantity: "1", Price: "5 600"}},
+	}
+}
+
+// sampleData returns the first sample change of the kind.
+func sampleData(changes *models.Changes, kind string) any {
+	switch kind {
+	case export.KindAdded:
+		return changes.Added[0]
+	case export.KindChanged:
+		return changes.Changed[0]
+	case export.KindRenamed:
+		return changes.Renamed[0]
+	default:
+		return changes.Removed[0]
+	}
+}
+
+// previewTemplateUsage explains the /previewtemplate command.
+const previewTemplateUsage = "Usage: /previewtemplate [added|changed|renamed|removed <template>]\n" +
+	"Without arguments the current templates are rendered with sample changes. " +
+	"With a kind and a template, the template is rendered without being applied."
+
+// previewTemplateHandler handles the admin /previewtemplate command: it renders sample changes with
+// the current templates, or with a candidate template for a single change kind.
+func (b *Bot) previewTemplateHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+
+	payload := strings.TrimSpace(ctx.Data())
+	if payload == "" {
+		b.sendPreview(ctx, chatID, b.formatChangesMessage(sampleChanges(), nil, variantA))
+		return nil
+	}
+
+	kind, source, _ := strings.Cut(payload, " ")
+	if !slices.Contains(TemplateKinds(), kind) || strings.TrimSpace(source) == "" {
+		b.sendMessage(ctx, chatID, previewTemplateUsage)
+		return nil
+	}
+
+	candidate, err := NewTemplates(map[string]string{kind: source})
+	if err != nil {
+		b.sendMessage(ctx, chatID, fmt.Sprintf("⚠️ Invalid template: %v", err))
+		return nil
+	}
+
+	line, err := candidate.render(kind, sampleData(sampleChanges(), kind))
+	if err != nil {
+		b.sendMessage(ctx, chatID, fmt.Sprintf("⚠️ Invalid template: %v", err))
+		return nil
+	}
+	b.sendPreview(ctx, chatID, line)
+
+	return nil
+}
+
+// sendPreview sends a rendered preview with the notification formatting. If Telegram rejects the
+// markup, the raw text is sent with the reason instead.
+func (b *Bot) sendPreview(ctx telebot.Context, chatID int64, text string) {
+	if err := ctx.Send(text, &telebot.SendOptions{ParseMode: telebot.ModeMarkdown}); err != nil {
+		b.log.Warn("Failed to send template preview", "chatID", chatID, "err", err)
+		b.sendMessage(ctx, chatID, fmt.Sprintf("⚠️ Telegram rejected the formatting: %v\n\n%s", err, text))
+	}
+}
@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTemplates(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults render the built-in format", func(t *testing.T) {
+		t.Parallel()
+
+		templates, err := NewTemplates(nil)
+		require.NoError(t, err)
+
+		line, err := templates.render("changed", models.ChangeInfo{
+			Old: models.Product{Model: "C1", Price: "100"},
+			New: models.Product{Model: "C1", Price: "90"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "🔄 `C1`\n  *Price*: 100 -> *90*\n", line)
+	})
+
+	t.Run("override gets a trailing line break", func(t *testing.T) {
+		t.Parallel()
+
+		templates, err := NewTemplates(map[string]string{"added": "🆕 {{.Model}} for {{.Price}}"})
+		require.NoError(t, err)
+
+		line, err := templates.render("added", models.Product{Model: "A1", Price: "100"})
+		require.NoError(t, err)
+		assert.Equal(t, "🆕 A1 for 100\n", line)
+	})
+
+	t.Run("extra fields are rendered and diffed", func(t *testing.T) {
+		t.Parallel()
+
+		templates, err := NewTemplates(map[string]string{
+			"changed": "{{.New.Model}} ({{extra .New \"ean\"}})\n{{diff .}}",
+		})
+		require.NoError(t, err)
+
+		line, err := templates.render("changed", models.ChangeInfo{
+			Old: models.Product{Model: "C1", Extras: map[string]string{"ean": "123", "warranty": "1y"}},
+			New: models.Product{Model: "C1", Extras: map[string]string{"ean": "123", "warranty": "2y"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "C1 (123)\n  *warranty*: 1y -> *2y*\n", line)
+	})
+
+	testCases := []struct {
+		name      string
+		overrides map[string]string
+		errText   string
+	}{
+		{name: "unknown kind", overrides: map[string]string{"moved": "x"}, errText: ErrUnknownTemplateKind.Error()},
+		{name: "syntax error", overrides: map[string]string{"removed": "{{.Model"}, errText: "failed to parse removed"},
+		{name: "missing field", overrides: map[string]string{"added": "{{.Weight}}"}, errText: "failed to render added"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			templates, err := NewTemplates(tc.overrides)
+
+			require.ErrorContains(t, err, tc.errText)
+			assert.Nil(t, templates)
+		})
+	}
+}
+
+func TestFormatChangesMessage_Templates(t *testing.T) {
+	t.Parallel()
+
+	templates, err := NewTemplates(map[string]string{"removed": "🗑 {{.Model}} ({{.Type}})"})
+	require.NoError(t, err)
+	testBot := Bot{log: slog.Default(), templates: templates}
+
+	msg := testBot.formatChangesMessage(&models.Changes{
+		Added:   []models.Product{{Model: "A1", Type: "Diver", Price: "100", Quantity: "1"}},
+		Removed: []models.Product{{Model: "R1", Type: "Diver"}},
+	}, nil, variantA)
+
+	assert.Contains(t, msg, "🗑 R1 (Diver)\n")
+	assert.Contains(t, msg, "✅ `A1`\n  *Price*: 100, *Quantity*: 1\n")
+}
+
+func TestPreviewTemplateHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		chatID   int64
+		payload  string
+		expected string
+	}{
+		{name: "not an admin", chatID: 1, expected: "administrators only"},
+		{name: "current templates", chatID: testAdminID, expected: "❌ `SNK809K2`"},
+		{name: "candidate template", chatID: testAdminID, payload: "removed 🗑 {{.Model}}", expected: "🗑 SNK809K2\n"},
+		{name: "invalid candidate", chatID: testAdminID, payload: "removed {{.Model", expected: "Invalid template"},
+		{name: "unknown kind", chatID: testAdminID, payload: "moved {{.Model}}", expected: "Usage: /previewtemplate"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			testBot := newAccessTestBot(nil, nil)
+			ctx, api := newTestContext(tc.chatID, tc.payload)
+
+			require.NoError(t, testBot.route("/previewtemplate", accessAdmin, testBot.previewTemplateHandler)(ctx))
+			require.Len(t, api.sent, 1)
+			assert.Contains(t, api.sent[0], tc.expected)
+		})
+	}
+}
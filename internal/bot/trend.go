@@ -0,0 +1,132 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// trendWindow is the period the cumulative price change of a trend is shown for.
+const trendWindow = 7 * 24 * time.Hour
+
+// trendOrdinals name the consecutive price changes of a streak, longer streaks are counted.
+//
+//nolint:gochecknoglobals // a read-only list of words.
+var trendOrdinals = []string{
+	"", "first", "second", "third", "fourth", "fifth", "sixth", "seventh", "eighth", "ninth", "tenth",
+}
+
+// priceHistories returns the history of every changed product whose price changed to a number, for its
+// price trend and watermark. The histories only annotate the notifications, so a product whose history
+// can't be read is left out.
+func (b *Bot) priceHistories(
+	ctx context.Context,
+	changes *models.Changes,
+) map[models.ProductRef][]models.ChangeRecord {
+	histories := make(map[models.ProductRef][]models.ChangeRecord)
+	for _, change := range changes.Changed {
+		if change.Old.Price == change.New.Price {
+			continue
+		}
+		if _, err := change.New.PriceValue(); err != nil {
+			continue
+		}
+
+		ref := models.ProductRef{Category: change.New.Category, Model: change.New.Model}
+		history, err := b.repo.GetProductHistory(ctx, ref.Category, ref.Model)
+		if err != nil {
+			b.log.WarnContext(ctx, "Failed to get product history", "model", ref.Model, "err", err)
+			continue
+		}
+		histories[ref] = history
+	}
+
+	return histories
+}
+
+// priceTrends computes the price trend of every changed product with a history, see priceHistories. A
+// product without numeric prices to compare has no trend.
+func priceTrends(
+	changes *models.Changes,
+	histories map[models.ProductRef][]models.ChangeRecord,
+	now time.Time,
+) map[models.ProductRef]models.PriceTrend {
+	trends := make(map[models.ProductRef]models.PriceTrend)
+	for _, change := range changes.Changed {
+		ref := models.ProductRef{Category: change.New.Category, Model: change.New.Model}
+		history, ok := histories[ref]
+		if !ok {
+			continue
+		}
+		if trend := models.NewPriceTrend(history, change, now, trendWindow); trend.Direction != 0 {
+			trends[ref] = trend
+		}
+	}
+
+	return trends
+}
+
+// sustainedTrendsOnly leaves out the price changes that aren't part of a sustained trend. A change of
+// the quantity is still notified, as are prices without a trend because they aren't numbers.
+func sustainedTrendsOnly(
+	changes *models.Changes,
+	trends map[models.ProductRef]models.PriceTrend,
+) *models.Changes {
+	filtered := *changes
+	filtered.Changed = nil
+	for _, change := range changes.Changed {
+		trend, ok := trends[models.ProductRef{Category: change.New.Category, Model: change.New.Model}]
+		if ok && !trend.IsSustained() && change.Old.Quantity == change.New.Quantity {
+			continue
+		}
+		filtered.Changed = append(filtered.Changed, change)
+	}
+
+	return &filtered
+}
+
+// formatTrend describes the price trend of a product on a line of its own, e.g.
+// "↗️ third consecutive increase, +15% over 7 days".
+func formatTrend(trend models.PriceTrend) string {
+	if trend.Direction == 0 {
+		return ""
+	}
+
+	arrow, change := "↗️", "increase"
+	if trend.Direction < 0 {
+		arrow, change = "↘️", "decrease"
+	}
+
+	var parts []string
+	switch {
+	case trend.Streak >= len(trendOrdinals):
+		parts = append(parts, fmt.Sprintf("%d consecutive %ss", trend.Streak, change))
+	case trend.Streak >= models.SustainedStreak:
+		parts = append(parts, fmt.Sprintf("%s consecutive %s", trendOrdinals[trend.Streak], change))
+	}
+	if percent := formatPercent(trend.Percent); percent != "" {
+		days := "days"
+		if trend.Days == 1 {
+			days = "day"
+		}
+		parts = append(parts, fmt.Sprintf("%s over %d %s", percent, trend.Days, days))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("  %s %s\n", arrow, strings.Join(parts, ", "))
+}
+
+// formatPercent formats a price change with a sign and at most one decimal, empty if it rounds to zero.
+func formatPercent(percent float64) string {
+	formatted := strings.TrimSuffix(fmt.Sprintf("%+.1f", percent), ".0")
+	if formatted == "+0" || formatted == "-0" {
+		return ""
+	}
+
+	return formatted + "%"
+}
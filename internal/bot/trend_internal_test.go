@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceTrends(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	daysAgo := func(days int) time.Time { return now.Add(-time.Duration(days) * 24 * time.Hour) }
+	changed := func(model, oldPrice, price string) models.ChangeInfo {
+		return models.ChangeInfo{
+			Old: models.Product{Model: model, Category: "new", Price: oldPrice, Quantity: "1"},
+			New: models.Product{Model: model, Category: "new", Price: price, Quantity: "1"},
+		}
+	}
+
+	mockRepo := mocks.NewRepository(t)
+	// Rising for the third time, the latest change is already recorded.
+	mockRepo.On("GetProductHistory", mock.Anything, "new", "A1").Return([]models.ChangeRecord{
+		{DetectedAt: daysAgo(30), Kind: models.KindAdded, Price: "80"},
+		{DetectedAt: daysAgo(10), Kind: models.KindChanged, OldPrice: "80", Price: "100"},
+		{DetectedAt: daysAgo(5), Kind: models.KindChanged, OldPrice: "100", Price: "105", Quantity: "2"},
+		{DetectedAt: daysAgo(3), Kind: models.KindChanged, OldPrice: "105", Price: "105", Quantity: "1"},
+		{DetectedAt: now, Kind: models.KindChanged, OldPrice: "105", Price: "115"},
+	}, nil).Once()
+	// A jiggle back down, the latest change isn't recorded yet and the history starts with a change.
+	mockRepo.On("GetProductHistory", mock.Anything, "new", "B2").Return([]models.ChangeRecord{
+		{DetectedAt: daysAgo(2), Kind: models.KindChanged, OldPrice: "200", Price: "210"},
+	}, nil).Once()
+	mockRepo.On("GetProductHistory", mock.Anything, "new", "C3").Return(nil, assert.AnError).Once()
+	mockRepo.On("GetProductHistory", mock.Anything, "new", "D4").Return(nil, nil).Once()
+	testBot := Bot{log: slog.Default(), repo: mockRepo}
+
+	changes := &models.Changes{Changed: []models.ChangeInfo{
+		changed("A1", "105", "115"),
+		changed("B2", "210", "190"),
+		changed("C3", "1", "2"),
+		changed("D4", "on request", "300"),
+		{Old: models.Product{Model: "E5", Price: "50", Quantity: "1"}, New: models.Product{Model: "E5", Price: "50"}},
+	}}
+
+	trends := priceTrends(changes, testBot.priceHistories(t.Context(), changes), now)
+
+	require.Len(t, trends, 2, "only the products with a known history and numeric prices have a trend")
+	a1 := trends[models.ProductRef{Category: "new", Model: "A1"}]
+	assert.Equal(t, 1, a1.Direction)
+	assert.Equal(t, 3, a1.Streak)
+	assert.True(t, a1.IsSustained())
+	assert.InDelta(t, 15, a1.Percent, 0.001, "measured from the price at the start of the window")
+	assert.Equal(t, 7, a1.Days)
+
+	b2 := trends[models.ProductRef{Category: "new", Model: "B2"}]
+	assert.Equal(t, -1, b2.Direction)
+	assert.Equal(t, 1, b2.Streak)
+	assert.False(t, b2.IsSustained())
+	assert.InDelta(t, -5, b2.Percent, 0.001, "measured from the oldest price")
+	assert.Equal(t, 2, b2.Days)
+}
+
+func TestSustainedTrendsOnly(t *testing.T) {
+	t.Parallel()
+
+	changes := &models.Changes{
+		Added: []models.Product{{Model: "N1"}},
+		Changed: []models.ChangeInfo{
+			{Old: models.Product{Model: "A1", Price: "100"}, New: models.Product{Model: "A1", Price: "110"}},
+			{Old: models.Product{Model: "B2", Price: "200"}, New: models.Product{Model: "B2", Price: "190"}},
+			{
+				Old: models.Product{Model: "C3", Price: "300", Quantity: "1"},
+				New: models.Product{Model: "C3", Price: "310", Quantity: "2"},
+			},
+			{Old: models.Product{Model: "D4", Price: "n/a"}, New: models.Product{Model: "D4", Price: "400"}},
+		},
+	}
+	trends := map[models.ProductRef]models.PriceTrend{
+		{Model: "A1"}: {Direction: 1, Streak: 2},
+		{Model: "B2"}: {Direction: -1, Streak: 1},
+		{Model: "C3"}: {Direction: 1, Streak: 1},
+	}
+
+	filtered := sustainedTrendsOnly(changes, trends)
+
+	var kept []string
+	for _, change := range filtered.Changed {
+		kept = append(kept, change.New.Model)
+	}
+	assert.Equal(t, []string{"A1", "C3", "D4"}, kept)
+	assert.Equal(t, changes.Added, filtered.Added)
+	assert.Len(t, changes.Changed, 4, "the changes aren't modified")
+}
+
+func TestFormatTrend(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name  string
+		trend models.PriceTrend
+		want  string
+	}{
+		{"no change", models.PriceTrend{}, ""},
+		{
+			"third consecutive increase", models.PriceTrend{Direction: 1, Streak: 3, Percent: 15, Days: 7},
+			"  ↗️ third consecutive increase, +15% over 7 days\n",
+		},
+		{
+			"single step", models.PriceTrend{Direction: -1, Streak: 1, Percent: -4.25, Days: 1},
+			"  ↘️ -4.2% over 1 day\n",
+		},
+		{
+			"long streak", models.PriceTrend{Direction: -1, Streak: 12, Percent: -30, Days: 7},
+			"  ↘️ 12 consecutive decreases, -30% over 7 days\n",
+		},
+		{
+			"back where it was", models.PriceTrend{Direction: 1, Streak: 2, Percent: 0.01, Days: 7},
+			"  ↗️ second consecutive increase\n",
+		},
+		{"single step back", models.PriceTrend{Direction: 1, Streak: 1, Days: 3}, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, formatTrend(tc.trend))
+		})
+	}
+}
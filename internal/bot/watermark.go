@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+// watermarkPeriods are the periods in days a chat can measure the lows and highs of the prices over, 0 is
+// the whole history. The settings menu cycles through them.
+//
+//nolint:gochecknoglobals // a read-only list of options.
+var watermarkPeriods = []int{0, 30, 90}
+
+// priceWatermark is a changed product whose price hit a new low or high.
+type priceWatermark struct {
+	product   models.Product
+	watermark models.Watermark
+}
+
+// priceWatermarks returns the changed products with a history whose price hit a new low or high over the
+// period in days, see priceHistories.
+func priceWatermarks(
+	changes *models.Changes,
+	histories map[models.ProductRef][]models.ChangeRecord,
+	now time.Time,
+	days int,
+) []priceWatermark {
+	var watermarks []priceWatermark
+	for _, change := range changes.Changed {
+		history, ok := histories[models.ProductRef{Category: change.New.Category, Model: change.New.Model}]
+		if !ok {
+			continue
+		}
+		if watermark, found := models.NewWatermark(history, change, now, days); found {
+			watermarks = append(watermarks, priceWatermark{product: change.New, watermark: watermark})
+		}
+	}
+
+	return watermarks
+}
+
+// sendWatermarkAlerts alerts the subscribed chats that opted in of the prices that hit a new low or high
+// over the period of the chat. The alerts are messages of their own, sent to the topic of the chat and
+// filtered by its filter group, and always with sound: silencing the price changes doesn't silence them.
+func (b *Bot) sendWatermarkAlerts(
+	ctx context.Context,
+	br *broadcast,
+	changes *models.Changes,
+	histories map[models.ProductRef][]models.ChangeRecord,
+	subscribers []int64,
+	chatSettings map[int64]models.ChatSettings,
+) {
+	now := time.Now()
+	watermarks := make(map[int][]priceWatermark)
+	for _, chatID := range subscribers {
+		settings := chatSettings[chatID]
+		if !settings.Watermarks {
+			continue
+		}
+
+		found, computed := watermarks[settings.WatermarkDays]
+		if !computed {
+			found = priceWatermarks(changes, histories, now, settings.WatermarkDays)
+			watermarks[settings.WatermarkDays] = found
+		}
+		text := formatWatermarks(found, b.filterGroups[settings.FilterGroup])
+		if text == "" {
+			continue
+		}
+
+		opts := &telebot.SendOptions{ParseMode: telebot.ModeMarkdown, ThreadID: settings.ThreadID}
+		br.send(ctx, chatID, func(ctx context.Context) {
+			if _, err := b.sendTo(ctx, chatID, text, opts); err != nil {
+				b.log.ErrorContext(ctx, "Failed to send watermark alert", "chatID", chatID, "err", err)
+			}
+		})
+	}
+}
+
+// formatWatermarks describes the new lows and highs of the products with the types, all of them without
+// types. It's empty if none has one of the types.
+func formatWatermarks(watermarks []priceWatermark, types []string) string {
+	var lines []string
+	for _, found := range watermarks {
+		if len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool {
+			return strings.EqualFold(t, found.product.Type)
+		}) {
+			continue
+		}
+
+		icon, extreme, before := "📉", "low", "lowest"
+		if found.watermark.Direction > 0 {
+			icon, extreme, before = "📈", "high", "highest"
+		}
+		lines = append(lines, fmt.Sprintf("• %s `%s` hit %s %s: *%s* (%s before: %s)", icon, found.product.Model,
+			formatWatermarkPeriod(found.watermark.Days), extreme, found.product.Price, before,
+			formatAmount(found.watermark.Previous)))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return "🚨 *Price watermarks*\n" + strings.Join(lines, "\n")
+}
+
+// formatWatermarkPeriod names the period of a watermark with its article, e.g. "an all-time" or "a 30-day".
+func formatWatermarkPeriod(days int) string {
+	if days == 0 {
+		return "an all-time"
+	}
+
+	return fmt.Sprintf("a %d-day", days)
+}
+
+// nextWatermarkPeriod returns the setting after the current one in the settings menu: off, then every
+// watermarkPeriods in turn, then off again.
+func nextWatermarkPeriod(settings models.ChatSettings) (bool, int) {
+	if !settings.Watermarks {
+		return true, watermarkPeriods[0]
+	}
+	idx := slices.Index(watermarkPeriods, settings.WatermarkDays)
+	if idx < 0 || idx == len(watermarkPeriods)-1 {
+		return false, 0
+	}
+
+	return true, watermarkPeriods[idx+1]
+}
+
+// describeWatermarks describes the watermark setting of the chat.
+func describeWatermarks(settings models.ChatSettings) string {
+	switch {
+	case !settings.Watermarks:
+		return "off"
+	case settings.WatermarkDays == 0:
+		return "all-time lows and highs"
+	default:
+		return fmt.Sprintf("%d-day lows and highs", settings.WatermarkDays)
+	}
+}
@@ -0,0 +1,103 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceWatermarks(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	daysAgo := func(days int) time.Time { return now.Add(-time.Duration(days) * 24 * time.Hour) }
+	changed := func(model, oldPrice, price string) models.ChangeInfo {
+		return models.ChangeInfo{
+			Old: models.Product{Model: model, Category: "new", Price: oldPrice},
+			New: models.Product{Model: model, Category: "new", Price: price},
+		}
+	}
+	changes := &models.Changes{Changed: []models.ChangeInfo{
+		changed("A1", "100", "90"),
+		changed("B2", "200", "260"),
+		changed("C3", "100", "90"),
+		changed("D4", "100", "90"),
+		changed("E5", "100", "90"),
+	}}
+	histories := map[models.ProductRef][]models.ChangeRecord{
+		// The lowest price was long ago, the latest change is already recorded.
+		{Category: "new", Model: "A1"}: {
+			{DetectedAt: daysAgo(100), Kind: models.KindAdded, Price: "80"},
+			{DetectedAt: daysAgo(60), Kind: models.KindChanged, OldPrice: "80", Price: "120"},
+			{DetectedAt: daysAgo(10), Kind: models.KindChanged, OldPrice: "120", Price: "100"},
+			{DetectedAt: now, Kind: models.KindChanged, OldPrice: "100", Price: "90"},
+		},
+		{Category: "new", Model: "B2"}: {
+			{DetectedAt: daysAgo(50), Kind: models.KindChanged, OldPrice: "250", Price: "200"},
+		},
+		// A single earlier price isn't a history to break.
+		{Category: "new", Model: "C3"}: nil,
+		// Matching the lowest price isn't a new low.
+		{Category: "new", Model: "D4"}: {
+			{DetectedAt: daysAgo(5), Kind: models.KindChanged, OldPrice: "90", Price: "100"},
+		},
+	}
+
+	t.Run("all-time", func(t *testing.T) {
+		t.Parallel()
+
+		watermarks := priceWatermarks(changes, histories, now, 0)
+
+		require.Len(t, watermarks, 1)
+		assert.Equal(t, "B2", watermarks[0].product.Model)
+		assert.Equal(t, models.Watermark{Direction: 1, Previous: 250}, watermarks[0].watermark)
+	})
+
+	t.Run("over a period, the price at its start included", func(t *testing.T) {
+		t.Parallel()
+
+		watermarks := priceWatermarks(changes, histories, now, 30)
+
+		require.Len(t, watermarks, 1, "B2 had a single price over the period")
+		assert.Equal(t, "A1", watermarks[0].product.Model)
+		assert.Equal(t, models.Watermark{Direction: -1, Previous: 100, Days: 30}, watermarks[0].watermark)
+	})
+}
+
+func TestFormatWatermarks(t *testing.T) {
+	t.Parallel()
+
+	watermarks := []priceWatermark{
+		{
+			product:   models.Product{Model: "A1", Type: "Watch", Price: "1,099"},
+			watermark: models.Watermark{Direction: -1, Previous: 1150},
+		},
+		{
+			product:   models.Product{Model: "B2", Type: "Clock", Price: "250.5"},
+			watermark: models.Watermark{Direction: 1, Previous: 240.25, Days: 30},
+		},
+	}
+
+	assert.Equal(t, "🚨 *Price watermarks*\n"+
+		"• 📉 `A1` hit an all-time low: *1,099* (lowest before: 1150)\n"+
+		"• 📈 `B2` hit a 30-day high: *250.5* (highest before: 240.25)", formatWatermarks(watermarks, nil))
+	assert.Equal(t, "🚨 *Price watermarks*\n• 📈 `B2` hit a 30-day high: *250.5* (highest before: 240.25)",
+		formatWatermarks(watermarks, []string{"clock"}))
+	assert.Empty(t, formatWatermarks(watermarks, []string{"Ring"}))
+}
+
+func TestNextWatermarkPeriod(t *testing.T) {
+	t.Parallel()
+
+	settings := models.ChatSettings{}
+	var seen []string
+	for range len(watermarkPeriods) + 1 {
+		settings.Watermarks, settings.WatermarkDays = nextWatermarkPeriod(settings)
+		seen = append(seen, describeWatermarks(settings))
+	}
+
+	assert.Equal(t, []string{"all-time lows and highs", "30-day lows and highs", "90-day lows and highs", "off"}, seen)
+}
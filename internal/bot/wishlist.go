@@ -0,0 +1,164 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+// wishlistUsage explains the /wishlist command.
+const wishlistUsage = "Usage: /wishlist [add <model> | remove <model> | budget <amount> | clear]\n" +
+	"You are notified once the total price of the wishlist drops within the budget."
+
+// wishlistHandler handles the /wishlist command: it shows the wishlist of the chat with the current
+// prices of its products, and changes its products and budget.
+func (b *Bot) wishlistHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+	repoCtx := context.Background()
+
+	action, arg, _ := strings.Cut(strings.TrimSpace(ctx.Data()), " ")
+	arg = strings.TrimSpace(arg)
+
+	var err error
+	switch {
+	case action == "":
+	case strings.EqualFold(action, "add") && arg != "":
+		err = b.repo.AddWishlistItem(repoCtx, chatID, arg)
+	case strings.EqualFold(action, "remove") && arg != "":
+		var removed bool
+		if removed, err = b.repo.RemoveWishlistItem(repoCtx, chatID, arg); err == nil && !removed {
+			b.sendMessage(ctx, chatID, fmt.Sprintf("🤷 %s isn't on the wishlist.", arg))
+			return nil
+		}
+	case strings.EqualFold(action, "budget") && arg != "":
+		budget, parseErr := models.ParsePrice(arg)
+		if parseErr != nil || budget <= 0 {
+			b.sendMessage(ctx, chatID, "⚠️ The budget must be a positive amount.\n"+wishlistUsage)
+			return nil
+		}
+		err = b.repo.SetWishlistBudget(repoCtx, chatID, budget)
+	case strings.EqualFold(action, "clear"):
+		if err = b.repo.ClearWishlist(repoCtx, chatID); err == nil {
+			b.sendMessage(ctx, chatID, "🗑 The wishlist is cleared.")
+			return nil
+		}
+	default:
+		b.sendMessage(ctx, chatID, wishlistUsage)
+		return nil
+	}
+	if err != nil {
+		b.log.Error("Failed to update wishlist", "chatID", chatID, "action", action, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to update the wishlist.")
+		return nil
+	}
+
+	wishlist, err := b.repo.GetWishlist(repoCtx, chatID)
+	if err != nil {
+		b.log.Error("Failed to get wishlist", "chatID", chatID, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to load the wishlist.")
+		return nil
+	}
+
+	// The answer already tells whether the wishlist is within the budget.
+	if wishlist.WithinBudget() != wishlist.Notified {
+		if err = b.repo.SetWishlistNotified(repoCtx, chatID, wishlist.WithinBudget()); err != nil {
+			b.log.Warn("Failed to update wishlist notification", "chatID", chatID, "err", err)
+		}
+	}
+
+	if err = ctx.Send(formatWishlist(wishlist), telebot.ModeMarkdown); err != nil {
+		b.log.Error("Failed to send wishlist", "chatID", chatID, "err", err)
+	}
+
+	return nil
+}
+
+// CheckWishlists tells the chats whose wishlist total dropped within the budget. A chat is told once,
+// and again only after the total exceeded the budget in between. It's run after every check with changes.
+func (b *Bot) CheckWishlists(ctx context.Context) error {
+	const opn = "bot.CheckWishlists"
+
+	wishlists, err := b.repo.GetWishlists(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to get wishlists: %w", opn, err)
+	}
+
+	chatSettings, err := b.repo.GetChatSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to get chat settings: %w", opn, err)
+	}
+
+	for _, wishlist := range wishlists {
+		within := wishlist.WithinBudget()
+		if within == wishlist.Notified || (within && !b.isAllowed(wishlist.ChatID)) {
+			continue
+		}
+
+		if within {
+			text := "🎯 *Your wishlist dropped within the budget!*\n\n" + formatWishlist(&wishlist)
+			opts := &telebot.SendOptions{
+				ParseMode: telebot.ModeMarkdown,
+				ThreadID:  chatSettings[wishlist.ChatID].ThreadID,
+			}
+			if _, err = b.sendTo(ctx, wishlist.ChatID, text, opts); err != nil {
+				// The chat is told on the next check.
+				b.log.ErrorContext(ctx, "Failed to send wishlist notification", "chatID", wishlist.ChatID, "err", err)
+				continue
+			}
+		}
+
+		if err = b.repo.SetWishlistNotified(ctx, wishlist.ChatID, within); err != nil {
+			return fmt.Errorf("%s: failed to update wishlist: %w", opn, err)
+		}
+	}
+
+	return nil
+}
+
+// formatWishlist describes the products of the wishlist with their current prices and the total.
+func formatWishlist(wishlist *models.Wishlist) string {
+	if len(wishlist.Items) == 0 {
+		return "🛍 The wishlist is empty. Add a product with /wishlist add <model>."
+	}
+
+	var builder strings.Builder
+	builder.WriteString("🛍 *Wishlist*")
+	for _, item := range wishlist.Items {
+		switch {
+		case item.Price != nil:
+			fmt.Fprintf(&builder, "\n`%s`: %s", item.Model, formatAmount(*item.Price))
+		case item.Listed:
+			fmt.Fprintf(&builder, "\n`%s`: no price", item.Model)
+		default:
+			fmt.Fprintf(&builder, "\n`%s`: not listed", item.Model)
+		}
+	}
+
+	total, priced := wishlist.Total()
+	switch {
+	case !priced:
+		builder.WriteString("\n\n💰 Total: unknown until every product has a price")
+	case wishlist.Budget <= 0:
+		fmt.Fprintf(&builder, "\n\n💰 Total: *%s*, set a budget with /wishlist budget <amount>", formatAmount(total))
+	case wishlist.WithinBudget():
+		fmt.Fprintf(&builder, "\n\n💰 Total: *%s* ✅ within the budget of %s",
+			formatAmount(total), formatAmount(wishlist.Budget))
+	default:
+		fmt.Fprintf(&builder, "\n\n💰 Total: *%s*, %s over the budget of %s",
+			formatAmount(total), formatAmount(total-wishlist.Budget), formatAmount(wishlist.Budget))
+	}
+
+	return builder.String()
+}
+
+// formatAmount formats a price with at most two decimals.
+func formatAmount(amount float64) string {
+	const cents = 100
+
+	return strconv.FormatFloat(math.Round(amount*cents)/cents, 'f', -1, 64)
+}
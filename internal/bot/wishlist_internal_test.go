@@ -0,0 +1,187 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+func TestWishlistHandler(t *testing.T) {
+	t.Parallel()
+
+	const chatID = int64(-100)
+	price := func(value float64) *float64 { return &value }
+	wishlist := &models.Wishlist{ChatID: chatID, Budget: 1000, Items: []models.WishlistItem{
+		{Model: "A1", Listed: true, Price: price(600)},
+		{Model: "B2", Listed: true, Price: price(350.5)},
+	}}
+	newWishlistBot := func(t *testing.T) (*Bot, *mocks.Repository) {
+		t.Helper()
+
+		mockRepo := mocks.NewRepository(t)
+		return &Bot{log: slog.Default(), repo: mockRepo, allowedChats: map[int64]bool{chatID: true}}, mockRepo
+	}
+
+	t.Run("product is added", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, mockRepo := newWishlistBot(t)
+		mockRepo.On("AddWishlistItem", mock.Anything, chatID, "B2").Return(nil).Once()
+		mockRepo.On("GetWishlist", mock.Anything, chatID).Return(wishlist, nil).Once()
+		mockRepo.On("SetWishlistNotified", mock.Anything, chatID, true).Return(nil).Once()
+		ctx, api := newTestContext(chatID, "add  B2")
+
+		require.NoError(t, testBot.wishlistHandler(ctx))
+		require.Len(t, api.sent, 1)
+		assert.Equal(t, "🛍 *Wishlist*\n`A1`: 600\n`B2`: 350.5\n\n💰 Total: *950.5* ✅ within the budget of 1000",
+			api.sent[0])
+	})
+
+	t.Run("budget is set", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, mockRepo := newWishlistBot(t)
+		mockRepo.On("SetWishlistBudget", mock.Anything, chatID, 1200.5).Return(nil).Once()
+		mockRepo.On("GetWishlist", mock.Anything, chatID).Return(&models.Wishlist{ChatID: chatID}, nil).Once()
+		ctx, api := newTestContext(chatID, "budget 1 200,50")
+
+		require.NoError(t, testBot.wishlistHandler(ctx))
+		assert.Contains(t, api.sent[0], "The wishlist is empty")
+	})
+
+	t.Run("invalid budget", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, _ := newWishlistBot(t)
+		ctx, api := newTestContext(chatID, "budget cheap")
+
+		require.NoError(t, testBot.wishlistHandler(ctx))
+		assert.Contains(t, api.sent[0], "The budget must be a positive amount")
+	})
+
+	t.Run("product not on the wishlist", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, mockRepo := newWishlistBot(t)
+		mockRepo.On("RemoveWishlistItem", mock.Anything, chatID, "C3").Return(false, nil).Once()
+		ctx, api := newTestContext(chatID, "remove C3")
+
+		require.NoError(t, testBot.wishlistHandler(ctx))
+		assert.Equal(t, []interface{}{"🤷 C3 isn't on the wishlist."}, api.sent)
+	})
+
+	t.Run("wishlist is cleared", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, mockRepo := newWishlistBot(t)
+		mockRepo.On("ClearWishlist", mock.Anything, chatID).Return(nil).Once()
+		ctx, api := newTestContext(chatID, "clear")
+
+		require.NoError(t, testBot.wishlistHandler(ctx))
+		assert.Equal(t, []interface{}{"🗑 The wishlist is cleared."}, api.sent)
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, _ := newWishlistBot(t)
+		ctx, api := newTestContext(chatID, "add")
+
+		require.NoError(t, testBot.wishlistHandler(ctx))
+		assert.Equal(t, []interface{}{wishlistUsage}, api.sent)
+	})
+
+	t.Run("unauthorized chat", func(t *testing.T) {
+		t.Parallel()
+
+		testBot := Bot{log: slog.Default(), repo: mocks.NewRepository(t), allowedChats: map[int64]bool{}}
+		ctx, api := newTestContext(chatID, "")
+
+		require.NoError(t, testBot.route("/wishlist", accessAllowed, testBot.wishlistHandler)(ctx))
+		assert.Contains(t, api.sent[0], "this bot is private")
+	})
+
+	t.Run("error: cannot update the wishlist", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, mockRepo := newWishlistBot(t)
+		mockRepo.On("AddWishlistItem", mock.Anything, chatID, "A1").Return(assert.AnError).Once()
+		ctx, api := newTestContext(chatID, "add A1")
+
+		require.NoError(t, testBot.wishlistHandler(ctx))
+		assert.Contains(t, api.sent[0], "internal error")
+	})
+}
+
+func TestCheckWishlists(t *testing.T) {
+	t.Parallel()
+
+	price := func(value float64) *float64 { return &value }
+	items := []models.WishlistItem{{Model: "A1", Listed: true, Price: price(600)}}
+
+	t.Run("chats are told once the total drops within the budget", func(t *testing.T) {
+		t.Parallel()
+
+		mockBot := mocks.NewAPI(t)
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetWishlists", mock.Anything).Return([]models.Wishlist{
+			{ChatID: 1, Budget: 700, Items: items},
+			{ChatID: 2, Budget: 700, Items: items, Notified: true},
+			{ChatID: 3, Budget: 500, Items: items, Notified: true},
+			{ChatID: 4, Budget: 500, Items: items},
+			{ChatID: 5, Budget: 700, Items: items},
+			{ChatID: 6, Budget: 700, Items: items},
+		}, nil).Once()
+		mockRepo.On("GetChatSettings", mock.Anything).Return(map[int64]models.ChatSettings{
+			1: {ThreadID: 15},
+		}, nil).Once()
+		mockBot.On("Send", &telebot.Chat{ID: 1}, mock.MatchedBy(func(text string) bool {
+			return text == "🎯 *Your wishlist dropped within the budget!*\n\n"+
+				"🛍 *Wishlist*\n`A1`: 600\n\n💰 Total: *600* ✅ within the budget of 700"
+		}), markdownOpts(15)).Return(&telebot.Message{}, nil).Once()
+		mockBot.On("Send", &telebot.Chat{ID: 5}, mock.AnythingOfType("string"), markdownOpts(0)).
+			Return(nil, assert.AnError).Once()
+		mockRepo.On("SetWishlistNotified", mock.Anything, int64(1), true).Return(nil).Once()
+		mockRepo.On("SetWishlistNotified", mock.Anything, int64(3), false).Return(nil).Once()
+		testBot := Bot{
+			bot:          mockBot,
+			log:          slog.Default(),
+			repo:         mockRepo,
+			allowedChats: map[int64]bool{1: true, 2: true, 3: true, 4: true, 5: true},
+		}
+
+		require.NoError(t, testBot.CheckWishlists(t.Context()))
+	})
+
+	t.Run("error: cannot get wishlists", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := mocks.NewRepository(t)
+		mockRepo.On("GetWishlists", mock.Anything).Return(nil, assert.AnError).Once()
+		testBot := Bot{bot: mocks.NewAPI(t), log: slog.Default(), repo: mockRepo}
+
+		require.ErrorIs(t, testBot.CheckWishlists(t.Context()), assert.AnError)
+	})
+}
+
+func TestFormatWishlist(t *testing.T) {
+	t.Parallel()
+
+	price := func(value float64) *float64 { return &value }
+
+	assert.Equal(t, "🛍 *Wishlist*\n`A1`: 600\n`B2`: no price\n`C3`: not listed\n\n"+
+		"💰 Total: unknown until every product has a price",
+		formatWishlist(&models.Wishlist{Budget: 100, Items: []models.WishlistItem{
+			{Model: "A1", Listed: true, Price: price(600)}, {Model: "B2", Listed: true}, {Model: "C3"},
+		}}))
+	assert.Equal(t, "🛍 *Wishlist*\n`A1`: 600.1\n\n💰 Total: *600.1*, 100.1 over the budget of 500",
+		formatWishlist(&models.Wishlist{Budget: 500, Items: []models.WishlistItem{{Model: "A1", Price: price(600.1)}}}))
+	assert.Contains(t, formatWishlist(&models.Wishlist{Items: []models.WishlistItem{{Model: "A1", Price: price(1)}}}),
+		"set a budget with /wishlist budget <amount>")
+}
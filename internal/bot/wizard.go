@@ -0,0 +1,347 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/parser"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"gopkg.in/telebot.v4"
+)
+
+// TargetManager tests and schedules the targets added with the /addtarget wizard.
+type TargetManager interface {
+	// Probe fetches the page of the target and returns the products parsed from it.
+	Probe(ctx context.Context, target models.Target) ([]models.Product, error)
+	// Schedule starts monitoring a stored target.
+	Schedule(target models.Target)
+	// Unschedule stops monitoring the target with the name.
+	Unschedule(name string)
+}
+
+// WithTargetManager enables the /addtarget wizard, which tests new targets and schedules them with manager,
+// and the /removetarget and /target commands.
+func WithTargetManager(manager TargetManager) Option {
+	return func(b *Bot) {
+		b.targets = manager
+	}
+}
+
+// addTargetUnique routes the callbacks of the buttons confirming a new target.
+const addTargetUnique = "addtarget"
+
+// Actions of the buttons confirming a new target.
+const (
+	addTargetConfirm = "confirm"
+	addTargetCancel  = "cancel"
+)
+
+const (
+	// probeTimeout limits the test parse of a new target.
+	probeTimeout = time.Minute
+	// sampleSize is the number of parsed products shown before a new target is confirmed.
+	sampleSize = 5
+)
+
+// wizardStep is the answer a target wizard waits for.
+type wizardStep int
+
+const (
+	stepURL wizardStep = iota
+	stepSelector
+	stepColumns
+	stepInterval
+	stepConfirm
+)
+
+// targetWizard is the state of an /addtarget conversation in a chat.
+type targetWizard struct {
+	step   wizardStep
+	target models.Target
+}
+
+// addTargetUsage explains the /addtarget command.
+const addTargetUsage = "Usage: /addtarget <name>\nThe name may contain lowercase letters, digits and dashes. " +
+	"The bot then asks for the page, the table and the check interval. Send /cancel to stop."
+
+// addTargetHandler handles the admin /addtarget <name> command: it starts a conversation
+// that collects the settings of a new target, tests them and schedules the target.
+func (b *Bot) addTargetHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+
+	if b.targets == nil {
+		b.sendMessage(ctx, chatID, "🤷 Adding targets from the bot isn't available.")
+		return nil
+	}
+
+	name := strings.TrimSpace(ctx.Data())
+	if err := models.ValidateTargetName(name); err != nil {
+		b.sendMessage(ctx, chatID, addTargetUsage)
+		return nil
+	}
+
+	b.setWizard(chatID, &targetWizard{step: stepURL, target: models.Target{Name: name}})
+	b.sendMessage(ctx, chatID, fmt.Sprintf("🆕 Adding target %s.\n🔗 Send the URL of the page to monitor.", name))
+
+	return nil
+}
+
+// cancelHandler handles the /cancel command: it stops the wizard running in the chat.
+func (b *Bot) cancelHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+	if b.takeWizard(chatID) == nil {
+		b.sendMessage(ctx, chatID, "Nothing to cancel.")
+		return nil
+	}
+
+	b.sendMessage(ctx, chatID, "🚫 Adding the target was canceled.")
+
+	return nil
+}
+
+// wizardTextHandler handles plain text messages: in a chat running a target wizard they answer its
+// current question, other messages are ignored.
+func (b *Bot) wizardTextHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+	wizard := b.wizard(chatID)
+	if wizard == nil || !b.adminChats[chatID] {
+		return nil
+	}
+
+	answer := strings.TrimSpace(ctx.Text())
+	var err error
+	switch wizard.step {
+	case stepURL:
+		if err = models.ValidateTargetURL(answer); err == nil {
+			wizard.target.URL = answer
+			wizard.step = stepSelector
+			b.sendMessage(ctx, chatID, "📋 Send the CSS selector of the product table, or - for the default table.")
+		}
+	case stepSelector:
+		wizard.target.Tables = nil
+		if selector := optionalAnswer(answer); selector != "" {
+			wizard.target.Tables = []models.TargetTable{{Selector: selector}}
+		}
+		wizard.step = stepColumns
+		b.sendMessage(ctx, chatID, "🧩 Send the column mapping as field=selector pairs separated by semicolons, "+
+			"e.g. image=td:nth-child(4) img@src, or - to recognize the columns by the table header.\nFields: "+
+			strings.Join(parser.Fields(), ", "))
+	case stepColumns:
+		if wizard.target.ColumnSelectors, err = parseColumnMapping(optionalAnswer(answer)); err == nil {
+			wizard.step = stepInterval
+			b.sendMessage(ctx, chatID, "⏱ How often should the page be checked? E.g. 30m or 2h.")
+		}
+	case stepInterval:
+		if wizard.target.Interval, err = parseInterval(answer); err == nil {
+			b.probeTarget(ctx, wizard)
+		}
+	case stepConfirm:
+		b.sendMessage(ctx, chatID, "Use the buttons above to add the target, or send /cancel.")
+	}
+
+	if err != nil {
+		b.sendMessage(ctx, chatID, fmt.Sprintf("⚠️ %v\nTry again or send /cancel.", err))
+	}
+
+	return nil
+}
+
+// probeTarget runs a test parse of the target and asks to confirm it with a sample of the products.
+// If nothing can be parsed, the wizard goes back to the table selector.
+func (b *Bot) probeTarget(ctx telebot.Context, wizard *targetWizard) {
+	chatID := ctx.Chat().ID
+	b.sendMessage(ctx, chatID, "🔎 Testing the target...")
+
+	probeCtx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	products, err := b.targets.Probe(probeCtx, wizard.target)
+	if err == nil && len(products) == 0 {
+		err = errors.New("no products found")
+	}
+	if err != nil {
+		b.log.Warn("Target test parse failed", "chatID", chatID, "target", wizard.target.Name, "err", err)
+		wizard.step = stepSelector
+		b.sendMessage(ctx, chatID, fmt.Sprintf(
+			"⚠️ Test parse failed: %v\n📋 Send another table selector, - for the default table, or /cancel.", err,
+		))
+		return
+	}
+
+	wizard.step = stepConfirm
+	markup := &telebot.ReplyMarkup{}
+	markup.Inline(markup.Row(
+		markup.Data("✅ Add target", addTargetUnique, addTargetConfirm),
+		markup.Data("❌ Cancel", addTargetUnique, addTargetCancel),
+	))
+	if err = ctx.Send(formatSample(wizard.target, products), markup); err != nil {
+		b.log.Error("Failed to send target sample", "chatID", chatID, "err", err)
+	}
+}
+
+// addTargetCallback handles the buttons confirming a tested target: the target is stored and scheduled,
+// or the wizard is canceled.
+func (b *Bot) addTargetCallback(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+	wizard := b.wizard(chatID)
+	if wizard == nil || wizard.step != stepConfirm {
+		return b.respond(ctx, "This target was already handled.")
+	}
+	b.takeWizard(chatID)
+
+	text := "🚫 Adding the target was canceled."
+	if ctx.Callback().Data == addTargetConfirm {
+		text = b.saveTarget(chatID, wizard.target)
+	}
+	if err := ctx.Edit(text); err != nil {
+		b.log.Warn("Failed to update target confirmation", "chatID", chatID, "err", err)
+	}
+
+	return b.respond(ctx, "")
+}
+
+// saveTarget stores and schedules a confirmed target and returns the message reporting the result.
+func (b *Bot) saveTarget(chatID int64, target models.Target) string {
+	target.CreatedAt = time.Now()
+	err := b.repo.CreateTarget(context.Background(), target)
+	if errors.Is(err, repository.ErrTargetExists) {
+		return fmt.Sprintf("⚠️ A target named %s already exists.", target.Name)
+	}
+	if err != nil {
+		b.log.Error("Failed to save target", "chatID", chatID, "target", target.Name, "err", err)
+		return "⛔ An internal error occurred. Failed to save the target."
+	}
+
+	b.targets.Schedule(target)
+	b.log.Info("Target added", "chatID", chatID, "target", target.Name, "url", target.URL)
+
+	return fmt.Sprintf("✅ Target %s added, it's checked every %s.", target.Name, target.Interval)
+}
+
+// removeTargetUsage explains the /removetarget command.
+const removeTargetUsage = "Usage: /removetarget <name>"
+
+// removeTargetHandler handles the admin /removetarget <name> command: it stops monitoring
+// an additional target and deletes it with its data.
+func (b *Bot) removeTargetHandler(ctx telebot.Context) error {
+	chatID := ctx.Chat().ID
+
+	if b.targets == nil {
+		b.sendMessage(ctx, chatID, "🤷 Removing targets from the bot isn't available.")
+		return nil
+	}
+
+	name := strings.TrimSpace(ctx.Data())
+	if name == "" {
+		b.sendMessage(ctx, chatID, removeTargetUsage)
+		return nil
+	}
+
+	err := b.repo.DeleteTarget(context.Background(), name)
+	switch {
+	case errors.Is(err, models.ErrMainTarget):
+		b.sendMessage(ctx, chatID, "⚠️ The main target can't be removed.")
+	case errors.Is(err, repository.ErrTargetNotFound):
+		b.sendMessage(ctx, chatID, fmt.Sprintf("🤷 There is no target named %s.", name))
+	case err != nil:
+		b.log.Error("Failed to remove target", "chatID", chatID, "target", name, "err", err)
+		b.sendMessage(ctx, chatID, "⛔ An internal error occurred. Failed to remove the target.")
+	default:
+		b.targets.Unschedule(name)
+		b.log.Info("Target removed", "chatID", chatID, "target", name)
+		b.sendMessage(ctx, chatID, fmt.Sprintf("🗑 Target %s removed with its data.", name))
+	}
+
+	return nil
+}
+
+// formatSample describes a tested target with the first parsed products.
+func formatSample(target models.Target, products []models.Product) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "🎯 %s\n🔗 %s\n⏱ Every %s\n\n🔎 Found %d products:\n",
+		target.Name, target.URL, target.Interval, len(products))
+	for _, product := range products[:min(sampleSize, len(products))] {
+		fmt.Fprintf(&builder, "• %s (%s), price: %s, quantity: %s\n",
+			product.Model, product.Type, product.Price, product.Quantity)
+	}
+	if len(products) > sampleSize {
+		fmt.Fprintf(&builder, "… and %d more\n", len(products)-sampleSize)
+	}
+
+	return builder.String()
+}
+
+// optionalAnswer returns the answer, or an empty string if it's "-".
+func optionalAnswer(answer string) string {
+	if answer == "-" {
+		return ""
+	}
+
+	return answer
+}
+
+// parseColumnMapping parses "field=selector" pairs separated by semicolons.
+func parseColumnMapping(mapping string) (map[string]string, error) {
+	if mapping == "" {
+		return nil, nil //nolint:nilnil // without a mapping the columns are recognized by the table header.
+	}
+
+	selectors := make(map[string]string)
+	for pair := range strings.SplitSeq(mapping, ";") {
+		field, selector, ok := strings.Cut(pair, "=")
+		field, selector = strings.ToLower(strings.TrimSpace(field)), strings.TrimSpace(selector)
+		if !ok || selector == "" || !slices.Contains(parser.Fields(), field) {
+			return nil, fmt.Errorf("invalid column mapping %q, expected field=selector", strings.TrimSpace(pair))
+		}
+		selectors[field] = selector
+	}
+
+	return selectors, nil
+}
+
+// parseInterval parses the check interval of a target.
+func parseInterval(answer string) (time.Duration, error) {
+	interval, err := time.ParseDuration(answer)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval %q", answer)
+	}
+	if interval < models.MinTargetInterval {
+		return 0, fmt.Errorf("the interval must be at least %s", models.MinTargetInterval)
+	}
+
+	return interval, nil
+}
+
+// wizard returns the target wizard running in the chat, nil if there is none.
+func (b *Bot) wizard(chatID int64) *targetWizard {
+	b.wizardMu.Lock()
+	defer b.wizardMu.Unlock()
+
+	return b.wizards[chatID]
+}
+
+// setWizard starts a target wizard in the chat, replacing the running one.
+func (b *Bot) setWizard(chatID int64, wizard *targetWizard) {
+	b.wizardMu.Lock()
+	defer b.wizardMu.Unlock()
+
+	if b.wizards == nil {
+		b.wizards = make(map[int64]*targetWizard)
+	}
+	b.wizards[chatID] = wizard
+}
+
+// takeWizard stops the target wizard running in the chat and returns it.
+func (b *Bot) takeWizard(chatID int64) *targetWizard {
+	b.wizardMu.Lock()
+	defer b.wizardMu.Unlock()
+
+	wizard := b.wizards[chatID]
+	delete(b.wizards, chatID)
+
+	return wizard
+}
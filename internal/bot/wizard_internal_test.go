@@ -0,0 +1,209 @@
+package bot
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v4"
+)
+
+// newTextContext creates a handler context for a plain text message sent from chatID.
+func newTextContext(chatID int64, text string) (telebot.Context, *recordingAPI) {
+	api := &recordingAPI{}
+	update := telebot.Update{Message: &telebot.Message{Chat: &telebot.Chat{ID: chatID}, Text: text}}
+
+	return telebot.NewContext(api, update), api
+}
+
+func TestAddTargetWizard(t *testing.T) {
+	t.Parallel()
+
+	const adminID = int64(42)
+	expected := models.Target{
+		Name:            "outlet",
+		URL:             "https://example.com/outlet",
+		Tables:          []models.TargetTable{{Selector: "table.outlet"}},
+		ColumnSelectors: map[string]string{"image": "td:nth-child(4) img@src"},
+		Interval:        30 * time.Minute,
+	}
+	isExpected := func(target models.Target) bool {
+		target.CreatedAt = time.Time{}
+		return assert.ObjectsAreEqual(expected, target)
+	}
+
+	newWizardBot := func(t *testing.T) (*Bot, *mocks.Repository, *mocks.TargetManager) {
+		t.Helper()
+
+		mockRepo := mocks.NewRepository(t)
+		manager := mocks.NewTargetManager(t)
+
+		return &Bot{
+			log:        slog.Default(),
+			repo:       mockRepo,
+			adminChats: map[int64]bool{adminID: true},
+			targets:    manager,
+		}, mockRepo, manager
+	}
+
+	// answer sends the answers to the wizard and returns the last reply.
+	answer := func(t *testing.T, testBot *Bot, answers ...string) interface{} {
+		t.Helper()
+
+		var api *recordingAPI
+		for _, text := range answers {
+			var ctx telebot.Context
+			ctx, api = newTextContext(adminID, text)
+			require.NoError(t, testBot.wizardTextHandler(ctx))
+		}
+		require.NotEmpty(t, api.sent)
+
+		return api.sent[len(api.sent)-1]
+	}
+
+	t.Run("target is tested, confirmed and scheduled", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, mockRepo, manager := newWizardBot(t)
+		manager.On("Probe", mock.Anything, mock.MatchedBy(isExpected)).
+			Return([]models.Product{{Model: "A1", Type: "Diver", Price: "100", Quantity: "2"}}, nil).Once()
+		mockRepo.On("CreateTarget", mock.Anything, mock.MatchedBy(isExpected)).Return(nil).Once()
+		manager.On("Schedule", mock.MatchedBy(isExpected)).Once()
+
+		ctx, _ := newTestContext(adminID, "outlet")
+		require.NoError(t, testBot.addTargetHandler(ctx))
+
+		reply := answer(t, testBot,
+			"https://example.com/outlet", "table.outlet", "image=td:nth-child(4) img@src", "30m")
+		assert.Contains(t, reply, "A1 (Diver), price: 100, quantity: 2")
+
+		callbackCtx, callbackAPI := newCallbackContext(adminID, addTargetConfirm)
+		require.NoError(t, testBot.addTargetCallback(callbackCtx))
+		require.Len(t, callbackAPI.edited, 1)
+		assert.Contains(t, callbackAPI.edited[0], "Target outlet added")
+		assert.Nil(t, testBot.wizard(adminID))
+	})
+
+	t.Run("invalid answers are asked again", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, _, _ := newWizardBot(t)
+		ctx, _ := newTestContext(adminID, "outlet")
+		require.NoError(t, testBot.addTargetHandler(ctx))
+
+		assert.Contains(t, answer(t, testBot, "not a url"), "invalid target URL")
+		assert.Contains(t, answer(t, testBot, "https://example.com/outlet", "-", "price"), "invalid column mapping")
+		assert.Contains(t, answer(t, testBot, "-", "10s"), "at least 1m0s")
+	})
+
+	t.Run("failed test parse goes back to the table selector", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, _, manager := newWizardBot(t)
+		manager.On("Probe", mock.Anything, mock.Anything).Return(nil, nil).Once()
+		ctx, _ := newTestContext(adminID, "outlet")
+		require.NoError(t, testBot.addTargetHandler(ctx))
+
+		reply := answer(t, testBot, "https://example.com/outlet", "-", "-", "1h")
+
+		assert.Contains(t, reply, "no products found")
+		assert.Equal(t, stepSelector, testBot.wizard(adminID).step)
+	})
+
+	t.Run("existing target is reported", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, mockRepo, _ := newWizardBot(t)
+		mockRepo.On("CreateTarget", mock.Anything, mock.Anything).Return(repository.ErrTargetExists).Once()
+		testBot.setWizard(adminID, &targetWizard{step: stepConfirm, target: expected})
+
+		ctx, api := newCallbackContext(adminID, addTargetConfirm)
+		require.NoError(t, testBot.addTargetCallback(ctx))
+		require.Len(t, api.edited, 1)
+		assert.Contains(t, api.edited[0], "already exists")
+	})
+
+	t.Run("wizard is canceled", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, _, _ := newWizardBot(t)
+		testBot.setWizard(adminID, &targetWizard{target: expected})
+
+		ctx, api := newTestContext(adminID, "")
+		require.NoError(t, testBot.cancelHandler(ctx))
+		assert.Contains(t, api.sent[0], "canceled")
+		assert.Nil(t, testBot.wizard(adminID))
+	})
+
+	t.Run("wizard without a table selector uses the default table", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, _, manager := newWizardBot(t)
+		manager.On("Probe", mock.Anything, mock.MatchedBy(func(target models.Target) bool {
+			return target.Tables == nil && target.ColumnSelectors == nil
+		})).Return(nil, assert.AnError).Once()
+		ctx, _ := newTestContext(adminID, "outlet")
+		require.NoError(t, testBot.addTargetHandler(ctx))
+
+		assert.Contains(t, answer(t, testBot, "https://example.com/outlet", "-", "-", "1h"), "Test parse failed")
+	})
+
+	t.Run("non-admins can't add targets", func(t *testing.T) {
+		t.Parallel()
+
+		testBot, _, _ := newWizardBot(t)
+		ctx, api := newTestContext(1, "outlet")
+
+		require.NoError(t, testBot.route("/addtarget", accessAdmin, testBot.addTargetHandler)(ctx))
+		assert.Contains(t, api.sent[0], "administrators only")
+		assert.Nil(t, testBot.wizard(1))
+	})
+}
+
+func TestRemoveTargetHandler(t *testing.T) {
+	t.Parallel()
+
+	const adminID = int64(42)
+
+	testCases := []struct {
+		name      string
+		payload   string
+		deleteErr error
+		expected  string
+	}{
+		{name: "target is removed", payload: "outlet", expected: "Target outlet removed"},
+		{name: "main target is kept", payload: "main", deleteErr: models.ErrMainTarget, expected: "can't be removed"},
+		{
+			name: "unknown target", payload: "missing", deleteErr: repository.ErrTargetNotFound,
+			expected: "no target named missing",
+		},
+		{name: "internal error", payload: "outlet", deleteErr: assert.AnError, expected: "internal error"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := mocks.NewRepository(t)
+			mockRepo.On("DeleteTarget", mock.Anything, tc.payload).Return(tc.deleteErr).Once()
+			manager := mocks.NewTargetManager(t)
+			if tc.deleteErr == nil {
+				manager.On("Unschedule", tc.payload).Once()
+			}
+			testBot := Bot{
+				log: slog.Default(), repo: mockRepo, adminChats: map[int64]bool{adminID: true}, targets: manager,
+			}
+			ctx, api := newTestContext(adminID, tc.payload)
+
+			require.NoError(t, testBot.removeTargetHandler(ctx))
+			require.Len(t, api.sent, 1)
+			assert.Contains(t, api.sent[0], tc.expected)
+		})
+	}
+}
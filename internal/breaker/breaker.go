@@ -0,0 +1,111 @@
+package breaker
+
+import "time"
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// Closed lets every request through.
+	Closed State = iota
+	// Open rejects requests until the backoff elapses.
+	Open
+	// HalfOpen lets a single probe through to decide whether to close or reopen.
+	HalfOpen
+)
+
+// String returns the state name.
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker is a circuit breaker with exponential backoff between probes.
+// It isn't safe for concurrent use, the scheduler loop is its only user.
+type Breaker struct {
+	threshold   int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+
+	state    State
+	failures int
+	backoff  time.Duration
+	retryAt  time.Time
+}
+
+// New creates a breaker which opens after threshold consecutive failures.
+// The backoff starts at baseBackoff and doubles after every failed probe up to maxBackoff.
+// A threshold of zero or less disables the breaker.
+func New(threshold int, baseBackoff, maxBackoff time.Duration) *Breaker {
+	return &Breaker{
+		threshold:   threshold,
+		baseBackoff: baseBackoff,
+		maxBackoff:  max(maxBackoff, baseBackoff),
+	}
+}
+
+// Allow reports whether a request may be made at now.
+// An open breaker turns half-open once its backoff has elapsed.
+func (b *Breaker) Allow(now time.Time) bool {
+	if b.state == Open && !now.Before(b.retryAt) {
+		b.state = HalfOpen
+	}
+
+	return b.state != Open
+}
+
+// Success records a successful request and closes the breaker.
+// It reports whether the breaker was open before, i.e. the target recovered.
+func (b *Breaker) Success() bool {
+	recovered := b.state != Closed
+	b.state = Closed
+	b.failures = 0
+	b.backoff = 0
+
+	return recovered
+}
+
+// Failure records a failed request at now.
+// It reports whether the breaker has just opened after being closed.
+func (b *Breaker) Failure(now time.Time) bool {
+	b.failures++
+
+	switch {
+	case b.state == HalfOpen:
+		// The probe failed, wait longer before the next one.
+		b.backoff = min(b.backoff*2, b.maxBackoff)
+	case b.threshold > 0 && b.failures >= b.threshold:
+		b.backoff = b.baseBackoff
+	default:
+		return false
+	}
+
+	opened := b.state == Closed
+	b.state = Open
+	b.retryAt = now.Add(b.backoff)
+
+	return opened
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	return b.state
+}
+
+// Failures returns the number of consecutive failures.
+func (b *Breaker) Failures() int {
+	return b.failures
+}
+
+// RetryAt returns the time the next probe is allowed at when the breaker is open.
+func (b *Breaker) RetryAt() time.Time {
+	return b.retryAt
+}
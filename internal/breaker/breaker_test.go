@@ -0,0 +1,69 @@
+package breaker_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/breaker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("opens after threshold and backs off exponentially", func(t *testing.T) {
+		t.Parallel()
+
+		brk := breaker.New(2, time.Minute, 3*time.Minute)
+
+		assert.True(t, brk.Allow(start))
+		assert.False(t, brk.Failure(start))
+		assert.Equal(t, breaker.Closed, brk.State())
+
+		assert.True(t, brk.Failure(start), "second failure opens the breaker")
+		assert.Equal(t, breaker.Open, brk.State())
+		assert.False(t, brk.Allow(start.Add(30*time.Second)))
+
+		// The backoff elapsed, a single probe is allowed.
+		assert.True(t, brk.Allow(start.Add(time.Minute)))
+		assert.Equal(t, breaker.HalfOpen, brk.State())
+
+		// The probe fails, the backoff doubles and no new opening is reported.
+		probeAt := start.Add(time.Minute)
+		assert.False(t, brk.Failure(probeAt))
+		assert.Equal(t, probeAt.Add(2*time.Minute), brk.RetryAt())
+
+		// Another failed probe is capped by the maximum backoff.
+		assert.True(t, brk.Allow(brk.RetryAt()))
+		probeAt = brk.RetryAt()
+		brk.Failure(probeAt)
+		assert.Equal(t, probeAt.Add(3*time.Minute), brk.RetryAt())
+		assert.Equal(t, 4, brk.Failures())
+	})
+
+	t.Run("successful probe closes the breaker", func(t *testing.T) {
+		t.Parallel()
+
+		brk := breaker.New(1, time.Minute, time.Hour)
+		brk.Failure(start)
+		assert.True(t, brk.Allow(start.Add(time.Minute)))
+
+		assert.True(t, brk.Success(), "success after an outage reports recovery")
+		assert.Equal(t, breaker.Closed, brk.State())
+		assert.Zero(t, brk.Failures())
+		assert.False(t, brk.Success())
+	})
+
+	t.Run("zero threshold never opens", func(t *testing.T) {
+		t.Parallel()
+
+		brk := breaker.New(0, time.Minute, time.Hour)
+		for range 10 {
+			assert.False(t, brk.Failure(start))
+		}
+		assert.True(t, brk.Allow(start))
+		assert.Equal(t, breaker.Closed, brk.State())
+	})
+}
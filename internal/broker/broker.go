@@ -0,0 +1,153 @@
+// Package broker publishes change and run events to a message broker, so downstream consumers
+// can react to changes without depending on the bot.
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/rpc/chronoflowv1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Payload formats of the published events.
+const (
+	FormatJSON     = "json"
+	FormatProtobuf = "protobuf"
+)
+
+// Subjects the events are published to, relative to the configured prefix.
+const (
+	SubjectChanges = "changes"
+	SubjectRuns    = "runs"
+)
+
+// ErrUnknownFormat is returned for a payload format other than FormatJSON and FormatProtobuf.
+var ErrUnknownFormat = errors.New("unknown event format")
+
+// Transport delivers encoded events to a broker.
+type Transport interface {
+	// Publish sends the payload to the subject and waits until the broker accepts it.
+	Publish(ctx context.Context, subject string, payload []byte) error
+	// Close releases the connection to the broker.
+	Close() error
+}
+
+// Discard is a transport dropping every event, it's used when publishing is disabled.
+var Discard Transport = discard{} //nolint:gochecknoglobals // Stateless sentinel like io.Discard.
+
+type discard struct{}
+
+func (discard) Publish(context.Context, string, []byte) error { return nil }
+func (discard) Close() error                                  { return nil }
+
+// Publisher encodes events and publishes them with a transport. Events are the
+// chronoflow.v1 ChangeEvent and RunEvent messages, serialized as protobuf or as their
+// canonical JSON mapping, so both formats share one schema.
+type Publisher struct {
+	log       *slog.Logger
+	transport Transport
+	prefix    string
+	marshal   func(proto.Message) ([]byte, error)
+}
+
+// NewPublisher creates a publisher sending events to subjects under prefix in the given format.
+func NewPublisher(log *slog.Logger, transport Transport, prefix, format string) (*Publisher, error) {
+	publisher := &Publisher{log: log, transport: transport, prefix: prefix}
+
+	switch format {
+	case FormatJSON:
+		publisher.marshal = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal
+	case FormatProtobuf:
+		publisher.marshal = proto.Marshal
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+
+	return publisher, nil
+}
+
+// Subject returns the full subject of an event kind.
+func (p *Publisher) Subject(kind string) string {
+	if p.prefix == "" {
+		return kind
+	}
+
+	return p.prefix + "." + kind
+}
+
+// PublishChanges publishes the changes detected by a check.
+func (p *Publisher) PublishChanges(ctx context.Context, runID string, detectedAt time.Time, changes *models.Changes) {
+	p.publish(ctx, SubjectChanges, chronoflowv1.NewChangeEvent(runID, detectedAt, changes))
+}
+
+// PublishRunStarted publishes the start of a check.
+func (p *Publisher) PublishRunStarted(ctx context.Context, runID string, at time.Time) {
+	p.publish(ctx, SubjectRuns, &chronoflowv1.RunEvent{
+		RunId:  runID,
+		Status: chronoflowv1.RunEvent_STATUS_STARTED,
+		At:     timestamppb.New(at),
+	})
+}
+
+// PublishRunFinished publishes the outcome of a check: the number of changes it detected,
+// or the reason it failed.
+func (p *Publisher) PublishRunFinished(ctx context.Context, runID string, at time.Time, changes int, err error) {
+	event := &chronoflowv1.RunEvent{
+		RunId:   runID,
+		Status:  chronoflowv1.RunEvent_STATUS_SUCCEEDED,
+		At:      timestamppb.New(at),
+		Changes: int32(min(changes, math.MaxInt32)),
+	}
+	if err != nil {
+		event.Status = chronoflowv1.RunEvent_STATUS_FAILED
+		event.Error = err.Error()
+	}
+
+	p.publish(ctx, SubjectRuns, event)
+}
+
+// ForTenant returns a publisher sharing the transport that publishes the events of a tenant under
+// "<prefix>.<tenant>". The empty ID is the default tenant and returns the publisher itself.
+// Only the original publisher must be closed.
+func (p *Publisher) ForTenant(id string) *Publisher {
+	if id == "" {
+		return p
+	}
+
+	scoped := *p
+	scoped.prefix = p.Subject(id)
+
+	return &scoped
+}
+
+// Close closes the transport.
+func (p *Publisher) Close() error {
+	if err := p.transport.Close(); err != nil {
+		return fmt.Errorf("failed to close broker transport: %w", err)
+	}
+
+	return nil
+}
+
+// publish encodes and sends an event. Failures are logged, a broker outage must not fail the check.
+func (p *Publisher) publish(ctx context.Context, kind string, event proto.Message) {
+	subject := p.Subject(kind)
+
+	payload, err := p.marshal(event)
+	if err != nil {
+		p.log.ErrorContext(ctx, "failed to encode event", "subject", subject, "error", err)
+		return
+	}
+
+	if err = p.transport.Publish(ctx, subject, payload); err != nil {
+		p.log.ErrorContext(ctx, "failed to publish event", "subject", subject, "error", err)
+	}
+}
@@ -0,0 +1,137 @@
+package broker_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/broker"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/rpc/chronoflowv1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// message is a payload sent with the recording transport.
+type message struct {
+	subject string
+	payload []byte
+}
+
+// recordingTransport keeps the published messages instead of sending them.
+type recordingTransport struct {
+	messages []message
+	err      error
+}
+
+func (r *recordingTransport) Publish(_ context.Context, subject string, payload []byte) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.messages = append(r.messages, message{subject: subject, payload: payload})
+
+	return nil
+}
+
+func (r *recordingTransport) Close() error { return nil }
+
+func TestNewPublisher_UnknownFormat(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	_, err := broker.NewPublisher(logger, &recordingTransport{}, "chrono-flow", "avro")
+
+	require.ErrorIs(t, err, broker.ErrUnknownFormat)
+}
+
+func TestPublisher_JSON(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	transport := &recordingTransport{}
+	publisher, err := broker.NewPublisher(logger, transport, "chrono-flow", broker.FormatJSON)
+	require.NoError(t, err)
+	detectedAt := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	publisher.PublishChanges(t.Context(), "run-1", detectedAt, &models.Changes{
+		Added: []models.Product{{Model: "A1", Category: "new", ProductURL: "https://example.com/a1"}},
+	})
+	publisher.PublishRunFinished(t.Context(), "run-1", detectedAt, 1, nil)
+
+	require.Len(t, transport.messages, 2)
+	assert.Equal(t, "chrono-flow.changes", transport.messages[0].subject)
+	assert.Equal(t, "chrono-flow.runs", transport.messages[1].subject)
+
+	var changes struct {
+		RunID      string `json:"run_id"`
+		DetectedAt string `json:"detected_at"`
+		Changes    struct {
+			Added []struct {
+				Model      string `json:"model"`
+				ProductURL string `json:"product_url"`
+			} `json:"added"`
+			Removed []any `json:"removed"`
+		} `json:"changes"`
+	}
+	require.NoError(t, json.Unmarshal(transport.messages[0].payload, &changes))
+	assert.Equal(t, "run-1", changes.RunID)
+	assert.Equal(t, "2025-07-01T12:00:00Z", changes.DetectedAt)
+	require.Len(t, changes.Changes.Added, 1)
+	assert.Equal(t, "A1", changes.Changes.Added[0].Model)
+	assert.Equal(t, "https://example.com/a1", changes.Changes.Added[0].ProductURL)
+	assert.NotNil(t, changes.Changes.Removed)
+
+	var run map[string]any
+	require.NoError(t, json.Unmarshal(transport.messages[1].payload, &run))
+	assert.Equal(t, "STATUS_SUCCEEDED", run["status"])
+	assert.InDelta(t, 1, run["changes"], 0)
+}
+
+func TestPublisher_Protobuf(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	transport := &recordingTransport{}
+	publisher, err := broker.NewPublisher(logger, transport, "", broker.FormatProtobuf)
+	require.NoError(t, err)
+	at := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	publisher.PublishRunStarted(t.Context(), "run-2", at)
+	publisher.PublishRunFinished(t.Context(), "run-2", at, 0, assert.AnError)
+
+	require.Len(t, transport.messages, 2)
+	assert.Equal(t, "runs", transport.messages[0].subject)
+
+	var started, finished chronoflowv1.RunEvent
+	require.NoError(t, proto.Unmarshal(transport.messages[0].payload, &started))
+	require.NoError(t, proto.Unmarshal(transport.messages[1].payload, &finished))
+	assert.Equal(t, chronoflowv1.RunEvent_STATUS_STARTED, started.GetStatus())
+	assert.Equal(t, "run-2", started.GetRunId())
+	assert.True(t, at.Equal(started.GetAt().AsTime()))
+	assert.Equal(t, chronoflowv1.RunEvent_STATUS_FAILED, finished.GetStatus())
+	assert.Equal(t, assert.AnError.Error(), finished.GetError())
+}
+
+func TestPublisher_TransportError(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	publisher, err := broker.NewPublisher(logger, &recordingTransport{err: assert.AnError}, "cf", broker.FormatJSON)
+	require.NoError(t, err)
+
+	assert.NotPanics(t, func() {
+		publisher.PublishRunStarted(t.Context(), "run-3", time.Now())
+	})
+}
+
+func TestPublisher_ForTenant(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	transport := &recordingTransport{}
+	publisher, err := broker.NewPublisher(logger, transport, "chrono-flow", broker.FormatJSON)
+	require.NoError(t, err)
+
+	assert.Same(t, publisher, publisher.ForTenant(""))
+
+	publisher.ForTenant("acme").PublishRunStarted(t.Context(), "run-4", time.Now())
+
+	require.Len(t, transport.messages, 1)
+	assert.Equal(t, "chrono-flow.acme.runs", transport.messages[0].subject)
+	assert.Equal(t, "chrono-flow.runs", publisher.Subject(broker.SubjectRuns))
+}
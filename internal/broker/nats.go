@@ -0,0 +1,66 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATS publishes events to NATS JetStream.
+type NATS struct {
+	conn *nats.Conn
+	js   jetstream.JetStream
+}
+
+// NewNATS connects to the NATS server at url. If stream is not empty, a JetStream stream with
+// that name capturing every subject under prefix is created or updated, otherwise the stream is
+// expected to be managed outside of the application.
+func NewNATS(ctx context.Context, url, stream, prefix string) (*NATS, error) {
+	const reconnectWait = 2 * time.Second
+
+	conn, err := nats.Connect(url, nats.Name("chrono-flow"), nats.MaxReconnects(-1), nats.ReconnectWait(reconnectWait))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	if stream != "" {
+		subjects := []string{prefix + ".>"}
+		if prefix == "" {
+			subjects = []string{SubjectChanges, SubjectRuns}
+		}
+		_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{Name: stream, Subjects: subjects})
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to set up stream %s: %w", stream, err)
+		}
+	}
+
+	return &NATS{conn: conn, js: js}, nil
+}
+
+// Publish sends the payload to the subject and waits for the JetStream acknowledgement.
+func (n *NATS) Publish(ctx context.Context, subject string, payload []byte) error {
+	if _, err := n.js.Publish(ctx, subject, payload); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", subject, err)
+	}
+
+	return nil
+}
+
+// Close flushes pending messages and closes the connection.
+func (n *NATS) Close() error {
+	if err := n.conn.Drain(); err != nil {
+		return fmt.Errorf("failed to drain NATS connection: %w", err)
+	}
+
+	return nil
+}
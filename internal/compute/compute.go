@@ -0,0 +1,159 @@
+// Package compute derives the computed product fields, e.g. a price per unit or a margin flag, from the
+// parsed fields of the products and the data of an uploaded CSV file.
+package compute
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// ErrInvalidData is returned for a product data file without a model column or with a non-numeric value.
+var ErrInvalidData = errors.New("invalid product data")
+
+// dataModelColumn is the column of the product data file holding the models the rows belong to.
+const dataModelColumn = "model"
+
+// Field is a computed field stored in Product.Extras under its name.
+type Field struct {
+	Name       string
+	Expression *Expression
+}
+
+// Data holds the numeric columns of the product data file, e.g. a cost, by the lowercase models and
+// the lowercase column names.
+type Data map[string]map[string]float64
+
+// Columns returns the lowercase names of the data columns.
+func (d Data) Columns() map[string]bool {
+	columns := make(map[string]bool)
+	for _, row := range d {
+		for column := range row {
+			columns[column] = true
+		}
+	}
+
+	return columns
+}
+
+// LoadData reads the product data from a CSV file with a header row. The "model" column matches the
+// rows to the products, the values of the other columns must be numbers, an empty one is left out.
+func LoadData(r io.Reader) (Data, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidData, err)
+	}
+	if len(records) == 0 {
+		return Data{}, nil
+	}
+
+	header := make([]string, len(records[0]))
+	modelIdx := -1
+	for idx, name := range records[0] {
+		header[idx] = strings.ToLower(strings.TrimSpace(name))
+		if header[idx] == dataModelColumn {
+			modelIdx = idx
+		}
+	}
+	if modelIdx < 0 {
+		return nil, fmt.Errorf("%w: no %q column", ErrInvalidData, dataModelColumn)
+	}
+
+	data := make(Data, len(records)-1)
+	for line, record := range records[1:] {
+		row := make(map[string]float64, len(record)-1)
+		for idx, raw := range record {
+			if raw = strings.TrimSpace(raw); idx == modelIdx || raw == "" {
+				continue
+			}
+			number, parseErr := strconv.ParseFloat(raw, 64)
+			if parseErr != nil {
+				return nil, fmt.Errorf("%w: line %d: %s is not a number: %q", ErrInvalidData, line+2, header[idx], raw)
+			}
+			row[header[idx]] = number
+		}
+		data[strings.ToLower(strings.TrimSpace(record[modelIdx]))] = row
+	}
+
+	return data, nil
+}
+
+// Computer computes the fields of the products in the configured order, so a field can use the ones
+// before it.
+type Computer struct {
+	fields []Field
+	data   Data
+}
+
+// NewComputer creates a computer of the fields using the product data, nil if there are no fields.
+func NewComputer(fields []Field, data Data) *Computer {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return &Computer{fields: fields, data: data}
+}
+
+// Apply stores the computed fields in the extras of the product. The variables of the expressions are
+// the price, the quantity, the extra fields and the data columns of the product model, as numbers. A
+// field whose variables have no value, or dividing by zero, is left out.
+func (c *Computer) Apply(product *models.Product) {
+	if c == nil {
+		return
+	}
+
+	row := c.data[strings.ToLower(product.Model)]
+	vars := func(name string) (float64, bool) {
+		if number, ok := row[name]; ok {
+			return number, true
+		}
+
+		return numericField(product, name)
+	}
+	for _, field := range c.fields {
+		number, boolean, ok := field.Expression.Eval(vars)
+		if !ok {
+			continue
+		}
+		if product.Extras == nil {
+			product.Extras = make(map[string]string, len(c.fields))
+		}
+		if field.Expression.IsBool() {
+			product.Extras[field.Name] = strconv.FormatBool(boolean)
+		} else {
+			product.Extras[field.Name] = formatNumber(number)
+		}
+	}
+}
+
+// numericField returns the number of the price, the quantity or an extra field of the product.
+func numericField(product *models.Product, name string) (float64, bool) {
+	var raw string
+	switch name {
+	case "price":
+		raw = product.Price
+	case "quantity":
+		raw = product.Quantity
+	default:
+		raw, _ = product.Extra(name)
+	}
+	if strings.TrimSpace(raw) == "" {
+		return 0, false
+	}
+	number, err := models.ParsePrice(raw)
+
+	return number, err == nil
+}
+
+// formatNumber formats the number rounded to two decimals without trailing zeros.
+func formatNumber(number float64) string {
+	formatted := strconv.FormatFloat(number, 'f', 2, 64)
+	formatted = strings.TrimRight(formatted, "0")
+
+	return strings.TrimSuffix(formatted, ".")
+}
@@ -0,0 +1,219 @@
+package compute_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/compute"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	vars := func(name string) (float64, bool) {
+		values := map[string]float64{"price": 120, "quantity": 4, "cost": 100}
+		value, ok := values[name]
+		return value, ok
+	}
+
+	testCases := []struct {
+		name    string
+		expr    string
+		number  float64
+		boolean bool
+		isBool  bool
+		ok      bool
+	}{
+		{name: "arithmetic precedence", expr: "price - cost * 2 / 4", number: 70, ok: true},
+		{name: "parentheses and unary minus", expr: "-(price - cost) * 2", number: -40, ok: true},
+		{name: "comparison", expr: "Price < Cost*1.1", boolean: false, isBool: true, ok: true},
+		{name: "logic", expr: "!(quantity >= 5) && (price > 100 || false)", boolean: true, isBool: true, ok: true},
+		{name: "boolean equality", expr: "(price > cost) == true", boolean: true, isBool: true, ok: true},
+		{name: "missing variable", expr: "price / weight", ok: false},
+		{name: "division by zero", expr: "price / (quantity - 4)", ok: false},
+		{name: "short-circuit skips a missing variable", expr: "price < 0 && weight > 1", isBool: true, ok: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := compute.Parse(tc.expr)
+			require.NoError(t, err)
+
+			number, boolean, ok := expr.Eval(vars)
+
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.isBool, expr.IsBool())
+			assert.InDelta(t, tc.number, number, 1e-9)
+			assert.Equal(t, tc.boolean, boolean)
+		})
+	}
+
+	t.Run("variables", func(t *testing.T) {
+		expr, err := compute.Parse("Price / quantity + price")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"price", "quantity", "price"}, expr.Variables())
+	})
+
+	for _, expr := range []string{"", "price +", "(price", "price $ 2", "price + true", "!price", "price < 1 < 2"} {
+		t.Run("error: "+expr, func(t *testing.T) {
+			_, err := compute.Parse(expr)
+
+			require.ErrorIs(t, err, compute.ErrInvalidExpression)
+		})
+	}
+}
+
+// testEnv is an Env with fixed values, the aggregates are keyed by "function(series, period)".
+type testEnv struct {
+	numbers    map[string]float64
+	strings    map[string]string
+	aggregates map[string]float64
+}
+
+func (e testEnv) Number(name string) (float64, bool) {
+	value, ok := e.numbers[name]
+	return value, ok
+}
+
+func (e testEnv) String(name string) (string, bool) {
+	value, ok := e.strings[name]
+	return value, ok
+}
+
+func (e testEnv) Aggregate(function, series string, period time.Duration) (float64, bool) {
+	value, ok := e.aggregates[fmt.Sprintf("%s(%s, %s)", function, series, period)]
+	return value, ok
+}
+
+func TestParseIn(t *testing.T) {
+	scope := compute.Scope{
+		Strings:   []string{"product.type", "change.kind"},
+		Series:    []string{"history"},
+		Functions: []string{"avg", "min"},
+	}
+	env := testEnv{
+		numbers: map[string]float64{"new.price": 700},
+		strings: map[string]string{"product.type": "GPU", "change.kind": "changed"},
+		aggregates: map[string]float64{
+			"avg(history, 720h0m0s)": 1000,
+			"min(history, 0s)":       800,
+			"avg(history, 12h0m0s)":  900,
+		},
+	}
+
+	testCases := []struct {
+		name    string
+		expr    string
+		boolean bool
+		ok      bool
+	}{
+		{
+			name: "function over a period", expr: `new.Price < 0.8*avg(history, 30d) && product.Type == "gpu"`,
+			boolean: true, ok: true,
+		},
+		{name: "function over the whole series", expr: "new.price < min(history)", boolean: true, ok: true},
+		{
+			name: "string inequality", expr: `change.kind != "changed" || avg(history, 12h) > 850`,
+			boolean: true, ok: true,
+		},
+		{name: "missing aggregate", expr: "new.price < avg(history, 2w)", ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := compute.ParseIn(tc.expr, scope)
+			require.NoError(t, err)
+
+			_, boolean, ok := expr.EvalIn(env)
+
+			assert.Equal(t, tc.ok, ok)
+			assert.True(t, expr.IsBool())
+			assert.Equal(t, tc.boolean, boolean)
+		})
+	}
+
+	for _, expr := range []string{
+		`product.type`, `product.type < "a"`, `product.type == 1`, `"gpu`, "history > 1", "sum(history)",
+		"avg(new.price)", "avg(history, 30)", "avg(history, 30y)", "avg(history, 30d", "30d > 1",
+	} {
+		t.Run("error: "+expr, func(t *testing.T) {
+			_, err := compute.ParseIn(expr, scope)
+
+			require.ErrorIs(t, err, compute.ErrInvalidExpression)
+		})
+	}
+}
+
+func TestLoadData(t *testing.T) {
+	t.Run("numeric columns by lowercase model", func(t *testing.T) {
+		data, err := compute.LoadData(strings.NewReader("Model,Cost,Weight\nPhone X,100.5,\nTab,80,0.4\n"))
+
+		require.NoError(t, err)
+		assert.Equal(t, compute.Data{
+			"phone x": {"cost": 100.5},
+			"tab":     {"cost": 80, "weight": 0.4},
+		}, data)
+		assert.Equal(t, map[string]bool{"cost": true, "weight": true}, data.Columns())
+	})
+
+	t.Run("error: no model column", func(t *testing.T) {
+		_, err := compute.LoadData(strings.NewReader("name,cost\nPhone,1\n"))
+
+		require.ErrorIs(t, err, compute.ErrInvalidData)
+	})
+
+	t.Run("error: value is not a number", func(t *testing.T) {
+		_, err := compute.LoadData(strings.NewReader("model,cost\nPhone,cheap\n"))
+
+		require.ErrorContains(t, err, `line 2: cost is not a number: "cheap"`)
+	})
+}
+
+func TestComputer_Apply(t *testing.T) {
+	field := func(name, expr string) compute.Field {
+		parsed, err := compute.Parse(expr)
+		require.NoError(t, err)
+		return compute.Field{Name: name, Expression: parsed}
+	}
+	computer := compute.NewComputer([]compute.Field{
+		field("price_per_unit", "price / quantity"),
+		field("margin_flag", "price < cost * 1.1"),
+		field("weight_total", "weight * quantity"),
+		field("per_unit_gram", "price_per_unit / (warranty * 1000)"),
+	}, compute.Data{"phone": {"cost": 1000}})
+
+	t.Run("fields are computed in order", func(t *testing.T) {
+		product := models.Product{
+			Model: "Phone", Price: "1 050 грн", Quantity: "> 3", Extras: map[string]string{"Warranty": "12"},
+		}
+
+		computer.Apply(&product)
+
+		assert.Equal(t, map[string]string{
+			"Warranty":       "12",
+			"price_per_unit": "350",
+			"margin_flag":    "true",
+			"per_unit_gram":  "0.03",
+		}, product.Extras)
+	})
+
+	t.Run("fields without values are left out", func(t *testing.T) {
+		product := models.Product{Model: "Tablet", Price: "n/a"}
+
+		computer.Apply(&product)
+
+		assert.Nil(t, product.Extras)
+	})
+
+	t.Run("no fields", func(t *testing.T) {
+		product := models.Product{Model: "Phone", Price: "10"}
+
+		compute.NewComputer(nil, nil).Apply(&product)
+
+		assert.Nil(t, product.Extras)
+	})
+}
@@ -0,0 +1,597 @@
+package compute
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrInvalidExpression is returned for an expression that can't be parsed or mixes the types of values.
+var ErrInvalidExpression = errors.New("invalid expression")
+
+// valueKind is the type of an expression value.
+type valueKind int
+
+const (
+	kindNumber valueKind = iota
+	kindBool
+	kindString
+)
+
+func (k valueKind) String() string {
+	switch k {
+	case kindBool:
+		return "boolean"
+	case kindString:
+		return "string"
+	default:
+		return "number"
+	}
+}
+
+// value is the result of an expression, a number, a boolean or a string depending on the kind of the
+// expression.
+type value struct {
+	number  float64
+	boolean bool
+	text    string
+}
+
+// Env provides the values an expression is evaluated with, each returns false for a missing value.
+type Env interface {
+	// Number returns the value of a numeric variable by its lowercase name.
+	Number(name string) (float64, bool)
+	// String returns the value of a string variable by its lowercase name.
+	String(name string) (string, bool)
+	// Aggregate returns the result of the function over the values of the series in the period before
+	// the evaluation, the whole series for a zero period.
+	Aggregate(function, series string, period time.Duration) (float64, bool)
+}
+
+// numberEnv is the Env of an expression reading numeric variables only.
+type numberEnv func(name string) (float64, bool)
+
+func (e numberEnv) Number(name string) (float64, bool)                      { return e(name) }
+func (e numberEnv) String(string) (string, bool)                            { return "", false }
+func (e numberEnv) Aggregate(string, string, time.Duration) (float64, bool) { return 0, false }
+
+// Scope declares the variables and the functions an expression may use besides the numeric variables.
+type Scope struct {
+	// Strings are the lowercase names of the string variables.
+	Strings []string
+	// Series are the lowercase names of the variables holding a series of values over time, they can
+	// only be passed to the Functions.
+	Series []string
+	// Functions are the lowercase names of the functions aggregating a series into a number, called with
+	// the series and an optional period like avg(history, 30d).
+	Functions []string
+}
+
+// node is a node of a parsed expression. eval returns false if a value the node reads is missing or
+// a number is divided by zero.
+type node interface {
+	kind() valueKind
+	eval(env Env) (value, bool)
+}
+
+// Expression is a parsed expression computing a number or a boolean from the variables of a product.
+// It supports numbers, "strings", variables, true and false, the + - * / arithmetic, the < <= > >= == !=
+// comparisons, the && || ! logic and parentheses. The strings are compared with == and != ignoring the
+// case. The functions of the Scope take a series and a period in hours, days or weeks, e.g. 12h, 30d or
+// 2w.
+type Expression struct {
+	source string
+	root   node
+	names  []string
+}
+
+// Parse parses and type-checks the expression over numeric variables.
+func Parse(source string) (*Expression, error) {
+	return ParseIn(source, Scope{})
+}
+
+// ParseIn parses and type-checks the expression over the variables and the functions of the scope, the
+// other variables are numbers. The expression must compute a number or a boolean.
+func ParseIn(source string, scope Scope) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidExpression, source, err)
+	}
+
+	p := &exprParser{tokens: tokens, scope: scope}
+	root, err := p.parseOr()
+	switch {
+	case err != nil:
+	case p.pos < len(p.tokens):
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	case root.kind() == kindString:
+		err = errors.New("a string can only be compared")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidExpression, source, err)
+	}
+
+	return &Expression{source: source, root: root, names: p.names}, nil
+}
+
+// String returns the source of the expression.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Variables returns the lowercase names of the variables the expression reads, in order of appearance.
+func (e *Expression) Variables() []string {
+	return e.names
+}
+
+// IsBool reports whether the expression computes a boolean.
+func (e *Expression) IsBool() bool {
+	return e.root.kind() == kindBool
+}
+
+// Eval computes the expression over numeric variables, it returns false if a variable it reads has no
+// value or a number is divided by zero.
+func (e *Expression) Eval(vars func(name string) (float64, bool)) (float64, bool, bool) {
+	return e.EvalIn(numberEnv(vars))
+}
+
+// EvalIn computes the expression with the values of the env, it returns false if a value it reads is
+// missing or a number is divided by zero.
+func (e *Expression) EvalIn(env Env) (float64, bool, bool) {
+	result, ok := e.root.eval(env)
+	if !ok {
+		return 0, false, false
+	}
+
+	return result.number, result.boolean, true
+}
+
+// token is a lexical token of an expression: a number, a period, a string, an identifier or an operator.
+type token struct {
+	text   string
+	number bool
+	period bool
+	quoted bool
+	ident  bool
+}
+
+// operators are the operator tokens, the two-character ones first.
+//
+//nolint:gochecknoglobals // a read-only list of the operator tokens.
+var operators = []string{
+	"<=", ">=", "==", "!=", "&&", "||", "+", "-", "*", "/", "<", ">", "!", "(", ")", ",",
+}
+
+// periodUnits are the units of the periods passed to the functions.
+//
+//nolint:gochecknoglobals // a read-only table of the units.
+var periodUnits = map[string]time.Duration{"h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+
+// tokenize splits the expression into tokens.
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	for pos := 0; pos < len(source); {
+		char := rune(source[pos])
+		switch {
+		case unicode.IsSpace(char):
+			pos++
+		case unicode.IsDigit(char) || char == '.':
+			end := pos
+			for end < len(source) && (unicode.IsDigit(rune(source[end])) || source[end] == '.') {
+				end++
+			}
+			// A number followed by a unit is a period, e.g. 30d.
+			unit := end
+			for unit < len(source) && unicode.IsLetter(rune(source[unit])) {
+				unit++
+			}
+			tokens = append(tokens, token{text: source[pos:unit], number: unit == end, period: unit > end})
+			pos = unit
+		case char == '"':
+			end := strings.IndexByte(source[pos+1:], '"')
+			if end < 0 {
+				return nil, errors.New("unterminated string")
+			}
+			tokens = append(tokens, token{text: source[pos+1 : pos+1+end], quoted: true})
+			pos += end + 2
+		case unicode.IsLetter(char) || char == '_':
+			// Dots join the names of nested variables, e.g. new.price.
+			end := pos
+			for end < len(source) && (isIdentChar(rune(source[end])) || source[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, token{text: source[pos:end], ident: true})
+			pos = end
+		default:
+			operator := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(source[pos:], candidate) {
+					operator = candidate
+					break
+				}
+			}
+			if operator == "" {
+				return nil, fmt.Errorf("unexpected character %q", char)
+			}
+			tokens = append(tokens, token{text: operator})
+			pos += len(operator)
+		}
+	}
+
+	return tokens, nil
+}
+
+// isIdentChar reports whether the character may be a part of an identifier.
+func isIdentChar(char rune) bool {
+	return char < unicode.MaxASCII && (unicode.IsLetter(char) || unicode.IsDigit(char) || char == '_')
+}
+
+// exprParser is a recursive descent parser of the tokens of an expression.
+type exprParser struct {
+	tokens []token
+	scope  Scope
+	pos    int
+	names  []string
+}
+
+// accept consumes the next token if it's one of the operators and returns it.
+func (p *exprParser) accept(operators ...string) (string, bool) {
+	if p.pos >= len(p.tokens) {
+		return "", false
+	}
+	next := p.tokens[p.pos]
+	for _, operator := range operators {
+		if !next.number && !next.period && !next.quoted && !next.ident && next.text == operator {
+			p.pos++
+			return operator, true
+		}
+	}
+
+	return "", false
+}
+
+// parseOr parses a || b.
+func (p *exprParser) parseOr() (node, error) {
+	return p.parseLogic(p.parseAnd, "||")
+}
+
+// parseAnd parses a && b.
+func (p *exprParser) parseAnd() (node, error) {
+	return p.parseLogic(p.parseComparison, "&&")
+}
+
+// parseLogic parses the operands joined by the logical operator.
+func (p *exprParser) parseLogic(operand func() (node, error), operator string) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept(operator); !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if err = expectKinds(operator, kindBool, left, right); err != nil {
+			return nil, err
+		}
+		left = &binaryNode{operator: operator, left: left, right: right}
+	}
+}
+
+// parseComparison parses a comparison of two sums.
+func (p *exprParser) parseComparison() (node, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	operator, ok := p.accept("<=", ">=", "==", "!=", "<", ">")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	ordered := left.kind() == kindNumber
+	if left.kind() != right.kind() || (!ordered && operator != "==" && operator != "!=") {
+		return nil, fmt.Errorf("can't compare a %s with a %s using %s", left.kind(), right.kind(), operator)
+	}
+
+	return &binaryNode{operator: operator, left: left, right: right}, nil
+}
+
+// parseSum parses a + b and a - b.
+func (p *exprParser) parseSum() (node, error) {
+	return p.parseArithmetic(p.parseProduct, "+", "-")
+}
+
+// parseProduct parses a * b and a / b.
+func (p *exprParser) parseProduct() (node, error) {
+	return p.parseArithmetic(p.parseUnary, "*", "/")
+}
+
+// parseArithmetic parses the numeric operands joined by the operators.
+func (p *exprParser) parseArithmetic(operand func() (node, error), operators ...string) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := p.accept(operators...)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if err = expectKinds(operator, kindNumber, left, right); err != nil {
+			return nil, err
+		}
+		left = &binaryNode{operator: operator, left: left, right: right}
+	}
+}
+
+// parseUnary parses -a and !a.
+func (p *exprParser) parseUnary() (node, error) {
+	operator, ok := p.accept("-", "!")
+	if !ok {
+		return p.parsePrimary()
+	}
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	want := kindNumber
+	if operator == "!" {
+		want = kindBool
+	}
+	if err = expectKinds(operator, want, operand); err != nil {
+		return nil, err
+	}
+
+	return &unaryNode{operator: operator, operand: operand}, nil
+}
+
+// parsePrimary parses a number, a string, a variable, a boolean, a function call or an expression in
+// parentheses.
+func (p *exprParser) parsePrimary() (node, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, errors.New("unexpected end")
+	}
+	next := p.tokens[p.pos]
+	p.pos++
+
+	switch {
+	case next.number:
+		number, err := strconv.ParseFloat(next.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", next.text)
+		}
+		return &literalNode{valueKind: kindNumber, value: value{number: number}}, nil
+	case next.period:
+		return nil, fmt.Errorf("the period %q can only be passed to a function", next.text)
+	case next.quoted:
+		return &literalNode{valueKind: kindString, value: value{text: next.text}}, nil
+	case next.ident && (strings.EqualFold(next.text, "true") || strings.EqualFold(next.text, "false")):
+		return &literalNode{valueKind: kindBool, value: value{boolean: strings.EqualFold(next.text, "true")}}, nil
+	case next.ident:
+		name := strings.ToLower(next.text)
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(name)
+		}
+		if slices.Contains(p.scope.Series, name) {
+			return nil, fmt.Errorf("the series %s can only be passed to a function", name)
+		}
+		p.names = append(p.names, name)
+		if slices.Contains(p.scope.Strings, name) {
+			return &variableNode{name: name, valueKind: kindString}, nil
+		}
+		return &variableNode{name: name, valueKind: kindNumber}, nil
+	case next.text == "(":
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, errors.New("missing )")
+		}
+		return inner, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q", next.text)
+	}
+}
+
+// parseCall parses the arguments of a call of the function after its opening parenthesis: a series and
+// an optional period.
+func (p *exprParser) parseCall(function string) (node, error) {
+	if !slices.Contains(p.scope.Functions, function) {
+		return nil, fmt.Errorf("unknown function %s", function)
+	}
+
+	call := &callNode{function: function}
+	if p.pos < len(p.tokens) && p.tokens[p.pos].ident {
+		call.series = strings.ToLower(p.tokens[p.pos].text)
+		p.pos++
+	}
+	if !slices.Contains(p.scope.Series, call.series) {
+		return nil, fmt.Errorf("%s needs a series", function)
+	}
+	if _, ok := p.accept(","); ok {
+		if p.pos >= len(p.tokens) || !p.tokens[p.pos].period {
+			return nil, fmt.Errorf("%s needs a period like 30d", function)
+		}
+		period, err := parsePeriod(p.tokens[p.pos].text)
+		if err != nil {
+			return nil, err
+		}
+		call.period = period
+		p.pos++
+	}
+	if _, ok := p.accept(")"); !ok {
+		return nil, errors.New("missing )")
+	}
+
+	return call, nil
+}
+
+// parsePeriod parses a period like 12h, 30d or 2w.
+func parsePeriod(text string) (time.Duration, error) {
+	end := strings.IndexFunc(text, unicode.IsLetter)
+	count, err := strconv.ParseFloat(text[:end], 64)
+	unit, ok := periodUnits[strings.ToLower(text[end:])]
+	if err != nil || !ok || count <= 0 {
+		return 0, fmt.Errorf("invalid period %q", text)
+	}
+
+	return time.Duration(count * float64(unit)), nil
+}
+
+// expectKinds checks that the operands of the operator are of the kind.
+func expectKinds(operator string, want valueKind, operands ...node) error {
+	for _, operand := range operands {
+		if operand.kind() != want {
+			return fmt.Errorf("%s needs %s operands", operator, want)
+		}
+	}
+
+	return nil
+}
+
+// literalNode is a number, a boolean or a string.
+type literalNode struct {
+	valueKind valueKind
+	value     value
+}
+
+func (n *literalNode) kind() valueKind        { return n.valueKind }
+func (n *literalNode) eval(Env) (value, bool) { return n.value, true }
+
+// variableNode is a numeric or a string variable.
+type variableNode struct {
+	name      string
+	valueKind valueKind
+}
+
+func (n *variableNode) kind() valueKind { return n.valueKind }
+
+func (n *variableNode) eval(env Env) (value, bool) {
+	if n.valueKind == kindString {
+		text, ok := env.String(n.name)
+		return value{text: text}, ok
+	}
+	number, ok := env.Number(n.name)
+
+	return value{number: number}, ok
+}
+
+// callNode is a function aggregating a series over a period.
+type callNode struct {
+	function string
+	series   string
+	period   time.Duration
+}
+
+func (n *callNode) kind() valueKind { return kindNumber }
+
+func (n *callNode) eval(env Env) (value, bool) {
+	number, ok := env.Aggregate(n.function, n.series, n.period)
+
+	return value{number: number}, ok
+}
+
+// unaryNode is -a or !a.
+type unaryNode struct {
+	operator string
+	operand  node
+}
+
+func (n *unaryNode) kind() valueKind { return n.operand.kind() }
+
+func (n *unaryNode) eval(env Env) (value, bool) {
+	operand, ok := n.operand.eval(env)
+	if !ok {
+		return value{}, false
+	}
+	if n.operator == "!" {
+		return value{boolean: !operand.boolean}, true
+	}
+
+	return value{number: -operand.number}, true
+}
+
+// binaryNode is an arithmetic, comparison or logical operation.
+type binaryNode struct {
+	operator    string
+	left, right node
+}
+
+func (n *binaryNode) kind() valueKind {
+	if n.operator == "+" || n.operator == "-" || n.operator == "*" || n.operator == "/" {
+		return kindNumber
+	}
+
+	return kindBool
+}
+
+func (n *binaryNode) eval(env Env) (value, bool) {
+	left, ok := n.left.eval(env)
+	if !ok {
+		return value{}, false
+	}
+	// The logical operators short-circuit, so a missing variable on the right doesn't matter.
+	switch {
+	case n.operator == "&&" && !left.boolean:
+		return value{boolean: false}, true
+	case n.operator == "||" && left.boolean:
+		return value{boolean: true}, true
+	}
+	right, ok := n.right.eval(env)
+	if !ok {
+		return value{}, false
+	}
+
+	switch n.operator {
+	case "+":
+		return value{number: left.number + right.number}, true
+	case "-":
+		return value{number: left.number - right.number}, true
+	case "*":
+		return value{number: left.number * right.number}, true
+	case "/":
+		if right.number == 0 {
+			return value{}, false
+		}
+		return value{number: left.number / right.number}, true
+	case "&&", "||":
+		return value{boolean: right.boolean}, true
+	case "==":
+		return value{boolean: n.equal(left, right)}, true
+	case "!=":
+		return value{boolean: !n.equal(left, right)}, true
+	case "<":
+		return value{boolean: left.number < right.number}, true
+	case "<=":
+		return value{boolean: left.number <= right.number}, true
+	case ">":
+		return value{boolean: left.number > right.number}, true
+	default:
+		return value{boolean: left.number >= right.number}, true
+	}
+}
+
+// equal compares the operands of == and !=, strings ignoring the case.
+func (n *binaryNode) equal(left, right value) bool {
+	if n.left.kind() == kindString {
+		return strings.EqualFold(left.text, right.text)
+	}
+
+	return left == right
+}
@@ -1,41 +1,406 @@
 package config
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Houeta/chrono-flow/internal/broker"
+	"github.com/Houeta/chrono-flow/internal/compute"
+	"github.com/Houeta/chrono-flow/internal/httpclient"
+	"github.com/Houeta/chrono-flow/internal/logging"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/normalize"
+	"github.com/Houeta/chrono-flow/internal/parser"
+	"github.com/Houeta/chrono-flow/internal/rules"
+	"github.com/Houeta/chrono-flow/internal/schedule"
 	"github.com/spf13/viper"
 )
 
-var ErrEmptyToken = errors.New("error getting CF_TELEGRAM_TOKEN: variable not specified or contains an empty string")
+var (
+	ErrEmptyToken             = errors.New("error getting CF_TELEGRAM_TOKEN: variable not specified or contains an empty string")
+	ErrInvalidFuzzyThreshold  = errors.New("error getting CF_FUZZY_THRESHOLD: value must be between 0 and 1")
+	ErrInvalidFilterGroup     = errors.New("error getting CF_FILTER_GROUPS: expected name=Type1,Type2;name2=Type3")
+	ErrInvalidMaxInvalidRatio = errors.New("error getting CF_MAX_INVALID_RATIO: value must be between 0 and 1")
+	ErrInvalidTables          = errors.New("error getting CF_TABLES: expected name=selector;name2=selector2")
+	ErrInvalidColumnSynonyms  = errors.New(
+		"error getting CF_COLUMN_SYNONYMS: expected field=Header1,Header2;field2=Header3",
+	)
+	ErrInvalidColumnSelectors = errors.New(
+		"error getting CF_COLUMN_SELECTORS: expected field=selector@attr;field2=selector",
+	)
+	ErrInvalidEncryptionKey = errors.New(
+		"error getting CF_ENCRYPTION_KEY: expected 32 bytes in base64, set either it or CF_ENCRYPTION_KEY_FILE",
+	)
+	ErrInvalidExtraFields = errors.New(
+		"error getting CF_EXTRA_FIELDS: expected field=Header1,Header2;field2, not naming a built-in field",
+	)
+	ErrInvalidComputedFields = errors.New(
+		"error getting CF_COMPUTED_FIELDS: expected field=expression;field2=expression of the known variables",
+	)
+	ErrInvalidAlertRules = errors.New(
+		"error getting CF_ALERT_RULES: expected name=condition;name2=condition, see /rule for the conditions",
+	)
+	ErrInvalidNormalize = errors.New(
+		"error getting CF_NORMALIZE: expected scripts,styles,whitespace,attributes and a CSS selector in " +
+			"CF_NORMALIZE_REMOVE",
+	)
+	ErrInvalidButtons = errors.New(
+		`error getting CF_TELEGRAM_BUTTONS_FILE: expected [[{"text": "Order form", "url": "https://..."}]]`,
+	)
+	ErrInvalidBrokerFormat = errors.New("error getting CF_BROKER_FORMAT: expected json or protobuf")
+	ErrEmptyS3Bucket       = errors.New("error getting CF_S3_BUCKET: required when CF_S3_ENDPOINT is set")
+	ErrInvalidWindowMode   = errors.New("error getting CF_MAINTENANCE_WINDOW_MODE: expected skip or ignore")
+	ErrInvalidLogLevels    = errors.New("error getting CF_LOG_LEVELS: expected component=level;component2=level")
+	ErrInvalidRole         = errors.New("error getting CF_ROLE: expected all, checker or bot")
+	ErrInvalidSimulate     = errors.New("error getting CF_SIMULATE: expected all or added,changed,renamed,removed")
+	ErrInvalidHeaders      = errors.New(`error getting CF_REQUEST_HEADERS: expected {"Header": "value"}`)
+	ErrInvalidRegions      = errors.New(`error getting CF_REGIONS: expected {"region": {"Header": "value"}}`)
+	ErrInvalidDNSHosts     = errors.New("error getting CF_DNS_HOSTS: expected host=ip;host2=ip")
+	ErrInvalidNetwork      = errors.New("error getting CF_IP_VERSION, CF_DNS_HOSTS or CF_DNS_RESOLVER")
+	ErrInvalidClientTLS    = errors.New("error getting CF_TLS_CLIENT_CERT or CF_TLS_CLIENT_KEY")
+	ErrInvalidFetchSteps   = errors.New(`error getting CF_FETCH_STEPS: expected [{"selector": "a@href"}, ...]`)
+	ErrInvalidRequest      = errors.New(`error getting CF_FETCH_REQUEST: expected {"method": "POST", "form": {...}}`)
+	ErrInvalidAssertions   = errors.New(`error getting CF_PAGE_ASSERTIONS: expected {"contains": [...], "min_rows": 1}`)
+)
+
+// Modes of handling checks that fall into a maintenance window of the target.
+const (
+	// WindowModeSkip doesn't run checks during a window.
+	WindowModeSkip = "skip"
+	// WindowModeIgnore fetches the page to track the availability of the target but ignores its content.
+	WindowModeIgnore = "ignore"
+)
+
+// Roles of the process, the checker and the bot may run as separate processes sharing the database.
+const (
+	// RoleAll checks the targets and delivers the changes with the bot.
+	RoleAll = "all"
+	// RoleChecker checks the targets and queues the changes in the outbox of the database.
+	RoleChecker = "checker"
+	// RoleBot runs the bot and delivers the changes queued by the checker.
+	RoleBot = "bot"
+)
+
+// filterGroupNameRe matches the characters allowed in Telegram deep-link payloads.
+var filterGroupNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
 
 type Config struct {
-	Env         string // Env is the current environment: local, dev, prod.
-	URL         string
-	StoragePath string
-	AllowedIDs  []int64
-	Interval    time.Duration
-	Tg          Telegram
+	// Role is the part of the application the process runs: RoleAll, RoleChecker or RoleBot.
+	Role string
+	// Simulate are the change kinds injected as synthetic changes into the notifications once the bot
+	// started, to check them without waiting for real changes. It's set by CF_SIMULATE or the simulate flag.
+	Simulate []string
+	// Force starts the process even if another instance runs its role against the database, e.g. to
+	// recover when the lock can't be taken on a network filesystem. It's set by CF_FORCE or the force flag.
+	Force bool
+	// OutboxPollInterval is how often the bot process looks for changes queued by the checker.
+	OutboxPollInterval time.Duration
+	Env                string // Env is the current environment: local, dev, prod.
+	URL                string
+	StoragePath        string
+	// QueryTimeout limits a single query of the repository, 0 disables the limit.
+	QueryTimeout time.Duration
+	// EncryptionKey encrypts the sensitive columns of the database at rest, nil stores them in the clear.
+	// It's set by CF_ENCRYPTION_KEY, or read from the file of CF_ENCRYPTION_KEY_FILE, e.g. provided
+	// by a KMS or a secret manager.
+	EncryptionKey []byte
+	AllowedIDs    []int64
+	// AdminIDs are chats allowed to run administrative commands.
+	AdminIDs []int64
+	// FilterGroups maps a deep-link payload to the product types a subscriber receives.
+	FilterGroups map[string][]string
+	// Tables are the named product tables parsed from the page, empty parses all tables without a category.
+	Tables []Table
+	// ColumnSynonyms maps a product field to extra table header texts it is recognized by.
+	ColumnSynonyms map[string][]string
+	// ColumnSelectors maps a product field to a "selector@attr" expression extracting it from a table row.
+	ColumnSelectors map[string]string
+	// ExtraFields maps the extra product fields, e.g. a warranty or an EAN, to the table header texts they
+	// are recognized by besides their names. Their values are stored, diffed and rendered with the products.
+	ExtraFields map[string][]string
+	// ComputedFields are derived from the parsed fields and the ProductData after parsing, e.g. a price per
+	// unit, and are stored, diffed, filtered and rendered like the ExtraFields.
+	ComputedFields []compute.Field
+	// ProductData are the numeric columns of the CSV file of CF_PRODUCT_DATA_FILE by model, e.g. a cost,
+	// the ComputedFields can use.
+	ProductData compute.Data
+	// AlertRules alert every subscribed chat of the changes matching their conditions, besides the rules
+	// the chats add with /rule.
+	AlertRules []rules.Rule
+	// StreamingParser extracts the products without building the document tree of the page, which keeps
+	// the memory use of huge pages low. Column selectors and complex table selectors aren't supported by it.
+	StreamingParser bool
+	// BoundedMemory checks the page product by product against the stored state instead of loading both
+	// product lists, so huge catalogs fit in a small memory limit. It implies StreamingParser.
+	BoundedMemory bool
+	// IframeSelector matches the iframe embedding the product tables, empty parses the page itself.
+	IframeSelector string
+	// FetchSteps lead from the page to the document with the product tables, see models.TargetStep.
+	FetchSteps []models.TargetStep
+	// FetchRequest is the method, body and headers the page is requested with, e.g. to submit a form, see
+	// models.TargetRequest.
+	FetchRequest models.TargetRequest
+	// PageAssertions are the sanity checks of the fetched page, a page failing them skips the check.
+	PageAssertions models.PageAssertions
+	// Normalizer rewrites the fetched page before it's hashed and parsed with the steps of CF_NORMALIZE, e.g.
+	// "scripts,whitespace", after removing the elements matching the CSS selector of CF_NORMALIZE_REMOVE.
+	// Nil if neither is set.
+	Normalizer *normalize.Normalizer
+	// PageDiffRuns is the number of the last runs whose page changed without a product changing that keep
+	// the diff of the page for debugging, 0 disables the diffs. They are served by the admin API.
+	PageDiffRuns int
+	// RequestHeaders are sent with every request of the page, e.g. its Accept-Language.
+	RequestHeaders map[string]string
+	// Regions are the regional profiles the page is also checked with, their products and changes are
+	// kept apart, see models.TargetRegion.
+	Regions  []models.TargetRegion
+	Interval time.Duration
+	// HTTPTimeout limits a single request to the target, 0 disables the limit.
+	HTTPTimeout time.Duration
+	// HARDir is the directory fetches are recorded to as a HAR archive for debugging, empty disables it.
+	HARDir string
+	// HARMaxEntries is the number of latest fetches kept in the HAR archive.
+	HARMaxEntries int
+	// DNSCacheTTL is how long resolved target addresses are reused, 0 disables the cache.
+	DNSCacheTTL time.Duration
+	// Network are the options of the connections to the page: the IP version of CF_IP_VERSION (ipv4 or
+	// ipv6), the static host addresses of CF_DNS_HOSTS and the DNS server of CF_DNS_RESOLVER.
+	Network models.TargetNetwork
+	// ClientTLS is the client certificate of CF_TLS_CLIENT_CERT and CF_TLS_CLIENT_KEY the page is fetched with,
+	// each is PEM or the path of a PEM file, e.g. of a mounted secret.
+	ClientTLS models.TargetTLS
+	// FuzzyThreshold is the minimal model similarity (0..1] for detecting renamed products, 0 disables it.
+	FuzzyThreshold float64
+	// MaxInvalidRatio is the maximal share of invalid rows (0..1) a page may contain, above it the check is aborted.
+	MaxInvalidRatio float64
+	// SummaryThreshold is the number of changes above which a summary with a CSV export is sent, 0 disables it.
+	SummaryThreshold int
+	// DedupWindow is how long a chat doesn't get the same changes again from any notifier, 0 disables it.
+	DedupWindow time.Duration
+	// DuplicateRunWindow is how long the changes of a check identical to the ones of a check delivered before
+	// are suppressed, e.g. of a manual check overlapping a scheduled one, 0 disables it.
+	DuplicateRunWindow time.Duration
+	// MaintenanceInterval is how often the database is vacuumed, 0 disables maintenance.
+	MaintenanceInterval time.Duration
+	// MaintenanceWindows are the recurring periods the target serves unreliable content in,
+	// scheduled checks are handled according to MaintenanceWindowMode during them.
+	MaintenanceWindows []schedule.Window
+	// MaintenanceWindowMode is either WindowModeSkip or WindowModeIgnore.
+	MaintenanceWindowMode string
+	// BreakerThreshold is the number of consecutive failed checks after which the target is backed off,
+	// 0 disables the circuit breaker.
+	BreakerThreshold int
+	// BreakerMaxBackoff caps the exponential backoff between checks of a failing target.
+	BreakerMaxBackoff time.Duration
+	// AlertThreshold is the number of consecutive failed checks after which admins are alerted,
+	// 0 disables alerts.
+	AlertThreshold int
+	// HeartbeatIntervals is the number of check intervals without a successful check after which admins
+	// are alerted by the dead-man's switch, 0 disables it.
+	HeartbeatIntervals int
+	// HeartbeatPingURL is requested after every successful check, e.g. a healthchecks.io check URL,
+	// empty disables it.
+	HeartbeatPingURL string
+	// EnrichURL is the webhook the detected changes are posted to before they're notified, the annotations
+	// it returns are added to the extra fields of the products. Empty disables the enrichment.
+	EnrichURL string
+	// EnrichTimeout limits a request to the enrichment webhook, the changes are notified without the
+	// annotations once it's exceeded.
+	EnrichTimeout time.Duration
+	// HistoryRetention is how long history records are kept by maintenance, 0 keeps them forever.
+	HistoryRetention time.Duration
+	// PruneUnreachableAfter is how long the messages of the bot have to fail to reach a subscriber, e.g.
+	// one that blocked the bot, before it's unsubscribed, 0 disables the pruning. The chats of deactivated
+	// accounts are unsubscribed right away. The pruning runs with the maintenance.
+	PruneUnreachableAfter time.Duration
+	// EventsLogFile is the file structured change events are appended to, empty writes them to stdout.
+	EventsLogFile string
+	// MetricsAddr is the address Prometheus metrics are served on, empty disables the endpoint.
+	MetricsAddr string
+	// APIAddr is the address the GraphQL API is served on, empty disables the API.
+	APIAddr string
+	// GRPCAddr is the address the gRPC API is served on, empty disables the API.
+	GRPCAddr string
+	// ConfigDir is the directory of CF_CONFIG_DIR the configuration is also read from, e.g. a mounted
+	// ConfigMap, empty if there is none. It's watched, the application restarts with a changed one.
+	ConfigDir string
+	// Pod identifies the Kubernetes pod the process runs in for the logs and the metrics.
+	Pod Pod
+	// APIAuth requires an API token with the matching scopes for the GraphQL and gRPC APIs.
+	APIAuth bool
+	Tg      Telegram
+	Broker  Broker
+	S3      S3
+	Log     Log
+}
+
+// Table is a named product table on the page selected by a CSS selector.
+type Table struct {
+	Name     string
+	Selector string
 }
 
 type Telegram struct {
 	Token   string        // Token is an unique telgram bot token.
 	Timeout time.Duration // Timeout is a poller timeout duration.
+	// Templates override the notification line templates by change kind: added, changed, renamed, removed.
+	Templates map[string]string
+	// TemplatesFile is the JSON file of templates by change kind overriding the Templates. It's watched, the
+	// templates of a changed file are used once an admin approved their preview.
+	TemplatesFile string
+	Channel       Channel
+	// RateLimit is the number of updates a chat may send the bot a minute, 0 disables the limit.
+	RateLimit int
+	// BroadcastWorkers send the notifications to the subscribers in the background, 0 sends them one
+	// after the other while the check waits.
+	BroadcastWorkers int
+	// BroadcastQueue is the number of messages queued for the broadcast workers before queueing blocks.
+	BroadcastQueue int
+	// VariantTemplates override the templates of the chats getting variant b of the notifications, empty
+	// sends every chat variant a.
+	VariantTemplates map[string]string
+	// Feedback adds buttons rating whether a notification was useful.
+	Feedback bool
+	// Photos sends the images of the added products after the notifications, as albums.
+	Photos bool
+	// MaxLines ranks the notification lines by the significance of their changes and lists at most that
+	// many, the minor rest is attached as CSV. 0 lists every change grouped by product type.
+	MaxLines int
+	// Buttons are the rows of link buttons added to every notification, read from the JSON file of
+	// CF_TELEGRAM_BUTTONS_FILE.
+	Buttons [][]Button
+}
+
+// Button is a link button added to every notification, e.g. to an order form.
+type Button struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// Channel configures posting to a Telegram channel the bot is an administrator of.
+type Channel struct {
+	ID     int64 // ID of the channel, 0 disables posting.
+	Silent bool  // Silent posts without a notification sound.
+	// Summary is when the summary of the last 24 hours is posted, nil disables it.
+	Summary    *schedule.Cron
+	PinSummary bool // PinSummary pins the summary in place of the previous one.
 }
 
-// MustLoad loads the configuration from environment variables and returns a Config struct.
+// Broker configures publishing change and run events to NATS JetStream.
+type Broker struct {
+	URL string // URL of the NATS server, empty disables publishing.
+	// Stream is the JetStream stream created for the events, empty expects it to exist already.
+	Stream        string
+	SubjectPrefix string // SubjectPrefix is prepended to the "changes" and "runs" subjects.
+	Format        string // Format is the payload format: json or protobuf.
+}
+
+// S3 configures the S3-compatible object storage change exports are archived to.
+type S3 struct {
+	Endpoint  string // Endpoint is the host[:port] of the storage, empty disables archiving.
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Region    string // Region of the bucket, empty looks it up.
+	UseSSL    bool
+	Prefix    string // Prefix is prepended to every object key.
+}
+
+// Log configures the log sinks and the levels of the components, the format and the default level
+// follow the environment.
+type Log struct {
+	// File is the file logs are also written to, rotated by size, empty disables it.
+	File       string
+	MaxSize    int           // MaxSize is the size in megabytes the file is rotated at.
+	MaxBackups int           // MaxBackups is the number of rotated files kept, 0 keeps all of them.
+	MaxAge     time.Duration // MaxAge is how long rotated files are kept, 0 keeps them forever.
+	Journald   bool          // Journald also sends logs to the systemd journal.
+	// Levels override the level of the environment for the bot, parser and repository components.
+	Levels map[string]slog.Level
+}
+
+// Pod is the Kubernetes pod the process runs in, usually set from the downward API, e.g. CF_POD_NAME from
+// metadata.name. Empty fields aren't added to the logs and the metrics.
+type Pod struct {
+	Name      string
+	Namespace string
+}
+
+// Labels returns the set fields of the pod as the labels of the logs and the metrics.
+func (p Pod) Labels() map[string]string {
+	labels := make(map[string]string, 2) //nolint:mnd // the name and the namespace.
+	if p.Name != "" {
+		labels["pod"] = p.Name
+	}
+	if p.Namespace != "" {
+		labels["namespace"] = p.Namespace
+	}
+
+	return labels
+}
+
+// MustLoad loads the configuration from environment variables and the files of CF_CONFIG_DIR, and returns
+// a Config struct. The environment variables take precedence over the files.
 func MustLoad() (*Config, error) {
 	// Automatically binds environment variables to config keys
 	viper.SetEnvPrefix("CF")
 	viper.AutomaticEnv()
 
+	configDir := viper.GetString("CONFIG_DIR")
+	if err := loadConfigDir(configDir); err != nil {
+		return nil, err
+	}
+
 	// optional args
 	viper.SetDefault("ENV", "production")
+	viper.SetDefault("ROLE", RoleAll)
+	viper.SetDefault("OUTBOX_POLL_INTERVAL", "10s")
+	viper.SetDefault("DUPLICATE_RUN_WINDOW", "5m")
 	viper.SetDefault("TELEGRAM_TIMEOUT", "15s")
+	viper.SetDefault("TELEGRAM_RATE_LIMIT", 20)
+	viper.SetDefault("TELEGRAM_BROADCAST_WORKERS", 3)
+	viper.SetDefault("TELEGRAM_BROADCAST_QUEUE", 1000)
+	viper.SetDefault("TELEGRAM_MAX_LINES", 0)
 	viper.SetDefault("STORAGE_PATH", "./chrono-flow.db")
+	viper.SetDefault("QUERY_TIMEOUT", "30s")
 	viper.SetDefault("CHECK_INTERVAL", "10m")
+	viper.SetDefault("HTTP_TIMEOUT", "30s")
+	viper.SetDefault("DNS_CACHE_TTL", "5m")
+	viper.SetDefault("HAR_MAX_ENTRIES", 50)
+	viper.SetDefault("PAGE_DIFF_RUNS", 0)
+	viper.SetDefault("FUZZY_THRESHOLD", 0)
+	viper.SetDefault("SUMMARY_THRESHOLD", 0)
+	viper.SetDefault("MAX_INVALID_RATIO", 0.2)
+	viper.SetDefault("MAINTENANCE_INTERVAL", "24h")
+	viper.SetDefault("BREAKER_THRESHOLD", 3)
+	viper.SetDefault("BREAKER_MAX_BACKOFF", "2h")
+	viper.SetDefault("ALERT_THRESHOLD", 3)
+	viper.SetDefault("HEARTBEAT_INTERVALS", 6)
+	viper.SetDefault("ENRICH_TIMEOUT", "5s")
+	viper.SetDefault("HISTORY_RETENTION", "2160h")
+	viper.SetDefault("PRUNE_UNREACHABLE_AFTER", "720h")
+	viper.SetDefault("BROKER_SUBJECT_PREFIX", "chrono-flow")
+	viper.SetDefault("BROKER_FORMAT", broker.FormatJSON)
+	viper.SetDefault("S3_USE_SSL", true)
+	viper.SetDefault("MAINTENANCE_WINDOW_MODE", WindowModeSkip)
+	viper.SetDefault("LOG_FILE_MAX_SIZE", 100)
+	viper.SetDefault("LOG_FILE_MAX_BACKUPS", 5)
+	viper.SetDefault("LOG_FILE_MAX_AGE", "720h")
+	viper.SetDefault("TELEGRAM_CHANNEL_SUMMARY", "0 9 * * *")
+	viper.SetDefault("TELEGRAM_CHANNEL_PIN_SUMMARY", true)
 
 	if viper.GetString("TELEGRAM_TOKEN") == "" {
 		return nil, ErrEmptyToken
@@ -47,19 +412,471 @@ func MustLoad() (*Config, error) {
 		return nil, fmt.Errorf("failed to get allowed IDs from environment variables: %w", err)
 	}
 
+	adminIDs, err := getInt64Slice(viper.GetStringSlice("ADMIN_CHAT_IDS"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get admin IDs from environment variables: %w", err)
+	}
+
+	filterGroups, err := parseFilterGroups(viper.GetString("FILTER_GROUPS"))
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := parseTables(viper.GetString("TABLES"))
+	if err != nil {
+		return nil, err
+	}
+
+	columnSynonyms, err := ParseColumnSynonyms(viper.GetString("COLUMN_SYNONYMS"))
+	if err != nil {
+		return nil, err
+	}
+
+	columnSelectors, err := ParseColumnSelectors(viper.GetString("COLUMN_SELECTORS"))
+	if err != nil {
+		return nil, err
+	}
+
+	extraFields, err := parseExtraFields(viper.GetString("EXTRA_FIELDS"))
+	if err != nil {
+		return nil, err
+	}
+
+	productData, err := loadProductData()
+	if err != nil {
+		return nil, err
+	}
+
+	computedFields, err := parseComputedFields(viper.GetString("COMPUTED_FIELDS"), extraFields, productData)
+	if err != nil {
+		return nil, err
+	}
+
+	alertRules, err := parseAlertRules(viper.GetString("ALERT_RULES"))
+	if err != nil {
+		return nil, err
+	}
+
+	normalizer, err := parseNormalizer(viper.GetString("NORMALIZE"), viper.GetString("NORMALIZE_REMOVE"))
+	if err != nil {
+		return nil, err
+	}
+
+	requestHeaders, err := ParseRequestHeaders(viper.GetString("REQUEST_HEADERS"))
+	if err != nil {
+		return nil, err
+	}
+
+	regions, err := ParseRegions(viper.GetString("REGIONS"))
+	if err != nil {
+		return nil, err
+	}
+
+	network, err := loadNetwork()
+	if err != nil {
+		return nil, err
+	}
+
+	clientTLS, err := loadClientTLS()
+	if err != nil {
+		return nil, err
+	}
+
+	fetchSteps, err := ParseFetchSteps(viper.GetString("FETCH_STEPS"))
+	if err != nil {
+		return nil, err
+	}
+
+	fetchRequest, err := ParseFetchRequest(viper.GetString("FETCH_REQUEST"))
+	if err != nil {
+		return nil, err
+	}
+
+	pageAssertions, err := ParsePageAssertions(viper.GetString("PAGE_ASSERTIONS"))
+	if err != nil {
+		return nil, err
+	}
+
+	encryptionKey, err := loadEncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+
+	fuzzyThreshold := viper.GetFloat64("FUZZY_THRESHOLD")
+	if fuzzyThreshold < 0 || fuzzyThreshold > 1 {
+		return nil, ErrInvalidFuzzyThreshold
+	}
+
+	maxInvalidRatio := viper.GetFloat64("MAX_INVALID_RATIO")
+	if maxInvalidRatio < 0 || maxInvalidRatio > 1 {
+		return nil, ErrInvalidMaxInvalidRatio
+	}
+
+	brokerConfig, err := loadBroker()
+	if err != nil {
+		return nil, err
+	}
+
+	s3Config, err := loadS3()
+	if err != nil {
+		return nil, err
+	}
+
+	logLevels, err := parseLogLevels(viper.GetString("LOG_LEVELS"))
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err := loadChannel()
+	if err != nil {
+		return nil, err
+	}
+
+	buttons, err := loadButtons()
+	if err != nil {
+		return nil, err
+	}
+
+	windows, err := schedule.ParseWindows(viper.GetString("MAINTENANCE_WINDOWS"))
+	if err != nil {
+		return nil, fmt.Errorf("error getting CF_MAINTENANCE_WINDOWS: %w", err)
+	}
+
+	windowMode := viper.GetString("MAINTENANCE_WINDOW_MODE")
+	if windowMode != WindowModeSkip && windowMode != WindowModeIgnore {
+		return nil, ErrInvalidWindowMode
+	}
+
+	role := viper.GetString("ROLE")
+	if role != RoleAll && role != RoleChecker && role != RoleBot {
+		return nil, ErrInvalidRole
+	}
+
+	simulate, err := parseSimulate(viper.GetString("SIMULATE"))
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
-		Env:         viper.GetString("ENV"),
-		URL:         viper.GetString("DEST_URL"),
-		StoragePath: viper.GetString("STORAGE_PATH"),
-		AllowedIDs:  allowedIDs,
-		Interval:    viper.GetDuration("CHECK_INTERVAL"),
+		Env:                   viper.GetString("ENV"),
+		Role:                  role,
+		Simulate:              simulate,
+		Force:                 viper.GetBool("FORCE"),
+		OutboxPollInterval:    viper.GetDuration("OUTBOX_POLL_INTERVAL"),
+		URL:                   viper.GetString("DEST_URL"),
+		StoragePath:           viper.GetString("STORAGE_PATH"),
+		QueryTimeout:          viper.GetDuration("QUERY_TIMEOUT"),
+		EncryptionKey:         encryptionKey,
+		AllowedIDs:            allowedIDs,
+		AdminIDs:              adminIDs,
+		FilterGroups:          filterGroups,
+		Tables:                tables,
+		ColumnSynonyms:        columnSynonyms,
+		ExtraFields:           extraFields,
+		ComputedFields:        computedFields,
+		ProductData:           productData,
+		AlertRules:            alertRules,
+		ColumnSelectors:       columnSelectors,
+		StreamingParser:       viper.GetBool("STREAMING_PARSER"),
+		BoundedMemory:         viper.GetBool("BOUNDED_MEMORY"),
+		IframeSelector:        viper.GetString("IFRAME_SELECTOR"),
+		FetchSteps:            fetchSteps,
+		FetchRequest:          fetchRequest,
+		PageAssertions:        pageAssertions,
+		Normalizer:            normalizer,
+		PageDiffRuns:          viper.GetInt("PAGE_DIFF_RUNS"),
+		RequestHeaders:        requestHeaders,
+		Regions:               regions,
+		Interval:              viper.GetDuration("CHECK_INTERVAL"),
+		HTTPTimeout:           viper.GetDuration("HTTP_TIMEOUT"),
+		DNSCacheTTL:           viper.GetDuration("DNS_CACHE_TTL"),
+		Network:               network,
+		ClientTLS:             clientTLS,
+		HARDir:                viper.GetString("HAR_DIR"),
+		HARMaxEntries:         viper.GetInt("HAR_MAX_ENTRIES"),
+		FuzzyThreshold:        fuzzyThreshold,
+		MaxInvalidRatio:       maxInvalidRatio,
+		SummaryThreshold:      viper.GetInt("SUMMARY_THRESHOLD"),
+		DedupWindow:           viper.GetDuration("DEDUP_WINDOW"),
+		DuplicateRunWindow:    viper.GetDuration("DUPLICATE_RUN_WINDOW"),
+		MaintenanceInterval:   viper.GetDuration("MAINTENANCE_INTERVAL"),
+		MaintenanceWindows:    windows,
+		MaintenanceWindowMode: windowMode,
+		BreakerThreshold:      viper.GetInt("BREAKER_THRESHOLD"),
+		BreakerMaxBackoff:     viper.GetDuration("BREAKER_MAX_BACKOFF"),
+		AlertThreshold:        viper.GetInt("ALERT_THRESHOLD"),
+		HeartbeatIntervals:    viper.GetInt("HEARTBEAT_INTERVALS"),
+		HeartbeatPingURL:      viper.GetString("HEARTBEAT_PING_URL"),
+		EnrichURL:             viper.GetString("ENRICH_URL"),
+		EnrichTimeout:         viper.GetDuration("ENRICH_TIMEOUT"),
+		HistoryRetention:      viper.GetDuration("HISTORY_RETENTION"),
+		PruneUnreachableAfter: viper.GetDuration("PRUNE_UNREACHABLE_AFTER"),
+		EventsLogFile:         viper.GetString("EVENTS_LOG_FILE"),
+		MetricsAddr:           viper.GetString("METRICS_ADDR"),
+		APIAddr:               viper.GetString("API_ADDR"),
+		GRPCAddr:              viper.GetString("GRPC_ADDR"),
+		APIAuth:               viper.GetBool("API_AUTH"),
+		ConfigDir:             configDir,
+		Pod: Pod{
+			Name:      viper.GetString("POD_NAME"),
+			Namespace: viper.GetString("POD_NAMESPACE"),
+		},
 		Tg: Telegram{
-			Token:   viper.GetString("TELEGRAM_TOKEN"),
-			Timeout: viper.GetDuration("TELEGRAM_TIMEOUT"),
+			Token:            viper.GetString("TELEGRAM_TOKEN"),
+			Timeout:          viper.GetDuration("TELEGRAM_TIMEOUT"),
+			Templates:        getTemplates("TELEGRAM_TEMPLATE_"),
+			VariantTemplates: getTemplates("TELEGRAM_TEMPLATE_B_"),
+			TemplatesFile:    viper.GetString("TELEGRAM_TEMPLATES_FILE"),
+			Feedback:         viper.GetBool("TELEGRAM_FEEDBACK"),
+			MaxLines:         viper.GetInt("TELEGRAM_MAX_LINES"),
+			Photos:           viper.GetBool("TELEGRAM_PHOTOS"),
+			RateLimit:        viper.GetInt("TELEGRAM_RATE_LIMIT"),
+			BroadcastWorkers: viper.GetInt("TELEGRAM_BROADCAST_WORKERS"),
+			BroadcastQueue:   viper.GetInt("TELEGRAM_BROADCAST_QUEUE"),
+			Channel:          channel,
+			Buttons:          buttons,
 		},
+		Broker: brokerConfig,
+		S3:     s3Config,
+		Log: Log{
+			File:       viper.GetString("LOG_FILE"),
+			MaxSize:    viper.GetInt("LOG_FILE_MAX_SIZE"),
+			MaxBackups: viper.GetInt("LOG_FILE_MAX_BACKUPS"),
+			MaxAge:     viper.GetDuration("LOG_FILE_MAX_AGE"),
+			Journald:   viper.GetBool("LOG_JOURNALD"),
+			Levels:     logLevels,
+		},
+	}, nil
+}
+
+// ForTenant returns a copy of the configuration for a tenant. The target page, the bot and its chats
+// come from the tenant, as do the maintenance windows if the tenant has any. The rest is shared with
+// the default tenant. Fetches of tenants aren't recorded to the HAR archive, as it only keeps the
+// fetches of a single target, and they don't post to the channel of the default bot or ping its
+// heartbeat monitor.
+func (c *Config) ForTenant(tenant models.Tenant) (*Config, error) {
+	tenantCfg := *c
+	tenantCfg.URL = tenant.URL
+	tenantCfg.AllowedIDs = tenant.AllowedIDs
+	tenantCfg.AdminIDs = tenant.AdminIDs
+	tenantCfg.Tg.Token = tenant.Token
+	tenantCfg.Tg.Channel = Channel{}
+	tenantCfg.HARDir = ""
+	tenantCfg.HeartbeatPingURL = ""
+
+	if tenant.MaintenanceWindows != "" {
+		windows, err := schedule.ParseWindows(tenant.MaintenanceWindows)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance windows of tenant %s: %w", tenant.ID, err)
+		}
+		tenantCfg.MaintenanceWindows = windows
+	}
+
+	return &tenantCfg, nil
+}
+
+// ForTarget returns the configuration of a target of the tenant: the page, the parser options and the
+// check interval come from the target, the rest from the tenant. Maintenance windows and the HAR
+// archive only apply to the main target.
+func (c *Config) ForTarget(target models.Target) *Config {
+	targetCfg := *c
+	targetCfg.URL = target.URL
+	targetCfg.Interval = target.Interval
+	targetCfg.Tables = make([]Table, 0, len(target.Tables))
+	for _, table := range target.Tables {
+		targetCfg.Tables = append(targetCfg.Tables, Table{Name: table.Name, Selector: table.Selector})
+	}
+	targetCfg.ColumnSelectors = target.ColumnSelectors
+	targetCfg.IframeSelector = target.IframeSelector
+	targetCfg.FetchSteps = target.Steps
+	targetCfg.FetchRequest = target.Request
+	targetCfg.PageAssertions = target.Assertions
+	targetCfg.RequestHeaders = target.Headers
+	targetCfg.Regions = target.Regions
+	targetCfg.Network = target.Network
+	targetCfg.ClientTLS = target.TLS
+	// Header texts are usually shared by the pages of a tenant, so a target without its own inherits them.
+	if len(target.ColumnSynonyms) > 0 {
+		targetCfg.ColumnSynonyms = target.ColumnSynonyms
+	}
+
+	if target.Name != models.MainTarget {
+		targetCfg.HARDir = ""
+		targetCfg.MaintenanceWindows = nil
+	}
+
+	return &targetCfg
+}
+
+// MainTarget returns the main target defined by the configuration, it's imported on first boot.
+func (c *Config) MainTarget() models.Target {
+	return models.Target{
+		Name:            models.MainTarget,
+		URL:             c.URL,
+		Tables:          targetTables(c.Tables),
+		ColumnSelectors: c.ColumnSelectors,
+		ColumnSynonyms:  c.ColumnSynonyms,
+		IframeSelector:  c.IframeSelector,
+		Steps:           c.FetchSteps,
+		Request:         c.FetchRequest,
+		Assertions:      c.PageAssertions,
+		Headers:         c.RequestHeaders,
+		Regions:         c.Regions,
+		Network:         c.Network,
+		TLS:             c.ClientTLS,
+		Interval:        c.Interval,
+	}
+}
+
+// targetTables converts the configured tables to the tables of a target.
+func targetTables(tables []Table) []models.TargetTable {
+	converted := make([]models.TargetTable, 0, len(tables))
+	for _, table := range tables {
+		converted = append(converted, models.TargetTable{Name: table.Name, Selector: table.Selector})
+	}
+
+	return converted
+}
+
+// loadS3 loads the object storage settings.
+func loadS3() (S3, error) {
+	cfg := S3{
+		Endpoint:  viper.GetString("S3_ENDPOINT"),
+		Bucket:    viper.GetString("S3_BUCKET"),
+		AccessKey: viper.GetString("S3_ACCESS_KEY"),
+		SecretKey: viper.GetString("S3_SECRET_KEY"),
+		Region:    viper.GetString("S3_REGION"),
+		UseSSL:    viper.GetBool("S3_USE_SSL"),
+		Prefix:    viper.GetString("S3_PREFIX"),
+	}
+	if cfg.Endpoint != "" && cfg.Bucket == "" {
+		return S3{}, ErrEmptyS3Bucket
+	}
+
+	return cfg, nil
+}
+
+// ReadDir reads the configuration from the files of the directory, e.g. a mounted ConfigMap: every file is
+// a variable named like the file, with or without the CF_ prefix, whose value is the trimmed content of the
+// file. Hidden files and directories are skipped, like the ..data of a ConfigMap.
+func ReadDir(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CF_CONFIG_DIR: %w", err)
+	}
+
+	values := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		// The keys of a ConfigMap are symlinks to the files of its current version.
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CF_CONFIG_DIR: %w", err)
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CF_CONFIG_DIR: %w", err)
+		}
+		key := strings.TrimPrefix(strings.ToUpper(entry.Name()), "CF_")
+		values[key] = strings.TrimSpace(string(data))
+	}
+
+	return values, nil
+}
+
+// loadConfigDir makes the variables of the configuration directory, see ReadDir, the configuration of
+// viper below the environment variables. An empty directory clears the variables loaded before.
+func loadConfigDir(dir string) error {
+	values := map[string]string{}
+	if dir != "" {
+		var err error
+		if values, err = ReadDir(dir); err != nil {
+			return err
+		}
+	}
+
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("error reading CF_CONFIG_DIR: %w", err)
+	}
+	viper.SetConfigType("json")
+	if err = viper.ReadConfig(bytes.NewReader(raw)); err != nil {
+		return fmt.Errorf("error reading CF_CONFIG_DIR: %w", err)
+	}
+
+	return nil
+}
+
+// loadEncryptionKey reads the encryption key of the database from CF_ENCRYPTION_KEY or from the file of
+// CF_ENCRYPTION_KEY_FILE, nil if neither is set.
+func loadEncryptionKey() ([]byte, error) {
+	const keySize = 32
+
+	encoded := viper.GetString("ENCRYPTION_KEY")
+	if path := viper.GetString("ENCRYPTION_KEY_FILE"); path != "" {
+		if encoded != "" {
+			return nil, ErrInvalidEncryptionKey
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CF_ENCRYPTION_KEY_FILE: %w", err)
+		}
+		encoded = string(data)
+	}
+	if encoded == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != keySize {
+		return nil, ErrInvalidEncryptionKey
+	}
+
+	return key, nil
+}
+
+// loadBroker loads the event publishing settings.
+func loadBroker() (Broker, error) {
+	format := viper.GetString("BROKER_FORMAT")
+	if format != broker.FormatJSON && format != broker.FormatProtobuf {
+		return Broker{}, ErrInvalidBrokerFormat
+	}
+
+	return Broker{
+		URL:           viper.GetString("BROKER_URL"),
+		Stream:        viper.GetString("BROKER_STREAM"),
+		SubjectPrefix: viper.GetString("BROKER_SUBJECT_PREFIX"),
+		Format:        format,
 	}, nil
 }
 
+// loadChannel reads the channel configuration, the summary is only posted if a channel is configured.
+func loadChannel() (Channel, error) {
+	channel := Channel{
+		ID:         viper.GetInt64("TELEGRAM_CHANNEL_ID"),
+		Silent:     viper.GetBool("TELEGRAM_CHANNEL_SILENT"),
+		PinSummary: viper.GetBool("TELEGRAM_CHANNEL_PIN_SUMMARY"),
+	}
+
+	if expr := viper.GetString("TELEGRAM_CHANNEL_SUMMARY"); channel.ID != 0 && expr != "" {
+		summary, err := schedule.ParseCron(expr)
+		if err != nil {
+			return Channel{}, fmt.Errorf("error getting CF_TELEGRAM_CHANNEL_SUMMARY: %w", err)
+		}
+		channel.Summary = &summary
+	}
+
+	return channel, nil
+}
+
 func getInt64Slice(stringSlice []string) ([]int64, error) {
 	int64Slice := make([]int64, 0, len(stringSlice))
 	for _, s := range stringSlice {
@@ -72,3 +889,511 @@ func getInt64Slice(stringSlice []string) ([]int64, error) {
 
 	return int64Slice, nil
 }
+
+// getTemplates returns the templates set with the prefix followed by the upper-cased change kind,
+// e.g. CF_TELEGRAM_TEMPLATE_ADDED.
+func getTemplates(prefix string) map[string]string {
+	templates := make(map[string]string)
+	for _, kind := range []string{"added", "changed", "renamed", "removed"} {
+		if template := viper.GetString(prefix + strings.ToUpper(kind)); template != "" {
+			templates[kind] = template
+		}
+	}
+
+	return templates
+}
+
+// parseFilterGroups parses groups in the "name=Type1,Type2;name2=Type3" format.
+func parseFilterGroups(raw string) (map[string][]string, error) {
+	groups := make(map[string][]string)
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, typesList, found := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !found || !filterGroupNameRe.MatchString(name) {
+			return nil, fmt.Errorf("%w: invalid entry %q", ErrInvalidFilterGroup, entry)
+		}
+
+		var types []string
+		for _, t := range strings.Split(typesList, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				types = append(types, t)
+			}
+		}
+		if len(types) == 0 {
+			return nil, fmt.Errorf("%w: group %q has no types", ErrInvalidFilterGroup, name)
+		}
+		groups[name] = types
+	}
+
+	return groups, nil
+}
+
+// parseLogLevels parses component levels in the "bot=debug;parser=warn" format.
+func parseLogLevels(raw string) (map[string]slog.Level, error) {
+	levels := make(map[string]slog.Level)
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		component, levelName, found := strings.Cut(entry, "=")
+		component = strings.TrimSpace(component)
+		var level slog.Level
+		if !found || !slices.Contains(logging.Components(), component) ||
+			level.UnmarshalText([]byte(strings.TrimSpace(levelName))) != nil {
+			return nil, fmt.Errorf("%w: invalid entry %q", ErrInvalidLogLevels, entry)
+		}
+		levels[component] = level
+	}
+
+	return levels, nil
+}
+
+// parseTables parses tables in the "name=selector;name2=selector2" format, keeping their order.
+func parseTables(raw string) ([]Table, error) {
+	var tables []Table
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, selector, found := strings.Cut(entry, "=")
+		name, selector = strings.TrimSpace(name), strings.TrimSpace(selector)
+		if !found || name == "" || selector == "" {
+			return nil, fmt.Errorf("%w: invalid entry %q", ErrInvalidTables, entry)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: duplicate table %q", ErrInvalidTables, name)
+		}
+		seen[name] = true
+		tables = append(tables, Table{Name: name, Selector: selector})
+	}
+
+	return tables, nil
+}
+
+// ParseTargetTables parses the tables of a target in the format of CF_TABLES. A value without "="
+// is the selector of a single unnamed table.
+func ParseTargetTables(raw string) ([]models.TargetTable, error) {
+	raw = strings.TrimSpace(raw)
+	if raw != "" && !strings.Contains(raw, "=") {
+		return []models.TargetTable{{Selector: raw}}, nil
+	}
+
+	tables, err := parseTables(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return targetTables(tables), nil
+}
+
+// ParseColumnSynonyms parses header synonyms in the "field=Header1,Header2;field2=Header3" format.
+func ParseColumnSynonyms(raw string) (map[string][]string, error) {
+	synonyms := make(map[string][]string)
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		field, headerList, found := strings.Cut(entry, "=")
+		field = strings.TrimSpace(field)
+		if !found || !slices.Contains(parser.Fields(), field) {
+			return nil, fmt.Errorf("%w: invalid entry %q", ErrInvalidColumnSynonyms, entry)
+		}
+
+		for _, header := range strings.Split(headerList, ",") {
+			if header = strings.TrimSpace(header); header != "" {
+				synonyms[field] = append(synonyms[field], header)
+			}
+		}
+		if len(synonyms[field]) == 0 {
+			return nil, fmt.Errorf("%w: field %q has no headers", ErrInvalidColumnSynonyms, field)
+		}
+	}
+
+	return synonyms, nil
+}
+
+// parseExtraFields parses extra fields in the "field=Header1,Header2;field2" format, a field without
+// headers is recognized by its name only.
+func parseExtraFields(raw string) (map[string][]string, error) {
+	fields := make(map[string][]string)
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		field, headerList, _ := strings.Cut(entry, "=")
+		field = strings.TrimSpace(field)
+		if _, seen := fields[field]; seen || field == "" || slices.Contains(parser.Fields(), strings.ToLower(field)) {
+			return nil, fmt.Errorf("%w: invalid entry %q", ErrInvalidExtraFields, entry)
+		}
+
+		fields[field] = []string{}
+		for _, header := range strings.Split(headerList, ",") {
+			if header = strings.TrimSpace(header); header != "" {
+				fields[field] = append(fields[field], header)
+			}
+		}
+	}
+
+	return fields, nil
+}
+
+// parseComputedFields parses computed fields in the "field=expression;field2=expression" format. The
+// names may hold letters, digits and underscores only and must not name a built-in or an extra field. An
+// expression may read the price, the quantity, the extra fields, the data columns and the fields before it.
+func parseComputedFields(raw string, extraFields map[string][]string, data compute.Data) ([]compute.Field, error) {
+	known := data.Columns()
+	known["price"], known["quantity"] = true, true
+	for field := range extraFields {
+		known[strings.ToLower(field)] = true
+	}
+
+	var fields []compute.Field
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, source, _ := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		lower := strings.ToLower(name)
+		if !validFieldName(name) || known[lower] || slices.Contains(parser.Fields(), lower) {
+			return nil, fmt.Errorf("%w: invalid entry %q", ErrInvalidComputedFields, entry)
+		}
+		expr, err := compute.Parse(strings.TrimSpace(source))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidComputedFields, err)
+		}
+		for _, variable := range expr.Variables() {
+			if !known[variable] {
+				return nil, fmt.Errorf("%w: field %q reads unknown %q", ErrInvalidComputedFields, name, variable)
+			}
+		}
+
+		known[lower] = true
+		fields = append(fields, compute.Field{Name: name, Expression: expr})
+	}
+
+	return fields, nil
+}
+
+// parseAlertRules parses the alert rules of the deployment in the "name=condition;name2=condition" format,
+// see rules.Compile.
+func parseAlertRules(raw string) ([]rules.Rule, error) {
+	var alertRules []rules.Rule
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, condition, _ := strings.Cut(entry, "=")
+		rule, err := rules.Compile(models.AlertRule{
+			Name: strings.TrimSpace(name), Expression: strings.TrimSpace(condition),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidAlertRules, err)
+		}
+		alertRules = append(alertRules, rule)
+	}
+
+	return alertRules, nil
+}
+
+// parseNormalizer parses the normalization steps of the page in the "scripts,whitespace" format and the
+// selector of the elements to remove, see normalize.New.
+func parseNormalizer(rawSteps, remove string) (*normalize.Normalizer, error) {
+	var steps []string
+	for _, step := range strings.Split(rawSteps, ",") {
+		if step = strings.TrimSpace(step); step != "" {
+			steps = append(steps, step)
+		}
+	}
+
+	normalizer, err := normalize.New(steps, remove)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidNormalize, err)
+	}
+
+	return normalizer, nil
+}
+
+// parseSimulate parses the change kinds to simulate in the "added,changed" format, "all" is every kind.
+func parseSimulate(raw string) ([]string, error) {
+	kinds := []string{models.KindAdded, models.KindChanged, models.KindRenamed, models.KindRemoved}
+	if strings.TrimSpace(raw) == "all" {
+		return kinds, nil
+	}
+
+	var simulate []string
+	for _, kind := range strings.Split(raw, ",") {
+		kind = strings.TrimSpace(kind)
+		if kind == "" {
+			continue
+		}
+		if !slices.Contains(kinds, kind) {
+			return nil, ErrInvalidSimulate
+		}
+		simulate = append(simulate, kind)
+	}
+
+	return simulate, nil
+}
+
+// validFieldName reports whether the name of a computed field holds letters, digits and underscores only.
+func validFieldName(name string) bool {
+	return name != "" && !strings.ContainsFunc(name, func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '_'
+	})
+}
+
+// loadProductData reads the product data of the computed fields from the CSV file of
+// CF_PRODUCT_DATA_FILE, empty if it isn't set.
+func loadProductData() (compute.Data, error) {
+	path := viper.GetString("PRODUCT_DATA_FILE")
+	if path == "" {
+		return compute.Data{}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CF_PRODUCT_DATA_FILE: %w", err)
+	}
+	defer file.Close()
+
+	data, err := compute.LoadData(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CF_PRODUCT_DATA_FILE: %w", err)
+	}
+
+	return data, nil
+}
+
+// loadButtons reads the rows of link buttons of the notifications from the JSON file of
+// CF_TELEGRAM_BUTTONS_FILE, none if it isn't set. A button links to an http, https or tg URL.
+func loadButtons() ([][]Button, error) {
+	path := viper.GetString("TELEGRAM_BUTTONS_FILE")
+	if path == "" {
+		return nil, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CF_TELEGRAM_BUTTONS_FILE: %w", err)
+	}
+
+	var rows [][]Button
+	if err = json.Unmarshal(raw, &rows); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidButtons, err)
+	}
+	for _, row := range rows {
+		if len(row) == 0 {
+			return nil, fmt.Errorf("%w: empty row", ErrInvalidButtons)
+		}
+		for _, button := range row {
+			link, parseErr := url.Parse(button.URL)
+			if strings.TrimSpace(button.Text) == "" || parseErr != nil ||
+				!slices.Contains([]string{"http", "https", "tg"}, link.Scheme) || link.Host == "" {
+				return nil, fmt.Errorf("%w: invalid button %q linking to %q",
+					ErrInvalidButtons, button.Text, button.URL)
+			}
+		}
+	}
+
+	return rows, nil
+}
+
+// ParseColumnSelectors parses field selectors in the "field=selector@attr;field2=selector" format.
+func ParseColumnSelectors(raw string) (map[string]string, error) {
+	selectors := make(map[string]string)
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		field, selector, found := strings.Cut(entry, "=")
+		field, selector = strings.TrimSpace(field), strings.TrimSpace(selector)
+		if !found || selector == "" || !slices.Contains(parser.Fields(), field) {
+			return nil, fmt.Errorf("%w: invalid entry %q", ErrInvalidColumnSelectors, entry)
+		}
+		selectors[field] = selector
+	}
+
+	return selectors, nil
+}
+
+// ParseRequestHeaders parses request headers given as a JSON object of header names and values. Unlike the
+// other options it's JSON, as header values like "de-DE,de;q=0.9" contain their separators.
+func ParseRequestHeaders(raw string) (map[string]string, error) {
+	headers := make(map[string]string)
+	if strings.TrimSpace(raw) == "" {
+		return headers, nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), &headers); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidHeaders, err)
+	}
+	if err := models.ValidateTargetHeaders(headers); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidHeaders, err)
+	}
+
+	return headers, nil
+}
+
+// ParseFetchSteps parses the fetch steps given as a JSON array of models.TargetStep, as their regexes and
+// URL templates contain any separator.
+func ParseFetchSteps(raw string) ([]models.TargetStep, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var steps []models.TargetStep
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&steps); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFetchSteps, err)
+	}
+	if err := models.ValidateTargetSteps(steps); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFetchSteps, err)
+	}
+
+	return steps, nil
+}
+
+// ParseFetchRequest parses the request of the page given as a JSON object of models.TargetRequest, e.g.
+// {"method": "POST", "form": {"category": "phones"}}.
+func ParseFetchRequest(raw string) (models.TargetRequest, error) {
+	if strings.TrimSpace(raw) == "" {
+		return models.TargetRequest{}, nil
+	}
+
+	var request models.TargetRequest
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		return models.TargetRequest{}, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+	if err := request.Validate(); err != nil {
+		return models.TargetRequest{}, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+
+	return request, nil
+}
+
+// ParsePageAssertions parses the sanity checks of the page given as a JSON object of models.PageAssertions,
+// e.g. {"contains": ["Price list"], "not_contains": ["maintenance mode"], "min_rows": 10}.
+func ParsePageAssertions(raw string) (models.PageAssertions, error) {
+	if strings.TrimSpace(raw) == "" {
+		return models.PageAssertions{}, nil
+	}
+
+	var assertions models.PageAssertions
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&assertions); err != nil {
+		return models.PageAssertions{}, fmt.Errorf("%w: %w", ErrInvalidAssertions, err)
+	}
+	if err := assertions.Validate(); err != nil {
+		return models.PageAssertions{}, fmt.Errorf("%w: %w", ErrInvalidAssertions, err)
+	}
+
+	return assertions, nil
+}
+
+// ParseRegions parses regional profiles given as a JSON object of region names and their request headers,
+// e.g. {"de": {"Accept-Language": "de-DE"}}. The regions are ordered by name.
+func ParseRegions(raw string) ([]models.TargetRegion, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var profiles map[string]map[string]string
+	if err := json.Unmarshal([]byte(raw), &profiles); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRegions, err)
+	}
+
+	regions := make([]models.TargetRegion, 0, len(profiles))
+	for _, name := range slices.Sorted(maps.Keys(profiles)) {
+		regions = append(regions, models.TargetRegion{Name: name, Headers: profiles[name]})
+	}
+	if err := models.ValidateTargetRegions(regions); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRegions, err)
+	}
+
+	return regions, nil
+}
+
+// loadNetwork loads the network options of the connections to the page.
+func loadNetwork() (models.TargetNetwork, error) {
+	hosts, err := ParseDNSHosts(viper.GetString("DNS_HOSTS"))
+	if err != nil {
+		return models.TargetNetwork{}, err
+	}
+
+	network := models.TargetNetwork{
+		IPVersion: strings.ToLower(strings.TrimSpace(viper.GetString("IP_VERSION"))),
+		Hosts:     hosts,
+		Resolver:  strings.TrimSpace(viper.GetString("DNS_RESOLVER")),
+	}
+	if err = network.Validate(); err != nil {
+		return models.TargetNetwork{}, fmt.Errorf("%w: %w", ErrInvalidNetwork, err)
+	}
+
+	return network, nil
+}
+
+// loadClientTLS loads the client certificate the page is fetched with, it's checked to load so a broken one
+// fails the start rather than every check.
+func loadClientTLS() (models.TargetTLS, error) {
+	clientTLS := models.TargetTLS{
+		Cert: strings.TrimSpace(viper.GetString("TLS_CLIENT_CERT")),
+		Key:  strings.TrimSpace(viper.GetString("TLS_CLIENT_KEY")),
+	}
+	if clientTLS.IsZero() {
+		return clientTLS, nil
+	}
+	if _, err := httpclient.ClientCertificate(clientTLS); err != nil {
+		return models.TargetTLS{}, fmt.Errorf("%w: %w", ErrInvalidClientTLS, err)
+	}
+
+	return clientTLS, nil
+}
+
+// ParseDNSHosts parses the static addresses of hosts in the "host=ip;host2=ip" format, nil if there are
+// none. The addresses are checked by models.TargetNetwork.Validate.
+func ParseDNSHosts(raw string) (map[string]string, error) {
+	var hosts map[string]string
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		host, addr, found := strings.Cut(entry, "=")
+		host, addr = strings.ToLower(strings.TrimSpace(host)), strings.TrimSpace(addr)
+		if !found || host == "" || addr == "" {
+			return nil, fmt.Errorf("%w: invalid entry %q", ErrInvalidDNSHosts, entry)
+		}
+		if hosts == nil {
+			hosts = make(map[string]string)
+		}
+		hosts[host] = addr
+	}
+
+	return hosts, nil
+}
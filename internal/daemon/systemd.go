@@ -0,0 +1,66 @@
+package daemon
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/daemon"
+)
+
+// Notifier reports the service state to systemd (sd_notify).
+// Outside of systemd, when NOTIFY_SOCKET is not set, every call is a no-op.
+type Notifier struct {
+	log    *slog.Logger
+	notify func(state string) (bool, error)
+}
+
+// NewNotifier creates a new Notifier instance.
+func NewNotifier(log *slog.Logger) *Notifier {
+	return &Notifier{
+		log: log,
+		notify: func(state string) (bool, error) {
+			return daemon.SdNotify(false, state)
+		},
+	}
+}
+
+// Ready tells systemd the service finished starting up.
+func (n *Notifier) Ready(ctx context.Context) {
+	n.send(ctx, daemon.SdNotifyReady)
+}
+
+// Stopping tells systemd the service is shutting down.
+func (n *Notifier) Stopping(ctx context.Context) {
+	n.send(ctx, daemon.SdNotifyStopping)
+}
+
+// Watchdog sends a keepalive to the systemd watchdog.
+func (n *Notifier) Watchdog(ctx context.Context) {
+	n.send(ctx, daemon.SdNotifyWatchdog)
+}
+
+// WatchdogInterval returns how often keepalives should be sent, or zero if the watchdog is disabled.
+// Keepalives are sent twice per WatchdogSec, as recommended by sd_watchdog_enabled(3).
+func (n *Notifier) WatchdogInterval(ctx context.Context) time.Duration {
+	const keepalivesPerTimeout = 2
+	timeout, err := daemon.SdWatchdogEnabled(false)
+	if err != nil {
+		n.log.WarnContext(ctx, "invalid systemd watchdog configuration", "error", err)
+		return 0
+	}
+
+	return timeout / keepalivesPerTimeout
+}
+
+// send delivers a state to systemd, failures are logged since they must not stop the service.
+func (n *Notifier) send(ctx context.Context, state string) {
+	sent, err := n.notify(state)
+	if err != nil {
+		n.log.WarnContext(ctx, "failed to notify systemd", "state", state, "error", err)
+		return
+	}
+	if sent {
+		n.log.DebugContext(ctx, "Notified systemd", "state", state)
+	}
+}
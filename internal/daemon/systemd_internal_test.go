@@ -0,0 +1,46 @@
+package daemon
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/daemon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifier(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("sends states", func(t *testing.T) {
+		var states []string
+		notifier := &Notifier{log: logger, notify: func(state string) (bool, error) {
+			states = append(states, state)
+			return true, nil
+		}}
+
+		notifier.Ready(t.Context())
+		notifier.Watchdog(t.Context())
+		notifier.Stopping(t.Context())
+
+		assert.Equal(t, []string{daemon.SdNotifyReady, daemon.SdNotifyWatchdog, daemon.SdNotifyStopping}, states)
+	})
+
+	t.Run("failures are not fatal", func(t *testing.T) {
+		notifier := &Notifier{log: logger, notify: func(string) (bool, error) { return false, assert.AnError }}
+
+		assert.NotPanics(t, func() { notifier.Ready(t.Context()) })
+	})
+
+	t.Run("watchdog interval", func(t *testing.T) {
+		notifier := NewNotifier(logger)
+
+		t.Setenv("WATCHDOG_USEC", "")
+		assert.Zero(t, notifier.WatchdogInterval(t.Context()))
+
+		t.Setenv("WATCHDOG_USEC", "30000000")
+		t.Setenv("WATCHDOG_PID", "")
+		assert.Equal(t, 15*time.Second, notifier.WatchdogInterval(t.Context()))
+	})
+}
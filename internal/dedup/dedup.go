@@ -0,0 +1,102 @@
+// Package dedup suppresses identical notifications sent to the same recipient within a short window,
+// even when they are delivered by different notifiers.
+package dedup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// key identifies a notification delivered to a recipient.
+type key struct {
+	recipient   string
+	fingerprint string
+}
+
+// Deduplicator remembers which notifications recipients received and rejects repeats within the
+// window. A nil Deduplicator lets every notification through. It's safe for concurrent use,
+// so a single instance can be shared by all notifiers.
+type Deduplicator struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	sent map[key]time.Time
+}
+
+// New creates a deduplicator rejecting repeats within window, nil if the window isn't positive.
+func New(window time.Duration) *Deduplicator {
+	if window <= 0 {
+		return nil
+	}
+
+	return &Deduplicator{window: window, now: time.Now, sent: make(map[key]time.Time)}
+}
+
+// ChatRecipient returns the recipient key of a chat. Notifiers reaching the same person
+// must use the same recipient key for their notifications to be deduplicated.
+func ChatRecipient(chatID int64) string {
+	return "chat:" + strconv.FormatInt(chatID, 10)
+}
+
+// Claim reports whether the notification with the fingerprint may be sent to the recipient and
+// records it as sent if so. A notification that failed to send should be released with Release.
+func (d *Deduplicator) Claim(recipient, fingerprint string) bool {
+	if d == nil {
+		return true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	for sentKey, sentAt := range d.sent {
+		if now.Sub(sentAt) >= d.window {
+			delete(d.sent, sentKey)
+		}
+	}
+
+	notificationKey := key{recipient: recipient, fingerprint: fingerprint}
+	if _, ok := d.sent[notificationKey]; ok {
+		return false
+	}
+	d.sent[notificationKey] = now
+
+	return true
+}
+
+// Release forgets a claimed notification, so it can be sent again.
+func (d *Deduplicator) Release(recipient, fingerprint string) {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.sent, key{recipient: recipient, fingerprint: fingerprint})
+}
+
+// Fingerprint identifies a change set by its content. It doesn't depend on the order of the
+// changes or the time they were detected, so the same changes detected twice match.
+func Fingerprint(changes *models.Changes) string {
+	records := changes.Records(time.Time{})
+	lines := make([]string, 0, len(records))
+	for _, record := range records {
+		lines = append(lines, strings.Join([]string{
+			record.Kind, record.Category, record.Model, record.OldModel, record.Type,
+			record.OldPrice, record.Price, record.OldQuantity, record.Quantity,
+		}, "\x1f"))
+	}
+	sort.Strings(lines)
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\x1e")))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,54 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeduplicator_Claim(t *testing.T) {
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	dedup := New(10 * time.Minute)
+	dedup.now = func() time.Time { return now }
+
+	assert.True(t, dedup.Claim(ChatRecipient(1), "a"))
+	assert.False(t, dedup.Claim(ChatRecipient(1), "a"), "repeat within the window")
+	assert.True(t, dedup.Claim(ChatRecipient(2), "a"), "another recipient")
+	assert.True(t, dedup.Claim(ChatRecipient(1), "b"), "another notification")
+
+	dedup.Release(ChatRecipient(2), "a")
+	assert.True(t, dedup.Claim(ChatRecipient(2), "a"), "released after a failed delivery")
+
+	now = now.Add(10 * time.Minute)
+	assert.True(t, dedup.Claim(ChatRecipient(1), "a"), "the window has passed")
+	assert.Len(t, dedup.sent, 1, "expired notifications are forgotten")
+}
+
+func TestDeduplicator_Disabled(t *testing.T) {
+	dedup := New(0)
+
+	assert.Nil(t, dedup)
+	assert.True(t, dedup.Claim(ChatRecipient(1), "a"))
+	assert.True(t, dedup.Claim(ChatRecipient(1), "a"))
+	assert.NotPanics(t, func() { dedup.Release(ChatRecipient(1), "a") })
+}
+
+func TestFingerprint(t *testing.T) {
+	first := &models.Changes{
+		Added:   []models.Product{{Model: "A1", Price: "100"}, {Model: "B1", Price: "200"}},
+		Removed: []models.Product{{Model: "C1"}},
+	}
+	reordered := &models.Changes{
+		Added:   []models.Product{{Model: "B1", Price: "200"}, {Model: "A1", Price: "100"}},
+		Removed: []models.Product{{Model: "C1"}},
+	}
+	otherPrice := &models.Changes{
+		Added:   []models.Product{{Model: "A1", Price: "90"}, {Model: "B1", Price: "200"}},
+		Removed: []models.Product{{Model: "C1"}},
+	}
+
+	assert.Equal(t, Fingerprint(first), Fingerprint(reordered))
+	assert.NotEqual(t, Fingerprint(first), Fingerprint(otherPrice))
+}
@@ -0,0 +1,103 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// Event names of the emitted records.
+const (
+	ProductAdded   = "product_added"
+	ProductRemoved = "product_removed"
+	ProductChanged = "product_changed"
+	ProductRenamed = "product_renamed"
+)
+
+// Logger emits every detected change as a structured record, so log pipelines
+// can index and alert on individual product events.
+type Logger struct {
+	log *slog.Logger
+}
+
+// NewLogger creates an event logger writing JSON records to w.
+// Events are always written at the info level, regardless of the application log level.
+func NewLogger(w io.Writer) *Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelInfo})
+
+	return &Logger{log: slog.New(handler).With("channel", "events")}
+}
+
+// NewRunID returns a random identifier which ties together the events of one check run.
+func NewRunID() string {
+	const runIDBytes = 8
+	buf := make([]byte, runIDBytes)
+	_, _ = rand.Read(buf) // crypto/rand.Read never returns an error.
+
+	return hex.EncodeToString(buf)
+}
+
+// runIDKey is the context key of the check run ID.
+type runIDKey struct{}
+
+// WithRunID returns a context carrying the ID of the check run.
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, runID)
+}
+
+// RunIDFromContext returns the ID of the check run, empty if the context doesn't carry one.
+func RunIDFromContext(ctx context.Context) string {
+	runID, _ := ctx.Value(runIDKey{}).(string)
+	return runID
+}
+
+// LogChanges emits one record per changed product.
+func (l *Logger) LogChanges(ctx context.Context, runID string, changes *models.Changes) {
+	for _, p := range changes.Added {
+		l.log.InfoContext(ctx, "Product added", productAttrs(ProductAdded, runID, p)...)
+	}
+	for _, p := range changes.Removed {
+		l.log.InfoContext(ctx, "Product removed", productAttrs(ProductRemoved, runID, p)...)
+	}
+	for _, change := range changes.Changed {
+		l.log.InfoContext(ctx, "Product changed", changeAttrs(ProductChanged, runID, change)...)
+	}
+	for _, change := range changes.Renamed {
+		l.log.InfoContext(ctx, "Product renamed",
+			append(changeAttrs(ProductRenamed, runID, change), slog.String("old_model", change.Old.Model))...)
+	}
+}
+
+// productAttrs returns the attributes of an added or removed product event.
+func productAttrs(event, runID string, p models.Product) []any {
+	return []any{
+		slog.String("event", event),
+		slog.String("run_id", runID),
+		slog.String("model", p.Model),
+		slog.String("category", p.Category),
+		slog.String("type", p.Type),
+		slog.String("price", p.Price),
+		slog.String("quantity", p.Quantity),
+		slog.String("url", p.ProductURL),
+	}
+}
+
+// changeAttrs returns the attributes of a changed or renamed product event.
+func changeAttrs(event, runID string, change models.ChangeInfo) []any {
+	return []any{
+		slog.String("event", event),
+		slog.String("run_id", runID),
+		slog.String("model", change.New.Model),
+		slog.String("category", change.New.Category),
+		slog.String("type", change.New.Type),
+		slog.String("old_price", change.Old.Price),
+		slog.String("new_price", change.New.Price),
+		slog.String("old_quantity", change.Old.Quantity),
+		slog.String("new_quantity", change.New.Quantity),
+		slog.String("url", change.New.ProductURL),
+	}
+}
@@ -0,0 +1,67 @@
+package events_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/events"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_LogChanges(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := events.NewLogger(&buf)
+
+	changes := &models.Changes{
+		Added: []models.Product{{Model: "C3", Price: "300"}},
+		Changed: []models.ChangeInfo{
+			{Old: models.Product{Model: "A1", Price: "100"}, New: models.Product{Model: "A1", Price: "110"}},
+		},
+		Renamed: []models.ChangeInfo{
+			{Old: models.Product{Model: "B2"}, New: models.Product{Model: "B2-1"}},
+		},
+	}
+	logger.LogChanges(t.Context(), "run-1", changes)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+
+	records := make([]map[string]any, 0, len(lines))
+	for _, line := range lines {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		assert.Equal(t, "events", record["channel"])
+		assert.Equal(t, "run-1", record["run_id"])
+		records = append(records, record)
+	}
+
+	assert.Equal(t, events.ProductAdded, records[0]["event"])
+	assert.Equal(t, "C3", records[0]["model"])
+	assert.Equal(t, events.ProductChanged, records[1]["event"])
+	assert.Equal(t, "100", records[1]["old_price"])
+	assert.Equal(t, "110", records[1]["new_price"])
+	assert.Equal(t, events.ProductRenamed, records[2]["event"])
+	assert.Equal(t, "B2", records[2]["old_model"])
+}
+
+func TestNewRunID(t *testing.T) {
+	t.Parallel()
+
+	first, second := events.NewRunID(), events.NewRunID()
+
+	assert.Len(t, first, 16)
+	assert.NotEqual(t, first, second)
+}
+
+func TestRunIDContext(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, events.RunIDFromContext(t.Context()))
+	assert.Equal(t, "run", events.RunIDFromContext(events.WithRunID(t.Context(), "run")))
+}
@@ -0,0 +1,38 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/blob"
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// ArchivePrefix is the key prefix of the change exports kept in object storage.
+const ArchivePrefix = "exports/"
+
+// ArchiveKey returns the key the changes of a check are archived under, grouped by day,
+// e.g. "exports/2025/07/01/120000-<run id>.csv".
+func ArchiveKey(runID string, detectedAt time.Time) string {
+	detectedAt = detectedAt.UTC()
+
+	return path.Join(ArchivePrefix+detectedAt.Format("2006/01/02"), detectedAt.Format("150405")+"-"+runID+".csv")
+}
+
+// Archive exports the changes of a check as CSV and stores them under ArchiveKey.
+func Archive(ctx context.Context, store blob.Store, runID string, detectedAt time.Time, changes *models.Changes) error {
+	var buf bytes.Buffer
+	if err := ChangesCSV(&buf, changes); err != nil {
+		return err
+	}
+
+	key := ArchiveKey(runID, detectedAt)
+	if err := store.Put(ctx, key, &buf, int64(buf.Len()), "text/csv"); err != nil {
+		return fmt.Errorf("failed to archive changes: %w", err)
+	}
+
+	return nil
+}
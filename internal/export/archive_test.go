@@ -0,0 +1,79 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore keeps objects in memory.
+type memoryStore struct {
+	objects map[string][]byte
+	types   map[string]string
+	err     error
+}
+
+func (m *memoryStore) Put(_ context.Context, key string, body io.Reader, _ int64, contentType string) error {
+	if m.err != nil {
+		return m.err
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	m.objects[key] = data
+	m.types[key] = contentType
+
+	return nil
+}
+
+func (m *memoryStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(m.objects[key])), nil
+}
+
+func (m *memoryStore) Delete(_ context.Context, key string) error {
+	delete(m.objects, key)
+	return nil
+}
+
+func (m *memoryStore) List(context.Context, string) ([]string, error) {
+	return nil, nil
+}
+
+func TestArchive(t *testing.T) {
+	detectedAt := time.Date(2025, 7, 1, 14, 30, 5, 0, time.FixedZone("EEST", 3*60*60))
+
+	t.Run("success", func(t *testing.T) {
+		store := &memoryStore{objects: make(map[string][]byte), types: make(map[string]string)}
+		changes := &models.Changes{Removed: []models.Product{{Model: "R1", Price: "5"}}}
+
+		err := export.Archive(t.Context(), store, "abc123", detectedAt, changes)
+
+		require.NoError(t, err)
+		key := "exports/2025/07/01/113005-abc123.csv"
+		assert.Equal(t, key, export.ArchiveKey("abc123", detectedAt))
+		require.Contains(t, store.objects, key)
+		assert.Equal(t, "text/csv", store.types[key])
+		records, err := csv.NewReader(bytes.NewReader(store.objects[key])).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, "removed", records[1][0])
+	})
+
+	t.Run("error: store", func(t *testing.T) {
+		store := &memoryStore{err: assert.AnError}
+
+		err := export.Archive(t.Context(), store, "abc123", detectedAt, &models.Changes{})
+
+		require.ErrorIs(t, err, assert.AnError)
+		require.ErrorContains(t, err, "failed to archive changes")
+	})
+}
@@ -0,0 +1,188 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// Formats of an imported baseline.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+var (
+	// ErrUnknownFormat is returned for a baseline format other than FormatCSV and FormatJSON.
+	ErrUnknownFormat = errors.New("unknown baseline format")
+	// ErrInvalidBaseline is returned when a baseline can't be read.
+	ErrInvalidBaseline = errors.New("invalid baseline")
+)
+
+// BaselineEntry is a product as it was recorded at some point, e.g. a row of a monitoring spreadsheet.
+// In CSV files the fields are columns named after the JSON keys, in any order.
+type BaselineEntry struct {
+	Model      string `json:"model"`
+	Type       string `json:"type"`
+	Price      string `json:"price"`
+	Quantity   string `json:"quantity"`
+	ImageURL   string `json:"image_url"`
+	Category   string `json:"category"`
+	ProductURL string `json:"url"`
+	// At is when the product was recorded, an RFC 3339 time or a date. Entries without it are
+	// recorded at the time of the import.
+	At string `json:"at"`
+}
+
+// Baseline is an imported product list with the history its entries imply.
+type Baseline struct {
+	// Products are the latest entries of the products, they become the stored state.
+	Products []models.Product
+	// History holds the changes between the entries of a product, grouped by time, oldest first.
+	// The first entry of a product is an addition.
+	History []BaselineChanges
+}
+
+// BaselineChanges are the changes of a baseline recorded at the same time.
+type BaselineChanges struct {
+	At      time.Time
+	Changes *models.Changes
+}
+
+// ReadBaseline reads the entries of a baseline in the format.
+func ReadBaseline(r io.Reader, format string) ([]BaselineEntry, error) {
+	switch format {
+	case FormatCSV:
+		return readBaselineCSV(r)
+	case FormatJSON:
+		var entries []BaselineEntry
+		if err := json.NewDecoder(r).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidBaseline, err)
+		}
+		return entries, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+// readBaselineCSV reads baseline entries from a CSV document with a header row.
+func readBaselineCSV(r io.Reader) ([]BaselineEntry, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBaseline, err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	header := rows[0]
+	for idx := range header {
+		header[idx] = strings.ToLower(strings.TrimSpace(header[idx]))
+	}
+	if !slices.Contains(header, "model") {
+		return nil, fmt.Errorf("%w: the header has no model column", ErrInvalidBaseline)
+	}
+
+	entries := make([]BaselineEntry, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		var entry BaselineEntry
+		fields := map[string]*string{
+			"model": &entry.Model, "type": &entry.Type, "price": &entry.Price, "quantity": &entry.Quantity,
+			"image_url": &entry.ImageURL, "category": &entry.Category, "url": &entry.ProductURL, "at": &entry.At,
+		}
+		for idx, column := range header {
+			if field, ok := fields[column]; ok {
+				*field = strings.TrimSpace(row[idx])
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// NewBaseline validates the entries and builds the baseline they describe. Entries without a time
+// are recorded at importedAt.
+func NewBaseline(entries []BaselineEntry, importedAt time.Time) (*Baseline, error) {
+	type recorded struct {
+		at      time.Time
+		product models.Product
+	}
+
+	records := make([]recorded, 0, len(entries))
+	for idx, entry := range entries {
+		product := entry.product()
+		if err := product.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: entry %d: %w", ErrInvalidBaseline, idx+1, err)
+		}
+		at, err := parseBaselineTime(entry.At, importedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%w: entry %d: %w", ErrInvalidBaseline, idx+1, err)
+		}
+		records = append(records, recorded{at: at, product: product})
+	}
+	slices.SortStableFunc(records, func(a, b recorded) int { return a.at.Compare(b.at) })
+
+	baseline := &Baseline{}
+	latest := make(map[string]int)
+	for _, record := range records {
+		if len(baseline.History) == 0 || !baseline.History[len(baseline.History)-1].At.Equal(record.at) {
+			baseline.History = append(baseline.History, BaselineChanges{At: record.at, Changes: &models.Changes{}})
+		}
+		changes := baseline.History[len(baseline.History)-1].Changes
+
+		key := record.product.Category + "\x00" + record.product.Model
+		idx, seen := latest[key]
+		switch {
+		case !seen:
+			latest[key] = len(baseline.Products)
+			baseline.Products = append(baseline.Products, record.product)
+			changes.Added = append(changes.Added, record.product)
+		case record.product.Updated(baseline.Products[idx]):
+			changes.Changed = append(changes.Changed,
+				models.ChangeInfo{Old: baseline.Products[idx], New: record.product})
+			baseline.Products[idx] = record.product
+		default:
+			baseline.Products[idx] = record.product
+		}
+	}
+	baseline.History = slices.DeleteFunc(baseline.History, func(group BaselineChanges) bool {
+		return !group.Changes.HasChanges()
+	})
+
+	return baseline, nil
+}
+
+// product returns the product of the entry.
+func (e BaselineEntry) product() models.Product {
+	return models.Product{
+		Model:      e.Model,
+		Type:       e.Type,
+		Quantity:   e.Quantity,
+		ImageURL:   e.ImageURL,
+		Price:      e.Price,
+		ProductURL: e.ProductURL,
+		Category:   e.Category,
+	}
+}
+
+// parseBaselineTime parses the time of an entry, an empty time is fallback.
+func parseBaselineTime(raw string, fallback time.Time) (time.Time, error) {
+	if raw == "" {
+		return fallback, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateTime, time.DateOnly} {
+		if at, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return at, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q: expected RFC 3339 or %s", raw, time.DateOnly)
+}
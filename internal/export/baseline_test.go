@@ -0,0 +1,81 @@
+package export_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadBaseline(t *testing.T) {
+	t.Run("CSV columns are matched by header", func(t *testing.T) {
+		entries, err := export.ReadBaseline(strings.NewReader(
+			"Price,Model,quantity,at\n4 299,GA-2100,3,2025-06-01\n",
+		), export.FormatCSV)
+
+		require.NoError(t, err)
+		assert.Equal(t, []export.BaselineEntry{{Model: "GA-2100", Price: "4 299", Quantity: "3", At: "2025-06-01"}},
+			entries)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		entries, err := export.ReadBaseline(strings.NewReader(
+			`[{"model": "GA-2100", "price": "4 299", "category": "new", "url": "https://example.com/ga"}]`,
+		), export.FormatJSON)
+
+		require.NoError(t, err)
+		assert.Equal(t, []export.BaselineEntry{{
+			Model: "GA-2100", Price: "4 299", Category: "new", ProductURL: "https://example.com/ga",
+		}}, entries)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := export.ReadBaseline(strings.NewReader("price\n100\n"), export.FormatCSV)
+		require.ErrorIs(t, err, export.ErrInvalidBaseline)
+
+		_, err = export.ReadBaseline(strings.NewReader("{"), export.FormatJSON)
+		require.ErrorIs(t, err, export.ErrInvalidBaseline)
+
+		_, err = export.ReadBaseline(strings.NewReader(""), "xlsx")
+		require.ErrorIs(t, err, export.ErrUnknownFormat)
+	})
+}
+
+func TestNewBaseline(t *testing.T) {
+	importedAt := time.Date(2025, 7, 1, 12, 0, 0, 0, time.Local)
+
+	baseline, err := export.NewBaseline([]export.BaselineEntry{
+		{Model: "A1", Price: "100", Quantity: "2", At: "2025-06-01"},
+		{Model: "B1", Price: "50", Quantity: "1", At: "2025-06-01"},
+		{Model: "A1", Price: "90", Quantity: "2", At: "2025-06-15"},
+		{Model: "B1", Price: "50", Quantity: "1", At: "2025-06-15"},
+		{Model: "C1", Price: "70", Quantity: "1"},
+	}, importedAt)
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.Product{
+		{Model: "A1", Price: "90", Quantity: "2"},
+		{Model: "B1", Price: "50", Quantity: "1"},
+		{Model: "C1", Price: "70", Quantity: "1"},
+	}, baseline.Products)
+
+	require.Len(t, baseline.History, 3, "B1 is unchanged on 2025-06-15")
+	assert.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local), baseline.History[0].At)
+	assert.Len(t, baseline.History[0].Changes.Added, 2)
+	assert.Equal(t, []models.ChangeInfo{{
+		Old: models.Product{Model: "A1", Price: "100", Quantity: "2"},
+		New: models.Product{Model: "A1", Price: "90", Quantity: "2"},
+	}}, baseline.History[1].Changes.Changed)
+	assert.Equal(t, importedAt, baseline.History[2].At)
+	assert.Equal(t, []models.Product{{Model: "C1", Price: "70", Quantity: "1"}}, baseline.History[2].Changes.Added)
+
+	_, err = export.NewBaseline([]export.BaselineEntry{{Model: "A1", Price: "free"}}, importedAt)
+	require.ErrorIs(t, err, export.ErrInvalidBaseline)
+
+	_, err = export.NewBaseline([]export.BaselineEntry{{Model: "A1", Price: "1", At: "yesterday"}}, importedAt)
+	require.ErrorIs(t, err, export.ErrInvalidBaseline)
+}
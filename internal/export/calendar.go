@@ -0,0 +1,131 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// CalendarEvent is a notable change published in the calendar.
+type CalendarEvent struct {
+	At      time.Time
+	Summary string
+	// key identifies the change the event is about, so the event keeps its UID between requests.
+	key string
+}
+
+// CalendarEvents picks the notable changes of the records, oldest first: removed products, products
+// back in stock and prices dropping below the threshold. A product is back in stock when it's added
+// again after being removed, or its quantity changes from zero. A threshold of zero or less picks no
+// price drops.
+func CalendarEvents(records []models.ChangeRecord, threshold float64) []CalendarEvent {
+	removed := make(map[models.ProductRef]bool)
+	var events []CalendarEvent
+	add := func(record models.ChangeRecord, summary string) {
+		events = append(events, CalendarEvent{
+			At:      record.DetectedAt,
+			Summary: withNote(record, inCategory(record, summary)),
+			key:     entryID(record.DetectedAt, record.Kind, record.Category, record.OldModel, record.Model),
+		})
+	}
+
+	for _, record := range records {
+		ref := models.ProductRef{Category: record.Category, Model: record.Model}
+		switch {
+		case record.Kind == KindRemoved:
+			removed[models.ProductRef{Category: record.Category, Model: record.OldModel}] = true
+			add(record, fmt.Sprintf("%s removed, was %s", record.OldModel, record.OldPrice))
+		case record.Kind == KindAdded && removed[ref]:
+			delete(removed, ref)
+			add(record, fmt.Sprintf("%s back in stock for %s", record.Model, record.Price))
+		case record.Kind == KindChanged && outOfStock(record.OldQuantity) && !outOfStock(record.Quantity):
+			add(record, fmt.Sprintf("%s back in stock for %s", record.Model, record.Price))
+		case droppedBelow(record, threshold):
+			add(record, fmt.Sprintf("%s below %g: %s", record.Model, threshold, record.Price))
+		}
+	}
+
+	return events
+}
+
+// outOfStock reports whether the quantity shown on the page means the product is out of stock.
+func outOfStock(quantity string) bool {
+	return strings.TrimSpace(quantity) == "0"
+}
+
+// droppedBelow reports whether the price of an added or changed product dropped below the threshold.
+func droppedBelow(record models.ChangeRecord, threshold float64) bool {
+	if threshold <= 0 || (record.Kind != KindAdded && record.Kind != KindChanged) {
+		return false
+	}
+
+	price, err := models.ParsePrice(record.Price)
+	if err != nil || price >= threshold {
+		return false
+	}
+	if record.Kind == KindAdded {
+		return true
+	}
+	oldPrice, err := models.ParsePrice(record.OldPrice)
+
+	return err != nil || oldPrice >= threshold
+}
+
+// WriteCalendar writes the events as an iCalendar document, see RFC 5545.
+func WriteCalendar(w io.Writer, name string, events []CalendarEvent) error {
+	var builder strings.Builder
+	line := func(name, value string) {
+		builder.WriteString(foldLine(name + ":" + value))
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//chrono-flow//changes//EN")
+	line("X-WR-CALNAME", escapeText(name))
+	for _, event := range events {
+		at := event.At.UTC().Format("20060102T150405Z")
+		line("BEGIN", "VEVENT")
+		line("UID", strings.TrimPrefix(event.key, "urn:chrono-flow:")+"@chrono-flow")
+		line("DTSTAMP", at)
+		line("DTSTART", at)
+		line("SUMMARY", escapeText(event.Summary))
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+
+	if _, err := io.WriteString(w, builder.String()); err != nil {
+		return fmt.Errorf("failed to write calendar: %w", err)
+	}
+
+	return nil
+}
+
+// escapeText escapes a TEXT value of a calendar property.
+func escapeText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(text)
+}
+
+// foldLine terminates a content line, folding it into lines of at most 75 octets without splitting
+// a UTF-8 sequence.
+func foldLine(line string) string {
+	const maxOctets = 75
+
+	var builder strings.Builder
+	octets := 0
+	for _, r := range line {
+		size := len(string(r))
+		if octets+size > maxOctets {
+			// The continuation line starts with a space, which counts towards its length.
+			builder.WriteString("\r\n ")
+			octets = 1
+		}
+		builder.WriteRune(r)
+		octets += size
+	}
+	builder.WriteString("\r\n")
+
+	return builder.String()
+}
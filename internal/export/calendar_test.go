@@ -0,0 +1,74 @@
+package export_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarEvents(t *testing.T) {
+	start := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	records := []models.ChangeRecord{
+		{DetectedAt: start, Kind: export.KindRemoved, OldModel: "A1", OldPrice: "100"},
+		{DetectedAt: start, Kind: export.KindAdded, Model: "B2", Price: "900"},
+		{DetectedAt: start.Add(time.Hour), Kind: export.KindAdded, Model: "A1", Price: "95"},
+		{DetectedAt: start.Add(time.Hour), Kind: export.KindAdded, Model: "A1", Price: "95", Category: "used"},
+		{DetectedAt: start.Add(2 * time.Hour), Kind: export.KindChanged, Model: "C3", OldQuantity: "0", Quantity: "2",
+			OldPrice: "300", Price: "300"},
+		{DetectedAt: start.Add(2 * time.Hour), Kind: export.KindChanged, Model: "D4", OldPrice: "600", Price: "450"},
+		{DetectedAt: start.Add(3 * time.Hour), Kind: export.KindChanged, Model: "D4", OldPrice: "450", Price: "400"},
+		{DetectedAt: start.Add(3 * time.Hour), Kind: export.KindRenamed, OldModel: "E5", Model: "E5X"},
+	}
+
+	t.Run("removed and back in stock", func(t *testing.T) {
+		events := export.CalendarEvents(records, 0)
+
+		summaries := make([]string, 0, len(events))
+		for _, event := range events {
+			summaries = append(summaries, event.Summary)
+		}
+		assert.Equal(t, []string{"A1 removed, was 100", "A1 back in stock for 95", "C3 back in stock for 300"},
+			summaries, "a product is back in stock only in the category it was removed from")
+		assert.True(t, start.Add(time.Hour).Equal(events[1].At))
+	})
+
+	t.Run("prices dropping below the threshold", func(t *testing.T) {
+		events := export.CalendarEvents(records, 500)
+
+		require.Len(t, events, 5)
+		assert.Equal(t, "used: A1 below 500: 95", events[2].Summary, "added products below the threshold count")
+		assert.Equal(t, "D4 below 500: 450", events[4].Summary, "only the price crossing the threshold counts")
+	})
+}
+
+func TestWriteCalendar(t *testing.T) {
+	at := time.Date(2025, 7, 1, 12, 30, 0, 0, time.UTC)
+	events := export.CalendarEvents([]models.ChangeRecord{
+		{DetectedAt: at, Kind: export.KindRemoved, OldModel: "Seiko; SRPD55, " + strings.Repeat("long", 20)},
+	}, 0)
+
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteCalendar(&buf, "Watches", events))
+
+	document := buf.String()
+	assert.True(t, strings.HasPrefix(document, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(document, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assert.Contains(t, document, "X-WR-CALNAME:Watches\r\n")
+	assert.Contains(t, document, "DTSTART:20250701T123000Z\r\n")
+	assert.Contains(t, document, `SUMMARY:Seiko\; SRPD55\, long`)
+	for _, line := range strings.Split(strings.TrimSuffix(document, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, "lines are folded")
+	}
+	unfolded := strings.ReplaceAll(document, "\r\n ", "")
+	assert.Contains(t, unfolded, `SUMMARY:Seiko\; SRPD55\, `+strings.Repeat("long", 20)+" removed")
+
+	var again bytes.Buffer
+	require.NoError(t, export.WriteCalendar(&again, "Watches", events))
+	assert.Equal(t, document, again.String(), "the events keep their UIDs between requests")
+}
@@ -0,0 +1,63 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// Change kinds used in exported files.
+const (
+	KindAdded   = models.KindAdded
+	KindChanged = models.KindChanged
+	KindRenamed = models.KindRenamed
+	KindRemoved = models.KindRemoved
+)
+
+//nolint:gochecknoglobals // header is a read-only list of CSV columns.
+var changesHeader = []string{
+	"kind", "old_model", "model", "type", "old_price", "price", "old_quantity", "quantity", "image_url", "category", "url",
+}
+
+// ChangesCSV writes all changes as a CSV document with one row per product.
+func ChangesCSV(w io.Writer, changes *models.Changes) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(changesHeader); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	var rows [][]string
+	for _, p := range changes.Added {
+		rows = append(rows, []string{
+			KindAdded, "", p.Model, p.Type, "", p.Price, "", p.Quantity, p.ImageURL, p.Category, p.ProductURL,
+		})
+	}
+	for _, c := range changes.Changed {
+		rows = append(rows, changeRow(KindChanged, c))
+	}
+	for _, c := range changes.Renamed {
+		rows = append(rows, changeRow(KindRenamed, c))
+	}
+	for _, p := range changes.Removed {
+		rows = append(rows, []string{
+			KindRemoved, p.Model, "", p.Type, p.Price, "", p.Quantity, "", p.ImageURL, p.Category, p.ProductURL,
+		})
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV rows: %w", err)
+	}
+
+	return nil
+}
+
+// changeRow converts a change into a CSV row.
+func changeRow(kind string, c models.ChangeInfo) []string {
+	return []string{
+		kind, c.Old.Model, c.New.Model, c.New.Type, c.Old.Price, c.New.Price, c.Old.Quantity, c.New.Quantity,
+		c.New.ImageURL, c.New.Category, c.New.ProductURL,
+	}
+}
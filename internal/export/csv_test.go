@@ -0,0 +1,49 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type errWriter struct{}
+
+func (errWriter) Write(_ []byte) (int, error) {
+	return 0, errors.New("test error: forced write failure")
+}
+
+func TestChangesCSV(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		changes := &models.Changes{
+			Added: []models.Product{{
+				Model: "A1", Type: "Diver", Price: "100", Quantity: "1", Category: "used", ProductURL: "https://example.com/a1",
+			}},
+			Changed: []models.ChangeInfo{{Old: models.Product{Model: "C1", Price: "10"}, New: models.Product{Model: "C1", Price: "12"}}},
+			Removed: []models.Product{{Model: "R1", Price: "5"}},
+		}
+
+		var buf bytes.Buffer
+		err := export.ChangesCSV(&buf, changes)
+		require.NoError(t, err)
+
+		records, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 4)
+		assert.Equal(t, "kind", records[0][0])
+		assert.Equal(t, []string{"added", "", "A1", "Diver", "", "100", "", "1", "", "used", "https://example.com/a1"}, records[1])
+		assert.Equal(t, []string{"changed", "C1", "C1", "", "10", "12", "", "", "", "", ""}, records[2])
+		assert.Equal(t, []string{"removed", "R1", "", "", "5", "", "", "", "", "", ""}, records[3])
+	})
+
+	t.Run("error: write failure", func(t *testing.T) {
+		err := export.ChangesCSV(errWriter{}, &models.Changes{Added: []models.Product{{Model: "A1"}}})
+
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,173 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// Formats of the full diff of a check.
+const (
+	DiffMarkdown = "md"
+	DiffHTML     = "html"
+	DiffCSV      = "csv"
+)
+
+// ErrUnknownDiffFormat is returned for a diff format other than the DiffFormats.
+var ErrUnknownDiffFormat = errors.New("unknown diff format")
+
+// DiffFormats returns the formats the full diff of a check is rendered in.
+func DiffFormats() []string {
+	return []string{DiffMarkdown, DiffHTML, DiffCSV}
+}
+
+// DiffContentType returns the media type of a diff format, empty for an unknown one.
+func DiffContentType(format string) string {
+	return map[string]string{
+		DiffMarkdown: "text/markdown; charset=utf-8",
+		DiffHTML:     "text/html; charset=utf-8",
+		DiffCSV:      "text/csv; charset=utf-8",
+	}[format]
+}
+
+// RenderDiff renders the changes of a check in the format under the title.
+func RenderDiff(format, title string, changes *models.Changes) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case DiffMarkdown:
+		err = ChangesMarkdown(&buf, title, changes)
+	case DiffHTML:
+		err = ChangesHTML(&buf, title, changes)
+	case DiffCSV:
+		err = ChangesCSV(&buf, changes)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownDiffFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// diffSection is the table of the changes of a kind, a changed value is rendered as "old → new".
+type diffSection struct {
+	Title string
+	Rows  [][]string
+}
+
+//nolint:gochecknoglobals // header is a read-only list of table columns.
+var diffHeader = []string{"Category", "Model", "Type", "Price", "Quantity", "URL"}
+
+// diffSections returns the tables of the kinds of changes there are, in the order of the notifications.
+func diffSections(changes *models.Changes) []diffSection {
+	productRows := func(products []models.Product) [][]string {
+		rows := make([][]string, 0, len(products))
+		for _, p := range products {
+			rows = append(rows, []string{p.Category, p.Model, p.Type, p.Price, p.Quantity, p.ProductURL})
+		}
+		return rows
+	}
+	changeRows := func(infos []models.ChangeInfo) [][]string {
+		rows := make([][]string, 0, len(infos))
+		for _, c := range infos {
+			rows = append(rows, []string{
+				c.New.Category, diffValue(c.Old.Model, c.New.Model), c.New.Type, diffValue(c.Old.Price, c.New.Price),
+				diffValue(c.Old.Quantity, c.New.Quantity), c.New.ProductURL,
+			})
+		}
+		return rows
+	}
+
+	var sections []diffSection
+	for _, section := range []diffSection{
+		{Title: "Added", Rows: productRows(changes.Added)},
+		{Title: "Changed", Rows: changeRows(changes.Changed)},
+		{Title: "Renamed", Rows: changeRows(changes.Renamed)},
+		{Title: "Removed", Rows: productRows(changes.Removed)},
+	} {
+		if len(section.Rows) > 0 {
+			section.Title = fmt.Sprintf("%s (%d)", section.Title, len(section.Rows))
+			sections = append(sections, section)
+		}
+	}
+
+	return sections
+}
+
+// diffValue renders a value that may have changed.
+func diffValue(oldValue, newValue string) string {
+	if oldValue == newValue {
+		return newValue
+	}
+
+	return oldValue + " → " + newValue
+}
+
+// ChangesMarkdown writes the changes as a Markdown document with a table per kind of change.
+func ChangesMarkdown(w io.Writer, title string, changes *models.Changes) error {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "# %s\n", title)
+	for _, section := range diffSections(changes) {
+		fmt.Fprintf(&builder, "\n## %s\n\n", section.Title)
+		writeMarkdownRow(&builder, diffHeader)
+		builder.WriteString("|" + strings.Repeat(" --- |", len(diffHeader)) + "\n")
+		for _, row := range section.Rows {
+			writeMarkdownRow(&builder, row)
+		}
+	}
+
+	if _, err := io.WriteString(w, builder.String()); err != nil {
+		return fmt.Errorf("failed to write Markdown: %w", err)
+	}
+
+	return nil
+}
+
+// writeMarkdownRow writes a row of a Markdown table, the pipes of the cells are escaped.
+func writeMarkdownRow(builder *strings.Builder, cells []string) {
+	builder.WriteString("|")
+	for _, cell := range cells {
+		builder.WriteString(" " + strings.ReplaceAll(cell, "|", `\|`) + " |")
+	}
+	builder.WriteString("\n")
+}
+
+// diffPage renders the changes as a standalone HTML page, see ChangesHTML.
+var diffPage = template.Must(template.New("diff").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Sections}}<h2>{{.Title}}</h2>
+<table>
+<tr>{{range $.Header}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+// ChangesHTML writes the changes as an HTML page with a table per kind of change.
+func ChangesHTML(w io.Writer, title string, changes *models.Changes) error {
+	data := struct {
+		Title    string
+		Header   []string
+		Sections []diffSection
+	}{Title: title, Header: diffHeader, Sections: diffSections(changes)}
+
+	if err := diffPage.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to write HTML: %w", err)
+	}
+
+	return nil
+}
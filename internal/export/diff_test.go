@@ -0,0 +1,71 @@
+package export_test
+
+import (
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func diffChanges() *models.Changes {
+	return &models.Changes{
+		Added: []models.Product{{Model: "A|1", Category: "new", Type: "Diver", Price: "100", Quantity: "1"}},
+		Changed: []models.ChangeInfo{{
+			Old: models.Product{Model: "C1", Category: "new", Price: "10", Quantity: "2"},
+			New: models.Product{Model: "C1", Category: "new", Price: "12", Quantity: "2"},
+		}},
+		Removed: []models.Product{{Model: "<R1>", Category: "used", Price: "5"}},
+	}
+}
+
+func TestRenderDiff_Markdown(t *testing.T) {
+	content, err := export.RenderDiff(export.DiffMarkdown, "Changes of run-1", diffChanges())
+
+	require.NoError(t, err)
+	assert.Equal(t, `# Changes of run-1
+
+## Added (1)
+
+| Category | Model | Type | Price | Quantity | URL |
+| --- | --- | --- | --- | --- | --- |
+| new | A\|1 | Diver | 100 | 1 |  |
+
+## Changed (1)
+
+| Category | Model | Type | Price | Quantity | URL |
+| --- | --- | --- | --- | --- | --- |
+| new | C1 |  | 10 → 12 | 2 |  |
+
+## Removed (1)
+
+| Category | Model | Type | Price | Quantity | URL |
+| --- | --- | --- | --- | --- | --- |
+| used | <R1> |  | 5 |  |  |
+`, string(content))
+}
+
+func TestRenderDiff_HTML(t *testing.T) {
+	content, err := export.RenderDiff(export.DiffHTML, "Changes of run-1", diffChanges())
+
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "<title>Changes of run-1</title>")
+	assert.Contains(t, string(content), "<h2>Changed (1)</h2>")
+	assert.Contains(t, string(content), "<td>10 → 12</td>")
+	assert.Contains(t, string(content), "<td>&lt;R1&gt;</td>", "the values are escaped")
+	assert.NotContains(t, string(content), "Renamed", "kinds without changes are left out")
+}
+
+func TestRenderDiff_Formats(t *testing.T) {
+	for _, format := range export.DiffFormats() {
+		content, err := export.RenderDiff(format, "title", diffChanges())
+		require.NoError(t, err, format)
+		assert.NotEmpty(t, content, format)
+		assert.NotEmpty(t, export.DiffContentType(format), format)
+	}
+
+	_, err := export.RenderDiff("pdf", "title", diffChanges())
+	require.ErrorIs(t, err, export.ErrUnknownDiffFormat)
+	assert.Empty(t, export.DiffContentType("pdf"))
+}
@@ -0,0 +1,273 @@
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// Feed formats the change history is published in.
+const (
+	FeedAtom = "atom"
+	FeedRSS  = "rss"
+)
+
+// ErrUnknownFeedFormat is returned for a feed format other than FeedAtom and FeedRSS.
+var ErrUnknownFeedFormat = errors.New("unknown feed format")
+
+// Feed describes a feed of the change history.
+type Feed struct {
+	Title string
+	// Link is the page the changes were detected on.
+	Link string
+	// PerRun publishes an entry per check listing all its changes instead of an entry per product change.
+	PerRun bool
+}
+
+// feedEntry is a format-neutral entry of a feed.
+type feedEntry struct {
+	id      string
+	title   string
+	content string
+	updated time.Time
+}
+
+// WriteFeed writes the change records, newest first, as an Atom or RSS document.
+func WriteFeed(w io.Writer, format string, feed Feed, records []models.ChangeRecord) error {
+	var entries []feedEntry
+	if feed.PerRun {
+		entries = runEntries(records)
+	} else {
+		entries = changeEntries(records)
+	}
+
+	var document any
+	switch format {
+	case FeedAtom:
+		document = atomDocument(feed, entries)
+	case FeedRSS:
+		document = rssDocument(feed, entries)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownFeedFormat, format)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+
+	return nil
+}
+
+// changeEntries returns an entry per change record.
+func changeEntries(records []models.ChangeRecord) []feedEntry {
+	entries := make([]feedEntry, 0, len(records))
+	for _, record := range records {
+		description := DescribeChange(record)
+		entries = append(entries, feedEntry{
+			id:      entryID(record.DetectedAt, record.Kind, record.Category, record.OldModel, record.Model),
+			title:   description,
+			content: description,
+			updated: record.DetectedAt,
+		})
+	}
+
+	return entries
+}
+
+// runEntries returns an entry per check, the records of a check share the time they were detected at.
+func runEntries(records []models.ChangeRecord) []feedEntry {
+	var entries []feedEntry
+	for start := 0; start < len(records); {
+		end := start + 1
+		for end < len(records) && records[end].DetectedAt.Equal(records[start].DetectedAt) {
+			end++
+		}
+
+		lines := make([]string, 0, end-start)
+		for _, record := range records[start:end] {
+			lines = append(lines, DescribeChange(record))
+		}
+		title := "1 change"
+		if len(lines) > 1 {
+			title = fmt.Sprintf("%d changes", len(lines))
+		}
+		entries = append(entries, feedEntry{
+			id:      entryID(records[start].DetectedAt),
+			title:   title,
+			content: strings.Join(lines, "\n"),
+			updated: records[start].DetectedAt,
+		})
+		start = end
+	}
+
+	return entries
+}
+
+// entryID returns an ID of the entry that stays the same between requests of the feed.
+func entryID(detectedAt time.Time, parts ...string) string {
+	key := detectedAt.UTC().Format(time.RFC3339Nano) + "\n" + strings.Join(parts, "\n")
+
+	return fmt.Sprintf("urn:chrono-flow:%x", sha256.Sum256([]byte(key)))
+}
+
+// DescribeChange returns a one-line description of the change, e.g. "used: Seiko SRPD55 price 200 → 180",
+// followed by the note of the admins if it has one.
+func DescribeChange(record models.ChangeRecord) string {
+	var description string
+	switch record.Kind {
+	case KindAdded:
+		description = fmt.Sprintf("%s added for %s", record.Model, record.Price)
+	case KindRemoved:
+		description = fmt.Sprintf("%s removed, was %s", record.OldModel, record.OldPrice)
+	case KindRenamed:
+		description = fmt.Sprintf("%s renamed to %s", record.OldModel, record.Model)
+	default:
+		var details []string
+		if record.OldPrice != record.Price {
+			details = append(details, fmt.Sprintf("price %s → %s", record.OldPrice, record.Price))
+		}
+		if record.OldQuantity != record.Quantity {
+			details = append(details, fmt.Sprintf("quantity %s → %s", record.OldQuantity, record.Quantity))
+		}
+		description = strings.TrimSpace(record.Model + " " + strings.Join(details, ", "))
+	}
+
+	return withNote(record, inCategory(record, description))
+}
+
+// withNote appends the note attached to the change by the admins to its description, if any.
+func withNote(record models.ChangeRecord, description string) string {
+	if record.Note == "" {
+		return description
+	}
+
+	return description + " (note: " + record.Note + ")"
+}
+
+// inCategory prefixes the description of the change with the category of its product, if any.
+func inCategory(record models.ChangeRecord, description string) string {
+	if record.Category == "" {
+		return description
+	}
+
+	return record.Category + ": " + description
+}
+
+// atomFeed is an Atom document, see RFC 4287.
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Link    atomLink    `xml:"link"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Updated string   `xml:"updated"`
+	Content atomText `xml:"content"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// atomDocument returns the Atom document of the entries. A feed without entries was updated at the
+// Unix epoch, so its update time doesn't change between requests.
+func atomDocument(feed Feed, entries []feedEntry) atomFeed {
+	document := atomFeed{
+		Title:   feed.Title,
+		ID:      "urn:chrono-flow:feed:" + feed.Link,
+		Link:    atomLink{Href: feed.Link},
+		Updated: time.Unix(0, 0).UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: "chrono-flow"},
+	}
+	if len(entries) > 0 {
+		document.Updated = entries[0].updated.UTC().Format(time.RFC3339)
+	}
+	for _, entry := range entries {
+		document.Entries = append(document.Entries, atomEntry{
+			Title:   entry.title,
+			ID:      entry.id,
+			Updated: entry.updated.UTC().Format(time.RFC3339),
+			Content: atomText{Type: "text", Body: entry.content},
+		})
+	}
+
+	return document
+}
+
+// rssFeed is an RSS 2.0 document.
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// rssDocument returns the RSS document of the entries.
+func rssDocument(feed Feed, entries []feedEntry) rssFeed {
+	document := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       feed.Title,
+			Link:        feed.Link,
+			Description: "Changes detected on " + feed.Link,
+		},
+	}
+	if len(entries) > 0 {
+		document.Channel.LastBuildDate = entries[0].updated.UTC().Format(time.RFC1123Z)
+	}
+	for _, entry := range entries {
+		document.Channel.Items = append(document.Channel.Items, rssItem{
+			Title:       entry.title,
+			Description: entry.content,
+			GUID:        rssGUID{Value: entry.id},
+			PubDate:     entry.updated.UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	return document
+}
@@ -0,0 +1,118 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFeed(t *testing.T) {
+	latest := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	earlier := latest.Add(-time.Hour)
+	records := []models.ChangeRecord{
+		{DetectedAt: latest, Kind: export.KindAdded, Model: "A1", Price: "100"},
+		{DetectedAt: latest, Kind: export.KindRemoved, OldModel: "B2", OldPrice: "200", Category: "used"},
+		{DetectedAt: earlier, Kind: export.KindChanged, Model: "C3", OldPrice: "300", Price: "280"},
+	}
+	feed := export.Feed{Title: "Watches", Link: "https://example.com"}
+
+	t.Run("atom entry per change", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, export.WriteFeed(&buf, export.FeedAtom, feed, records))
+
+		var document struct {
+			Title   string `xml:"title"`
+			Updated string `xml:"updated"`
+			Entries []struct {
+				Title   string `xml:"title"`
+				ID      string `xml:"id"`
+				Updated string `xml:"updated"`
+			} `xml:"entry"`
+		}
+		require.NoError(t, xml.Unmarshal(buf.Bytes(), &document))
+		assert.Contains(t, buf.String(), `xmlns="http://www.w3.org/2005/Atom"`)
+		assert.Equal(t, "Watches", document.Title)
+		assert.Equal(t, "2025-07-01T12:00:00Z", document.Updated)
+		require.Len(t, document.Entries, 3)
+		assert.Equal(t, "A1 added for 100", document.Entries[0].Title)
+		assert.Equal(t, "used: B2 removed, was 200", document.Entries[1].Title)
+		assert.Equal(t, "2025-07-01T11:00:00Z", document.Entries[2].Updated)
+		assert.NotEqual(t, document.Entries[0].ID, document.Entries[1].ID)
+
+		var again bytes.Buffer
+		require.NoError(t, export.WriteFeed(&again, export.FeedAtom, feed, records))
+		assert.Equal(t, buf.String(), again.String(), "the entries keep their IDs between requests")
+	})
+
+	t.Run("rss item per run", func(t *testing.T) {
+		feed := feed
+		feed.PerRun = true
+		var buf bytes.Buffer
+		require.NoError(t, export.WriteFeed(&buf, export.FeedRSS, feed, records))
+
+		var document struct {
+			Version string `xml:"version,attr"`
+			Channel struct {
+				Link  string `xml:"link"`
+				Items []struct {
+					Title       string `xml:"title"`
+					Description string `xml:"description"`
+					PubDate     string `xml:"pubDate"`
+				} `xml:"item"`
+			} `xml:"channel"`
+		}
+		require.NoError(t, xml.Unmarshal(buf.Bytes(), &document))
+		assert.Equal(t, "2.0", document.Version)
+		assert.Equal(t, "https://example.com", document.Channel.Link)
+		require.Len(t, document.Channel.Items, 2)
+		assert.Equal(t, "2 changes", document.Channel.Items[0].Title)
+		assert.Equal(t, "A1 added for 100\nused: B2 removed, was 200", document.Channel.Items[0].Description)
+		assert.Equal(t, "Tue, 01 Jul 2025 12:00:00 +0000", document.Channel.Items[0].PubDate)
+		assert.Equal(t, "1 change", document.Channel.Items[1].Title)
+	})
+
+	t.Run("empty feed", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, export.WriteFeed(&buf, export.FeedAtom, feed, nil))
+
+		assert.Contains(t, buf.String(), "<updated>1970-01-01T00:00:00Z</updated>")
+		assert.NotContains(t, buf.String(), "<entry>")
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		err := export.WriteFeed(&bytes.Buffer{}, "json", feed, records)
+
+		require.ErrorIs(t, err, export.ErrUnknownFeedFormat)
+	})
+}
+
+func TestDescribeChange(t *testing.T) {
+	tests := []struct {
+		record models.ChangeRecord
+		want   string
+	}{
+		{models.ChangeRecord{Kind: export.KindAdded, Model: "A1", Price: "100"}, "A1 added for 100"},
+		{models.ChangeRecord{Kind: export.KindRemoved, OldModel: "A1", OldPrice: "100"}, "A1 removed, was 100"},
+		{models.ChangeRecord{Kind: export.KindRenamed, OldModel: "A1", Model: "A1X"}, "A1 renamed to A1X"},
+		{
+			models.ChangeRecord{Kind: export.KindChanged, Model: "A1", OldPrice: "100", Price: "90", OldQuantity: "1",
+				Quantity: "2", Category: "new"},
+			"new: A1 price 100 → 90, quantity 1 → 2",
+		},
+		{
+			models.ChangeRecord{Kind: export.KindChanged, Model: "A1", OldPrice: "100", Price: "1",
+				Note: "supplier confirmed pricing error"},
+			"A1 price 100 → 1 (note: supplier confirmed pricing error)",
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, export.DescribeChange(tt.record))
+	}
+}
@@ -0,0 +1,90 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+//nolint:gochecknoglobals // header is a read-only list of CSV columns.
+var stateHeader = []string{"category", "model", "type", "price", "quantity", "image_url", "url"}
+
+// StateProduct is a product listed by the catalog at some time.
+type StateProduct struct {
+	Category   string `json:"category"`
+	Model      string `json:"model"`
+	Type       string `json:"type"`
+	Price      string `json:"price"`
+	Quantity   string `json:"quantity"`
+	ImageURL   string `json:"image_url"`
+	ProductURL string `json:"url"`
+}
+
+// State is the catalog as it was at some time.
+type State struct {
+	At       time.Time      `json:"at"`
+	Products []StateProduct `json:"products"`
+}
+
+// ParseStateTime parses the time a state is asked for, an RFC 3339 time, a date time or a date in the
+// local time zone. A date stands for its end, so the state of a date holds the changes detected that day.
+func ParseStateTime(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	for _, layout := range []string{time.RFC3339, time.DateTime} {
+		if at, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return at, nil
+		}
+	}
+	if day, err := time.ParseInLocation(time.DateOnly, raw, time.Local); err == nil {
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q: expected RFC 3339 or %s", raw, time.DateOnly)
+}
+
+// StateCSV writes the products of a state as a CSV document with one row per product.
+func StateCSV(w io.Writer, products []models.Product) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(stateHeader); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	rows := make([][]string, 0, len(products))
+	for _, p := range products {
+		rows = append(rows, []string{p.Category, p.Model, p.Type, p.Price, p.Quantity, p.ImageURL, p.ProductURL})
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV rows: %w", err)
+	}
+
+	return nil
+}
+
+// StateJSON writes the products of the state at the time as a JSON document, see State.
+func StateJSON(w io.Writer, at time.Time, products []models.Product) error {
+	state := State{At: at, Products: make([]StateProduct, 0, len(products))}
+	for _, p := range products {
+		state.Products = append(state.Products, StateProduct{
+			Category:   p.Category,
+			Model:      p.Model,
+			Type:       p.Type,
+			Price:      p.Price,
+			Quantity:   p.Quantity,
+			ImageURL:   p.ImageURL,
+			ProductURL: p.ProductURL,
+		})
+	}
+
+	if err := json.NewEncoder(w).Encode(state); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/export"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStateTime(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want time.Time
+	}{
+		{raw: "2026-03-03", want: time.Date(2026, time.March, 3, 23, 59, 59, 999999999, time.Local)},
+		{raw: " 2026-03-03 10:30:00 ", want: time.Date(2026, time.March, 3, 10, 30, 0, 0, time.Local)},
+		{raw: "2026-03-03T10:30:00Z", want: time.Date(2026, time.March, 3, 10, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			at, err := export.ParseStateTime(tt.raw)
+
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(at), "got %s", at)
+		})
+	}
+
+	_, err := export.ParseStateTime("March 3rd")
+	require.ErrorContains(t, err, `invalid time "March 3rd"`)
+}
+
+func TestStateCSV(t *testing.T) {
+	products := []models.Product{
+		{
+			Model: "A1", Category: "new", Type: "Diver", Price: "100", Quantity: "1",
+			ProductURL: "https://example.com/a1",
+		},
+		{Model: "B1", Category: "used", Price: "50"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, export.StateCSV(&buf, products))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"category", "model", "type", "price", "quantity", "image_url", "url"},
+		{"new", "A1", "Diver", "100", "1", "", "https://example.com/a1"},
+		{"used", "B1", "", "50", "", "", ""},
+	}, records)
+
+	require.Error(t, export.StateCSV(errWriter{}, products))
+}
+
+func TestStateJSON(t *testing.T) {
+	at := time.Date(2026, time.March, 3, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	require.NoError(t, export.StateJSON(&buf, at, []models.Product{{Model: "A1", Category: "new", Price: "100"}}))
+
+	var state export.State
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &state))
+	assert.True(t, at.Equal(state.At))
+	assert.Equal(t, []export.StateProduct{{Model: "A1", Category: "new", Price: "100"}}, state.Products)
+	assert.Contains(t, buf.String(), `"url":""`)
+}
@@ -0,0 +1,224 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// ArchiveName is the name of the archive file in the capture directory.
+	ArchiveName = "chrono-flow.har"
+
+	bodiesDir   = "bodies"
+	harVersion  = "1.2"
+	creatorName = "chrono-flow"
+	redacted    = "[redacted]"
+	dirPerm     = 0o750
+	filePerm    = 0o640
+)
+
+// Recorder is an http.RoundTripper that records every exchange into a HAR archive on disk.
+// Only the last maxEntries exchanges are kept, older entries and their bodies are deleted.
+// Failing to store an entry is logged and never fails the request.
+type Recorder struct {
+	log        *slog.Logger
+	next       http.RoundTripper
+	dir        string
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries []Entry
+	seq     int
+}
+
+// NewRecorder creates a Recorder that sends requests with next and stores the archive in dir.
+func NewRecorder(log *slog.Logger, next http.RoundTripper, dir string, maxEntries int) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &Recorder{log: log, next: next, dir: dir, maxEntries: max(maxEntries, 1), now: time.Now}
+}
+
+// RoundTrip sends the request and records it together with the response or the transport error.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := r.now()
+	entry := Entry{StartedDateTime: start, Request: newRequest(req)}
+
+	resp, err := r.next.RoundTrip(req)
+	wait := r.now().Sub(start)
+	if err != nil {
+		entry.Time = milliseconds(wait)
+		entry.Timings = Timings{Send: -1, Wait: milliseconds(wait), Receive: -1}
+		entry.Error = err.Error()
+		r.record(req, entry, nil)
+
+		return nil, err //nolint:wrapcheck // a RoundTripper passes transport errors through, the client wraps them.
+	}
+
+	// The body is buffered to store it, the caller reads the same bytes.
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	total := r.now().Sub(start)
+	entry.Time = milliseconds(total)
+	entry.Timings = Timings{Send: -1, Wait: milliseconds(wait), Receive: milliseconds(total - wait)}
+	entry.Response = newResponse(resp, len(body))
+	if err != nil {
+		entry.Error = err.Error()
+		r.record(req, entry, body)
+
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	r.record(req, entry, body)
+
+	return resp, nil
+}
+
+// record stores the response body and rewrites the archive with the new entry.
+func (r *Recorder) record(req *http.Request, entry Entry, body []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Join(r.dir, bodiesDir), dirPerm); err != nil {
+		r.log.ErrorContext(req.Context(), "failed to create HAR directory", "dir", r.dir, "error", err)
+		return
+	}
+
+	r.seq++
+	if body != nil {
+		name := filepath.Join(bodiesDir, fmt.Sprintf("%s-%d.body", entry.StartedDateTime.Format("20060102T150405"), r.seq))
+		if err := os.WriteFile(filepath.Join(r.dir, name), body, filePerm); err != nil {
+			r.log.ErrorContext(req.Context(), "failed to store HAR response body", "error", err)
+		} else {
+			entry.Response.Content.File = filepath.ToSlash(name)
+		}
+	}
+
+	r.entries = append(r.entries, entry)
+	for len(r.entries) > r.maxEntries {
+		if file := r.entries[0].Response.Content.File; file != "" {
+			_ = os.Remove(filepath.Join(r.dir, filepath.FromSlash(file)))
+		}
+		r.entries = r.entries[1:]
+	}
+
+	if err := r.writeArchive(); err != nil {
+		r.log.ErrorContext(req.Context(), "failed to write HAR archive", "error", err)
+	}
+}
+
+// writeArchive atomically replaces the archive file with the current entries.
+func (r *Recorder) writeArchive() error {
+	data, err := json.MarshalIndent(Archive{Log: Log{
+		Version: harVersion,
+		Creator: Creator{Name: creatorName, Version: harVersion},
+		Entries: r.entries,
+	}}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode archive: %w", err)
+	}
+
+	tmp := filepath.Join(r.dir, ArchiveName+".tmp")
+	if err = os.WriteFile(tmp, data, filePerm); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err = os.Rename(tmp, filepath.Join(r.dir, ArchiveName)); err != nil {
+		return fmt.Errorf("failed to replace archive: %w", err)
+	}
+
+	return nil
+}
+
+// newRequest converts an HTTP request into its HAR description.
+func newRequest(req *http.Request) Request {
+	var cookies []Header
+	for _, cookie := range req.Cookies() {
+		cookies = append(cookies, Header{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	var query []Header
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			query = append(query, Header{Name: name, Value: value})
+		}
+	}
+	sortHeaders(query)
+
+	return Request{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: req.Proto,
+		Headers:     convertHeaders(req.Header),
+		Cookies:     nonNil(cookies),
+		QueryString: nonNil(query),
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+}
+
+// newResponse converts an HTTP response into its HAR description.
+func newResponse(resp *http.Response, size int) Response {
+	var cookies []Header
+	for _, cookie := range resp.Cookies() {
+		cookies = append(cookies, Header{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	return Response{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Headers:     convertHeaders(resp.Header),
+		Cookies:     nonNil(cookies),
+		Content:     Content{Size: size, MimeType: resp.Header.Get("Content-Type")},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    size,
+	}
+}
+
+// convertHeaders returns the headers sorted by name, with credentials redacted.
+func convertHeaders(header http.Header) []Header {
+	headers := make([]Header, 0, len(header))
+	for name, values := range header {
+		for _, value := range values {
+			if name == "Authorization" || name == "Proxy-Authorization" {
+				value = redacted
+			}
+			headers = append(headers, Header{Name: name, Value: value})
+		}
+	}
+	sortHeaders(headers)
+
+	return headers
+}
+
+// sortHeaders orders headers by name to keep archives stable.
+func sortHeaders(headers []Header) {
+	sort.SliceStable(headers, func(i, j int) bool { return headers[i].Name < headers[j].Name })
+}
+
+// nonNil returns an empty slice instead of nil, HAR requires the arrays to be present.
+func nonNil(headers []Header) []Header {
+	if headers == nil {
+		return []Header{}
+	}
+
+	return headers
+}
+
+// milliseconds converts a duration into fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
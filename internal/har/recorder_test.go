@@ -0,0 +1,106 @@
+package har_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(_ *http.Request) (*http.Response, error) {
+	return nil, errors.New("test error: connection refused")
+}
+
+// readArchive loads the archive written by the recorder.
+func readArchive(t *testing.T, dir string) har.Archive {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, har.ArchiveName))
+	require.NoError(t, err)
+
+	var archive har.Archive
+	require.NoError(t, json.Unmarshal(data, &archive))
+
+	return archive
+}
+
+func TestRecorder_RoundTrip(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = io.WriteString(w, "<table></table>")
+	}))
+	t.Cleanup(server.Close)
+
+	t.Run("response is recorded and passed through", func(t *testing.T) {
+		dir := t.TempDir()
+		client := &http.Client{Transport: har.NewRecorder(logger, nil, dir, 10)}
+
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL+"/stock?page=2", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+
+		assert.Equal(t, "<table></table>", string(body))
+		archive := readArchive(t, dir)
+		require.Len(t, archive.Log.Entries, 1)
+		entry := archive.Log.Entries[0]
+		assert.Equal(t, server.URL+"/stock?page=2", entry.Request.URL)
+		assert.Contains(t, entry.Request.Headers, har.Header{Name: "Authorization", Value: "[redacted]"})
+		assert.Equal(t, []har.Header{{Name: "page", Value: "2"}}, entry.Request.QueryString)
+		assert.Equal(t, http.StatusOK, entry.Response.Status)
+		assert.Equal(t, "text/html", entry.Response.Content.MimeType)
+		stored, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(entry.Response.Content.File)))
+		require.NoError(t, err)
+		assert.Equal(t, "<table></table>", string(stored))
+	})
+
+	t.Run("only the last entries are kept", func(t *testing.T) {
+		dir := t.TempDir()
+		client := &http.Client{Transport: har.NewRecorder(logger, nil, dir, 2)}
+
+		for range 3 {
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+			require.NoError(t, err)
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+		}
+
+		assert.Len(t, readArchive(t, dir).Log.Entries, 2)
+		bodies, err := os.ReadDir(filepath.Join(dir, "bodies"))
+		require.NoError(t, err)
+		assert.Len(t, bodies, 2)
+	})
+
+	t.Run("transport error is recorded", func(t *testing.T) {
+		dir := t.TempDir()
+		client := &http.Client{Transport: har.NewRecorder(logger, failingTransport{}, dir, 10)}
+
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://shop.test/", nil)
+		require.NoError(t, err)
+		_, err = client.Do(req) //nolint:bodyclose // the request fails without a response.
+
+		require.Error(t, err)
+		archive := readArchive(t, dir)
+		require.Len(t, archive.Log.Entries, 1)
+		assert.Equal(t, "test error: connection refused", archive.Log.Entries[0].Error)
+		assert.Zero(t, archive.Log.Entries[0].Response.Status)
+	})
+}
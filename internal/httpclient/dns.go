@@ -0,0 +1,111 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// errNoAddresses is returned when a host resolves to no addresses.
+var errNoAddresses = errors.New("no addresses found")
+
+// dnsEntry is a cached host lookup.
+type dnsEntry struct {
+	addrs     []string
+	expiresAt time.Time
+}
+
+// resolver caches host lookups for a fixed time to save a DNS round trip on every new connection.
+type resolver struct {
+	ttl    time.Duration
+	lookup func(ctx context.Context, host string) ([]string, error)
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+// newResolver creates a resolver caching lookups of the default resolver for ttl.
+func newResolver(ttl time.Duration) *resolver {
+	return &resolver{
+		ttl:     ttl,
+		lookup:  net.DefaultResolver.LookupHost,
+		now:     time.Now,
+		entries: make(map[string]dnsEntry),
+	}
+}
+
+// resolve returns the addresses of the host, from the cache if they haven't expired.
+func (r *resolver) resolve(ctx context.Context, host string) ([]string, error) {
+	r.mu.Lock()
+	entry, ok := r.entries[host]
+	r.mu.Unlock()
+	if ok && r.now().Before(entry.expiresAt) {
+		return entry.addrs, nil
+	}
+
+	addrs, err := r.lookup(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("failed to resolve %s: %w", host, errNoAddresses)
+	}
+
+	r.mu.Lock()
+	r.entries[host] = dnsEntry{addrs: addrs, expiresAt: r.now().Add(r.ttl)}
+	r.mu.Unlock()
+
+	return addrs, nil
+}
+
+// forget drops the cached addresses of the host, so the next dial resolves it again.
+func (r *resolver) forget(host string) {
+	r.mu.Lock()
+	delete(r.entries, host)
+	r.mu.Unlock()
+}
+
+// dialContext returns a dial function connecting to the cached addresses of the host in turn, skipping
+// the ones of the other IP version on a tcp4 or tcp6 network.
+func (r *resolver) dialContext(dialer *net.Dialer) dialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil || net.ParseIP(host) != nil {
+			conn, dialErr := dialer.DialContext(ctx, network, address)
+			if dialErr != nil {
+				return nil, fmt.Errorf("failed to dial %s: %w", address, dialErr)
+			}
+			return conn, nil
+		}
+
+		addrs, err := r.resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var dialErrs error
+		for _, addr := range addrs {
+			if !matchesNetwork(network, addr) {
+				continue
+			}
+			conn, dialErr := dialer.DialContext(ctx, network, net.JoinHostPort(addr, port))
+			if dialErr == nil {
+				return conn, nil
+			}
+			dialErrs = errors.Join(dialErrs, dialErr)
+		}
+		if dialErrs == nil {
+			// None of the addresses is of the IP version of the network.
+			dialErrs = errNoAddresses
+		}
+
+		// The host may have moved, look it up again next time.
+		r.forget(host)
+
+		return nil, fmt.Errorf("failed to dial %s: %w", address, dialErrs)
+	}
+}
@@ -0,0 +1,92 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolver_Resolve(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	lookups := 0
+	res := newResolver(time.Minute)
+	res.now = func() time.Time { return now }
+	res.lookup = func(_ context.Context, _ string) ([]string, error) {
+		lookups++
+		return []string{"10.0.0.1"}, nil
+	}
+
+	for range 3 {
+		addrs, err := res.resolve(t.Context(), "example.com")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.1"}, addrs)
+	}
+	assert.Equal(t, 1, lookups, "cached addresses must be reused")
+
+	now = now.Add(2 * time.Minute)
+	_, err := res.resolve(t.Context(), "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 2, lookups, "expired addresses must be looked up again")
+}
+
+func TestResolver_DialContext(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	res := newResolver(time.Minute)
+	res.lookup = func(_ context.Context, _ string) ([]string, error) {
+		return []string{"127.0.0.1"}, nil
+	}
+	dial := res.dialContext(&net.Dialer{Timeout: time.Second})
+
+	conn, err := dial(t.Context(), "tcp", net.JoinHostPort("shop.test", port))
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	// A host whose cached addresses refuse connections is forgotten.
+	res.entries["stale.test"] = dnsEntry{addrs: []string{"127.0.0.1"}, expiresAt: time.Now().Add(time.Hour)}
+	_, err = dial(t.Context(), "tcp", net.JoinHostPort("stale.test", "1"))
+	require.Error(t, err)
+	assert.NotContains(t, res.entries, "stale.test")
+}
+
+func TestResolver_DialContextNetwork(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	res := newResolver(time.Minute)
+	res.lookup = func(_ context.Context, _ string) ([]string, error) {
+		return []string{"::1", "127.0.0.1"}, nil
+	}
+	dial := res.dialContext(&net.Dialer{Timeout: time.Second})
+
+	conn, err := dial(t.Context(), "tcp4", net.JoinHostPort("shop.test", port))
+	require.NoError(t, err, "the IPv6 address is skipped")
+	require.NoError(t, conn.Close())
+
+	res.lookup = func(_ context.Context, _ string) ([]string, error) {
+		return []string{"127.0.0.1"}, nil
+	}
+	_, err = dial(t.Context(), "tcp6", net.JoinHostPort("other.test", port))
+	require.ErrorIs(t, err, errNoAddresses, "no address is of the IP version")
+}
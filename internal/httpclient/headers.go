@@ -0,0 +1,29 @@
+package httpclient
+
+import "net/http"
+
+// headerTransport sets headers on every request before passing it on.
+type headerTransport struct {
+	next    http.RoundTripper
+	headers map[string]string
+}
+
+// WithHeaders returns a transport setting the headers on every request sent through next, replacing
+// the ones the request already has, e.g. the Accept-Language of a regional profile of a target.
+func WithHeaders(next http.RoundTripper, headers map[string]string) http.RoundTripper {
+	if len(headers) == 0 {
+		return next
+	}
+
+	return &headerTransport{next: next, headers: headers}
+}
+
+// RoundTrip sends a copy of the request with the headers set, the request itself isn't modified.
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+
+	return t.next.RoundTrip(req) //nolint:wrapcheck // the transport passes the error of next on unchanged.
+}
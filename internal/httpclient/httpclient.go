@@ -0,0 +1,62 @@
+// Package httpclient builds the HTTP client shared by all fetches of the target pages.
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+const (
+	dialTimeout           = 10 * time.Second
+	keepAlive             = 30 * time.Second
+	maxIdleConns          = 100
+	maxIdleConnsPerHost   = 10
+	idleConnTimeout       = 90 * time.Second
+	tlsHandshakeTimeout   = 10 * time.Second
+	expectContinueTimeout = time.Second
+)
+
+// New returns a client with a transport tuned for frequent requests to the same hosts: connections are
+// kept alive and reused between checks, HTTP/2 is negotiated when the server supports it and resolved
+// addresses are cached for dnsCacheTTL (zero disables the cache). The timeout limits a whole request,
+// zero means no limit. The network options set the IP version, the static addresses of hosts and the DNS
+// server the connections are made with, the client certificate is presented to the servers requiring one.
+func New(
+	timeout, dnsCacheTTL time.Duration,
+	network models.TargetNetwork,
+	clientTLS models.TargetTLS,
+) *http.Client {
+	dialer := &net.Dialer{Timeout: dialTimeout, KeepAlive: keepAlive}
+	if network.Resolver != "" {
+		dialer.Resolver = dnsServer(network.ResolverAddress())
+	}
+
+	var dialContext dialFunc = dialer.DialContext
+	if dnsCacheTTL > 0 {
+		cache := newResolver(dnsCacheTTL)
+		if dialer.Resolver != nil {
+			cache.lookup = dialer.Resolver.LookupHost
+		}
+		dialContext = cache.dialContext(dialer)
+	}
+	dialContext = networkDial(dialContext, network)
+
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          maxIdleConns,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		IdleConnTimeout:       idleConnTimeout,
+		TLSHandshakeTimeout:   tlsHandshakeTimeout,
+		ExpectContinueTimeout: expectContinueTimeout,
+	}
+	if !clientTLS.IsZero() {
+		transport.TLSClientConfig = clientTLSConfig(clientTLS)
+	}
+
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
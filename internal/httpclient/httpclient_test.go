@@ -0,0 +1,203 @@
+package httpclient_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/httpclient"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_ReusesConnections(t *testing.T) {
+	t.Parallel()
+
+	var remotes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remotes = append(remotes, r.RemoteAddr)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	client := httpclient.New(5*time.Second, time.Minute, models.TargetNetwork{}, models.TargetTLS{})
+	for range 3 {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	require.Len(t, remotes, 3)
+	assert.Equal(t, remotes[0], remotes[2], "requests must share a kept-alive connection")
+	assert.Equal(t, 5*time.Second, client.Timeout)
+}
+
+func TestNew_Network(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Host))
+	}))
+	t.Cleanup(server.Close)
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	page := "http://shop.test:" + port + "/"
+
+	get := func(client *http.Client) (string, error) {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, page, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body), nil
+	}
+
+	for _, ttl := range []time.Duration{0, time.Minute} {
+		client := httpclient.New(5*time.Second, ttl, models.TargetNetwork{
+			IPVersion: models.IPv4, Hosts: map[string]string{"Shop.test": "127.0.0.1"},
+		}, models.TargetTLS{})
+		host, err := get(client)
+		require.NoError(t, err, "the host is connected to its static address")
+		assert.Equal(t, "shop.test:"+port, host, "the request still names the host")
+	}
+
+	client := httpclient.New(5*time.Second, 0, models.TargetNetwork{
+		IPVersion: models.IPv6, Hosts: map[string]string{"shop.test": "127.0.0.1"},
+	}, models.TargetTLS{})
+	_, err = get(client)
+	require.Error(t, err, "an IPv4 address isn't dialed over IPv6")
+}
+
+// clientCertificate returns a self-signed client certificate and its key, PEM encoded.
+func clientCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "chrono-flow"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func TestNew_ClientCertificate(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	cert, key := clientCertificate(t)
+	certPath := filepath.Join(t.TempDir(), "client.crt")
+	require.NoError(t, os.WriteFile(certPath, []byte(cert), 0o600))
+
+	get := func(clientTLS models.TargetTLS) (string, error) {
+		client := httpclient.New(5*time.Second, 0, models.TargetNetwork{}, clientTLS)
+		transport, ok := client.Transport.(*http.Transport)
+		require.True(t, ok)
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		transport.TLSClientConfig.RootCAs = x509.NewCertPool()
+		transport.TLSClientConfig.RootCAs.AddCert(server.Certificate())
+
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body), nil
+	}
+
+	subject, err := get(models.TargetTLS{Cert: certPath, Key: key})
+	require.NoError(t, err, "the certificate is read from the file, the key is inline")
+	assert.Equal(t, "chrono-flow", subject)
+
+	_, err = get(models.TargetTLS{})
+	require.Error(t, err, "the server requires a client certificate")
+
+	_, err = get(models.TargetTLS{Cert: filepath.Join(t.TempDir(), "missing.crt"), Key: key})
+	require.ErrorContains(t, err, "failed to read the client certificate")
+}
+
+func TestClientCertificate(t *testing.T) {
+	t.Parallel()
+
+	cert, key := clientCertificate(t)
+	_, otherKey := clientCertificate(t)
+
+	pair, err := httpclient.ClientCertificate(models.TargetTLS{Cert: cert, Key: key})
+	require.NoError(t, err)
+	assert.Len(t, pair.Certificate, 1)
+
+	_, err = httpclient.ClientCertificate(models.TargetTLS{Cert: cert})
+	require.ErrorIs(t, err, models.ErrInvalidTargetTLS)
+
+	_, err = httpclient.ClientCertificate(models.TargetTLS{Cert: cert, Key: otherKey})
+	require.ErrorContains(t, err, "invalid client certificate")
+}
+
+func TestWithHeaders(t *testing.T) {
+	t.Parallel()
+
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	client := httpclient.New(5*time.Second, 0, models.TargetNetwork{}, models.TargetTLS{})
+	client.Transport = httpclient.WithHeaders(client.Transport, map[string]string{
+		"Accept-Language": "de-DE,de;q=0.9",
+		"X-Forwarded-For": "203.0.113.7",
+	})
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Language", "en")
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.Equal(t, "de-DE,de;q=0.9", got.Get("Accept-Language"), "the header of the request is replaced")
+	assert.Equal(t, "203.0.113.7", got.Get("X-Forwarded-For"))
+	assert.Equal(t, "en", req.Header.Get("Accept-Language"), "the request itself isn't modified")
+}
@@ -0,0 +1,71 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// dialFunc connects to an address like net.Dialer.DialContext does.
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// dnsServer returns a resolver sending its queries to the DNS server at the address instead of the
+// servers of the system.
+func dnsServer(address string) *net.Resolver {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, address)
+		},
+	}
+}
+
+// networkDial returns a dial function connecting through next with the IP version and the static
+// addresses of the hosts of the options.
+func networkDial(next dialFunc, options models.TargetNetwork) dialFunc {
+	if options.IPVersion == "" && len(options.Hosts) == 0 {
+		return next
+	}
+
+	hosts := make(map[string]string, len(options.Hosts))
+	for host, addr := range options.Hosts {
+		hosts[strings.ToLower(host)] = addr
+	}
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		switch {
+		case network != "tcp":
+		case options.IPVersion == models.IPv4:
+			network = "tcp4"
+		case options.IPVersion == models.IPv6:
+			network = "tcp6"
+		}
+		if host, port, err := net.SplitHostPort(address); err == nil {
+			if addr, ok := hosts[strings.ToLower(host)]; ok {
+				address = net.JoinHostPort(addr, port)
+			}
+		}
+
+		return next(ctx, network, address)
+	}
+}
+
+// matchesNetwork reports whether the IP address can be dialed on the network, e.g. tcp4 only dials
+// IPv4 addresses.
+func matchesNetwork(network, addr string) bool {
+	ip := net.ParseIP(addr)
+	switch {
+	case ip == nil:
+		return true
+	case strings.HasSuffix(network, "4"):
+		return ip.To4() != nil
+	case strings.HasSuffix(network, "6"):
+		return ip.To4() == nil
+	default:
+		return true
+	}
+}
@@ -0,0 +1,65 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// pemPrefix starts the PEM encoded certificates and keys, telling them apart from the paths of files.
+const pemPrefix = "-----BEGIN"
+
+// ClientCertificate loads the client certificate of the options from their PEM values or files.
+func ClientCertificate(options models.TargetTLS) (tls.Certificate, error) {
+	if err := options.Validate(); err != nil {
+		return tls.Certificate{}, err //nolint:wrapcheck // the validation error is descriptive on its own.
+	}
+
+	cert, err := readPEM(options.Cert)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to read the client certificate: %w", err)
+	}
+	key, err := readPEM(options.Key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to read the client key: %w", err)
+	}
+
+	pair, err := tls.X509KeyPair(cert, key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("invalid client certificate: %w", err)
+	}
+
+	return pair, nil
+}
+
+// readPEM returns the PEM value, or the content of the file if the value is a path.
+func readPEM(value string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), pemPrefix) {
+		return []byte(value), nil
+	}
+
+	data, err := os.ReadFile(value)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the caller names the file.
+	}
+
+	return data, nil
+}
+
+// clientTLSConfig returns the TLS configuration presenting the client certificate of the options to the
+// servers asking for one. The certificate is loaded on every handshake, a failure fails the request.
+func clientTLSConfig(options models.TargetTLS) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := ClientCertificate(options)
+			if err != nil {
+				return nil, err
+			}
+			return &cert, nil
+		},
+	}
+}
@@ -0,0 +1,74 @@
+// Package instance keeps a second process from running the same part of the monitor against the same
+// database, which would notify the subscribers twice and fight over the locks of SQLite. The lock is a
+// lock of the operating system on a file next to the database, so it's released when the process dies
+// and a crash doesn't leave a stale lock behind.
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrLocked is returned when another process holds the lock.
+var ErrLocked = errors.New("another instance holds the lock")
+
+// maxPIDSize limits the PID read from a lock file held by another process.
+const maxPIDSize = 32
+
+// Lock is a lock on a file held by the process until it's released or the process exits.
+type Lock struct {
+	file *os.File
+}
+
+// Acquire takes the lock on the file at the path, creating the file if needed, and writes the PID of
+// the process into it. If another process holds the lock, the error wraps ErrLocked and names its PID.
+func Acquire(path string) (*Lock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644) //nolint:gosec,mnd // the lock file isn't secret.
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	locked, err := tryLock(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	if !locked {
+		holder, _ := io.ReadAll(io.LimitReader(file, maxPIDSize))
+		_ = file.Close()
+		if pid := strings.TrimSpace(string(holder)); pid != "" {
+			return nil, fmt.Errorf("%w: %s is held by PID %s", ErrLocked, path, pid)
+		}
+
+		return nil, fmt.Errorf("%w: %s", ErrLocked, path)
+	}
+
+	if err = file.Truncate(0); err == nil {
+		_, err = file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	if err != nil {
+		_ = unlock(file)
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to write the PID to %s: %w", path, err)
+	}
+
+	return &Lock{file: file}, nil
+}
+
+// Release releases the lock. The file is kept, deleting it would race with a process locking it.
+func (l *Lock) Release() error {
+	_ = l.file.Truncate(0)
+	err := unlock(l.file)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+
+	return nil
+}
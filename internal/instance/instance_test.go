@@ -0,0 +1,45 @@
+//go:build unix && !aix
+
+package instance_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/instance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chrono-flow.db.checker.lock")
+
+	// Act
+	lock, err := instance.Acquire(path)
+
+	// Assert
+	require.NoError(t, err)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid())+"\n", string(content))
+
+	_, err = instance.Acquire(path)
+	require.ErrorIs(t, err, instance.ErrLocked)
+	assert.ErrorContains(t, err, "held by PID "+strconv.Itoa(os.Getpid()))
+
+	require.NoError(t, lock.Release())
+	lock, err = instance.Acquire(path)
+	require.NoError(t, err, "the released lock can be taken again")
+	require.NoError(t, lock.Release())
+}
+
+func TestAcquire_Error(t *testing.T) {
+	// Act
+	_, err := instance.Acquire(filepath.Join(t.TempDir(), "missing", "chrono-flow.db.lock"))
+
+	// Assert
+	require.ErrorContains(t, err, "failed to open lock file")
+	assert.NotErrorIs(t, err, instance.ErrLocked)
+}
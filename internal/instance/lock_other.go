@@ -0,0 +1,16 @@
+//go:build !(unix && !aix) && !windows
+
+package instance
+
+import "os"
+
+// tryLock doesn't lock the file, the platform has no file locks the package supports, so the PID is
+// written but a second process isn't kept from starting.
+func tryLock(*os.File) (bool, error) {
+	return true, nil
+}
+
+// unlock does nothing, see tryLock.
+func unlock(*os.File) error {
+	return nil
+}
@@ -0,0 +1,29 @@
+//go:build unix && !aix
+
+package instance
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// tryLock takes the exclusive lock on the file without waiting, it reports false if another process
+// holds it.
+func tryLock(file *os.File) (bool, error) {
+	err := unix.Flock(int(file.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if errors.Is(err, unix.EWOULDBLOCK) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err //nolint:wrapcheck // Acquire wraps the error.
+	}
+
+	return true, nil
+}
+
+// unlock releases the lock on the file.
+func unlock(file *os.File) error {
+	return unix.Flock(int(file.Fd()), unix.LOCK_UN) //nolint:wrapcheck // Release wraps the error.
+}
@@ -0,0 +1,42 @@
+//go:build windows
+
+package instance
+
+import (
+	"errors"
+	"math"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockRange is the byte range locked, it's past the PID, so other processes can still read it.
+func lockRange() *windows.Overlapped {
+	return &windows.Overlapped{Offset: math.MaxUint32, OffsetHigh: math.MaxInt32}
+}
+
+// tryLock takes the exclusive lock on the file without waiting, it reports false if another process
+// holds it.
+func tryLock(file *os.File) (bool, error) {
+	err := windows.LockFileEx(
+		windows.Handle(file.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY,
+		0,
+		1,
+		0,
+		lockRange(),
+	)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err //nolint:wrapcheck // Acquire wraps the error.
+	}
+
+	return true, nil
+}
+
+// unlock releases the lock on the file.
+func unlock(file *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(file.Fd()), 0, 1, 0, lockRange()) //nolint:wrapcheck // Release wraps it.
+}
@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/coreos/go-systemd/v22/journal"
+)
+
+// journalHandler sends the records to the systemd journal. The attributes are appended to the message,
+// so "journalctl" shows them, and are sent as fields, so they can be matched, e.g. COMPONENT=parser.
+type journalHandler struct {
+	send   func(message string, priority journal.Priority, vars map[string]string) error
+	attrs  []slog.Attr
+	prefix string
+}
+
+func (h *journalHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *journalHandler) Handle(_ context.Context, record slog.Record) error {
+	attrs := make([]slog.Attr, 0, len(h.attrs)+record.NumAttrs())
+	attrs = append(attrs, h.attrs...)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, h.prefixed(attr))
+		return true
+	})
+
+	var message strings.Builder
+	message.WriteString(record.Message)
+	vars := make(map[string]string, len(attrs))
+	for _, attr := range attrs {
+		appendJournalAttr(&message, vars, "", attr)
+	}
+
+	return h.send(message.String(), journalPriority(record.Level), vars)
+}
+
+func (h *journalHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *h
+	handler.attrs = make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	handler.attrs = append(handler.attrs, h.attrs...)
+	for _, attr := range attrs {
+		handler.attrs = append(handler.attrs, h.prefixed(attr))
+	}
+
+	return &handler
+}
+
+func (h *journalHandler) WithGroup(name string) slog.Handler {
+	handler := *h
+	handler.prefix += name + "."
+
+	return &handler
+}
+
+// prefixed returns the attribute with the groups of the handler prepended to its key.
+func (h *journalHandler) prefixed(attr slog.Attr) slog.Attr {
+	attr.Key = h.prefix + attr.Key
+	return attr
+}
+
+// appendJournalAttr appends the attribute to the message as key=value and adds it to the fields,
+// group attributes are flattened with dotted keys.
+func appendJournalAttr(message *strings.Builder, vars map[string]string, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, member := range attr.Value.Group() {
+			appendJournalAttr(message, vars, prefix, member)
+		}
+		return
+	}
+
+	key, value := prefix+attr.Key, attr.Value.String()
+	message.WriteString(" " + key + "=" + value)
+	if field := journalField(key); field != "" && field != "MESSAGE" && field != "PRIORITY" {
+		vars[field] = value
+	}
+}
+
+// journalField converts an attribute key to a journal field name: upper-case letters, digits and
+// underscores, not starting with an underscore, which is reserved for trusted fields.
+func journalField(key string) string {
+	field := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+
+	return strings.TrimLeft(field, "_0123456789")
+}
+
+// journalPriority maps a record level to a journal priority.
+func journalPriority(level slog.Level) journal.Priority {
+	switch {
+	case level >= slog.LevelError:
+		return journal.PriErr
+	case level >= slog.LevelWarn:
+		return journal.PriWarning
+	case level >= slog.LevelInfo:
+		return journal.PriInfo
+	default:
+		return journal.PriDebug
+	}
+}
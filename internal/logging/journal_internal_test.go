@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/coreos/go-systemd/v22/journal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalHandler(t *testing.T) {
+	type entry struct {
+		message  string
+		priority journal.Priority
+		vars     map[string]string
+	}
+	var sent []entry
+	handler := &journalHandler{send: func(message string, priority journal.Priority, vars map[string]string) error {
+		sent = append(sent, entry{message: message, priority: priority, vars: vars})
+		return nil
+	}}
+
+	logger := slog.New(handler).With(ComponentKey, ComponentParser).WithGroup("fetch")
+	logger.Warn("slow page", "latency", "3s", slog.Group("page", "url", "https://example.com"), "message", "x")
+	slog.New(handler).Debug("tick")
+
+	assert.Equal(t, []entry{
+		{
+			message:  "slow page component=parser fetch.latency=3s fetch.page.url=https://example.com fetch.message=x",
+			priority: journal.PriWarning,
+			vars: map[string]string{
+				"COMPONENT":      "parser",
+				"FETCH_LATENCY":  "3s",
+				"FETCH_PAGE_URL": "https://example.com",
+				"FETCH_MESSAGE":  "x",
+			},
+		},
+		{message: "tick", priority: journal.PriDebug, vars: map[string]string{}},
+	}, sent)
+}
+
+func TestJournalField(t *testing.T) {
+	assert.Equal(t, "TARGET_URL", journalField("target.url"))
+	assert.Equal(t, "ERROR", journalField("_error"))
+	assert.Equal(t, "CHAT_ID", journalField("chat-id"))
+}
@@ -0,0 +1,277 @@
+// Package logging builds the application logger: it writes records to several sinks and filters them
+// by the level of the component that logged them.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/journal"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// ComponentKey is the attribute naming the component a logger belongs to.
+const ComponentKey = "component"
+
+// Components whose level can be set apart from the level of the environment.
+const (
+	ComponentBot        = "bot"
+	ComponentParser     = "parser"
+	ComponentRepository = "repository"
+	// ComponentDefault names the loggers without one of the components.
+	ComponentDefault = "default"
+)
+
+// minLevel lets every record through the sinks, the levels of the components filter them.
+const minLevel = slog.Level(math.MinInt)
+
+// day is the unit lumberjack keeps rotated files for.
+const day = 24 * time.Hour
+
+var (
+	// ErrJournalUnavailable is returned when the journald sink is enabled but the journal can't be reached.
+	ErrJournalUnavailable = errors.New("the systemd journal is unavailable")
+	// ErrUnknownComponent is returned when changing the level of a component that doesn't exist.
+	ErrUnknownComponent = errors.New("unknown component")
+)
+
+// Components returns the components whose level can be set apart from ComponentDefault.
+func Components() []string {
+	return []string{ComponentBot, ComponentParser, ComponentRepository}
+}
+
+// Options configure the sinks and the levels of a logger.
+type Options struct {
+	// Level is the minimal level of the records of components without their own level.
+	Level slog.Level
+	// Levels override Level for the components.
+	Levels map[string]slog.Level
+	// JSON writes the records as JSON instead of text.
+	JSON bool
+	// AddSource adds the source position of the log call to the records.
+	AddSource bool
+	// OmitTime drops the time of the records written to the output, e.g. when the service manager adds it.
+	// The file keeps it.
+	OmitTime bool
+	// File is the file records are also written to, it's rotated by size. Empty disables the file.
+	File string
+	// MaxSize is the size in megabytes the file is rotated at.
+	MaxSize int
+	// MaxBackups is the number of rotated files kept, 0 keeps all of them.
+	MaxBackups int
+	// MaxAge is how long rotated files are kept, 0 keeps them forever.
+	MaxAge time.Duration
+	// Journald also sends the records to the systemd journal.
+	Journald bool
+}
+
+// Logs owns the logger and the sinks it writes to.
+type Logs struct {
+	logger *slog.Logger
+	levels *Levels
+	file   io.Closer
+}
+
+// New creates a logger writing to the output and the sinks enabled by the options.
+func New(output io.Writer, opts Options) (*Logs, error) {
+	fileOpts := &slog.HandlerOptions{Level: minLevel, AddSource: opts.AddSource}
+	outputOpts := *fileOpts
+	if opts.OmitTime {
+		outputOpts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		}
+	}
+	newHandler := func(w io.Writer, handlerOpts *slog.HandlerOptions) slog.Handler {
+		if opts.JSON {
+			return slog.NewJSONHandler(w, handlerOpts)
+		}
+		return slog.NewTextHandler(w, handlerOpts)
+	}
+
+	logs := &Logs{levels: NewLevels(opts.Level, opts.Levels)}
+	sinks := fanout{newHandler(output, &outputOpts)}
+	if opts.File != "" {
+		file := &lumberjack.Logger{
+			Filename:   opts.File,
+			MaxSize:    opts.MaxSize,
+			MaxBackups: opts.MaxBackups,
+			MaxAge:     int((opts.MaxAge + day - 1) / day),
+		}
+		logs.file = file
+		sinks = append(sinks, newHandler(file, fileOpts))
+	}
+	if opts.Journald {
+		if !journal.Enabled() {
+			return nil, ErrJournalUnavailable
+		}
+		sinks = append(sinks, &journalHandler{send: journal.Send})
+	}
+	logs.logger = slog.New(&componentHandler{
+		inner: sinks, levels: logs.levels, level: logs.levels.leveler(ComponentDefault),
+	})
+
+	return logs, nil
+}
+
+// Logger returns the logger, loggers of a component add the ComponentKey attribute to it.
+func (l *Logs) Logger() *slog.Logger {
+	return l.logger
+}
+
+// Levels returns the levels the records are filtered by.
+func (l *Logs) Levels() *Levels {
+	return l.levels
+}
+
+// Close closes the log file.
+func (l *Logs) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	return nil
+}
+
+// Levels holds the minimal level of the records of every component. The levels are slog.LevelVar,
+// so changing one applies to the loggers already created.
+type Levels struct {
+	// configured are the levels the components started with.
+	configured map[string]slog.Level
+	vars       map[string]*slog.LevelVar
+}
+
+// NewLevels creates the levels of the components, the ones without an override use the base level,
+// which is also the level of ComponentDefault.
+func NewLevels(base slog.Level, overrides map[string]slog.Level) *Levels {
+	levels := &Levels{configured: make(map[string]slog.Level), vars: make(map[string]*slog.LevelVar)}
+	for _, component := range append([]string{ComponentDefault}, Components()...) {
+		level, ok := overrides[component]
+		if !ok {
+			level = base
+		}
+		levels.configured[component] = level
+		levels.vars[component] = new(slog.LevelVar)
+		levels.vars[component].Set(level)
+	}
+
+	return levels
+}
+
+// Level returns the minimal level of the records of the component, loggers without a known component
+// use the level of ComponentDefault.
+func (l *Levels) Level(component string) slog.Level {
+	return l.leveler(component).Level()
+}
+
+// All returns the current level of ComponentDefault and of every component.
+func (l *Levels) All() map[string]slog.Level {
+	levels := make(map[string]slog.Level, len(l.vars))
+	for component, level := range l.vars {
+		levels[component] = level.Level()
+	}
+
+	return levels
+}
+
+// Set changes the level of the component.
+func (l *Levels) Set(component string, level slog.Level) error {
+	levelVar, ok := l.vars[component]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownComponent, component)
+	}
+	levelVar.Set(level)
+
+	return nil
+}
+
+// Reset restores the configured level of the component.
+func (l *Levels) Reset(component string) error {
+	return l.Set(component, l.configured[component])
+}
+
+// leveler returns the level variable of the component.
+func (l *Levels) leveler(component string) slog.Leveler {
+	if levelVar, ok := l.vars[component]; ok {
+		return levelVar
+	}
+
+	return l.vars[ComponentDefault]
+}
+
+// componentHandler filters the records by the level of the component of the logger.
+type componentHandler struct {
+	inner  slog.Handler
+	levels *Levels
+	level  slog.Leveler
+}
+
+func (h *componentHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.inner.Enabled(ctx, level)
+}
+
+func (h *componentHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.inner.Handle(ctx, record) //nolint:wrapcheck // the handler errors are returned to slog as is.
+}
+
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	level := h.level
+	for _, attr := range attrs {
+		if attr.Key == ComponentKey {
+			level = h.levels.leveler(attr.Value.String())
+		}
+	}
+
+	return &componentHandler{inner: h.inner.WithAttrs(attrs), levels: h.levels, level: level}
+}
+
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	return &componentHandler{inner: h.inner.WithGroup(name), levels: h.levels, level: h.level}
+}
+
+// fanout passes the records to all of its handlers.
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, level slog.Level) bool {
+	return slices.ContainsFunc(f, func(h slog.Handler) bool { return h.Enabled(ctx, level) })
+}
+
+func (f fanout) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, record.Level) {
+			errs = append(errs, h.Handle(ctx, record.Clone()))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanout, 0, len(f))
+	for _, h := range f {
+		handlers = append(handlers, h.WithAttrs(attrs))
+	}
+
+	return handlers
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	handlers := make(fanout, 0, len(f))
+	for _, h := range f {
+		handlers = append(handlers, h.WithGroup(name))
+	}
+
+	return handlers
+}
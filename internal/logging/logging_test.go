@@ -0,0 +1,92 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_ComponentLevels(t *testing.T) {
+	var output bytes.Buffer
+	logs, err := logging.New(&output, logging.Options{
+		Level:  slog.LevelWarn,
+		Levels: map[string]slog.Level{logging.ComponentParser: slog.LevelDebug},
+		JSON:   true,
+	})
+	require.NoError(t, err)
+	defer logs.Close()
+
+	logger := logs.Logger()
+	parserLogger := logger.With(logging.ComponentKey, logging.ComponentParser)
+	botLogger := logger.With(logging.ComponentKey, logging.ComponentBot)
+
+	logger.Info("app info")
+	logger.Warn("app warning")
+	parserLogger.WithGroup("fetch").Debug("parser debug", "rows", 3)
+	botLogger.Info("bot info")
+	botLogger.Error("bot error")
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		messages = append(messages, record["msg"].(string))
+	}
+	assert.Equal(t, []string{"app warning", "parser debug", "bot error"}, messages)
+	assert.Contains(t, output.String(), `"component":"parser","fetch":{"rows":3}`)
+	assert.Equal(t, slog.LevelDebug, logs.Levels().Level(logging.ComponentParser))
+	assert.Equal(t, slog.LevelWarn, logs.Levels().Level(logging.ComponentBot))
+}
+
+func TestNew_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chrono-flow.log")
+	var output bytes.Buffer
+	logs, err := logging.New(&output, logging.Options{Level: slog.LevelInfo, OmitTime: true, File: path, MaxSize: 1})
+	require.NoError(t, err)
+
+	logs.Logger().Info("started", "interval", "10m")
+	require.NoError(t, logs.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "level=INFO msg=started interval=10m\n", output.String())
+	assert.True(t, strings.HasPrefix(string(data), "time="), "the file keeps the time")
+	assert.True(t, strings.HasSuffix(string(data), " "+output.String()))
+}
+
+func TestLevels_SetReset(t *testing.T) {
+	var output bytes.Buffer
+	logs, err := logging.New(&output, logging.Options{Level: slog.LevelWarn})
+	require.NoError(t, err)
+	defer logs.Close()
+
+	parserLogger := logs.Logger().With(logging.ComponentKey, logging.ComponentParser)
+	parserLogger.Debug("hidden")
+
+	levels := logs.Levels()
+	require.NoError(t, levels.Set(logging.ComponentParser, slog.LevelDebug))
+	parserLogger.Debug("shown")
+	logs.Logger().Info("default stays at warn")
+	assert.Equal(t, map[string]slog.Level{
+		logging.ComponentDefault:    slog.LevelWarn,
+		logging.ComponentBot:        slog.LevelWarn,
+		logging.ComponentParser:     slog.LevelDebug,
+		logging.ComponentRepository: slog.LevelWarn,
+	}, levels.All())
+
+	require.NoError(t, levels.Reset(logging.ComponentParser))
+	parserLogger.Debug("hidden again")
+	assert.Equal(t, slog.LevelWarn, levels.Level(logging.ComponentParser))
+
+	require.ErrorIs(t, levels.Set("scheduler", slog.LevelDebug), logging.ErrUnknownComponent)
+	require.ErrorIs(t, levels.Reset("scheduler"), logging.ErrUnknownComponent)
+	assert.Equal(t, "level=DEBUG msg=shown component=parser\n", strings.SplitN(output.String(), " ", 2)[1])
+}
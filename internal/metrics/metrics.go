@@ -0,0 +1,304 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "chrono_flow"
+
+// Metrics holds the application's Prometheus collectors.
+type Metrics struct {
+	registry *prometheus.Registry
+	// registerer registers the collectors in the registry with the constant labels, see WithLabels.
+	registerer prometheus.Registerer
+
+	// MaintenanceRuns counts maintenance runs by result ("success" or "error").
+	MaintenanceRuns *prometheus.CounterVec
+	// DatabaseSize is the size of the database file after the last maintenance run.
+	DatabaseSize prometheus.Gauge
+	// ReclaimedBytes is the total disk space reclaimed by maintenance runs.
+	ReclaimedBytes prometheus.Counter
+	// PrunedRecords is the total number of history records deleted by maintenance runs.
+	PrunedRecords prometheus.Counter
+
+	// Fetches counts fetches of the target page by result ("success" or "error").
+	Fetches *prometheus.CounterVec
+	// FetchDuration observes the latency of fetches of the target page.
+	FetchDuration prometheus.Histogram
+
+	// ParsedRows counts rows parsed from the target page by validation result ("valid" or "invalid"),
+	// valid rows repeating a model already listed are also counted as "duplicate".
+	ParsedRows *prometheus.CounterVec
+	// InvalidRowRatio is the share of invalid rows on the last parsed page.
+	InvalidRowRatio prometheus.Gauge
+
+	// CheckFailures counts the failed checks of the target page by the class of their error, e.g. "fetch"
+	// or "parse", see checker.ErrorClass.
+	CheckFailures *prometheus.CounterVec
+
+	// BotUpdates counts the updates handled by the bot by route and result ("ok", "error", "panic",
+	// "denied" or "limited").
+	BotUpdates *prometheus.CounterVec
+	// BotUpdateDuration observes how long the bot handled the updates of a route.
+	BotUpdateDuration *prometheus.HistogramVec
+
+	// BroadcastQueue is the number of messages waiting for a broadcast worker.
+	BroadcastQueue prometheus.Gauge
+	// BroadcastMessages counts the messages of the broadcasts sent to the chats, failed ones included.
+	BroadcastMessages prometheus.Counter
+	// BroadcastsInFlight is the number of broadcasts whose messages aren't all sent yet, more than one
+	// means a broadcast overlaps with the next one.
+	BroadcastsInFlight prometheus.Gauge
+	// BroadcastDuration observes how long the broadcasts took from the first message queued to the last
+	// one sent.
+	BroadcastDuration prometheus.Histogram
+
+	// TelegramRequests counts the requests to the Telegram Bot API by method and result ("ok", "rejected",
+	// "throttled" or "error"), every attempt of a retried request is counted.
+	TelegramRequests *prometheus.CounterVec
+	// TelegramRequestDuration observes how long the requests to the Telegram Bot API took by method.
+	TelegramRequestDuration *prometheus.HistogramVec
+	// TelegramRetries counts the requests to the Telegram Bot API retried after Telegram throttled them.
+	TelegramRetries *prometheus.CounterVec
+
+	// RepositoryQueries counts the queries of the repository by method and result ("ok", "error",
+	// "timeout" or "conflict").
+	RepositoryQueries *prometheus.CounterVec
+	// RepositoryQueryDuration observes how long the queries of a repository method took.
+	RepositoryQueryDuration *prometheus.HistogramVec
+}
+
+// Option configures the Metrics.
+type Option func(*Metrics)
+
+// WithLabels adds the constant labels to every metric, e.g. the pod and the namespace of the process.
+func WithLabels(labels map[string]string) Option {
+	return func(m *Metrics) {
+		if len(labels) > 0 {
+			m.registerer = prometheus.WrapRegistererWith(labels, m.registry)
+		}
+	}
+}
+
+// New creates the application metrics and registers them in a dedicated registry.
+func New(opts ...Option) *Metrics {
+	registry := prometheus.NewRegistry()
+	metrics := &Metrics{
+		registry:   registry,
+		registerer: registry,
+		MaintenanceRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "maintenance",
+			Name:      "runs_total",
+			Help:      "Number of database maintenance runs by result.",
+		}, []string{"result"}),
+		DatabaseSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "database",
+			Name:      "size_bytes",
+			Help:      "Size of the database after the last maintenance run.",
+		}),
+		ReclaimedBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "maintenance",
+			Name:      "reclaimed_bytes_total",
+			Help:      "Disk space reclaimed by database maintenance.",
+		}),
+		PrunedRecords: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "maintenance",
+			Name:      "pruned_records_total",
+			Help:      "History records deleted by database maintenance.",
+		}),
+		Fetches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "target",
+			Name:      "fetches_total",
+			Help:      "Number of fetches of the target page by result.",
+		}, []string{"result"}),
+		FetchDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "target",
+			Name:      "fetch_duration_seconds",
+			Help:      "Latency of fetches of the target page.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		ParsedRows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "parser",
+			Name:      "rows_total",
+			Help:      "Number of rows parsed from the target page by validation result.",
+		}, []string{"result"}),
+		InvalidRowRatio: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "parser",
+			Name:      "invalid_row_ratio",
+			Help:      "Share of invalid rows on the last parsed page.",
+		}),
+		CheckFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "target",
+			Name:      "check_failures_total",
+			Help:      "Number of failed checks of the target page by error class.",
+		}, []string{"class"}),
+		BotUpdates: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "bot",
+			Name:      "updates_total",
+			Help:      "Number of updates handled by the bot by route and result.",
+		}, []string{"route", "result"}),
+		BotUpdateDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "bot",
+			Name:      "update_duration_seconds",
+			Help:      "Time the bot took to handle an update by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route"}),
+		BroadcastQueue: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "broadcast",
+			Name:      "queued_messages",
+			Help:      "Number of messages waiting for a broadcast worker.",
+		}),
+		BroadcastMessages: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "broadcast",
+			Name:      "messages_total",
+			Help:      "Number of broadcast messages sent to the chats.",
+		}),
+		BroadcastsInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "broadcast",
+			Name:      "in_flight",
+			Help:      "Number of broadcasts still sending messages.",
+		}),
+		BroadcastDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "broadcast",
+			Name:      "duration_seconds",
+			Help:      "Time the broadcasts took to send their messages.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 12), //nolint:mnd // from a second to about an hour.
+		}),
+		TelegramRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "telegram",
+			Name:      "requests_total",
+			Help:      "Number of requests to the Telegram Bot API by method and result.",
+		}, []string{"method", "result"}),
+		TelegramRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "telegram",
+			Name:      "request_duration_seconds",
+			Help:      "Time the requests to the Telegram Bot API took by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		TelegramRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "telegram",
+			Name:      "retries_total",
+			Help:      "Number of requests to the Telegram Bot API retried after a throttling by method.",
+		}, []string{"method"}),
+		RepositoryQueries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "repository",
+			Name:      "queries_total",
+			Help:      "Number of repository queries by method and result.",
+		}, []string{"method", "result"}),
+		RepositoryQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "repository",
+			Name:      "query_duration_seconds",
+			Help:      "Time the repository queries took by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+	}
+
+	for _, opt := range opts {
+		opt(metrics)
+	}
+
+	metrics.registerer.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		metrics.MaintenanceRuns,
+		metrics.DatabaseSize,
+		metrics.ReclaimedBytes,
+		metrics.PrunedRecords,
+		metrics.Fetches,
+		metrics.FetchDuration,
+		metrics.ParsedRows,
+		metrics.InvalidRowRatio,
+		metrics.CheckFailures,
+		metrics.BotUpdates,
+		metrics.BotUpdateDuration,
+		metrics.BroadcastQueue,
+		metrics.BroadcastMessages,
+		metrics.BroadcastsInFlight,
+		metrics.BroadcastDuration,
+		metrics.TelegramRequests,
+		metrics.TelegramRequestDuration,
+		metrics.TelegramRetries,
+		metrics.RepositoryQueries,
+		metrics.RepositoryQueryDuration,
+	)
+
+	return metrics
+}
+
+// Handler returns an HTTP handler exposing the metrics in the Prometheus format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Serve exposes the metrics on addr at /metrics until the context is canceled.
+func (m *Metrics) Serve(ctx context.Context, log *slog.Logger, addr string) error {
+	const shutdownTimeout = 5 * time.Second
+	const readHeaderTimeout = 10 * time.Second
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: readHeaderTimeout}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.ErrorContext(ctx, "failed to shut down metrics server", "error", err)
+		}
+	}()
+
+	log.InfoContext(ctx, "Serving metrics", "addr", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("metrics server failed: %w", err)
+	}
+
+	return nil
+}
+
+// ObserveQuery counts a query of the repository method and observes how long it took, a query
+// failing with repository.ErrQueryTimeout is counted as "timeout" and one failing with
+// repository.ErrStateConflict as "conflict".
+func (m *Metrics) ObserveQuery(method string, took time.Duration, err error) {
+	result := "ok"
+	switch {
+	case errors.Is(err, repository.ErrQueryTimeout):
+		result = "timeout"
+	case errors.Is(err, repository.ErrStateConflict):
+		result = "conflict"
+	case err != nil:
+		result = "error"
+	}
+	m.RepositoryQueries.WithLabelValues(method, result).Inc()
+	m.RepositoryQueryDuration.WithLabelValues(method).Observe(took.Seconds())
+}
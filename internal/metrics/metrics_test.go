@@ -0,0 +1,94 @@
+package metrics_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/metrics"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_Handler(t *testing.T) {
+	t.Parallel()
+
+	appMetrics := metrics.New()
+	appMetrics.ReclaimedBytes.Add(4096)
+	appMetrics.MaintenanceRuns.WithLabelValues("success").Inc()
+
+	recorder := httptest.NewRecorder()
+	appMetrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	assert.Contains(t, body, "chrono_flow_maintenance_reclaimed_bytes_total 4096")
+	assert.Contains(t, body, `chrono_flow_maintenance_runs_total{result="success"} 1`)
+}
+
+func TestMetrics_WithLabels(t *testing.T) {
+	t.Parallel()
+
+	appMetrics := metrics.New(metrics.WithLabels(map[string]string{"pod": "chrono-flow-0", "namespace": "shop"}))
+	appMetrics.MaintenanceRuns.WithLabelValues("success").Inc()
+	appMetrics.RegisterSubscribers(subscriberSource{})
+
+	recorder := httptest.NewRecorder()
+	appMetrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	assert.Contains(t, body,
+		`chrono_flow_maintenance_runs_total{namespace="shop",pod="chrono-flow-0",result="success"} 1`)
+	assert.Contains(t, body, `chrono_flow_bot_subscribers{namespace="shop",pod="chrono-flow-0"} 12`)
+}
+
+// subscriberSource returns fixed subscriber statistics.
+type subscriberSource struct{}
+
+func (subscriberSource) GetSubscriberStats(context.Context, time.Time) ([]models.SubscriberDay, error) {
+	return []models.SubscriberDay{{Subscribers: 12, ActiveChats: 5}}, nil
+}
+
+func (subscriberSource) CountSubscriptionEvents(context.Context) (int64, int64, error) {
+	return 20, 8, nil
+}
+
+func TestMetrics_RegisterSubscribers(t *testing.T) {
+	t.Parallel()
+
+	appMetrics := metrics.New()
+	appMetrics.RegisterSubscribers(subscriberSource{})
+
+	recorder := httptest.NewRecorder()
+	appMetrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	assert.Contains(t, body, "chrono_flow_bot_subscribers 12")
+	assert.Contains(t, body, "chrono_flow_bot_active_chats 5")
+	assert.Contains(t, body, `chrono_flow_bot_subscription_events_total{event="subscribe"} 20`)
+	assert.Contains(t, body, `chrono_flow_bot_subscription_events_total{event="unsubscribe"} 8`)
+}
+
+func TestMetrics_ObserveQuery(t *testing.T) {
+	t.Parallel()
+
+	appMetrics := metrics.New()
+	appMetrics.ObserveQuery("GetState", 20*time.Millisecond, nil)
+	appMetrics.ObserveQuery("GetState", time.Second, fmt.Errorf("%w: slow", repository.ErrQueryTimeout))
+	appMetrics.ObserveQuery("UpdateState", time.Millisecond, assert.AnError)
+	appMetrics.ObserveQuery("UpdateState", time.Millisecond, fmt.Errorf("%w: locked", repository.ErrStateConflict))
+
+	assert.InDelta(t, 1, testutil.ToFloat64(appMetrics.RepositoryQueries.WithLabelValues("GetState", "ok")), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(appMetrics.RepositoryQueries.WithLabelValues("GetState", "timeout")), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(appMetrics.RepositoryQueries.WithLabelValues("UpdateState", "error")), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(appMetrics.RepositoryQueries.WithLabelValues("UpdateState", "conflict")), 0)
+	assert.Equal(t, 2, testutil.CollectAndCount(appMetrics.RepositoryQueryDuration))
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SubscriberSource provides the subscriber statistics exposed by the metrics.
+type SubscriberSource interface {
+	// GetSubscriberStats returns the statistics of every UTC day since the given time, oldest first.
+	GetSubscriberStats(ctx context.Context, since time.Time) ([]models.SubscriberDay, error)
+	// CountSubscriptionEvents returns how many times chats subscribed and unsubscribed in total.
+	CountSubscriptionEvents(ctx context.Context) (int64, int64, error)
+}
+
+// RegisterSubscribers exposes the subscribers, the chats active today and the subscription events of
+// the source. They're stored in the database, so they're read on every scrape.
+func (m *Metrics) RegisterSubscribers(source SubscriberSource) {
+	m.registerer.MustRegister(&subscribersCollector{
+		source: source,
+		subscribers: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bot", "subscribers"),
+			"Number of subscribed chats.", nil, nil,
+		),
+		activeChats: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bot", "active_chats"),
+			"Number of chats that used the bot today (UTC).", nil, nil,
+		),
+		events: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bot", "subscription_events_total"),
+			"Number of subscriptions and unsubscriptions by event.", []string{"event"}, nil,
+		),
+	})
+}
+
+// subscribersCollector reads the subscriber metrics from the source on every scrape.
+type subscribersCollector struct {
+	source      SubscriberSource
+	subscribers *prometheus.Desc
+	activeChats *prometheus.Desc
+	events      *prometheus.Desc
+}
+
+func (c *subscribersCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.subscribers
+	ch <- c.activeChats
+	ch <- c.events
+}
+
+func (c *subscribersCollector) Collect(ch chan<- prometheus.Metric) {
+	const timeout = 5 * time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	days, err := c.source.GetSubscriberStats(ctx, time.Now())
+	switch {
+	case err != nil:
+		ch <- prometheus.NewInvalidMetric(c.subscribers, err)
+	case len(days) > 0:
+		today := days[len(days)-1]
+		ch <- prometheus.MustNewConstMetric(c.subscribers, prometheus.GaugeValue, float64(today.Subscribers))
+		ch <- prometheus.MustNewConstMetric(c.activeChats, prometheus.GaugeValue, float64(today.ActiveChats))
+	}
+
+	subscribed, unsubscribed, err := c.source.CountSubscriptionEvents(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.events, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.events, prometheus.CounterValue, float64(subscribed), "subscribe")
+	ch <- prometheus.MustNewConstMetric(c.events, prometheus.CounterValue, float64(unsubscribed), "unsubscribe")
+}
@@ -0,0 +1,114 @@
+package models
+
+import "strings"
+
+// ChangeCategory classifies a change by how important it is for subscribers.
+type ChangeCategory string
+
+const (
+	CategoryAdded     ChangeCategory = "added"
+	CategoryRemoved   ChangeCategory = "removed"
+	CategoryRenamed   ChangeCategory = "renamed"
+	CategoryPriceDrop ChangeCategory = "price_drop"
+	CategoryPriceRise ChangeCategory = "price_rise"
+	// CategoryQuantity covers updates that leave the price untouched, e.g. stock changes.
+	CategoryQuantity ChangeCategory = "quantity"
+)
+
+// ChangeCategories returns all known categories in display order.
+func ChangeCategories() []ChangeCategory {
+	return []ChangeCategory{
+		CategoryAdded, CategoryRemoved, CategoryRenamed, CategoryPriceDrop, CategoryPriceRise, CategoryQuantity,
+	}
+}
+
+// IsValid reports whether the category is one of the known categories.
+func (c ChangeCategory) IsValid() bool {
+	for _, known := range ChangeCategories() {
+		if c == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Category classifies a single product update. A price that can't be parsed
+// but differs from the old one is treated as a rise, so it's never silenced as a drop.
+func (c ChangeInfo) Category() ChangeCategory {
+	if c.Old.Price == c.New.Price {
+		return CategoryQuantity
+	}
+
+	oldPrice, oldErr := c.Old.PriceValue()
+	newPrice, newErr := c.New.PriceValue()
+	if oldErr == nil && newErr == nil && newPrice < oldPrice {
+		return CategoryPriceDrop
+	}
+
+	return CategoryPriceRise
+}
+
+// Categories returns the set of categories present in the changes.
+func (c *Changes) Categories() map[ChangeCategory]bool {
+	categories := make(map[ChangeCategory]bool)
+	if len(c.Added) > 0 {
+		categories[CategoryAdded] = true
+	}
+	if len(c.Removed) > 0 {
+		categories[CategoryRemoved] = true
+	}
+	if len(c.Renamed) > 0 {
+		categories[CategoryRenamed] = true
+	}
+	for _, change := range c.Changed {
+		categories[change.Category()] = true
+	}
+
+	return categories
+}
+
+// ParseChangeCategory returns the category named by text, case-insensitive and with dashes or a plural
+// accepted, e.g. "price-drops" for CategoryPriceDrop.
+func ParseChangeCategory(text string) (ChangeCategory, bool) {
+	name := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(text)), "-", "_")
+	singular := strings.TrimSuffix(name, "s")
+	for _, candidate := range []ChangeCategory{ChangeCategory(name), ChangeCategory(singular)} {
+		if candidate.IsValid() {
+			return candidate, true
+		}
+	}
+
+	return "", false
+}
+
+// FilterByCategories returns only the changes of the given categories. An empty category list returns
+// the changes unfiltered.
+func (c *Changes) FilterByCategories(categories []ChangeCategory) *Changes {
+	if len(categories) == 0 {
+		return c
+	}
+
+	wanted := make(map[ChangeCategory]bool, len(categories))
+	for _, category := range categories {
+		wanted[category] = true
+	}
+
+	filtered := &Changes{}
+	if wanted[CategoryAdded] {
+		filtered.Added = c.Added
+	}
+	if wanted[CategoryRemoved] {
+		filtered.Removed = c.Removed
+	}
+	if wanted[CategoryRenamed] {
+		filtered.Renamed = c.Renamed
+	}
+	for _, change := range c.Changed {
+		if wanted[change.Category()] {
+			filtered.Changed = append(filtered.Changed, change)
+		}
+	}
+
+	return filtered
+}
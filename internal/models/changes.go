@@ -1,5 +1,13 @@
 package models
 
+import (
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
 // ChangeInfo - information about the changed product.
 type ChangeInfo struct {
 	Old Product
@@ -11,15 +19,163 @@ type Changes struct {
 	Added   []Product
 	Removed []Product
 	Changed []ChangeInfo
+	// Renamed holds pairs of removed and added products whose models are similar
+	// enough to be treated as the same product under a new name.
+	Renamed []ChangeInfo
 }
 
 // HasChanges checks if any changes have been detected.
 func (c *Changes) HasChanges() bool {
-	return len(c.Added) > 0 || len(c.Removed) > 0 || len(c.Changed) > 0
+	return len(c.Added) > 0 || len(c.Removed) > 0 || len(c.Changed) > 0 || len(c.Renamed) > 0
+}
+
+// Count returns the total number of changed products.
+func (c *Changes) Count() int {
+	return len(c.Added) + len(c.Removed) + len(c.Changed) + len(c.Renamed)
+}
+
+// Fingerprint identifies the change set regardless of the order of its changes: equal change sets have
+// equal fingerprints. It's empty if there are no changes.
+func (c *Changes) Fingerprint() string {
+	if !c.HasChanges() {
+		return ""
+	}
+
+	lines := make([]string, 0, c.Count())
+	for _, p := range c.Added {
+		lines = append(lines, fmt.Sprintf("added %#v", p))
+	}
+	for _, p := range c.Removed {
+		lines = append(lines, fmt.Sprintf("removed %#v", p))
+	}
+	for _, change := range c.Changed {
+		lines = append(lines, fmt.Sprintf("changed %#v", change))
+	}
+	for _, change := range c.Renamed {
+		lines = append(lines, fmt.Sprintf("renamed %#v", change))
+	}
+	sort.Strings(lines)
+
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(lines, "\n"))))
+}
+
+// ProductRef identifies a product by its category and model.
+type ProductRef struct {
+	Category string
+	Model    string
+}
+
+// ProductRefs returns the products affected by the changes, the new model of changed and renamed ones.
+func (c *Changes) ProductRefs() []ProductRef {
+	refs := make([]ProductRef, 0, c.Count())
+	for _, p := range c.Added {
+		refs = append(refs, ProductRef{Category: p.Category, Model: p.Model})
+	}
+	for _, change := range c.Changed {
+		refs = append(refs, ProductRef{Category: change.New.Category, Model: change.New.Model})
+	}
+	for _, change := range c.Renamed {
+		refs = append(refs, ProductRef{Category: change.New.Category, Model: change.New.Model})
+	}
+	for _, p := range c.Removed {
+		refs = append(refs, ProductRef{Category: p.Category, Model: p.Model})
+	}
+
+	return refs
+}
+
+// FilterByTypes returns only the changes of products with one of the given types (case-insensitive).
+// An empty type list returns the changes unfiltered.
+func (c *Changes) FilterByTypes(types []string) *Changes {
+	if len(types) == 0 {
+		return c
+	}
+
+	allowed := make(map[string]bool, len(types))
+	for _, t := range types {
+		allowed[strings.ToLower(t)] = true
+	}
+	matches := func(p Product) bool { return allowed[strings.ToLower(p.Type)] }
+
+	filtered := &Changes{}
+	for _, p := range c.Added {
+		if matches(p) {
+			filtered.Added = append(filtered.Added, p)
+		}
+	}
+	for _, p := range c.Removed {
+		if matches(p) {
+			filtered.Removed = append(filtered.Removed, p)
+		}
+	}
+	for _, change := range c.Changed {
+		if matches(change.Old) || matches(change.New) {
+			filtered.Changed = append(filtered.Changed, change)
+		}
+	}
+	for _, change := range c.Renamed {
+		if matches(change.Old) || matches(change.New) {
+			filtered.Renamed = append(filtered.Renamed, change)
+		}
+	}
+
+	return filtered
 }
 
 // State - the complete state stored in the database.
 type State struct {
 	PageHash string
 	Products []Product
+	// Outbox is queued for delivery in the transaction saving the state, nil queues nothing.
+	Outbox *OutboxEntry
+	// Skipped is set by the update saving the state to the products it failed to store, the others are
+	// saved without them and the skipped ones keep their stored version.
+	Skipped []SkippedProduct
+}
+
+// WithoutSkipped returns the changes without the ones of the products the update saving the state skipped,
+// the changes themselves if none was skipped. A skipped product keeps its stored version, so its change is
+// detected again by a later check: it's left out of the added and changed products, and a renamed product
+// is only removed. A product skipped as a repeat of a stored one isn't left out.
+func (s *State) WithoutSkipped(changes *Changes) *Changes {
+	if len(s.Skipped) == 0 {
+		return changes
+	}
+
+	// A product isn't stored if every product with its category and model was skipped.
+	pending := make(map[ProductRef]int, len(s.Products))
+	for _, p := range s.Products {
+		pending[ProductRef{Category: p.Category, Model: p.Model}]++
+	}
+	for _, skipped := range s.Skipped {
+		pending[ProductRef{Category: skipped.Product.Category, Model: skipped.Product.Model}]--
+	}
+	stored := func(p Product) bool { return pending[ProductRef{Category: p.Category, Model: p.Model}] > 0 }
+
+	filtered := &Changes{Removed: slices.Clone(changes.Removed)}
+	for _, p := range changes.Added {
+		if stored(p) {
+			filtered.Added = append(filtered.Added, p)
+		}
+	}
+	for _, change := range changes.Changed {
+		if stored(change.New) {
+			filtered.Changed = append(filtered.Changed, change)
+		}
+	}
+	for _, change := range changes.Renamed {
+		if stored(change.New) {
+			filtered.Renamed = append(filtered.Renamed, change)
+		} else {
+			filtered.Removed = append(filtered.Removed, change.Old)
+		}
+	}
+
+	return filtered
+}
+
+// SkippedProduct is a product left out of the saved state with the reason it couldn't be stored.
+type SkippedProduct struct {
+	Product Product
+	Reason  string
 }
@@ -0,0 +1,32 @@
+package models
+
+// VariantFeedback describes how the chats rated the notifications rendered with a template variant.
+type VariantFeedback struct {
+	Variant string
+	// Sent is the number of notifications sent with the variant.
+	Sent      int
+	Useful    int
+	NotUseful int
+}
+
+// EngagementRate returns the share of the sent notifications that were rated in percent, 0 if none were
+// sent. A notification rated by several members of a group counts once per vote.
+func (f VariantFeedback) EngagementRate() float64 {
+	const percent = 100
+	if f.Sent == 0 {
+		return 0
+	}
+
+	return float64(f.Useful+f.NotUseful) / float64(f.Sent) * percent
+}
+
+// UsefulRate returns the share of the votes rating the notifications useful in percent, 0 without votes.
+func (f VariantFeedback) UsefulRate() float64 {
+	const percent = 100
+	votes := f.Useful + f.NotUseful
+	if votes == 0 {
+		return 0
+	}
+
+	return float64(f.Useful) / float64(votes) * percent
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Heartbeat is the state of the dead-man's switch of a target.
+type Heartbeat struct {
+	// LastSuccess is the time of the last successful check, or of the first start if there was none.
+	LastSuccess time.Time
+	// Alerted is set once admins were told the checks went silent, until the next successful check.
+	Alerted bool
+}
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Change kinds stored in the change history and exports.
+const (
+	KindAdded   = "added"
+	KindChanged = "changed"
+	KindRenamed = "renamed"
+	KindRemoved = "removed"
+)
+
+// ChangeRecord is a single detected change stored in the change history.
+type ChangeRecord struct {
+	DetectedAt  time.Time
+	Kind        string
+	Model       string
+	OldModel    string
+	Category    string
+	Type        string
+	OldPrice    string
+	Price       string
+	OldQuantity string
+	Quantity    string
+	// RunID is the check run that detected the change, empty for changes imported or recorded before
+	// the runs were.
+	RunID string
+	// Note is the context an admin attached to the change with /annotate, e.g. a confirmed pricing error.
+	Note string
+}
+
+// Records flattens the changes into history records detected at the given time.
+func (c *Changes) Records(detectedAt time.Time) []ChangeRecord {
+	records := make([]ChangeRecord, 0, c.Count())
+	for _, p := range c.Added {
+		records = append(records, ChangeRecord{
+			DetectedAt: detectedAt, Kind: KindAdded, Model: p.Model, Category: p.Category, Type: p.Type,
+			Price: p.Price, Quantity: p.Quantity,
+		})
+	}
+	for _, change := range c.Changed {
+		records = append(records, changeRecord(detectedAt, KindChanged, change))
+	}
+	for _, change := range c.Renamed {
+		records = append(records, changeRecord(detectedAt, KindRenamed, change))
+	}
+	for _, p := range c.Removed {
+		records = append(records, ChangeRecord{
+			DetectedAt: detectedAt, Kind: KindRemoved, OldModel: p.Model, Category: p.Category, Type: p.Type,
+			OldPrice: p.Price, OldQuantity: p.Quantity,
+		})
+	}
+
+	return records
+}
+
+// changeRecord converts a changed or renamed product into a history record.
+func changeRecord(detectedAt time.Time, kind string, change ChangeInfo) ChangeRecord {
+	return ChangeRecord{
+		DetectedAt:  detectedAt,
+		Kind:        kind,
+		Model:       change.New.Model,
+		OldModel:    change.Old.Model,
+		Category:    change.New.Category,
+		Type:        change.New.Type,
+		OldPrice:    change.Old.Price,
+		Price:       change.New.Price,
+		OldQuantity: change.Old.Quantity,
+		Quantity:    change.New.Quantity,
+	}
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidSort is returned for an unknown product sort order.
+var ErrInvalidSort = errors.New("invalid sort order")
+
+// ProductSort is the order products are listed in.
+type ProductSort string
+
+const (
+	// SortByModel orders products by category and model, it's the default order.
+	SortByModel ProductSort = "model"
+	// SortByType orders products by type, then by category and model.
+	SortByType ProductSort = "type"
+	// SortByPrice orders products from the cheapest, products without a numeric price come last.
+	SortByPrice ProductSort = "price"
+	// SortByPriceDesc orders products from the most expensive, products without a numeric price come last.
+	SortByPriceDesc ProductSort = "-price"
+)
+
+// ParseProductSort converts a sort order name into a ProductSort, the empty name is the default order.
+func ParseProductSort(name string) (ProductSort, error) {
+	switch sort := ProductSort(name); sort {
+	case "":
+		return SortByModel, nil
+	case SortByModel, SortByType, SortByPrice, SortByPriceDesc:
+		return sort, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidSort, name)
+	}
+}
+
+// ProductFilter selects the listed products, zero fields don't filter.
+type ProductFilter struct {
+	Category string
+	Type     string
+	// MinPrice and MaxPrice bound the numeric price, products without a numeric price are left out
+	// if either is set.
+	MinPrice *float64
+	MaxPrice *float64
+	// InStock leaves out the products whose quantity has no non-zero digit, e.g. "0" or "".
+	InStock bool
+	// Extras maps the names of extra or computed fields to the values the products must have.
+	Extras map[string]string
+}
+
+// ProductPage is a page of the listed products.
+type ProductPage struct {
+	Products []Product
+	// Total is the number of products matching the filter on all pages.
+	Total int
+}
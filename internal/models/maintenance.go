@@ -0,0 +1,13 @@
+package models
+
+// MaintenanceReport describes the result of a database maintenance run.
+type MaintenanceReport struct {
+	// SizeBefore and SizeAfter are the database sizes in bytes.
+	SizeBefore int64
+	SizeAfter  int64
+}
+
+// Reclaimed returns the number of bytes freed by the maintenance run.
+func (r *MaintenanceReport) Reclaimed() int64 {
+	return max(r.SizeBefore-r.SizeAfter, 0)
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// EANField is the extra field holding the EAN of a product, products with equal EANs are matched across
+// targets regardless of their models.
+const EANField = "ean"
+
+// Offer is a current product of one of the targets of the tenant.
+type Offer struct {
+	Target  string
+	Product Product
+}
+
+// MatchModel returns the model the products of the targets are matched by: the lowercase letters and
+// digits of the model, so "GA-2100 1A" and "ga2100-1a" match.
+func MatchModel(model string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(model) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			builder.WriteRune(r)
+		}
+	}
+
+	return builder.String()
+}
+
+// EAN returns the digits of the EAN extra field of the product, empty if it has none.
+func (p Product) EAN() string {
+	value, _ := p.Extra(EANField)
+
+	return strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, value)
+}
+
+// CheapestOffer returns the offer with the lowest numeric price, the first one of equally cheap offers.
+// It returns false if no offer has a numeric price.
+func CheapestOffer(offers []Offer) (Offer, bool) {
+	var (
+		cheapest Offer
+		lowest   float64
+		found    bool
+	)
+	for _, offer := range offers {
+		price, err := offer.Product.PriceValue()
+		if err != nil || (found && price >= lowest) {
+			continue
+		}
+		cheapest, lowest, found = offer, price, true
+	}
+
+	return cheapest, found
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// OutboxEntry is a change set detected by a check and queued for delivery to the subscribers.
+type OutboxEntry struct {
+	ID         int64
+	RunID      string
+	DetectedAt time.Time
+	// Target is the name of the target the changes were detected on.
+	Target  string
+	Changes *Changes
+}
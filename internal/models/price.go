@@ -0,0 +1,62 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPrice is returned when a price string doesn't contain a number.
+var ErrInvalidPrice = errors.New("invalid price")
+
+const thousandsGroupLen = 3
+
+// ParsePrice converts a price as shown on the page (e.g. "1 199,50 грн", "$250.50", "1.199,50") into a number.
+func ParsePrice(raw string) (float64, error) {
+	var builder strings.Builder
+	for _, r := range raw {
+		if (r >= '0' && r <= '9') || r == '.' || r == ',' || r == '-' {
+			builder.WriteRune(r)
+		}
+	}
+	cleaned := builder.String()
+
+	// The rightmost of a dot and a comma is the decimal separator, the other one groups thousands.
+	lastDot, lastComma := strings.LastIndex(cleaned, "."), strings.LastIndex(cleaned, ",")
+	decimal, grouping, last := ".", ",", lastDot
+	if lastComma > lastDot {
+		decimal, grouping, last = ",", ".", lastComma
+	}
+
+	switch {
+	case strings.Contains(cleaned, grouping):
+		cleaned = strings.ReplaceAll(cleaned, grouping, "")
+	case last >= 0 && strings.Count(cleaned, decimal) > 1:
+		// A repeated lone separator groups thousands, e.g. "1.234.567".
+		cleaned = strings.ReplaceAll(cleaned, decimal, "")
+	case last >= 0 && len(cleaned)-last-1 == thousandsGroupLen && leadsThousands(cleaned[:last]):
+		// A lone separator followed by exactly three digits groups thousands, e.g. "1.199" but not "0.125".
+		cleaned = strings.ReplaceAll(cleaned, decimal, "")
+	}
+	cleaned = strings.Replace(cleaned, decimal, ".", 1)
+
+	value, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil {
+		return 0, ErrInvalidPrice
+	}
+
+	return value, nil
+}
+
+// leadsThousands reports whether the integer part of a price can lead a group of thousands: it's non-zero and
+// has no leading zero.
+func leadsThousands(integer string) bool {
+	integer = strings.TrimPrefix(integer, "-")
+
+	return integer != "" && integer[0] != '0'
+}
+
+// PriceValue returns the numeric value of the product price.
+func (p Product) PriceValue() (float64, error) {
+	return ParsePrice(p.Price)
+}
@@ -0,0 +1,52 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePrice(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		raw      string
+		expected float64
+	}{
+		{raw: "1,199.50", expected: 1199.5},
+		{raw: "1.199,50", expected: 1199.5},
+		{raw: "1 199", expected: 1199},
+		{raw: "1.199", expected: 1199},
+		{raw: "1,199", expected: 1199},
+		{raw: "-5", expected: -5},
+		{raw: "$250.50", expected: 250.5},
+		{raw: "1 199,50 грн", expected: 1199.5},
+		{raw: "1,234,567.89", expected: 1234567.89},
+		{raw: "1.234.567", expected: 1234567},
+		{raw: "12,5", expected: 12.5},
+		{raw: "0.125", expected: 0.125},
+		{raw: "0,500", expected: 0.5},
+		{raw: "-0.125", expected: -0.125},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.raw, func(t *testing.T) {
+			t.Parallel()
+
+			value, err := models.ParsePrice(tc.raw)
+
+			require.NoError(t, err)
+			assert.InDelta(t, tc.expected, value, 0.001)
+		})
+	}
+
+	t.Run("no number", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := models.ParsePrice("on request")
+
+		require.ErrorIs(t, err, models.ErrInvalidPrice)
+	})
+}
@@ -0,0 +1,9 @@
+package models
+
+// ForgottenData is a kind of data stored about a chat that was deleted on its request.
+type ForgottenData struct {
+	// Kind describes the data, e.g. "subscription" or "wishlist items".
+	Kind string
+	// Deleted is the number of records deleted.
+	Deleted int
+}
@@ -1,5 +1,11 @@
 package models
 
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
 // Product is a structure for storing data for one product from a table.
 type Product struct {
 	Model    string
@@ -7,4 +13,61 @@ type Product struct {
 	Quantity string
 	ImageURL string
 	Price    string
+	// ProductURL is the link to the product page, empty if the table has none.
+	ProductURL string
+	// Category is the name of the page table the product was parsed from, empty for a single unnamed table.
+	Category string
+	// Extras are the values of the extra fields configured for the tables by field name, e.g. a warranty
+	// or an EAN. Empty values are left out, so it's nil if the product has none.
+	Extras map[string]string
+}
+
+// Equal reports whether the products have the same fields.
+func (p Product) Equal(other Product) bool {
+	return p.Model == other.Model && p.Type == other.Type && p.Quantity == other.Quantity &&
+		p.ImageURL == other.ImageURL && p.Price == other.Price && p.ProductURL == other.ProductURL &&
+		p.Category == other.Category && maps.Equal(p.Extras, other.Extras)
+}
+
+// Extra returns the value of the extra field of the product by its name ignoring the case, false if it
+// has none.
+func (p Product) Extra(name string) (string, bool) {
+	for field, value := range p.Extras {
+		if strings.EqualFold(field, name) {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// Updated reports whether the product differs from an older listing of it in a field changes are
+// detected in: the price, the quantity or an extra field.
+func (p Product) Updated(old Product) bool {
+	return p.Price != old.Price || p.Quantity != old.Quantity || !maps.Equal(p.Extras, old.Extras)
+}
+
+// InStock reports whether the quantity of the product has a non-zero digit, e.g. "3" or ">10" but not
+// "0" or "".
+func (p Product) InStock() bool {
+	return strings.ContainsAny(p.Quantity, "123456789")
+}
+
+// ChangedExtras returns the names of the extra fields whose values differ between the old and the new
+// product, sorted.
+func (c ChangeInfo) ChangedExtras() []string {
+	var changed []string
+	for field := range c.Old.Extras {
+		if c.New.Extras[field] != c.Old.Extras[field] {
+			changed = append(changed, field)
+		}
+	}
+	for field := range c.New.Extras {
+		if _, ok := c.Old.Extras[field]; !ok {
+			changed = append(changed, field)
+		}
+	}
+	slices.Sort(changed)
+
+	return changed
 }
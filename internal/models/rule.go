@@ -0,0 +1,11 @@
+package models
+
+// AlertRule is a condition over a change and the price history of its product that alerts a chat when
+// a change matches it, see the rules package.
+type AlertRule struct {
+	// ChatID is the chat the rule alerts, zero for a rule of the deployment alerting every subscribed chat.
+	ChatID int64
+	Name   string
+	// Expression is the source of the condition, e.g. new.price < 0.8*avg(history, 30d).
+	Expression string
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// RunRecord fingerprints a successful check, so the content it fetched and the changes it detected can
+// be told apart from those of other runs.
+type RunRecord struct {
+	RunID     string
+	CheckedAt time.Time
+	// PageHash is the fingerprint of the fetched page.
+	PageHash string
+	// DiffHash is the fingerprint of the detected changes, see Changes.Fingerprint. It's empty if the
+	// run detected no changes.
+	DiffHash string
+	// Skipped is the number of products the state update failed to store, see State.Skipped.
+	Skipped int
+}
+
+// PageDiff is the unified diff between the pages fetched by two checks whose page hashes differ while no
+// product changed, it shows what churns on the page.
+type PageDiff struct {
+	RunID     string
+	CheckedAt time.Time
+	// OldHash and NewHash are the fingerprints of the previous and the current page.
+	OldHash string
+	NewHash string
+	Diff    string
+}
+
+// DiffArtifact is the rendering of the changes detected by a check in a download format, e.g. Markdown,
+// HTML or CSV, kept so the full diff can be downloaded whatever its size.
+type DiffArtifact struct {
+	RunID     string
+	Format    string
+	CreatedAt time.Time
+	Content   []byte
+}
@@ -0,0 +1,37 @@
+package models
+
+// ChatSettings holds the per-chat notification preferences.
+type ChatSettings struct {
+	// FilterGroup is the predefined filter group applied to notifications, empty means no filter.
+	FilterGroup string
+	// ThreadID is the forum topic notifications are sent to, zero means the General topic.
+	ThreadID int
+	// Silent are the change categories delivered without a notification sound.
+	Silent []ChangeCategory
+	// SustainedTrends leaves out price changes that aren't part of a sustained trend, see PriceTrend.
+	SustainedTrends bool
+	// Watermarks alerts the chat when a price hits a new low or high, see Watermark.
+	Watermarks bool
+	// WatermarkDays is the period the lows and highs are measured over, 0 for the whole history.
+	WatermarkDays int
+}
+
+// IsSilent reports whether a notification with the given categories should be sent silently,
+// which is the case only when every category in it is silenced by the chat.
+func (s ChatSettings) IsSilent(categories map[ChangeCategory]bool) bool {
+	if len(categories) == 0 || len(s.Silent) == 0 {
+		return false
+	}
+
+	silenced := make(map[ChangeCategory]bool, len(s.Silent))
+	for _, category := range s.Silent {
+		silenced[category] = true
+	}
+	for category := range categories {
+		if !silenced[category] {
+			return false
+		}
+	}
+
+	return true
+}
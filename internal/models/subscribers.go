@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// SubscriberDay describes the subscribers of a tenant on a day.
+type SubscriberDay struct {
+	// Day is the UTC midnight the day starts at.
+	Day time.Time
+	// Subscribers is the number of subscribed chats at the end of the day.
+	Subscribers  int
+	Subscribed   int
+	Unsubscribed int
+	// ActiveChats is the number of chats that used the bot on the day.
+	ActiveChats int
+}
+
+// Churn returns the share of the chats subscribed at the start of the days that unsubscribed during
+// them in percent, 0 if there were no subscribers.
+func Churn(days []SubscriberDay) float64 {
+	const percent = 100
+	if len(days) == 0 {
+		return 0
+	}
+
+	first := days[0]
+	start := first.Subscribers - first.Subscribed + first.Unsubscribed
+	if start <= 0 {
+		return 0
+	}
+
+	var unsubscribed int
+	for _, day := range days {
+		unsubscribed += day.Unsubscribed
+	}
+
+	return float64(unsubscribed) / float64(start) * percent
+}
+
+// UnreachableChat is a chat the messages of the bot fail to reach for a reason that won't go away by
+// itself, e.g. the bot was blocked or the chat was deleted.
+type UnreachableChat struct {
+	ChatID int64
+	// FailingSince is when the first of the failures in a row happened, LastFailure the last one.
+	FailingSince time.Time
+	LastFailure  time.Time
+	// Reason is the error of the last failure.
+	Reason string
+	// Deactivated is set once Telegram answered the account of the chat is deactivated.
+	Deactivated bool
+}
@@ -0,0 +1,477 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// MinTargetInterval is the shortest interval a target can be checked at.
+const MinTargetInterval = time.Minute
+
+// MainTarget is the name of the tenant's main page. It's imported from the configuration on first boot
+// and its data is stored in the scope of the tenant itself.
+const MainTarget = "main"
+
+// RegionSeparator joins the names of a target and of one of its regions into the name of the regional
+// target, e.g. "main@de". Target names can't contain it.
+const RegionSeparator = "@"
+
+var (
+	// ErrInvalidTargetName is returned for a target name that can't be used as a namespace.
+	ErrInvalidTargetName = errors.New("invalid target name: expected 1-32 lowercase letters, digits or dashes")
+	// ErrInvalidTargetURL is returned when the page of a target isn't an absolute HTTP(S) URL.
+	ErrInvalidTargetURL = errors.New("invalid target URL")
+	// ErrInvalidTargetInterval is returned for a check interval below MinTargetInterval.
+	ErrInvalidTargetInterval = errors.New("invalid target interval")
+	// ErrMainTarget is returned when removing or disabling the main target, which the tenant can't do without.
+	ErrMainTarget = errors.New("the main target can't be removed or disabled")
+	// ErrInvalidTargetHeaders is returned for a request header that can't be sent.
+	ErrInvalidTargetHeaders = errors.New("invalid target request headers")
+	// ErrInvalidTargetRegion is returned for a region without a valid name or with a repeated one.
+	ErrInvalidTargetRegion = errors.New("invalid target region")
+	// ErrInvalidTargetNetwork is returned for network options the page can't be fetched with.
+	ErrInvalidTargetNetwork = errors.New("invalid target network options")
+	// ErrInvalidTargetStep is returned for a fetch step that can't find the next URL.
+	ErrInvalidTargetStep = errors.New("invalid target fetch step")
+	// ErrInvalidTargetRequest is returned for a fetch request with an unsupported method or an invalid body.
+	ErrInvalidTargetRequest = errors.New("invalid target request")
+	// ErrInvalidTargetTLS is returned for a client certificate without its key or a key without its certificate.
+	ErrInvalidTargetTLS = errors.New("invalid target client certificate: expected both a certificate and a key")
+)
+
+// IP versions the connections to a target can be restricted to, see TargetNetwork.
+const (
+	IPv4 = "ipv4"
+	IPv6 = "ipv6"
+)
+
+// Target is a page monitored by a tenant. Products and changes of additional targets are stored apart
+// from the tenant's main page, notifications go to the subscribers of the tenant.
+type Target struct {
+	// Name identifies the target within the tenant, it follows the format of tenant IDs.
+	Name string
+	URL  string
+	// Tables are the product tables on the page, empty uses the parser default.
+	Tables []TargetTable
+	// ColumnSelectors map product fields to "selector@attr" expressions, empty recognizes
+	// the columns by the table header.
+	ColumnSelectors map[string]string
+	// ColumnSynonyms are additional header texts the product fields are recognized by.
+	ColumnSynonyms map[string][]string
+	// IframeSelector selects the iframe embedding the tables, empty parses the page itself.
+	IframeSelector string
+	// Steps lead from the page to the document with the tables, e.g. through a link with a rotating token.
+	Steps []TargetStep
+	// Request is the method and the body the page is requested with, e.g. to submit a form.
+	Request TargetRequest
+	// Assertions are the sanity checks of the fetched page, a page failing them is skipped.
+	Assertions PageAssertions
+	// Headers are sent with every request of the page, e.g. the Accept-Language of the prices to show.
+	Headers map[string]string
+	// Regions are the regional profiles the page is also checked with, see ForRegion.
+	Regions []TargetRegion
+	// Network are the options of the connections to the page, e.g. for a host with a broken IPv6 address.
+	Network TargetNetwork
+	// TLS is the client certificate of the page, for pages requiring mutual TLS.
+	TLS TargetTLS
+	// Interval is how often the target is checked.
+	Interval time.Duration
+	// Disabled targets are kept with their data but aren't checked.
+	Disabled  bool
+	CreatedAt time.Time
+}
+
+// TargetStatus is a target with the outcome of its last check.
+type TargetStatus struct {
+	Target
+	// LastCheck is the last fetch of the target page, its FetchedAt is zero if the target wasn't checked yet.
+	LastCheck FetchRecord
+	// LastError is the last failed check of the target, nil if none failed yet.
+	LastError *CheckError
+}
+
+// CheckError is the last failed check of a target, kept after the checks recover so the admins can tell
+// what failed last.
+type CheckError struct {
+	// Class is the kind of failure, e.g. "fetch" or "parse", see checker.ErrorClass.
+	Class   string
+	Message string
+	// FailingSince is when the first of the failed checks in a row happened, LastFailure the last one.
+	FailingSince time.Time
+	LastFailure  time.Time
+	// Failures is the number of checks failed in a row, zero once a check succeeded again.
+	Failures int
+}
+
+// Failing reports whether the last check of the target failed.
+func (e *CheckError) Failing() bool {
+	return e.Failures > 0
+}
+
+// TargetTable is a product table on the page of a target.
+type TargetTable struct {
+	// Name is the category of the products in the table, empty for a single unnamed table.
+	Name     string `json:"name"`
+	Selector string `json:"selector"`
+}
+
+// TargetRegion is a regional profile of a target, for pages serving prices by the region of the request:
+// the currency or the VAT may follow its Accept-Language or X-Forwarded-For header.
+type TargetRegion struct {
+	// Name identifies the region within the target, it follows the format of target names.
+	Name string `json:"name"`
+	// Headers are sent with the requests of the region, they replace the headers of the target.
+	Headers map[string]string `json:"headers"`
+}
+
+// TargetRequest is the request the page of a target is fetched with, for tables only reachable with a
+// form submission. The zero value is a GET request.
+type TargetRequest struct {
+	// Method is GET or POST, empty for GET.
+	Method string `json:"method,omitempty"`
+	// Form are the fields sent URL-encoded in the body.
+	Form map[string]string `json:"form,omitempty"`
+	// JSON is a JSON body, sent instead of the form.
+	JSON string `json:"json,omitempty"`
+	// Headers are sent with the request of the page only, on top of the headers of the target, e.g. the
+	// X-Requested-With of a form submitted by a script.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// IsZero reports whether the request is a plain GET.
+func (r TargetRequest) IsZero() bool {
+	return r.Method == "" && len(r.Form) == 0 && r.JSON == "" && len(r.Headers) == 0
+}
+
+// HTTPMethod returns the method of the request, GET if none is set.
+func (r TargetRequest) HTTPMethod() string {
+	if r.Method == "" {
+		return http.MethodGet
+	}
+
+	return strings.ToUpper(r.Method)
+}
+
+// Body renders the body of the request for the time of the fetch with its content type, empty if the
+// request has none. The form values and the JSON body are templates like the URL, see RenderTargetURL.
+func (r TargetRequest) Body(at time.Time) (string, string, error) {
+	if r.JSON != "" {
+		body, err := renderTimeTemplate("JSON body", r.JSON, at)
+		if err != nil {
+			return "", "", err
+		}
+		if !json.Valid([]byte(body)) {
+			return "", "", fmt.Errorf("%w: the JSON body isn't valid JSON", ErrInvalidTargetRequest)
+		}
+		return body, "application/json", nil
+	}
+	if len(r.Form) == 0 {
+		return "", "", nil
+	}
+
+	form := make(url.Values, len(r.Form))
+	for name, value := range r.Form {
+		rendered, err := renderTimeTemplate("form", value, at)
+		if err != nil {
+			return "", "", fmt.Errorf("field %s: %w", name, err)
+		}
+		form.Set(name, rendered)
+	}
+
+	return form.Encode(), "application/x-www-form-urlencoded", nil
+}
+
+// Validate checks that the method is GET or POST, that only a POST has a body, of a single kind, that
+// the body renders and that the headers are valid.
+func (r TargetRequest) Validate() error {
+	method := r.HTTPMethod()
+	switch {
+	case method != http.MethodGet && method != http.MethodPost:
+		return fmt.Errorf("%w: method %s, expected GET or POST", ErrInvalidTargetRequest, r.Method)
+	case len(r.Form) > 0 && r.JSON != "":
+		return fmt.Errorf("%w: expected either a form or a JSON body", ErrInvalidTargetRequest)
+	case method == http.MethodGet && (len(r.Form) > 0 || r.JSON != ""):
+		return fmt.Errorf("%w: a GET request has no body", ErrInvalidTargetRequest)
+	}
+	if _, _, err := r.Body(time.Now()); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTargetRequest, err)
+	}
+	if err := ValidateTargetHeaders(r.Headers); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTargetRequest, err)
+	}
+
+	return nil
+}
+
+// TargetStep is a step of the fetch flow of a target: it extracts a value from the current document and
+// requests the URL made of it. The steps start with the page and the tables are parsed from the document
+// of the last one.
+type TargetStep struct {
+	// Selector extracts the value as "selector@attr" or "selector" for the text, e.g. "a.pricelist@href".
+	Selector string `json:"selector,omitempty"`
+	// Regex extracts the value from the document source, its first group if it has one, e.g. `token=(\w+)`.
+	Regex string `json:"regex,omitempty"`
+	// URL is the template of the next URL with the value as the dot and the query function escaping it,
+	// e.g. "/prices?token={{query .}}". Empty requests the value itself. Relative URLs are resolved
+	// against the current document.
+	URL string `json:"url,omitempty"`
+}
+
+// NextURL returns the URL the step requests with the value it extracted.
+func (s TargetStep) NextURL(value string) (string, error) {
+	if s.URL == "" {
+		return value, nil
+	}
+
+	tmpl, err := template.New("step").Funcs(template.FuncMap{"query": url.QueryEscape}).Parse(s.URL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse step URL: %w", err)
+	}
+
+	var builder strings.Builder
+	if err = tmpl.Execute(&builder, value); err != nil {
+		return "", fmt.Errorf("failed to render step URL: %w", err)
+	}
+
+	return builder.String(), nil
+}
+
+// ValidateTargetSteps checks that every step extracts its value in exactly one way, with a regex that
+// compiles, and has a URL template that renders.
+func ValidateTargetSteps(steps []TargetStep) error {
+	for idx, step := range steps {
+		if (step.Selector == "") == (step.Regex == "") {
+			return fmt.Errorf("%w %d: expected either a selector or a regex", ErrInvalidTargetStep, idx+1)
+		}
+		if step.Regex != "" {
+			if _, err := regexp.Compile(step.Regex); err != nil {
+				return fmt.Errorf("%w %d: %w", ErrInvalidTargetStep, idx+1, err)
+			}
+		}
+		if _, err := step.NextURL("value"); err != nil {
+			return fmt.Errorf("%w %d: %w", ErrInvalidTargetStep, idx+1, err)
+		}
+	}
+
+	return nil
+}
+
+// TargetNetwork are the options of the connections to the page of a target. The zero value connects like
+// any client does.
+type TargetNetwork struct {
+	// IPVersion restricts the connections to IPv4 or IPv6, empty allows both.
+	IPVersion string `json:"ip_version,omitempty"`
+	// Hosts map host names to the IP addresses they are connected to instead of resolving them, like
+	// /etc/hosts does. The requests still name the host, e.g. for the TLS certificate.
+	Hosts map[string]string `json:"hosts,omitempty"`
+	// Resolver is the DNS server the hosts are resolved with, as "ip" or "ip:port", empty uses the system one.
+	Resolver string `json:"resolver,omitempty"`
+}
+
+// IsZero reports whether no option is set.
+func (n TargetNetwork) IsZero() bool {
+	return n.IPVersion == "" && len(n.Hosts) == 0 && n.Resolver == ""
+}
+
+// ResolverAddress returns the address of the DNS server with the default port if it has none, empty if
+// no resolver is set.
+func (n TargetNetwork) ResolverAddress() string {
+	if n.Resolver == "" || net.ParseIP(n.Resolver) == nil {
+		return n.Resolver
+	}
+
+	return net.JoinHostPort(n.Resolver, "53")
+}
+
+// Validate checks that the IP version is known, that the hosts map to addresses of that version and that
+// the resolver is an IP address, as resolving it would need a resolver itself.
+func (n TargetNetwork) Validate() error {
+	if n.IPVersion != "" && n.IPVersion != IPv4 && n.IPVersion != IPv6 {
+		return fmt.Errorf("%w: IP version %q, expected %s or %s", ErrInvalidTargetNetwork, n.IPVersion, IPv4, IPv6)
+	}
+	for host, addr := range n.Hosts {
+		ip := net.ParseIP(addr)
+		switch {
+		case host == "" || strings.ContainsAny(host, " :/"):
+			return fmt.Errorf("%w: host %q", ErrInvalidTargetNetwork, host)
+		case ip == nil:
+			return fmt.Errorf("%w: address %q of %s", ErrInvalidTargetNetwork, addr, host)
+		case n.IPVersion == IPv4 && ip.To4() == nil, n.IPVersion == IPv6 && ip.To4() != nil:
+			return fmt.Errorf("%w: address %q of %s isn't %s", ErrInvalidTargetNetwork, addr, host, n.IPVersion)
+		}
+	}
+	if n.Resolver != "" {
+		host, _, err := net.SplitHostPort(n.ResolverAddress())
+		if err != nil || net.ParseIP(host) == nil {
+			return fmt.Errorf("%w: resolver %q, expected an IP address", ErrInvalidTargetNetwork, n.Resolver)
+		}
+	}
+
+	return nil
+}
+
+// TargetTLS is the client certificate the page of a target is fetched with. The certificate and the key
+// are either PEM encoded or the paths of the PEM files, which are read on every TLS handshake, so renewed
+// files are picked up.
+type TargetTLS struct {
+	Cert string `json:"cert,omitempty"`
+	Key  string `json:"key,omitempty"`
+}
+
+// IsZero reports whether no client certificate is set.
+func (t TargetTLS) IsZero() bool {
+	return t.Cert == "" && t.Key == ""
+}
+
+// Validate checks that the certificate and the key are set together.
+func (t TargetTLS) Validate() error {
+	if (t.Cert == "") != (t.Key == "") {
+		return ErrInvalidTargetTLS
+	}
+
+	return nil
+}
+
+// Validate checks that the target has a valid name, an absolute HTTP(S) URL, a sane interval, regions
+// that can be told apart, a valid request, fetch steps, page assertions and network options and a
+// complete client certificate.
+func (t Target) Validate() error {
+	if err := ValidateTargetName(t.Name); err != nil {
+		return err
+	}
+	if err := ValidateTargetURL(t.URL); err != nil {
+		return err
+	}
+	if t.Interval < MinTargetInterval {
+		return fmt.Errorf("%w: %s is below %s", ErrInvalidTargetInterval, t.Interval, MinTargetInterval)
+	}
+	if err := ValidateTargetHeaders(t.Headers); err != nil {
+		return err
+	}
+	if err := ValidateTargetRegions(t.Regions); err != nil {
+		return err
+	}
+	if err := t.Request.Validate(); err != nil {
+		return err
+	}
+	if err := ValidateTargetSteps(t.Steps); err != nil {
+		return err
+	}
+	if err := t.Assertions.Validate(); err != nil {
+		return err
+	}
+	if err := t.Network.Validate(); err != nil {
+		return err
+	}
+
+	return t.TLS.Validate()
+}
+
+// ForRegion returns the target checked with the profile of the region: it's named after the target and
+// the region, so its products and changes are stored apart, and it sends the headers of the target
+// with the ones of the region replacing them.
+func (t Target) ForRegion(region TargetRegion) Target {
+	regional := t
+	regional.Name = t.Name + RegionSeparator + region.Name
+	regional.Headers = make(map[string]string, len(t.Headers)+len(region.Headers))
+	maps.Copy(regional.Headers, t.Headers)
+	maps.Copy(regional.Headers, region.Headers)
+	regional.Regions = nil
+
+	return regional
+}
+
+// ValidateTargetRegions checks that the regions have valid and distinct names and headers that can be sent.
+func ValidateTargetRegions(regions []TargetRegion) error {
+	seen := make(map[string]bool, len(regions))
+	for _, region := range regions {
+		if !tenantIDRe.MatchString(region.Name) || seen[region.Name] {
+			return fmt.Errorf("%w: %q", ErrInvalidTargetRegion, region.Name)
+		}
+		seen[region.Name] = true
+		if err := ValidateTargetHeaders(region.Headers); err != nil {
+			return fmt.Errorf("region %s: %w", region.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateTargetHeaders checks that the request headers have names and values that can be sent.
+func ValidateTargetHeaders(headers map[string]string) error {
+	for name, value := range headers {
+		if name == "" || strings.ContainsAny(name, " :\t\r\n") || strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("%w: %q", ErrInvalidTargetHeaders, name)
+		}
+	}
+
+	return nil
+}
+
+// ValidateTargetName checks that the name can identify a target.
+func ValidateTargetName(name string) error {
+	if !tenantIDRe.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidTargetName, name)
+	}
+
+	return nil
+}
+
+// ValidateTargetURL checks that the URL is an absolute HTTP(S) URL, a URL template must render to one.
+func ValidateTargetURL(raw string) error {
+	rendered, err := RenderTargetURL(raw, time.Now())
+	if err != nil {
+		return fmt.Errorf("%w: %q: %w", ErrInvalidTargetURL, raw, err)
+	}
+	parsed, err := url.Parse(rendered)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return fmt.Errorf("%w: %q", ErrInvalidTargetURL, raw)
+	}
+
+	return nil
+}
+
+// IsTargetURLTemplate reports whether the URL of a target is a template, see RenderTargetURL.
+func IsTargetURLTemplate(raw string) bool {
+	return strings.Contains(raw, "{{")
+}
+
+// RenderTargetURL renders the URL of a target for the time of the fetch. The URL may be a text/template
+// for pages named after the date, e.g. ".../pricelist-{{now.Format \"2006-01-02\"}}.html", with the
+// functions now (the time), weekday (its English name, e.g. Monday) and lower. Other URLs are returned
+// as they are.
+func RenderTargetURL(raw string, at time.Time) (string, error) {
+	if !IsTargetURLTemplate(raw) {
+		return raw, nil
+	}
+
+	return renderTimeTemplate("URL", raw, at)
+}
+
+// renderTimeTemplate renders a template of the fetch of a target for the time, see RenderTargetURL.
+func renderTimeTemplate(name, raw string, at time.Time) (string, error) {
+	tmpl, err := template.New(name).Funcs(template.FuncMap{
+		"now":     func() time.Time { return at },
+		"weekday": func() string { return at.Weekday().String() },
+		"lower":   strings.ToLower,
+	}).Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+
+	var builder strings.Builder
+	if err = tmpl.Execute(&builder, nil); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+
+	return builder.String(), nil
+}
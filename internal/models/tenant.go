@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+var (
+	// ErrInvalidTenantID is returned for a tenant ID that can't be used as a namespace.
+	ErrInvalidTenantID = errors.New("invalid tenant ID: expected 1-32 lowercase letters, digits or dashes")
+	// ErrInvalidTenantURL is returned when the target page of a tenant isn't an absolute URL.
+	ErrInvalidTenantURL = errors.New("invalid tenant URL")
+	// ErrEmptyTenantToken is returned when a tenant has no bot token.
+	ErrEmptyTenantToken = errors.New("empty tenant bot token")
+)
+
+// tenantIDRe matches the IDs tenants can be created with. The ID is also used in event subjects,
+// so it's restricted to characters that are safe there.
+var tenantIDRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// Tenant is an isolated namespace with its own target page, bot and subscribers.
+// The default tenant configured from the environment has an empty ID and isn't stored.
+type Tenant struct {
+	ID   string
+	Name string
+	// URL is the target page the tenant tracks.
+	URL string
+	// Token is the token of the Telegram bot the tenant notifies through.
+	Token string
+	// AllowedIDs are chats allowed to use the tenant's bot.
+	AllowedIDs []int64
+	// AdminIDs are chats allowed to run administrative commands of the tenant's bot.
+	AdminIDs []int64
+	// MaintenanceWindows are the maintenance windows of the target in the CF_MAINTENANCE_WINDOWS
+	// format, empty uses the windows of the default tenant.
+	MaintenanceWindows string
+	CreatedAt          time.Time
+}
+
+// Validate checks that the tenant has a valid ID, an absolute target URL and a bot token.
+func (t Tenant) Validate() error {
+	if !tenantIDRe.MatchString(t.ID) {
+		return fmt.Errorf("%w: %q", ErrInvalidTenantID, t.ID)
+	}
+	if parsed, err := url.Parse(t.URL); err != nil || !parsed.IsAbs() || parsed.Host == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidTenantURL, t.URL)
+	}
+	if t.Token == "" {
+		return ErrEmptyTenantToken
+	}
+
+	return nil
+}
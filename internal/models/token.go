@@ -0,0 +1,32 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// ScopeAdmin grants every scope of the API.
+const ScopeAdmin = "admin"
+
+// APIToken is a bearer token granting access to the API. Only the hash of the token is stored.
+type APIToken struct {
+	ID     int64
+	Name   string
+	Scopes []string
+	// CreatedAt is the time the token was issued.
+	CreatedAt time.Time
+	// RevokedAt is the time the token was revoked, zero while the token is active.
+	RevokedAt time.Time
+	// ChatID is the chat whose settings filter the feeds read with the token, zero for none.
+	ChatID int64
+}
+
+// HasScope reports whether the token grants the scope, the admin scope grants every scope.
+func (t APIToken) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope) || slices.Contains(t.Scopes, ScopeAdmin)
+}
+
+// Revoked reports whether the token was revoked.
+func (t APIToken) Revoked() bool {
+	return !t.RevokedAt.IsZero()
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// day is the unit the period of a price trend is measured in.
+const day = 24 * time.Hour
+
+// SustainedStreak is the number of consecutive price changes in the same direction that make a trend
+// sustained rather than a single-step jiggle.
+const SustainedStreak = 2
+
+// PriceTrend describes where the price of a product has been heading.
+type PriceTrend struct {
+	// Direction is 1 for a rising price, -1 for a falling one and 0 if the price never changed.
+	Direction int
+	// Streak is the number of consecutive price changes in the Direction, the latest one included.
+	Streak int
+	// Percent is the change of the price over the Days before the latest price.
+	Percent float64
+	// Days is the period Percent is measured over, up to the window the trend was computed for.
+	Days int
+}
+
+// IsSustained reports whether the price has moved in the same direction at least SustainedStreak times.
+func (t PriceTrend) IsSustained() bool {
+	return t.Direction != 0 && t.Streak >= SustainedStreak
+}
+
+// pricePoint is a price a product had since the time it was detected.
+type pricePoint struct {
+	at    time.Time
+	price float64
+}
+
+// NewPriceTrend computes the trend of a product price from its history, oldest first, and its latest
+// change detected now. The history may or may not include the latest change already. Prices that aren't
+// numbers are skipped.
+func NewPriceTrend(history []ChangeRecord, change ChangeInfo, now time.Time, window time.Duration) PriceTrend {
+	points := pricePoints(history, change, now)
+	if len(points) == 0 {
+		return PriceTrend{}
+	}
+	price := points[len(points)-1].price
+
+	const percent = 100
+
+	trend := PriceTrend{}
+	for idx := len(points) - 1; idx > 0; idx-- {
+		direction := sign(points[idx].price - points[idx-1].price)
+		if trend.Direction != 0 && direction != trend.Direction {
+			break
+		}
+		trend.Direction = direction
+		trend.Streak++
+	}
+
+	// The reference is the price at the start of the window, or the oldest price if it's newer.
+	start := now.Add(-window)
+	reference := points[0]
+	for _, point := range points[1:] {
+		if point.at.After(start) {
+			break
+		}
+		reference = point
+	}
+	if reference.price != 0 {
+		trend.Percent = (price - reference.price) / reference.price * percent
+	}
+	if reference.at.Before(start) {
+		reference.at = start
+	}
+	trend.Days = max(int(math.Round(float64(now.Sub(reference.at))/float64(day))), 1)
+
+	return trend
+}
+
+// pricePoints returns the prices of a product from its history, oldest first, and its latest change
+// detected now. A price is only kept when it differs from the one before, and prices that aren't numbers
+// are skipped.
+func pricePoints(history []ChangeRecord, change ChangeInfo, now time.Time) []pricePoint {
+	var points []pricePoint
+	add := func(at time.Time, raw string) {
+		value, err := ParsePrice(raw)
+		if err != nil || (len(points) > 0 && points[len(points)-1].price == value) {
+			return
+		}
+		points = append(points, pricePoint{at: at, price: value})
+	}
+	for _, record := range append(slices.Clip(history), changeRecord(now, KindChanged, change)) {
+		if record.Kind == KindRemoved {
+			continue
+		}
+		// The price before the oldest kept change is the start of the history.
+		if len(points) == 0 && record.Kind != KindAdded {
+			add(record.DetectedAt, record.OldPrice)
+		}
+		add(record.DetectedAt, record.Price)
+	}
+
+	return points
+}
+
+// EarlierPrices returns the prices a product had before its latest change detected now, since the start,
+// oldest first. The price in effect at the start counts as one of them, and the current price too if the
+// change didn't change it. The history may or may not include the latest change already, prices that
+// aren't numbers are skipped.
+func EarlierPrices(history []ChangeRecord, change ChangeInfo, now, start time.Time) []float64 {
+	points := earlierPoints(history, change, now, start)
+	prices := make([]float64, 0, len(points))
+	for _, point := range points {
+		prices = append(prices, point.price)
+	}
+
+	return prices
+}
+
+// earlierPoints returns the prices of EarlierPrices with the time they were detected.
+func earlierPoints(history []ChangeRecord, change ChangeInfo, now, start time.Time) []pricePoint {
+	points := pricePoints(history, change, now)
+	// The last price is the one after the change, unless the change didn't change it or it isn't a number.
+	price, err := ParsePrice(change.New.Price)
+	if oldPrice, oldErr := ParsePrice(change.Old.Price); err == nil && (oldErr != nil || oldPrice != price) {
+		points = points[:len(points)-1]
+	}
+
+	first := 0
+	for idx, point := range points {
+		if point.at.After(start) {
+			break
+		}
+		first = idx
+	}
+
+	return points[first:]
+}
+
+// sign returns 1 for a positive difference, -1 for a negative one and 0 otherwise.
+func sign(diff float64) int {
+	switch {
+	case diff > 0:
+		return 1
+	case diff < 0:
+		return -1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// FetchRecord is the outcome of a single fetch of the target page.
+type FetchRecord struct {
+	FetchedAt time.Time
+	Latency   time.Duration
+	Success   bool
+	// Error is the failure reason, empty for successful fetches.
+	Error string
+}
+
+// UptimeStats aggregates fetch records over a period.
+type UptimeStats struct {
+	Fetches    int
+	Successful int
+	AvgLatency time.Duration
+	// LastSuccess is the time of the last successful fetch, zero if there was none.
+	LastSuccess time.Time
+}
+
+// Uptime returns the share of successful fetches in percent, 0 if there were no fetches.
+func (s *UptimeStats) Uptime() float64 {
+	const percent = 100
+	if s.Fetches == 0 {
+		return 0
+	}
+
+	return float64(s.Successful) / float64(s.Fetches) * percent
+}
@@ -0,0 +1,11 @@
+package models
+
+// UserSubscription is a member of a group chat who also gets the changes of some categories as direct
+// messages, while the group keeps getting its own notifications.
+type UserSubscription struct {
+	UserID int64
+	// ChatID is the group the user subscribed in. The direct messages follow its filter group and stop
+	// once it may no longer use the bot.
+	ChatID     int64
+	Categories []ChangeCategory
+}
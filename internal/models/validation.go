@@ -0,0 +1,137 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrEmptyModel is returned when a product row has no model.
+	ErrEmptyModel = errors.New("empty model")
+	// ErrInvalidImageURL is returned when a product image URL isn't a well-formed URL.
+	ErrInvalidImageURL = errors.New("invalid image URL")
+	// ErrInvalidProductURL is returned when a product link isn't a well-formed URL.
+	ErrInvalidProductURL = errors.New("invalid product URL")
+	// ErrPageAssertionFailed is returned for a fetched page failing the assertions of its target, the page
+	// can't be trusted, e.g. it's a maintenance page, so the check is skipped rather than failed.
+	ErrPageAssertionFailed = errors.New("page assertion failed")
+	// ErrInvalidPageAssertions is returned for assertions with an empty marker or a negative row count.
+	ErrInvalidPageAssertions = errors.New("invalid page assertions")
+)
+
+// Validate checks that the product was parsed from a well-formed row: it has a model,
+// a numeric price and, if present, well-formed image and product URLs.
+func (p Product) Validate() error {
+	if p.Model == "" {
+		return ErrEmptyModel
+	}
+	if _, err := p.PriceValue(); err != nil {
+		return fmt.Errorf("%w: %q", err, p.Price)
+	}
+	if !isWellFormedURL(p.ImageURL) {
+		return fmt.Errorf("%w: %q", ErrInvalidImageURL, p.ImageURL)
+	}
+	if !isWellFormedURL(p.ProductURL) {
+		return fmt.Errorf("%w: %q", ErrInvalidProductURL, p.ProductURL)
+	}
+
+	return nil
+}
+
+// isWellFormedURL reports whether raw is empty or can be used as a link.
+func isWellFormedURL(raw string) bool {
+	if raw == "" {
+		return true
+	}
+	_, err := url.Parse(raw)
+
+	return err == nil && !strings.ContainsAny(raw, " \t\n")
+}
+
+// ParseReport summarizes the quality of the rows parsed from the page.
+type ParseReport struct {
+	Valid   int
+	Invalid int
+
+	// Duplicates is the number of valid rows repeating a model already listed in the same table.
+	Duplicates int
+}
+
+// Total returns the number of parsed rows.
+func (r ParseReport) Total() int {
+	return r.Valid + r.Invalid
+}
+
+// InvalidRatio returns the share of invalid rows (0..1), zero if nothing was parsed.
+func (r ParseReport) InvalidRatio() float64 {
+	if r.Total() == 0 {
+		return 0
+	}
+
+	return float64(r.Invalid) / float64(r.Total())
+}
+
+// PageAssertions are the sanity checks of a fetched page before its products are compared with the
+// stored ones, so an error page served with 200 OK isn't taken for an emptied catalog. The markers are
+// matched case-insensitively against the page as it's fetched.
+type PageAssertions struct {
+	// Contains are the markers the page must contain, e.g. the title of the price list.
+	Contains []string `json:"contains,omitempty"`
+	// NotContains are the markers the page must not contain, e.g. "maintenance mode".
+	NotContains []string `json:"not_contains,omitempty"`
+	// MinRows is the minimal number of table rows parsed from the page, valid or not.
+	MinRows int `json:"min_rows,omitempty"`
+}
+
+// IsZero reports whether there are no assertions.
+func (a PageAssertions) IsZero() bool {
+	return len(a.Contains) == 0 && len(a.NotContains) == 0 && a.MinRows == 0
+}
+
+// Validate checks that the markers aren't empty and the row count isn't negative.
+func (a PageAssertions) Validate() error {
+	if a.MinRows < 0 {
+		return fmt.Errorf("%w: min_rows %d is negative", ErrInvalidPageAssertions, a.MinRows)
+	}
+	for _, marker := range append(slices.Clip(a.Contains), a.NotContains...) {
+		if strings.TrimSpace(marker) == "" {
+			return fmt.Errorf("%w: empty marker", ErrInvalidPageAssertions)
+		}
+	}
+
+	return nil
+}
+
+// CheckContent checks the markers against the page.
+func (a PageAssertions) CheckContent(page []byte) error {
+	if len(a.Contains) == 0 && len(a.NotContains) == 0 {
+		return nil
+	}
+
+	page = bytes.ToLower(page)
+	for _, marker := range a.Contains {
+		if !bytes.Contains(page, []byte(strings.ToLower(marker))) {
+			return fmt.Errorf("%w: the page doesn't contain %q", ErrPageAssertionFailed, marker)
+		}
+	}
+	for _, marker := range a.NotContains {
+		if bytes.Contains(page, []byte(strings.ToLower(marker))) {
+			return fmt.Errorf("%w: the page contains %q", ErrPageAssertionFailed, marker)
+		}
+	}
+
+	return nil
+}
+
+// CheckRows checks the number of table rows parsed from the page.
+func (a PageAssertions) CheckRows(rows int) error {
+	if rows < a.MinRows {
+		return fmt.Errorf("%w: %d table rows, expected at least %d", ErrPageAssertionFailed, rows, a.MinRows)
+	}
+
+	return nil
+}
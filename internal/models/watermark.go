@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// MinWatermarkPrices is the number of earlier prices a price has to break to be a watermark. A product
+// whose price changed once has no history to speak of, any change would be a new low or high.
+const MinWatermarkPrices = 2
+
+// Watermark is a price of a product lower or higher than every price it had before in a period.
+type Watermark struct {
+	// Direction is -1 for a new low and 1 for a new high.
+	Direction int
+	// Previous is the lowest or the highest of the earlier prices, the one the price broke.
+	Previous float64
+	// Days is the period of the earlier prices, 0 for the whole history.
+	Days int
+}
+
+// NewWatermark finds out whether the latest change of a product detected now took its price to a new low
+// or high, comparing it with the prices of its history, oldest first, over the last days, the whole history
+// if days is 0. The price in effect at the start of the period counts as one of its prices. The history may
+// or may not include the latest change already.
+func NewWatermark(history []ChangeRecord, change ChangeInfo, now time.Time, days int) (Watermark, bool) {
+	price, err := ParsePrice(change.New.Price)
+	if err != nil || change.Old.Price == change.New.Price {
+		return Watermark{}, false
+	}
+	var start time.Time
+	if days > 0 {
+		start = now.Add(-time.Duration(days) * day)
+	}
+	earlier := earlierPoints(history, change, now, start)
+	if len(earlier) < MinWatermarkPrices {
+		return Watermark{}, false
+	}
+
+	lowest, highest := earlier[0].price, earlier[0].price
+	for _, point := range earlier[1:] {
+		lowest = min(lowest, point.price)
+		highest = max(highest, point.price)
+	}
+	switch {
+	case price < lowest:
+		return Watermark{Direction: -1, Previous: lowest, Days: days}, true
+	case price > highest:
+		return Watermark{Direction: 1, Previous: highest, Days: days}, true
+	default:
+		return Watermark{}, false
+	}
+}
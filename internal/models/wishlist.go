@@ -0,0 +1,41 @@
+package models
+
+// WishlistItem is a product model on a wishlist with its current price.
+type WishlistItem struct {
+	Model string
+	// Listed is set if a product with the model is currently on the page.
+	Listed bool
+	// Price is the lowest current price of the products with the model, nil if none has a numeric price.
+	Price *float64
+}
+
+// Wishlist is the list of product models a chat wants to buy together for the budget.
+type Wishlist struct {
+	ChatID int64
+	// Budget is the total price the chat waits for, zero means no budget is set.
+	Budget float64
+	Items  []WishlistItem
+	// Notified is set once the chat was told the total is within the budget, it's told again only after
+	// the total exceeded the budget in between.
+	Notified bool
+}
+
+// Total returns the sum of the current prices of the items, false if an item has no current price.
+func (w Wishlist) Total() (float64, bool) {
+	var total float64
+	for _, item := range w.Items {
+		if item.Price == nil {
+			return 0, false
+		}
+		total += *item.Price
+	}
+
+	return total, true
+}
+
+// WithinBudget reports whether every item has a price and their total doesn't exceed the budget.
+func (w Wishlist) WithinBudget() bool {
+	total, ok := w.Total()
+
+	return ok && len(w.Items) > 0 && w.Budget > 0 && total <= w.Budget
+}
@@ -0,0 +1,150 @@
+// Package normalize removes the markup churn of the fetched pages that doesn't change their products, e.g.
+// inline scripts with session tokens or attributes rendered in a random order, before the pages are hashed
+// and parsed, so the hash of an unchanged catalog stays the same.
+package normalize
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// ErrUnknownStep is returned for a normalization step that doesn't exist.
+var ErrUnknownStep = errors.New("unknown normalization step")
+
+// Steps of the normalization, they run in this order after the elements to remove were removed.
+const (
+	// StepScripts removes the script and noscript elements.
+	StepScripts = "scripts"
+	// StepStyles removes the style elements and the style attributes.
+	StepStyles = "styles"
+	// StepWhitespace collapses the runs of whitespace of the texts to a single space, except in pre and
+	// textarea elements.
+	StepWhitespace = "whitespace"
+	// StepAttributes sorts the attributes of every element by name.
+	StepAttributes = "attributes"
+)
+
+// Steps returns the normalization steps in the order they run.
+func Steps() []string {
+	return []string{StepScripts, StepStyles, StepWhitespace, StepAttributes}
+}
+
+// Normalizer rewrites the fetched pages with the configured steps.
+type Normalizer struct {
+	steps map[string]bool
+	// remove matches the elements removed from the pages, nil removes none.
+	remove cascadia.Selector
+}
+
+// New returns the normalizer running the steps, see Steps, and removing the elements matching the CSS
+// selector first, e.g. ".banner, #last-updated". An empty selector removes no elements. It returns nil
+// if there are no steps and no selector, as the pages are then used as they are fetched.
+func New(steps []string, remove string) (*Normalizer, error) {
+	normalizer := &Normalizer{steps: make(map[string]bool, len(steps))}
+	for _, step := range steps {
+		if !slices.Contains(Steps(), step) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownStep, step)
+		}
+		normalizer.steps[step] = true
+	}
+
+	if remove = strings.TrimSpace(remove); remove != "" {
+		selector, err := cascadia.Compile(remove)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector of the elements to remove %q: %w", remove, err)
+		}
+		normalizer.remove = selector
+	}
+
+	if len(normalizer.steps) == 0 && normalizer.remove == nil {
+		return nil, nil //nolint:nilnil // no normalization is needed.
+	}
+
+	return normalizer, nil
+}
+
+// Normalize returns the page with the elements to remove removed and the steps applied. The page is
+// rendered again, so it differs from the fetched one even if no step changed it.
+func (n *Normalizer) Normalize(page []byte) ([]byte, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the page to normalize: %w", err)
+	}
+
+	if n.remove != nil {
+		doc.FindMatcher(n.remove).Remove()
+	}
+	if n.steps[StepScripts] {
+		doc.Find("script, noscript").Remove()
+	}
+	if n.steps[StepStyles] {
+		doc.Find("style").Remove()
+		doc.Find("[style]").RemoveAttr("style")
+	}
+	for _, node := range doc.Nodes {
+		n.walk(node, false)
+	}
+
+	var buf bytes.Buffer
+	for _, node := range doc.Nodes {
+		if err = html.Render(&buf, node); err != nil {
+			return nil, fmt.Errorf("failed to render the normalized page: %w", err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// walk applies the steps rewriting the nodes to the node and its descendants. Preformatted tells whether
+// the node is in a pre or a textarea element, whose whitespace is kept.
+func (n *Normalizer) walk(node *html.Node, preformatted bool) {
+	switch node.Type {
+	case html.TextNode:
+		if n.steps[StepWhitespace] && !preformatted {
+			node.Data = collapseWhitespace(node.Data)
+		}
+	case html.ElementNode:
+		if n.steps[StepAttributes] {
+			slices.SortStableFunc(node.Attr, func(a, b html.Attribute) int {
+				return strings.Compare(a.Key, b.Key)
+			})
+		}
+		preformatted = preformatted || node.Data == "pre" || node.Data == "textarea"
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		n.walk(child, preformatted)
+	}
+}
+
+// collapseWhitespace replaces every run of HTML whitespace with a single space. Non-breaking spaces aren't
+// HTML whitespace, e.g. the ones grouping the digits of prices, and are kept.
+func collapseWhitespace(text string) string {
+	var builder strings.Builder
+	builder.Grow(len(text))
+
+	space := false
+	for _, char := range text {
+		if strings.ContainsRune(" \t\n\f\r", char) {
+			space = true
+			continue
+		}
+		if space {
+			builder.WriteByte(' ')
+			space = false
+		}
+		builder.WriteRune(char)
+	}
+	if space {
+		builder.WriteByte(' ')
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,71 @@
+package normalize_test
+
+import (
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/normalize"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	normalizer, err := normalize.New(nil, " ")
+	require.NoError(t, err)
+	assert.Nil(t, normalizer, "nothing to normalize")
+
+	_, err = normalize.New([]string{normalize.StepScripts, "comments"}, "")
+	require.ErrorIs(t, err, normalize.ErrUnknownStep)
+
+	_, err = normalize.New(nil, "div[")
+	require.ErrorContains(t, err, "invalid selector of the elements to remove")
+}
+
+func TestNormalizer_Normalize(t *testing.T) {
+	t.Parallel()
+
+	const page = `<html><head><script>var token = "abc";</script><style>td { color: red }</style></head>` +
+		`<body><div class="banner">Sale ends in 5 minutes</div>` +
+		`<table id="products" class="table-bordered"><tr><td style="width: 10px">  A1
+			</td><td>1` + "\u00a0" + `000</td></tr></table>` +
+		`<noscript>Enable JavaScript</noscript><pre>  kept  </pre><span id="ts">12:00</span></body></html>`
+
+	tests := []struct {
+		name   string
+		steps  []string
+		remove string
+		want   string
+	}{
+		{
+			name:  "scripts",
+			steps: []string{normalize.StepScripts},
+			want: `<html><head><style>td { color: red }</style></head>` +
+				`<body><div class="banner">Sale ends in 5 minutes</div>` +
+				`<table id="products" class="table-bordered"><tbody><tr><td style="width: 10px">  A1
+			</td><td>1` + "\u00a0" + `000</td></tr></tbody></table>` +
+				`<pre>  kept  </pre><span id="ts">12:00</span></body></html>`,
+		},
+		{
+			name:   "every step and removed elements",
+			steps:  normalize.Steps(),
+			remove: ".banner, #ts",
+			want: `<html><head></head><body>` +
+				`<table class="table-bordered" id="products"><tbody><tr><td> A1 </td><td>1` + "\u00a0" +
+				`000</td></tr></tbody></table><pre>  kept  </pre></body></html>`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			normalizer, err := normalize.New(test.steps, test.remove)
+			require.NoError(t, err)
+
+			normalized, err := normalizer.Normalize([]byte(page))
+
+			require.NoError(t, err)
+			assert.Equal(t, test.want, string(normalized))
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Product fields that are mapped to table columns.
+const (
+	FieldModel    = "model"
+	FieldType     = "type"
+	FieldQuantity = "quantity"
+	FieldImage    = "image"
+	FieldPrice    = "price"
+	FieldURL      = "url"
+)
+
+// Fields returns all product fields that are mapped to table columns.
+func Fields() []string {
+	return []string{FieldModel, FieldType, FieldQuantity, FieldImage, FieldPrice, FieldURL}
+}
+
+// defaultColumnSynonyms returns the header texts every field is recognized by out of the box.
+func defaultColumnSynonyms() map[string][]string {
+	return map[string][]string{
+		FieldModel:    {"Model", "Модель"},
+		FieldType:     {"Type", "Тип"},
+		FieldQuantity: {"Quantity", "Qty", "Кількість"},
+		FieldImage:    {"Image", "Photo", "Фото", "Зображення"},
+		FieldPrice:    {"Price", "Ціна"},
+		FieldURL:      {"Link", "URL", "Посилання"},
+	}
+}
+
+// columnMap holds the cell index of every field found in a table.
+type columnMap map[string]int
+
+// indexColumns returns the fixed column layout used for tables without a recognizable header.
+func indexColumns() columnMap {
+	return columnMap{FieldModel: 0, FieldType: 1, FieldQuantity: 2, FieldImage: 3, FieldPrice: 4}
+}
+
+// width returns the minimal number of cells a row needs to contain all mapped columns.
+func (c columnMap) width() int {
+	var width int
+	for _, idx := range c {
+		width = max(width, idx+1)
+	}
+
+	return width
+}
+
+// cell returns the trimmed text of the field's cell, or an empty string if the table has no such column.
+func (c columnMap) cell(cells *goquery.Selection, field string) string {
+	idx, ok := c[field]
+	if !ok {
+		return ""
+	}
+
+	return strings.TrimSpace(cells.Eq(idx).Text())
+}
+
+// normalizeHeader makes header texts comparable regardless of case and surrounding whitespace.
+func normalizeHeader(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// headerIndex maps normalized header texts to the fields they stand for.
+func headerIndex(synonyms map[string][]string) map[string]string {
+	index := make(map[string]string)
+	for field, headers := range synonyms {
+		for _, header := range headers {
+			index[normalizeHeader(header)] = field
+		}
+	}
+
+	return index
+}
+
+// resolveColumns maps the table columns by their header texts. The fixed index layout is used when
+// the table has no header or the model column can't be recognized in it.
+func (p *Parser) resolveColumns(ctx context.Context, category string, table *goquery.Selection) columnMap {
+	header := table.Find("thead tr").First().Find("th, td")
+	if header.Length() == 0 {
+		header = table.Find("tr").First().Find("th")
+	}
+
+	return p.mapColumns(ctx, category, header.Map(func(_ int, cell *goquery.Selection) string {
+		return cell.Text()
+	}))
+}
+
+// mapColumns maps the columns by the header texts in their order, see resolveColumns.
+func (p *Parser) mapColumns(ctx context.Context, category string, header []string) columnMap {
+	if len(header) == 0 {
+		return indexColumns()
+	}
+
+	columns := make(columnMap)
+	for idx, text := range header {
+		field, ok := p.headers[normalizeHeader(text)]
+		if _, seen := columns[field]; ok && !seen {
+			columns[field] = idx
+		}
+	}
+
+	if _, ok := columns[FieldModel]; !ok {
+		p.log.WarnContext(ctx, "table header not recognized, falling back to column indices", "table", category)
+		return indexColumns()
+	}
+
+	for _, field := range slices.Concat(Fields(), p.extras) {
+		if _, ok := columns[field]; !ok && field != FieldURL && !p.hasSelector(field) {
+			p.log.WarnContext(ctx, "table column not found", "table", category, "field", field)
+		}
+	}
+
+	return columns
+}
+
+// extraValues returns the non-empty values of the extra fields read with value, nil if there are none.
+func (p *Parser) extraValues(value func(field string) string) map[string]string {
+	var extras map[string]string
+	for _, field := range p.extras {
+		text := value(field)
+		if text == "" {
+			continue
+		}
+		if extras == nil {
+			extras = make(map[string]string, len(p.extras))
+		}
+		extras[field] = text
+	}
+
+	return extras
+}
+
+// hasSelector reports whether the field is extracted with a configured selector.
+func (p *Parser) hasSelector(field string) bool {
+	_, ok := p.selectors[field]
+	return ok
+}
@@ -0,0 +1,126 @@
+package parser_test
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/compute"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTableResponse_HeaderColumns(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	testCases := []struct {
+		name     string
+		opts     []parser.Option
+		html     string
+		expected []models.Product
+	}{
+		{
+			name: "reordered columns are mapped by header",
+			html: `<table class="table-bordered">
+				<thead><tr><th>Price</th><th>Model</th><th>Quantity</th><th>Type</th><th>Image</th></tr></thead>
+				<tbody><tr><td>100</td><td>Model A</td><td>5</td><td>Diver</td><td>url_a</td></tr></tbody>
+			</table>`,
+			expected: []models.Product{
+				{Model: "Model A", Type: "Diver", Quantity: "5", ImageURL: "url_a", Price: "100"},
+			},
+		},
+		{
+			name: "header row inside tbody with localized texts",
+			html: `<table class="table-bordered"><tbody>
+				<tr><th>Модель</th><th> ціна </th><th>Кількість</th></tr>
+				<tr><td>Model A</td><td>100</td><td>5</td></tr>
+			</tbody></table>`,
+			expected: []models.Product{{Model: "Model A", Quantity: "5", Price: "100"}},
+		},
+		{
+			name: "configured synonyms extend the defaults",
+			opts: []parser.Option{parser.WithColumnSynonyms(map[string][]string{
+				parser.FieldModel: {"Артикул"},
+				parser.FieldPrice: {"Вартість"},
+			})},
+			html: `<table class="table-bordered">
+				<thead><tr><th>Вартість</th><th>Артикул</th><th>Type</th></tr></thead>
+				<tbody><tr><td>100</td><td>Model A</td><td>Diver</td></tr></tbody>
+			</table>`,
+			expected: []models.Product{{Model: "Model A", Type: "Diver", Price: "100"}},
+		},
+		{
+			name: "extra fields are recognized by their names and headers",
+			opts: []parser.Option{parser.WithExtraFields(map[string][]string{
+				"warranty": {"Гарантія"},
+				"ean":      nil,
+				"supplier": nil,
+			})},
+			html: `<table class="table-bordered">
+				<thead><tr>
+					<th>Model</th><th>Price</th><th>Гарантія</th><th>EAN</th><th>Supplier</th>
+				</tr></thead>
+				<tbody>
+					<tr><td>Model A</td><td>100</td><td>2 years</td><td>4006381333931</td><td>Acme</td></tr>
+					<tr><td>Model B</td><td>200</td><td></td><td></td><td></td></tr>
+				</tbody>
+			</table>`,
+			expected: []models.Product{
+				{Model: "Model A", Price: "100", Extras: map[string]string{
+					"warranty": "2 years", "ean": "4006381333931", "supplier": "Acme",
+				}},
+				{Model: "Model B", Price: "200"},
+			},
+		},
+		{
+			name: "computed fields are added after the extra fields",
+			opts: []parser.Option{
+				parser.WithExtraFields(map[string][]string{"pack": nil}),
+				parser.WithComputedFields(compute.NewComputer([]compute.Field{
+					{Name: "unit_price", Expression: mustParseExpression(t, "price / pack")},
+				}, nil)),
+			},
+			html: `<table class="table-bordered">
+				<thead><tr><th>Model</th><th>Price</th><th>Pack</th></tr></thead>
+				<tbody><tr><td>Model A</td><td>100</td><td>8</td></tr></tbody>
+			</table>`,
+			expected: []models.Product{
+				{Model: "Model A", Price: "100", Extras: map[string]string{"pack": "8", "unit_price": "12.5"}},
+			},
+		},
+		{
+			name: "unrecognized header falls back to column indices",
+			html: `<table class="table-bordered">
+				<thead><tr><th>A</th><th>B</th><th>C</th><th>D</th><th>E</th></tr></thead>
+				<tbody><tr><td>Model A</td><td>Diver</td><td>5</td><td>url_a</td><td>100</td></tr></tbody>
+			</table>`,
+			expected: []models.Product{
+				{Model: "Model A", Type: "Diver", Quantity: "5", ImageURL: "url_a", Price: "100"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := parser.NewParser(logger, "", tc.opts...)
+
+			products, err := p.ParseTableResponse(t.Context(), io.NopCloser(strings.NewReader(tc.html)))
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, products)
+		})
+	}
+}
+
+// mustParseExpression parses the expression of a computed field.
+func mustParseExpression(t *testing.T, source string) *compute.Expression {
+	t.Helper()
+
+	expr, err := compute.Parse(source)
+	require.NoError(t, err)
+
+	return expr
+}
@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// cellSelector extracts a field value from a table row: the text or an attribute of the first
+// element matching a CSS selector relative to the row.
+type cellSelector struct {
+	selector string
+	attr     string
+}
+
+// parseCellSelector parses an expression in the "selector@attr" format, e.g. "td:nth-child(4) img@src".
+// Without the "@attr" suffix the element text is extracted.
+func parseCellSelector(expr string) cellSelector {
+	expr = strings.TrimSpace(expr)
+	idx := strings.LastIndex(expr, "@")
+	if idx < 0 || strings.ContainsAny(expr[idx+1:], " ]):") {
+		return cellSelector{selector: expr}
+	}
+
+	return cellSelector{selector: strings.TrimSpace(expr[:idx]), attr: strings.TrimSpace(expr[idx+1:])}
+}
+
+// extract returns the trimmed value of the selector in the row.
+func (c cellSelector) extract(row *goquery.Selection) string {
+	element := row.Find(c.selector).First()
+	if c.attr == "" {
+		return strings.TrimSpace(element.Text())
+	}
+
+	value, _ := element.Attr(c.attr)
+
+	return strings.TrimSpace(value)
+}
+
+// fieldValue returns the value of a product field in the row. A configured selector takes precedence
+// over the mapped column. Image and link fields are taken from the src and href attributes of the
+// column, and the link defaults to the one in the model cell, then to the anchor of the row.
+func (p *Parser) fieldValue(row, cells *goquery.Selection, columns columnMap, field string) string {
+	if selector, ok := p.selectors[field]; ok {
+		value := selector.extract(row)
+		if selector.attr != "" {
+			value = p.resolveURL(value)
+		}
+		return value
+	}
+
+	switch field {
+	case FieldImage:
+		if text := columns.cell(cells, field); text != "" {
+			return text
+		}
+		return p.columnAttr(cells, columns, field, "img", "src")
+	case FieldURL:
+		linkField := FieldModel
+		if _, ok := columns[field]; ok {
+			linkField = field
+		}
+		if link := p.columnAttr(cells, columns, linkField, "a", "href"); link != "" {
+			return link
+		}
+		id, _ := row.Attr("id")
+		return rowAnchor(p.resolveURL, id)
+	default:
+		return columns.cell(cells, field)
+	}
+}
+
+// columnAttr returns the attribute of the first matching element in the field's cell as an absolute URL.
+func (p *Parser) columnAttr(cells *goquery.Selection, columns columnMap, field, element, attr string) string {
+	idx, ok := columns[field]
+	if !ok {
+		return ""
+	}
+
+	value, _ := cells.Eq(idx).Find(element).First().Attr(attr)
+
+	return p.resolveURL(strings.TrimSpace(value))
+}
+
+// rowAnchor returns the link to the row with the id on the page, so a product without a link of its own can
+// still be jumped to. It's empty if the row has no id or the page URL isn't absolute.
+func rowAnchor(resolve func(string) string, id string) string {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return ""
+	}
+
+	anchor, err := url.Parse(resolve((&url.URL{Fragment: id}).String()))
+	if err != nil || !anchor.IsAbs() {
+		return ""
+	}
+
+	return anchor.String()
+}
+
+// resolveURL makes a link taken from the page absolute using the document URL as the base.
+func (p *Parser) resolveURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+
+	resolved, err := resolveReference(p.documentBase(), raw)
+	if err != nil {
+		return raw
+	}
+
+	return resolved
+}
+
+// documentBase returns the URL relative links on the page are resolved against.
+func (p *Parser) documentBase() string {
+	if documentURL := p.documentURL.Load(); documentURL != nil {
+		return *documentURL
+	}
+
+	return p.destURL
+}
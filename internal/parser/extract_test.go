@@ -0,0 +1,90 @@
+package parser_test
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTableResponse_Attributes(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	testCases := []struct {
+		name     string
+		opts     []parser.Option
+		html     string
+		expected []models.Product
+	}{
+		{
+			name: "links and images are taken from attributes by default",
+			html: `<table class="table-bordered"><tbody><tr>
+				<td><a href="/watch/a1">Model A</a></td><td>Diver</td><td>5</td>
+				<td><img src="https://cdn.example.com/a1.jpg"></td><td>100</td>
+			</tr></tbody></table>`,
+			expected: []models.Product{{
+				Model:      "Model A",
+				Type:       "Diver",
+				Quantity:   "5",
+				ImageURL:   "https://cdn.example.com/a1.jpg",
+				Price:      "100",
+				ProductURL: "https://example.com/watch/a1",
+			}},
+		},
+		{
+			name: "configured selectors override the columns",
+			opts: []parser.Option{parser.WithColumnSelectors(map[string]string{
+				parser.FieldImage: "td:nth-child(4) img@data-src",
+				parser.FieldURL:   "td:nth-child(2) a@href",
+				parser.FieldType:  "td:nth-child(2) span",
+			})},
+			html: `<table class="table-bordered"><tbody><tr>
+				<td>Model A</td><td><a href="details?id=1"><span>Diver</span></a></td><td>5</td>
+				<td><img src="placeholder.gif" data-src="/img/a1.jpg"></td><td>100</td>
+			</tr></tbody></table>`,
+			expected: []models.Product{{
+				Model:      "Model A",
+				Type:       "Diver",
+				Quantity:   "5",
+				ImageURL:   "https://example.com/img/a1.jpg",
+				Price:      "100",
+				ProductURL: "https://example.com/details?id=1",
+			}},
+		},
+		{
+			name: "rows without a link are linked by their anchor",
+			html: `<table class="table-bordered"><tbody>
+				<tr id="a1"><td>Model A</td><td>Diver</td><td>5</td><td></td><td>100</td></tr>
+				<tr id="b2"><td><a href="/watch/b2">Model B</a></td><td>Pilot</td><td>1</td><td></td><td>200</td></tr>
+				<tr><td>Model C</td><td>Field</td><td>2</td><td></td><td>300</td></tr>
+			</tbody></table>`,
+			expected: []models.Product{
+				{
+					Model: "Model A", Type: "Diver", Quantity: "5", Price: "100",
+					ProductURL: "https://example.com/catalog#a1",
+				},
+				{
+					Model: "Model B", Type: "Pilot", Quantity: "1", Price: "200",
+					ProductURL: "https://example.com/watch/b2",
+				},
+				{Model: "Model C", Type: "Field", Quantity: "2", Price: "300"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := parser.NewParser(logger, "https://example.com/catalog", tc.opts...)
+
+			products, err := p.ParseTableResponse(t.Context(), io.NopCloser(strings.NewReader(tc.html)))
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, products)
+		})
+	}
+}
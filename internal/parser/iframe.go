@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ErrIframeNotFound is returned when the page has no iframe matching the configured selector.
+var ErrIframeNotFound = errors.New("iframe not found on the page")
+
+// WithIframe makes the parser follow the src of the first iframe matching selector and parse the
+// embedded document instead of the page itself. Cookies set by the page are sent with the iframe request.
+func WithIframe(selector string) Option {
+	return func(p *Parser) {
+		p.iframeSelector = selector
+	}
+}
+
+// followIframe fetches the document embedded into the page with the configured iframe.
+// It takes ownership of the page response and closes its body.
+func (p *Parser) followIframe(ctx context.Context, page *http.Response) (*http.Response, error) {
+	defer page.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(page.Body)
+	if err != nil {
+		return nil, fmt.Errorf("data cannot be parsed as HTML: %w", err)
+	}
+
+	src, _ := doc.Find(p.iframeSelector).First().Attr("src")
+	if src = strings.TrimSpace(src); src == "" {
+		return nil, fmt.Errorf("%w: %s", ErrIframeNotFound, p.iframeSelector)
+	}
+
+	pageURL := p.documentBase()
+	if page.Request != nil && page.Request.URL != nil {
+		pageURL = page.Request.URL.String()
+	}
+	frameURL, err := resolveReference(pageURL, src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve iframe src %s: %w", src, err)
+	}
+
+	p.log.DebugContext(ctx, "Following iframe", "src", frameURL)
+	p.documentURL.Store(&frameURL)
+
+	return p.get(ctx, frameURL, pageURL)
+}
+
+// resolveReference resolves ref against the base URL.
+func resolveReference(base, ref string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL: %w", err)
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid reference: %w", err)
+	}
+
+	return baseURL.ResolveReference(refURL).String(), nil
+}
@@ -0,0 +1,59 @@
+package parser_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProducts_Iframe(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/shop/catalog", func(w http.ResponseWriter, _ *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1", Path: "/"})
+		_, _ = io.WriteString(w, `<html><body><iframe id="stock" src="../embed/table"></iframe></body></html>`)
+	})
+	mux.HandleFunc("/embed/table", func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "s1" {
+			http.Error(w, "no session", http.StatusForbidden)
+			return
+		}
+		_, _ = io.WriteString(w, `<table class="table-bordered"><tbody><tr>
+			<td><a href="item/1">Model A</a></td><td>Diver</td><td>5</td><td></td><td>100</td>
+		</tr></tbody></table>`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	t.Run("embedded document is parsed with the page session", func(t *testing.T) {
+		p := parser.NewParser(logger, server.URL+"/shop/catalog", parser.WithIframe("iframe#stock"))
+
+		products, err := p.ParseProducts(t.Context())
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.Product{{
+			Model:      "Model A",
+			Type:       "Diver",
+			Quantity:   "5",
+			Price:      "100",
+			ProductURL: server.URL + "/embed/item/1",
+		}}, products)
+	})
+
+	t.Run("missing iframe", func(t *testing.T) {
+		p := parser.NewParser(logger, server.URL+"/shop/catalog", parser.WithIframe("iframe#missing"))
+
+		products, err := p.ParseProducts(t.Context())
+
+		require.ErrorIs(t, err, parser.ErrIframeNotFound)
+		assert.Nil(t, products)
+	})
+}
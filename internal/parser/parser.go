@@ -2,21 +2,143 @@ package parser
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"net/http"
+	"net/http/cookiejar"
 	"net/url"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/Houeta/chrono-flow/internal/compute"
 	"github.com/Houeta/chrono-flow/internal/models"
 	"github.com/PuerkitoBio/goquery"
 )
 
+// defaultTableSelector matches the product tables when no tables are configured.
+const defaultTableSelector = ".table-bordered"
+
 type Parser struct {
 	log     *slog.Logger
 	Client  *http.Client
 	destURL string
+	tables  []Table
+	// headers maps normalized header texts to product fields.
+	headers map[string]string
+	// selectors extract fields from the rows regardless of the table columns.
+	selectors map[string]cellSelector
+	// extras are the names of the extra fields parsed into Product.Extras, sorted.
+	extras []string
+	// computer adds the computed fields to the parsed products, nil computes none.
+	computer *compute.Computer
+	// iframeSelector matches the iframe embedding the products, empty parses the page itself.
+	iframeSelector string
+	// steps lead from the page to the document with the products, stepsErr is set if one is invalid.
+	steps    []fetchStep
+	stepsErr error
+	// documentURL is the URL of the last fetched document, the page or the document embedded into it,
+	// relative links are resolved against it.
+	documentURL atomic.Pointer[string]
+	// request is the method, body and headers the page is requested with, see WithRequest.
+	request models.TargetRequest
+	// now returns the time the URL template and the request body of the page are rendered for.
+	now func() time.Time
+
+	// streaming extracts the products with the HTML tokenizer, see WithStreaming.
+	streaming bool
+	// streamTables are the selectors of the tables for the streaming parser, nil uses the document tree.
+	streamTables []simpleSelector
+}
+
+// Table describes a product table on the page. Products parsed from it are tagged with its name.
+type Table struct {
+	// Name is the category of the products in the table, empty for a single unnamed table.
+	Name string
+	// Selector is a CSS selector matching the table element.
+	Selector string
+}
+
+// Option configures optional Parser behavior.
+type Option func(*Parser)
+
+// WithClient sets the HTTP client pages are fetched with, it's expected to be shared between checks
+// to reuse connections.
+func WithClient(client *http.Client) Option {
+	return func(p *Parser) {
+		p.Client = client
+	}
+}
+
+// WithColumnSynonyms adds header texts the product fields are recognized by, on top of the defaults.
+func WithColumnSynonyms(synonyms map[string][]string) Option {
+	return func(p *Parser) {
+		for header, field := range headerIndex(synonyms) {
+			p.headers[header] = field
+		}
+	}
+}
+
+// WithColumnSelectors extracts the fields with "selector@attr" expressions relative to the table row,
+// e.g. "td:nth-child(4) img@src". Without the "@attr" suffix the element text is extracted.
+func WithColumnSelectors(selectors map[string]string) Option {
+	return func(p *Parser) {
+		for field, expr := range selectors {
+			p.selectors[field] = parseCellSelector(expr)
+		}
+	}
+}
+
+// WithExtraFields parses the extra columns of the tables into Product.Extras, e.g. a warranty or an EAN.
+// The fields map to the header texts they are recognized by, a field is recognized by its name too.
+func WithExtraFields(fields map[string][]string) Option {
+	return func(p *Parser) {
+		for field, headers := range fields {
+			p.extras = append(p.extras, field)
+			p.headers[normalizeHeader(field)] = field
+			for _, header := range headers {
+				p.headers[normalizeHeader(header)] = field
+			}
+		}
+		slices.Sort(p.extras)
+	}
+}
+
+// WithComputedFields adds the fields of the computer to the extras of the parsed products.
+func WithComputedFields(computer *compute.Computer) Option {
+	return func(p *Parser) {
+		p.computer = computer
+	}
+}
+
+// WithTables sets the named tables products are parsed from. Without it every ".table-bordered"
+// table on the page is parsed without a category.
+func WithTables(tables []Table) Option {
+	return func(p *Parser) {
+		if len(tables) > 0 {
+			p.tables = tables
+		}
+	}
+}
+
+// ErrFetchFailed wraps every error of getting the document with the products, see GetHTMLResponse: the
+// page couldn't be requested, it was answered with a status other than 200 OK, e.g. a StatusError, or its
+// steps or iframe couldn't be followed.
+var ErrFetchFailed = errors.New("fetch failed")
+
+// StatusError is returned for a response with a status other than 200 OK.
+type StatusError struct {
+	Code   int
+	Status string
+}
+
+// Error implements error.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status code error: [%d] %s", e.Code, e.Status)
 }
 
 type HTMLParser interface {
@@ -25,8 +147,35 @@ type HTMLParser interface {
 	ParseTableResponse(ctx context.Context, inp io.ReadCloser) ([]models.Product, error)
 }
 
-func NewParser(log *slog.Logger, destinationURL string) *Parser {
-	return &Parser{log: log, destURL: destinationURL, Client: http.DefaultClient}
+func NewParser(log *slog.Logger, destinationURL string, opts ...Option) *Parser {
+	p := &Parser{
+		log:       log,
+		destURL:   destinationURL,
+		Client:    http.DefaultClient,
+		tables:    []Table{{Selector: defaultTableSelector}},
+		headers:   headerIndex(defaultColumnSynonyms()),
+		selectors: make(map[string]cellSelector),
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if (p.iframeSelector != "" || len(p.steps) > 0) && p.Client.Jar == nil {
+		// The embedded document and the documents of the steps often rely on the session cookies set by the page.
+		// The copy shares the transport, so connections are still reused.
+		client := *p.Client
+		client.Jar, _ = cookiejar.New(nil) // never fails without options
+		p.Client = &client
+	}
+	if p.streaming {
+		selectors, err := p.streamSelectors()
+		if err != nil {
+			log.Warn("Streaming parser can't be used, falling back to the document tree", "error", err)
+		}
+		p.streamTables = selectors
+	}
+
+	return p
 }
 
 func (p *Parser) ParseProducts(ctx context.Context) ([]models.Product, error) {
@@ -39,28 +188,137 @@ func (p *Parser) ParseProducts(ctx context.Context) ([]models.Product, error) {
 	return p.ParseTableResponse(ctx, resp.Body)
 }
 
+// GetHTMLResponse fetches the document with the products, its errors wrap ErrFetchFailed.
 func (p *Parser) GetHTMLResponse(ctx context.Context) (*http.Response, error) {
-	reqURL, err := url.Parse(p.destURL)
+	res, err := p.getDocument(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse destination URL %s: %w", p.destURL, err)
+		return nil, fmt.Errorf("%w: %w", ErrFetchFailed, err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL.String(), nil)
+	return res, nil
+}
+
+// getDocument requests the page and follows its steps and its iframe to the document with the products.
+func (p *Parser) getDocument(ctx context.Context) (*http.Response, error) {
+	res, err := p.getPage(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create new request %s: %w", reqURL.String(), err)
+		return nil, err
+	}
+	if len(p.steps) > 0 {
+		if res, err = p.followSteps(ctx, res); err != nil {
+			return nil, err
+		}
+	}
+	if p.iframeSelector == "" {
+		return res, nil
 	}
 
+	return p.followIframe(ctx, res)
+}
+
+// WithRequest sets the method, body and headers the page is requested with, e.g. to submit the form the
+// table is only reachable through. The following requests, of the steps and of the iframe, are plain GETs.
+func WithRequest(request models.TargetRequest) Option {
+	return func(p *Parser) {
+		p.request = request
+	}
+}
+
+// getPage requests the page. A URL template and the request body are rendered for the current time, and
+// for the day before if the page of today isn't found, e.g. as the price list of the day isn't published
+// yet.
+func (p *Parser) getPage(ctx context.Context) (*http.Response, error) {
+	now := p.now()
+	pageURL, err := models.RenderTargetURL(p.destURL, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render destination URL %s: %w", p.destURL, err)
+	}
+
+	res, err := p.requestPage(ctx, pageURL, now)
+	var statusErr *StatusError
+	if models.IsTargetURLTemplate(p.destURL) && errors.As(err, &statusErr) && statusErr.Code == http.StatusNotFound {
+		previous := now.AddDate(0, 0, -1)
+		previousURL, renderErr := models.RenderTargetURL(p.destURL, previous)
+		if renderErr == nil && previousURL != pageURL {
+			p.log.WarnContext(ctx, "Page not found, falling back to the previous day", "url", pageURL,
+				"fallback", previousURL)
+			pageURL = previousURL
+			res, err = p.requestPage(ctx, pageURL, previous)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.documentURL.Store(&pageURL)
+
+	return res, nil
+}
+
+// requestPage requests the page at the URL with the method, body and headers of the request, the body
+// is rendered for the time.
+func (p *Parser) requestPage(ctx context.Context, pageURL string, at time.Time) (*http.Response, error) {
+	body, contentType, err := p.request.Body(at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render request body: %w", err)
+	}
+
+	req, err := p.newRequest(ctx, p.request.HTTPMethod(), pageURL, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for name, value := range p.request.Headers {
+		req.Header.Set(name, value)
+	}
+
+	return p.do(ctx, req)
+}
+
+// get requests the target URL, the referer is sent if it isn't empty.
+func (p *Parser) get(ctx context.Context, target, referer string) (*http.Response, error) {
+	req, err := p.newRequest(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if referer != "" {
+		req.Header.Add("Referer", referer)
+	}
+
+	return p.do(ctx, req)
+}
+
+// newRequest creates a request of the target URL with the User-Agent of the parser.
+func (p *Parser) newRequest(ctx context.Context, method, target string, body io.Reader) (*http.Request, error) {
+	reqURL, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse destination URL %s: %w", target, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new request %s: %w", reqURL.String(), err)
+	}
 	req.Header.Add("User-Agent", "Mozilla/5.0 (compatible; GoHttpClient/1.0)")
 
-	p.log.DebugContext(ctx, "Send request", "method", req.Method, "URL", req.URL, "header", req.Header)
+	return req, nil
+}
+
+// do sends the request, a response other than 200 OK is returned as a StatusError.
+func (p *Parser) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	// The configured headers may hold credentials, only their names are logged.
+	p.log.DebugContext(ctx, "Send request", "method", req.Method, "URL", req.URL,
+		"headers", slices.Sorted(maps.Keys(req.Header)))
 
 	res, err := p.Client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to request %s: %w", p.destURL, err)
+		return nil, fmt.Errorf("failed to request %s: %w", req.URL, err)
 	}
 
 	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("status code error: [%d] %s", res.StatusCode, res.Status)
+		_ = res.Body.Close()
+		return nil, &StatusError{Code: res.StatusCode, Status: res.Status}
 	}
 
 	p.log.InfoContext(ctx, "Successfully received http response", "status code", res.StatusCode)
@@ -69,42 +327,83 @@ func (p *Parser) GetHTMLResponse(ctx context.Context) (*http.Response, error) {
 }
 
 func (p *Parser) ParseTableResponse(ctx context.Context, inp io.ReadCloser) ([]models.Product, error) {
+	if p.streamTables != nil {
+		return p.parseStream(ctx, inp, p.streamTables)
+	}
+
 	doc, err := goquery.NewDocumentFromReader(inp)
 	if err != nil {
 		return nil, fmt.Errorf("data cannot be parsed as HTML: %w", err)
 	}
 
 	var products []models.Product
-	numberOfCells := 5
-	modelIdx := 0
-	typeIdx := 1
-	quantityIdx := 2
-	imageIdx := 3
-	priceIdx := 4
-
-	doc.Find(".table-bordered tbody tr").Each(func(idx int, s *goquery.Selection) {
+	for _, table := range p.tables {
+		found := doc.Find(table.Selector)
+		if found.Length() == 0 && table.Name != "" {
+			p.log.WarnContext(ctx, "table not found on the page", "table", table.Name, "selector", table.Selector)
+		}
+		found.Each(func(_ int, tbl *goquery.Selection) {
+			columns := p.resolveColumns(ctx, table.Name, tbl)
+			products = append(products, p.parseRows(ctx, table.Name, columns, tbl.Find("tbody tr"))...)
+		})
+	}
+
+	return products, nil
+}
+
+// parseRows converts table rows into products of the given category, skipping malformed rows.
+func (p *Parser) parseRows(
+	ctx context.Context,
+	category string,
+	columns columnMap,
+	rows *goquery.Selection,
+) []models.Product {
+	var products []models.Product
+	width := columns.width()
+
+	rows.Each(func(idx int, s *goquery.Selection) {
 		cells := s.Find("td")
 
-		if cells.Length() == numberOfCells {
-			product := models.Product{
-				Model:    strings.TrimSpace(cells.Eq(modelIdx).Text()),
-				Type:     strings.TrimSpace(cells.Eq(typeIdx).Text()),
-				Quantity: strings.TrimSpace(cells.Eq(quantityIdx).Text()),
-				ImageURL: strings.TrimSpace(cells.Eq(imageIdx).Text()),
-				Price:    strings.TrimSpace(cells.Eq(priceIdx).Text()),
-			}
-			p.log.DebugContext(
-				ctx,
-				"Parsed product",
-				"Model", product.Model,
-				"Price", product.Price,
-				"Quantity", product.Quantity,
-			)
-			products = append(products, product)
-		} else {
+		switch {
+		case cells.Length() == 0:
+			// Header rows placed in tbody contain only th cells.
+			return
+		case cells.Length() < width:
 			p.log.WarnContext(ctx, "table row has insufficient cells", "index", idx, "length", cells.Length())
+			return
 		}
+
+		product := models.Product{
+			Model:      p.fieldValue(s, cells, columns, FieldModel),
+			Type:       p.fieldValue(s, cells, columns, FieldType),
+			Quantity:   p.fieldValue(s, cells, columns, FieldQuantity),
+			ImageURL:   p.fieldValue(s, cells, columns, FieldImage),
+			Price:      p.fieldValue(s, cells, columns, FieldPrice),
+			ProductURL: p.fieldValue(s, cells, columns, FieldURL),
+			Category:   category,
+			Extras: p.extraValues(func(field string) string {
+				return columns.cell(cells, field)
+			}),
+		}
+		p.computer.Apply(&product)
+		p.logProduct(ctx, product)
+		products = append(products, product)
 	})
 
-	return products, nil
+	return products
+}
+
+// logProduct logs a parsed product. The level is checked first to spare boxing the attributes of every row.
+func (p *Parser) logProduct(ctx context.Context, product models.Product) {
+	if !p.log.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	p.log.DebugContext(
+		ctx,
+		"Parsed product",
+		"Model", product.Model,
+		"Category", product.Category,
+		"Price", product.Price,
+		"Quantity", product.Quantity,
+	)
 }
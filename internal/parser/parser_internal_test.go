@@ -0,0 +1,162 @@
+package parser
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProducts_URLTemplate(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var requested []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/saturday/pricelist-2025-05-31.html", func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		_, _ = io.WriteString(w, `<table class="table-bordered"><tbody><tr>
+			<td><a href="a1">Model A</a></td><td>Diver</td><td>5</td><td></td><td>100</td>
+		</tr></tbody></table>`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	newParser := func(destURL string) *Parser {
+		p := NewParser(logger, destURL)
+		p.now = func() time.Time { return time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC) }
+		return p
+	}
+
+	t.Run("the page of the day before is fetched if today's isn't found", func(t *testing.T) {
+		requested = nil
+		p := newParser(server.URL + `/{{lower weekday}}/pricelist-{{now.Format "2006-01-02"}}.html`)
+
+		products, err := p.ParseProducts(t.Context())
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/sunday/pricelist-2025-06-01.html", "/saturday/pricelist-2025-05-31.html"}, requested)
+		assert.Equal(t, []models.Product{{
+			Model:      "Model A",
+			Type:       "Diver",
+			Quantity:   "5",
+			Price:      "100",
+			ProductURL: server.URL + "/saturday/a1",
+		}}, products, "the links are resolved against the page fetched")
+	})
+
+	t.Run("a plain URL isn't retried", func(t *testing.T) {
+		requested = nil
+		p := newParser(server.URL + "/pricelist-2025-06-01.html")
+
+		_, err := p.ParseProducts(t.Context())
+
+		var statusErr *StatusError
+		require.ErrorAs(t, err, &statusErr)
+		require.ErrorIs(t, err, ErrFetchFailed)
+		assert.Equal(t, http.StatusNotFound, statusErr.Code)
+		assert.Len(t, requested, 1)
+	})
+
+	t.Run("error: invalid template", func(t *testing.T) {
+		p := newParser(server.URL + "/{{now.Month.Missing}}.html")
+
+		_, err := p.ParseProducts(t.Context())
+
+		require.ErrorContains(t, err, "failed to render destination URL")
+	})
+}
+
+func TestParseProducts_Request(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	type received struct {
+		method, contentType, requestedWith, body string
+	}
+	var got received
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = received{
+			method:        r.Method,
+			contentType:   r.Header.Get("Content-Type"),
+			requestedWith: r.Header.Get("X-Requested-With"),
+			body:          string(body),
+		}
+		_, _ = io.WriteString(w, `<table class="table-bordered"><tbody><tr>
+			<td>Model A</td><td>Diver</td><td>5</td><td></td><td>100</td>
+		</tr></tbody></table>`)
+	}))
+	t.Cleanup(server.Close)
+
+	newParser := func(request models.TargetRequest) *Parser {
+		p := NewParser(logger, server.URL, WithRequest(request))
+		p.now = func() time.Time { return time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC) }
+		return p
+	}
+
+	t.Run("a form is posted", func(t *testing.T) {
+		p := newParser(models.TargetRequest{
+			Method:  "post",
+			Form:    map[string]string{"category": "watches", "date": `{{now.Format "2006-01-02"}}`},
+			Headers: map[string]string{"X-Requested-With": "XMLHttpRequest"},
+		})
+
+		products, err := p.ParseProducts(t.Context())
+
+		require.NoError(t, err)
+		assert.Len(t, products, 1)
+		assert.Equal(t, received{
+			method:        http.MethodPost,
+			contentType:   "application/x-www-form-urlencoded",
+			requestedWith: "XMLHttpRequest",
+			body:          "category=watches&date=2025-06-01",
+		}, got)
+	})
+
+	t.Run("a JSON body is posted", func(t *testing.T) {
+		p := newParser(models.TargetRequest{Method: http.MethodPost, JSON: `{"warehouse": "{{lower weekday}}"}`})
+
+		_, err := p.ParseProducts(t.Context())
+
+		require.NoError(t, err)
+		assert.Equal(t, received{
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"warehouse": "sunday"}`,
+		}, got)
+	})
+
+	t.Run("error: the rendered body isn't JSON", func(t *testing.T) {
+		p := newParser(models.TargetRequest{Method: http.MethodPost, JSON: `{"warehouse": {{weekday}}}`})
+
+		_, err := p.ParseProducts(t.Context())
+
+		require.ErrorContains(t, err, "failed to render request body")
+		require.ErrorIs(t, err, models.ErrInvalidTargetRequest)
+	})
+}
+
+func TestParser_DoLogsHeaderNames(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+	t.Cleanup(server.Close)
+	request := models.TargetRequest{Headers: map[string]string{"Authorization": "Bearer secret-token"}}
+
+	res, err := NewParser(logger, server.URL, WithRequest(request)).GetHTMLResponse(t.Context())
+
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Contains(t, logs.String(), "Authorization")
+	assert.NotContains(t, logs.String(), "secret-token", "the values of the headers aren't logged")
+}
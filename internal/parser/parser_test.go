@@ -111,6 +111,35 @@ func TestParseTableResponse(t *testing.T) {
 	}
 }
 
+func TestParseTableResponse_NamedTables(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	p := parser.NewParser(logger, "", parser.WithTables([]parser.Table{
+		{Name: "new", Selector: "#new-stock"},
+		{Name: "used", Selector: "#used-stock"},
+		{Name: "incoming", Selector: "#incoming"},
+	}))
+
+	html := `
+	<html>
+	<body>
+		<table id="new-stock" class="table-bordered"><tbody>
+			<tr><td>Model A</td><td>Type A</td><td>5</td><td>url_a</td><td>100.00</td></tr>
+		</tbody></table>
+		<table id="used-stock" class="table-bordered"><tbody>
+			<tr><td>Model A</td><td>Type A</td><td>1</td><td>url_a</td><td>70.00</td></tr>
+		</tbody></table>
+	</body>
+	</html>`
+
+	products, err := p.ParseTableResponse(t.Context(), io.NopCloser(strings.NewReader(html)))
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.Product{
+		{Model: "Model A", Type: "Type A", Quantity: "5", ImageURL: "url_a", Price: "100.00", Category: "new"},
+		{Model: "Model A", Type: "Type A", Quantity: "1", ImageURL: "url_a", Price: "70.00", Category: "used"},
+	}, products)
+}
+
 // =============================================================================
 // Tests for network logic
 // =============================================================================
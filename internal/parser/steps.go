@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ErrStepNotMatched is returned when a fetch step finds no value in its document.
+var ErrStepNotMatched = errors.New("fetch step found nothing")
+
+// fetchStep is a fetch step with its value extraction prepared, see models.TargetStep.
+type fetchStep struct {
+	models.TargetStep
+	// selector extracts the value if the step has no regex.
+	selector cellSelector
+	regex    *regexp.Regexp
+}
+
+// WithSteps makes the parser follow the steps from the page and parse the document of the last one, e.g.
+// to find the link to a price list that rotates. Cookies set by the documents are sent with the following
+// requests. Invalid steps, which models.ValidateTargetSteps rejects, fail every fetch.
+func WithSteps(steps []models.TargetStep) Option {
+	return func(p *Parser) {
+		p.steps = make([]fetchStep, 0, len(steps))
+		for _, step := range steps {
+			prepared := fetchStep{TargetStep: step, selector: parseCellSelector(step.Selector)}
+			if step.Regex != "" {
+				var err error
+				if prepared.regex, err = regexp.Compile(step.Regex); err != nil {
+					p.stepsErr = fmt.Errorf("%w: %w", models.ErrInvalidTargetStep, err)
+				}
+			}
+			p.steps = append(p.steps, prepared)
+		}
+	}
+}
+
+// followSteps follows the fetch steps from the page. It takes ownership of the page response.
+func (p *Parser) followSteps(ctx context.Context, page *http.Response) (*http.Response, error) {
+	if p.stepsErr != nil {
+		_ = page.Body.Close()
+		return nil, p.stepsErr
+	}
+
+	var err error
+	for idx, step := range p.steps {
+		if page, err = p.followStep(ctx, page, step); err != nil {
+			return nil, fmt.Errorf("step %d: %w", idx+1, err)
+		}
+	}
+
+	return page, nil
+}
+
+// followStep extracts the value of the step from the document and requests the next URL made of it.
+// It takes ownership of the document response and closes its body.
+func (p *Parser) followStep(ctx context.Context, document *http.Response, step fetchStep) (*http.Response, error) {
+	defer document.Body.Close()
+
+	body, err := io.ReadAll(document.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the document: %w", err)
+	}
+
+	value, err := step.extract(body)
+	if err != nil {
+		return nil, err
+	}
+	next, err := step.NextURL(value)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the error of the template is descriptive on its own.
+	}
+
+	documentURL := p.documentBase()
+	if document.Request != nil && document.Request.URL != nil {
+		documentURL = document.Request.URL.String()
+	}
+	nextURL, err := resolveReference(documentURL, next)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the next URL %s: %w", next, err)
+	}
+
+	p.log.DebugContext(ctx, "Following fetch step", "url", nextURL)
+	p.documentURL.Store(&nextURL)
+
+	return p.get(ctx, nextURL, documentURL)
+}
+
+// extract returns the value of the step in the document source.
+func (s fetchStep) extract(body []byte) (string, error) {
+	var value string
+	if s.regex != nil {
+		if match := s.regex.FindSubmatch(body); match != nil {
+			value = string(match[min(1, len(match)-1)])
+		}
+	} else {
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+		if err != nil {
+			return "", fmt.Errorf("data cannot be parsed as HTML: %w", err)
+		}
+		value = s.selector.extract(doc.Selection)
+	}
+
+	if value = strings.TrimSpace(value); value == "" {
+		return "", fmt.Errorf("%w: %s%s", ErrStepNotMatched, s.Selector, s.Regex)
+	}
+
+	return value, nil
+}
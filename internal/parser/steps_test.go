@@ -0,0 +1,77 @@
+package parser_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProducts_Steps(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/supplier/", func(w http.ResponseWriter, _ *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1", Path: "/"})
+		_, _ = io.WriteString(w, `<html><body><a class="pricelist" href="lists/current">Price list</a></body></html>`)
+	})
+	mux.HandleFunc("/supplier/lists/current", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `<script>var download = "/export?token=a1b2&format=html";</script>`)
+	})
+	mux.HandleFunc("/prices", func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+		if err != nil || cookie.Value != "s1" || r.URL.Query().Get("token") != "a1b2" {
+			http.Error(w, "expired", http.StatusForbidden)
+			return
+		}
+		_, _ = io.WriteString(w, `<table class="table-bordered"><tbody><tr>
+			<td><a href="item/1">Model A</a></td><td>Diver</td><td>5</td><td></td><td>100</td>
+		</tr></tbody></table>`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	t.Run("the steps lead to the tables with the session of the page", func(t *testing.T) {
+		p := parser.NewParser(logger, server.URL+"/supplier/", parser.WithSteps([]models.TargetStep{
+			{Selector: "a.pricelist@href"},
+			{Regex: `token=(\w+)`, URL: "/prices?token={{query .}}"},
+		}))
+
+		products, err := p.ParseProducts(t.Context())
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.Product{{
+			Model:      "Model A",
+			Type:       "Diver",
+			Quantity:   "5",
+			Price:      "100",
+			ProductURL: server.URL + "/item/1",
+		}}, products, "the links are resolved against the document of the last step")
+	})
+
+	t.Run("step without a match", func(t *testing.T) {
+		p := parser.NewParser(logger, server.URL+"/supplier/", parser.WithSteps([]models.TargetStep{
+			{Selector: "a.archive@href"},
+		}))
+
+		products, err := p.ParseProducts(t.Context())
+
+		require.ErrorIs(t, err, parser.ErrStepNotMatched)
+		require.ErrorContains(t, err, "step 1")
+		assert.Nil(t, products)
+	})
+
+	t.Run("invalid step", func(t *testing.T) {
+		p := parser.NewParser(logger, server.URL+"/supplier/", parser.WithSteps([]models.TargetStep{{Regex: "("}}))
+
+		_, err := p.ParseProducts(t.Context())
+
+		require.ErrorIs(t, err, models.ErrInvalidTargetStep)
+	})
+}
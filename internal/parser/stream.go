@@ -0,0 +1,546 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"golang.org/x/net/html"
+)
+
+// ErrUnsupportedSelector is returned when a table selector can't be matched by the streaming parser.
+var ErrUnsupportedSelector = errors.New("selector isn't supported by the streaming parser")
+
+// simpleSelectorRe matches a compound selector of an optional tag name, id and classes, e.g. "table#stock.wide".
+var simpleSelectorRe = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*)?((?:[.#][A-Za-z0-9_-]+)*)$`)
+
+// WithStreaming extracts the products with the HTML tokenizer instead of building the whole document
+// tree, which keeps the memory use of huge pages low. It only supports table selectors made of a tag
+// name, an id and classes and no column selectors, the document tree is used otherwise.
+func WithStreaming() Option {
+	return func(p *Parser) {
+		p.streaming = true
+	}
+}
+
+// simpleSelector matches an element by its tag name, id and classes, empty parts match anything.
+type simpleSelector struct {
+	tag     string
+	id      string
+	classes []string
+}
+
+// parseSimpleSelector parses a selector supported by the streaming parser.
+func parseSimpleSelector(selector string) (simpleSelector, error) {
+	match := simpleSelectorRe.FindStringSubmatch(strings.TrimSpace(selector))
+	if match == nil || match[0] == "" {
+		return simpleSelector{}, fmt.Errorf("%w: %q", ErrUnsupportedSelector, selector)
+	}
+
+	parsed := simpleSelector{tag: strings.ToLower(match[1])}
+	parts := match[2]
+	for parts != "" {
+		end := strings.IndexAny(parts[1:], ".#") + 1
+		if end == 0 {
+			end = len(parts)
+		}
+		if parts[0] == '#' {
+			parsed.id = parts[1:end]
+		} else {
+			parsed.classes = append(parsed.classes, parts[1:end])
+		}
+		parts = parts[end:]
+	}
+
+	return parsed, nil
+}
+
+// matches reports whether the element with the given tag name and attributes is matched.
+func (s simpleSelector) matches(tag string, attrs map[string]string) bool {
+	if s.tag != "" && s.tag != tag {
+		return false
+	}
+	if s.id != "" && attrs["id"] != s.id {
+		return false
+	}
+	classes := strings.Fields(attrs["class"])
+	for _, class := range s.classes {
+		if !slices.Contains(classes, class) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// streamSelectors returns the selectors of the tables, or an error if the streaming parser can't be used.
+func (p *Parser) streamSelectors() ([]simpleSelector, error) {
+	if len(p.selectors) > 0 {
+		return nil, fmt.Errorf("%w: column selectors are configured", ErrUnsupportedSelector)
+	}
+
+	selectors := make([]simpleSelector, 0, len(p.tables))
+	for _, table := range p.tables {
+		selector, err := parseSimpleSelector(table.Selector)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, selector)
+	}
+
+	return selectors, nil
+}
+
+// streamCell is a table cell with the values fields are extracted from.
+type streamCell struct {
+	header bool
+	text   []byte
+	// image and link are the src of the first image and the href of the first link in the cell.
+	image string
+	link  string
+}
+
+// streamTable is the state of a product table being parsed. The cells of the current row are reused
+// between rows, so a row doesn't allocate anything but the values of its product.
+type streamTable struct {
+	// matched are the indices of the configured tables matching the element.
+	matched []int
+	// columns are resolved from the first row, nil until then.
+	columns columnMap
+	// rows is the number of rows seen so far.
+	rows int
+	// head and foot are set inside the thead and tfoot sections.
+	head, foot bool
+	// inRow is set while a row is open, rowHead if it's in the thead section.
+	inRow, rowHead bool
+	// rowID is the id attribute of the open row, its anchor is the link of a product without one.
+	rowID string
+	// cells are the first count cells of the current row, cell is the open one or -1.
+	cells []streamCell
+	count int
+	cell  int
+	// data are the td cells of the current row.
+	data []*streamCell
+	// nested is the depth of tables nested into a cell, their content is a part of the cell text.
+	nested int
+	// base is the parsed document URL links are resolved against, nil if it's invalid.
+	base *url.URL
+	// emit receives the products of the table, err is the first error it returned.
+	emit emitFunc
+	err  error
+}
+
+// resolveURL makes a link absolute like Parser.resolveURL does, without parsing the base for every link.
+func (t *streamTable) resolveURL(raw string) string {
+	if raw == "" || t.base == nil {
+		return raw
+	}
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	return t.base.ResolveReference(ref).String()
+}
+
+// openCell returns the open cell, nil if there is none or no table is being parsed.
+func (t *streamTable) openCell() *streamCell {
+	if t == nil || t.cell < 0 {
+		return nil
+	}
+
+	return &t.cells[t.cell]
+}
+
+// startRow opens a new row with the id in the current section.
+func (t *streamTable) startRow(id string) {
+	t.inRow, t.rowHead, t.rowID = true, t.head, id
+	t.count, t.cell = 0, -1
+}
+
+// startCell opens a new cell in the current row.
+func (t *streamTable) startCell(header bool) {
+	if !t.inRow {
+		t.startRow("")
+	}
+	if t.count == len(t.cells) {
+		t.cells = append(t.cells, streamCell{})
+	}
+	t.cells[t.count] = streamCell{header: header, text: t.cells[t.count].text[:0]}
+	t.cell = t.count
+	t.count++
+}
+
+// dataCells returns the td cells of the row, header cells are skipped like in the document tree.
+func (t *streamTable) dataCells() []*streamCell {
+	t.data = t.data[:0]
+	for idx := range t.cells[:t.count] {
+		if !t.cells[idx].header {
+			t.data = append(t.data, &t.cells[idx])
+		}
+	}
+
+	return t.data
+}
+
+// StreamParser extracts the products of a page one by one, so they don't have to be held in memory at once.
+type StreamParser interface {
+	StreamTableResponse(ctx context.Context, inp io.Reader, yield func(models.Product) error) error
+}
+
+// StreamTableResponse passes the products of the page to yield in the order of the page and stops at its
+// first error. Without the streaming parser the products are extracted from the document tree first.
+func (p *Parser) StreamTableResponse(ctx context.Context, inp io.Reader, yield func(models.Product) error) error {
+	if p.streamTables == nil {
+		products, err := p.ParseTableResponse(ctx, io.NopCloser(inp))
+		if err != nil {
+			return err
+		}
+		for _, product := range products {
+			if err = yield(product); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return p.tokenize(ctx, inp, p.streamTables, func(_ int, product models.Product) error {
+		return yield(product)
+	})
+}
+
+// parseStream extracts the products from the page with the HTML tokenizer. The products are grouped
+// by the configured tables in their order, like the ones extracted from the document tree.
+func (p *Parser) parseStream(ctx context.Context, inp io.Reader, selectors []simpleSelector) ([]models.Product, error) {
+	grouped := make([][]models.Product, len(p.tables))
+	err := p.tokenize(ctx, inp, selectors, func(table int, product models.Product) error {
+		grouped[table] = append(grouped[table], product)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, products := range grouped {
+		total += len(products)
+	}
+
+	products := make([]models.Product, 0, total)
+	for _, tableProducts := range grouped {
+		products = append(products, tableProducts...)
+	}
+
+	return products, nil
+}
+
+// emitFunc receives a product extracted from the configured table with the index.
+type emitFunc func(table int, product models.Product) error
+
+// tokenize extracts the products from the page with the HTML tokenizer in the order of the page
+// and stops at the first error of emit.
+func (p *Parser) tokenize(ctx context.Context, inp io.Reader, selectors []simpleSelector, emit emitFunc) error {
+	tokenizer := html.NewTokenizer(inp)
+	found := make([]bool, len(p.tables))
+	var table *streamTable
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); !errors.Is(err, io.EOF) {
+				return fmt.Errorf("data cannot be parsed as HTML: %w", err)
+			}
+			if table != nil {
+				p.endStreamTable(ctx, table)
+				if table.err != nil {
+					return table.err
+				}
+			}
+			p.warnMissingTables(ctx, found)
+			return nil
+		case html.TextToken:
+			if cell := table.openCell(); cell != nil {
+				cell.text = append(cell.text, tokenizer.Text()...)
+			}
+		case html.StartTagToken:
+			if table == nil {
+				table = p.startStreamTable(tokenizer, selectors, found, emit)
+				continue
+			}
+			p.streamStartTag(ctx, table, tokenizer)
+		case html.SelfClosingTagToken:
+			if cell := table.openCell(); cell != nil {
+				name, hasAttr := tokenizer.TagName()
+				cell.addElement(tokenizer, string(name), hasAttr)
+			}
+		case html.EndTagToken:
+			if table == nil {
+				continue
+			}
+			name, _ := tokenizer.TagName()
+			closed := p.streamEndTag(ctx, table, string(name))
+			if table.err != nil {
+				return table.err
+			}
+			if closed {
+				table = nil
+			}
+		}
+		if table != nil && table.err != nil {
+			return table.err
+		}
+	}
+}
+
+// startStreamTable starts parsing a table if the element is matched by any of the selectors.
+func (p *Parser) startStreamTable(
+	tokenizer *html.Tokenizer,
+	selectors []simpleSelector,
+	found []bool,
+	emit emitFunc,
+) *streamTable {
+	name, hasAttr := tokenizer.TagName()
+	if string(name) != "table" {
+		return nil
+	}
+
+	attrs := make(map[string]string)
+	for hasAttr {
+		var key, val []byte
+		key, val, hasAttr = tokenizer.TagAttr()
+		attrs[string(key)] = string(val)
+	}
+
+	var matched []int
+	for idx, selector := range selectors {
+		if selector.matches("table", attrs) {
+			matched = append(matched, idx)
+			found[idx] = true
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+
+	base, _ := url.Parse(p.documentBase()) // an invalid base leaves the links as they are
+
+	return &streamTable{matched: matched, cell: -1, base: base, emit: emit}
+}
+
+// streamStartTag updates the state of the table with an opening tag inside it.
+func (p *Parser) streamStartTag(
+	ctx context.Context,
+	table *streamTable,
+	tokenizer *html.Tokenizer,
+) {
+	name, hasAttr := tokenizer.TagName()
+	tag := string(name)
+	if table.nested > 0 || (table.cell >= 0 && tag != "td" && tag != "th" && tag != "tr") {
+		if tag == "table" {
+			table.nested++
+		}
+		if cell := table.openCell(); cell != nil {
+			cell.addElement(tokenizer, tag, hasAttr)
+		}
+		return
+	}
+
+	switch tag {
+	case "thead", "tbody", "tfoot":
+		p.endStreamRow(ctx, table)
+		table.head, table.foot = tag == "thead", tag == "tfoot"
+	case "tr":
+		p.endStreamRow(ctx, table)
+		table.startRow(tagAttr(tokenizer, hasAttr, "id"))
+	case "td", "th":
+		table.startCell(tag == "th")
+	}
+}
+
+// streamEndTag updates the state of the table with a closing tag inside it, it reports whether
+// the table itself was closed.
+func (p *Parser) streamEndTag(ctx context.Context, table *streamTable, name string) bool {
+	if table.nested > 0 {
+		if name == "table" {
+			table.nested--
+		}
+		return false
+	}
+
+	switch name {
+	case "table":
+		p.endStreamTable(ctx, table)
+		return true
+	case "td", "th":
+		table.cell = -1
+	case "tr":
+		p.endStreamRow(ctx, table)
+	case "thead", "tfoot":
+		p.endStreamRow(ctx, table)
+		table.head, table.foot = false, false
+	}
+
+	return false
+}
+
+// addElement keeps the first image and link of the cell. The tokenizer returns the tag name only once,
+// so it's passed along with the attributes left to read.
+func (c *streamCell) addElement(tokenizer *html.Tokenizer, name string, hasAttr bool) {
+	var want string
+	switch {
+	case name == "img" && c.image == "":
+		want = "src"
+	case name == "a" && c.link == "":
+		want = "href"
+	default:
+		return
+	}
+
+	for hasAttr {
+		var key, val []byte
+		key, val, hasAttr = tokenizer.TagAttr()
+		if string(key) != want {
+			continue
+		}
+		if want == "src" {
+			c.image = string(val)
+		} else {
+			c.link = string(val)
+		}
+		return
+	}
+}
+
+// tagAttr returns the value of the attribute of the current tag, empty if it has none.
+func tagAttr(tokenizer *html.Tokenizer, hasAttr bool, name string) string {
+	for hasAttr {
+		var key, val []byte
+		key, val, hasAttr = tokenizer.TagAttr()
+		if string(key) == name {
+			return string(val)
+		}
+	}
+
+	return ""
+}
+
+// endStreamTable parses the last row of the table.
+func (p *Parser) endStreamTable(ctx context.Context, table *streamTable) {
+	p.endStreamRow(ctx, table)
+	if table.columns == nil && table.rows > 0 {
+		table.columns = p.mapColumns(ctx, p.tables[table.matched[0]].Name, nil)
+	}
+}
+
+// endStreamRow resolves the columns from the first row of the table or converts the row into a product
+// of every table the element is matched by. The first error of emit is kept in the table.
+func (p *Parser) endStreamRow(ctx context.Context, table *streamTable) {
+	if !table.inRow || table.err != nil {
+		return
+	}
+	table.inRow, table.cell = false, -1
+	table.rows++
+
+	if table.columns == nil {
+		p.resolveStreamColumns(ctx, table)
+	}
+	if table.rowHead || table.foot {
+		return
+	}
+
+	cells := table.dataCells()
+	switch width := table.columns.width(); {
+	case len(cells) == 0:
+		return
+	case len(cells) < width:
+		p.log.WarnContext(ctx, "table row has insufficient cells", "index", table.rows-1, "length", len(cells))
+		return
+	}
+
+	for _, idx := range table.matched {
+		product := models.Product{
+			Model:      streamFieldValue(table, cells, FieldModel),
+			Type:       streamFieldValue(table, cells, FieldType),
+			Quantity:   streamFieldValue(table, cells, FieldQuantity),
+			ImageURL:   streamFieldValue(table, cells, FieldImage),
+			Price:      streamFieldValue(table, cells, FieldPrice),
+			ProductURL: streamFieldValue(table, cells, FieldURL),
+			Category:   p.tables[idx].Name,
+			Extras: p.extraValues(func(field string) string {
+				return streamFieldValue(table, cells, field)
+			}),
+		}
+		p.computer.Apply(&product)
+		p.logProduct(ctx, product)
+		if table.err = table.emit(idx, product); table.err != nil {
+			return
+		}
+	}
+}
+
+// resolveStreamColumns maps the table columns by the header texts of its first row: all cells of a
+// thead row or the th cells of a body row.
+func (p *Parser) resolveStreamColumns(ctx context.Context, table *streamTable) {
+	var header []string
+	for _, cell := range table.cells[:table.count] {
+		if table.rowHead || cell.header {
+			header = append(header, string(cell.text))
+		}
+	}
+
+	// The columns are the same for every matched table, the name of the first one is used in warnings.
+	table.columns = p.mapColumns(ctx, p.tables[table.matched[0]].Name, header)
+}
+
+// streamFieldValue returns the value of a product field in the row cells, like fieldValue does
+// in the document tree.
+func streamFieldValue(table *streamTable, cells []*streamCell, field string) string {
+	cell := func(field string) *streamCell {
+		if idx, ok := table.columns[field]; ok {
+			return cells[idx]
+		}
+		return nil
+	}
+
+	switch field {
+	case FieldImage:
+		image := cell(field)
+		if image == nil {
+			return ""
+		}
+		if text := bytes.TrimSpace(image.text); len(text) > 0 {
+			return string(text)
+		}
+		return table.resolveURL(strings.TrimSpace(image.image))
+	case FieldURL:
+		link := cell(field)
+		if link == nil {
+			link = cell(FieldModel)
+		}
+		if link != nil && strings.TrimSpace(link.link) != "" {
+			return table.resolveURL(strings.TrimSpace(link.link))
+		}
+		return rowAnchor(table.resolveURL, table.rowID)
+	default:
+		if value := cell(field); value != nil {
+			return string(bytes.TrimSpace(value.text))
+		}
+		return ""
+	}
+}
+
+// warnMissingTables warns about the named tables that weren't found on the page.
+func (p *Parser) warnMissingTables(ctx context.Context, found []bool) {
+	for idx, table := range p.tables {
+		if !found[idx] && table.Name != "" {
+			p.log.WarnContext(ctx, "table not found on the page", "table", table.Name, "selector", table.Selector)
+		}
+	}
+}
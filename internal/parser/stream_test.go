@@ -0,0 +1,230 @@
+package parser_test
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Tests for the streaming parser
+// =============================================================================
+
+func TestParseTableResponse_Streaming(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tables := []parser.Table{
+		{Name: "new", Selector: "table#new.table-bordered"},
+		{Name: "used", Selector: ".used"},
+		{Name: "missing", Selector: "#missing"},
+	}
+
+	testCases := []struct {
+		name string
+		html string
+		opts []parser.Option
+	}{
+		{
+			name: "header in thead and links",
+			html: `<table id="new" class="table table-bordered">
+				<thead><tr><th>Price</th><th>Model</th><th>Photo</th><th>Qty</th></tr></thead>
+				<tbody>
+					<tr>
+						<td> 100 </td><td><a href="/p/a1">A1 <b>Pro</b></a></td><td><img src="a1.png"></td><td>2</td>
+					</tr>
+					<tr><td>200</td><td>B&amp;2</td><td></td><td>&gt; 5</td></tr>
+					<tr><td>short</td></tr>
+				</tbody>
+				<tfoot><tr><td>Total</td><td></td><td></td><td>7</td></tr></tfoot>
+			</table>`,
+			opts: []parser.Option{parser.WithTables(tables)},
+		},
+		{
+			name: "header row of th cells without sections",
+			html: `<p>intro</p><table class="used">
+				<tr><th>Model</th><th>Type</th><th>Price</th><th>Link</th></tr>
+				<tr><td>C3</td><td>Diver</td><td>300</td><td><a href="https://example.com/c3">buy</a></td></tr>
+				<tr><td>D4</td><td>Dress</td><td>400</td><td></td></tr>
+			</table>`,
+			opts: []parser.Option{parser.WithTables(tables)},
+		},
+		{
+			name: "same table matched twice and implied end tags",
+			html: `<table id="new" class="table-bordered used">
+				<tbody><tr><td>E5<td>Pilot<td>1<td>e5.png<td>500
+				<tr><td>F6<td>Field<td>2<td>f6.png<td>600
+			</table>`,
+			opts: []parser.Option{parser.WithTables(tables)},
+		},
+		{
+			name: "extra fields",
+			html: `<table class="table-bordered">
+				<tr><th>Model</th><th>Warranty</th><th>Price</th></tr>
+				<tr><td>J9</td><td> 2 years </td><td>900</td></tr>
+				<tr><td>K1</td><td></td><td>100</td></tr>
+			</table>`,
+			opts: []parser.Option{parser.WithExtraFields(map[string][]string{"warranty": nil})},
+		},
+		{
+			name: "row anchors",
+			html: `<table class="table-bordered">
+				<tr><th>Model</th><th>Type</th><th>Price</th></tr>
+				<tr id="l2"><td>L2</td><td>Diver</td><td>200</td></tr>
+				<tr id="m3"><td><a href="/p/m3">M3</a></td><td>Pilot</td><td>300</td></tr>
+			</table>`,
+		},
+		{
+			name: "default table with the index layout",
+			html: `<table class="table-bordered"><tbody>
+				<tr><td>Model A</td><td>Type A</td><td>5</td><td>url_a</td><td>100.00</td></tr>
+			</tbody></table><table class="other"><tr><td>ignored</td></tr></table>`,
+		},
+		{
+			name: "unsupported selector falls back to the document tree",
+			html: `<div id="stock"><table>
+				<tr><td>G7</td><td>Racing</td><td>3</td><td></td><td>700</td></tr>
+			</table></div>`,
+			opts: []parser.Option{parser.WithTables([]parser.Table{{Name: "stock", Selector: "#stock table"}})},
+		},
+		{
+			name: "column selectors fall back to the document tree",
+			html: `<table class="table-bordered">
+				<tr><td>H8</td><td data-type="Smart">?</td><td>4</td><td></td><td>800</td></tr>
+			</table>`,
+			opts: []parser.Option{
+				parser.WithColumnSelectors(map[string]string{"type": "td:nth-child(2)@data-type"}),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree := parser.NewParser(logger, "https://example.com/catalog/", tc.opts...)
+			stream := parser.NewParser(
+				logger, "https://example.com/catalog/", append(tc.opts, parser.WithStreaming())...,
+			)
+
+			want, err := tree.ParseTableResponse(t.Context(), io.NopCloser(strings.NewReader(tc.html)))
+			require.NoError(t, err)
+			require.NotEmpty(t, want)
+
+			got, err := stream.ParseTableResponse(t.Context(), io.NopCloser(strings.NewReader(tc.html)))
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestParseTableResponse_StreamingValues(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	p := parser.NewParser(logger, "https://example.com/catalog/", parser.WithStreaming())
+
+	products, err := p.ParseTableResponse(t.Context(), io.NopCloser(strings.NewReader(`
+		<table class="table-bordered">
+			<thead><tr><th>Model</th><th>Type</th><th>Quantity</th><th>Image</th><th>Price</th></tr></thead>
+			<tbody><tr>
+				<td><a href="a1">A1</a></td><td>Diver</td><td>2</td><td><img src="/img/a1.png"></td><td>100</td>
+			</tr></tbody>
+		</table>`)))
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.Product{{
+		Model:      "A1",
+		Type:       "Diver",
+		Quantity:   "2",
+		ImageURL:   "https://example.com/img/a1.png",
+		Price:      "100",
+		ProductURL: "https://example.com/catalog/a1",
+	}}, products)
+}
+
+// =============================================================================
+// Benchmarks
+// =============================================================================
+
+// benchmarkPage returns a page with a product table of the given number of rows.
+func benchmarkPage(rows int) string {
+	var page strings.Builder
+	page.WriteString(`<html><body><table class="table-bordered"><thead><tr>` +
+		`<th>Model</th><th>Type</th><th>Quantity</th><th>Image</th><th>Price</th></tr></thead><tbody>`)
+	for idx := range rows {
+		fmt.Fprintf(&page, `<tr><td><a href="/p/%[1]d">Model %[1]d</a></td><td>Diver</td><td>%[2]d</td>`+
+			`<td><img src="/img/%[1]d.png"></td><td>%[3]d.00</td></tr>`, idx, idx%10, 100+idx)
+	}
+	page.WriteString(`</tbody></table></body></html>`)
+
+	return page.String()
+}
+
+func BenchmarkParseTableResponse(b *testing.B) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	page := benchmarkPage(20_000)
+
+	for _, bc := range []struct {
+		name string
+		opts []parser.Option
+	}{
+		{"document", nil},
+		{"streaming", []parser.Option{parser.WithStreaming()}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			p := parser.NewParser(logger, "https://example.com/", bc.opts...)
+			b.ReportAllocs()
+			b.SetBytes(int64(len(page)))
+
+			for b.Loop() {
+				if _, err := p.ParseTableResponse(b.Context(), io.NopCloser(strings.NewReader(page))); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamTableResponse(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tables := []parser.Table{{Name: "new", Selector: "#new"}, {Name: "used", Selector: "#used"}}
+	page := `<table id="used"><tr><td>B2</td><td>Dress</td><td>1</td><td></td><td>200</td></tr></table>
+		<table id="new"><tr><td>A1</td><td>Diver</td><td>2</td><td></td><td>100</td></tr></table>`
+
+	for _, tc := range []struct {
+		name string
+		opts []parser.Option
+		want []string
+	}{
+		{"streaming in the order of the page", []parser.Option{parser.WithStreaming()}, []string{"B2", "A1"}},
+		{"document tree in the order of the tables", nil, []string{"A1", "B2"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := parser.NewParser(logger, "", append(tc.opts, parser.WithTables(tables))...)
+
+			var streamed []string
+			err := p.StreamTableResponse(t.Context(), strings.NewReader(page), func(product models.Product) error {
+				streamed = append(streamed, product.Model)
+				return nil
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, streamed)
+		})
+
+		t.Run(tc.name+" stops at the first error", func(t *testing.T) {
+			p := parser.NewParser(logger, "", append(tc.opts, parser.WithTables(tables))...)
+
+			calls := 0
+			err := p.StreamTableResponse(t.Context(), strings.NewReader(page), func(models.Product) error {
+				calls++
+				return assert.AnError
+			})
+
+			require.ErrorIs(t, err, assert.AnError)
+			assert.Equal(t, 1, calls)
+		})
+	}
+}
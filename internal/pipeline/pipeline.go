@@ -0,0 +1,152 @@
+// Package pipeline lets Go callbacks take part in the checks of a target: the hooks rewrite the requests
+// of the page before they're sent, drop or rewrite the products parsed from it, and enrich the detected
+// changes or cancel their notification. A hook returning ErrVeto cancels the step it's called at.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// ErrVeto is returned, possibly wrapped, by a hook canceling the step it's called at. A vetoed fetch or
+// parse cancels the check, which isn't counted as a failure of the target, a vetoed notification keeps
+// the changes from being notified.
+var ErrVeto = errors.New("vetoed by a pipeline hook")
+
+// PreFetch is called with every request of the page before it's sent, e.g. the request of the page and
+// the one of its iframe, and may rewrite it.
+type PreFetch func(ctx context.Context, req *http.Request) error
+
+// PostParse is called with the valid products parsed from the page and returns the ones kept, which it
+// may rewrite. When the memory is bounded the products are passed one at a time.
+type PostParse func(ctx context.Context, products []models.Product) ([]models.Product, error)
+
+// PreNotify is called with the changes detected by a check before they're queued for notification, and
+// may enrich them. The changes are stored in the state even if it vetoes their notification.
+type PreNotify func(ctx context.Context, changes *models.Changes) error
+
+// Hooks are the callbacks registered at the hook points of the checks, they're called in the order they
+// were registered. A nil Hooks has no callbacks.
+type Hooks struct {
+	mu        sync.RWMutex
+	preFetch  []PreFetch
+	postParse []PostParse
+	preNotify []PreNotify
+}
+
+// New returns the hooks without callbacks.
+func New() *Hooks {
+	return &Hooks{}
+}
+
+// OnPreFetch registers the callback called before every request of the page.
+func (h *Hooks) OnPreFetch(hook PreFetch) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.preFetch = append(h.preFetch, hook)
+}
+
+// OnPostParse registers the callback called with the products parsed from the page.
+func (h *Hooks) OnPostParse(hook PostParse) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.postParse = append(h.postParse, hook)
+}
+
+// OnPreNotify registers the callback called with the changes detected before they're notified.
+func (h *Hooks) OnPreNotify(hook PreNotify) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.preNotify = append(h.preNotify, hook)
+}
+
+// PreFetch calls the pre-fetch callbacks with the request, the first error stops them.
+func (h *Hooks) PreFetch(ctx context.Context, req *http.Request) error {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.RLock()
+	hooks := h.preFetch
+	h.mu.RUnlock()
+	for _, hook := range hooks {
+		if err := hook(ctx, req); err != nil {
+			return fmt.Errorf("pre-fetch hook: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// PostParse passes the products through the post-parse callbacks and returns the ones kept, the first
+// error stops them.
+func (h *Hooks) PostParse(ctx context.Context, products []models.Product) ([]models.Product, error) {
+	if h == nil {
+		return products, nil
+	}
+
+	h.mu.RLock()
+	hooks := h.postParse
+	h.mu.RUnlock()
+	for _, hook := range hooks {
+		var err error
+		if products, err = hook(ctx, products); err != nil {
+			return nil, fmt.Errorf("post-parse hook: %w", err)
+		}
+	}
+
+	return products, nil
+}
+
+// PreNotify calls the pre-notify callbacks with the changes, the first error stops them.
+func (h *Hooks) PreNotify(ctx context.Context, changes *models.Changes) error {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.RLock()
+	hooks := h.preNotify
+	h.mu.RUnlock()
+	for _, hook := range hooks {
+		if err := hook(ctx, changes); err != nil {
+			return fmt.Errorf("pre-notify hook: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// hookTransport calls the pre-fetch callbacks before sending a request.
+type hookTransport struct {
+	next  http.RoundTripper
+	hooks *Hooks
+}
+
+// Transport returns a transport calling the pre-fetch callbacks of the hooks with a copy of every
+// request sent through next, before it's sent.
+func Transport(next http.RoundTripper, hooks *Hooks) http.RoundTripper {
+	if hooks == nil {
+		return next
+	}
+
+	return &hookTransport{next: next, hooks: hooks}
+}
+
+// RoundTrip sends the copy of the request the pre-fetch callbacks rewrote, the request itself isn't
+// modified.
+func (t *hookTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if err := t.hooks.PreFetch(req.Context(), req); err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+
+	return t.next.RoundTrip(req) //nolint:wrapcheck // the transport passes the error of next on unchanged.
+}
@@ -0,0 +1,118 @@
+package pipeline_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHooks_Nil(t *testing.T) {
+	var hooks *pipeline.Hooks
+	products := []models.Product{{Model: "A1"}}
+
+	kept, err := hooks.PostParse(t.Context(), products)
+
+	require.NoError(t, err)
+	assert.Equal(t, products, kept)
+	require.NoError(t, hooks.PreFetch(t.Context(), httptest.NewRequest(http.MethodGet, "/", nil)))
+	require.NoError(t, hooks.PreNotify(t.Context(), &models.Changes{}))
+	assert.Equal(t, http.DefaultTransport, pipeline.Transport(http.DefaultTransport, nil))
+}
+
+func TestHooks_PostParse(t *testing.T) {
+	// Arrange
+	hooks := pipeline.New()
+	hooks.OnPostParse(func(_ context.Context, products []models.Product) ([]models.Product, error) {
+		return products[1:], nil
+	})
+	hooks.OnPostParse(func(_ context.Context, products []models.Product) ([]models.Product, error) {
+		products[0].Price = "90"
+		return products, nil
+	})
+
+	// Act
+	kept, err := hooks.PostParse(t.Context(), []models.Product{{Model: "A1"}, {Model: "B2", Price: "100"}})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []models.Product{{Model: "B2", Price: "90"}}, kept, "the hooks run in the order registered")
+
+	hooks.OnPostParse(func(context.Context, []models.Product) ([]models.Product, error) {
+		return nil, fmt.Errorf("catalog is frozen: %w", pipeline.ErrVeto)
+	})
+	_, err = hooks.PostParse(t.Context(), []models.Product{{Model: "A1"}, {Model: "B2"}})
+	require.ErrorIs(t, err, pipeline.ErrVeto)
+}
+
+func TestHooks_PreNotify(t *testing.T) {
+	// Arrange
+	hooks := pipeline.New()
+	hooks.OnPreNotify(func(_ context.Context, changes *models.Changes) error {
+		changes.Added[0].Extras = map[string]string{"source": "hook"}
+		return nil
+	})
+	changes := &models.Changes{Added: []models.Product{{Model: "A1"}}}
+
+	// Act
+	err := hooks.PreNotify(t.Context(), changes)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "hook", changes.Added[0].Extras["source"])
+}
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("X-Session")))
+	}))
+	t.Cleanup(server.Close)
+
+	t.Run("rewrites the request", func(t *testing.T) {
+		// Arrange
+		hooks := pipeline.New()
+		hooks.OnPreFetch(func(_ context.Context, req *http.Request) error {
+			req.Header.Set("X-Session", "token")
+			return nil
+		})
+		client := &http.Client{Transport: pipeline.Transport(http.DefaultTransport, hooks)}
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		// Act
+		resp, err := client.Do(req)
+
+		// Assert
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "token", string(body))
+		assert.Empty(t, req.Header.Get("X-Session"), "the request itself isn't modified")
+	})
+
+	t.Run("veto", func(t *testing.T) {
+		// Arrange
+		hooks := pipeline.New()
+		hooks.OnPreFetch(func(context.Context, *http.Request) error { return pipeline.ErrVeto })
+		client := &http.Client{Transport: pipeline.Transport(http.DefaultTransport, hooks)}
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		// Act
+		resp, err := client.Do(req)
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+
+		// Assert
+		require.ErrorIs(t, err, pipeline.ErrVeto)
+	})
+}
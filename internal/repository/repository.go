@@ -2,4 +2,16 @@ package repository
 
 import "errors"
 
-var ErrStateNotFound = errors.New("state not found")
+var (
+	ErrStateNotFound    = errors.New("state not found")
+	ErrTenantNotFound   = errors.New("tenant not found")
+	ErrTenantExists     = errors.New("tenant already exists")
+	ErrTokenNotFound    = errors.New("token not found")
+	ErrTargetExists     = errors.New("target already exists")
+	ErrTargetNotFound   = errors.New("target not found")
+	ErrQueryTimeout     = errors.New("query timed out")
+	ErrArtifactNotFound = errors.New("artifact not found")
+	// ErrStateConflict is returned when another connection holds the database, e.g. the checker process
+	// writing the state while the bot process reads it, the query may succeed when it's retried.
+	ErrStateConflict = errors.New("state conflict")
+)
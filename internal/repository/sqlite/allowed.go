@@ -0,0 +1,67 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// AllowChat adds the chat ID to the allowed chats table.
+func (r *Repository) AllowChat(ctx context.Context, chatID int64) (err error) {
+	const op = "repository.sqlite.AllowChat"
+	ctx, done := r.observe(ctx, "AllowChat")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(
+		ctx,
+		"INSERT OR IGNORE INTO allowed_chats (tenant_id, chat_id) VALUES (?, ?)",
+		r.tenant,
+		chatID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DisallowChat deletes the chat ID from the allowed chats table.
+func (r *Repository) DisallowChat(ctx context.Context, chatID int64) (err error) {
+	const op = "repository.sqlite.DisallowChat"
+	ctx, done := r.observe(ctx, "DisallowChat")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(ctx, "DELETE FROM allowed_chats WHERE chat_id = ? AND tenant_id = ?", chatID, r.tenant)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetAllowedChats returns a slice of all chat IDs allowed at runtime.
+func (r *Repository) GetAllowedChats(ctx context.Context) (_ []int64, err error) {
+	const opn = "repository.sqlite.GetAllowedChats"
+	ctx, done := r.observe(ctx, "GetAllowedChats")
+	defer func() { err = done(err) }()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT chat_id FROM allowed_chats WHERE tenant_id = ?", r.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+	defer rows.Close()
+
+	var chatIDs []int64
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan chat_id: %w", opn, err)
+		}
+		chatIDs = append(chatIDs, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	return chatIDs, nil
+}
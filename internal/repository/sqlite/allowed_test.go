@@ -0,0 +1,76 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_AllowedChats(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+
+	chatIDs, err := repo.GetAllowedChats(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, chatIDs)
+
+	require.NoError(t, repo.AllowChat(ctx, -100))
+	require.NoError(t, repo.AllowChat(ctx, 200))
+	require.NoError(t, repo.AllowChat(ctx, 200)) // Allowing twice is a no-op.
+
+	chatIDs, err = repo.GetAllowedChats(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{-100, 200}, chatIDs)
+
+	require.NoError(t, repo.DisallowChat(ctx, -100))
+
+	chatIDs, err = repo.GetAllowedChats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{200}, chatIDs)
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestAllowedChats_Failures(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: allow chat", func(t *testing.T) {
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT OR IGNORE INTO allowed_chats").WillReturnError(assert.AnError)
+
+		err := repo.AllowChat(ctx, 1)
+
+		require.ErrorContains(t, err, "repository.sqlite.AllowChat")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: disallow chat", func(t *testing.T) {
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("DELETE FROM allowed_chats").WillReturnError(assert.AnError)
+
+		err := repo.DisallowChat(ctx, 1)
+
+		require.ErrorContains(t, err, "repository.sqlite.DisallowChat")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: get allowed chats", func(t *testing.T) {
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("SELECT chat_id FROM allowed_chats").WillReturnError(assert.AnError)
+
+		_, err := repo.GetAllowedChats(ctx)
+
+		require.ErrorContains(t, err, "repository.sqlite.GetAllowedChats")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+)
+
+// RecordDiffArtifacts stores the renderings of the changes of a check, replacing the ones of the same run
+// and format.
+func (r *Repository) RecordDiffArtifacts(ctx context.Context, artifacts []models.DiffArtifact) (err error) {
+	const opn = "repository.sqlite.RecordDiffArtifacts"
+	ctx, done := r.observe(ctx, "RecordDiffArtifacts")
+	defer func() { err = done(err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil) //nolint:varnamelen // tx its a default naming for transaction
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", opn, err)
+	}
+	defer tx.Rollback() //nolint:errcheck // The rollback after a commit does nothing.
+
+	for _, artifact := range artifacts {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT OR REPLACE INTO run_artifacts (tenant_id, run_id, format, created_at, content)
+			VALUES (?, ?, ?, ?, ?)`,
+			r.tenant,
+			artifact.RunID,
+			artifact.Format,
+			artifact.CreatedAt.UTC(),
+			artifact.Content,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: failed to insert artifact: %w", opn, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", opn, err)
+	}
+
+	return nil
+}
+
+// GetDiffArtifact returns the rendering of the changes of the run in the format, or
+// repository.ErrArtifactNotFound.
+func (r *Repository) GetDiffArtifact(ctx context.Context, runID, format string) (_ *models.DiffArtifact, err error) {
+	const opn = "repository.sqlite.GetDiffArtifact"
+	ctx, done := r.observe(ctx, "GetDiffArtifact")
+	defer func() { err = done(err) }()
+
+	artifact := models.DiffArtifact{RunID: runID, Format: format}
+	err = r.db.QueryRowContext(
+		ctx,
+		"SELECT created_at, content FROM run_artifacts WHERE tenant_id = ? AND run_id = ? AND format = ?",
+		r.tenant, runID, format,
+	).Scan(&artifact.CreatedAt, &artifact.Content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrArtifactNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get artifact: %w", opn, err)
+	}
+
+	return &artifact, nil
+}
@@ -0,0 +1,87 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_DiffArtifacts(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	createdAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// Arrange
+	require.NoError(t, repo.RecordDiffArtifacts(ctx, []models.DiffArtifact{
+		{RunID: "run-1", Format: "md", CreatedAt: createdAt, Content: []byte("# Changes")},
+		{RunID: "run-1", Format: "csv", CreatedAt: createdAt, Content: []byte("kind\n")},
+	}))
+	require.NoError(t, repo.RecordDiffArtifacts(ctx, []models.DiffArtifact{
+		{RunID: "run-1", Format: "md", CreatedAt: createdAt, Content: []byte("# Changes of run-1")},
+	}))
+
+	// Act
+	artifact, err := repo.GetDiffArtifact(ctx, "run-1", "md")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []byte("# Changes of run-1"), artifact.Content, "the artifact is replaced")
+	assert.True(t, createdAt.Equal(artifact.CreatedAt))
+
+	_, err = repo.GetDiffArtifact(ctx, "run-1", "html")
+	require.ErrorIs(t, err, repository.ErrArtifactNotFound)
+	_, err = repo.ForTenant("acme").GetDiffArtifact(ctx, "run-1", "md")
+	require.ErrorIs(t, err, repository.ErrArtifactNotFound, "the artifacts are scoped by tenant")
+
+	deleted, err := repo.PruneHistory(ctx, createdAt.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	_, err = repo.GetDiffArtifact(ctx, "run-1", "csv")
+	require.ErrorIs(t, err, repository.ErrArtifactNotFound, "the artifacts are pruned with the history")
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestRecordDiffArtifacts(t *testing.T) {
+	t.Run("error: insert artifact", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT OR REPLACE INTO run_artifacts").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		err := repo.RecordDiffArtifacts(t.Context(), []models.DiffArtifact{{RunID: "run-1", Format: "md"}})
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.RecordDiffArtifacts: failed to insert artifact")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetDiffArtifact(t *testing.T) {
+	t.Run("error: query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("SELECT created_at, content FROM run_artifacts").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.GetDiffArtifact(t.Context(), "run-1", "md")
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.GetDiffArtifact: failed to get artifact")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// RecordCheckError stores the error of a failed check as the last one of the target. The time the failures
+// started is kept while they go on, the class, the message and the time of the last failure are replaced.
+func (r *Repository) RecordCheckError(ctx context.Context, class, message string, at time.Time) (err error) {
+	const opn = "repository.sqlite.RecordCheckError"
+	ctx, done := r.observe(ctx, "RecordCheckError")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO check_errors (tenant_id, class, message, failing_since, last_failure, failures)
+		VALUES (?1, ?2, ?3, ?4, ?4, 1)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			class = excluded.class,
+			message = excluded.message,
+			failing_since = CASE WHEN failures = 0 THEN excluded.failing_since ELSE failing_since END,
+			last_failure = excluded.last_failure,
+			failures = failures + 1`,
+		r.tenant,
+		class,
+		message,
+		at.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opn, err)
+	}
+
+	return nil
+}
+
+// ResetCheckErrors ends the failures in a row of the target after a successful check, its last error is kept.
+func (r *Repository) ResetCheckErrors(ctx context.Context) (err error) {
+	const opn = "repository.sqlite.ResetCheckErrors"
+	ctx, done := r.observe(ctx, "ResetCheckErrors")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(
+		ctx, "UPDATE check_errors SET failures = 0 WHERE tenant_id = ? AND failures > 0", r.tenant,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opn, err)
+	}
+
+	return nil
+}
+
+// GetCheckError returns the last failed check of the target, nil if none failed yet.
+func (r *Repository) GetCheckError(ctx context.Context) (_ *models.CheckError, err error) {
+	const opn = "repository.sqlite.GetCheckError"
+	ctx, done := r.observe(ctx, "GetCheckError")
+	defer func() { err = done(err) }()
+
+	checkErr, err := r.getCheckError(ctx, r.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+
+	return checkErr, nil
+}
+
+// getCheckError returns the last failed check stored in the scope, nil if there is none.
+func (r *Repository) getCheckError(ctx context.Context, scope string) (*models.CheckError, error) {
+	var checkErr models.CheckError
+	err := r.db.QueryRowContext(
+		ctx,
+		"SELECT class, message, failing_since, last_failure, failures FROM check_errors WHERE tenant_id = ?",
+		scope,
+	).Scan(&checkErr.Class, &checkErr.Message, &checkErr.FailingSince, &checkErr.LastFailure, &checkErr.Failures)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil //nolint:nilnil // No check of the target failed yet.
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get check error: %w", err)
+	}
+
+	return &checkErr, nil
+}
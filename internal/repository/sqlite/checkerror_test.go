@@ -0,0 +1,93 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_CheckErrors(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	start := time.Date(2025, 3, 1, 2, 14, 0, 0, time.UTC)
+
+	checkErr, err := repo.GetCheckError(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, checkErr, "no error before the first failed check")
+
+	// Act: an outage of two failed checks, a recovery and a new failure.
+	require.NoError(t, repo.RecordCheckError(ctx, "fetch", "TLS handshake timeout", start))
+	require.NoError(t, repo.RecordCheckError(ctx, "parse", "no products found", start.Add(time.Hour)))
+
+	// Assert
+	checkErr, err = repo.GetCheckError(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &models.CheckError{
+		Class:        "parse",
+		Message:      "no products found",
+		FailingSince: start,
+		LastFailure:  start.Add(time.Hour),
+		Failures:     2,
+	}, checkErr)
+	assert.True(t, checkErr.Failing())
+
+	require.NoError(t, repo.ResetCheckErrors(ctx))
+	checkErr, err = repo.GetCheckError(ctx)
+	require.NoError(t, err)
+	assert.False(t, checkErr.Failing())
+	assert.Equal(t, "no products found", checkErr.Message, "the last error is kept after a recovery")
+
+	require.NoError(t, repo.RecordCheckError(ctx, "fetch", "status 503", start.Add(2*time.Hour)))
+	checkErr, err = repo.GetCheckError(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, checkErr.Failures)
+	assert.True(t, start.Add(2*time.Hour).Equal(checkErr.FailingSince), "a new outage starts")
+
+	checkErr, err = repo.ForTarget("outlet").GetCheckError(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, checkErr, "the errors are scoped by target")
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestRecordCheckError(t *testing.T) {
+	t.Run("error: exec", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT INTO check_errors").WillReturnError(assert.AnError)
+
+		// Act
+		err := repo.RecordCheckError(t.Context(), "fetch", "timeout", time.Now())
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.RecordCheckError")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetCheckError(t *testing.T) {
+	t.Run("error: query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("SELECT class, message, failing_since, last_failure, failures FROM check_errors").
+			WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.GetCheckError(t.Context())
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.GetCheckError")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
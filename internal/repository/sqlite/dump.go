@@ -0,0 +1,331 @@
+package sqlite
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DumpVersion is the version of the dump format written by Dump.
+const DumpVersion = 1
+
+// dumpManifest is the name of the archive entry describing a dump, it precedes the table entries.
+const dumpManifest = "manifest.json"
+
+// timestampLayout is the layout the driver stores time values with, dumped times keep it so they
+// compare the same way after loading.
+const timestampLayout = "2006-01-02 15:04:05.999999999-07:00"
+
+var (
+	// ErrUnsupportedDump is returned when loading a dump of a newer format or another schema version.
+	ErrUnsupportedDump = errors.New("unsupported dump")
+	// ErrInvalidDump is returned when a dump archive is malformed.
+	ErrInvalidDump = errors.New("invalid dump")
+	// ErrDatabaseNotEmpty is returned when loading a dump into a database that already has data.
+	ErrDatabaseNotEmpty = errors.New("the database isn't empty")
+)
+
+// Manifest describes a dump: the versions it was written with and the number of rows of every table.
+type Manifest struct {
+	Version       int              `json:"version"`
+	SchemaVersion int              `json:"schema_version"`
+	CreatedAt     time.Time        `json:"created_at"`
+	Tables        map[string]int64 `json:"tables"`
+}
+
+// dumpTables are the tables of a dump in the order they are written and loaded.
+func dumpTables() []string {
+	return []string{
+		"tenants", "targets", "api_tokens", "page_state", "products", "subscriptions", "allowed_chats",
+		"chat_settings", "fetches", "changes", "subscription_events", "chat_activity", "notification_products",
+		"wishlists", "wishlist_items", "heartbeats", "outbox", "runs", "users", "notification_variants",
+		"notification_feedback", "alert_rules", "diff_cache", "unreachable_chats", "page_snapshots", "page_diffs",
+		"run_artifacts", "check_errors",
+	}
+}
+
+// Dump writes the data of all tenants as a gzip-compressed tar archive. The archive holds the manifest and
+// a JSON Lines file per table with a row object keyed by column name per line, so it doesn't depend on
+// the storage backend it's loaded into.
+func (r *Repository) Dump(ctx context.Context, w io.Writer) error {
+	const opn = "repository.sqlite.Dump"
+
+	manifest := Manifest{Version: DumpVersion, CreatedAt: time.Now().UTC(), Tables: make(map[string]int64)}
+	if err := r.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&manifest.SchemaVersion); err != nil {
+		return fmt.Errorf("%s: failed to get schema version: %w", opn, err)
+	}
+
+	// The manifest comes first but needs the row counts, so the tables are encoded before writing.
+	files := make(map[string][]byte)
+	for _, table := range dumpTables() {
+		var buf bytes.Buffer
+		count, err := r.dumpTable(ctx, table, &buf)
+		if err != nil {
+			return fmt.Errorf("%s: failed to dump %s: %w", opn, table, err)
+		}
+		files[table] = buf.Bytes()
+		manifest.Tables[table] = count
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%s: failed to encode manifest: %w", opn, err)
+	}
+
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+	if err = writeArchiveFile(archive, dumpManifest, manifestData); err != nil {
+		return fmt.Errorf("%s: %w", opn, err)
+	}
+	for _, table := range dumpTables() {
+		if err = writeArchiveFile(archive, table+".jsonl", files[table]); err != nil {
+			return fmt.Errorf("%s: %w", opn, err)
+		}
+	}
+	if err = archive.Close(); err != nil {
+		return fmt.Errorf("%s: failed to close archive: %w", opn, err)
+	}
+	if err = gz.Close(); err != nil {
+		return fmt.Errorf("%s: failed to compress archive: %w", opn, err)
+	}
+
+	return nil
+}
+
+// dumpTable writes the rows of a table as JSON Lines and returns how many were written.
+func (r *Repository) dumpTable(ctx context.Context, table string, w io.Writer) (int64, error) {
+	// Table names come from a fixed list.
+	rows, err := r.db.QueryContext(ctx, "SELECT * FROM "+table)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query rows: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get columns: %w", err)
+	}
+
+	encoder := json.NewEncoder(w)
+	var count int64
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for idx := range values {
+			pointers[idx] = &values[idx]
+		}
+		if err = rows.Scan(pointers...); err != nil {
+			return 0, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		row := make(map[string]any, len(columns))
+		for idx, column := range columns {
+			switch value := values[idx].(type) {
+			case time.Time:
+				row[column] = value.Format(timestampLayout)
+			case []byte:
+				row[column] = string(value)
+			default:
+				row[column] = value
+			}
+		}
+		if err = encoder.Encode(row); err != nil {
+			return 0, fmt.Errorf("failed to encode row: %w", err)
+		}
+		count++
+	}
+
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return count, nil
+}
+
+// writeArchiveFile adds a file to the archive.
+func writeArchiveFile(archive *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: time.Now()}
+	if err := archive.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s header: %w", name, err)
+	}
+	if _, err := archive.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// Load restores a dump written by Dump into an empty database in a single transaction. The dump must
+// have the schema version of the database, so both ends of a migration need to run the same release.
+func (r *Repository) Load(ctx context.Context, rd io.Reader) (*Manifest, error) {
+	const opn = "repository.sqlite.Load"
+
+	gz, err := gzip.NewReader(rd)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %w", opn, ErrInvalidDump, err)
+	}
+	defer gz.Close()
+	archive := tar.NewReader(gz)
+
+	manifest, err := r.readManifest(ctx, archive)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", opn, err)
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after a successful commit only returns sql.ErrTxDone.
+
+	for _, table := range dumpTables() {
+		var count int64
+		if err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&count); err != nil {
+			return nil, fmt.Errorf("%s: failed to count %s: %w", opn, table, err)
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("%s: %w: %s has %d rows", opn, ErrDatabaseNotEmpty, table, count)
+		}
+	}
+
+	for {
+		header, nextErr := archive.Next()
+		if errors.Is(nextErr, io.EOF) {
+			break
+		}
+		if nextErr != nil {
+			return nil, fmt.Errorf("%s: %w: %w", opn, ErrInvalidDump, nextErr)
+		}
+
+		table, found := strings.CutSuffix(header.Name, ".jsonl")
+		if !found || !slices.Contains(dumpTables(), table) {
+			return nil, fmt.Errorf("%s: %w: unexpected entry %q", opn, ErrInvalidDump, header.Name)
+		}
+		if err = loadTable(ctx, tx, table, archive); err != nil {
+			return nil, fmt.Errorf("%s: failed to load %s: %w", opn, table, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit transaction: %w", opn, err)
+	}
+
+	return manifest, nil
+}
+
+// readManifest reads the manifest opening the archive and checks the dump can be loaded.
+func (r *Repository) readManifest(ctx context.Context, archive *tar.Reader) (*Manifest, error) {
+	header, err := archive.Next()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDump, err)
+	}
+	if header.Name != dumpManifest {
+		return nil, fmt.Errorf("%w: the archive doesn't start with %s", ErrInvalidDump, dumpManifest)
+	}
+
+	var manifest Manifest
+	if err = json.NewDecoder(archive).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode manifest: %w", ErrInvalidDump, err)
+	}
+
+	var schemaVersion int
+	if err = r.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&schemaVersion); err != nil {
+		return nil, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	if manifest.Version > DumpVersion || manifest.SchemaVersion != schemaVersion {
+		return nil, fmt.Errorf("%w: format %d, schema %d, this build supports format %d, schema %d",
+			ErrUnsupportedDump, manifest.Version, manifest.SchemaVersion, DumpVersion, schemaVersion)
+	}
+
+	return &manifest, nil
+}
+
+// loadTable inserts the JSON Lines rows of a table. Column names are checked against the table, so
+// a crafted dump can't inject SQL.
+func loadTable(ctx context.Context, tx *sql.Tx, table string, rd io.Reader) error {
+	columns, err := tableColumns(ctx, tx, table)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bufio.NewReader(rd))
+	decoder.UseNumber()
+	for line := 1; ; line++ {
+		var row map[string]any
+		if err = decoder.Decode(&row); errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: row %d: %w", ErrInvalidDump, line, err)
+		}
+
+		names := make([]string, 0, len(row))
+		for name := range row {
+			if !slices.Contains(columns, name) {
+				return fmt.Errorf("%w: row %d: unknown column %q", ErrInvalidDump, line, name)
+			}
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		args := make([]any, 0, len(names))
+		for _, name := range names {
+			args = append(args, dumpValue(row[name]))
+		}
+		query := fmt.Sprintf("INSERT INTO %s (\"%s\") VALUES (%s)", table, strings.Join(names, `", "`),
+			strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", "))
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("row %d: %w", line, err)
+		}
+	}
+}
+
+// tableColumns returns the names of the columns of a table.
+func tableColumns(ctx context.Context, tx *sql.Tx, table string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err = rows.Scan(&column); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		columns = append(columns, column)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return columns, nil
+}
+
+// dumpValue converts a decoded JSON value to a query argument, integers stay integers.
+func dumpValue(value any) any {
+	number, ok := value.(json.Number)
+	if !ok {
+		return value
+	}
+	if integer, err := number.Int64(); err == nil {
+		return integer
+	}
+	if float, err := number.Float64(); err == nil {
+		return float
+	}
+
+	return number.String()
+}
@@ -0,0 +1,163 @@
+package sqlite_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_DumpLoad(t *testing.T) {
+	source := newTestDB(t)
+	ctx := t.Context()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	products := []models.Product{{Model: "A1", Category: "new", Type: "Diver", Quantity: "1", Price: "90"}}
+	require.NoError(t, source.UpdateState(ctx, &models.State{PageHash: "hash", Products: products}))
+	require.NoError(t, source.RecordChanges(ctx, now.Add(-time.Hour), &models.Changes{Added: products}))
+	require.NoError(t, source.RecordFetch(ctx, models.FetchRecord{FetchedAt: now, Latency: time.Second, Success: true}))
+	require.NoError(t, source.SubscribeChat(ctx, 1))
+	require.NoError(t, source.SetFilterGroup(ctx, 1, "warehouse"))
+	require.NoError(t, source.CreateTarget(ctx, models.Target{
+		Name: "outlet", URL: "https://example.com/outlet", Interval: time.Hour, CreatedAt: now,
+		ColumnSelectors: map[string]string{"url": "td a@href"},
+	}))
+	require.NoError(t, source.ForTenant("acme").SubscribeChat(ctx, 2))
+
+	var dump bytes.Buffer
+	require.NoError(t, source.Dump(ctx, &dump))
+
+	target := newTestDB(t)
+	manifest, err := target.Load(ctx, bytes.NewReader(dump.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, sqlite.DumpVersion, manifest.Version)
+	assert.Equal(t, int64(1), manifest.Tables["products"])
+	assert.Equal(t, int64(2), manifest.Tables["subscriptions"])
+
+	state, err := target.GetState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "hash", state.PageHash)
+	assert.Equal(t, products, state.Products)
+
+	changes, err := target.GetChanges(ctx, now.Add(-2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.True(t, now.Add(-time.Hour).Equal(changes[0].DetectedAt))
+
+	fetches, err := target.GetFetches(ctx, now.Add(-time.Minute))
+	require.NoError(t, err, "dumped times keep comparing with the stored ones")
+	require.Len(t, fetches, 1)
+	assert.Equal(t, time.Second, fetches[0].Latency)
+
+	settings, err := target.GetChatSettings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "warehouse", settings[1].FilterGroup)
+
+	stored, err := target.GetTarget(ctx, "outlet")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"url": "td a@href"}, stored.ColumnSelectors)
+
+	chats, err := target.ForTenant("acme").GetSubscribedChats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, chats)
+
+	// A dump is only loaded into an empty database.
+	_, err = target.Load(ctx, bytes.NewReader(dump.Bytes()))
+	require.ErrorIs(t, err, sqlite.ErrDatabaseNotEmpty)
+}
+
+// TestRepository_Integration_DumpCoversSchema dumps every table of the schema, the search index is rebuilt
+// from the products on load.
+func TestRepository_Integration_DumpCoversSchema(t *testing.T) {
+	ctx := t.Context()
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	source, err := sqlite.NewRepository(ctx, slog.New(slog.NewTextHandler(io.Discard, nil)), dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = source.Close() })
+
+	raw, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = raw.Close() })
+	rows, err := raw.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table'
+		AND name NOT LIKE 'sqlite\_%' ESCAPE '\' AND name NOT LIKE 'products\_fts%' ESCAPE '\'`)
+	require.NoError(t, err)
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var table string
+		require.NoError(t, rows.Scan(&table))
+		tables = append(tables, table)
+	}
+	require.NoError(t, rows.Err())
+
+	var dump bytes.Buffer
+	require.NoError(t, source.Dump(ctx, &dump))
+	manifest, err := newTestDB(t).Load(ctx, &dump)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, tables, slices.Collect(maps.Keys(manifest.Tables)))
+}
+
+func TestRepository_Integration_LoadInvalidDump(t *testing.T) {
+	ctx := t.Context()
+
+	// archive writes a dump with the manifest followed by the files given as name and content pairs.
+	archive := func(manifest sqlite.Manifest, files ...string) []byte {
+		data, err := json.Marshal(manifest)
+		require.NoError(t, err)
+		files = append([]string{"manifest.json", string(data)}, files...)
+
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		writer := tar.NewWriter(gz)
+		for idx := 0; idx < len(files); idx += 2 {
+			header := &tar.Header{Name: files[idx], Mode: 0o600, Size: int64(len(files[idx+1]))}
+			require.NoError(t, writer.WriteHeader(header))
+			_, err = writer.Write([]byte(files[idx+1]))
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+		require.NoError(t, gz.Close())
+
+		return buf.Bytes()
+	}
+
+	var current bytes.Buffer
+	require.NoError(t, newTestDB(t).Dump(ctx, &current))
+	target := newTestDB(t)
+	manifest, err := target.Load(ctx, bytes.NewReader(current.Bytes()))
+	require.NoError(t, err, "an empty dump loads")
+
+	_, err = newTestDB(t).Load(ctx, bytes.NewReader([]byte("not an archive")))
+	require.ErrorIs(t, err, sqlite.ErrInvalidDump)
+
+	newer := *manifest
+	newer.SchemaVersion++
+	_, err = newTestDB(t).Load(ctx, bytes.NewReader(archive(newer)))
+	require.ErrorIs(t, err, sqlite.ErrUnsupportedDump)
+
+	_, err = newTestDB(t).Load(ctx, bytes.NewReader(archive(*manifest,
+		"subscriptions.jsonl", `{"chat_id": 1, "tenant_id": "", "evil); DROP TABLE products; --": 1}`+"\n",
+	)))
+	require.ErrorIs(t, err, sqlite.ErrInvalidDump, "unknown columns are refused")
+
+	_, err = newTestDB(t).Load(ctx, bytes.NewReader(archive(*manifest, "sqlite_master.jsonl", "{}\n")))
+	require.ErrorIs(t, err, sqlite.ErrInvalidDump, "only known tables are loaded")
+}
@@ -0,0 +1,199 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrEncryptionKeyRequired is returned when an encrypted column is read without the encryption key.
+var ErrEncryptionKeyRequired = errors.New("the column is encrypted, the encryption key is required")
+
+// encryptedPrefix marks the values of the encrypted columns, the values without it are stored in the
+// clear.
+const encryptedPrefix = "enc:"
+
+// encryptedColumns are the columns encrypted at rest: the request headers of the targets and of their
+// regions may hold the session cookies and the credentials of the target site, as may the forms of the
+// page requests, the client certificates may hold their private keys. The chat IDs key the lookups and the
+// joins of most tables, so they are stored in the clear.
+func encryptedColumns() map[string][]string {
+	return map[string][]string{"targets": {"headers", "regions", "tls", "request"}}
+}
+
+// WithEncryptionKey encrypts the encryptedColumns with AES-GCM using the key, which must be 32 bytes
+// long. The values stored in the clear before are encrypted when the repository is created. Without it
+// the encrypted values can't be read.
+func WithEncryptionKey(key []byte) Option {
+	return func(r *Repository) {
+		r.encryptionKey = key
+	}
+}
+
+// columnCipher encrypts and decrypts the values of the encrypted columns. The name of the column is
+// authenticated with the value, so a value can't be moved to another column.
+type columnCipher struct {
+	aead cipher.AEAD
+}
+
+// newColumnCipher creates a cipher using the AES-256 key.
+func newColumnCipher(key []byte) (*columnCipher, error) {
+	const keySize = 32
+	if len(key) != keySize {
+		return nil, fmt.Errorf("the encryption key must be %d bytes long, got %d", keySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return &columnCipher{aead: aead}, nil
+}
+
+// seal encrypts the value of the column, the empty value is kept empty.
+func (c *columnCipher) seal(column, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(value), []byte(column))
+
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts the value of the column, a value stored in the clear is returned as it is.
+func (c *columnCipher) open(column, value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return value, nil
+	}
+	if c == nil {
+		return "", fmt.Errorf("%w: %s", ErrEncryptionKeyRequired, column)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < c.aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted value of %s", column)
+	}
+	nonce, sealed := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, sealed, []byte(column))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: %w", column, err)
+	}
+
+	return string(plain), nil
+}
+
+// sealed is the value of an encrypted column: it's encrypted when it's written and decrypted when it's
+// scanned. Without a cipher the value is written in the clear.
+type sealed struct {
+	cipher *columnCipher
+	// column is the name of the column as "table.column".
+	column string
+	value  *string
+}
+
+// sealed returns the value of the column to pass as a query argument or a scan destination.
+func (r *Repository) sealed(column string, value *string) sealed {
+	return sealed{cipher: r.cipher, column: column, value: value}
+}
+
+// Value implements driver.Valuer.
+func (s sealed) Value() (driver.Value, error) {
+	if s.cipher == nil {
+		return *s.value, nil
+	}
+
+	return s.cipher.seal(s.column, *s.value)
+}
+
+// Scan implements sql.Scanner.
+func (s sealed) Scan(src any) error {
+	var stored string
+	switch src := src.(type) {
+	case string:
+		stored = src
+	case []byte:
+		stored = string(src)
+	case nil:
+	default:
+		return fmt.Errorf("unsupported type %T of %s", src, s.column)
+	}
+
+	value, err := s.cipher.open(s.column, stored)
+	if err != nil {
+		return err
+	}
+	*s.value = value
+
+	return nil
+}
+
+// encryptStoredColumns encrypts the values of the encryptedColumns stored in the clear, e.g. before
+// the key was configured.
+func (r *Repository) encryptStoredColumns(ctx context.Context) error {
+	for table, columns := range encryptedColumns() {
+		for _, column := range columns {
+			if err := r.encryptStoredColumn(ctx, table, column); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// encryptStoredColumn encrypts the values of the column stored in the clear.
+func (r *Repository) encryptStoredColumn(ctx context.Context, table, column string) error {
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT rowid, %[1]s FROM %[2]s WHERE %[1]s != '' AND %[1]s NOT LIKE '%[3]s%%'",
+		column, table, encryptedPrefix,
+	))
+	if err != nil {
+		return fmt.Errorf("failed to get the values of %s.%s: %w", table, column, err)
+	}
+
+	values := make(map[int64]string)
+	for rows.Next() {
+		var (
+			rowID int64
+			value string
+		)
+		if err = rows.Scan(&rowID, &value); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan the value of %s.%s: %w", table, column, err)
+		}
+		values[rowID] = value
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to get the values of %s.%s: %w", table, column, err)
+	}
+
+	name := table + "." + column
+	for rowID, value := range values {
+		_, err = r.db.ExecContext(
+			ctx, fmt.Sprintf("UPDATE %s SET %s = ? WHERE rowid = ?", table, column), r.sealed(name, &value), rowID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt the value of %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
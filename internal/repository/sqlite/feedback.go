@@ -0,0 +1,120 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// RecordNotificationVariant stores the template variant of a notification sent to the chat as the given
+// message, so the ratings of the notification are counted for the variant.
+func (r *Repository) RecordNotificationVariant(
+	ctx context.Context,
+	chatID int64,
+	messageID int,
+	variant string,
+	sentAt time.Time,
+) (err error) {
+	const op = "repository.sqlite.RecordNotificationVariant"
+	ctx, done := r.observe(ctx, "RecordNotificationVariant")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT OR REPLACE INTO notification_variants (tenant_id, chat_id, message_id, variant, sent_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		r.tenant,
+		chatID,
+		messageID,
+		variant,
+		sentAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RecordFeedback stores whether the user found the notification sent to the chat as the given message
+// useful, replacing the earlier rating of the user. It returns false if the message isn't a notification
+// with a recorded variant, e.g. one pruned by maintenance.
+func (r *Repository) RecordFeedback(
+	ctx context.Context,
+	chatID int64,
+	messageID int,
+	userID int64,
+	useful bool,
+	votedAt time.Time,
+) (_ bool, err error) {
+	const opn = "repository.sqlite.RecordFeedback"
+	ctx, done := r.observe(ctx, "RecordFeedback")
+	defer func() { err = done(err) }()
+
+	res, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO notification_feedback (tenant_id, chat_id, message_id, user_id, useful, voted_at)
+		SELECT tenant_id, chat_id, message_id, ?, ?, ? FROM notification_variants
+		WHERE tenant_id = ? AND chat_id = ? AND message_id = ?
+		ON CONFLICT(tenant_id, chat_id, message_id, user_id) DO UPDATE
+		SET useful = excluded.useful, voted_at = excluded.voted_at`,
+		userID,
+		useful,
+		votedAt.UTC(),
+		r.tenant,
+		chatID,
+		messageID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", opn, err)
+	}
+
+	recorded, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to get affected rows: %w", opn, err)
+	}
+
+	return recorded > 0, nil
+}
+
+// GetFeedbackStats returns the number of notifications sent since the given time and their ratings for
+// every template variant, ordered by variant.
+func (r *Repository) GetFeedbackStats(ctx context.Context, since time.Time) (_ []models.VariantFeedback, err error) {
+	const opn = "repository.sqlite.GetFeedbackStats"
+	ctx, done := r.observe(ctx, "GetFeedbackStats")
+	defer func() { err = done(err) }()
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT v.variant, COUNT(DISTINCT v.chat_id || ':' || v.message_id),
+			COALESCE(SUM(f.useful = 1), 0), COALESCE(SUM(f.useful = 0), 0)
+		FROM notification_variants v
+		LEFT JOIN notification_feedback f
+			ON f.tenant_id = v.tenant_id AND f.chat_id = v.chat_id AND f.message_id = v.message_id
+		WHERE v.tenant_id = ? AND v.sent_at >= ?
+		GROUP BY v.variant ORDER BY v.variant`,
+		r.tenant,
+		since.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query feedback: %w", opn, err)
+	}
+	defer rows.Close()
+
+	var stats []models.VariantFeedback
+	for rows.Next() {
+		var feedback models.VariantFeedback
+		if err = rows.Scan(&feedback.Variant, &feedback.Sent, &feedback.Useful, &feedback.NotUseful); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan feedback: %w", opn, err)
+		}
+		stats = append(stats, feedback)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	return stats, nil
+}
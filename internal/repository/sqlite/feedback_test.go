@@ -0,0 +1,143 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_Feedback(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	now := time.Now()
+
+	require.NoError(t, repo.RecordNotificationVariant(ctx, -100, 1, "a", now))
+	require.NoError(t, repo.RecordNotificationVariant(ctx, -100, 2, "a", now))
+	require.NoError(t, repo.RecordNotificationVariant(ctx, -200, 1, "b", now))
+	require.NoError(t, repo.RecordNotificationVariant(ctx, -200, 2, "b", now.Add(-48*time.Hour)))
+	require.NoError(t, repo.ForTenant("acme").RecordNotificationVariant(ctx, -100, 3, "a", now))
+
+	t.Run("ratings of known notifications are recorded", func(t *testing.T) {
+		for _, vote := range []struct {
+			chatID    int64
+			messageID int
+			userID    int64
+			useful    bool
+		}{{-100, 1, 7, true}, {-100, 1, 8, true}, {-100, 2, 7, false}, {-200, 1, 9, false}} {
+			recorded, err := repo.RecordFeedback(ctx, vote.chatID, vote.messageID, vote.userID, vote.useful, now)
+			require.NoError(t, err)
+			assert.True(t, recorded)
+		}
+
+		recorded, err := repo.RecordFeedback(ctx, -100, 3, 7, true, now)
+		require.NoError(t, err)
+		assert.False(t, recorded, "the notification was sent to another tenant")
+	})
+
+	t.Run("a new rating replaces the earlier one", func(t *testing.T) {
+		recorded, err := repo.RecordFeedback(ctx, -200, 1, 9, true, now)
+		require.NoError(t, err)
+		assert.True(t, recorded)
+	})
+
+	t.Run("stats per variant", func(t *testing.T) {
+		stats, err := repo.GetFeedbackStats(ctx, now.Add(-24*time.Hour))
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.VariantFeedback{
+			{Variant: "a", Sent: 2, Useful: 2, NotUseful: 1},
+			{Variant: "b", Sent: 1, Useful: 1},
+		}, stats)
+	})
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestRecordNotificationVariant(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: insert", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT OR REPLACE INTO notification_variants").WillReturnError(assert.AnError)
+
+		// Act
+		err := repo.RecordNotificationVariant(ctx, -100, 1, "a", time.Now())
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.RecordNotificationVariant")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRecordFeedback(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: insert", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT INTO notification_feedback").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.RecordFeedback(ctx, -100, 1, 7, true, time.Now())
+
+		// Assert
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: affected rows", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT INTO notification_feedback").WillReturnResult(sqlmock.NewErrorResult(assert.AnError))
+
+		// Act
+		_, err := repo.RecordFeedback(ctx, -100, 1, 7, true, time.Now())
+
+		// Assert
+		require.ErrorContains(t, err, "failed to get affected rows")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetFeedbackStats(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("FROM notification_variants").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.GetFeedbackStats(ctx, time.Now())
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.GetFeedbackStats: failed to query feedback")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: scan", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("FROM notification_variants").WillReturnRows(sqlmock.NewRows([]string{"variant"}).AddRow("a"))
+
+		// Act
+		_, err := repo.GetFeedbackStats(ctx, time.Now())
+
+		// Assert
+		require.ErrorContains(t, err, "failed to scan feedback")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
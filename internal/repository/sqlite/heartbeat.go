@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// GetHeartbeat returns the state of the dead-man's switch of the target, nil if it wasn't saved yet.
+func (r *Repository) GetHeartbeat(ctx context.Context) (_ *models.Heartbeat, err error) {
+	const opn = "repository.sqlite.GetHeartbeat"
+	ctx, done := r.observe(ctx, "GetHeartbeat")
+	defer func() { err = done(err) }()
+
+	var heartbeat models.Heartbeat
+	err = r.db.QueryRowContext(
+		ctx,
+		"SELECT last_success, alerted FROM heartbeats WHERE tenant_id = ?",
+		r.tenant,
+	).Scan(&heartbeat.LastSuccess, &heartbeat.Alerted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil //nolint:nilnil // A missing heartbeat isn't an error, the switch wasn't started yet.
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+
+	return &heartbeat, nil
+}
+
+// SaveHeartbeat replaces the state of the dead-man's switch of the target.
+func (r *Repository) SaveHeartbeat(ctx context.Context, heartbeat models.Heartbeat) (err error) {
+	const opn = "repository.sqlite.SaveHeartbeat"
+	ctx, done := r.observe(ctx, "SaveHeartbeat")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO heartbeats (tenant_id, last_success, alerted) VALUES (?, ?, ?)
+		ON CONFLICT(tenant_id) DO UPDATE SET last_success = excluded.last_success, alerted = excluded.alerted`,
+		r.tenant,
+		heartbeat.LastSuccess.UTC(),
+		heartbeat.Alerted,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opn, err)
+	}
+
+	return nil
+}
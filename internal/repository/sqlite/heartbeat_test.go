@@ -0,0 +1,71 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_Heartbeat(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	lastSuccess := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	heartbeat, err := repo.GetHeartbeat(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, heartbeat, "no heartbeat before the first save")
+
+	require.NoError(t, repo.SaveHeartbeat(ctx, models.Heartbeat{LastSuccess: lastSuccess}))
+	require.NoError(t, repo.SaveHeartbeat(ctx, models.Heartbeat{LastSuccess: lastSuccess, Alerted: true}))
+
+	heartbeat, err = repo.GetHeartbeat(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &models.Heartbeat{LastSuccess: lastSuccess, Alerted: true}, heartbeat)
+
+	heartbeat, err = repo.ForTenant("acme").GetHeartbeat(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, heartbeat, "heartbeats are scoped by tenant")
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestGetHeartbeat(t *testing.T) {
+	t.Run("error: query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("SELECT last_success, alerted FROM heartbeats").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.GetHeartbeat(t.Context())
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.GetHeartbeat")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSaveHeartbeat(t *testing.T) {
+	t.Run("error: exec", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT INTO heartbeats").WillReturnError(assert.AnError)
+
+		// Act
+		err := repo.SaveHeartbeat(t.Context(), models.Heartbeat{LastSuccess: time.Now()})
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.SaveHeartbeat")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,260 @@
+package sqlite
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/events"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+)
+
+// changeColumns lists the columns of the changes table in the order they are scanned.
+const changeColumns = "detected_at, kind, model, old_model, category, type, old_price, price, old_quantity, " +
+	"quantity, run_id, note"
+
+// RecordChanges stores the changes detected at the given time in the change history, with the ID of the
+// check run of the context, see events.WithRunID.
+func (r *Repository) RecordChanges(ctx context.Context, detectedAt time.Time, changes *models.Changes) (err error) {
+	const opn = "repository.sqlite.RecordChanges"
+	ctx, done := r.observe(ctx, "RecordChanges")
+	defer func() { err = done(err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil) //nolint:varnamelen // tx its a default naming for transaction
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", opn, err)
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after a successful commit only returns sql.ErrTxDone.
+
+	stmt, err := tx.PrepareContext(
+		ctx,
+		"INSERT INTO changes (tenant_id, "+changeColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '')",
+	)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", opn, err)
+	}
+	defer stmt.Close()
+
+	runID := events.RunIDFromContext(ctx)
+	for _, record := range changes.Records(detectedAt.UTC()) {
+		_, err = stmt.ExecContext(ctx, r.tenant, record.DetectedAt, record.Kind, record.Model, record.OldModel,
+			record.Category, record.Type, record.OldPrice, record.Price, record.OldQuantity, record.Quantity, runID)
+		if err != nil {
+			return fmt.Errorf("%s: failed to insert change: %w", opn, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", opn, err)
+	}
+
+	return nil
+}
+
+// GetChanges returns the changes detected since the given time, oldest first.
+func (r *Repository) GetChanges(ctx context.Context, since time.Time) (_ []models.ChangeRecord, err error) {
+	const opn = "repository.sqlite.GetChanges"
+	ctx, done := r.observe(ctx, "GetChanges")
+	defer func() { err = done(err) }()
+
+	records, err := r.queryChanges(
+		ctx,
+		"SELECT "+changeColumns+" FROM changes WHERE tenant_id = ? AND detected_at >= ? ORDER BY detected_at, id",
+		r.tenant,
+		since.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+
+	return records, nil
+}
+
+// GetProductHistory returns the changes of a product model in a category, oldest first.
+// A rename is part of the history of both the old and the new model.
+func (r *Repository) GetProductHistory(
+	ctx context.Context,
+	category, model string,
+) (_ []models.ChangeRecord, err error) {
+	const opn = "repository.sqlite.GetProductHistory"
+	ctx, done := r.observe(ctx, "GetProductHistory")
+	defer func() { err = done(err) }()
+
+	records, err := r.queryChanges(
+		ctx,
+		"SELECT "+changeColumns+` FROM changes
+		WHERE tenant_id = ? AND category = ? AND (model = ? OR old_model = ?) ORDER BY detected_at, id`,
+		r.tenant, category, model, model,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+
+	return records, nil
+}
+
+// AnnotateChange attaches the note to the changes of the model detected by the check run, replacing the
+// note they had. A rename is a change of both the old and the new model. It returns how many changes were
+// annotated, none if the run didn't change the model.
+func (r *Repository) AnnotateChange(ctx context.Context, runID, model, note string) (_ int, err error) {
+	const opn = "repository.sqlite.AnnotateChange"
+	ctx, done := r.observe(ctx, "AnnotateChange")
+	defer func() { err = done(err) }()
+
+	res, err := r.db.ExecContext(ctx, `UPDATE changes SET note = ?
+		WHERE tenant_id = ? AND run_id = ? AND (model = ? COLLATE NOCASE OR old_model = ? COLLATE NOCASE)`,
+		note, r.tenant, runID, model, model)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to annotate changes: %w", opn, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get affected rows: %w", opn, err)
+	}
+
+	return int(affected), nil
+}
+
+// GetStateAt returns the products listed at the given time, sorted by category and model. They're
+// reconstructed from the current products by undoing the changes detected since, newest first, so only
+// their model, type, price and quantity are as they were: the other fields are the current ones, and
+// the products removed since have none. It returns repository.ErrStateNotFound if no change was recorded
+// by then, i.e. the target wasn't checked yet or the history of the time was pruned.
+func (r *Repository) GetStateAt(ctx context.Context, at time.Time) (_ []models.Product, err error) {
+	const opn = "repository.sqlite.GetStateAt"
+	ctx, done := r.observe(ctx, "GetStateAt")
+	defer func() { err = done(err) }()
+
+	// The products and the changes are read in a transaction, so a check can't update them in between.
+	tx, err := r.db.BeginTx(ctx, nil) //nolint:varnamelen // tx its a default naming for transaction
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", opn, err)
+	}
+	defer tx.Rollback() //nolint:errcheck // The transaction only reads.
+
+	var tracked bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM changes WHERE tenant_id = ? AND detected_at <= ?)",
+		r.tenant, at.UTC()).Scan(&tracked)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to check the history: %w", opn, err)
+	}
+	if !tracked {
+		return nil, repository.ErrStateNotFound
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+productColumns+" FROM products WHERE tenant_id = ?", r.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get products: %w", opn, err)
+	}
+	defer rows.Close()
+
+	var products []models.Product
+	for rows.Next() {
+		var p models.Product
+		if err = rows.Scan(productFields(&p)...); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan product: %w", opn, err)
+		}
+		products = append(products, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	records, err := queryChanges(
+		ctx, tx,
+		"SELECT "+changeColumns+` FROM changes
+		WHERE tenant_id = ? AND detected_at > ? ORDER BY detected_at DESC, id DESC`,
+		r.tenant, at.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+
+	return rewind(products, records), nil
+}
+
+// rewind undoes the changes, newest first, on the products and returns them sorted by category and model.
+func rewind(products []models.Product, records []models.ChangeRecord) []models.Product {
+	type key struct{ category, model string }
+
+	state := make(map[key]models.Product, len(products))
+	for _, p := range products {
+		state[key{p.Category, p.Model}] = p
+	}
+
+	for _, record := range records {
+		switch record.Kind {
+		case models.KindAdded:
+			delete(state, key{record.Category, record.Model})
+		case models.KindRemoved:
+			state[key{record.Category, record.OldModel}] = models.Product{
+				Model: record.OldModel, Category: record.Category, Type: record.Type,
+				Price: record.OldPrice, Quantity: record.OldQuantity,
+			}
+		case models.KindChanged, models.KindRenamed:
+			current := key{record.Category, record.Model}
+			product, ok := state[current]
+			if !ok {
+				product = models.Product{Category: record.Category, Type: record.Type}
+			}
+			delete(state, current)
+			product.Model = cmp.Or(record.OldModel, record.Model)
+			product.Price, product.Quantity = record.OldPrice, record.OldQuantity
+			state[key{product.Category, product.Model}] = product
+		}
+	}
+
+	rewound := slices.Collect(maps.Values(state))
+	slices.SortFunc(rewound, func(a, b models.Product) int {
+		return cmp.Or(cmp.Compare(a.Category, b.Category), cmp.Compare(a.Model, b.Model))
+	})
+
+	return rewound
+}
+
+// queryChanges runs a query selecting changeColumns and scans the result.
+func (r *Repository) queryChanges(ctx context.Context, query string, args ...any) ([]models.ChangeRecord, error) {
+	return queryChanges(ctx, r.db, query, args...)
+}
+
+// changesQuerier runs the queries of changes, the database or a transaction.
+type changesQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// queryChanges runs a query selecting changeColumns with the querier and scans the result.
+func queryChanges(
+	ctx context.Context,
+	querier changesQuerier,
+	query string,
+	args ...any,
+) ([]models.ChangeRecord, error) {
+	rows, err := querier.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changes: %w", err)
+	}
+	defer rows.Close()
+
+	var records []models.ChangeRecord
+	for rows.Next() {
+		var record models.ChangeRecord
+		err = rows.Scan(&record.DetectedAt, &record.Kind, &record.Model, &record.OldModel, &record.Category,
+			&record.Type, &record.OldPrice, &record.Price, &record.OldQuantity, &record.Quantity, &record.RunID,
+			&record.Note)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan change: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,249 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Houeta/chrono-flow/internal/events"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_History(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	records, err := repo.GetChanges(ctx, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	older := &models.Changes{
+		Added: []models.Product{{Model: "A1", Category: "new", Type: "Diver", Price: "100", Quantity: "1"}},
+	}
+	newer := &models.Changes{
+		Changed: []models.ChangeInfo{{
+			Old: models.Product{Model: "A1", Category: "new", Type: "Diver", Price: "100", Quantity: "1"},
+			New: models.Product{Model: "A1", Category: "new", Type: "Diver", Price: "90", Quantity: "1"},
+		}},
+		Renamed: []models.ChangeInfo{{
+			Old: models.Product{Model: "B1", Category: "new", Price: "50"},
+			New: models.Product{Model: "B1X", Category: "new", Price: "50"},
+		}},
+		Removed: []models.Product{{Model: "A1", Category: "used", Price: "70", Quantity: "1"}},
+	}
+	require.NoError(t, repo.RecordChanges(ctx, now.Add(-48*time.Hour), older))
+	require.NoError(t, repo.RecordChanges(events.WithRunID(ctx, "run2"), now.Add(-10*time.Minute), newer))
+
+	records, err = repo.GetChanges(ctx, now.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, models.KindChanged, records[0].Kind)
+	assert.Equal(t, "90", records[0].Price)
+	assert.Equal(t, "100", records[0].OldPrice)
+	assert.True(t, now.Add(-10*time.Minute).Equal(records[0].DetectedAt))
+	assert.Equal(t, models.KindRenamed, records[1].Kind)
+	assert.Equal(t, models.KindRemoved, records[2].Kind)
+	assert.Equal(t, "A1", records[2].OldModel)
+
+	history, err := repo.GetProductHistory(ctx, "new", "A1")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, models.KindAdded, history[0].Kind)
+	assert.Equal(t, models.KindChanged, history[1].Kind)
+
+	history, err = repo.GetProductHistory(ctx, "new", "B1")
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "B1X", history[0].Model)
+
+	annotated, err := repo.AnnotateChange(ctx, "run2", "b1", "supplier confirmed pricing error")
+	require.NoError(t, err)
+	assert.Equal(t, 1, annotated, "a rename is annotated by its old model too")
+	annotated, err = repo.AnnotateChange(ctx, "run1", "B1", "wrong run")
+	require.NoError(t, err)
+	assert.Zero(t, annotated)
+
+	history, err = repo.GetProductHistory(ctx, "new", "B1")
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "run2", history[0].RunID)
+	assert.Equal(t, "supplier confirmed pricing error", history[0].Note)
+
+	deleted, err := repo.PruneHistory(ctx, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	history, err = repo.GetProductHistory(ctx, "new", "A1")
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestRecordChanges(t *testing.T) {
+	ctx := t.Context()
+	changes := &models.Changes{Added: []models.Product{{Model: "A1"}}}
+
+	t.Run("error: begin transaction", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin().WillReturnError(assert.AnError)
+
+		// Act
+		err := repo.RecordChanges(ctx, time.Now(), changes)
+
+		// Assert
+		require.ErrorContains(t, err, "failed to begin transaction")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: insert change", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectPrepare("INSERT INTO changes").ExpectExec().WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		err := repo.RecordChanges(ctx, time.Now(), changes)
+
+		// Assert
+		require.ErrorContains(t, err, "failed to insert change")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAnnotateChange(t *testing.T) {
+	t.Run("error: update", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("UPDATE changes SET note").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.AnnotateChange(t.Context(), "run1", "A1", "note")
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.AnnotateChange: failed to annotate changes")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetChanges(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("SELECT detected_at").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.GetChanges(ctx, time.Now())
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.GetChanges: failed to get changes")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_Integration_StateAt(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	march3 := time.Date(2026, time.March, 3, 12, 0, 0, 0, time.UTC)
+
+	_, err := repo.GetStateAt(ctx, march3)
+	require.ErrorIs(t, err, repository.ErrStateNotFound, "nothing was recorded yet")
+
+	first := &models.Changes{Added: []models.Product{
+		{Model: "A1", Category: "new", Type: "Diver", Price: "100", Quantity: "1"},
+		{Model: "B1", Category: "new", Type: "Sport", Price: "50", Quantity: "2"},
+		{Model: "C1", Category: "used", Type: "Diver", Price: "70", Quantity: "1"},
+	}}
+	later := &models.Changes{
+		Added: []models.Product{{Model: "D1", Category: "new", Type: "Dress", Price: "300", Quantity: "1"}},
+		Changed: []models.ChangeInfo{{
+			Old: models.Product{Model: "A1", Category: "new", Type: "Diver", Price: "100", Quantity: "1"},
+			New: models.Product{Model: "A1", Category: "new", Type: "Diver", Price: "90", Quantity: "3"},
+		}},
+		Renamed: []models.ChangeInfo{{
+			Old: models.Product{Model: "B1", Category: "new", Type: "Sport", Price: "50", Quantity: "2"},
+			New: models.Product{Model: "B1X", Category: "new", Type: "Sport", Price: "55", Quantity: "2"},
+		}},
+		Removed: []models.Product{{Model: "C1", Category: "used", Type: "Diver", Price: "70", Quantity: "1"}},
+	}
+	require.NoError(t, repo.RecordChanges(ctx, march3.Add(-24*time.Hour), first))
+	require.NoError(t, repo.RecordChanges(ctx, march3.Add(24*time.Hour), later))
+	require.NoError(t, repo.UpdateState(ctx, &models.State{PageHash: "hash", Products: []models.Product{
+		{Model: "A1", Category: "new", Type: "Diver", Price: "90", Quantity: "3", ImageURL: "a1.jpg"},
+		{Model: "B1X", Category: "new", Type: "Sport", Price: "55", Quantity: "2"},
+		{Model: "D1", Category: "new", Type: "Dress", Price: "300", Quantity: "1"},
+	}}))
+
+	// Act
+	products, err := repo.GetStateAt(ctx, march3)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []models.Product{
+		{Model: "A1", Category: "new", Type: "Diver", Price: "100", Quantity: "1", ImageURL: "a1.jpg"},
+		{Model: "B1", Category: "new", Type: "Sport", Price: "50", Quantity: "2"},
+		{Model: "C1", Category: "used", Type: "Diver", Price: "70", Quantity: "1"},
+	}, products)
+
+	products, err = repo.GetStateAt(ctx, march3.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, products, 3, "the changes detected at the time are part of the state")
+	assert.Equal(t, "90", products[0].Price)
+
+	_, err = repo.GetStateAt(ctx, march3.Add(-48*time.Hour))
+	require.ErrorIs(t, err, repository.ErrStateNotFound, "the target wasn't checked yet")
+}
+
+func TestGetStateAt(t *testing.T) {
+	t.Run("error: check the history", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT EXISTS").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		_, err := repo.GetStateAt(t.Context(), time.Now())
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.GetStateAt: failed to check the history")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: query changes", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT EXISTS").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery("SELECT model").WillReturnRows(sqlmock.NewRows([]string{"model"}))
+		mock.ExpectQuery("SELECT detected_at").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		_, err := repo.GetStateAt(t.Context(), time.Now())
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.GetStateAt: failed to get changes")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// Vacuum rebuilds the database file to reclaim free pages and refreshes the query planner statistics.
+func (r *Repository) Vacuum(ctx context.Context) (*models.MaintenanceReport, error) {
+	const opn = "repository.sqlite.Vacuum"
+
+	sizeBefore, err := r.size(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+
+	// VACUUM can't run inside a transaction, so it is executed directly on the connection pool.
+	if _, err = r.db.ExecContext(ctx, "VACUUM"); err != nil {
+		return nil, fmt.Errorf("%s: failed to vacuum: %w", opn, err)
+	}
+
+	if _, err = r.db.ExecContext(ctx, "PRAGMA optimize"); err != nil {
+		return nil, fmt.Errorf("%s: failed to optimize: %w", opn, err)
+	}
+
+	sizeAfter, err := r.size(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+
+	return &models.MaintenanceReport{SizeBefore: sizeBefore, SizeAfter: sizeAfter}, nil
+}
+
+// size returns the database size in bytes.
+func (r *Repository) size(ctx context.Context) (int64, error) {
+	var pageCount, pageSize int64
+	if err := r.db.QueryRowContext(ctx, "PRAGMA page_count").Scan(&pageCount); err != nil {
+		return 0, fmt.Errorf("failed to get page count: %w", err)
+	}
+	if err := r.db.QueryRowContext(ctx, "PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("failed to get page size: %w", err)
+	}
+
+	return pageCount * pageSize, nil
+}
@@ -0,0 +1,71 @@
+package sqlite_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_Vacuum(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+
+	// Fill the database and delete everything to leave free pages behind.
+	products := make([]models.Product, 0, 2000)
+	for i := range 2000 {
+		products = append(products, models.Product{Model: fmt.Sprintf("model-%d", i), Price: "100"})
+	}
+	require.NoError(t, repo.UpdateState(ctx, &models.State{PageHash: "hash", Products: products}))
+	require.NoError(t, repo.UpdateState(ctx, &models.State{PageHash: "hash"}))
+
+	report, err := repo.Vacuum(ctx)
+	require.NoError(t, err)
+	assert.Positive(t, report.SizeAfter)
+	assert.Positive(t, report.Reclaimed())
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestVacuum(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: cannot get size", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("PRAGMA page_count").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.Vacuum(ctx)
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.Vacuum")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: vacuum fails", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("PRAGMA page_count").WillReturnRows(sqlmock.NewRows([]string{"page_count"}).AddRow(10))
+		mock.ExpectQuery("PRAGMA page_size").WillReturnRows(sqlmock.NewRows([]string{"page_size"}).AddRow(4096))
+		mock.ExpectExec("VACUUM").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.Vacuum(ctx)
+
+		// Assert
+		require.ErrorContains(t, err, "failed to vacuum")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,358 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// schemaMigrations are applied in order on top of initSchema to upgrade existing databases.
+// The index of the last applied migration plus one is stored in PRAGMA user_version,
+// so migrations must only ever be appended to the list.
+func schemaMigrations() []string {
+	return []string{
+		`ALTER TABLE chat_settings ADD COLUMN thread_id INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE chat_settings ADD COLUMN silent_categories TEXT NOT NULL DEFAULT ''`,
+		// SQLite can't change a primary key in place, so products are copied into a table keyed by category and model.
+		`CREATE TABLE products_v3 (
+			model TEXT NOT NULL,
+			category TEXT NOT NULL DEFAULT '',
+			type TEXT,
+			quantity TEXT,
+			price TEXT,
+			image_url TEXT,
+			PRIMARY KEY (category, model)
+		);
+		INSERT INTO products_v3 (model, type, quantity, price, image_url)
+			SELECT model, type, quantity, price, image_url FROM products;
+		DROP TABLE products;
+		ALTER TABLE products_v3 RENAME TO products;`,
+		`ALTER TABLE products ADD COLUMN product_url TEXT NOT NULL DEFAULT ''`,
+		// Every table is scoped by tenant, the existing data belongs to the default tenant with the empty id.
+		`CREATE TABLE page_state_v5 (
+			tenant_id TEXT PRIMARY KEY NOT NULL,
+			page_hash TEXT NOT NULL
+		);
+		INSERT INTO page_state_v5 (tenant_id, page_hash) SELECT '', page_hash FROM page_state;
+		DROP TABLE page_state;
+		ALTER TABLE page_state_v5 RENAME TO page_state;
+
+		CREATE TABLE products_v5 (
+			tenant_id TEXT NOT NULL DEFAULT '',
+			model TEXT NOT NULL,
+			category TEXT NOT NULL DEFAULT '',
+			type TEXT,
+			quantity TEXT,
+			price TEXT,
+			image_url TEXT,
+			product_url TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (tenant_id, category, model)
+		);
+		INSERT INTO products_v5 (model, category, type, quantity, price, image_url, product_url)
+			SELECT model, category, type, quantity, price, image_url, product_url FROM products;
+		DROP TABLE products;
+		ALTER TABLE products_v5 RENAME TO products;
+
+		CREATE TABLE subscriptions_v5 (
+			tenant_id TEXT NOT NULL DEFAULT '',
+			chat_id INTEGER NOT NULL,
+			subscribed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (tenant_id, chat_id)
+		);
+		INSERT INTO subscriptions_v5 (chat_id, subscribed_at) SELECT chat_id, subscribed_at FROM subscriptions;
+		DROP TABLE subscriptions;
+		ALTER TABLE subscriptions_v5 RENAME TO subscriptions;
+
+		CREATE TABLE allowed_chats_v5 (
+			tenant_id TEXT NOT NULL DEFAULT '',
+			chat_id INTEGER NOT NULL,
+			allowed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (tenant_id, chat_id)
+		);
+		INSERT INTO allowed_chats_v5 (chat_id, allowed_at) SELECT chat_id, allowed_at FROM allowed_chats;
+		DROP TABLE allowed_chats;
+		ALTER TABLE allowed_chats_v5 RENAME TO allowed_chats;
+
+		CREATE TABLE chat_settings_v5 (
+			tenant_id TEXT NOT NULL DEFAULT '',
+			chat_id INTEGER NOT NULL,
+			filter_group TEXT NOT NULL DEFAULT '',
+			thread_id INTEGER NOT NULL DEFAULT 0,
+			silent_categories TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (tenant_id, chat_id)
+		);
+		INSERT INTO chat_settings_v5 (chat_id, filter_group, thread_id, silent_categories)
+			SELECT chat_id, filter_group, thread_id, silent_categories FROM chat_settings;
+		DROP TABLE chat_settings;
+		ALTER TABLE chat_settings_v5 RENAME TO chat_settings;
+
+		ALTER TABLE fetches ADD COLUMN tenant_id TEXT NOT NULL DEFAULT '';
+		CREATE INDEX idx_fetches_tenant ON fetches (tenant_id, fetched_at);
+		ALTER TABLE changes ADD COLUMN tenant_id TEXT NOT NULL DEFAULT '';
+		CREATE INDEX idx_changes_tenant ON changes (tenant_id, detected_at);`,
+		`ALTER TABLE tenants ADD COLUMN maintenance_windows TEXT NOT NULL DEFAULT ''`,
+		// Targets carry all parser options, so the main page of a tenant can be stored as a target too.
+		`ALTER TABLE targets ADD COLUMN tables TEXT NOT NULL DEFAULT '';
+		UPDATE targets SET tables = json_array(json_object('name', '', 'selector', table_selector))
+			WHERE table_selector != '';
+		ALTER TABLE targets DROP COLUMN table_selector;
+		ALTER TABLE targets ADD COLUMN column_synonyms TEXT NOT NULL DEFAULT '';
+		ALTER TABLE targets ADD COLUMN iframe_selector TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE targets ADD COLUMN disabled INTEGER NOT NULL DEFAULT 0`,
+		// Subscriber statistics, the existing subscribers are recorded as subscribed when they subscribed.
+		`CREATE TABLE subscription_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			tenant_id TEXT NOT NULL DEFAULT '',
+			chat_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			at TIMESTAMP NOT NULL
+		);
+		CREATE INDEX idx_subscription_events_tenant ON subscription_events (tenant_id, at);
+		INSERT INTO subscription_events (tenant_id, chat_id, kind, at)
+			SELECT tenant_id, chat_id, 'subscribe',
+				strftime('%Y-%m-%d %H:%M:%S+00:00', COALESCE(subscribed_at, CURRENT_TIMESTAMP))
+			FROM subscriptions;
+
+		CREATE TABLE chat_activity (
+			tenant_id TEXT NOT NULL DEFAULT '',
+			chat_id INTEGER NOT NULL,
+			day TEXT NOT NULL,
+			PRIMARY KEY (tenant_id, day, chat_id)
+		);`,
+		// The products of every notification sent, so replies to a notification can be resolved to a product.
+		`CREATE TABLE notification_products (
+			tenant_id TEXT NOT NULL DEFAULT '',
+			chat_id INTEGER NOT NULL,
+			message_id INTEGER NOT NULL,
+			position INTEGER NOT NULL,
+			category TEXT NOT NULL,
+			model TEXT NOT NULL,
+			sent_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (tenant_id, chat_id, message_id, position)
+		);
+		CREATE INDEX idx_notification_products_sent_at ON notification_products (sent_at);`,
+		// The numeric price the products are filtered and sorted by, NULL if the price isn't a number.
+		// Existing products get it from backfillPriceValues.
+		`ALTER TABLE products ADD COLUMN price_value REAL;
+		CREATE INDEX idx_products_price_value ON products (tenant_id, price_value);`,
+		// Full-text index of the product models and types for searches. FTS4 is compiled into the driver
+		// by default, unlike FTS5. The index reads the products table, the triggers keep it in sync.
+		`CREATE VIRTUAL TABLE products_fts USING fts4(
+			content="products", model, type, prefix="2,3", tokenize=unicode61
+		);
+		CREATE TRIGGER products_fts_before_delete BEFORE DELETE ON products BEGIN
+			DELETE FROM products_fts WHERE docid = old.rowid;
+		END;
+		CREATE TRIGGER products_fts_before_update BEFORE UPDATE OF model, type ON products BEGIN
+			DELETE FROM products_fts WHERE docid = old.rowid;
+		END;
+		CREATE TRIGGER products_fts_after_update AFTER UPDATE OF model, type ON products BEGIN
+			INSERT INTO products_fts (docid, model, type) VALUES (new.rowid, new.model, new.type);
+		END;
+		CREATE TRIGGER products_fts_after_insert AFTER INSERT ON products BEGIN
+			INSERT INTO products_fts (docid, model, type) VALUES (new.rowid, new.model, new.type);
+		END;
+		INSERT INTO products_fts (products_fts) VALUES ('rebuild');`,
+		`ALTER TABLE chat_settings ADD COLUMN sustained_trends INTEGER NOT NULL DEFAULT 0`,
+		// Wishlists of product models a chat waits to drop below a total budget.
+		`CREATE TABLE wishlists (
+			tenant_id TEXT NOT NULL DEFAULT '',
+			chat_id INTEGER NOT NULL,
+			budget REAL NOT NULL DEFAULT 0,
+			notified INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (tenant_id, chat_id)
+		);
+		CREATE TABLE wishlist_items (
+			tenant_id TEXT NOT NULL DEFAULT '',
+			chat_id INTEGER NOT NULL,
+			model TEXT NOT NULL COLLATE NOCASE,
+			PRIMARY KEY (tenant_id, chat_id, model)
+		);`,
+		// State of the dead-man's switch, kept across restarts so crash loops are detected.
+		`CREATE TABLE heartbeats (
+			tenant_id TEXT NOT NULL PRIMARY KEY,
+			last_success DATETIME NOT NULL,
+			alerted INTEGER NOT NULL DEFAULT 0
+		);`,
+		// Change sets queued by the checker process for the bot process.
+		`CREATE TABLE outbox (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			tenant_id TEXT NOT NULL DEFAULT '',
+			run_id TEXT NOT NULL,
+			detected_at DATETIME NOT NULL,
+			changes TEXT NOT NULL,
+			delivered_at DATETIME
+		);
+		CREATE INDEX idx_outbox_pending ON outbox (delivered_at, id);`,
+		// Fingerprints of the check runs, and of the queued change sets so replayed ones are skipped.
+		`CREATE TABLE runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			tenant_id TEXT NOT NULL DEFAULT '',
+			run_id TEXT NOT NULL,
+			checked_at DATETIME NOT NULL,
+			page_hash TEXT NOT NULL,
+			diff_hash TEXT NOT NULL DEFAULT ''
+		);
+		CREATE INDEX idx_runs_checked_at ON runs (checked_at);
+		ALTER TABLE outbox ADD COLUMN fingerprint TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE api_tokens ADD COLUMN chat_id INTEGER NOT NULL DEFAULT 0`,
+		// Members of group chats getting the changes of some categories as direct messages.
+		`CREATE TABLE users (
+			tenant_id TEXT NOT NULL DEFAULT '',
+			user_id INTEGER NOT NULL,
+			chat_id INTEGER NOT NULL,
+			categories TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (tenant_id, user_id)
+		);`,
+		// The template variant of every notification sent and the ratings of its readers.
+		`CREATE TABLE notification_variants (
+			tenant_id TEXT NOT NULL DEFAULT '',
+			chat_id INTEGER NOT NULL,
+			message_id INTEGER NOT NULL,
+			variant TEXT NOT NULL,
+			sent_at DATETIME NOT NULL,
+			PRIMARY KEY (tenant_id, chat_id, message_id)
+		);
+		CREATE INDEX idx_notification_variants_sent_at ON notification_variants (sent_at);
+		CREATE TABLE notification_feedback (
+			tenant_id TEXT NOT NULL DEFAULT '',
+			chat_id INTEGER NOT NULL,
+			message_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			useful INTEGER NOT NULL,
+			voted_at DATETIME NOT NULL,
+			PRIMARY KEY (tenant_id, chat_id, message_id, user_id)
+		);`,
+		// Request headers and regional profiles of the targets, encoded as JSON.
+		`ALTER TABLE targets ADD COLUMN headers TEXT NOT NULL DEFAULT '';
+		ALTER TABLE targets ADD COLUMN regions TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE runs ADD COLUMN skipped INTEGER NOT NULL DEFAULT 0`,
+		// The extra fields of the products as a JSON object, empty if a product has none.
+		`ALTER TABLE products ADD COLUMN extras TEXT NOT NULL DEFAULT ''`,
+		// The normalized model and the EAN the products of the targets are matched by, see models.MatchModel.
+		// Existing products get them from backfillMatchKeys.
+		`ALTER TABLE products ADD COLUMN match_model TEXT NOT NULL DEFAULT '';
+		ALTER TABLE products ADD COLUMN match_ean TEXT NOT NULL DEFAULT '';
+		CREATE INDEX idx_products_match_model ON products (match_model);
+		CREATE INDEX idx_products_match_ean ON products (match_ean) WHERE match_ean != '';`,
+		`ALTER TABLE chat_settings ADD COLUMN watermarks INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE chat_settings ADD COLUMN watermark_days INTEGER NOT NULL DEFAULT 0;`,
+		// Alert rules of the chats, conditions over a change and its price history, see the rules package.
+		`CREATE TABLE alert_rules (
+			tenant_id TEXT NOT NULL DEFAULT '',
+			chat_id INTEGER NOT NULL,
+			name TEXT NOT NULL COLLATE NOCASE,
+			expression TEXT NOT NULL,
+			PRIMARY KEY (tenant_id, chat_id, name)
+		);`,
+		// The check run that detected a change, and the note an admin attached to it with /annotate.
+		`ALTER TABLE changes ADD COLUMN run_id TEXT NOT NULL DEFAULT '';
+		ALTER TABLE changes ADD COLUMN note TEXT NOT NULL DEFAULT '';
+		CREATE INDEX idx_changes_run_id ON changes (tenant_id, run_id);`,
+		// The fingerprints of the change sets delivered lately, identical ones are suppressed until they expire.
+		`CREATE TABLE diff_cache (
+			tenant_id TEXT NOT NULL,
+			fingerprint TEXT NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (tenant_id, fingerprint)
+		);`,
+		// The chats the messages of the bot persistently fail to reach, pruned from the subscribers once
+		// they failed for too long.
+		`CREATE TABLE unreachable_chats (
+			tenant_id TEXT NOT NULL,
+			chat_id INTEGER NOT NULL,
+			failing_since TIMESTAMP NOT NULL,
+			last_failure TIMESTAMP NOT NULL,
+			reason TEXT NOT NULL,
+			deactivated INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (tenant_id, chat_id)
+		);`,
+		// The last page fetched with its hash, and the diffs of the pages that changed without a product
+		// changing, kept for debugging.
+		`CREATE TABLE page_snapshots (
+			tenant_id TEXT PRIMARY KEY,
+			page_hash TEXT NOT NULL,
+			page BLOB NOT NULL
+		);
+		CREATE TABLE page_diffs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			tenant_id TEXT NOT NULL,
+			run_id TEXT NOT NULL,
+			checked_at TIMESTAMP NOT NULL,
+			old_hash TEXT NOT NULL,
+			new_hash TEXT NOT NULL,
+			diff TEXT NOT NULL
+		);
+		CREATE INDEX idx_page_diffs_tenant ON page_diffs (tenant_id, id);`,
+		`CREATE TABLE run_artifacts (
+			tenant_id TEXT NOT NULL,
+			run_id TEXT NOT NULL,
+			format TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			content BLOB NOT NULL,
+			PRIMARY KEY (tenant_id, run_id, format)
+		);
+		CREATE INDEX idx_run_artifacts_created_at ON run_artifacts (created_at);`,
+		// The network options of the targets encoded as JSON, see models.TargetNetwork.
+		`ALTER TABLE targets ADD COLUMN network TEXT NOT NULL DEFAULT ''`,
+		// The client certificates of the targets encoded as JSON, see models.TargetTLS.
+		`ALTER TABLE targets ADD COLUMN tls TEXT NOT NULL DEFAULT ''`,
+		// The fetch steps of the targets encoded as JSON, see models.TargetStep.
+		`ALTER TABLE targets ADD COLUMN steps TEXT NOT NULL DEFAULT ''`,
+		// The requests of the pages of the targets encoded as JSON, see models.TargetRequest.
+		`ALTER TABLE targets ADD COLUMN request TEXT NOT NULL DEFAULT ''`,
+		// The assertions on the pages of the targets encoded as JSON, see models.PageAssertions.
+		`ALTER TABLE targets ADD COLUMN assertions TEXT NOT NULL DEFAULT ''`,
+		// The last failed check of every target, see models.CheckError.
+		`CREATE TABLE check_errors (
+			tenant_id TEXT NOT NULL PRIMARY KEY,
+			class TEXT NOT NULL,
+			message TEXT NOT NULL,
+			failing_since DATETIME NOT NULL,
+			last_failure DATETIME NOT NULL,
+			failures INTEGER NOT NULL DEFAULT 0
+		);`,
+		`ALTER TABLE outbox ADD COLUMN claimed_until DATETIME`,
+	}
+}
+
+// migrateSchema applies the schema migrations that weren't applied to the database yet.
+func migrateSchema(ctx context.Context, dtb *sql.DB) error {
+	var version int
+	if err := dtb.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	migrations := schemaMigrations()
+	for idx := version; idx < len(migrations); idx++ {
+		if err := applyMigration(ctx, dtb, idx+1, migrations[idx]); err != nil {
+			return fmt.Errorf("failed to apply migration %d: %w", idx+1, err)
+		}
+	}
+
+	return nil
+}
+
+// applyMigration runs a single migration and bumps the schema version in one transaction.
+func applyMigration(ctx context.Context, dtb *sql.DB, version int, query string) error {
+	txn, err := dtb.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = txn.Rollback() }()
+
+	if _, err = txn.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to execute migration: %w", err)
+	}
+
+	// PRAGMA doesn't support placeholders, version is always an integer.
+	if _, err = txn.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", version)); err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	if err = txn.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// RecordNotificationProducts stores the products of a notification sent to the chat as the given message.
+func (r *Repository) RecordNotificationProducts(
+	ctx context.Context,
+	chatID int64,
+	messageID int,
+	sentAt time.Time,
+	products []models.ProductRef,
+) (err error) {
+	const opn = "repository.sqlite.RecordNotificationProducts"
+	ctx, done := r.observe(ctx, "RecordNotificationProducts")
+	defer func() { err = done(err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil) //nolint:varnamelen // tx its a default naming for transaction
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", opn, err)
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after a successful commit only returns sql.ErrTxDone.
+
+	stmt, err := tx.PrepareContext(
+		ctx,
+		`INSERT OR REPLACE INTO notification_products
+		(tenant_id, chat_id, message_id, position, category, model, sent_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", opn, err)
+	}
+	defer stmt.Close()
+
+	for position, product := range products {
+		_, err = stmt.ExecContext(ctx, r.tenant, chatID, messageID, position, product.Category, product.Model,
+			sentAt.UTC())
+		if err != nil {
+			return fmt.Errorf("%s: failed to insert product: %w", opn, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", opn, err)
+	}
+
+	return nil
+}
+
+// GetNotificationProducts returns the products of the notification sent to the chat as the given message
+// in the order they were listed, none if the message isn't a known notification.
+func (r *Repository) GetNotificationProducts(
+	ctx context.Context,
+	chatID int64,
+	messageID int,
+) (_ []models.ProductRef, err error) {
+	const opn = "repository.sqlite.GetNotificationProducts"
+	ctx, done := r.observe(ctx, "GetNotificationProducts")
+	defer func() { err = done(err) }()
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT category, model FROM notification_products
+		WHERE tenant_id = ? AND chat_id = ? AND message_id = ? ORDER BY position`,
+		r.tenant,
+		chatID,
+		messageID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query products: %w", opn, err)
+	}
+	defer rows.Close()
+
+	var products []models.ProductRef
+	for rows.Next() {
+		var product models.ProductRef
+		if err = rows.Scan(&product.Category, &product.Model); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan product: %w", opn, err)
+		}
+		products = append(products, product)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	return products, nil
+}
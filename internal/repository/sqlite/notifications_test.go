@@ -0,0 +1,102 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_NotificationProducts(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	now := time.Now()
+	products := []models.ProductRef{{Category: "new", Model: "B2"}, {Category: "", Model: "A1"}}
+
+	require.NoError(t, repo.RecordNotificationProducts(ctx, -100, 7, now, products))
+	require.NoError(t, repo.RecordNotificationProducts(ctx, -100, 8, now.Add(-48*time.Hour), products[:1]))
+	require.NoError(t, repo.ForTenant("acme").RecordNotificationProducts(ctx, -100, 9, now, products))
+
+	got, err := repo.GetNotificationProducts(ctx, -100, 7)
+	require.NoError(t, err)
+	assert.Equal(t, products, got, "the products keep the order they were listed in")
+
+	got, err = repo.GetNotificationProducts(ctx, -100, 9)
+	require.NoError(t, err)
+	assert.Empty(t, got, "the notifications of other tenants aren't visible")
+
+	got, err = repo.GetNotificationProducts(ctx, -200, 7)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	deleted, err := repo.PruneHistory(ctx, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	got, err = repo.GetNotificationProducts(ctx, -100, 8)
+	require.NoError(t, err)
+	assert.Empty(t, got, "expired notifications are pruned")
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestRecordNotificationProducts(t *testing.T) {
+	ctx := t.Context()
+	products := []models.ProductRef{{Model: "A1"}}
+
+	t.Run("error: begin transaction", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin().WillReturnError(assert.AnError)
+
+		// Act
+		err := repo.RecordNotificationProducts(ctx, 1, 2, time.Now(), products)
+
+		// Assert
+		require.ErrorContains(t, err, "failed to begin transaction")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: insert product", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectPrepare("INSERT OR REPLACE INTO notification_products").ExpectExec().WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		err := repo.RecordNotificationProducts(ctx, 1, 2, time.Now(), products)
+
+		// Assert
+		require.ErrorContains(t, err, "failed to insert product")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetNotificationProducts(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("SELECT category, model FROM notification_products").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.GetNotificationProducts(ctx, 1, 2)
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.GetNotificationProducts: failed to query products")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,64 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Option configures optional Repository behavior.
+type Option func(*Repository)
+
+// QueryObserver is called after every query method of the repository with the name of the method, how
+// long it took and its error, if any.
+type QueryObserver func(method string, took time.Duration, err error)
+
+// WithQueryTimeout bounds every query method of the repository by the timeout, zero bounds them by the
+// context of the caller only. A state update made product by product is bounded as a whole, from
+// BeginStateUpdate to its commit. The dump, the load and the maintenance scale with the database and are
+// left unbounded.
+func WithQueryTimeout(timeout time.Duration) Option {
+	return func(r *Repository) {
+		r.queryTimeout = timeout
+	}
+}
+
+// WithQueryObserver registers an observer notified about every query method of the repository.
+func WithQueryObserver(observer QueryObserver) Option {
+	return func(r *Repository) {
+		r.queryObserver = observer
+	}
+}
+
+// observe bounds the context of a query method by the query timeout. The returned function must be
+// deferred with the error of the method: it reports the query to the observer and returns the error,
+// wrapped with repository.ErrQueryTimeout if the timeout stopped the query, or with
+// repository.ErrStateConflict if another connection held the database.
+func (r *Repository) observe(ctx context.Context, method string) (context.Context, func(error) error) {
+	start := time.Now()
+	queryCtx, cancel := ctx, context.CancelFunc(func() {})
+	if r.queryTimeout > 0 {
+		queryCtx, cancel = context.WithTimeout(ctx, r.queryTimeout)
+	}
+
+	return queryCtx, func(err error) error {
+		cancel()
+		// The deadline of the caller isn't the query timeout.
+		if err != nil && errors.Is(queryCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			err = fmt.Errorf("%w after %s: %w", repository.ErrQueryTimeout, r.queryTimeout, err)
+		}
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked) {
+			err = fmt.Errorf("%w: %w", repository.ErrStateConflict, err)
+		}
+		if r.queryObserver != nil {
+			r.queryObserver(method, time.Since(start), err)
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,163 @@
+package sqlite_test
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// observedQuery is a query reported to the query observer.
+type observedQuery struct {
+	method string
+	err    error
+}
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_QueryObserver(t *testing.T) {
+	// Arrange
+	var queries []observedQuery
+	observer := func(method string, _ time.Duration, err error) {
+		queries = append(queries, observedQuery{method: method, err: err})
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo, err := sqlite.NewRepository(t.Context(), logger, filepath.Join(t.TempDir(), "test.db"),
+		sqlite.WithQueryTimeout(time.Minute), sqlite.WithQueryObserver(observer))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = repo.Close() })
+
+	// Act: the scoped repositories report their queries too.
+	require.NoError(t, repo.ForTenant("acme").SubscribeChat(t.Context(), 1))
+	_, err = repo.GetState(t.Context())
+	require.ErrorIs(t, err, repository.ErrStateNotFound)
+
+	// Assert
+	require.Len(t, queries, 2)
+	assert.Equal(t, observedQuery{method: "SubscribeChat"}, queries[0])
+	assert.Equal(t, "GetState", queries[1].method)
+	require.ErrorIs(t, queries[1].err, repository.ErrStateNotFound)
+}
+
+func TestRepository_Integration_StateUpdateObserver(t *testing.T) {
+	var queries []observedQuery
+	newRepo := func(t *testing.T, timeout time.Duration) *sqlite.Repository {
+		t.Helper()
+
+		queries = nil
+		logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		repo, err := sqlite.NewRepository(t.Context(), logger, filepath.Join(t.TempDir(), "test.db"),
+			sqlite.WithQueryTimeout(timeout), sqlite.WithQueryObserver(func(method string, _ time.Duration, err error) {
+				queries = append(queries, observedQuery{method: method, err: err})
+			}))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = repo.Close() })
+
+		return repo
+	}
+
+	t.Run("committed update", func(t *testing.T) {
+		repo := newRepo(t, time.Minute)
+		update, err := repo.BeginStateUpdate(t.Context())
+		require.NoError(t, err)
+		put(t, update, models.Product{Model: "A1", Price: "100"})
+		require.NoError(t, update.Commit(t.Context(), "hash"))
+		require.NoError(t, update.Rollback())
+
+		assert.Equal(t, []observedQuery{{method: "StateUpdate"}}, queries, "the update is observed once")
+	})
+
+	t.Run("rolled back update", func(t *testing.T) {
+		repo := newRepo(t, time.Minute)
+		update, err := repo.BeginStateUpdate(t.Context())
+		require.NoError(t, err)
+		require.NoError(t, update.Rollback())
+
+		require.Len(t, queries, 1)
+		assert.Equal(t, "StateUpdate", queries[0].method)
+		require.Error(t, queries[0].err)
+	})
+
+	t.Run("error: update timed out", func(t *testing.T) {
+		repo := newRepo(t, 20*time.Millisecond)
+		update, err := repo.BeginStateUpdate(t.Context())
+		require.NoError(t, err)
+		time.Sleep(100 * time.Millisecond)
+
+		err = update.Commit(t.Context(), "hash")
+
+		require.ErrorIs(t, err, repository.ErrQueryTimeout)
+		require.Len(t, queries, 1)
+		require.ErrorIs(t, queries[0].err, repository.ErrQueryTimeout)
+		_, err = repo.GetPageHash(t.Context())
+		require.ErrorIs(t, err, repository.ErrStateNotFound, "the update isn't committed")
+	})
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestRepository_QueryTimeout(t *testing.T) {
+	t.Run("error: query timed out", func(t *testing.T) {
+		// Arrange
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		t.Cleanup(func() { mockDB.Close() })
+		var queries []observedQuery
+		repo := sqlite.NewForTest(mockDB, sqlite.WithQueryTimeout(10*time.Millisecond),
+			sqlite.WithQueryObserver(func(method string, _ time.Duration, err error) {
+				queries = append(queries, observedQuery{method: method, err: err})
+			}))
+		mock.ExpectQuery("SELECT chat_id FROM subscriptions").WillDelayFor(time.Second).
+			WillReturnRows(sqlmock.NewRows([]string{"chat_id"}))
+
+		// Act
+		_, err = repo.GetSubscribedChats(t.Context())
+
+		// Assert
+		require.ErrorIs(t, err, repository.ErrQueryTimeout)
+		assert.ErrorContains(t, err, "repository.sqlite.GetSubscribedChats")
+		require.Len(t, queries, 1)
+		assert.Equal(t, "GetSubscribedChats", queries[0].method)
+		require.ErrorIs(t, queries[0].err, repository.ErrQueryTimeout)
+	})
+
+	t.Run("error: query failed in time", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("SELECT chat_id FROM subscriptions").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.GetSubscribedChats(t.Context())
+
+		// Assert
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NotErrorIs(t, err, repository.ErrQueryTimeout)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("error: database locked", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("SELECT chat_id FROM subscriptions").WillReturnError(sqlite3.Error{Code: sqlite3.ErrBusy})
+
+		// Act
+		_, err := repo.GetSubscribedChats(t.Context())
+
+		// Assert
+		require.ErrorIs(t, err, repository.ErrStateConflict)
+		assert.NotErrorIs(t, err, repository.ErrQueryTimeout)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
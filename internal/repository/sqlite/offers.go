@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// GetOffers returns the current products of all targets of the tenant matching the product by the
+// normalized model or the EAN, ordered by target. A product matched by the model links the products
+// sharing its EAN too. Regional targets are left out, as their prices aren't comparable.
+func (r *Repository) GetOffers(ctx context.Context, product models.Product) (_ []models.Offer, err error) {
+	const opn = "repository.sqlite.GetOffers"
+	ctx, done := r.observe(ctx, "GetOffers")
+	defer func() { err = done(err) }()
+
+	matchModel := models.MatchModel(product.Model)
+	rows, err := r.db.QueryContext(
+		ctx,
+		`WITH scope AS (
+			SELECT tenant_id, `+productColumns+`, match_model, match_ean FROM products
+			WHERE (tenant_id = ?1 OR tenant_id LIKE ?2) AND tenant_id NOT LIKE '%`+models.RegionSeparator+`%'
+		)
+		SELECT tenant_id, `+productColumns+` FROM scope
+		WHERE (?3 != '' AND match_model = ?3) OR (?4 != '' AND match_ean = ?4)
+			OR match_ean IN (SELECT match_ean FROM scope WHERE ?3 != '' AND match_model = ?3 AND match_ean != '')
+		ORDER BY tenant_id, category, model`,
+		r.tenant, r.tenant+"/%", matchModel, product.EAN(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get offers: %w", opn, err)
+	}
+	defer rows.Close()
+
+	var offers []models.Offer
+	for rows.Next() {
+		var (
+			scope string
+			offer models.Offer
+		)
+		if err = rows.Scan(append([]any{&scope}, productFields(&offer.Product)...)...); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan offer: %w", opn, err)
+		}
+		offer.Target = models.MainTarget
+		if name, ok := strings.CutPrefix(scope, r.tenant+"/"); ok {
+			offer.Target = name
+		}
+		offers = append(offers, offer)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	return offers, nil
+}
+
+// backfillMatchKeys stores the normalized model and the EAN of the products saved before they were kept.
+// Products whose model has no letters or digits are checked again on every start, there are only a few.
+func backfillMatchKeys(ctx context.Context, dtb *sql.DB) error {
+	rows, err := dtb.QueryContext(
+		ctx, "SELECT rowid, model, extras FROM products WHERE match_model = '' AND model != ''",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get products without match keys: %w", err)
+	}
+
+	type matchKeys struct{ model, ean string }
+	keys := make(map[int64]matchKeys)
+	for rows.Next() {
+		var (
+			rowID int64
+			p     models.Product
+		)
+		if err = rows.Scan(&rowID, &p.Model, extrasColumn{&p.Extras}); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan product: %w", err)
+		}
+		if model := models.MatchModel(p.Model); model != "" {
+			keys[rowID] = matchKeys{model: model, ean: p.EAN()}
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	txn, err := dtb.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = txn.Rollback() }()
+
+	for rowID, key := range keys {
+		_, err = txn.ExecContext(
+			ctx, "UPDATE products SET match_model = ?, match_ean = ? WHERE rowid = ?", key.model, key.ean, rowID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store the match keys: %w", err)
+		}
+	}
+	if err = txn.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_GetOffers(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	ean := func(value string) map[string]string { return map[string]string{"EAN": value} }
+	require.NoError(t, repo.UpdateState(ctx, &models.State{PageHash: "hash", Products: []models.Product{
+		{Model: "GA-2100 1A", Category: "new", Price: "1 250"},
+		{Model: "Edifice", Price: "300", Extras: ean("4971850-000017")},
+	}}))
+	require.NoError(t, repo.ForTarget("supplier-b").UpdateState(ctx, &models.State{
+		PageHash: "hash", Products: []models.Product{
+			{Model: "ga2100-1a", Price: "1 199"},
+			{Model: "Casio EFR-556", Price: "280", Extras: ean("4971850000017")},
+		},
+	}))
+	update, err := repo.ForTarget("supplier-c").BeginStateUpdate(ctx)
+	require.NoError(t, err)
+	put(t, update, models.Product{Model: "GA 2100 1A", Price: "1 300", Extras: ean("123")})
+	require.NoError(t, update.Commit(ctx, "hash"))
+	require.NoError(t, repo.ForTarget("supplier-b@de").UpdateState(ctx, &models.State{
+		PageHash: "hash", Products: []models.Product{{Model: "GA-2100-1A", Price: "99 EUR"}},
+	}))
+	require.NoError(t, repo.ForTenant("acme").UpdateState(ctx, &models.State{
+		PageHash: "hash", Products: []models.Product{{Model: "GA-2100-1A", Price: "1"}},
+	}))
+	offersOf := func(offers []models.Offer) []string {
+		described := make([]string, 0, len(offers))
+		for _, offer := range offers {
+			described = append(described, offer.Target+": "+offer.Product.Model)
+		}
+		return described
+	}
+
+	testCases := []struct {
+		name    string
+		product models.Product
+		want    []string
+	}{
+		{
+			"normalized model", models.Product{Model: "ga-2100-1a"},
+			[]string{"main: GA-2100 1A", "supplier-b: ga2100-1a", "supplier-c: GA 2100 1A"},
+		},
+		{"EAN", models.Product{Extras: ean("4971850000017")}, []string{"main: Edifice", "supplier-b: Casio EFR-556"}},
+		{
+			"model linking the EAN", models.Product{Model: "Edifice"},
+			[]string{"main: Edifice", "supplier-b: Casio EFR-556"},
+		},
+		{"no match", models.Product{Model: "Rolex"}, []string{}},
+		{"nothing to match by", models.Product{Model: "--"}, []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			offers, err := repo.GetOffers(ctx, tc.product)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, offersOf(offers))
+		})
+	}
+
+	t.Run("products are returned as they are", func(t *testing.T) {
+		// Act
+		offers, err := repo.GetOffers(ctx, models.Product{Extras: ean("123")})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, []models.Offer{{
+			Target:  "supplier-c",
+			Product: models.Product{Model: "GA 2100 1A", Price: "1 300", Extras: ean("123")},
+		}}, offers)
+	})
+}
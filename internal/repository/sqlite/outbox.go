@@ -0,0 +1,216 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// enqueueChanges queues the change set of the tenant within the transaction saving the state, it's
+// pending until it's delivered, see GetPendingChanges.
+func enqueueChanges(ctx context.Context, tx *sql.Tx, tenant string, entry *models.OutboxEntry) error {
+	payload, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode changes: %w", err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO outbox (tenant_id, run_id, detected_at, changes, fingerprint) VALUES (?, ?, ?, ?, ?)",
+		tenant,
+		entry.RunID,
+		entry.DetectedAt.UTC(),
+		string(payload),
+		entry.Changes.Fingerprint(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to queue changes: %w", err)
+	}
+
+	return nil
+}
+
+// GetPendingChanges returns the queued change sets of the tenant and its targets that weren't delivered
+// yet, oldest first. The targets of the tenant are delivered by its bot, so they share the queue.
+func (r *Repository) GetPendingChanges(ctx context.Context) (_ []models.OutboxEntry, err error) {
+	const opn = "repository.sqlite.GetPendingChanges"
+	ctx, done := r.observe(ctx, "GetPendingChanges")
+	defer func() { err = done(err) }()
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, tenant_id, run_id, detected_at, changes FROM outbox
+		WHERE (tenant_id = ? OR tenant_id LIKE ?) AND delivered_at IS NULL ORDER BY id`,
+		r.tenant,
+		r.tenant+"/%",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get pending changes: %w", opn, err)
+	}
+	defer rows.Close()
+
+	var entries []models.OutboxEntry
+	for rows.Next() {
+		var (
+			entry   models.OutboxEntry
+			scope   string
+			payload string
+		)
+		if err = rows.Scan(&entry.ID, &scope, &entry.RunID, &entry.DetectedAt, &payload); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan entry: %w", opn, err)
+		}
+		if err = json.Unmarshal([]byte(payload), &entry.Changes); err != nil {
+			return nil, fmt.Errorf("%s: failed to decode changes of entry %d: %w", opn, entry.ID, err)
+		}
+
+		entry.Target = models.MainTarget
+		if scope != r.tenant {
+			entry.Target = strings.TrimPrefix(scope, r.tenant+"/")
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	return entries, nil
+}
+
+// ClaimChanges claims the queued change set with the ID for its delivery until the lease expired, so a
+// single process sends it. A change set that isn't marked delivered when its lease expired, e.g. as the
+// process stopped while sending it, is claimed again, see MarkChangesDelivered. It returns false if the
+// change set was delivered or is claimed by an unexpired lease, or if it has the fingerprint of the change
+// set of its scope claimed right before it: the same changes can't be detected twice in a row, so it's a
+// replay, e.g. of a restored database, and it's marked delivered without being sent. Delivered entries
+// are pruned with the history, see PruneHistory.
+func (r *Repository) ClaimChanges(
+	ctx context.Context,
+	id int64,
+	claimedAt time.Time,
+	lease time.Duration,
+) (_ bool, err error) {
+	const opn = "repository.sqlite.ClaimChanges"
+	ctx, done := r.observe(ctx, "ClaimChanges")
+	defer func() { err = done(err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil) //nolint:varnamelen // tx its a default naming for transaction
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to begin transaction: %w", opn, err)
+	}
+	defer tx.Rollback() //nolint:errcheck // The rollback after a commit does nothing.
+
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE outbox SET claimed_until = ?
+		WHERE id = ? AND delivered_at IS NULL AND (claimed_until IS NULL OR claimed_until <= ?)`,
+		claimedAt.Add(lease).UTC(),
+		id,
+		claimedAt.UTC(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to claim changes: %w", opn, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to get affected rows: %w", opn, err)
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	var replayed sql.NullBool
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT entry.fingerprint = (
+			SELECT previous.fingerprint FROM outbox AS previous
+			WHERE previous.tenant_id = entry.tenant_id AND previous.id < entry.id
+				AND (previous.delivered_at IS NOT NULL OR previous.claimed_until IS NOT NULL)
+			ORDER BY previous.id DESC LIMIT 1
+		) FROM outbox AS entry WHERE entry.id = ?`,
+		id,
+	).Scan(&replayed)
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to compare fingerprints: %w", opn, err)
+	}
+	if replayed.Bool {
+		_, err = tx.ExecContext(ctx, "UPDATE outbox SET delivered_at = ? WHERE id = ?", claimedAt.UTC(), id)
+		if err != nil {
+			return false, fmt.Errorf("%s: failed to mark replayed changes delivered: %w", opn, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("%s: failed to commit transaction: %w", opn, err)
+	}
+
+	return !replayed.Bool, nil
+}
+
+// MarkChangesDelivered marks the queued change set with the ID delivered once it was sent, it's no longer
+// pending then.
+func (r *Repository) MarkChangesDelivered(ctx context.Context, id int64, deliveredAt time.Time) (err error) {
+	const opn = "repository.sqlite.MarkChangesDelivered"
+	ctx, done := r.observe(ctx, "MarkChangesDelivered")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(
+		ctx, "UPDATE outbox SET delivered_at = ? WHERE id = ? AND delivered_at IS NULL", deliveredAt.UTC(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: failed to mark changes delivered: %w", opn, err)
+	}
+
+	return nil
+}
+
+// ClaimDiff remembers the fingerprint of a change set of the target delivered at the given time, see
+// models.Changes.Fingerprint, until the ttl passed. It returns false if a change set with the same
+// fingerprint was delivered within its ttl, e.g. by a manual check overlapping a scheduled one, the change
+// set must not be sent then. Expired fingerprints are deleted.
+func (r *Repository) ClaimDiff(
+	ctx context.Context,
+	target, fingerprint string,
+	claimedAt time.Time,
+	ttl time.Duration,
+) (_ bool, err error) {
+	const opn = "repository.sqlite.ClaimDiff"
+	ctx, done := r.observe(ctx, "ClaimDiff")
+	defer func() { err = done(err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil) //nolint:varnamelen // tx its a default naming for transaction
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to begin transaction: %w", opn, err)
+	}
+	defer tx.Rollback() //nolint:errcheck // The rollback after a commit does nothing.
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM diff_cache WHERE expires_at <= ?", claimedAt.UTC()); err != nil {
+		return false, fmt.Errorf("%s: failed to delete expired fingerprints: %w", opn, err)
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		"INSERT OR IGNORE INTO diff_cache (tenant_id, fingerprint, expires_at) VALUES (?, ?, ?)",
+		targetScope(r.tenant, target),
+		fingerprint,
+		claimedAt.Add(ttl).UTC(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to remember fingerprint: %w", opn, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to get affected rows: %w", opn, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("%s: failed to commit transaction: %w", opn, err)
+	}
+
+	return affected == 1, nil
+}
@@ -0,0 +1,315 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_Outbox(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	detectedAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	changes := &models.Changes{
+		Added:   []models.Product{{Model: "A1", Category: "new", Price: "100"}},
+		Changed: []models.ChangeInfo{{Old: models.Product{Model: "B2"}, New: models.Product{Model: "B2", Price: "90"}}},
+	}
+
+	queue := func(repo *sqlite.Repository, runID string, detectedAt time.Time) {
+		t.Helper()
+		require.NoError(t, repo.UpdateState(ctx, &models.State{
+			PageHash: "hash",
+			Outbox:   &models.OutboxEntry{RunID: runID, DetectedAt: detectedAt, Changes: changes},
+		}))
+	}
+	queue(repo, "run-1", detectedAt)
+	queue(repo.ForTarget("outlet"), "run-2", detectedAt.Add(time.Minute))
+	queue(repo.ForTenant("acme"), "run-3", detectedAt)
+	require.NoError(t, repo.UpdateState(ctx, &models.State{PageHash: "hash"}), "a state without changes queues nothing")
+
+	t.Run("changes queued by a committed state update", func(t *testing.T) {
+		update, err := repo.ForTenant("acme").BeginStateUpdate(ctx)
+		require.NoError(t, err)
+		entry := &models.OutboxEntry{RunID: "run-4", DetectedAt: detectedAt, Changes: changes}
+		require.NoError(t, update.Enqueue(ctx, entry))
+		require.NoError(t, update.Commit(ctx, "hash"))
+
+		update, err = repo.ForTenant("acme").BeginStateUpdate(ctx)
+		require.NoError(t, err)
+		entry = &models.OutboxEntry{RunID: "run-5", DetectedAt: detectedAt, Changes: changes}
+		require.NoError(t, update.Enqueue(ctx, entry))
+		require.NoError(t, update.Rollback())
+
+		entries, err := repo.ForTenant("acme").GetPendingChanges(ctx)
+
+		require.NoError(t, err)
+		require.Len(t, entries, 2, "the changes of a rolled back update are not queued")
+		assert.Equal(t, "run-3", entries[0].RunID)
+		assert.Equal(t, "run-4", entries[1].RunID)
+	})
+
+	t.Run("pending change sets of the tenant and its targets", func(t *testing.T) {
+		entries, err := repo.GetPendingChanges(ctx)
+
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "run-1", entries[0].RunID)
+		assert.Equal(t, models.MainTarget, entries[0].Target)
+		assert.True(t, detectedAt.Equal(entries[0].DetectedAt))
+		assert.Equal(t, changes, entries[0].Changes)
+		assert.Equal(t, "outlet", entries[1].Target)
+	})
+
+	t.Run("delivered change sets are no longer pending", func(t *testing.T) {
+		entries, err := repo.GetPendingChanges(ctx)
+		require.NoError(t, err)
+		claimed, err := repo.ClaimChanges(ctx, entries[0].ID, detectedAt.Add(time.Hour), time.Minute)
+		require.NoError(t, err)
+		require.True(t, claimed)
+
+		claimed, err = repo.ClaimChanges(ctx, entries[0].ID, detectedAt.Add(time.Hour), time.Minute)
+		require.NoError(t, err)
+		assert.False(t, claimed, "a change set is claimed once within its lease")
+		entries, err = repo.GetPendingChanges(ctx)
+		require.NoError(t, err)
+		require.Len(t, entries, 2, "a claimed change set is pending until it's delivered")
+
+		claimed, err = repo.ClaimChanges(ctx, entries[0].ID, detectedAt.Add(time.Hour+time.Minute), time.Minute)
+		require.NoError(t, err)
+		require.True(t, claimed, "a change set whose lease expired is claimed again")
+		require.NoError(t, repo.MarkChangesDelivered(ctx, entries[0].ID, detectedAt.Add(time.Hour)))
+
+		claimed, err = repo.ClaimChanges(ctx, entries[0].ID, detectedAt.Add(2*time.Hour), time.Minute)
+		require.NoError(t, err)
+		assert.False(t, claimed, "a delivered change set isn't claimed again")
+		entries, err = repo.GetPendingChanges(ctx)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "run-2", entries[0].RunID)
+	})
+
+	t.Run("change set replaying the previous one is skipped", func(t *testing.T) {
+		acme := repo.ForTenant("acme")
+		entries, err := acme.GetPendingChanges(ctx)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+
+		claimed, err := acme.ClaimChanges(ctx, entries[0].ID, detectedAt.Add(time.Hour), time.Minute)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+		claimed, err = acme.ClaimChanges(ctx, entries[1].ID, detectedAt.Add(time.Hour), time.Minute)
+		require.NoError(t, err)
+		assert.False(t, claimed, "the same changes can't be detected twice in a row")
+
+		pending, err := acme.GetPendingChanges(ctx)
+		require.NoError(t, err)
+		require.Len(t, pending, 1, "the replayed change set is no longer pending")
+		assert.Equal(t, entries[0].ID, pending[0].ID)
+		require.NoError(t, acme.MarkChangesDelivered(ctx, entries[0].ID, detectedAt.Add(time.Hour)))
+	})
+
+	t.Run("change set of another target is not a replay", func(t *testing.T) {
+		entries, err := repo.GetPendingChanges(ctx)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+
+		claimed, err := repo.ClaimChanges(ctx, entries[0].ID, detectedAt.Add(time.Hour), time.Minute)
+
+		require.NoError(t, err)
+		assert.True(t, claimed, "the previous change set with the same changes is of the main page")
+		require.NoError(t, repo.MarkChangesDelivered(ctx, entries[0].ID, detectedAt.Add(time.Hour)))
+	})
+
+	t.Run("delivered change sets are pruned", func(t *testing.T) {
+		_, err := repo.PruneHistory(ctx, detectedAt.Add(2*time.Hour))
+		require.NoError(t, err)
+
+		entries, err := repo.GetPendingChanges(ctx)
+
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestRepository_Integration_ClaimDiff(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	claimedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	claimed, err := repo.ClaimDiff(ctx, "", "abc", claimedAt, 5*time.Minute)
+	require.NoError(t, err)
+	assert.True(t, claimed, "a new fingerprint is claimed")
+
+	claimed, err = repo.ClaimDiff(ctx, "", "abc", claimedAt.Add(time.Minute), 5*time.Minute)
+	require.NoError(t, err)
+	assert.False(t, claimed, "the same fingerprint within the window is a duplicate")
+
+	claimed, err = repo.ClaimDiff(ctx, "shop", "abc", claimedAt.Add(time.Minute), 5*time.Minute)
+	require.NoError(t, err)
+	assert.True(t, claimed, "the fingerprints of the targets are apart")
+
+	claimed, err = repo.ForTenant("acme").ClaimDiff(ctx, "", "abc", claimedAt.Add(time.Minute), 5*time.Minute)
+	require.NoError(t, err)
+	assert.True(t, claimed, "the fingerprints of the tenants are apart")
+
+	claimed, err = repo.ClaimDiff(ctx, "", "abc", claimedAt.Add(5*time.Minute), 5*time.Minute)
+	require.NoError(t, err)
+	assert.True(t, claimed, "an expired fingerprint is claimed again")
+}
+
+func TestUpdateState_Outbox(t *testing.T) {
+	t.Run("error: insert", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT OR REPLACE INTO page_state").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery("DELETE FROM products").WillReturnRows(sqlmock.NewRows(productColumns))
+		mock.ExpectPrepare("INSERT INTO products")
+		mock.ExpectExec("INSERT INTO outbox").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		err := repo.UpdateState(t.Context(), &models.State{
+			Outbox: &models.OutboxEntry{RunID: "run", DetectedAt: time.Now(), Changes: &models.Changes{}},
+		})
+
+		// Assert
+		require.ErrorContains(t, err, "failed to queue changes")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetPendingChanges(t *testing.T) {
+	columns := []string{"id", "tenant_id", "run_id", "detected_at", "changes"}
+
+	t.Run("error: query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("FROM outbox").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.GetPendingChanges(t.Context())
+
+		// Assert
+		require.ErrorContains(t, err, "failed to get pending changes")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: invalid payload", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("FROM outbox").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(7, "", "run", time.Now(), "{broken"))
+
+		// Act
+		_, err := repo.GetPendingChanges(t.Context())
+
+		// Assert
+		require.ErrorContains(t, err, "failed to decode changes of entry 7")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestClaimChanges(t *testing.T) {
+	t.Run("error: update", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE outbox").
+			WithArgs(sqlmock.AnyArg(), int64(7), sqlmock.AnyArg()).
+			WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		_, err := repo.ClaimChanges(t.Context(), 7, time.Now(), time.Minute)
+
+		// Assert
+		require.ErrorContains(t, err, "failed to claim changes")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: compare fingerprints", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE outbox").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT entry.fingerprint").WithArgs(int64(7)).WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		_, err := repo.ClaimChanges(t.Context(), 7, time.Now(), time.Minute)
+
+		// Assert
+		require.ErrorContains(t, err, "failed to compare fingerprints")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMarkChangesDelivered(t *testing.T) {
+	t.Run("error: update", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("UPDATE outbox").WithArgs(sqlmock.AnyArg(), int64(7)).WillReturnError(assert.AnError)
+
+		// Act
+		err := repo.MarkChangesDelivered(t.Context(), 7, time.Now())
+
+		// Assert
+		require.ErrorContains(t, err, "failed to mark changes delivered")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestClaimDiff(t *testing.T) {
+	t.Run("error: delete expired", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM diff_cache").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		_, err := repo.ClaimDiff(t.Context(), "", "abc", time.Now(), time.Minute)
+
+		// Assert
+		require.ErrorContains(t, err, "failed to delete expired fingerprints")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: insert", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM diff_cache").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT OR IGNORE INTO diff_cache").
+			WithArgs(sqlmock.AnyArg(), "abc", sqlmock.AnyArg()).WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		_, err := repo.ClaimDiff(t.Context(), "", "abc", time.Now(), time.Minute)
+
+		// Assert
+		require.ErrorContains(t, err, "failed to remember fingerprint")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,130 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// SwapPage stores the page as the last page fetched unless it has the hash of the stored one, and returns
+// the stored page it replaced.
+func (r *Repository) SwapPage(ctx context.Context, hash string, page []byte) (_ []byte, err error) {
+	const opn = "repository.sqlite.SwapPage"
+	ctx, done := r.observe(ctx, "SwapPage")
+	defer func() { err = done(err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil) //nolint:varnamelen // tx its a default naming for transaction
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", opn, err)
+	}
+	defer tx.Rollback() //nolint:errcheck // The rollback after a commit does nothing.
+
+	var previous []byte
+	err = tx.QueryRowContext(
+		ctx, "SELECT page FROM page_snapshots WHERE tenant_id = ? AND page_hash <> ?", r.tenant, hash,
+	).Scan(&previous)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: failed to get the previous page: %w", opn, err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO page_snapshots (tenant_id, page_hash, page) VALUES (?, ?, ?)
+		ON CONFLICT (tenant_id) DO UPDATE SET page_hash = excluded.page_hash, page = excluded.page
+		WHERE page_hash <> excluded.page_hash`,
+		r.tenant,
+		hash,
+		page,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to store the page: %w", opn, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit transaction: %w", opn, err)
+	}
+
+	return previous, nil
+}
+
+// RecordPageDiff stores the diff of the pages and deletes the diffs older than the last keep ones.
+func (r *Repository) RecordPageDiff(ctx context.Context, diff models.PageDiff, keep int) (err error) {
+	const opn = "repository.sqlite.RecordPageDiff"
+	ctx, done := r.observe(ctx, "RecordPageDiff")
+	defer func() { err = done(err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil) //nolint:varnamelen // tx its a default naming for transaction
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", opn, err)
+	}
+	defer tx.Rollback() //nolint:errcheck // The rollback after a commit does nothing.
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO page_diffs (tenant_id, run_id, checked_at, old_hash, new_hash, diff)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		r.tenant,
+		diff.RunID,
+		diff.CheckedAt.UTC(),
+		diff.OldHash,
+		diff.NewHash,
+		diff.Diff,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: failed to insert diff: %w", opn, err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM page_diffs WHERE tenant_id = ?1 AND id NOT IN (
+			SELECT id FROM page_diffs WHERE tenant_id = ?1 ORDER BY id DESC LIMIT ?2
+		)`,
+		r.tenant,
+		keep,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: failed to delete old diffs: %w", opn, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", opn, err)
+	}
+
+	return nil
+}
+
+// GetPageDiffs returns the stored diffs of the pages, newest first.
+func (r *Repository) GetPageDiffs(ctx context.Context) (_ []models.PageDiff, err error) {
+	const opn = "repository.sqlite.GetPageDiffs"
+	ctx, done := r.observe(ctx, "GetPageDiffs")
+	defer func() { err = done(err) }()
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT run_id, checked_at, old_hash, new_hash, diff FROM page_diffs
+		WHERE tenant_id = ? ORDER BY id DESC`,
+		r.tenant,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get diffs: %w", opn, err)
+	}
+	defer rows.Close()
+
+	var diffs []models.PageDiff
+	for rows.Next() {
+		var diff models.PageDiff
+		if err = rows.Scan(&diff.RunID, &diff.CheckedAt, &diff.OldHash, &diff.NewHash, &diff.Diff); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan diff: %w", opn, err)
+		}
+		diffs = append(diffs, diff)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	return diffs, nil
+}
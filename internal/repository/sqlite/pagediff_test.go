@@ -0,0 +1,155 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_SwapPage(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+
+	// Act & Assert
+	previous, err := repo.SwapPage(ctx, "h1", []byte("<p>one</p>"))
+	require.NoError(t, err)
+	assert.Nil(t, previous, "there is no previous page on the first run")
+
+	previous, err = repo.SwapPage(ctx, "h1", []byte("<p>one</p>"))
+	require.NoError(t, err)
+	assert.Nil(t, previous, "the same page isn't a previous one")
+
+	previous, err = repo.SwapPage(ctx, "h2", []byte("<p>two</p>"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("<p>one</p>"), previous)
+
+	previous, err = repo.SwapPage(ctx, "h3", []byte("<p>three</p>"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("<p>two</p>"), previous, "the page was replaced")
+
+	previous, err = repo.ForTenant("acme").SwapPage(ctx, "h4", []byte("<p>four</p>"))
+	require.NoError(t, err)
+	assert.Nil(t, previous, "the pages are scoped by tenant")
+}
+
+func TestRepository_Integration_PageDiffs(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	checkedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// Arrange
+	for i, runID := range []string{"run-1", "run-2", "run-3"} {
+		diff := models.PageDiff{
+			RunID:     runID,
+			CheckedAt: checkedAt.Add(time.Duration(i) * time.Minute),
+			OldHash:   "old-" + runID,
+			NewHash:   "new-" + runID,
+			Diff:      "-a\n+b\n",
+		}
+		require.NoError(t, repo.RecordPageDiff(ctx, diff, 2))
+	}
+	require.NoError(t, repo.ForTenant("acme").RecordPageDiff(ctx, models.PageDiff{RunID: "run-4"}, 2))
+
+	// Act
+	diffs, err := repo.GetPageDiffs(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []models.PageDiff{
+		{
+			RunID:     "run-3",
+			CheckedAt: checkedAt.Add(2 * time.Minute),
+			OldHash:   "old-run-3",
+			NewHash:   "new-run-3",
+			Diff:      "-a\n+b\n",
+		},
+		{
+			RunID:     "run-2",
+			CheckedAt: checkedAt.Add(time.Minute),
+			OldHash:   "old-run-2",
+			NewHash:   "new-run-2",
+			Diff:      "-a\n+b\n",
+		},
+	}, diffs, "only the last diffs are kept, newest first")
+
+	diffs, err = repo.ForTenant("acme").GetPageDiffs(ctx)
+	require.NoError(t, err)
+	require.Len(t, diffs, 1, "the diffs are scoped by tenant")
+	assert.Equal(t, "run-4", diffs[0].RunID)
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestSwapPage(t *testing.T) {
+	t.Run("select fails", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT page FROM page_snapshots").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		_, err := repo.SwapPage(t.Context(), "hash", []byte("page"))
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.SwapPage")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("upsert fails", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT page FROM page_snapshots").WillReturnRows(mock.NewRows([]string{"page"}))
+		mock.ExpectExec("INSERT INTO page_snapshots").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		_, err := repo.SwapPage(t.Context(), "hash", []byte("page"))
+
+		// Assert
+		require.ErrorContains(t, err, "failed to store the page")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRecordPageDiff(t *testing.T) {
+	// Arrange
+	repo, mock := newMockedRepo(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO page_diffs").WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+
+	// Act
+	err := repo.RecordPageDiff(t.Context(), models.PageDiff{RunID: "run"}, 5)
+
+	// Assert
+	require.ErrorContains(t, err, "repository.sqlite.RecordPageDiff")
+	require.ErrorIs(t, err, assert.AnError)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPageDiffs(t *testing.T) {
+	// Arrange
+	repo, mock := newMockedRepo(t)
+	mock.ExpectQuery("SELECT run_id, checked_at").WillReturnError(assert.AnError)
+
+	// Act
+	_, err := repo.GetPageDiffs(t.Context())
+
+	// Assert
+	require.ErrorContains(t, err, "repository.sqlite.GetPageDiffs")
+	require.ErrorIs(t, err, assert.AnError)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,83 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// chatData is a kind of data stored about a chat.
+type chatData struct {
+	kind  string
+	table string
+	// where matches the records of the chat, ?1 is the chat ID.
+	where string
+	// global data isn't scoped by tenant.
+	global bool
+}
+
+// chatDataKinds are the kinds of data stored about a chat, in the order they are reported. The chat ID is
+// matched as the user ID too, as the ID of a private chat is the ID of the user. The access granted to the
+// chat by admins isn't data of the chat and is kept.
+func chatDataKinds() []chatData {
+	return []chatData{
+		{kind: "subscription", table: "subscriptions", where: "chat_id = ?1"},
+		{kind: "settings and filters", table: "chat_settings", where: "chat_id = ?1"},
+		{kind: "wishlists", table: "wishlists", where: "chat_id = ?1"},
+		{kind: "wishlist items", table: "wishlist_items", where: "chat_id = ?1"},
+		{kind: "alert rules", table: "alert_rules", where: "chat_id = ?1"},
+		{kind: "direct messages", table: "users", where: "chat_id = ?1 OR user_id = ?1"},
+		{kind: "subscription history", table: "subscription_events", where: "chat_id = ?1"},
+		{kind: "activity days", table: "chat_activity", where: "chat_id = ?1"},
+		{kind: "sent notifications", table: "notification_products", where: "chat_id = ?1"},
+		{kind: "notification variants", table: "notification_variants", where: "chat_id = ?1"},
+		{kind: "notification ratings", table: "notification_feedback", where: "chat_id = ?1 OR user_id = ?1"},
+		{kind: "delivery failures", table: "unreachable_chats", where: "chat_id = ?1"},
+		{kind: "API tokens", table: "api_tokens", where: "chat_id = ?1", global: true},
+	}
+}
+
+// ForgetChat deletes every record stored about the chat by the tenant and its targets in a single
+// transaction, and returns how many records of every kind were deleted.
+func (r *Repository) ForgetChat(ctx context.Context, chatID int64) (_ []models.ForgottenData, err error) {
+	const opn = "repository.sqlite.ForgetChat"
+	ctx, done := r.observe(ctx, "ForgetChat")
+	defer func() { err = done(err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil) //nolint:varnamelen // tx its a default naming for transaction
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", opn, err)
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after a successful commit only returns sql.ErrTxDone.
+
+	kinds := chatDataKinds()
+	forgotten := make([]models.ForgottenData, 0, len(kinds))
+	for _, data := range kinds {
+		// Table names and conditions come from a fixed list, only the chat ID is user input.
+		query := "DELETE FROM " + data.table + " WHERE (" + data.where + ")"
+		args := []any{chatID}
+		if !data.global {
+			// The data of the tenant's targets is scoped by "<tenant>/<target>".
+			query += " AND (tenant_id = ?2 OR tenant_id LIKE ?3)"
+			args = append(args, r.tenant, r.tenant+"/%")
+		}
+
+		var res sql.Result
+		if res, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return nil, fmt.Errorf("%s: failed to delete %s: %w", opn, data.table, err)
+		}
+		var affected int64
+		if affected, err = res.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("%s: failed to get affected rows of %s: %w", opn, data.table, err)
+		}
+		forgotten = append(forgotten, models.ForgottenData{Kind: data.kind, Deleted: int(affected)})
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit transaction: %w", opn, err)
+	}
+
+	return forgotten, nil
+}
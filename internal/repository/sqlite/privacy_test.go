@@ -0,0 +1,129 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_ForgetChat(t *testing.T) {
+	// Arrange
+	repo := newTestDB(t)
+	ctx := t.Context()
+	now := time.Now().UTC()
+	remember := func(chatID int64) {
+		require.NoError(t, repo.SubscribeChat(ctx, chatID))
+		require.NoError(t, repo.SetFilterGroup(ctx, chatID, "warehouse"))
+		require.NoError(t, repo.AddWishlistItem(ctx, chatID, "A1"))
+		require.NoError(t, repo.AddWishlistItem(ctx, chatID, "B2"))
+		require.NoError(t, repo.SetAlertRule(ctx, models.AlertRule{ChatID: chatID, Name: "cheap", Expression: "true"}))
+		require.NoError(t, repo.RecordChatActivity(ctx, chatID, now))
+		require.NoError(t, repo.RecordNotificationProducts(ctx, chatID, 1, now,
+			[]models.ProductRef{{Category: "new", Model: "A1"}}))
+		require.NoError(t, repo.RecordNotificationVariant(ctx, chatID, 1, "compact", now))
+		_, err := repo.RecordFeedback(ctx, chatID, 1, 7, true, now)
+		require.NoError(t, err)
+		_, err = repo.CreateToken(ctx, models.APIToken{Name: "feed", ChatID: chatID, CreatedAt: now},
+			time.Now().String())
+		require.NoError(t, err)
+	}
+	remember(-100)
+	remember(-200)
+	require.NoError(t, repo.SetUserSubscription(ctx, models.UserSubscription{UserID: 7, ChatID: -100}))
+	require.NoError(t, repo.ForTarget("outlet").SubscribeChat(ctx, -100))
+	require.NoError(t, repo.ForTenant("acme").SubscribeChat(ctx, -100))
+	require.NoError(t, repo.RecordSendFailure(ctx, -100, time.Now(), "Forbidden: bot was kicked", false))
+
+	// Act
+	forgotten, err := repo.ForgetChat(ctx, -100)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []models.ForgottenData{
+		{Kind: "subscription", Deleted: 2},
+		{Kind: "settings and filters", Deleted: 1},
+		{Kind: "wishlists", Deleted: 1},
+		{Kind: "wishlist items", Deleted: 2},
+		{Kind: "alert rules", Deleted: 1},
+		{Kind: "direct messages", Deleted: 1},
+		{Kind: "subscription history", Deleted: 2},
+		{Kind: "activity days", Deleted: 1},
+		{Kind: "sent notifications", Deleted: 1},
+		{Kind: "notification variants", Deleted: 1},
+		{Kind: "notification ratings", Deleted: 1},
+		{Kind: "delivery failures", Deleted: 1},
+		{Kind: "API tokens", Deleted: 1},
+	}, forgotten, "the data of the targets of the tenant is deleted too")
+
+	subscribers, err := repo.GetSubscribedChats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{-200}, subscribers, "the other chats are kept")
+	wishlist, err := repo.GetWishlist(ctx, -200)
+	require.NoError(t, err)
+	assert.Len(t, wishlist.Items, 2)
+	tokens, err := repo.GetTokens(ctx)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, int64(-200), tokens[0].ChatID)
+	acme, err := repo.ForTenant("acme").GetSubscribedChats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{-100}, acme, "the data of other tenants is kept")
+
+	forgotten, err = repo.ForgetChat(ctx, -100)
+	require.NoError(t, err)
+	for _, data := range forgotten {
+		assert.Zero(t, data.Deleted, data.Kind)
+	}
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestForgetChat(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: delete rolls back", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM subscriptions").WithArgs(int64(-100), "", "/%").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM chat_settings").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		_, err := repo.ForgetChat(ctx, -100)
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.ForgetChat: failed to delete chat_settings")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: commit", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		for range 13 {
+			mock.ExpectExec("DELETE FROM").WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+		mock.ExpectCommit().WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.ForgetChat(ctx, -100)
+
+		// Assert
+		require.ErrorContains(t, err, "failed to commit transaction")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
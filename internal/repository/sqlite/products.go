@@ -0,0 +1,385 @@
+package sqlite
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// productOrders are the ORDER BY clauses of the product sort orders. Products without a numeric
+// price come last when sorting by price.
+//
+//nolint:gochecknoglobals // a read-only lookup of SQL clauses.
+var productOrders = map[models.ProductSort]string{
+	models.SortByModel:     "category, model",
+	models.SortByType:      "type, category, model",
+	models.SortByPrice:     "price_value IS NULL, price_value, category, model",
+	models.SortByPriceDesc: "price_value IS NULL, price_value DESC, category, model",
+}
+
+// productColumns lists the columns of the products table in the order productFields scans them.
+const productColumns = "model, category, type, quantity, price, image_url, product_url, extras"
+
+// productFields returns the scan destinations of productColumns.
+func productFields(p *models.Product) []any {
+	return []any{&p.Model, &p.Category, &p.Type, &p.Quantity, &p.Price, &p.ImageURL, &p.ProductURL,
+		extrasColumn{&p.Extras}}
+}
+
+// extrasColumn stores the extra fields of a product as a JSON object, empty if the product has none.
+type extrasColumn struct {
+	extras *map[string]string
+}
+
+// Value implements driver.Valuer.
+func (e extrasColumn) Value() (driver.Value, error) {
+	return marshalOption(*e.extras, len(*e.extras))
+}
+
+// Scan implements sql.Scanner.
+func (e extrasColumn) Scan(src any) error {
+	var stored []byte
+	switch src := src.(type) {
+	case string:
+		stored = []byte(src)
+	case []byte:
+		stored = src
+	case nil:
+	default:
+		return fmt.Errorf("unsupported type %T of the extra fields", src)
+	}
+
+	*e.extras = nil
+	if len(stored) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(stored, e.extras); err != nil {
+		return fmt.Errorf("invalid extra fields: %w", err)
+	}
+
+	return nil
+}
+
+// ListProducts returns a page of the current products matching the filter in the given order, with the
+// number of matching products on all pages. A limit of zero or less returns all products from the offset.
+func (r *Repository) ListProducts(
+	ctx context.Context,
+	filter models.ProductFilter,
+	sort models.ProductSort,
+	offset, limit int,
+) (_ *models.ProductPage, err error) {
+	const opn = "repository.sqlite.ListProducts"
+	ctx, done := r.observe(ctx, "ListProducts")
+	defer func() { err = done(err) }()
+
+	if sort == "" {
+		sort = models.SortByModel
+	}
+	order, ok := productOrders[sort]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w: %q", opn, models.ErrInvalidSort, sort)
+	}
+
+	where, args := productConditions(r.tenant, filter)
+
+	page := &models.ProductPage{}
+	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM products WHERE "+where, args...).Scan(&page.Total)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to count products: %w", opn, err)
+	}
+
+	// SQLite treats a negative limit as no limit.
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+productColumns+" FROM products WHERE "+where+
+			" ORDER BY "+order+" LIMIT ? OFFSET ?",
+		append(args, limit, max(offset, 0))...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get products: %w", opn, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p models.Product
+		err = rows.Scan(productFields(&p)...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan product: %w", opn, err)
+		}
+		page.Products = append(page.Products, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	return page, nil
+}
+
+// productConditions builds the WHERE clause selecting the products of the tenant that match the filter.
+func productConditions(tenant string, filter models.ProductFilter) (string, []any) {
+	conditions := []string{"tenant_id = ?"}
+	args := []any{tenant}
+
+	if filter.Category != "" {
+		conditions = append(conditions, "category = ?")
+		args = append(args, filter.Category)
+	}
+	if filter.Type != "" {
+		conditions = append(conditions, "type = ?")
+		args = append(args, filter.Type)
+	}
+	if filter.MinPrice != nil {
+		conditions = append(conditions, "price_value >= ?")
+		args = append(args, *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		conditions = append(conditions, "price_value <= ?")
+		args = append(args, *filter.MaxPrice)
+	}
+	if filter.InStock {
+		conditions = append(conditions, "quantity GLOB '*[1-9]*'")
+	}
+	for _, name := range slices.Sorted(maps.Keys(filter.Extras)) {
+		conditions = append(conditions, "json_extract(CASE WHEN extras = '' THEN '{}' ELSE extras END, ?) = ?")
+		args = append(args, `$."`+name+`"`, filter.Extras[name])
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// priceValue returns the numeric price stored with a product, NULL if the price isn't a number.
+func priceValue(p models.Product) sql.NullFloat64 {
+	value, err := p.PriceValue()
+
+	return sql.NullFloat64{Float64: value, Valid: err == nil}
+}
+
+// backfillPriceValues stores the numeric price of the products saved before it was kept. Products
+// whose price isn't a number are checked again on every start, there are only a few of them.
+func backfillPriceValues(ctx context.Context, dtb *sql.DB) error {
+	rows, err := dtb.QueryContext(
+		ctx, "SELECT rowid, price FROM products WHERE price_value IS NULL AND COALESCE(price, '') != ''",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get products without a price value: %w", err)
+	}
+
+	values := make(map[int64]float64)
+	for rows.Next() {
+		var (
+			rowID int64
+			price string
+		)
+		if err = rows.Scan(&rowID, &price); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan product: %w", err)
+		}
+		if value, parseErr := models.ParsePrice(price); parseErr == nil {
+			values[rowID] = value
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	txn, err := dtb.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = txn.Rollback() }()
+
+	for rowID, value := range values {
+		_, err = txn.ExecContext(ctx, "UPDATE products SET price_value = ? WHERE rowid = ?", value, rowID)
+		if err != nil {
+			return fmt.Errorf("failed to store the price value: %w", err)
+		}
+	}
+	if err = txn.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// searchWeights are the weights of the hits in the columns of the search index: model and type.
+//
+//nolint:gochecknoglobals // a read-only list of column weights.
+var searchWeights = []float64{2, 1}
+
+// searchResult is a product matching a search with its relevance.
+type searchResult struct {
+	rowID int64
+	score float64
+}
+
+// SearchProducts returns up to limit current products whose model or type contain words starting with
+// the words of the text, the most relevant first. Hits in the model weigh more than hits in the type,
+// and rare words more than common ones.
+func (r *Repository) SearchProducts(ctx context.Context, text string, limit int) (_ []models.Product, err error) {
+	const opn = "repository.sqlite.SearchProducts"
+	ctx, done := r.observe(ctx, "SearchProducts")
+	defer func() { err = done(err) }()
+
+	match := searchMatch(text)
+	if match == "" || limit <= 0 {
+		return nil, nil
+	}
+
+	// Only the row IDs are ranked, so a short prefix matching most of the catalog doesn't load all of it.
+	rows, err := r.db.QueryContext(ctx, `SELECT products.rowid, matchinfo(products_fts, 'pcx')
+		FROM products_fts JOIN products ON products.rowid = products_fts.docid
+		WHERE products_fts MATCH ? AND products.tenant_id = ?`, match, r.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to search products: %w", opn, err)
+	}
+
+	var results []searchResult
+	for rows.Next() {
+		var (
+			result searchResult
+			info   []byte
+		)
+		if err = rows.Scan(&result.rowID, &info); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("%s: failed to scan match: %w", opn, err)
+		}
+		result.score = searchScore(info)
+		results = append(results, result)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	slices.SortStableFunc(results, func(a, b searchResult) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(a.rowID, b.rowID))
+	})
+	results = results[:min(len(results), limit)]
+
+	return r.productsByRowID(ctx, results)
+}
+
+// productsByRowID loads the products of the search results in the order of the results.
+func (r *Repository) productsByRowID(ctx context.Context, results []searchResult) ([]models.Product, error) {
+	const opn = "repository.sqlite.SearchProducts"
+
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	args := make([]any, 0, len(results))
+	positions := make(map[int64]int, len(results))
+	for idx, result := range results {
+		args = append(args, result.rowID)
+		positions[result.rowID] = idx
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT rowid, "+productColumns+" FROM products "+
+			"WHERE rowid IN (?"+strings.Repeat(", ?", len(args)-1)+")", args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get products: %w", opn, err)
+	}
+	defer rows.Close()
+
+	products := make([]models.Product, len(results))
+	found := 0
+	for rows.Next() {
+		var (
+			rowID int64
+			p     models.Product
+		)
+		err = rows.Scan(append([]any{&rowID}, productFields(&p)...)...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan product: %w", opn, err)
+		}
+		products[positions[rowID]] = p
+		found++
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+	if found != len(results) {
+		// A product was removed between the queries, the missing ones are left out.
+		products = slices.DeleteFunc(products, func(p models.Product) bool { return p.Model == "" })
+	}
+
+	return products, nil
+}
+
+// searchMatch converts the text of a search into a full-text query matching the products with words
+// starting with every word of the text. The words are lowercased, so they are never query operators.
+func searchMatch(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for idx, word := range words {
+		words[idx] = word + "*"
+	}
+
+	return strings.Join(words, " ")
+}
+
+// searchScore ranks a match by the matchinfo 'pcx' of the search index: every phrase hit in a column
+// scores the weight of the column divided by the number of hits of the phrase in all rows, so the hits
+// of rare words score more.
+func searchScore(info []byte) float64 {
+	const (
+		intSize = 4
+		// headerSize is the number of values before the hits: the numbers of phrases and columns.
+		headerSize = 2
+		// hitValues is the number of values per phrase and column: the hits in the row, the hits in
+		// all rows and the number of rows with hits.
+		hitValues = 3
+	)
+
+	values := make([]uint32, len(info)/intSize)
+	for idx := range values {
+		values[idx] = binary.NativeEndian.Uint32(info[idx*intSize:])
+	}
+	if len(values) < headerSize {
+		return 0
+	}
+
+	phrases, columns := int(values[0]), int(values[1])
+	hits := values[headerSize:]
+	if len(hits) < phrases*columns*hitValues {
+		return 0
+	}
+
+	var score float64
+	for phrase := range phrases {
+		phraseHits := hits[phrase*columns*hitValues : (phrase+1)*columns*hitValues]
+
+		var total uint32
+		for column := range columns {
+			total += phraseHits[column*hitValues+1]
+		}
+		if total == 0 {
+			continue
+		}
+		for column := range min(columns, len(searchWeights)) {
+			score += searchWeights[column] * float64(phraseHits[column*hitValues]) / float64(total)
+		}
+	}
+
+	return score
+}
@@ -0,0 +1,308 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_ListProducts(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	require.NoError(t, repo.UpdateState(ctx, &models.State{PageHash: "hash", Products: []models.Product{
+		{
+			Model: "A1", Category: "new", Type: "Diver", Quantity: "2", Price: "1 200,50 грн",
+			Extras: map[string]string{"margin_flag": "true", "warranty period": "2 years"},
+		},
+		{Model: "B2", Category: "new", Type: "Dress", Quantity: "0", Price: "300", Extras: map[string]string{
+			"margin_flag": "false",
+		}},
+		{Model: "C3", Category: "used", Type: "Diver", Quantity: "> 5", Price: "on request"},
+		{Model: "D4", Category: "used", Type: "Diver", Quantity: "", Price: "$99.90"},
+	}}))
+	require.NoError(t, repo.ForTenant("acme").UpdateState(ctx, &models.State{
+		PageHash: "hash", Products: []models.Product{{Model: "E5", Type: "Diver", Quantity: "1", Price: "10"}},
+	}))
+	price := func(value float64) *float64 { return &value }
+	modelsOf := func(page *models.ProductPage) []string {
+		names := make([]string, 0, len(page.Products))
+		for _, product := range page.Products {
+			names = append(names, product.Model)
+		}
+		return names
+	}
+
+	testCases := []struct {
+		name          string
+		filter        models.ProductFilter
+		sort          models.ProductSort
+		offset, limit int
+		want          []string
+		wantTotal     int
+	}{
+		{"all in the default order", models.ProductFilter{}, "", 0, 0, []string{"A1", "B2", "C3", "D4"}, 4},
+		{"first page", models.ProductFilter{}, models.SortByModel, 0, 3, []string{"A1", "B2", "C3"}, 4},
+		{"last page", models.ProductFilter{}, models.SortByModel, 3, 3, []string{"D4"}, 4},
+		{"past the last page", models.ProductFilter{}, models.SortByModel, 8, 3, []string{}, 4},
+		{"by type", models.ProductFilter{}, models.SortByType, 0, 0, []string{"A1", "C3", "D4", "B2"}, 4},
+		{"cheapest first", models.ProductFilter{}, models.SortByPrice, 0, 0, []string{"D4", "B2", "A1", "C3"}, 4},
+		{
+			"most expensive first", models.ProductFilter{}, models.SortByPriceDesc, 0, 0,
+			[]string{"A1", "B2", "D4", "C3"}, 4,
+		},
+		{
+			"category and type", models.ProductFilter{Category: "used", Type: "Diver"}, "", 0, 0,
+			[]string{"C3", "D4"}, 2,
+		},
+		{
+			"price range", models.ProductFilter{MinPrice: price(99.9), MaxPrice: price(300)}, models.SortByPrice, 0, 0,
+			[]string{"D4", "B2"}, 2,
+		},
+		{"minimum price", models.ProductFilter{MinPrice: price(1000)}, "", 0, 0, []string{"A1"}, 1},
+		{"in stock", models.ProductFilter{InStock: true}, "", 0, 0, []string{"A1", "C3"}, 2},
+		{"in stock page", models.ProductFilter{InStock: true}, "", 1, 1, []string{"C3"}, 2},
+		{
+			"extra fields",
+			models.ProductFilter{Extras: map[string]string{"margin_flag": "true", "warranty period": "2 years"}},
+			"", 0, 0, []string{"A1"}, 1,
+		},
+		{
+			"computed field", models.ProductFilter{Extras: map[string]string{"margin_flag": "false"}}, "", 0, 0,
+			[]string{"B2"}, 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			page, err := repo.ListProducts(ctx, tc.filter, tc.sort, tc.offset, tc.limit)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, modelsOf(page))
+			assert.Equal(t, tc.wantTotal, page.Total)
+		})
+	}
+
+	t.Run("products are stored as they are", func(t *testing.T) {
+		page, err := repo.ListProducts(ctx, models.ProductFilter{Type: "Dress"}, "", 0, 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.Product{
+			{
+				Model: "B2", Category: "new", Type: "Dress", Quantity: "0", Price: "300",
+				Extras: map[string]string{"margin_flag": "false"},
+			},
+		}, page.Products)
+	})
+
+	t.Run("products put one by one are filtered by price", func(t *testing.T) {
+		// Arrange
+		acme := repo.ForTenant("acme")
+		update, err := acme.BeginStateUpdate(ctx)
+		require.NoError(t, err)
+		put(t, update, models.Product{Model: "F6", Type: "Diver", Quantity: "1", Price: "20"})
+		require.NoError(t, update.Commit(ctx, "hash2"))
+
+		// Act
+		page, err := acme.ListProducts(ctx, models.ProductFilter{MinPrice: price(15)}, "", 0, 0)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, []string{"F6"}, modelsOf(page), "only the products of the tenant are listed")
+	})
+}
+
+func TestRepository_Integration_SearchProducts(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	require.NoError(t, repo.UpdateState(ctx, &models.State{PageHash: "hash", Products: []models.Product{
+		{Model: "Casio G-Shock GA-2100", Category: "new", Type: "Sport", Price: "100"},
+		{Model: "Seiko Prospex", Category: "new", Type: "Diver Casio-style", Price: "200"},
+		{Model: "Casio Edifice", Category: "used", Type: "Chrono", Price: "300"},
+		{Model: "Orient Bambino", Category: "used", Type: "Dress", Price: "400"},
+		{Model: "Годинник Полёт", Category: "used", Type: "Механічний", Price: "500"},
+	}}))
+	require.NoError(t, repo.ForTenant("acme").UpdateState(ctx, &models.State{
+		PageHash: "hash", Products: []models.Product{{Model: "Casio Other Tenant"}},
+	}))
+	modelsOf := func(products []models.Product) []string {
+		names := make([]string, 0, len(products))
+		for _, product := range products {
+			names = append(names, product.Model)
+		}
+		return names
+	}
+
+	testCases := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{
+			"model hits rank above type hits", "casio", 10,
+			[]string{"Casio G-Shock GA-2100", "Casio Edifice", "Seiko Prospex"},
+		},
+		{"prefix of every word", "cas edi", 10, []string{"Casio Edifice"}},
+		{"punctuation and case are ignored", "G-SHOCK", 10, []string{"Casio G-Shock GA-2100"}},
+		{"type", "dress", 10, []string{"Orient Bambino"}},
+		{"cyrillic", "полёт механ", 10, []string{"Годинник Полёт"}},
+		{"limit", "casio", 1, []string{"Casio G-Shock GA-2100"}},
+		{"query operators are words", "casio OR dress", 10, []string{}},
+		{"no match", "rolex", 10, []string{}},
+		{"no words", " -*\" ", 10, []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			products, err := repo.SearchProducts(ctx, tc.text, tc.limit)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, modelsOf(products))
+		})
+	}
+
+	t.Run("the index follows the state", func(t *testing.T) {
+		// Arrange
+		update, err := repo.BeginStateUpdate(ctx)
+		require.NoError(t, err)
+		put(t, update, models.Product{Model: "Casio Edifice", Category: "used", Type: "Racing"})
+		put(t, update, models.Product{Model: "Tissot PRX", Category: "new", Type: "Sport"})
+		_, err = update.RemoveUnseen(ctx)
+		require.NoError(t, err)
+		require.NoError(t, update.Commit(ctx, "hash2"))
+
+		// Act
+		racing, err := repo.SearchProducts(ctx, "racing", 10)
+		require.NoError(t, err)
+		sport, err := repo.SearchProducts(ctx, "sport", 10)
+		require.NoError(t, err)
+		chrono, err := repo.SearchProducts(ctx, "chrono", 10)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, []string{"Casio Edifice"}, modelsOf(racing))
+		assert.Equal(t, []string{"Tissot PRX"}, modelsOf(sport))
+		assert.Empty(t, chrono, "the old type isn't indexed anymore")
+	})
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestRepository_ListProducts_Failures(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: unknown sort order", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+
+		// Act
+		_, err := repo.ListProducts(ctx, models.ProductFilter{}, "cheapest", 0, 10)
+
+		// Assert
+		require.ErrorIs(t, err, models.ErrInvalidSort)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: count", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("SELECT COUNT").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.ListProducts(ctx, models.ProductFilter{}, "", 0, 10)
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.ListProducts: failed to count products")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery("SELECT model").WithArgs("", "Diver", -1, 0).WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.ListProducts(ctx, models.ProductFilter{Type: "Diver"}, "", -5, 0)
+
+		// Assert
+		require.ErrorContains(t, err, "failed to get products")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: scan", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery("SELECT model").WillReturnRows(sqlmock.NewRows([]string{"model"}).AddRow("A1"))
+
+		// Act
+		_, err := repo.ListProducts(ctx, models.ProductFilter{}, "", 0, 10)
+
+		// Assert
+		require.ErrorContains(t, err, "failed to scan product")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_SearchProducts_Failures(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("no words", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+
+		// Act
+		products, err := repo.SearchProducts(ctx, "-- !", 10)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Empty(t, products)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: search", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("products_fts MATCH").WithArgs("casio* g*", "").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.SearchProducts(ctx, "Casio G", 10)
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.SearchProducts: failed to search products")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: get products", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("products_fts MATCH").
+			WillReturnRows(sqlmock.NewRows([]string{"rowid", "matchinfo"}).AddRow(1, []byte{}))
+		mock.ExpectQuery("WHERE rowid IN").WithArgs(1).WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.SearchProducts(ctx, "casio", 10)
+
+		// Assert
+		require.ErrorContains(t, err, "failed to get products")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// RecordSendFailure records a failure to reach the chat. The time the failures started is kept while they
+// go on, the last failure and its reason are replaced, and a deactivated chat stays deactivated.
+func (r *Repository) RecordSendFailure(
+	ctx context.Context,
+	chatID int64,
+	at time.Time,
+	reason string,
+	deactivated bool,
+) (err error) {
+	const opn = "repository.sqlite.RecordSendFailure"
+	ctx, done := r.observe(ctx, "RecordSendFailure")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO unreachable_chats (tenant_id, chat_id, failing_since, last_failure, reason, deactivated)
+		VALUES (?1, ?2, ?3, ?3, ?4, ?5)
+		ON CONFLICT (tenant_id, chat_id) DO UPDATE SET
+			last_failure = excluded.last_failure,
+			reason = excluded.reason,
+			deactivated = MAX(deactivated, excluded.deactivated)`,
+		r.tenant,
+		chatID,
+		at.UTC(),
+		reason,
+		deactivated,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opn, err)
+	}
+
+	return nil
+}
+
+// ClearSendFailure deletes the failures recorded for the chat.
+func (r *Repository) ClearSendFailure(ctx context.Context, chatID int64) (err error) {
+	const opn = "repository.sqlite.ClearSendFailure"
+	ctx, done := r.observe(ctx, "ClearSendFailure")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(
+		ctx, "DELETE FROM unreachable_chats WHERE tenant_id = ? AND chat_id = ?", r.tenant, chatID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opn, err)
+	}
+
+	return nil
+}
+
+// GetUnreachableChats returns the chats with recorded failures, the ones failing the longest first.
+func (r *Repository) GetUnreachableChats(ctx context.Context) (_ []models.UnreachableChat, err error) {
+	const opn = "repository.sqlite.GetUnreachableChats"
+	ctx, done := r.observe(ctx, "GetUnreachableChats")
+	defer func() { err = done(err) }()
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT chat_id, failing_since, last_failure, reason, deactivated FROM unreachable_chats
+		WHERE tenant_id = ? ORDER BY failing_since, chat_id`,
+		r.tenant,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+	defer rows.Close()
+
+	var chats []models.UnreachableChat
+	for rows.Next() {
+		var chat models.UnreachableChat
+		err = rows.Scan(&chat.ChatID, &chat.FailingSince, &chat.LastFailure, &chat.Reason, &chat.Deactivated)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan unreachable chat: %w", opn, err)
+		}
+		chats = append(chats, chat)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	return chats, nil
+}
@@ -0,0 +1,92 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_Reachability(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// Arrange
+	blocked := "Forbidden: bot was blocked by the user"
+	require.NoError(t, repo.RecordSendFailure(ctx, 2, start.Add(time.Hour), blocked, false))
+	require.NoError(t, repo.RecordSendFailure(ctx, 1, start, "Forbidden: bot was kicked", false))
+	require.NoError(t, repo.RecordSendFailure(ctx, 1, start.Add(time.Minute), "Forbidden: user is deactivated", true))
+	require.NoError(t, repo.RecordSendFailure(ctx, 1, start.Add(2*time.Minute), "Bad Request: chat not found", false))
+	require.NoError(t, repo.ForTenant("acme").RecordSendFailure(ctx, 3, start, "Bad Request: chat not found", false))
+
+	// Act
+	chats, err := repo.GetUnreachableChats(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []models.UnreachableChat{
+		{
+			ChatID:       1,
+			FailingSince: start,
+			LastFailure:  start.Add(2 * time.Minute),
+			Reason:       "Bad Request: chat not found",
+			Deactivated:  true,
+		},
+		{
+			ChatID:       2,
+			FailingSince: start.Add(time.Hour),
+			LastFailure:  start.Add(time.Hour),
+			Reason:       blocked,
+		},
+	}, chats, "the first failure is kept, a deactivated chat stays deactivated")
+
+	require.NoError(t, repo.ClearSendFailure(ctx, 1))
+	chats, err = repo.GetUnreachableChats(ctx)
+	require.NoError(t, err)
+	require.Len(t, chats, 1)
+	assert.Equal(t, int64(2), chats[0].ChatID)
+
+	chats, err = repo.ForTenant("acme").GetUnreachableChats(ctx)
+	require.NoError(t, err)
+	require.Len(t, chats, 1, "the failures are scoped by tenant")
+	assert.Equal(t, int64(3), chats[0].ChatID)
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestRecordSendFailure(t *testing.T) {
+	// Arrange
+	repo, mock := newMockedRepo(t)
+	mock.ExpectExec("INSERT INTO unreachable_chats").WillReturnError(assert.AnError)
+
+	// Act
+	err := repo.RecordSendFailure(t.Context(), 1, time.Now(), "Forbidden", false)
+
+	// Assert
+	require.ErrorContains(t, err, "repository.sqlite.RecordSendFailure")
+	require.ErrorIs(t, err, assert.AnError)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUnreachableChats(t *testing.T) {
+	// Arrange
+	repo, mock := newMockedRepo(t)
+	mock.ExpectQuery("SELECT chat_id, failing_since").WillReturnError(assert.AnError)
+
+	// Act
+	_, err := repo.GetUnreachableChats(t.Context())
+
+	// Assert
+	require.ErrorContains(t, err, "repository.sqlite.GetUnreachableChats")
+	require.ErrorIs(t, err, assert.AnError)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
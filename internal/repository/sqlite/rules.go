@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// SetAlertRule stores the alert rule of the chat, replacing the one with the same name.
+func (r *Repository) SetAlertRule(ctx context.Context, rule models.AlertRule) (err error) {
+	const opn = "repository.sqlite.SetAlertRule"
+	ctx, done := r.observe(ctx, "SetAlertRule")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO alert_rules (tenant_id, chat_id, name, expression) VALUES (?, ?, ?, ?)
+		ON CONFLICT(tenant_id, chat_id, name) DO UPDATE SET expression = excluded.expression`,
+		r.tenant,
+		rule.ChatID,
+		rule.Name,
+		rule.Expression,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opn, err)
+	}
+
+	return nil
+}
+
+// DeleteAlertRule deletes the alert rule of the chat by its name. It returns false if the chat had no
+// rule with the name.
+func (r *Repository) DeleteAlertRule(ctx context.Context, chatID int64, name string) (_ bool, err error) {
+	const opn = "repository.sqlite.DeleteAlertRule"
+	ctx, done := r.observe(ctx, "DeleteAlertRule")
+	defer func() { err = done(err) }()
+
+	res, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM alert_rules WHERE tenant_id = ? AND chat_id = ? AND name = ?",
+		r.tenant,
+		chatID,
+		name,
+	)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", opn, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to get affected rows: %w", opn, err)
+	}
+
+	return deleted > 0, nil
+}
+
+// GetAlertRules returns the alert rules of all chats, ordered by chat and name.
+func (r *Repository) GetAlertRules(ctx context.Context) (_ []models.AlertRule, err error) {
+	const opn = "repository.sqlite.GetAlertRules"
+	ctx, done := r.observe(ctx, "GetAlertRules")
+	defer func() { err = done(err) }()
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT chat_id, name, expression FROM alert_rules WHERE tenant_id = ? ORDER BY chat_id, name",
+		r.tenant,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+	defer rows.Close()
+
+	var rules []models.AlertRule
+	for rows.Next() {
+		var rule models.AlertRule
+		if err = rows.Scan(&rule.ChatID, &rule.Name, &rule.Expression); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan alert rule: %w", opn, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	return rules, nil
+}
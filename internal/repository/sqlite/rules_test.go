@@ -0,0 +1,90 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_AlertRules(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+
+	require.NoError(t, repo.SetAlertRule(ctx, models.AlertRule{ChatID: -200, Name: "gpu", Expression: "true"}))
+	require.NoError(t, repo.SetAlertRule(ctx, models.AlertRule{ChatID: -100, Name: "cheap", Expression: "false"}))
+	require.NoError(t, repo.SetAlertRule(ctx, models.AlertRule{ChatID: -100, Name: "Cheap", Expression: "true"}),
+		"a rule with the same name is replaced")
+	require.NoError(t, repo.ForTenant("acme").SetAlertRule(ctx, models.AlertRule{ChatID: -100, Name: "acme"}))
+
+	t.Run("rules of all chats of the tenant", func(t *testing.T) {
+		rules, err := repo.GetAlertRules(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.AlertRule{
+			{ChatID: -200, Name: "gpu", Expression: "true"},
+			{ChatID: -100, Name: "cheap", Expression: "true"},
+		}, rules)
+	})
+
+	t.Run("rule is deleted", func(t *testing.T) {
+		deleted, err := repo.DeleteAlertRule(ctx, -100, "CHEAP")
+		require.NoError(t, err)
+		assert.True(t, deleted)
+
+		deleted, err = repo.DeleteAlertRule(ctx, -100, "cheap")
+		require.NoError(t, err)
+		assert.False(t, deleted)
+
+		rules, err := repo.GetAlertRules(ctx)
+		require.NoError(t, err)
+		assert.Len(t, rules, 1)
+	})
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestSetAlertRule(t *testing.T) {
+	ctx := t.Context()
+	rule := models.AlertRule{ChatID: -100, Name: "cheap", Expression: "new.price < 10"}
+
+	t.Run("error: exec query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT INTO alert_rules").WithArgs("", rule.ChatID, rule.Name, rule.Expression).
+			WillReturnError(assert.AnError)
+
+		// Act
+		err := repo.SetAlertRule(ctx, rule)
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.SetAlertRule")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetAlertRules(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: cannot execute query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("SELECT chat_id, name, expression FROM alert_rules").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.GetAlertRules(ctx)
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.GetAlertRules")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
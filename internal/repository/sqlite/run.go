@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// RecordRun stores the fingerprints of a successful check.
+func (r *Repository) RecordRun(ctx context.Context, run models.RunRecord) (err error) {
+	const op = "repository.sqlite.RecordRun"
+	ctx, done := r.observe(ctx, "RecordRun")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(
+		ctx,
+		"INSERT INTO runs (tenant_id, run_id, checked_at, page_hash, diff_hash, skipped) VALUES (?, ?, ?, ?, ?, ?)",
+		r.tenant,
+		run.RunID,
+		run.CheckedAt.UTC(),
+		run.PageHash,
+		run.DiffHash,
+		run.Skipped,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetRuns returns the successful checks made since the given time, oldest first.
+func (r *Repository) GetRuns(ctx context.Context, since time.Time) (_ []models.RunRecord, err error) {
+	const opn = "repository.sqlite.GetRuns"
+	ctx, done := r.observe(ctx, "GetRuns")
+	defer func() { err = done(err) }()
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT run_id, checked_at, page_hash, diff_hash, skipped FROM runs
+		WHERE tenant_id = ? AND checked_at >= ? ORDER BY checked_at, id`,
+		r.tenant,
+		since.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get runs: %w", opn, err)
+	}
+	defer rows.Close()
+
+	var runs []models.RunRecord
+	for rows.Next() {
+		var run models.RunRecord
+		if err = rows.Scan(&run.RunID, &run.CheckedAt, &run.PageHash, &run.DiffHash, &run.Skipped); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan run: %w", opn, err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,85 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_Runs(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	old := models.RunRecord{RunID: "run-1", CheckedAt: now.Add(-48 * time.Hour), PageHash: "page-1"}
+	changed := models.RunRecord{
+		RunID: "run-2", CheckedAt: now.Add(-time.Hour), PageHash: "page-2", DiffHash: "diff", Skipped: 2,
+	}
+	same := models.RunRecord{RunID: "run-3", CheckedAt: now, PageHash: "page-2"}
+	for _, run := range []models.RunRecord{old, changed, same} {
+		require.NoError(t, repo.RecordRun(ctx, run))
+	}
+	require.NoError(t, repo.ForTenant("acme").RecordRun(ctx, models.RunRecord{RunID: "acme", CheckedAt: now}))
+
+	runs, err := repo.GetRuns(ctx, now.Add(-24*time.Hour))
+
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "run-2", runs[0].RunID)
+	assert.True(t, changed.CheckedAt.Equal(runs[0].CheckedAt))
+	assert.Equal(t, "page-2", runs[0].PageHash)
+	assert.Equal(t, "diff", runs[0].DiffHash)
+	assert.Equal(t, 2, runs[0].Skipped)
+	assert.Empty(t, runs[1].DiffHash)
+	assert.Zero(t, runs[1].Skipped)
+
+	deleted, err := repo.PruneHistory(ctx, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	runs, err = repo.GetRuns(ctx, time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, runs, 2)
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestRecordRun(t *testing.T) {
+	t.Run("error: exec query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT INTO runs").WillReturnError(assert.AnError)
+
+		// Act
+		err := repo.RecordRun(t.Context(), models.RunRecord{RunID: "run", CheckedAt: time.Now()})
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.RecordRun")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetRuns(t *testing.T) {
+	t.Run("error: query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("FROM runs").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.GetRuns(t.Context(), time.Now())
+
+		// Assert
+		require.ErrorContains(t, err, "failed to get runs")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
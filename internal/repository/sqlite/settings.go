@@ -0,0 +1,172 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// SetFilterGroup stores the filter group of the chat.
+func (r *Repository) SetFilterGroup(ctx context.Context, chatID int64, group string) (err error) {
+	const op = "repository.sqlite.SetFilterGroup"
+	ctx, done := r.observe(ctx, "SetFilterGroup")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO chat_settings (tenant_id, chat_id, filter_group) VALUES (?, ?, ?)
+		ON CONFLICT(tenant_id, chat_id) DO UPDATE SET filter_group = excluded.filter_group`,
+		r.tenant,
+		chatID,
+		group,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetThreadID stores the forum topic of the chat.
+func (r *Repository) SetThreadID(ctx context.Context, chatID int64, threadID int) (err error) {
+	const op = "repository.sqlite.SetThreadID"
+	ctx, done := r.observe(ctx, "SetThreadID")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO chat_settings (tenant_id, chat_id, thread_id) VALUES (?, ?, ?)
+		ON CONFLICT(tenant_id, chat_id) DO UPDATE SET thread_id = excluded.thread_id`,
+		r.tenant,
+		chatID,
+		threadID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetSilentCategories stores the silenced change categories of the chat.
+func (r *Repository) SetSilentCategories(
+	ctx context.Context,
+	chatID int64,
+	categories []models.ChangeCategory,
+) (err error) {
+	const op = "repository.sqlite.SetSilentCategories"
+	ctx, done := r.observe(ctx, "SetSilentCategories")
+	defer func() { err = done(err) }()
+
+	names := make([]string, 0, len(categories))
+	for _, category := range categories {
+		names = append(names, string(category))
+	}
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO chat_settings (tenant_id, chat_id, silent_categories) VALUES (?, ?, ?)
+		ON CONFLICT(tenant_id, chat_id) DO UPDATE SET silent_categories = excluded.silent_categories`,
+		r.tenant,
+		chatID,
+		strings.Join(names, ","),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetSustainedTrends stores whether the chat is notified only of price changes in sustained trends.
+func (r *Repository) SetSustainedTrends(ctx context.Context, chatID int64, enabled bool) (err error) {
+	const op = "repository.sqlite.SetSustainedTrends"
+	ctx, done := r.observe(ctx, "SetSustainedTrends")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO chat_settings (tenant_id, chat_id, sustained_trends) VALUES (?, ?, ?)
+		ON CONFLICT(tenant_id, chat_id) DO UPDATE SET sustained_trends = excluded.sustained_trends`,
+		r.tenant,
+		chatID,
+		enabled,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetWatermarks stores whether the chat is alerted of the new lows and highs of the prices, and the
+// period in days they are measured over, 0 for the whole history.
+func (r *Repository) SetWatermarks(ctx context.Context, chatID int64, enabled bool, days int) (err error) {
+	const op = "repository.sqlite.SetWatermarks"
+	ctx, done := r.observe(ctx, "SetWatermarks")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO chat_settings (tenant_id, chat_id, watermarks, watermark_days) VALUES (?, ?, ?, ?)
+		ON CONFLICT(tenant_id, chat_id) DO UPDATE SET
+			watermarks = excluded.watermarks, watermark_days = excluded.watermark_days`,
+		r.tenant,
+		chatID,
+		enabled,
+		days,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetChatSettings returns a map of chat IDs to their settings.
+func (r *Repository) GetChatSettings(ctx context.Context) (_ map[int64]models.ChatSettings, err error) {
+	const opn = "repository.sqlite.GetChatSettings"
+	ctx, done := r.observe(ctx, "GetChatSettings")
+	defer func() { err = done(err) }()
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT chat_id, filter_group, thread_id, silent_categories, sustained_trends, watermarks, watermark_days
+		FROM chat_settings WHERE tenant_id = ?`,
+		r.tenant,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+	defer rows.Close()
+
+	settings := make(map[int64]models.ChatSettings)
+	for rows.Next() {
+		var (
+			chatID int64
+			chat   models.ChatSettings
+			silent string
+		)
+		err = rows.Scan(
+			&chatID, &chat.FilterGroup, &chat.ThreadID, &silent, &chat.SustainedTrends, &chat.Watermarks,
+			&chat.WatermarkDays,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan chat settings: %w", opn, err)
+		}
+		if silent != "" {
+			for _, name := range strings.Split(silent, ",") {
+				chat.Silent = append(chat.Silent, models.ChangeCategory(name))
+			}
+		}
+		settings[chatID] = chat
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	return settings, nil
+}
@@ -0,0 +1,258 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_ChatSettings(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+
+	require.NoError(t, repo.SetFilterGroup(ctx, -100, "warehouse"))
+	require.NoError(t, repo.SetThreadID(ctx, -100, 15))
+	require.NoError(t, repo.SetThreadID(ctx, -200, 3))
+	require.NoError(t, repo.SetFilterGroup(ctx, -200, ""))
+	require.NoError(t, repo.SetSilentCategories(ctx, -200, []models.ChangeCategory{models.CategoryQuantity}))
+	require.NoError(t, repo.SetSustainedTrends(ctx, -300, true))
+	require.NoError(t, repo.SetWatermarks(ctx, -300, true, 30))
+	require.NoError(t, repo.SetWatermarks(ctx, -400, true, 0))
+
+	settings, err := repo.GetChatSettings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]models.ChatSettings{
+		-100: {FilterGroup: "warehouse", ThreadID: 15},
+		-200: {ThreadID: 3, Silent: []models.ChangeCategory{models.CategoryQuantity}},
+		-300: {SustainedTrends: true, Watermarks: true, WatermarkDays: 30},
+		-400: {Watermarks: true},
+	}, settings)
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestSetFilterGroup(t *testing.T) {
+	ctx := t.Context()
+	chatID := int64(-123456789)
+
+	t.Run("error: exec query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT INTO chat_settings").WithArgs("", chatID, "warehouse").WillReturnError(assert.AnError)
+
+		// Act
+		err := repo.SetFilterGroup(ctx, chatID, "warehouse")
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.SetFilterGroup")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT INTO chat_settings").WithArgs("", chatID, "warehouse").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		// Act
+		err := repo.SetFilterGroup(ctx, chatID, "warehouse")
+
+		// Assert
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSetThreadID(t *testing.T) {
+	ctx := t.Context()
+	chatID := int64(-123456789)
+
+	t.Run("error: exec query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT INTO chat_settings").WithArgs("", chatID, 15).WillReturnError(assert.AnError)
+
+		// Act
+		err := repo.SetThreadID(ctx, chatID, 15)
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.SetThreadID")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT INTO chat_settings").WithArgs("", chatID, 15).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		// Act
+		err := repo.SetThreadID(ctx, chatID, 15)
+
+		// Assert
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSetSilentCategories(t *testing.T) {
+	ctx := t.Context()
+	chatID := int64(-123456789)
+	categories := []models.ChangeCategory{models.CategoryQuantity, models.CategoryAdded}
+
+	t.Run("error: exec query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT INTO chat_settings").WithArgs("", chatID, "quantity,added").
+			WillReturnError(assert.AnError)
+
+		// Act
+		err := repo.SetSilentCategories(ctx, chatID, categories)
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.SetSilentCategories")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT INTO chat_settings").WithArgs("", chatID, "quantity,added").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		// Act
+		err := repo.SetSilentCategories(ctx, chatID, categories)
+
+		// Assert
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSetSustainedTrends(t *testing.T) {
+	ctx := t.Context()
+	chatID := int64(-123456789)
+
+	t.Run("error: exec query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT INTO chat_settings").WithArgs("", chatID, true).WillReturnError(assert.AnError)
+
+		// Act
+		err := repo.SetSustainedTrends(ctx, chatID, true)
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.SetSustainedTrends")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSetWatermarks(t *testing.T) {
+	ctx := t.Context()
+	chatID := int64(-123456789)
+
+	t.Run("error: exec query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT INTO chat_settings").WithArgs("", chatID, true, 30).WillReturnError(assert.AnError)
+
+		// Act
+		err := repo.SetWatermarks(ctx, chatID, true, 30)
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.SetWatermarks")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetChatSettings(t *testing.T) {
+	const chatSettingsQuery = "SELECT chat_id, filter_group, thread_id, silent_categories, sustained_trends, " +
+		"watermarks, watermark_days FROM chat_settings"
+	ctx := t.Context()
+	chatID := int64(-123456789)
+	chatSettingsColumns := []string{
+		"chat_id", "filter_group", "thread_id", "silent_categories", "sustained_trends", "watermarks", "watermark_days",
+	}
+
+	t.Run("error: cannot execute query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery(chatSettingsQuery).WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.GetChatSettings(ctx)
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.GetChatSettings")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: failed to scan chat settings", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		invalidRow := sqlmock.NewRows(chatSettingsColumns).AddRow("invalid_id", "warehouse", 0, "", 0, 0, 0)
+		mock.ExpectQuery(chatSettingsQuery).WillReturnRows(invalidRow)
+
+		// Act
+		_, err := repo.GetChatSettings(ctx)
+
+		// Assert
+		require.ErrorContains(t, err, "failed to scan chat settings")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: rows error", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		rowWithErr := sqlmock.NewRows(chatSettingsColumns).
+			AddRow(chatID, "warehouse", 7, "quantity,price_rise", 1, 0, 0).
+			RowError(0, assert.AnError)
+		mock.ExpectQuery(chatSettingsQuery).WillReturnRows(rowWithErr)
+
+		// Act
+		_, err := repo.GetChatSettings(ctx)
+
+		// Assert
+		require.ErrorContains(t, err, "rows iteration error")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		validRow := sqlmock.NewRows(chatSettingsColumns).
+			AddRow(chatID, "warehouse", 7, "quantity,price_rise", 1, 1, 30)
+		mock.ExpectQuery(chatSettingsQuery).WillReturnRows(validRow)
+
+		// Act
+		settings, err := repo.GetChatSettings(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, map[int64]models.ChatSettings{chatID: {
+			FilterGroup:     "warehouse",
+			ThreadID:        7,
+			Silent:          []models.ChangeCategory{models.CategoryQuantity, models.CategoryPriceRise},
+			SustainedTrends: true,
+			Watermarks:      true,
+			WatermarkDays:   30,
+		}}, settings)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
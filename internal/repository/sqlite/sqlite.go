@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/Houeta/chrono-flow/internal/models"
 )
@@ -12,18 +13,75 @@ import (
 // Repository represents a data repository that interacts with the database
 // and provides logging capabilities. It holds a reference to the database
 // and a logger instance for logging operations.
+// All data is scoped by the tenant of the repository, see ForTenant.
 type Repository struct {
-	db  *sql.DB
-	log *slog.Logger
+	db     *sql.DB
+	log    *slog.Logger
+	tenant string
+
+	// queryTimeout and queryObserver bound and observe the query methods, see observe.
+	queryTimeout  time.Duration
+	queryObserver QueryObserver
+
+	// encryptionKey creates the cipher of the encrypted columns, nil if they are stored in the clear.
+	encryptionKey []byte
+	cipher        *columnCipher
 }
 
 type StateRepository interface {
 	// GetState returns the last saved state (page hash and product list).
 	GetState(ctx context.Context) (*models.State, error)
-	// UpdateState completely replaces the old state with the new one.
+	// UpdateState completely replaces the old state with the new one, the products failing to insert are
+	// skipped and listed in state.Skipped.
 	UpdateState(ctx context.Context, state *models.State) error
 }
 
+// StreamingStateRepository replaces the state product by product, for catalogs too large to be held in memory.
+type StreamingStateRepository interface {
+	// GetPageHash returns the hash of the last saved page, or repository.ErrStateNotFound.
+	GetPageHash(ctx context.Context) (string, error)
+	// BeginStateUpdate starts replacing the stored products, nothing changes until the update is committed.
+	BeginStateUpdate(ctx context.Context) (StateUpdate, error)
+}
+
+// StateUpdate replaces the stored products within a transaction. It must be committed or rolled back.
+type StateUpdate interface {
+	// Product returns the stored product with the category and model, nil if there is none. A product put
+	// in the update is returned as it was put.
+	Product(ctx context.Context, category, model string) (*models.Product, error)
+	// Seen reports whether a product with the category and model was put in the update.
+	Seen(ctx context.Context, category, model string) (bool, error)
+	// Put stores the product in place of the one with the same category and model. It returns why the
+	// product was skipped, empty if it was stored: a product failing to store is rolled back alone and
+	// keeps its stored version.
+	Put(ctx context.Context, product models.Product) (string, error)
+	// RemoveUnseen deletes the stored products that weren't put in the update and returns them.
+	RemoveUnseen(ctx context.Context) ([]models.Product, error)
+	// Enqueue queues the change set for delivery, it's committed with the update.
+	Enqueue(ctx context.Context, entry *models.OutboxEntry) error
+	// Commit saves the page hash and applies the update.
+	Commit(ctx context.Context, pageHash string) error
+	// Rollback discards the update, it does nothing after Commit.
+	Rollback() error
+}
+
+// ProductRepository lists and searches the current products without loading the whole state.
+type ProductRepository interface {
+	// ListProducts returns a page of the products matching the filter in the given order, with the number
+	// of matching products on all pages. A limit of zero or less returns all products from the offset.
+	ListProducts(
+		ctx context.Context, filter models.ProductFilter, sort models.ProductSort, offset, limit int,
+	) (*models.ProductPage, error)
+
+	// SearchProducts returns up to limit products whose model or type have words starting with the words
+	// of the text, the most relevant first.
+	SearchProducts(ctx context.Context, text string, limit int) ([]models.Product, error)
+
+	// GetOffers returns the current products of all targets of the tenant matching the product by the
+	// normalized model or the EAN, ordered by target, see models.MatchModel. Regional targets are left out.
+	GetOffers(ctx context.Context, product models.Product) ([]models.Offer, error)
+}
+
 type SubscribeRepository interface {
 	// SubscribeChat adds a new chat to the list of subscribers.
 	SubscribeChat(ctx context.Context, chatID int64) error
@@ -35,9 +93,323 @@ type SubscribeRepository interface {
 	GetSubscribedChats(ctx context.Context) ([]int64, error)
 }
 
+type AllowedChatsRepository interface {
+	// AllowChat grants the chat access to the bot.
+	AllowChat(ctx context.Context, chatID int64) error
+
+	// DisallowChat revokes the access granted with AllowChat.
+	DisallowChat(ctx context.Context, chatID int64) error
+
+	// GetAllowedChats returns all chats that were granted access at runtime.
+	GetAllowedChats(ctx context.Context) ([]int64, error)
+}
+
+type ChatSettingsRepository interface {
+	// SetFilterGroup assigns a predefined filter group to the chat, an empty group removes the filter.
+	SetFilterGroup(ctx context.Context, chatID int64, group string) error
+
+	// SetThreadID sets the forum topic the chat receives notifications in, zero means the General topic.
+	SetThreadID(ctx context.Context, chatID int64, threadID int) error
+
+	// SetSilentCategories sets the change categories the chat receives without a notification sound.
+	SetSilentCategories(ctx context.Context, chatID int64, categories []models.ChangeCategory) error
+
+	// SetSustainedTrends sets whether the chat is notified of price changes only when they are part of
+	// a sustained trend, see models.PriceTrend.
+	SetSustainedTrends(ctx context.Context, chatID int64, enabled bool) error
+
+	// SetWatermarks sets whether the chat is alerted when a price hits a new low or high over the period
+	// in days, 0 for the whole history, see models.Watermark.
+	SetWatermarks(ctx context.Context, chatID int64, enabled bool, days int) error
+
+	// GetChatSettings returns the settings of every chat that has any.
+	GetChatSettings(ctx context.Context) (map[int64]models.ChatSettings, error)
+}
+
+type MaintenanceRepository interface {
+	// Vacuum reclaims unused disk space and optimizes the database.
+	Vacuum(ctx context.Context) (*models.MaintenanceReport, error)
+
+	// PruneHistory deletes history records older than the given time.
+	PruneHistory(ctx context.Context, before time.Time) (int64, error)
+}
+
+type UptimeRepository interface {
+	// RecordFetch stores the outcome of a fetch of the target page.
+	RecordFetch(ctx context.Context, record models.FetchRecord) error
+
+	// GetUptimeStats aggregates the fetches made since the given time.
+	GetUptimeStats(ctx context.Context, since time.Time) (*models.UptimeStats, error)
+
+	// RecordRun stores the fingerprints of a successful check.
+	RecordRun(ctx context.Context, run models.RunRecord) error
+}
+
+// PageDiffRepository keeps the last page fetched and the diffs of the pages that changed without a
+// product changing.
+type PageDiffRepository interface {
+	// SwapPage stores the page with its hash as the last page fetched and returns the previous one if its
+	// hash differs, nil if it's the same page or there was none.
+	SwapPage(ctx context.Context, hash string, page []byte) ([]byte, error)
+
+	// RecordPageDiff stores the diff and deletes the older ones beyond the last keep diffs.
+	RecordPageDiff(ctx context.Context, diff models.PageDiff, keep int) error
+
+	// GetPageDiffs returns the stored diffs, newest first.
+	GetPageDiffs(ctx context.Context) ([]models.PageDiff, error)
+}
+
+// RunArtifactRepository keeps the renderings of the changes detected by the checks, pruned with the history.
+type RunArtifactRepository interface {
+	// RecordDiffArtifacts stores the renderings of the changes of a check, replacing the ones of the same
+	// run and format.
+	RecordDiffArtifacts(ctx context.Context, artifacts []models.DiffArtifact) error
+
+	// GetDiffArtifact returns the rendering of the changes of the run in the format, or
+	// repository.ErrArtifactNotFound.
+	GetDiffArtifact(ctx context.Context, runID, format string) (*models.DiffArtifact, error)
+}
+
+// HeartbeatRepository keeps the state of the dead-man's switch of the target.
+type HeartbeatRepository interface {
+	// GetHeartbeat returns the stored heartbeat, nil if there is none yet.
+	GetHeartbeat(ctx context.Context) (*models.Heartbeat, error)
+
+	// SaveHeartbeat replaces the stored heartbeat.
+	SaveHeartbeat(ctx context.Context, heartbeat models.Heartbeat) error
+}
+
+// CheckErrorRepository keeps the last failed check of the target.
+type CheckErrorRepository interface {
+	// RecordCheckError stores the error of a failed check as the last one and counts the failures in a row.
+	RecordCheckError(ctx context.Context, class, message string, at time.Time) error
+
+	// ResetCheckErrors ends the failures in a row after a successful check, the last error is kept.
+	ResetCheckErrors(ctx context.Context) error
+
+	// GetCheckError returns the last failed check, nil if none failed yet.
+	GetCheckError(ctx context.Context) (*models.CheckError, error)
+}
+
+// OutboxRepository holds the change sets detected by the checks until they are delivered. They are
+// queued together with the state, see models.State.Outbox and StateUpdate.Enqueue.
+type OutboxRepository interface {
+	// GetPendingChanges returns the queued change sets of the tenant and its targets that weren't
+	// delivered yet, oldest first.
+	GetPendingChanges(ctx context.Context) ([]models.OutboxEntry, error)
+
+	// ClaimChanges claims the queued change set with the ID for its delivery until the lease expired, it's
+	// claimed again then unless it was marked delivered. It returns false if the change set was delivered,
+	// is claimed by an unexpired lease or replays the change set claimed before it, the change set must
+	// not be sent then.
+	ClaimChanges(ctx context.Context, id int64, claimedAt time.Time, lease time.Duration) (bool, error)
+
+	// MarkChangesDelivered marks the queued change set with the ID delivered once it was sent.
+	MarkChangesDelivered(ctx context.Context, id int64, deliveredAt time.Time) error
+
+	// ClaimDiff remembers the fingerprint of a change set of the target delivered at the given time for the
+	// ttl. It returns false if a change set with the same fingerprint was delivered within its ttl.
+	ClaimDiff(ctx context.Context, target, fingerprint string, claimedAt time.Time, ttl time.Duration) (bool, error)
+}
+
+// SubscriberStatsRepository records how chats use the bot and aggregates the subscriber statistics.
+// Subscription events are recorded by SubscribeChat and UnsubscribeChat.
+type SubscriberStatsRepository interface {
+	// RecordChatActivity records that the chat used the bot on the UTC day of the given time.
+	RecordChatActivity(ctx context.Context, chatID int64, at time.Time) error
+
+	// GetSubscriberStats returns the statistics of every UTC day since the given time, oldest first.
+	GetSubscriberStats(ctx context.Context, since time.Time) ([]models.SubscriberDay, error)
+
+	// CountSubscriptionEvents returns how many times chats subscribed and unsubscribed in total.
+	CountSubscriptionEvents(ctx context.Context) (int64, int64, error)
+}
+
+// ReachabilityRepository tracks the chats the messages of the bot persistently fail to reach.
+type ReachabilityRepository interface {
+	// RecordSendFailure records that a message failed to reach the chat at the given time for the reason,
+	// the first failure in a row is kept. Deactivated marks the account of the chat as deactivated.
+	RecordSendFailure(ctx context.Context, chatID int64, at time.Time, reason string, deactivated bool) error
+
+	// ClearSendFailure forgets the failures of the chat, e.g. once a message reached it again.
+	ClearSendFailure(ctx context.Context, chatID int64) error
+
+	// GetUnreachableChats returns the chats with failures, the ones failing the longest first.
+	GetUnreachableChats(ctx context.Context) ([]models.UnreachableChat, error)
+}
+
+// NotificationRepository remembers the products listed in the notifications sent to chats.
+type NotificationRepository interface {
+	// RecordNotificationProducts stores the products of a notification sent to the chat as the given message.
+	RecordNotificationProducts(
+		ctx context.Context, chatID int64, messageID int, sentAt time.Time, products []models.ProductRef,
+	) error
+
+	// GetNotificationProducts returns the products of the notification sent to the chat as the given message.
+	GetNotificationProducts(ctx context.Context, chatID int64, messageID int) ([]models.ProductRef, error)
+}
+
+// FeedbackRepository stores the template variant notifications were rendered with and how they were rated.
+type FeedbackRepository interface {
+	// RecordNotificationVariant stores the template variant of a notification sent to the chat as the message.
+	RecordNotificationVariant(ctx context.Context, chatID int64, messageID int, variant string, sentAt time.Time) error
+
+	// RecordFeedback stores the rating of the user for a notification, replacing an earlier one. It returns
+	// false if the message isn't a known notification.
+	RecordFeedback(
+		ctx context.Context, chatID int64, messageID int, userID int64, useful bool, votedAt time.Time,
+	) (bool, error)
+
+	// GetFeedbackStats returns the notifications sent since the given time and their ratings per variant.
+	GetFeedbackStats(ctx context.Context, since time.Time) ([]models.VariantFeedback, error)
+}
+
+// WishlistRepository stores the wishlists of product models chats wait to fit into a budget.
+type WishlistRepository interface {
+	// AddWishlistItem adds the product model to the wishlist of the chat.
+	AddWishlistItem(ctx context.Context, chatID int64, model string) error
+
+	// RemoveWishlistItem removes the product model from the wishlist of the chat, false if it wasn't on it.
+	RemoveWishlistItem(ctx context.Context, chatID int64, model string) (bool, error)
+
+	// SetWishlistBudget sets the total budget of the wishlist of the chat.
+	SetWishlistBudget(ctx context.Context, chatID int64, budget float64) error
+
+	// SetWishlistNotified sets whether the chat was told that its wishlist is within the budget.
+	SetWishlistNotified(ctx context.Context, chatID int64, notified bool) error
+
+	// ClearWishlist removes the wishlist of the chat.
+	ClearWishlist(ctx context.Context, chatID int64) error
+
+	// GetWishlist returns the wishlist of the chat with the current prices of its items.
+	GetWishlist(ctx context.Context, chatID int64) (*models.Wishlist, error)
+
+	// GetWishlists returns the wishlists of all chats with the current prices of their items.
+	GetWishlists(ctx context.Context) ([]models.Wishlist, error)
+
+	// GetWatchCounts returns the number of chats with the model on their wishlist by the lower-case model.
+	GetWatchCounts(ctx context.Context) (map[string]int, error)
+}
+
+// AlertRuleRepository stores the alert rules of the chats.
+type AlertRuleRepository interface {
+	// SetAlertRule stores the alert rule of the chat, replacing the one with the same name.
+	SetAlertRule(ctx context.Context, rule models.AlertRule) error
+
+	// DeleteAlertRule deletes the alert rule of the chat by its name, false if the chat had none with it.
+	DeleteAlertRule(ctx context.Context, chatID int64, name string) (bool, error)
+
+	// GetAlertRules returns the alert rules of all chats.
+	GetAlertRules(ctx context.Context) ([]models.AlertRule, error)
+}
+
+// UserRepository stores the members of group chats getting changes as direct messages.
+type UserRepository interface {
+	// SetUserSubscription stores the categories of the changes the user gets as direct messages.
+	SetUserSubscription(ctx context.Context, subscription models.UserSubscription) error
+
+	// DeleteUserSubscription stops the direct messages of the user, it returns false if there were none.
+	DeleteUserSubscription(ctx context.Context, userID int64) (bool, error)
+
+	// GetUserSubscriptions returns the users getting direct messages.
+	GetUserSubscriptions(ctx context.Context) ([]models.UserSubscription, error)
+}
+
+// PrivacyRepository deletes the data stored about chats on their request.
+type PrivacyRepository interface {
+	// ForgetChat deletes every record stored about the chat, it returns how many records of every kind
+	// were deleted.
+	ForgetChat(ctx context.Context, chatID int64) ([]models.ForgottenData, error)
+}
+
+// ChatMigrationRepository moves the data of the groups upgraded to supergroups, Telegram changes the ID of
+// a group when it's upgraded.
+type ChatMigrationRepository interface {
+	// MigrateChat moves the data of the group to the supergroup it was upgraded to, it returns how many
+	// records were moved.
+	MigrateChat(ctx context.Context, fromID, toID int64) (int, error)
+}
+
+type HistoryRepository interface {
+	// RecordChanges stores the changes detected at the given time in the change history.
+	RecordChanges(ctx context.Context, detectedAt time.Time, changes *models.Changes) error
+
+	// GetChanges returns the changes detected since the given time, oldest first.
+	GetChanges(ctx context.Context, since time.Time) ([]models.ChangeRecord, error)
+
+	// GetProductHistory returns the changes of a product model in a category, oldest first.
+	GetProductHistory(ctx context.Context, category, model string) ([]models.ChangeRecord, error)
+
+	// GetStateAt returns the products listed at the given time, reconstructed from the change history.
+	GetStateAt(ctx context.Context, at time.Time) ([]models.Product, error)
+
+	// AnnotateChange attaches the note to the changes of the model detected by the check run, it returns
+	// how many changes were annotated.
+	AnnotateChange(ctx context.Context, runID, model, note string) (int, error)
+
+	// GetFetches returns the fetches of the target page made since the given time, oldest first.
+	GetFetches(ctx context.Context, since time.Time) ([]models.FetchRecord, error)
+
+	// GetRuns returns the successful checks made since the given time, oldest first.
+	GetRuns(ctx context.Context, since time.Time) ([]models.RunRecord, error)
+}
+
+type TenantRepository interface {
+	// CreateTenant stores a new tenant, it fails with repository.ErrTenantExists if the ID is taken.
+	CreateTenant(ctx context.Context, tenant models.Tenant) error
+
+	// GetTenants returns all stored tenants ordered by ID.
+	GetTenants(ctx context.Context) ([]models.Tenant, error)
+
+	// DeleteTenant removes a tenant together with all its data.
+	DeleteTenant(ctx context.Context, id string) error
+}
+
+type TokenRepository interface {
+	// CreateToken stores an API token by the hash of its secret and returns the token ID.
+	CreateToken(ctx context.Context, token models.APIToken, hash string) (int64, error)
+
+	// GetTokenByHash returns the active token with the given hash, or repository.ErrTokenNotFound.
+	GetTokenByHash(ctx context.Context, hash string) (*models.APIToken, error)
+
+	// GetTokens returns all tokens including the revoked ones ordered by ID.
+	GetTokens(ctx context.Context) ([]models.APIToken, error)
+
+	// RevokeToken revokes an active token, it fails with repository.ErrTokenNotFound otherwise.
+	RevokeToken(ctx context.Context, id int64, revokedAt time.Time) error
+}
+
+type TargetRepository interface {
+	// CreateTarget stores a target of the tenant, it fails with repository.ErrTargetExists
+	// if the tenant already has a target with the same name.
+	CreateTarget(ctx context.Context, target models.Target) error
+
+	// GetTargets returns the targets of the tenant ordered by name.
+	GetTargets(ctx context.Context) ([]models.Target, error)
+
+	// GetTarget returns the target of the tenant with the name, or repository.ErrTargetNotFound.
+	GetTarget(ctx context.Context, name string) (*models.Target, error)
+
+	// UpdateTarget replaces the definition of a stored target, it fails with repository.ErrTargetNotFound
+	// if there is no target with the same name.
+	UpdateTarget(ctx context.Context, target models.Target) error
+
+	// SetTargetDisabled pauses or resumes the checks of an additional target. It fails with models.ErrMainTarget
+	// for the main target and with repository.ErrTargetNotFound if there is no such target.
+	SetTargetDisabled(ctx context.Context, name string, disabled bool) error
+
+	// GetTargetStatuses returns the targets of the tenant ordered by name with the outcome of their last check
+	// and their last failed check.
+	GetTargetStatuses(ctx context.Context) ([]models.TargetStatus, error)
+
+	// DeleteTarget removes an additional target with its data. It fails with models.ErrMainTarget
+	// for the main target and with repository.ErrTargetNotFound if there is no such target.
+	DeleteTarget(ctx context.Context, name string) error
+}
+
 // NewRepository creates a new instance of Repository with the provided Database.
 // It returns a pointer to the newly created Repository.
-func NewRepository(ctx context.Context, log *slog.Logger, storagePath string) (*Repository, error) {
+func NewRepository(ctx context.Context, log *slog.Logger, storagePath string, opts ...Option) (*Repository, error) {
 	// Open (or create if it doesn't exist) the database file.
 	dtb, err := sql.Open("sqlite3", fmt.Sprintf("%s?_pragma=foreign_keys(1)", storagePath))
 	if err != nil {
@@ -54,12 +426,74 @@ func NewRepository(ctx context.Context, log *slog.Logger, storagePath string) (*
 		return nil, fmt.Errorf("DB schema initialization error: %w", err)
 	}
 
-	return &Repository{db: dtb, log: log}, nil
+	// Upgrade tables created by previous versions.
+	if err = migrateSchema(ctx, dtb); err != nil {
+		return nil, fmt.Errorf("DB schema migration error: %w", err)
+	}
+
+	if err = backfillPriceValues(ctx, dtb); err != nil {
+		return nil, fmt.Errorf("DB price values backfill error: %w", err)
+	}
+
+	if err = backfillMatchKeys(ctx, dtb); err != nil {
+		return nil, fmt.Errorf("DB match keys backfill error: %w", err)
+	}
+
+	repo := &Repository{db: dtb, log: log}
+	for _, opt := range opts {
+		opt(repo)
+	}
+
+	if repo.encryptionKey != nil {
+		if repo.cipher, err = newColumnCipher(repo.encryptionKey); err != nil {
+			return nil, fmt.Errorf("DB encryption error: %w", err)
+		}
+		if err = repo.encryptStoredColumns(ctx); err != nil {
+			return nil, fmt.Errorf("DB encryption error: %w", err)
+		}
+	}
+
+	return repo, nil
 }
 
 // NewForTest creates a repository with an existing DB connection (for testing).
-func NewForTest(db *sql.DB) *Repository {
-	return &Repository{db: db}
+func NewForTest(db *sql.DB, opts ...Option) *Repository {
+	repo := &Repository{db: db}
+	for _, opt := range opts {
+		opt(repo)
+	}
+
+	return repo
+}
+
+// ForTenant returns a repository sharing the database connection that reads and writes the data of
+// the tenant. The empty ID is the default tenant. The returned repository must not be closed.
+func (r *Repository) ForTenant(id string) *Repository {
+	scoped := *r
+	scoped.tenant = id
+
+	return &scoped
+}
+
+// ForTarget returns a repository sharing the database connection that reads and writes the data of
+// a target of the tenant. The returned repository must not be closed.
+func (r *Repository) ForTarget(name string) *Repository {
+	return r.ForTenant(targetScope(r.tenant, name))
+}
+
+// targetScope returns the scope the data of a tenant's target is stored in. The main target
+// keeps its data in the scope of the tenant.
+func targetScope(tenant, name string) string {
+	if name == models.MainTarget {
+		return tenant
+	}
+
+	return tenant + "/" + name
+}
+
+// Tenant returns the ID of the tenant the repository is scoped by.
+func (r *Repository) Tenant() string {
+	return r.tenant
 }
 
 // initSchema creates the necessary tables if they don't already exist.
@@ -82,6 +516,72 @@ func initSchema(ctx context.Context, dtb *sql.DB) error {
 		chat_id INTEGER PRIMARY KEY NOT NULL,
 		subscribed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
+
+	CREATE TABLE IF NOT EXISTS allowed_chats (
+		chat_id INTEGER PRIMARY KEY NOT NULL,
+		allowed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS chat_settings (
+		chat_id INTEGER PRIMARY KEY NOT NULL,
+		filter_group TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE IF NOT EXISTS fetches (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		fetched_at TIMESTAMP NOT NULL,
+		latency_ms INTEGER NOT NULL,
+		success INTEGER NOT NULL,
+		error TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_fetches_fetched_at ON fetches (fetched_at);
+
+	CREATE TABLE IF NOT EXISTS changes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		detected_at TIMESTAMP NOT NULL,
+		kind TEXT NOT NULL,
+		model TEXT NOT NULL DEFAULT '',
+		old_model TEXT NOT NULL DEFAULT '',
+		category TEXT NOT NULL DEFAULT '',
+		type TEXT NOT NULL DEFAULT '',
+		old_price TEXT NOT NULL DEFAULT '',
+		price TEXT NOT NULL DEFAULT '',
+		old_quantity TEXT NOT NULL DEFAULT '',
+		quantity TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_changes_detected_at ON changes (detected_at);
+
+	CREATE TABLE IF NOT EXISTS tenants (
+		id TEXT PRIMARY KEY NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		target_url TEXT NOT NULL,
+		bot_token TEXT NOT NULL,
+		allowed_ids TEXT NOT NULL DEFAULT '',
+		admin_ids TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS api_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL DEFAULT '',
+		token_hash TEXT NOT NULL UNIQUE,
+		scopes TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		revoked_at TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS targets (
+		tenant_id TEXT NOT NULL DEFAULT '',
+		name TEXT NOT NULL,
+		url TEXT NOT NULL,
+		table_selector TEXT NOT NULL DEFAULT '',
+		column_selectors TEXT NOT NULL DEFAULT '',
+		interval_seconds INTEGER NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (tenant_id, name)
+	);
 	`
 	_, err := dtb.ExecContext(ctx, migrationQuery)
 	if err != nil {
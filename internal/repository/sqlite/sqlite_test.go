@@ -1,14 +1,19 @@
 package sqlite_test
 
 import (
+	"database/sql"
 	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/Houeta/chrono-flow/internal/models"
 	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewRepository_Success(t *testing.T) {
@@ -102,3 +107,79 @@ func TestSchemaInitialization(t *testing.T) {
 		t.Errorf("expected tables 'page_state' and 'products' to exist, got: %+v", found)
 	}
 }
+
+func TestSchemaMigration_UpgradesExistingDatabase(t *testing.T) {
+	ctx := t.Context()
+	dbPath := filepath.Join(t.TempDir(), "legacy.sqlite")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Simulate a database created before the thread_id column and product categories existed.
+	legacy, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	_, err = legacy.ExecContext(ctx, `CREATE TABLE chat_settings (
+		chat_id INTEGER PRIMARY KEY NOT NULL,
+		filter_group TEXT NOT NULL DEFAULT ''
+	);
+	INSERT INTO chat_settings (chat_id, filter_group) VALUES (-100, 'warehouse');
+	CREATE TABLE page_state (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		page_hash TEXT NOT NULL
+	);
+	INSERT INTO page_state (id, page_hash) VALUES (1, 'legacy');
+	CREATE TABLE products (
+		model TEXT PRIMARY KEY NOT NULL,
+		type TEXT,
+		quantity TEXT,
+		price TEXT,
+		image_url TEXT
+	);
+	INSERT INTO products (model, type, quantity, price, image_url) VALUES ('A1', 'Diver', '1', '100', '');
+	CREATE TABLE subscriptions (
+		chat_id INTEGER PRIMARY KEY NOT NULL,
+		subscribed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	INSERT INTO subscriptions (chat_id, subscribed_at) VALUES (-100, '2025-01-02 03:04:05');`)
+	require.NoError(t, err)
+	require.NoError(t, legacy.Close())
+
+	repo, err := sqlite.NewRepository(ctx, logger, dbPath)
+	require.NoError(t, err)
+
+	settings, err := repo.GetChatSettings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]models.ChatSettings{-100: {FilterGroup: "warehouse"}}, settings)
+
+	// Products stored before categories existed are kept in the unnamed category.
+	state, err := repo.GetState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Product{{Model: "A1", Type: "Diver", Quantity: "1", Price: "100"}}, state.Products)
+
+	// Stored products get the numeric price they are filtered by.
+	minPrice := 100.0
+	page, err := repo.ListProducts(ctx, models.ProductFilter{MinPrice: &minPrice}, models.SortByPrice, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, page.Total)
+
+	// Stored products are indexed for searches.
+	found, err := repo.SearchProducts(ctx, "div", 10)
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+
+	// Stored products are matched across targets.
+	offers, err := repo.GetOffers(ctx, models.Product{Model: "a-1"})
+	require.NoError(t, err)
+	assert.Len(t, offers, 1)
+
+	// Existing subscribers are recorded as subscribed when they subscribed.
+	days, err := repo.GetSubscriberStats(ctx, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, models.SubscriberDay{
+		Day: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), Subscribers: 1, Subscribed: 1,
+	}, days[1])
+
+	// Reopening an up-to-date database must not apply the migrations again.
+	require.NoError(t, repo.Close())
+	repo, err = sqlite.NewRepository(ctx, logger, dbPath)
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+}
@@ -10,13 +10,18 @@ import (
 	"github.com/Houeta/chrono-flow/internal/repository"
 )
 
+// errStateUpdateRolledBack is the error a state update rolled back before its commit is observed with.
+var errStateUpdateRolledBack = errors.New("state update rolled back")
+
 // GetState implements an interface method for retrieving state from the database.
-func (r *Repository) GetState(ctx context.Context) (*models.State, error) {
+func (r *Repository) GetState(ctx context.Context) (_ *models.State, err error) {
 	const opn = "repository.sqlite.GetState"
+	ctx, done := r.observe(ctx, "GetState")
+	defer func() { err = done(err) }()
 
 	// 1. Get hash of page
 	var pageHash string
-	err := r.db.QueryRowContext(ctx, "SELECT page_hash FROM page_state WHERE id = 1").Scan(&pageHash)
+	err = r.db.QueryRowContext(ctx, "SELECT page_hash FROM page_state WHERE tenant_id = ?", r.tenant).Scan(&pageHash)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, repository.ErrStateNotFound
@@ -25,7 +30,11 @@ func (r *Repository) GetState(ctx context.Context) (*models.State, error) {
 	}
 
 	// 2. Get all items from table
-	rows, err := r.db.QueryContext(ctx, "SELECT model, type, quantity, price, image_url FROM products")
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+productColumns+" FROM products WHERE tenant_id = ?",
+		r.tenant,
+	)
 	if err != nil {
 		return nil, fmt.Errorf("%s: failed to get products: %w", opn, err)
 	}
@@ -35,7 +44,8 @@ func (r *Repository) GetState(ctx context.Context) (*models.State, error) {
 	var products []models.Product
 	for rows.Next() {
 		var p models.Product
-		if err = rows.Scan(&p.Model, &p.Type, &p.Quantity, &p.Price, &p.ImageURL); err != nil {
+		err = rows.Scan(productFields(&p)...)
+		if err != nil {
 			return nil, fmt.Errorf("%s: failed to scan product: %w", opn, err)
 		}
 		products = append(products, p)
@@ -51,9 +61,14 @@ func (r *Repository) GetState(ctx context.Context) (*models.State, error) {
 	}, nil
 }
 
-// UpdateState atomically updates the state using a transaction.
-func (r *Repository) UpdateState(ctx context.Context, state *models.State) error {
+// UpdateState atomically updates the state using a transaction. Every product is inserted within a
+// savepoint: a product failing to insert is rolled back alone, keeps its stored row if it had one and is
+// listed in state.Skipped, the others are committed. The changes of the skipped products are left out of
+// the queued ones, see models.State.WithoutSkipped.
+func (r *Repository) UpdateState(ctx context.Context, state *models.State) (err error) {
 	const opn = "storage.sqlite.UpdateState"
+	ctx, done := r.observe(ctx, "UpdateState")
+	defer func() { err = done(err) }()
 
 	// 1. begin transaction
 	tx, err := r.db.BeginTx(ctx, nil) //nolint:varnamelen // tx its a default naming for transaction
@@ -63,13 +78,19 @@ func (r *Repository) UpdateState(ctx context.Context, state *models.State) error
 	defer tx.Rollback() //nolint:errcheck // Because in Go, it's common practice to ignore the Rollback() error in a defer, since if the transaction committed successfully, the rollback would just return sql.ErrTxDone and it's not useful to log or act on.
 
 	// 2. Update (or insert) hash of page.
-	_, err = tx.ExecContext(ctx, "INSERT OR REPLACE INTO page_state (id, page_hash) VALUES (1, ?)", state.PageHash)
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT OR REPLACE INTO page_state (tenant_id, page_hash) VALUES (?, ?)",
+		r.tenant,
+		state.PageHash,
+	)
 	if err != nil {
 		return fmt.Errorf("%s: failed to update page hash: %w", opn, err)
 	}
 
-	// 3. Completely clear the products table to record the new current state.
-	_, err = tx.ExecContext(ctx, "DELETE FROM products")
+	// 3. Completely clear the products table to record the new current state, the deleted products are
+	// kept to restore the ones failing to insert.
+	stored, err := deleteProducts(ctx, tx, r.tenant)
 	if err != nil {
 		return fmt.Errorf("%s: failed to delete old products: %w", opn, err)
 	}
@@ -77,24 +98,345 @@ func (r *Repository) UpdateState(ctx context.Context, state *models.State) error
 	// 4. Preparing a request for the effective insertion of new products.
 	stmt, err := tx.PrepareContext(
 		ctx,
-		"INSERT INTO products (model, type, quantity, price, image_url) VALUES (?, ?, ?, ?, ?)",
+		"INSERT INTO products (tenant_id, model, category, type, quantity, price, image_url, product_url, "+
+			"extras, price_value, match_model, match_ean) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 	)
 	if err != nil {
 		return fmt.Errorf("%s: failed to prepare insert statement: %w", opn, err)
 	}
 	defer stmt.Close()
 
-	// 5. Insert each new product into the table.
+	// 5. Insert each new product into the table, skipping the ones failing to insert. A skipped product
+	// keeps its stored row unless another product with its category and model was inserted.
+	state.Skipped = nil
+	inserted := make(map[models.ProductRef]bool, len(state.Products))
 	for _, p := range state.Products {
-		if _, err = stmt.ExecContext(ctx, p.Model, p.Type, p.Quantity, p.Price, p.ImageURL); err != nil {
-			return fmt.Errorf("%s: failed to insert product with model %s: %w", opn, p.Model, err)
+		ref := models.ProductRef{Category: p.Category, Model: p.Model}
+		reason, err := insertProduct(ctx, tx, stmt, r.tenant, p)
+		if err != nil {
+			return fmt.Errorf("%s: %w", opn, err)
+		}
+		if reason == "" {
+			inserted[ref] = true
+			continue
+		}
+
+		state.Skipped = append(state.Skipped, models.SkippedProduct{Product: p, Reason: reason})
+		if old, found := stored[ref]; found && !inserted[ref] {
+			if reason, err = insertProduct(ctx, tx, stmt, r.tenant, old); err != nil {
+				return fmt.Errorf("%s: %w", opn, err)
+			}
+			inserted[ref] = reason == ""
 		}
 	}
 
-	// 6. If all operations went through without errors - confirm the transaction.
+	// 6. Queue the detected changes, so they are delivered even if the process stops right after the update.
+	// The changes of the skipped products weren't stored, they are left out.
+	if state.Outbox != nil && len(state.Skipped) > 0 {
+		state.Outbox.Changes = state.WithoutSkipped(state.Outbox.Changes)
+		if !state.Outbox.Changes.HasChanges() {
+			state.Outbox = nil
+		}
+	}
+	if state.Outbox != nil {
+		if err = enqueueChanges(ctx, tx, r.tenant, state.Outbox); err != nil {
+			return fmt.Errorf("%s: %w", opn, err)
+		}
+	}
+
+	// 7. If all operations went through without errors - confirm the transaction.
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("%s: failed to commit transaction: %w", opn, err)
 	}
 
 	return nil
 }
+
+// deleteProducts deletes the products of the tenant and returns them by category and model.
+func deleteProducts(ctx context.Context, tx *sql.Tx, tenant string) (map[models.ProductRef]models.Product, error) {
+	rows, err := tx.QueryContext(ctx, "DELETE FROM products WHERE tenant_id = ? RETURNING "+productColumns, tenant)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the caller names the operation.
+	}
+	defer rows.Close()
+
+	deleted := make(map[models.ProductRef]models.Product)
+	for rows.Next() {
+		var p models.Product
+		if err = rows.Scan(productFields(&p)...); err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		deleted[models.ProductRef{Category: p.Category, Model: p.Model}] = p
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// insertProduct inserts the product within a savepoint, so a failing insert is rolled back without the
+// rest of the transaction. It returns why the product was skipped, empty if it was inserted, and an
+// error if the savepoint failed.
+func insertProduct(ctx context.Context, tx *sql.Tx, stmt *sql.Stmt, tenant string, p models.Product) (string, error) {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT product"); err != nil {
+		return "", fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	var reason string
+	_, insertErr := stmt.ExecContext(
+		ctx, tenant, p.Model, p.Category, p.Type, p.Quantity, p.Price, p.ImageURL, p.ProductURL,
+		extrasColumn{&p.Extras}, priceValue(p), models.MatchModel(p.Model), p.EAN(),
+	)
+	if insertErr != nil {
+		reason = fmt.Sprintf("failed to insert product with model %s: %v", p.Model, insertErr)
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO product"); err != nil {
+			return "", fmt.Errorf("failed to roll back product with model %s: %w", p.Model, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "RELEASE product"); err != nil {
+		return "", fmt.Errorf("failed to release savepoint: %w", err)
+	}
+
+	return reason, nil
+}
+
+// GetPageHash returns the hash of the last saved page without loading its products.
+func (r *Repository) GetPageHash(ctx context.Context) (_ string, err error) {
+	const opn = "repository.sqlite.GetPageHash"
+	ctx, done := r.observe(ctx, "GetPageHash")
+	defer func() { err = done(err) }()
+
+	var pageHash string
+	err = r.db.QueryRowContext(ctx, "SELECT page_hash FROM page_state WHERE tenant_id = ?", r.tenant).Scan(&pageHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", repository.ErrStateNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: failed to get page hash: %w", opn, err)
+	}
+
+	return pageHash, nil
+}
+
+// stateUpdate replaces the products of a tenant within a transaction. The keys of the products put
+// in the update are kept in a temporary table, so the removed ones are found without loading the
+// stored products.
+type stateUpdate struct {
+	tx     *sql.Tx
+	tenant string
+	// done ends the observation of the update, nil once it ended, see finish.
+	done   func(error) error
+	lookup *sql.Stmt
+	seen   *sql.Stmt
+	upsert *sql.Stmt
+	mark   *sql.Stmt
+}
+
+// BeginStateUpdate starts replacing the stored products one by one, nothing changes until the update
+// is committed. The update holds the write lock of the database until it's committed or rolled back.
+// It's observed as a whole as StateUpdate, the query timeout bounds the transaction.
+func (r *Repository) BeginStateUpdate(ctx context.Context) (_ StateUpdate, err error) {
+	const opn = "repository.sqlite.BeginStateUpdate"
+	ctx, done := r.observe(ctx, "StateUpdate")
+	defer func() {
+		if err != nil {
+			err = done(err)
+		}
+	}()
+
+	tx, err := r.db.BeginTx(ctx, nil) //nolint:varnamelen // tx its a default naming for transaction
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", opn, err)
+	}
+
+	// The temporary table belongs to the connection of the transaction, it may be left by a previous update.
+	_, err = tx.ExecContext(ctx, `CREATE TEMP TABLE IF NOT EXISTS seen_products (
+			category TEXT NOT NULL,
+			model TEXT NOT NULL,
+			PRIMARY KEY (category, model)
+		);
+		DELETE FROM temp.seen_products;`)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("%s: failed to create seen products table: %w", opn, err)
+	}
+
+	update := &stateUpdate{tx: tx, tenant: r.tenant, done: done}
+	statements := []struct {
+		stmt  **sql.Stmt
+		query string
+	}{
+		{&update.lookup, "SELECT " + productColumns + ` FROM products
+			WHERE tenant_id = ? AND category = ? AND model = ?`},
+		{&update.seen, "SELECT 1 FROM temp.seen_products WHERE category = ? AND model = ?"},
+		// An upsert keeps the row of a stored product, so the search index is updated in place.
+		{&update.upsert, `INSERT INTO products
+			(tenant_id, model, category, type, quantity, price, image_url, product_url, extras, price_value,
+				match_model, match_ean)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (tenant_id, category, model) DO UPDATE SET type = excluded.type,
+				quantity = excluded.quantity, price = excluded.price, image_url = excluded.image_url,
+				product_url = excluded.product_url, extras = excluded.extras, price_value = excluded.price_value,
+				match_ean = excluded.match_ean`},
+		{&update.mark, "INSERT OR IGNORE INTO temp.seen_products (category, model) VALUES (?, ?)"},
+	}
+	for _, statement := range statements {
+		if *statement.stmt, err = tx.PrepareContext(ctx, statement.query); err != nil {
+			_ = tx.Rollback()
+			return nil, fmt.Errorf("%s: failed to prepare statement: %w", opn, err)
+		}
+	}
+
+	return update, nil
+}
+
+// Product returns the stored product with the category and model, nil if there is none.
+func (u *stateUpdate) Product(ctx context.Context, category, model string) (*models.Product, error) {
+	var p models.Product
+	err := u.lookup.QueryRowContext(ctx, u.tenant, category, model).
+		Scan(productFields(&p)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil //nolint:nilnil // A missing product isn't an error for the lookups of a diff.
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository.sqlite.StateUpdate.Product: failed to get product: %w", err)
+	}
+
+	return &p, nil
+}
+
+// Seen reports whether a product with the category and model was put in the update.
+func (u *stateUpdate) Seen(ctx context.Context, category, model string) (bool, error) {
+	var seen int
+	err := u.seen.QueryRowContext(ctx, category, model).Scan(&seen)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("repository.sqlite.StateUpdate.Seen: failed to check product: %w", err)
+	}
+
+	return true, nil
+}
+
+// Put stores the product in place of the one with the same category and model within a savepoint, so a
+// failing upsert is rolled back without the rest of the update. It returns why the product was skipped,
+// empty if it was stored, and an error if the savepoint failed.
+func (u *stateUpdate) Put(ctx context.Context, p models.Product) (string, error) {
+	const opn = "repository.sqlite.StateUpdate.Put"
+
+	if _, err := u.tx.ExecContext(ctx, "SAVEPOINT product"); err != nil {
+		return "", fmt.Errorf("%s: failed to create savepoint: %w", opn, err)
+	}
+
+	var reason string
+	_, err := u.upsert.ExecContext(
+		ctx, u.tenant, p.Model, p.Category, p.Type, p.Quantity, p.Price, p.ImageURL, p.ProductURL,
+		extrasColumn{&p.Extras}, priceValue(p), models.MatchModel(p.Model), p.EAN(),
+	)
+	if err != nil {
+		reason = fmt.Sprintf("failed to store product with model %s: %v", p.Model, err)
+		if _, err = u.tx.ExecContext(ctx, "ROLLBACK TO product"); err != nil {
+			return "", fmt.Errorf("%s: failed to roll back product with model %s: %w", opn, p.Model, err)
+		}
+	}
+	if _, err = u.tx.ExecContext(ctx, "RELEASE product"); err != nil {
+		return "", fmt.Errorf("%s: failed to release savepoint: %w", opn, err)
+	}
+
+	// A skipped product is marked too, so its stored version isn't removed.
+	if _, err = u.mark.ExecContext(ctx, p.Category, p.Model); err != nil {
+		return "", fmt.Errorf("%s: failed to mark product with model %s: %w", opn, p.Model, err)
+	}
+
+	return reason, nil
+}
+
+// RemoveUnseen deletes the stored products that weren't put in the update and returns them.
+func (u *stateUpdate) RemoveUnseen(ctx context.Context) ([]models.Product, error) {
+	const opn = "repository.sqlite.StateUpdate.RemoveUnseen"
+
+	const unseen = `FROM products WHERE tenant_id = ? AND NOT EXISTS (
+		SELECT 1 FROM temp.seen_products s WHERE s.category = products.category AND s.model = products.model)`
+	rows, err := u.tx.QueryContext(
+		ctx, "SELECT "+productColumns+" "+unseen, u.tenant,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get removed products: %w", opn, err)
+	}
+	defer rows.Close()
+
+	var removed []models.Product
+	for rows.Next() {
+		var p models.Product
+		err = rows.Scan(productFields(&p)...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan product: %w", opn, err)
+		}
+		removed = append(removed, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	if _, err = u.tx.ExecContext(ctx, "DELETE "+unseen, u.tenant); err != nil {
+		return nil, fmt.Errorf("%s: failed to delete removed products: %w", opn, err)
+	}
+
+	return removed, nil
+}
+
+// Enqueue queues the change set for delivery, it's committed with the update.
+func (u *stateUpdate) Enqueue(ctx context.Context, entry *models.OutboxEntry) error {
+	if err := enqueueChanges(ctx, u.tx, u.tenant, entry); err != nil {
+		return fmt.Errorf("repository.sqlite.StateUpdate.Enqueue: %w", err)
+	}
+
+	return nil
+}
+
+// Commit saves the page hash and applies the update.
+func (u *stateUpdate) Commit(ctx context.Context, pageHash string) (err error) {
+	const opn = "repository.sqlite.StateUpdate.Commit"
+	defer func() { err = u.finish(err) }()
+
+	_, err = u.tx.ExecContext(
+		ctx, "INSERT OR REPLACE INTO page_state (tenant_id, page_hash) VALUES (?, ?)", u.tenant, pageHash,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: failed to update page hash: %w", opn, err)
+	}
+	if _, err = u.tx.ExecContext(ctx, "DELETE FROM temp.seen_products"); err != nil {
+		return fmt.Errorf("%s: failed to clear seen products: %w", opn, err)
+	}
+	if err = u.tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", opn, err)
+	}
+
+	return nil
+}
+
+// Rollback discards the update, it does nothing after Commit.
+func (u *stateUpdate) Rollback() error {
+	err := u.tx.Rollback()
+	// An update rolled back before its commit is observed as failed.
+	_ = u.finish(errStateUpdateRolledBack)
+	if err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return fmt.Errorf("repository.sqlite.StateUpdate.Rollback: %w", err)
+	}
+
+	return nil
+}
+
+// finish ends the observation of the update with its error, once: it returns the error as observed, see
+// Repository.observe.
+func (u *stateUpdate) finish(err error) error {
+	if u.done == nil {
+		return err
+	}
+	done := u.done
+	u.done = nil
+
+	return done(err)
+}
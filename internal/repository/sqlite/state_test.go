@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/Houeta/chrono-flow/internal/models"
@@ -20,7 +21,7 @@ import (
 // =============================================================================
 
 // newTestDB is a helper function that creates a temporary database for a test.
-func newTestDB(t *testing.T) sqlite.StateRepository {
+func newTestDB(t *testing.T) *sqlite.Repository {
 	// t.Helper() marks this function as a test helper.
 	t.Helper()
 
@@ -65,6 +66,7 @@ func TestRepository_Integration_UpdateAndGetState(t *testing.T) {
 		Products: []models.Product{
 			{Model: "A1", Price: "100"},
 			{Model: "B2", Price: "200"},
+			{Model: "A1", Price: "80", Category: "used", ProductURL: "https://example.com/a1-used"},
 		},
 	}
 
@@ -115,6 +117,136 @@ func TestRepository_Integration_UpdateAndGetState(t *testing.T) {
 	})
 }
 
+// TestRepository_Integration_UpdateState_SkipsFailingProducts stores the products around one failing to insert.
+func TestRepository_Integration_UpdateState_SkipsFailingProducts(t *testing.T) {
+	// Arrange: the repeated model violates the primary key of the products.
+	repo := newTestDB(t)
+	ctx := t.Context()
+	state := &models.State{PageHash: "hash", Products: []models.Product{
+		{Model: "A1", Price: "100"},
+		{Model: "A1", Price: "90"},
+		{Model: "B2", Price: "200"},
+	}}
+
+	// Act
+	err := repo.UpdateState(ctx, state)
+
+	// Assert: the repeated model is skipped, the others are committed.
+	require.NoError(t, err)
+	require.Len(t, state.Skipped, 1)
+	assert.Equal(t, models.Product{Model: "A1", Price: "90"}, state.Skipped[0].Product)
+	assert.Contains(t, state.Skipped[0].Reason, "UNIQUE constraint failed")
+
+	stored, err := repo.GetState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "hash", stored.PageHash)
+	assert.ElementsMatch(t, []models.Product{{Model: "A1", Price: "100"}, {Model: "B2", Price: "200"}}, stored.Products)
+
+	// Every update lists the products it skipped anew.
+	require.NoError(t, repo.UpdateState(ctx, state))
+	assert.Len(t, state.Skipped, 1)
+}
+
+// put stores the product in the state update, failing the test if the product is skipped.
+func put(t *testing.T, update sqlite.StateUpdate, p models.Product) {
+	t.Helper()
+
+	reason, err := update.Put(t.Context(), p)
+	require.NoError(t, err)
+	require.Empty(t, reason)
+}
+
+// TestRepository_Integration_StateUpdate replaces the state product by product.
+func TestRepository_Integration_StateUpdate(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	a1 := models.Product{Model: "A1", Price: "100"}
+	b2 := models.Product{Model: "B2", Price: "200"}
+	usedA1 := models.Product{Model: "A1", Price: "80", Category: "used"}
+	require.NoError(t, repo.UpdateState(ctx, &models.State{
+		PageHash: "hash1",
+		Products: []models.Product{a1, b2, usedA1},
+	}))
+	require.NoError(t, repo.ForTenant("acme").UpdateState(ctx, &models.State{
+		PageHash: "acme",
+		Products: []models.Product{a1},
+	}))
+
+	t.Run("page hash", func(t *testing.T) {
+		hash, err := repo.GetPageHash(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "hash1", hash)
+
+		_, err = repo.ForTenant("unknown").GetPageHash(ctx)
+		require.ErrorIs(t, err, repository.ErrStateNotFound)
+	})
+
+	t.Run("rolled back update changes nothing", func(t *testing.T) {
+		update, err := repo.BeginStateUpdate(ctx)
+		require.NoError(t, err)
+		put(t, update, models.Product{Model: "C3", Price: "300"})
+		_, err = update.RemoveUnseen(ctx)
+		require.NoError(t, err)
+		require.NoError(t, update.Rollback())
+
+		state, err := repo.GetState(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "hash1", state.PageHash)
+		assert.ElementsMatch(t, []models.Product{a1, b2, usedA1}, state.Products)
+	})
+
+	t.Run("committed update", func(t *testing.T) {
+		update, err := repo.BeginStateUpdate(ctx)
+		require.NoError(t, err)
+
+		stored, err := update.Product(ctx, "", "A1")
+		require.NoError(t, err)
+		assert.Equal(t, &a1, stored)
+		stored, err = update.Product(ctx, "", "C3")
+		require.NoError(t, err)
+		assert.Nil(t, stored)
+
+		cheaper := models.Product{Model: "A1", Price: "90"}
+		put(t, update, cheaper)
+		put(t, update, models.Product{Model: "C3", Price: "300"})
+
+		seen, err := update.Seen(ctx, "", "A1")
+		require.NoError(t, err)
+		assert.True(t, seen)
+		seen, err = update.Seen(ctx, "used", "A1")
+		require.NoError(t, err)
+		assert.False(t, seen, "products are told apart by category")
+		stored, err = update.Product(ctx, "", "A1")
+		require.NoError(t, err)
+		assert.Equal(t, &cheaper, stored, "a product put in the update is returned as it was put")
+
+		removed, err := update.RemoveUnseen(ctx)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []models.Product{b2, usedA1}, removed)
+		require.NoError(t, update.Commit(ctx, "hash2"))
+		require.NoError(t, update.Rollback(), "rolling back a committed update does nothing")
+
+		state, err := repo.GetState(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "hash2", state.PageHash)
+		assert.ElementsMatch(t, []models.Product{cheaper, {Model: "C3", Price: "300"}}, state.Products)
+
+		acme, err := repo.ForTenant("acme").GetState(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Product{a1}, acme.Products, "the products of other tenants are kept")
+	})
+
+	t.Run("products seen by a previous update are forgotten", func(t *testing.T) {
+		update, err := repo.BeginStateUpdate(ctx)
+		require.NoError(t, err)
+		defer update.Rollback() //nolint:errcheck // The update is only inspected.
+
+		seen, err := update.Seen(ctx, "", "A1")
+		require.NoError(t, err)
+		assert.False(t, seen)
+	})
+}
+
 // =============================================================================
 // Unit Tests (using sqlmock for failure scenarios)
 // =============================================================================
@@ -134,6 +266,15 @@ func newMockedRepo(t *testing.T) (*sqlite.Repository, sqlmock.Sqlmock) {
 	return repo, mock
 }
 
+// selectProductsQuery matches the query GetState loads the products with.
+const selectProductsQuery = "SELECT model, category, type, quantity, price, image_url, product_url, extras " +
+	"FROM products"
+
+//nolint:gochecknoglobals // productColumns is a read-only list of the products table columns.
+var productColumns = []string{
+	"model", "category", "type", "quantity", "price", "image_url", "product_url", "extras",
+}
+
 // TestRepository_GetState_Failures tests how GetState handles database errors.
 func TestRepository_GetState_Failures(t *testing.T) {
 	ctx := t.Context()
@@ -163,7 +304,7 @@ func TestRepository_GetState_Failures(t *testing.T) {
 
 		// Expect a query for products and return an error.
 		expectedErr := errors.New("table products is locked")
-		mock.ExpectQuery("SELECT model, type, quantity, price, image_url FROM products").
+		mock.ExpectQuery(selectProductsQuery).
 			WillReturnError(expectedErr)
 
 		// Act
@@ -183,9 +324,9 @@ func TestRepository_GetState_Failures(t *testing.T) {
 		mock.ExpectQuery("SELECT page_hash FROM page_state").WillReturnRows(hashRows)
 
 		// Expect a query for products and return an error.
-		productRows := sqlmock.NewRows([]string{"model", "type", "quantity", "price", "image_url"}).
-			AddRow(nil, 123, 123, 123, 123)
-		mock.ExpectQuery("SELECT model, type, quantity, price, image_url FROM products").WillReturnRows(productRows)
+		productRows := sqlmock.NewRows(productColumns).
+			AddRow(nil, 123, 123, 123, 123, 123, 123, "")
+		mock.ExpectQuery(selectProductsQuery).WillReturnRows(productRows)
 
 		// Act
 		_, err := repo.GetState(ctx)
@@ -196,6 +337,22 @@ func TestRepository_GetState_Failures(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("error_on_invalid_extras", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		hashRows := sqlmock.NewRows([]string{"page_hash"}).AddRow("test_hash")
+		mock.ExpectQuery("SELECT page_hash FROM page_state").WillReturnRows(hashRows)
+		productRows := sqlmock.NewRows(productColumns).AddRow("A1", "", "", "", "", "", "", "{")
+		mock.ExpectQuery(selectProductsQuery).WillReturnRows(productRows)
+
+		// Act
+		_, err := repo.GetState(ctx)
+
+		// Assert
+		require.ErrorContains(t, err, "invalid extra fields")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("error_on_rows", func(t *testing.T) {
 		// Arrange
 		repo, mock := newMockedRepo(t)
@@ -204,10 +361,10 @@ func TestRepository_GetState_Failures(t *testing.T) {
 		mock.ExpectQuery("SELECT page_hash FROM page_state").WillReturnRows(hashRows)
 
 		// Expect a query for products and return an error.
-		productRows := sqlmock.NewRows([]string{"model", "type", "quantity", "price", "image_url"}).
-			AddRow(123, 123, 123, 123, 123).
+		productRows := sqlmock.NewRows(productColumns).
+			AddRow(123, 123, 123, 123, 123, 123, 123, "").
 			RowError(0, assert.AnError)
-		mock.ExpectQuery("SELECT model, type, quantity, price, image_url FROM products").WillReturnRows(productRows)
+		mock.ExpectQuery(selectProductsQuery).WillReturnRows(productRows)
 
 		// Act
 		_, err := repo.GetState(ctx)
@@ -251,7 +408,7 @@ func TestRepository_UpdateState_Failures(t *testing.T) {
 
 		// Expect successful page_state update
 		mock.ExpectExec("INSERT OR REPLACE INTO page_state").
-			WithArgs(stateToUpdate.PageHash).
+			WithArgs("", stateToUpdate.PageHash).
 			WillReturnError(assert.AnError)
 
 		// Because an error occurred, expect a Rollback.
@@ -274,12 +431,12 @@ func TestRepository_UpdateState_Failures(t *testing.T) {
 
 		// Expect successful page_state update
 		mock.ExpectExec("INSERT OR REPLACE INTO page_state").
-			WithArgs(stateToUpdate.PageHash).
+			WithArgs("", stateToUpdate.PageHash).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		// Expect the DELETE query and return an error.
 		expectedErr := errors.New("delete failed")
-		mock.ExpectExec("DELETE FROM products").
+		mock.ExpectQuery("DELETE FROM products").
 			WillReturnError(expectedErr)
 
 		// Because an error occurred, expect a Rollback.
@@ -298,7 +455,7 @@ func TestRepository_UpdateState_Failures(t *testing.T) {
 		repo, mock := newMockedRepo(t)
 		mock.ExpectBegin()
 		mock.ExpectExec("INSERT OR REPLACE INTO page_state").WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectExec("DELETE FROM products").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("DELETE FROM products").WillReturnRows(sqlmock.NewRows(productColumns))
 
 		// Expect the method prepare returns an error
 		mock.ExpectPrepare("INSERT INTO products").WillReturnError(assert.AnError)
@@ -316,27 +473,124 @@ func TestRepository_UpdateState_Failures(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("error_on_insert_query", func(t *testing.T) {
+	t.Run("error_on_insert_query_skips_product", func(t *testing.T) {
 		// Arrange
 		repo, mock := newMockedRepo(t)
 		mock.ExpectBegin()
 		mock.ExpectExec("INSERT OR REPLACE INTO page_state").WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectExec("DELETE FROM products").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("DELETE FROM products").WillReturnRows(sqlmock.NewRows(productColumns))
+		prep := mock.ExpectPrepare("INSERT INTO products")
+
+		// Expect the failing insert to be rolled back to its savepoint and the rest to be committed.
+		mock.ExpectExec("SAVEPOINT product").WillReturnResult(sqlmock.NewResult(0, 0))
+		prep.ExpectExec().WithArgs("", "A1", "", "", "", "", "", "", "", nil, "a1", "").WillReturnError(assert.AnError)
+		mock.ExpectExec("ROLLBACK TO product").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("RELEASE product").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		state := &models.State{PageHash: "new_hash", Products: []models.Product{{Model: "A1"}}}
+
+		// Act
+		err := repo.UpdateState(ctx, state)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, state.Skipped, 1)
+		assert.Equal(t, "A1", state.Skipped[0].Product.Model)
+		assert.Contains(t, state.Skipped[0].Reason, "failed to insert product with model A1")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 
-		// Expect the prepared statement and a successful execution.
+	t.Run("error_on_insert_query_keeps_stored_product", func(t *testing.T) {
+		// Arrange: A1 is stored, its new price fails to insert.
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT OR REPLACE INTO page_state").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery("DELETE FROM products").
+			WillReturnRows(sqlmock.NewRows(productColumns).AddRow("A1", "", "", "", "100", "", "", ""))
 		prep := mock.ExpectPrepare("INSERT INTO products")
-		prep.ExpectExec().WithArgs("A1", "", "", "", "").WillReturnError(assert.AnError)
+		mock.ExpectExec("SAVEPOINT product").WillReturnResult(sqlmock.NewResult(0, 0))
+		prep.ExpectExec().WithArgs("", "A1", "", "", "", "90", "", "", "", sqlmock.AnyArg(), "a1", "").
+			WillReturnError(assert.AnError)
+		mock.ExpectExec("ROLLBACK TO product").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("RELEASE product").WillReturnResult(sqlmock.NewResult(0, 0))
 
-		// Because an error occurred, expect a Rollback.
+		// Expect the stored A1 to be inserted back, then B2.
+		mock.ExpectExec("SAVEPOINT product").WillReturnResult(sqlmock.NewResult(0, 0))
+		prep.ExpectExec().WithArgs("", "A1", "", "", "", "100", "", "", "", sqlmock.AnyArg(), "a1", "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("RELEASE product").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("SAVEPOINT product").WillReturnResult(sqlmock.NewResult(0, 0))
+		prep.ExpectExec().WithArgs("", "B2", "", "", "", "200", "", "", "", sqlmock.AnyArg(), "b2", "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("RELEASE product").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// Expect only the change of B2 to be queued.
+		queued := &models.Changes{Added: []models.Product{{Model: "B2", Price: "200"}}}
+		mock.ExpectExec("INSERT INTO outbox").
+			WithArgs("", "run", sqlmock.AnyArg(), sqlmock.AnyArg(), queued.Fingerprint()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		changes := &models.Changes{
+			Added: []models.Product{{Model: "B2", Price: "200"}},
+			Changed: []models.ChangeInfo{
+				{Old: models.Product{Model: "A1", Price: "100"}, New: models.Product{Model: "A1", Price: "90"}},
+			},
+		}
+		state := &models.State{
+			PageHash: "new_hash",
+			Products: []models.Product{{Model: "A1", Price: "90"}, {Model: "B2", Price: "200"}},
+			Outbox:   &models.OutboxEntry{RunID: "run", DetectedAt: time.Now(), Changes: changes},
+		}
+
+		// Act
+		err := repo.UpdateState(ctx, state)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, state.Skipped, 1)
+		assert.Equal(t, models.Product{Model: "A1", Price: "90"}, state.Skipped[0].Product)
+		assert.Equal(t, queued, state.Outbox.Changes)
+		assert.Equal(t, queued, state.WithoutSkipped(changes), "the returned changes exclude the skipped product")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error_on_savepoint", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT OR REPLACE INTO page_state").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery("DELETE FROM products").WillReturnRows(sqlmock.NewRows(productColumns))
+		mock.ExpectPrepare("INSERT INTO products")
+		mock.ExpectExec("SAVEPOINT product").WillReturnError(assert.AnError)
 		mock.ExpectRollback()
 
 		// Act
 		err := repo.UpdateState(ctx, stateToUpdate)
 
 		// Assert
-		require.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to insert product with model")
 		require.ErrorIs(t, err, assert.AnError)
+		assert.Contains(t, err.Error(), "failed to create savepoint")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error_on_rollback_to_savepoint", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT OR REPLACE INTO page_state").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery("DELETE FROM products").WillReturnRows(sqlmock.NewRows(productColumns))
+		prep := mock.ExpectPrepare("INSERT INTO products")
+		mock.ExpectExec("SAVEPOINT product").WillReturnResult(sqlmock.NewResult(0, 0))
+		prep.ExpectExec().WillReturnError(errors.New("insert failed"))
+		mock.ExpectExec("ROLLBACK TO product").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		err := repo.UpdateState(ctx, stateToUpdate)
+
+		// Assert
+		require.ErrorIs(t, err, assert.AnError)
+		assert.Contains(t, err.Error(), "failed to roll back product with model A1")
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
@@ -345,11 +599,14 @@ func TestRepository_UpdateState_Failures(t *testing.T) {
 		repo, mock := newMockedRepo(t)
 		mock.ExpectBegin()
 		mock.ExpectExec("INSERT OR REPLACE INTO page_state").WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectExec("DELETE FROM products").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("DELETE FROM products").WillReturnRows(sqlmock.NewRows(productColumns))
 
-		// Expect the prepared statement and a successful execution.
+		// Expect the prepared statement and a successful execution within a savepoint.
 		prep := mock.ExpectPrepare("INSERT INTO products")
-		prep.ExpectExec().WithArgs("A1", "", "", "", "").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("SAVEPOINT product").WillReturnResult(sqlmock.NewResult(0, 0))
+		prep.ExpectExec().WithArgs("", "A1", "", "", "", "", "", "", "", nil, "a1", "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("RELEASE product").WillReturnResult(sqlmock.NewResult(0, 0))
 
 		// Expect the final Commit call and return an error.
 		expectedErr := errors.New("commit failed")
@@ -364,3 +621,69 @@ func TestRepository_UpdateState_Failures(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
+
+// TestRepository_StateUpdate_Failures tests how the incremental state update handles database errors.
+func TestRepository_StateUpdate_Failures(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error_on_page_hash_query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("SELECT page_hash FROM page_state").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.GetPageHash(ctx)
+
+		// Assert
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "repository.sqlite.GetPageHash: failed to get page hash")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error_on_begin", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin().WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.BeginStateUpdate(ctx)
+
+		// Assert
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to begin transaction")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error_on_seen_table", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("CREATE TEMP TABLE IF NOT EXISTS seen_products").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		_, err := repo.BeginStateUpdate(ctx)
+
+		// Assert
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to create seen products table")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error_on_prepare", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("CREATE TEMP TABLE IF NOT EXISTS seen_products").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPrepare(selectProductsQuery).WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		_, err := repo.BeginStateUpdate(ctx)
+
+		// Assert
+		require.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to prepare statement")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
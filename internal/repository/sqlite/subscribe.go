@@ -3,12 +3,25 @@ package sqlite
 import (
 	"context"
 	"fmt"
+	"time"
 )
 
-// SubscribeChat adds the chat ID to the table.
-func (r *Repository) SubscribeChat(ctx context.Context, chatID int64) error {
+// Kinds of the recorded subscription events.
+const (
+	subscriptionEventSubscribe   = "subscribe"
+	subscriptionEventUnsubscribe = "unsubscribe"
+)
+
+// SubscribeChat adds the chat ID to the table and records the subscription for the subscriber statistics.
+func (r *Repository) SubscribeChat(ctx context.Context, chatID int64) (err error) {
 	const op = "repository.sqlite.SubcribeChat"
-	_, err := r.db.ExecContext(ctx, "INSERT OR IGNORE INTO subscriptions (chat_id) VALUES (?)", chatID)
+	ctx, done := r.observe(ctx, "SubscribeChat")
+	defer func() { err = done(err) }()
+
+	err = r.changeSubscription(
+		ctx, chatID, subscriptionEventSubscribe,
+		"INSERT OR IGNORE INTO subscriptions (tenant_id, chat_id) VALUES (?, ?)",
+	)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -16,10 +29,16 @@ func (r *Repository) SubscribeChat(ctx context.Context, chatID int64) error {
 	return nil
 }
 
-// UnsubscribeChat deletes the chat ID from table.
-func (r *Repository) UnsubscribeChat(ctx context.Context, chatID int64) error {
+// UnsubscribeChat deletes the chat ID from table and records the unsubscription for the subscriber statistics.
+func (r *Repository) UnsubscribeChat(ctx context.Context, chatID int64) (err error) {
 	const op = "repository.sqlite.UnsubscribeChat"
-	_, err := r.db.ExecContext(ctx, "DELETE FROM subscriptions WHERE chat_id = ?", chatID)
+	ctx, done := r.observe(ctx, "UnsubscribeChat")
+	defer func() { err = done(err) }()
+
+	err = r.changeSubscription(
+		ctx, chatID, subscriptionEventUnsubscribe,
+		"DELETE FROM subscriptions WHERE tenant_id = ? AND chat_id = ?",
+	)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -27,10 +46,50 @@ func (r *Repository) UnsubscribeChat(ctx context.Context, chatID int64) error {
 	return nil
 }
 
+// changeSubscription runs the query taking the tenant and the chat ID and records the event if the query
+// changed the subscription, so repeated commands aren't counted.
+func (r *Repository) changeSubscription(ctx context.Context, chatID int64, kind, query string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after a successful commit only returns sql.ErrTxDone.
+
+	res, err := tx.ExecContext(ctx, query, r.tenant, chatID)
+	if err != nil {
+		return err //nolint:wrapcheck // the caller names the operation.
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return nil
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO subscription_events (tenant_id, chat_id, kind, at) VALUES (?, ?, ?, ?)",
+		r.tenant, chatID, kind, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record %s event: %w", kind, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
 // GetSubscribedChats returns a slice of all subscribed chat IDs.
-func (r *Repository) GetSubscribedChats(ctx context.Context) ([]int64, error) {
+func (r *Repository) GetSubscribedChats(ctx context.Context) (_ []int64, err error) {
 	const opn = "repository.sqlite.GetSubscribedChats"
-	rows, err := r.db.QueryContext(ctx, "SELECT chat_id FROM subscriptions")
+	ctx, done := r.observe(ctx, "GetSubscribedChats")
+	defer func() { err = done(err) }()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT chat_id FROM subscriptions WHERE tenant_id = ?", r.tenant)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", opn, err)
 	}
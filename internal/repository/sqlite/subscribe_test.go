@@ -19,7 +19,9 @@ func TestSubscribeChat(t *testing.T) {
 	t.Run("error: exec query", func(t *testing.T) {
 		// Arrange
 		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
 		mock.ExpectExec("INSERT OR IGNORE INTO subscriptions").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
 
 		// Act
 		err := repo.SubscribeChat(ctx, int64(chatID))
@@ -34,7 +36,25 @@ func TestSubscribeChat(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		// Arrange
 		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
 		mock.ExpectExec("INSERT OR IGNORE INTO subscriptions").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO subscription_events").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		// Act
+		err := repo.SubscribeChat(ctx, int64(chatID))
+
+		// Assert
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("success: already subscribed chat isn't recorded again", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT OR IGNORE INTO subscriptions").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
 
 		// Act
 		err := repo.SubscribeChat(ctx, int64(chatID))
@@ -52,7 +72,9 @@ func TestUnsubscribeChat(t *testing.T) {
 	t.Run("error: exec query", func(t *testing.T) {
 		// Arrange
 		repo, mock := newMockedRepo(t)
-		mock.ExpectExec("DELETE FROM subscriptions WHERE chat_id").WillReturnError(assert.AnError)
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM subscriptions WHERE tenant_id").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
 
 		// Act
 		err := repo.UnsubscribeChat(ctx, int64(chatID))
@@ -67,7 +89,10 @@ func TestUnsubscribeChat(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		// Arrange
 		repo, mock := newMockedRepo(t)
-		mock.ExpectExec("DELETE FROM subscriptions WHERE chat_id").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM subscriptions WHERE tenant_id").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO subscription_events").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
 		// Act
 		err := repo.UnsubscribeChat(ctx, int64(chatID))
@@ -0,0 +1,174 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// day is the length of a statistics day.
+const day = 24 * time.Hour
+
+// RecordChatActivity records that the chat used the bot on the UTC day of the given time.
+func (r *Repository) RecordChatActivity(ctx context.Context, chatID int64, at time.Time) (err error) {
+	const op = "repository.sqlite.RecordChatActivity"
+	ctx, done := r.observe(ctx, "RecordChatActivity")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(
+		ctx,
+		"INSERT OR IGNORE INTO chat_activity (tenant_id, chat_id, day) VALUES (?, ?, ?)",
+		r.tenant,
+		chatID,
+		at.UTC().Format(time.DateOnly),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetSubscriberStats returns the subscriber statistics of every UTC day from the one of since to today,
+// oldest first. The number of subscribers of a day is derived from the current subscribers and the
+// subscription events recorded after the day.
+func (r *Repository) GetSubscriberStats(ctx context.Context, since time.Time) (_ []models.SubscriberDay, err error) {
+	const opn = "repository.sqlite.GetSubscriberStats"
+	ctx, done := r.observe(ctx, "GetSubscriberStats")
+	defer func() { err = done(err) }()
+
+	first := since.UTC().Truncate(day)
+	today := time.Now().UTC().Truncate(day)
+	if first.After(today) {
+		return nil, nil
+	}
+	days := make([]models.SubscriberDay, int(today.Sub(first)/day)+1)
+	for idx := range days {
+		days[idx].Day = first.Add(time.Duration(idx) * day)
+	}
+
+	var subscribers int
+	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM subscriptions WHERE tenant_id = ?", r.tenant).
+		Scan(&subscribers)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to count subscribers: %w", opn, err)
+	}
+
+	if err = r.addSubscriptionEvents(ctx, days); err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+	if err = r.addChatActivity(ctx, days); err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+
+	// Walk back from today, the subscribers at the end of a day are the ones at the end of the next
+	// day without the chats that subscribed on the next day and with the ones that left on it.
+	for idx := len(days) - 1; idx >= 0; idx-- {
+		days[idx].Subscribers = subscribers
+		subscribers += days[idx].Unsubscribed - days[idx].Subscribed
+	}
+
+	return days, nil
+}
+
+// addSubscriptionEvents counts the subscription events of the days.
+func (r *Repository) addSubscriptionEvents(ctx context.Context, days []models.SubscriberDay) error {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT kind, at FROM subscription_events WHERE tenant_id = ? AND at >= ?",
+		r.tenant,
+		days[0].Day,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query subscription events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			kind string
+			at   time.Time
+		)
+		if err = rows.Scan(&kind, &at); err != nil {
+			return fmt.Errorf("failed to scan subscription event: %w", err)
+		}
+
+		idx := int(at.UTC().Sub(days[0].Day) / day)
+		if idx >= len(days) {
+			continue
+		}
+		if kind == subscriptionEventSubscribe {
+			days[idx].Subscribed++
+		} else {
+			days[idx].Unsubscribed++
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("subscription events iteration error: %w", err)
+	}
+
+	return nil
+}
+
+// addChatActivity counts the active chats of the days.
+func (r *Repository) addChatActivity(ctx context.Context, days []models.SubscriberDay) error {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT day, COUNT(*) FROM chat_activity WHERE tenant_id = ? AND day >= ? GROUP BY day",
+		r.tenant,
+		days[0].Day.Format(time.DateOnly),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query chat activity: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			date  string
+			count int
+		)
+		if err = rows.Scan(&date, &count); err != nil {
+			return fmt.Errorf("failed to scan chat activity: %w", err)
+		}
+
+		activeOn, parseErr := time.Parse(time.DateOnly, date)
+		if parseErr != nil {
+			return fmt.Errorf("invalid chat activity day %q: %w", date, parseErr)
+		}
+		if idx := int(activeOn.Sub(days[0].Day) / day); idx < len(days) {
+			days[idx].ActiveChats = count
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("chat activity iteration error: %w", err)
+	}
+
+	return nil
+}
+
+// CountSubscriptionEvents returns how many times chats subscribed and unsubscribed in total.
+func (r *Repository) CountSubscriptionEvents(ctx context.Context) (_ int64, _ int64, err error) {
+	const op = "repository.sqlite.CountSubscriptionEvents"
+	ctx, done := r.observe(ctx, "CountSubscriptionEvents")
+	defer func() { err = done(err) }()
+
+	var subscribed, unsubscribed int64
+	err = r.db.QueryRowContext(
+		ctx,
+		`SELECT COALESCE(SUM(kind = ?), 0), COALESCE(SUM(kind = ?), 0)
+		FROM subscription_events WHERE tenant_id = ?`,
+		subscriptionEventSubscribe,
+		subscriptionEventUnsubscribe,
+		r.tenant,
+	).Scan(&subscribed, &unsubscribed)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return subscribed, unsubscribed, nil
+}
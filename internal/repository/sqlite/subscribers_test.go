@@ -0,0 +1,68 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_SubscriberStats(t *testing.T) {
+	ctx := t.Context()
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	repo, err := sqlite.NewRepository(ctx, slog.New(slog.NewTextHandler(io.Discard, nil)), dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = repo.Close() })
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	// The repository records events at the current time, so an older subscription is stored directly.
+	raw, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	_, err = raw.ExecContext(ctx, `INSERT INTO subscriptions (tenant_id, chat_id) VALUES ('', 1);
+		INSERT INTO subscription_events (tenant_id, chat_id, kind, at) VALUES ('', 1, 'subscribe', ?)`,
+		today.Add(-36*time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, raw.Close())
+
+	require.NoError(t, repo.SubscribeChat(ctx, 2))
+	require.NoError(t, repo.SubscribeChat(ctx, 2), "a repeated subscription isn't recorded again")
+	require.NoError(t, repo.UnsubscribeChat(ctx, 1))
+	require.NoError(t, repo.UnsubscribeChat(ctx, 1))
+	require.NoError(t, repo.RecordChatActivity(ctx, 2, time.Now()))
+	require.NoError(t, repo.RecordChatActivity(ctx, 2, time.Now()))
+	require.NoError(t, repo.RecordChatActivity(ctx, 1, today.Add(-time.Hour)))
+	require.NoError(t, repo.ForTenant("acme").SubscribeChat(ctx, 3))
+	require.NoError(t, repo.ForTenant("acme").RecordChatActivity(ctx, 3, time.Now()))
+
+	days, err := repo.GetSubscriberStats(ctx, today.Add(-3*24*time.Hour+time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []models.SubscriberDay{
+		{Day: today.Add(-3 * 24 * time.Hour)},
+		{Day: today.Add(-2 * 24 * time.Hour), Subscribers: 1, Subscribed: 1},
+		{Day: today.Add(-24 * time.Hour), Subscribers: 1, ActiveChats: 1},
+		{Day: today, Subscribers: 1, Subscribed: 1, Unsubscribed: 1, ActiveChats: 1},
+	}, days)
+	assert.Zero(t, models.Churn(days), "there were no subscribers at the start")
+	assert.InDelta(t, 100.0, models.Churn(days[2:]), 0.001)
+
+	subscribed, unsubscribed, err := repo.CountSubscriptionEvents(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), subscribed)
+	assert.Equal(t, int64(1), unsubscribed)
+
+	future, err := repo.GetSubscriberStats(ctx, today.Add(48*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, future)
+}
@@ -0,0 +1,74 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migratedChatData are the kinds of data of a group moved to the supergroup it was upgraded to. The
+// notifications sent to the group refer to its messages, the supergroup doesn't have them, so they and
+// their ratings stay with the group.
+func migratedChatData() []chatData {
+	return []chatData{
+		{kind: "access", table: "allowed_chats"},
+		{kind: "subscription", table: "subscriptions"},
+		{kind: "settings and filters", table: "chat_settings"},
+		{kind: "wishlists", table: "wishlists"},
+		{kind: "wishlist items", table: "wishlist_items"},
+		{kind: "alert rules", table: "alert_rules"},
+		{kind: "direct messages", table: "users"},
+		{kind: "subscription history", table: "subscription_events"},
+		{kind: "activity days", table: "chat_activity"},
+		{kind: "API tokens", table: "api_tokens", global: true},
+	}
+}
+
+// MigrateChat moves the data stored about the group by the tenant and its targets to the supergroup the
+// group was upgraded to, in a single transaction, and returns how many records were moved. The records
+// the supergroup has already are kept, the conflicting ones of the group are deleted.
+func (r *Repository) MigrateChat(ctx context.Context, fromID, toID int64) (_ int, err error) {
+	const opn = "repository.sqlite.MigrateChat"
+	ctx, done := r.observe(ctx, "MigrateChat")
+	defer func() { err = done(err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil) //nolint:varnamelen // tx its a default naming for transaction
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", opn, err)
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after a successful commit only returns sql.ErrTxDone.
+
+	moved := 0
+	for _, data := range migratedChatData() {
+		// Table names come from a fixed list, only the chat IDs are user input.
+		scope := " WHERE chat_id = ?2"
+		args := []any{toID, fromID}
+		if !data.global {
+			// The data of the tenant's targets is scoped by "<tenant>/<target>".
+			scope += " AND (tenant_id = ?3 OR tenant_id LIKE ?4)"
+			args = append(args, r.tenant, r.tenant+"/%")
+		}
+
+		var res sql.Result
+		query := "UPDATE OR IGNORE " + data.table + " SET chat_id = ?1" + scope
+		if res, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return 0, fmt.Errorf("%s: failed to move %s: %w", opn, data.table, err)
+		}
+		var affected int64
+		if affected, err = res.RowsAffected(); err != nil {
+			return 0, fmt.Errorf("%s: failed to get affected rows of %s: %w", opn, data.table, err)
+		}
+		moved += int(affected)
+
+		// The records left conflicted with the ones of the supergroup.
+		if _, err = tx.ExecContext(ctx, "DELETE FROM "+data.table+scope, args...); err != nil {
+			return 0, fmt.Errorf("%s: failed to delete conflicting %s: %w", opn, data.table, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", opn, err)
+	}
+
+	return moved, nil
+}
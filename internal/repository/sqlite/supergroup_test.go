@@ -0,0 +1,125 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_MigrateChat(t *testing.T) {
+	// Arrange
+	repo := newTestDB(t)
+	ctx := t.Context()
+	now := time.Now().UTC()
+	require.NoError(t, repo.AllowChat(ctx, -100))
+	require.NoError(t, repo.SubscribeChat(ctx, -100))
+	require.NoError(t, repo.SetFilterGroup(ctx, -100, "warehouse"))
+	require.NoError(t, repo.SetThreadID(ctx, -100, 15))
+	require.NoError(t, repo.AddWishlistItem(ctx, -100, "A1"))
+	require.NoError(t, repo.SetAlertRule(ctx, models.AlertRule{ChatID: -100, Name: "cheap", Expression: "true"}))
+	require.NoError(t, repo.SetUserSubscription(ctx, models.UserSubscription{UserID: 7, ChatID: -100}))
+	require.NoError(t, repo.RecordNotificationProducts(ctx, -100, 1, now,
+		[]models.ProductRef{{Category: "new", Model: "A1"}}))
+	_, err := repo.CreateToken(ctx, models.APIToken{Name: "feed", ChatID: -100, CreatedAt: now}, "secret")
+	require.NoError(t, err)
+	require.NoError(t, repo.ForTarget("outlet").SubscribeChat(ctx, -100))
+	require.NoError(t, repo.ForTenant("acme").SubscribeChat(ctx, -100))
+	// The supergroup was allowed meanwhile, it keeps its own record.
+	require.NoError(t, repo.AllowChat(ctx, -1001))
+
+	// Act
+	moved, err := repo.MigrateChat(ctx, -100, -1001)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 10, moved)
+
+	allowed, err := repo.GetAllowedChats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{-1001}, allowed)
+	subscribers, err := repo.GetSubscribedChats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{-1001}, subscribers)
+	settings, err := repo.GetChatSettings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]models.ChatSettings{-1001: {FilterGroup: "warehouse", ThreadID: 15}}, settings)
+	wishlist, err := repo.GetWishlist(ctx, -1001)
+	require.NoError(t, err)
+	assert.Len(t, wishlist.Items, 1)
+	alertRules, err := repo.GetAlertRules(ctx)
+	require.NoError(t, err)
+	require.Len(t, alertRules, 1)
+	assert.Equal(t, int64(-1001), alertRules[0].ChatID)
+	users, err := repo.GetUserSubscriptions(ctx)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, int64(-1001), users[0].ChatID)
+	tokens, err := repo.GetTokens(ctx)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, int64(-1001), tokens[0].ChatID)
+	outlet, err := repo.ForTarget("outlet").GetSubscribedChats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{-1001}, outlet, "the data of the targets of the tenant is moved too")
+	acme, err := repo.ForTenant("acme").GetSubscribedChats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{-100}, acme, "the data of other tenants is kept")
+
+	moved, err = repo.MigrateChat(ctx, -100, -1001)
+	require.NoError(t, err)
+	assert.Zero(t, moved, "nothing is left to move")
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestMigrateChat(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: update rolls back", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE OR IGNORE allowed_chats SET chat_id").WithArgs(int64(-1001), int64(-100), "", "/%").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM allowed_chats").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("UPDATE OR IGNORE subscriptions").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		_, err := repo.MigrateChat(ctx, -100, -1001)
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.MigrateChat: failed to move subscriptions")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: commit", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		for range 10 {
+			mock.ExpectExec("UPDATE OR IGNORE").WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec("DELETE FROM").WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+		mock.ExpectCommit().WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.MigrateChat(ctx, -100, -1001)
+
+		// Assert
+		require.ErrorContains(t, err, "failed to commit transaction")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
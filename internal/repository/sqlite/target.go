@@ -0,0 +1,410 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+)
+
+// targetColumns lists the columns of the targets table in the order scanTarget reads them.
+const targetColumns = "name, url, tables, column_selectors, column_synonyms, iframe_selector, interval_seconds, " +
+	"disabled, created_at, headers, regions, network, tls, steps, request, assertions"
+
+// CreateTarget stores a target of the tenant.
+func (r *Repository) CreateTarget(ctx context.Context, target models.Target) (err error) {
+	const op = "repository.sqlite.CreateTarget"
+	ctx, done := r.observe(ctx, "CreateTarget")
+	defer func() { err = done(err) }()
+
+	options, err := marshalTargetOptions(target)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := r.db.ExecContext(
+		ctx,
+		`INSERT OR IGNORE INTO targets (tenant_id, `+targetColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.tenant,
+		target.Name,
+		target.URL,
+		options.tables,
+		options.selectors,
+		options.synonyms,
+		target.IframeSelector,
+		int64(target.Interval/time.Second),
+		target.Disabled,
+		target.CreatedAt.UTC(),
+		r.sealed("targets.headers", &options.headers),
+		r.sealed("targets.regions", &options.regions),
+		options.network,
+		r.sealed("targets.tls", &options.tls),
+		options.steps,
+		r.sealed("targets.request", &options.request),
+		options.assertions,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w: %s", op, repository.ErrTargetExists, target.Name)
+	}
+
+	return nil
+}
+
+// GetTargets returns the targets of the tenant ordered by name.
+func (r *Repository) GetTargets(ctx context.Context) (_ []models.Target, err error) {
+	const opn = "repository.sqlite.GetTargets"
+	ctx, done := r.observe(ctx, "GetTargets")
+	defer func() { err = done(err) }()
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+targetColumns+" FROM targets WHERE tenant_id = ? ORDER BY name",
+		r.tenant,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+	defer rows.Close()
+
+	var targets []models.Target
+	for rows.Next() {
+		target, scanErr := r.scanTarget(rows)
+		if scanErr != nil {
+			return nil, fmt.Errorf("%s: %w", opn, scanErr)
+		}
+		targets = append(targets, target)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	return targets, nil
+}
+
+// GetTarget returns the target of the tenant with the name.
+func (r *Repository) GetTarget(ctx context.Context, name string) (_ *models.Target, err error) {
+	const op = "repository.sqlite.GetTarget"
+	ctx, done := r.observe(ctx, "GetTarget")
+	defer func() { err = done(err) }()
+
+	target, err := r.scanTarget(r.db.QueryRowContext(
+		ctx,
+		"SELECT "+targetColumns+" FROM targets WHERE tenant_id = ? AND name = ?",
+		r.tenant,
+		name,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w: %s", op, repository.ErrTargetNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &target, nil
+}
+
+// UpdateTarget replaces the definition of a stored target, its creation time and whether it's disabled are kept.
+func (r *Repository) UpdateTarget(ctx context.Context, target models.Target) (err error) {
+	const op = "repository.sqlite.UpdateTarget"
+	ctx, done := r.observe(ctx, "UpdateTarget")
+	defer func() { err = done(err) }()
+
+	options, err := marshalTargetOptions(target)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE targets SET url = ?, tables = ?, column_selectors = ?, column_synonyms = ?, iframe_selector = ?,
+		interval_seconds = ?, headers = ?, regions = ?, network = ?, tls = ?, steps = ?,
+		request = ?, assertions = ?
+		WHERE tenant_id = ? AND name = ?`,
+		target.URL,
+		options.tables,
+		options.selectors,
+		options.synonyms,
+		target.IframeSelector,
+		int64(target.Interval/time.Second),
+		r.sealed("targets.headers", &options.headers),
+		r.sealed("targets.regions", &options.regions),
+		options.network,
+		r.sealed("targets.tls", &options.tls),
+		options.steps,
+		r.sealed("targets.request", &options.request),
+		options.assertions,
+		r.tenant,
+		target.Name,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w: %s", op, repository.ErrTargetNotFound, target.Name)
+	}
+
+	return nil
+}
+
+// SetTargetDisabled pauses or resumes the checks of an additional target.
+func (r *Repository) SetTargetDisabled(ctx context.Context, name string, disabled bool) (err error) {
+	const op = "repository.sqlite.SetTargetDisabled"
+	ctx, done := r.observe(ctx, "SetTargetDisabled")
+	defer func() { err = done(err) }()
+
+	if name == models.MainTarget {
+		return fmt.Errorf("%s: %w", op, models.ErrMainTarget)
+	}
+
+	res, err := r.db.ExecContext(
+		ctx,
+		"UPDATE targets SET disabled = ? WHERE tenant_id = ? AND name = ?",
+		disabled,
+		r.tenant,
+		name,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w: %s", op, repository.ErrTargetNotFound, name)
+	}
+
+	return nil
+}
+
+// GetTargetStatuses returns the targets of the tenant ordered by name with the last fetch and the last
+// failed check of each.
+func (r *Repository) GetTargetStatuses(ctx context.Context) (_ []models.TargetStatus, err error) {
+	const opn = "repository.sqlite.GetTargetStatuses"
+	ctx, done := r.observe(ctx, "GetTargetStatuses")
+	defer func() { err = done(err) }()
+
+	targets, err := r.GetTargets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+
+	statuses := make([]models.TargetStatus, 0, len(targets))
+	for _, target := range targets {
+		status := models.TargetStatus{Target: target}
+		var latencyMs int64
+		err = r.db.QueryRowContext(
+			ctx,
+			`SELECT fetched_at, latency_ms, success, error FROM fetches
+			WHERE tenant_id = ? ORDER BY fetched_at DESC, id DESC LIMIT 1`,
+			targetScope(r.tenant, target.Name),
+		).Scan(&status.LastCheck.FetchedAt, &latencyMs, &status.LastCheck.Success, &status.LastCheck.Error)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: failed to get last fetch of %s: %w", opn, target.Name, err)
+		}
+		status.LastCheck.Latency = time.Duration(latencyMs) * time.Millisecond
+		if status.LastError, err = r.getCheckError(ctx, targetScope(r.tenant, target.Name)); err != nil {
+			return nil, fmt.Errorf("%s: failed to get last error of %s: %w", opn, target.Name, err)
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// DeleteTarget removes an additional target and the data stored in its scope and in the ones of its regions
+// in a single transaction.
+func (r *Repository) DeleteTarget(ctx context.Context, name string) (err error) {
+	const opn = "repository.sqlite.DeleteTarget"
+	ctx, done := r.observe(ctx, "DeleteTarget")
+	defer func() { err = done(err) }()
+
+	if name == models.MainTarget {
+		return fmt.Errorf("%s: %w", opn, models.ErrMainTarget)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", opn, err)
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after a successful commit only returns sql.ErrTxDone.
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM targets WHERE tenant_id = ? AND name = ?", r.tenant, name)
+	if err != nil {
+		return fmt.Errorf("%s: failed to delete target: %w", opn, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", opn, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w: %s", opn, repository.ErrTargetNotFound, name)
+	}
+
+	scope := targetScope(r.tenant, name)
+	for _, table := range targetTables() {
+		// Table names come from a fixed list, only the target name is user input. Target names have no
+		// LIKE wildcards, they only match the scopes of the regions.
+		query := "DELETE FROM " + table + " WHERE tenant_id = ? OR tenant_id LIKE ?"
+		if _, err = tx.ExecContext(ctx, query, scope, scope+models.RegionSeparator+"%"); err != nil {
+			return fmt.Errorf("%s: failed to delete %s: %w", opn, table, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", opn, err)
+	}
+
+	return nil
+}
+
+// targetTables are the tables holding the data of a target, the subscribers belong to the tenant.
+func targetTables() []string {
+	return []string{
+		"page_state", "products", "fetches", "changes", "outbox", "runs", "diff_cache", "page_snapshots", "page_diffs",
+		"run_artifacts", "check_errors",
+	}
+}
+
+// targetOptions are the parser options of a target encoded as JSON, empty options are stored as empty strings.
+type targetOptions struct {
+	tables     string
+	selectors  string
+	synonyms   string
+	headers    string
+	regions    string
+	network    string
+	tls        string
+	steps      string
+	request    string
+	assertions string
+}
+
+// marshalTargetOptions encodes the parser options of the target.
+func marshalTargetOptions(target models.Target) (targetOptions, error) {
+	var (
+		options targetOptions
+		err     error
+	)
+	if options.tables, err = marshalOption(target.Tables, len(target.Tables)); err != nil {
+		return targetOptions{}, fmt.Errorf("failed to encode tables: %w", err)
+	}
+	if options.selectors, err = marshalOption(target.ColumnSelectors, len(target.ColumnSelectors)); err != nil {
+		return targetOptions{}, fmt.Errorf("failed to encode column selectors: %w", err)
+	}
+	if options.synonyms, err = marshalOption(target.ColumnSynonyms, len(target.ColumnSynonyms)); err != nil {
+		return targetOptions{}, fmt.Errorf("failed to encode column synonyms: %w", err)
+	}
+	if options.steps, err = marshalOption(target.Steps, len(target.Steps)); err != nil {
+		return targetOptions{}, fmt.Errorf("failed to encode fetch steps: %w", err)
+	}
+	if options.headers, err = marshalOption(target.Headers, len(target.Headers)); err != nil {
+		return targetOptions{}, fmt.Errorf("failed to encode headers: %w", err)
+	}
+	if options.regions, err = marshalOption(target.Regions, len(target.Regions)); err != nil {
+		return targetOptions{}, fmt.Errorf("failed to encode regions: %w", err)
+	}
+	if !target.Network.IsZero() {
+		if options.network, err = marshalOption(target.Network, 1); err != nil {
+			return targetOptions{}, fmt.Errorf("failed to encode network options: %w", err)
+		}
+	}
+	if !target.TLS.IsZero() {
+		if options.tls, err = marshalOption(target.TLS, 1); err != nil {
+			return targetOptions{}, fmt.Errorf("failed to encode client certificate: %w", err)
+		}
+	}
+	if !target.Request.IsZero() {
+		if options.request, err = marshalOption(target.Request, 1); err != nil {
+			return targetOptions{}, fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+	if !target.Assertions.IsZero() {
+		if options.assertions, err = marshalOption(target.Assertions, 1); err != nil {
+			return targetOptions{}, fmt.Errorf("failed to encode page assertions: %w", err)
+		}
+	}
+
+	return options, nil
+}
+
+// marshalOption encodes an option as JSON, or as an empty string if it has no elements.
+func marshalOption(value any, length int) (string, error) {
+	if length == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err //nolint:wrapcheck // the caller names the option.
+	}
+
+	return string(data), nil
+}
+
+// scanTarget scans a row selecting targetColumns, the encrypted options are decrypted.
+func (r *Repository) scanTarget(row rowScanner) (models.Target, error) {
+	var (
+		target   models.Target
+		options  targetOptions
+		interval int64
+	)
+	err := row.Scan(
+		&target.Name, &target.URL, &options.tables, &options.selectors, &options.synonyms, &target.IframeSelector,
+		&interval, &target.Disabled, &target.CreatedAt,
+		r.sealed("targets.headers", &options.headers), r.sealed("targets.regions", &options.regions),
+		&options.network, r.sealed("targets.tls", &options.tls), &options.steps,
+		r.sealed("targets.request", &options.request), &options.assertions,
+	)
+	if err != nil {
+		return models.Target{}, fmt.Errorf("failed to scan target: %w", err)
+	}
+	target.Interval = time.Duration(interval) * time.Second
+
+	decode := []struct {
+		data  string
+		value any
+	}{
+		{options.tables, &target.Tables},
+		{options.selectors, &target.ColumnSelectors},
+		{options.synonyms, &target.ColumnSynonyms},
+		{options.headers, &target.Headers},
+		{options.regions, &target.Regions},
+		{options.network, &target.Network},
+		{options.tls, &target.TLS},
+		{options.steps, &target.Steps},
+		{options.request, &target.Request},
+		{options.assertions, &target.Assertions},
+	}
+	for _, option := range decode {
+		if option.data == "" {
+			continue
+		}
+		if err = json.Unmarshal([]byte(option.data), option.value); err != nil {
+			return models.Target{}, fmt.Errorf("failed to decode options of %s: %w", target.Name, err)
+		}
+	}
+
+	return target, nil
+}
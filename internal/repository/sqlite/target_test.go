@@ -0,0 +1,236 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_Targets(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	createdAt := time.Now().UTC().Truncate(time.Second)
+
+	target := models.Target{
+		Name:            "outlet",
+		URL:             "https://example.com/outlet",
+		Tables:          []models.TargetTable{{Name: "sale", Selector: "table.outlet"}},
+		ColumnSelectors: map[string]string{"image": "td:nth-child(4) img@src"},
+		ColumnSynonyms:  map[string][]string{"price": {"Cost"}},
+		IframeSelector:  "iframe#outlet",
+		Steps:           []models.TargetStep{{Regex: `token=(\w+)`, URL: "/prices?token={{query .}}"}},
+		Request:         models.TargetRequest{Method: "POST", Form: map[string]string{"warehouse": "main"}},
+		Assertions:      models.PageAssertions{Contains: []string{"Outlet"}, MinRows: 10},
+		Headers:         map[string]string{"Accept-Language": "en-GB"},
+		Regions: []models.TargetRegion{
+			{Name: "de", Headers: map[string]string{"Accept-Language": "de-DE,de;q=0.9"}},
+		},
+		Network: models.TargetNetwork{
+			IPVersion: models.IPv4, Hosts: map[string]string{"example.com": "203.0.113.10"}, Resolver: "192.0.2.53",
+		},
+		TLS:       models.TargetTLS{Cert: "/etc/chrono-flow/outlet.crt", Key: "/etc/chrono-flow/outlet.key"},
+		Interval:  30 * time.Minute,
+		CreatedAt: createdAt,
+	}
+	require.NoError(t, repo.CreateTarget(ctx, target))
+	require.NoError(t, repo.CreateTarget(ctx, models.Target{
+		Name: "archive", URL: "https://example.com/archive", Interval: time.Hour, CreatedAt: createdAt,
+	}))
+	require.ErrorIs(t, repo.CreateTarget(ctx, target), repository.ErrTargetExists)
+
+	targets, err := repo.GetTargets(ctx)
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	assert.Equal(t, "archive", targets[0].Name)
+	assert.Nil(t, targets[0].ColumnSelectors)
+	assert.Zero(t, targets[0].Network)
+	assert.Zero(t, targets[0].TLS)
+	assert.Nil(t, targets[0].Steps)
+	assert.Zero(t, targets[0].Request)
+	assert.Zero(t, targets[0].Assertions)
+	assert.True(t, createdAt.Equal(targets[1].CreatedAt))
+	targets[1].CreatedAt = target.CreatedAt
+	assert.Equal(t, target, targets[1])
+
+	// A target is edited in place and keeps its creation time.
+	target.URL = "https://example.com/sale"
+	target.Tables = nil
+	target.Regions = nil
+	target.Network.IPVersion = models.IPv6
+	target.Network.Hosts = nil
+	target.Interval = time.Hour
+	require.NoError(t, repo.UpdateTarget(ctx, target))
+	stored, err := repo.GetTarget(ctx, "outlet")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/sale", stored.URL)
+	assert.Nil(t, stored.Tables)
+	assert.Nil(t, stored.Regions)
+	assert.Equal(t, models.TargetNetwork{IPVersion: models.IPv6, Resolver: "192.0.2.53"}, stored.Network)
+	assert.Equal(t, time.Hour, stored.Interval)
+	assert.True(t, createdAt.Equal(stored.CreatedAt))
+
+	require.ErrorIs(t, repo.UpdateTarget(ctx, models.Target{Name: "missing"}), repository.ErrTargetNotFound)
+	_, err = repo.GetTarget(ctx, "missing")
+	require.ErrorIs(t, err, repository.ErrTargetNotFound)
+
+	// Targets and their data belong to the tenant.
+	tenantTargets, err := repo.ForTenant("acme").GetTargets(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, tenantTargets)
+	assert.Equal(t, "acme/outlet", repo.ForTenant("acme").ForTarget("outlet").Tenant())
+	assert.Equal(t, "acme", repo.ForTenant("acme").ForTarget(models.MainTarget).Tenant())
+}
+
+func TestRepository_Integration_DeleteTarget(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	now := time.Now()
+
+	for _, name := range []string{models.MainTarget, "outlet"} {
+		require.NoError(t, repo.CreateTarget(ctx, models.Target{
+			Name: name, URL: "https://example.com/" + name, Interval: time.Hour, CreatedAt: now,
+		}))
+		require.NoError(t, repo.ForTarget(name).RecordFetch(ctx, models.FetchRecord{FetchedAt: now, Success: true}))
+	}
+	region := repo.ForTarget("outlet" + models.RegionSeparator + "de")
+	require.NoError(t, region.RecordFetch(ctx, models.FetchRecord{FetchedAt: now, Success: true}))
+	outlet := repo.ForTarget("outlet")
+	require.NoError(t, outlet.RecordRun(ctx, models.RunRecord{RunID: "run", CheckedAt: now, PageHash: "hash"}))
+	require.NoError(t, outlet.RecordPageDiff(ctx, models.PageDiff{RunID: "run", CheckedAt: now, Diff: "-a\n+b"}, 10))
+
+	require.ErrorIs(t, repo.DeleteTarget(ctx, models.MainTarget), models.ErrMainTarget)
+	require.NoError(t, repo.DeleteTarget(ctx, "outlet"))
+	require.ErrorIs(t, repo.DeleteTarget(ctx, "outlet"), repository.ErrTargetNotFound)
+
+	targets, err := repo.GetTargets(ctx)
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	assert.Equal(t, models.MainTarget, targets[0].Name)
+
+	fetches, err := repo.ForTarget("outlet").GetFetches(ctx, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, fetches, "the data of the removed target is deleted")
+	fetches, err = region.GetFetches(ctx, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, fetches, "the data of the regions of the removed target is deleted")
+	runs, err := outlet.GetRuns(ctx, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+	diffs, err := outlet.GetPageDiffs(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, diffs)
+	fetches, err = repo.GetFetches(ctx, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, fetches, 1, "the data of the main target is kept")
+}
+
+func TestRepository_Integration_TargetStatuses(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for _, name := range []string{models.MainTarget, "outlet", "sale"} {
+		require.NoError(t, repo.CreateTarget(ctx, models.Target{
+			Name: name, URL: "https://example.com/" + name, Interval: time.Hour, CreatedAt: now,
+		}))
+	}
+	require.NoError(t, repo.RecordFetch(ctx, models.FetchRecord{FetchedAt: now, Success: true}))
+	outlet := repo.ForTarget("outlet")
+	require.NoError(t, outlet.RecordFetch(ctx, models.FetchRecord{FetchedAt: now.Add(-time.Hour), Success: true}))
+	require.NoError(t, outlet.RecordFetch(ctx, models.FetchRecord{
+		FetchedAt: now, Latency: time.Second, Error: "status 503",
+	}))
+	require.NoError(t, outlet.RecordCheckError(ctx, "fetch", "status 503", now))
+
+	require.ErrorIs(t, repo.SetTargetDisabled(ctx, models.MainTarget, true), models.ErrMainTarget)
+	require.ErrorIs(t, repo.SetTargetDisabled(ctx, "missing", true), repository.ErrTargetNotFound)
+	require.NoError(t, repo.SetTargetDisabled(ctx, "outlet", true))
+
+	// Editing a target doesn't resume it.
+	require.NoError(t, repo.UpdateTarget(ctx, models.Target{
+		Name: "outlet", URL: "https://example.com/outlet", Interval: 2 * time.Hour,
+	}))
+
+	statuses, err := repo.GetTargetStatuses(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	assert.Equal(t, models.MainTarget, statuses[0].Name)
+	assert.True(t, statuses[0].LastCheck.Success)
+	assert.Equal(t, "outlet", statuses[1].Name)
+	assert.True(t, statuses[1].Disabled)
+	assert.True(t, now.Equal(statuses[1].LastCheck.FetchedAt))
+	assert.False(t, statuses[1].LastCheck.Success)
+	assert.Equal(t, "status 503", statuses[1].LastCheck.Error)
+	assert.Equal(t, time.Second, statuses[1].LastCheck.Latency)
+	assert.Nil(t, statuses[0].LastError)
+	require.NotNil(t, statuses[1].LastError)
+	assert.Equal(t, "status 503", statuses[1].LastError.Message)
+	assert.False(t, statuses[2].Disabled)
+	assert.True(t, statuses[2].LastCheck.FetchedAt.IsZero(), "sale wasn't checked yet")
+
+	require.NoError(t, repo.SetTargetDisabled(ctx, "outlet", false))
+	stored, err := repo.GetTarget(ctx, "outlet")
+	require.NoError(t, err)
+	assert.False(t, stored.Disabled)
+}
+
+func TestRepository_Integration_DeleteTenantTargets(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+
+	require.NoError(t, repo.CreateTenant(ctx, models.Tenant{
+		ID: "acme", URL: "https://acme.example.com", Token: "token", CreatedAt: time.Now(),
+	}))
+	tenant := repo.ForTenant("acme")
+	require.NoError(t, tenant.CreateTarget(ctx, models.Target{
+		Name: "outlet", URL: "https://acme.example.com/outlet", Interval: time.Hour, CreatedAt: time.Now(),
+	}))
+	require.NoError(t, tenant.ForTarget("outlet").SubscribeChat(ctx, 1))
+
+	require.NoError(t, repo.DeleteTenant(ctx, "acme"))
+
+	targets, err := tenant.GetTargets(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, targets)
+	chats, err := tenant.ForTarget("outlet").GetSubscribedChats(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, chats)
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock)
+// =============================================================================
+
+func TestRepository_Unit_Targets(t *testing.T) {
+	t.Run("GetTargets fails on query error", func(t *testing.T) {
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("SELECT name, url, tables").WithArgs("").WillReturnError(assert.AnError)
+
+		_, err := repo.GetTargets(t.Context())
+
+		require.ErrorIs(t, err, assert.AnError)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteTarget rolls back on data deletion error", func(t *testing.T) {
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM targets").WithArgs("", "outlet").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM page_state").WithArgs("/outlet", "/outlet@%").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		err := repo.DeleteTarget(t.Context(), "outlet")
+
+		require.ErrorIs(t, err, assert.AnError)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
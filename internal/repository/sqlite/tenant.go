@@ -0,0 +1,168 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+)
+
+// tenantTables are the tables holding data scoped by tenant.
+func tenantTables() []string {
+	return []string{
+		"page_state", "products", "subscriptions", "allowed_chats", "chat_settings", "fetches", "changes", "targets",
+		"subscription_events", "chat_activity", "notification_products", "wishlists", "wishlist_items",
+		"heartbeats", "outbox", "runs", "users", "notification_variants", "notification_feedback", "alert_rules",
+		"diff_cache", "unreachable_chats", "page_snapshots", "page_diffs", "run_artifacts", "check_errors",
+	}
+}
+
+// CreateTenant stores a new tenant.
+func (r *Repository) CreateTenant(ctx context.Context, tenant models.Tenant) (err error) {
+	const op = "repository.sqlite.CreateTenant"
+	ctx, done := r.observe(ctx, "CreateTenant")
+	defer func() { err = done(err) }()
+
+	res, err := r.db.ExecContext(
+		ctx,
+		`INSERT OR IGNORE INTO tenants
+		(id, name, target_url, bot_token, allowed_ids, admin_ids, maintenance_windows, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		tenant.ID,
+		tenant.Name,
+		tenant.URL,
+		tenant.Token,
+		joinIDs(tenant.AllowedIDs),
+		joinIDs(tenant.AdminIDs),
+		tenant.MaintenanceWindows,
+		tenant.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w: %s", op, repository.ErrTenantExists, tenant.ID)
+	}
+
+	return nil
+}
+
+// GetTenants returns all stored tenants ordered by ID.
+func (r *Repository) GetTenants(ctx context.Context) (_ []models.Tenant, err error) {
+	const opn = "repository.sqlite.GetTenants"
+	ctx, done := r.observe(ctx, "GetTenants")
+	defer func() { err = done(err) }()
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, name, target_url, bot_token, allowed_ids, admin_ids, maintenance_windows, created_at
+		FROM tenants ORDER BY id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+	defer rows.Close()
+
+	var tenants []models.Tenant
+	for rows.Next() {
+		var (
+			tenant            models.Tenant
+			allowed, adminIDs string
+		)
+		err = rows.Scan(&tenant.ID, &tenant.Name, &tenant.URL, &tenant.Token, &allowed, &adminIDs,
+			&tenant.MaintenanceWindows, &tenant.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan tenant: %w", opn, err)
+		}
+		if tenant.AllowedIDs, err = splitIDs(allowed); err != nil {
+			return nil, fmt.Errorf("%s: invalid allowed IDs of tenant %s: %w", opn, tenant.ID, err)
+		}
+		if tenant.AdminIDs, err = splitIDs(adminIDs); err != nil {
+			return nil, fmt.Errorf("%s: invalid admin IDs of tenant %s: %w", opn, tenant.ID, err)
+		}
+		tenants = append(tenants, tenant)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	return tenants, nil
+}
+
+// DeleteTenant removes a tenant and the data of every table scoped by it in a single transaction.
+func (r *Repository) DeleteTenant(ctx context.Context, id string) (err error) {
+	const opn = "repository.sqlite.DeleteTenant"
+	ctx, done := r.observe(ctx, "DeleteTenant")
+	defer func() { err = done(err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil) //nolint:varnamelen // tx its a default naming for transaction
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", opn, err)
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after a successful commit only returns sql.ErrTxDone.
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM tenants WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("%s: failed to delete tenant: %w", opn, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", opn, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w: %s", opn, repository.ErrTenantNotFound, id)
+	}
+
+	for _, table := range tenantTables() {
+		// Table names come from a fixed list, only the tenant ID is user input.
+		// The data of the tenant's targets is scoped by "<tenant>/<target>".
+		query := "DELETE FROM " + table + " WHERE tenant_id = ? OR tenant_id LIKE ?"
+		if _, err = tx.ExecContext(ctx, query, id, id+"/%"); err != nil {
+			return fmt.Errorf("%s: failed to delete %s: %w", opn, table, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", opn, err)
+	}
+
+	return nil
+}
+
+// joinIDs stores chat IDs as a comma separated list.
+func joinIDs(ids []int64) string {
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatInt(id, 10))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// splitIDs parses chat IDs stored with joinIDs.
+func splitIDs(raw string) ([]int64, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(raw, ",")
+	ids := make([]int64, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse chat ID %q: %w", part, err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
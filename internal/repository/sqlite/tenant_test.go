@@ -0,0 +1,137 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_Tenants(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	createdAt := time.Now().UTC().Truncate(time.Second)
+
+	tenant := models.Tenant{
+		ID:         "acme",
+		Name:       "Acme",
+		URL:        "https://acme.example.com/catalog",
+		Token:      "token",
+		AllowedIDs: []int64{1, -100},
+		AdminIDs:   []int64{1},
+		// The windows are stored as given, they're validated when the tenant is created.
+		MaintenanceWindows: "0 2 * * * 1h",
+		CreatedAt:          createdAt,
+	}
+	require.NoError(t, repo.CreateTenant(ctx, tenant))
+	require.ErrorIs(t, repo.CreateTenant(ctx, tenant), repository.ErrTenantExists)
+
+	tenants, err := repo.GetTenants(ctx)
+	require.NoError(t, err)
+	require.Len(t, tenants, 1)
+	assert.True(t, createdAt.Equal(tenants[0].CreatedAt))
+	tenants[0].CreatedAt = createdAt
+	assert.Equal(t, tenant, tenants[0])
+
+	// The data of a tenant is invisible to the default tenant and the other way round.
+	scoped := repo.ForTenant("acme")
+	assert.Equal(t, "acme", scoped.Tenant())
+	require.NoError(t, repo.SubscribeChat(ctx, 10))
+	require.NoError(t, scoped.SubscribeChat(ctx, 20))
+	require.NoError(t, scoped.AllowChat(ctx, 20))
+	require.NoError(t, scoped.SetFilterGroup(ctx, 20, "warehouse"))
+	products := []models.Product{{Model: "A1"}}
+	require.NoError(t, repo.UpdateState(ctx, &models.State{PageHash: "default", Products: products}))
+	require.NoError(t, scoped.UpdateState(ctx, &models.State{PageHash: "acme", Products: products}))
+	require.NoError(t, scoped.SetAlertRule(ctx, models.AlertRule{ChatID: 20, Name: "cheap", Expression: "true"}))
+
+	chats, err := repo.GetSubscribedChats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{10}, chats)
+	chats, err = scoped.GetSubscribedChats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{20}, chats)
+
+	allowed, err := repo.GetAllowedChats(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, allowed)
+
+	state, err := repo.GetState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "default", state.PageHash)
+	state, err = scoped.GetState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", state.PageHash)
+
+	// Deleting a tenant removes its data and leaves the default tenant intact.
+	require.NoError(t, repo.DeleteTenant(ctx, "acme"))
+	require.ErrorIs(t, repo.DeleteTenant(ctx, "acme"), repository.ErrTenantNotFound)
+
+	tenants, err = repo.GetTenants(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, tenants)
+
+	_, err = scoped.GetState(ctx)
+	require.ErrorIs(t, err, repository.ErrStateNotFound)
+	settings, err := scoped.GetChatSettings(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, settings)
+	rules, err := scoped.GetAlertRules(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	chats, err = repo.GetSubscribedChats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{10}, chats)
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock)
+// =============================================================================
+
+func TestRepository_Unit_Tenants(t *testing.T) {
+	t.Run("CreateTenant fails on exec error", func(t *testing.T) {
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT OR IGNORE INTO tenants").WillReturnError(assert.AnError)
+
+		err := repo.CreateTenant(t.Context(), models.Tenant{ID: "acme"})
+
+		require.ErrorIs(t, err, assert.AnError)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetTenants fails on invalid stored chat IDs", func(t *testing.T) {
+		repo, mock := newMockedRepo(t)
+		columns := []string{
+			"id", "name", "target_url", "bot_token", "allowed_ids", "admin_ids", "maintenance_windows", "created_at",
+		}
+		rows := sqlmock.NewRows(columns).AddRow("acme", "", "https://example.com", "token", "1,x", "", "", time.Now())
+		mock.ExpectQuery("SELECT id, name, target_url").WillReturnRows(rows)
+
+		_, err := repo.GetTenants(t.Context())
+
+		require.ErrorContains(t, err, "invalid allowed IDs of tenant acme")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteTenant rolls back when the tenant data can't be deleted", func(t *testing.T) {
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM tenants").WithArgs("acme").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM page_state").WithArgs("acme", "acme/%").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		err := repo.DeleteTenant(t.Context(), "acme")
+
+		require.ErrorIs(t, err, assert.AnError)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,142 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+)
+
+// tokenColumns lists the columns of the api_tokens table in the order scanToken reads them.
+const tokenColumns = "id, name, scopes, created_at, revoked_at, chat_id"
+
+// rowScanner is implemented by both sql.Row and sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// CreateToken stores an API token by the hash of its secret and returns the token ID.
+func (r *Repository) CreateToken(ctx context.Context, token models.APIToken, hash string) (_ int64, err error) {
+	const op = "repository.sqlite.CreateToken"
+	ctx, done := r.observe(ctx, "CreateToken")
+	defer func() { err = done(err) }()
+
+	res, err := r.db.ExecContext(
+		ctx,
+		"INSERT INTO api_tokens (name, token_hash, scopes, created_at, chat_id) VALUES (?, ?, ?, ?, ?)",
+		token.Name,
+		hash,
+		strings.Join(token.Scopes, ","),
+		token.CreatedAt.UTC(),
+		token.ChatID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get token ID: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// GetTokenByHash returns the active token with the given hash.
+func (r *Repository) GetTokenByHash(ctx context.Context, hash string) (_ *models.APIToken, err error) {
+	const op = "repository.sqlite.GetTokenByHash"
+	ctx, done := r.observe(ctx, "GetTokenByHash")
+	defer func() { err = done(err) }()
+
+	token, err := scanToken(r.db.QueryRowContext(
+		ctx,
+		"SELECT "+tokenColumns+" FROM api_tokens WHERE token_hash = ? AND revoked_at IS NULL",
+		hash,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &token, nil
+}
+
+// GetTokens returns all tokens including the revoked ones ordered by ID.
+func (r *Repository) GetTokens(ctx context.Context) (_ []models.APIToken, err error) {
+	const opn = "repository.sqlite.GetTokens"
+	ctx, done := r.observe(ctx, "GetTokens")
+	defer func() { err = done(err) }()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+tokenColumns+" FROM api_tokens ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+	defer rows.Close()
+
+	var tokens []models.APIToken
+	for rows.Next() {
+		token, scanErr := scanToken(rows)
+		if scanErr != nil {
+			return nil, fmt.Errorf("%s: %w", opn, scanErr)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	return tokens, nil
+}
+
+// RevokeToken marks an active token as revoked. Revoked tokens are kept to show when they were revoked.
+func (r *Repository) RevokeToken(ctx context.Context, id int64, revokedAt time.Time) (err error) {
+	const op = "repository.sqlite.RevokeToken"
+	ctx, done := r.observe(ctx, "RevokeToken")
+	defer func() { err = done(err) }()
+
+	res, err := r.db.ExecContext(
+		ctx,
+		"UPDATE api_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL",
+		revokedAt.UTC(),
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w: %d", op, repository.ErrTokenNotFound, id)
+	}
+
+	return nil
+}
+
+// scanToken scans a row selecting tokenColumns.
+func scanToken(row rowScanner) (models.APIToken, error) {
+	var (
+		token     models.APIToken
+		scopes    string
+		revokedAt sql.NullTime
+	)
+	if err := row.Scan(&token.ID, &token.Name, &scopes, &token.CreatedAt, &revokedAt, &token.ChatID); err != nil {
+		return models.APIToken{}, fmt.Errorf("failed to scan token: %w", err)
+	}
+	if scopes != "" {
+		token.Scopes = strings.Split(scopes, ",")
+	}
+	token.RevokedAt = revokedAt.Time
+
+	return token, nil
+}
@@ -0,0 +1,85 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_Tokens(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	createdAt := time.Now().UTC().Truncate(time.Second)
+
+	id, err := repo.CreateToken(ctx, models.APIToken{
+		Name:      "dashboard",
+		Scopes:    []string{"read:products", "read:changes"},
+		CreatedAt: createdAt,
+		ChatID:    42,
+	}, "hash-1")
+	require.NoError(t, err)
+
+	token, err := repo.GetTokenByHash(ctx, "hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, id, token.ID)
+	assert.Equal(t, "dashboard", token.Name)
+	assert.Equal(t, []string{"read:products", "read:changes"}, token.Scopes)
+	assert.Equal(t, int64(42), token.ChatID)
+	assert.False(t, token.Revoked())
+
+	_, err = repo.GetTokenByHash(ctx, "hash-2")
+	require.ErrorIs(t, err, repository.ErrTokenNotFound)
+
+	// The same secret can't be stored twice.
+	_, err = repo.CreateToken(ctx, models.APIToken{Scopes: []string{"admin"}, CreatedAt: createdAt}, "hash-1")
+	require.Error(t, err)
+
+	// A revoked token can't be used anymore but is still listed.
+	revokedAt := createdAt.Add(time.Hour)
+	require.NoError(t, repo.RevokeToken(ctx, id, revokedAt))
+	require.ErrorIs(t, repo.RevokeToken(ctx, id, revokedAt), repository.ErrTokenNotFound)
+
+	_, err = repo.GetTokenByHash(ctx, "hash-1")
+	require.ErrorIs(t, err, repository.ErrTokenNotFound)
+
+	tokens, err := repo.GetTokens(ctx)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.True(t, tokens[0].Revoked())
+	assert.True(t, revokedAt.Equal(tokens[0].RevokedAt))
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock)
+// =============================================================================
+
+func TestRepository_Unit_Tokens(t *testing.T) {
+	t.Run("GetTokenByHash fails on query error", func(t *testing.T) {
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("SELECT id, name, scopes, created_at, revoked_at, chat_id FROM api_tokens").
+			WillReturnError(assert.AnError)
+
+		_, err := repo.GetTokenByHash(t.Context(), "hash")
+
+		require.ErrorIs(t, err, assert.AnError)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RevokeToken fails on exec error", func(t *testing.T) {
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("UPDATE api_tokens SET revoked_at").WillReturnError(assert.AnError)
+
+		err := repo.RevokeToken(t.Context(), 1, time.Now())
+
+		require.ErrorIs(t, err, assert.AnError)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
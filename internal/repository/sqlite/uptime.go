@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// RecordFetch stores the outcome of a fetch of the target page.
+func (r *Repository) RecordFetch(ctx context.Context, record models.FetchRecord) (err error) {
+	const op = "repository.sqlite.RecordFetch"
+	ctx, done := r.observe(ctx, "RecordFetch")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(
+		ctx,
+		"INSERT INTO fetches (tenant_id, fetched_at, latency_ms, success, error) VALUES (?, ?, ?, ?, ?)",
+		r.tenant,
+		record.FetchedAt.UTC(),
+		record.Latency.Milliseconds(),
+		record.Success,
+		record.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetUptimeStats aggregates the fetches made since the given time.
+func (r *Repository) GetUptimeStats(ctx context.Context, since time.Time) (_ *models.UptimeStats, err error) {
+	const opn = "repository.sqlite.GetUptimeStats"
+	ctx, done := r.observe(ctx, "GetUptimeStats")
+	defer func() { err = done(err) }()
+
+	var (
+		stats     models.UptimeStats
+		avgMillis float64
+	)
+	err = r.db.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*), COALESCE(SUM(success), 0), COALESCE(AVG(latency_ms), 0)
+		FROM fetches WHERE tenant_id = ? AND fetched_at >= ?`,
+		r.tenant,
+		since.UTC(),
+	).Scan(&stats.Fetches, &stats.Successful, &avgMillis)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to aggregate fetches: %w", opn, err)
+	}
+	stats.AvgLatency = time.Duration(avgMillis * float64(time.Millisecond))
+
+	err = r.db.QueryRowContext(
+		ctx,
+		"SELECT fetched_at FROM fetches WHERE tenant_id = ? AND success = 1 ORDER BY fetched_at DESC LIMIT 1",
+		r.tenant,
+	).Scan(&stats.LastSuccess)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: failed to get last successful fetch: %w", opn, err)
+	}
+
+	return &stats, nil
+}
+
+// GetFetches returns the fetches of the target page made since the given time, oldest first.
+func (r *Repository) GetFetches(ctx context.Context, since time.Time) (_ []models.FetchRecord, err error) {
+	const opn = "repository.sqlite.GetFetches"
+	ctx, done := r.observe(ctx, "GetFetches")
+	defer func() { err = done(err) }()
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT fetched_at, latency_ms, success, error FROM fetches
+		WHERE tenant_id = ? AND fetched_at >= ? ORDER BY fetched_at, id`,
+		r.tenant,
+		since.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get fetches: %w", opn, err)
+	}
+	defer rows.Close()
+
+	var records []models.FetchRecord
+	for rows.Next() {
+		var (
+			record    models.FetchRecord
+			latencyMs int64
+		)
+		if err = rows.Scan(&record.FetchedAt, &latencyMs, &record.Success, &record.Error); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan fetch: %w", opn, err)
+		}
+		record.Latency = time.Duration(latencyMs) * time.Millisecond
+		records = append(records, record)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	return records, nil
+}
+
+// PruneHistory deletes fetch, run, change, notification, diff artifact and delivered outbox records of all
+// tenants older than the given time and returns how many were deleted.
+func (r *Repository) PruneHistory(ctx context.Context, before time.Time) (_ int64, err error) {
+	const op = "repository.sqlite.PruneHistory"
+	ctx, done := r.observe(ctx, "PruneHistory")
+	defer func() { err = done(err) }()
+
+	var deleted int64
+	for _, query := range []string{
+		"DELETE FROM fetches WHERE fetched_at < ?",
+		"DELETE FROM runs WHERE checked_at < ?",
+		"DELETE FROM changes WHERE detected_at < ?",
+		"DELETE FROM notification_products WHERE sent_at < ?",
+		"DELETE FROM notification_variants WHERE sent_at < ?",
+		"DELETE FROM notification_feedback WHERE voted_at < ?",
+		"DELETE FROM outbox WHERE delivered_at < ?",
+		"DELETE FROM run_artifacts WHERE created_at < ?",
+	} {
+		res, err := r.db.ExecContext(ctx, query, before.UTC())
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+		}
+		deleted += affected
+	}
+
+	return deleted, nil
+}
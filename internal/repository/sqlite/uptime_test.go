@@ -0,0 +1,131 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_Uptime(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	stats, err := repo.GetUptimeStats(ctx, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, stats.Fetches)
+	assert.True(t, stats.LastSuccess.IsZero())
+
+	records := []models.FetchRecord{
+		{FetchedAt: now.Add(-48 * time.Hour), Latency: 5 * time.Second, Success: true},
+		{FetchedAt: now.Add(-30 * time.Minute), Latency: 800 * time.Millisecond, Success: true},
+		{FetchedAt: now.Add(-20 * time.Minute), Latency: 900 * time.Millisecond, Success: true},
+		{FetchedAt: now.Add(-10 * time.Minute), Latency: 1300 * time.Millisecond, Error: "timeout"},
+	}
+	for _, record := range records {
+		require.NoError(t, repo.RecordFetch(ctx, record))
+	}
+
+	stats, err = repo.GetUptimeStats(ctx, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Fetches)
+	assert.Equal(t, 2, stats.Successful)
+	assert.Equal(t, time.Second, stats.AvgLatency)
+	assert.True(t, now.Add(-20*time.Minute).Equal(stats.LastSuccess))
+
+	fetches, err := repo.GetFetches(ctx, now.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, fetches, 3)
+	assert.Equal(t, 800*time.Millisecond, fetches[0].Latency)
+	assert.False(t, fetches[2].Success)
+	assert.Equal(t, "timeout", fetches[2].Error)
+
+	deleted, err := repo.PruneHistory(ctx, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	stats, err = repo.GetUptimeStats(ctx, now.Add(-72*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Fetches)
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestRecordFetch(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: exec query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT INTO fetches").WillReturnError(assert.AnError)
+
+		// Act
+		err := repo.RecordFetch(ctx, models.FetchRecord{FetchedAt: time.Now(), Success: true})
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.RecordFetch")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetUptimeStats(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: cannot aggregate fetches", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("SELECT COUNT").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.GetUptimeStats(ctx, time.Now())
+
+		// Assert
+		require.ErrorContains(t, err, "failed to aggregate fetches")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: cannot get last successful fetch", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count", "sum", "avg"}).AddRow(1, 1, 10))
+		mock.ExpectQuery("SELECT fetched_at FROM fetches").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.GetUptimeStats(ctx, time.Now())
+
+		// Assert
+		require.ErrorContains(t, err, "failed to get last successful fetch")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPruneHistory(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: exec query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("DELETE FROM fetches").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.PruneHistory(ctx, time.Now())
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.PruneHistory")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,96 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// SetUserSubscription stores the categories of the changes the user gets as direct messages, replacing
+// the subscription the user made in any group before.
+func (r *Repository) SetUserSubscription(ctx context.Context, subscription models.UserSubscription) (err error) {
+	const op = "repository.sqlite.SetUserSubscription"
+	ctx, done := r.observe(ctx, "SetUserSubscription")
+	defer func() { err = done(err) }()
+
+	names := make([]string, 0, len(subscription.Categories))
+	for _, category := range subscription.Categories {
+		names = append(names, string(category))
+	}
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO users (tenant_id, user_id, chat_id, categories) VALUES (?, ?, ?, ?)
+		ON CONFLICT(tenant_id, user_id) DO UPDATE SET chat_id = excluded.chat_id, categories = excluded.categories`,
+		r.tenant,
+		subscription.UserID,
+		subscription.ChatID,
+		strings.Join(names, ","),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteUserSubscription stops the direct messages of the user. It returns false if there were none.
+func (r *Repository) DeleteUserSubscription(ctx context.Context, userID int64) (_ bool, err error) {
+	const opn = "repository.sqlite.DeleteUserSubscription"
+	ctx, done := r.observe(ctx, "DeleteUserSubscription")
+	defer func() { err = done(err) }()
+
+	res, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE tenant_id = ? AND user_id = ?", r.tenant, userID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", opn, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to get affected rows: %w", opn, err)
+	}
+
+	return deleted > 0, nil
+}
+
+// GetUserSubscriptions returns the users getting direct messages, ordered by user ID.
+func (r *Repository) GetUserSubscriptions(ctx context.Context) (_ []models.UserSubscription, err error) {
+	const opn = "repository.sqlite.GetUserSubscriptions"
+	ctx, done := r.observe(ctx, "GetUserSubscriptions")
+	defer func() { err = done(err) }()
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT user_id, chat_id, categories FROM users WHERE tenant_id = ? ORDER BY user_id",
+		r.tenant,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+	defer rows.Close()
+
+	var subscriptions []models.UserSubscription
+	for rows.Next() {
+		var (
+			subscription models.UserSubscription
+			categories   string
+		)
+		if err = rows.Scan(&subscription.UserID, &subscription.ChatID, &categories); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan user: %w", opn, err)
+		}
+		if categories != "" {
+			for _, name := range strings.Split(categories, ",") {
+				subscription.Categories = append(subscription.Categories, models.ChangeCategory(name))
+			}
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	return subscriptions, nil
+}
@@ -0,0 +1,147 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_UserSubscriptions(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+
+	require.NoError(t, repo.SetUserSubscription(ctx, models.UserSubscription{
+		UserID: 7, ChatID: -100, Categories: []models.ChangeCategory{models.CategoryPriceDrop},
+	}))
+	require.NoError(t, repo.SetUserSubscription(ctx, models.UserSubscription{UserID: 3, ChatID: -100}))
+	require.NoError(t, repo.ForTenant("acme").SetUserSubscription(ctx, models.UserSubscription{UserID: 7, ChatID: -1}))
+
+	t.Run("subscriptions of the tenant", func(t *testing.T) {
+		subscriptions, err := repo.GetUserSubscriptions(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.UserSubscription{
+			{UserID: 3, ChatID: -100},
+			{UserID: 7, ChatID: -100, Categories: []models.ChangeCategory{models.CategoryPriceDrop}},
+		}, subscriptions)
+	})
+
+	t.Run("a new subscription replaces the old one", func(t *testing.T) {
+		categories := []models.ChangeCategory{models.CategoryAdded, models.CategoryPriceRise}
+		require.NoError(t, repo.SetUserSubscription(ctx, models.UserSubscription{
+			UserID: 7, ChatID: -200, Categories: categories,
+		}))
+
+		subscriptions, err := repo.GetUserSubscriptions(ctx)
+
+		require.NoError(t, err)
+		require.Len(t, subscriptions, 2)
+		assert.Equal(t, models.UserSubscription{UserID: 7, ChatID: -200, Categories: categories}, subscriptions[1])
+	})
+
+	t.Run("subscription is deleted", func(t *testing.T) {
+		deleted, err := repo.DeleteUserSubscription(ctx, 7)
+		require.NoError(t, err)
+		assert.True(t, deleted)
+
+		deleted, err = repo.DeleteUserSubscription(ctx, 7)
+		require.NoError(t, err)
+		assert.False(t, deleted)
+
+		acme, err := repo.ForTenant("acme").GetUserSubscriptions(ctx)
+		require.NoError(t, err)
+		assert.Len(t, acme, 1, "the subscriptions of other tenants are kept")
+	})
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestSetUserSubscription(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: insert", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("INSERT INTO users").WithArgs("", int64(7), int64(-100), "added,price_drop").
+			WillReturnError(assert.AnError)
+
+		// Act
+		categories := []models.ChangeCategory{models.CategoryAdded, models.CategoryPriceDrop}
+		err := repo.SetUserSubscription(ctx, models.UserSubscription{UserID: 7, ChatID: -100, Categories: categories})
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.SetUserSubscription")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDeleteUserSubscription(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: delete", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("DELETE FROM users").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.DeleteUserSubscription(ctx, 7)
+
+		// Assert
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: affected rows", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectExec("DELETE FROM users").WillReturnResult(sqlmock.NewErrorResult(assert.AnError))
+
+		// Act
+		_, err := repo.DeleteUserSubscription(ctx, 7)
+
+		// Assert
+		require.ErrorContains(t, err, "failed to get affected rows")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetUserSubscriptions(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("FROM users").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.GetUserSubscriptions(ctx)
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.GetUserSubscriptions")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: scan", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("FROM users").WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(7))
+
+		// Act
+		_, err := repo.GetUserSubscriptions(ctx)
+
+		// Assert
+		require.ErrorContains(t, err, "failed to scan user")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,258 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// AddWishlistItem adds the product model to the wishlist of the chat, a model already on it is kept.
+func (r *Repository) AddWishlistItem(ctx context.Context, chatID int64, model string) (err error) {
+	const opn = "repository.sqlite.AddWishlistItem"
+	ctx, done := r.observe(ctx, "AddWishlistItem")
+	defer func() { err = done(err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil) //nolint:varnamelen // tx its a default naming for transaction
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", opn, err)
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after a successful commit only returns sql.ErrTxDone.
+
+	_, err = tx.ExecContext(ctx, "INSERT OR IGNORE INTO wishlists (tenant_id, chat_id) VALUES (?, ?)", r.tenant, chatID)
+	if err != nil {
+		return fmt.Errorf("%s: failed to create wishlist: %w", opn, err)
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT OR IGNORE INTO wishlist_items (tenant_id, chat_id, model) VALUES (?, ?, ?)",
+		r.tenant,
+		chatID,
+		model,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: failed to add item: %w", opn, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", opn, err)
+	}
+
+	return nil
+}
+
+// RemoveWishlistItem removes the product model from the wishlist of the chat. It returns false if the
+// model wasn't on the wishlist.
+func (r *Repository) RemoveWishlistItem(ctx context.Context, chatID int64, model string) (_ bool, err error) {
+	const opn = "repository.sqlite.RemoveWishlistItem"
+	ctx, done := r.observe(ctx, "RemoveWishlistItem")
+	defer func() { err = done(err) }()
+
+	res, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM wishlist_items WHERE tenant_id = ? AND chat_id = ? AND model = ?",
+		r.tenant,
+		chatID,
+		model,
+	)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", opn, err)
+	}
+
+	removed, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to get affected rows: %w", opn, err)
+	}
+
+	return removed > 0, nil
+}
+
+// SetWishlistBudget stores the budget of the wishlist of the chat. The chat is told about a total within
+// the new budget even if it was told about the old one.
+func (r *Repository) SetWishlistBudget(ctx context.Context, chatID int64, budget float64) (err error) {
+	const opn = "repository.sqlite.SetWishlistBudget"
+	ctx, done := r.observe(ctx, "SetWishlistBudget")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO wishlists (tenant_id, chat_id, budget) VALUES (?, ?, ?)
+		ON CONFLICT(tenant_id, chat_id) DO UPDATE SET budget = excluded.budget, notified = 0`,
+		r.tenant,
+		chatID,
+		budget,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opn, err)
+	}
+
+	return nil
+}
+
+// SetWishlistNotified stores whether the chat was told that its wishlist is within the budget.
+func (r *Repository) SetWishlistNotified(ctx context.Context, chatID int64, notified bool) (err error) {
+	const opn = "repository.sqlite.SetWishlistNotified"
+	ctx, done := r.observe(ctx, "SetWishlistNotified")
+	defer func() { err = done(err) }()
+
+	_, err = r.db.ExecContext(
+		ctx,
+		"UPDATE wishlists SET notified = ? WHERE tenant_id = ? AND chat_id = ?",
+		notified,
+		r.tenant,
+		chatID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opn, err)
+	}
+
+	return nil
+}
+
+// ClearWishlist removes the wishlist of the chat with its budget.
+func (r *Repository) ClearWishlist(ctx context.Context, chatID int64) (err error) {
+	const opn = "repository.sqlite.ClearWishlist"
+	ctx, done := r.observe(ctx, "ClearWishlist")
+	defer func() { err = done(err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil) //nolint:varnamelen // tx its a default naming for transaction
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", opn, err)
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after a successful commit only returns sql.ErrTxDone.
+
+	for _, table := range []string{"wishlist_items", "wishlists"} {
+		_, err = tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE tenant_id = ? AND chat_id = ?", r.tenant, chatID)
+		if err != nil {
+			return fmt.Errorf("%s: failed to delete from %s: %w", opn, table, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", opn, err)
+	}
+
+	return nil
+}
+
+// GetWishlist returns the wishlist of the chat with the current prices of its items. A chat without
+// a wishlist gets an empty one.
+func (r *Repository) GetWishlist(ctx context.Context, chatID int64) (_ *models.Wishlist, err error) {
+	const opn = "repository.sqlite.GetWishlist"
+	ctx, done := r.observe(ctx, "GetWishlist")
+	defer func() { err = done(err) }()
+
+	wishlists, err := r.queryWishlists(ctx, "AND w.chat_id = ?", chatID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+	if len(wishlists) == 0 {
+		return &models.Wishlist{ChatID: chatID}, nil
+	}
+
+	return &wishlists[0], nil
+}
+
+// GetWishlists returns the wishlists of all chats with the current prices of their items.
+func (r *Repository) GetWishlists(ctx context.Context) (_ []models.Wishlist, err error) {
+	const opn = "repository.sqlite.GetWishlists"
+	ctx, done := r.observe(ctx, "GetWishlists")
+	defer func() { err = done(err) }()
+
+	wishlists, err := r.queryWishlists(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+
+	return wishlists, nil
+}
+
+// queryWishlists returns the wishlists of the tenant matching the extra condition, ordered by chat. The
+// price of an item is the lowest price of the current products with its model in any category.
+func (r *Repository) queryWishlists(ctx context.Context, condition string, args ...any) ([]models.Wishlist, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT w.chat_id, w.budget, w.notified, i.model, COUNT(p.model), MIN(p.price_value)
+		FROM wishlists w
+		LEFT JOIN wishlist_items i ON i.tenant_id = w.tenant_id AND i.chat_id = w.chat_id
+		LEFT JOIN products p ON p.tenant_id = w.tenant_id AND i.model = p.model
+		WHERE w.tenant_id = ? `+condition+`
+		GROUP BY w.chat_id, i.model
+		ORDER BY w.chat_id, i.model`,
+		append([]any{r.tenant}, args...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wishlists: %w", err)
+	}
+	defer rows.Close()
+
+	var wishlists []models.Wishlist
+	for rows.Next() {
+		var (
+			wishlist models.Wishlist
+			model    sql.NullString
+			listed   int
+			price    sql.NullFloat64
+		)
+		err = rows.Scan(&wishlist.ChatID, &wishlist.Budget, &wishlist.Notified, &model, &listed, &price)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan wishlist item: %w", err)
+		}
+		if len(wishlists) == 0 || wishlists[len(wishlists)-1].ChatID != wishlist.ChatID {
+			wishlists = append(wishlists, wishlist)
+		}
+		// A wishlist without items has a single row without a model.
+		if !model.Valid {
+			continue
+		}
+		item := models.WishlistItem{Model: model.String, Listed: listed > 0}
+		if price.Valid {
+			item.Price = &price.Float64
+		}
+		last := &wishlists[len(wishlists)-1]
+		last.Items = append(last.Items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return wishlists, nil
+}
+
+// GetWatchCounts returns the number of chats with the model on their wishlist by the lower-case model,
+// models on no wishlist are left out.
+func (r *Repository) GetWatchCounts(ctx context.Context) (_ map[string]int, err error) {
+	const opn = "repository.sqlite.GetWatchCounts"
+	ctx, done := r.observe(ctx, "GetWatchCounts")
+	defer func() { err = done(err) }()
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT LOWER(model), COUNT(*) FROM wishlist_items WHERE tenant_id = ? GROUP BY LOWER(model)",
+		r.tenant,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get watch counts: %w", opn, err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var (
+			model string
+			count int
+		)
+		if err = rows.Scan(&model, &count); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan watch count: %w", opn, err)
+		}
+		counts[model] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows iteration error: %w", opn, err)
+	}
+
+	return counts, nil
+}
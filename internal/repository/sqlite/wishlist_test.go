@@ -0,0 +1,174 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Integration Tests (using a real temporary database)
+// =============================================================================
+
+func TestRepository_Integration_Wishlists(t *testing.T) {
+	repo := newTestDB(t)
+	ctx := t.Context()
+	require.NoError(t, repo.UpdateState(ctx, &models.State{PageHash: "hash", Products: []models.Product{
+		{Model: "A1", Category: "new", Price: "1 200"},
+		{Model: "A1", Category: "used", Price: "900"},
+		{Model: "B2", Category: "new", Price: "on request"},
+	}}))
+	price := func(value float64) *float64 { return &value }
+
+	require.NoError(t, repo.AddWishlistItem(ctx, -100, "a1"))
+	require.NoError(t, repo.AddWishlistItem(ctx, -100, "B2"))
+	require.NoError(t, repo.AddWishlistItem(ctx, -100, "C3"))
+	require.NoError(t, repo.AddWishlistItem(ctx, -100, "A1"), "a model already on the wishlist is kept")
+	require.NoError(t, repo.SetWishlistBudget(ctx, -100, 1000))
+	require.NoError(t, repo.SetWishlistBudget(ctx, -200, 500))
+	require.NoError(t, repo.ForTenant("acme").AddWishlistItem(ctx, -100, "A1"))
+
+	t.Run("items with their lowest current price", func(t *testing.T) {
+		wishlist, err := repo.GetWishlist(ctx, -100)
+
+		require.NoError(t, err)
+		assert.Equal(t, &models.Wishlist{ChatID: -100, Budget: 1000, Items: []models.WishlistItem{
+			{Model: "a1", Listed: true, Price: price(900)},
+			{Model: "B2", Listed: true},
+			{Model: "C3"},
+		}}, wishlist)
+	})
+
+	t.Run("wishlists of all chats of the tenant", func(t *testing.T) {
+		require.NoError(t, repo.SetWishlistNotified(ctx, -200, true))
+
+		wishlists, err := repo.GetWishlists(ctx)
+
+		require.NoError(t, err)
+		require.Len(t, wishlists, 2)
+		assert.Equal(t, int64(-200), wishlists[0].ChatID)
+		assert.Equal(t, models.Wishlist{ChatID: -200, Budget: 500, Notified: true}, wishlists[0])
+		assert.Len(t, wishlists[1].Items, 3)
+	})
+
+	t.Run("chats watching every model", func(t *testing.T) {
+		require.NoError(t, repo.AddWishlistItem(ctx, -200, "A1"))
+
+		counts, err := repo.GetWatchCounts(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"a1": 2, "b2": 1, "c3": 1}, counts)
+	})
+
+	t.Run("a new budget notifies again", func(t *testing.T) {
+		require.NoError(t, repo.SetWishlistBudget(ctx, -200, 400))
+
+		wishlist, err := repo.GetWishlist(ctx, -200)
+
+		require.NoError(t, err)
+		assert.False(t, wishlist.Notified)
+		assert.InDelta(t, 400, wishlist.Budget, 0)
+	})
+
+	t.Run("items are removed", func(t *testing.T) {
+		removed, err := repo.RemoveWishlistItem(ctx, -100, "c3")
+		require.NoError(t, err)
+		assert.True(t, removed)
+
+		removed, err = repo.RemoveWishlistItem(ctx, -100, "C3")
+		require.NoError(t, err)
+		assert.False(t, removed)
+
+		wishlist, err := repo.GetWishlist(ctx, -100)
+		require.NoError(t, err)
+		assert.Len(t, wishlist.Items, 2)
+	})
+
+	t.Run("wishlist is cleared", func(t *testing.T) {
+		require.NoError(t, repo.ClearWishlist(ctx, -100))
+
+		wishlist, err := repo.GetWishlist(ctx, -100)
+
+		require.NoError(t, err)
+		assert.Equal(t, &models.Wishlist{ChatID: -100}, wishlist)
+		acme, err := repo.ForTenant("acme").GetWishlist(ctx, -100)
+		require.NoError(t, err)
+		assert.Len(t, acme.Items, 1, "the wishlists of other tenants are kept")
+	})
+}
+
+// =============================================================================
+// Unit Tests (using sqlmock for failure scenarios)
+// =============================================================================
+
+func TestAddWishlistItem(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: add item", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT OR IGNORE INTO wishlists").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT OR IGNORE INTO wishlist_items").WithArgs("", int64(-100), "A1").
+			WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		// Act
+		err := repo.AddWishlistItem(ctx, -100, "A1")
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.AddWishlistItem: failed to add item")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetWatchCounts(t *testing.T) {
+	t.Run("error: query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("FROM wishlist_items").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.GetWatchCounts(t.Context())
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.GetWatchCounts: failed to get watch counts")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetWishlists(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("error: query", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("FROM wishlists").WillReturnError(assert.AnError)
+
+		// Act
+		_, err := repo.GetWishlists(ctx)
+
+		// Assert
+		require.ErrorContains(t, err, "repository.sqlite.GetWishlists: failed to get wishlists")
+		require.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error: scan", func(t *testing.T) {
+		// Arrange
+		repo, mock := newMockedRepo(t)
+		mock.ExpectQuery("FROM wishlists").WillReturnRows(sqlmock.NewRows([]string{"chat_id"}).AddRow(1))
+
+		// Act
+		_, err := repo.GetWishlists(ctx)
+
+		// Assert
+		require.ErrorContains(t, err, "failed to scan wishlist item")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
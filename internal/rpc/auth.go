@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Houeta/chrono-flow/internal/auth"
+	"github.com/Houeta/chrono-flow/internal/rpc/chronoflowv1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// methodScopes maps every method of the service to the scope it requires.
+func methodScopes() map[string]string {
+	return map[string]string{
+		chronoflowv1.ChronoFlowService_ListProducts_FullMethodName: auth.ScopeReadProducts,
+		chronoflowv1.ChronoFlowService_CheckNow_FullMethodName:     auth.ScopeTriggerCheck,
+		chronoflowv1.ChronoFlowService_Subscribe_FullMethodName:    auth.ScopeReadChanges,
+	}
+}
+
+// ServerOptions returns the options the gRPC server has to be created with to serve the service,
+// they install the token checks if an authenticator is configured.
+func (s *Server) ServerOptions() []grpc.ServerOption {
+	if s.authenticator == nil {
+		return nil
+	}
+
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(s.authorizeUnary),
+		grpc.StreamInterceptor(s.authorizeStream),
+	}
+}
+
+// authorizeUnary rejects unary calls whose token lacks the scope of the method.
+func (s *Server) authorizeUnary(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	if err := s.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// authorizeStream rejects streaming calls whose token lacks the scope of the method.
+func (s *Server) authorizeStream(
+	srv any,
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if err := s.authorize(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+
+	return handler(srv, stream)
+}
+
+// authorize checks the token in the call metadata against the scope of the method. Methods
+// missing from methodScopes are denied, so a new method can't be exposed by accident.
+func (s *Server) authorize(ctx context.Context, method string) error {
+	var header string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			header = values[0]
+		}
+	}
+
+	token, err := s.authenticator.Authenticate(ctx, header)
+	switch {
+	case errors.Is(err, auth.ErrMissingToken), errors.Is(err, auth.ErrInvalidToken):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case err != nil:
+		s.log.ErrorContext(ctx, "failed to authenticate call", "method", method, "error", err)
+		return status.Error(codes.Internal, "failed to authenticate call")
+	}
+
+	scope, ok := methodScopes()[method]
+	if !ok || !token.HasScope(scope) {
+		return status.Errorf(codes.PermissionDenied, "%v: %s", auth.ErrForbidden, scope)
+	}
+
+	return nil
+}
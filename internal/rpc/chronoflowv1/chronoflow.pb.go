@@ -0,0 +1,778 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        v5.29.3
+// source: chronoflow/v1/chronoflow.proto
+
+package chronoflowv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RunEvent_Status int32
+
+const (
+	RunEvent_STATUS_UNSPECIFIED RunEvent_Status = 0
+	RunEvent_STATUS_STARTED     RunEvent_Status = 1
+	RunEvent_STATUS_SUCCEEDED   RunEvent_Status = 2
+	RunEvent_STATUS_FAILED      RunEvent_Status = 3
+)
+
+// Enum value maps for RunEvent_Status.
+var (
+	RunEvent_Status_name = map[int32]string{
+		0: "STATUS_UNSPECIFIED",
+		1: "STATUS_STARTED",
+		2: "STATUS_SUCCEEDED",
+		3: "STATUS_FAILED",
+	}
+	RunEvent_Status_value = map[string]int32{
+		"STATUS_UNSPECIFIED": 0,
+		"STATUS_STARTED":     1,
+		"STATUS_SUCCEEDED":   2,
+		"STATUS_FAILED":      3,
+	}
+)
+
+func (x RunEvent_Status) Enum() *RunEvent_Status {
+	p := new(RunEvent_Status)
+	*p = x
+	return p
+}
+
+func (x RunEvent_Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RunEvent_Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_chronoflow_v1_chronoflow_proto_enumTypes[0].Descriptor()
+}
+
+func (RunEvent_Status) Type() protoreflect.EnumType {
+	return &file_chronoflow_v1_chronoflow_proto_enumTypes[0]
+}
+
+func (x RunEvent_Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RunEvent_Status.Descriptor instead.
+func (RunEvent_Status) EnumDescriptor() ([]byte, []int) {
+	return file_chronoflow_v1_chronoflow_proto_rawDescGZIP(), []int{9, 0}
+}
+
+// Product is a row of a product table on the target page.
+type Product struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Model string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	// Name of the table the product was found in.
+	Category      string `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`
+	Type          string `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Quantity      string `protobuf:"bytes,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Price         string `protobuf:"bytes,5,opt,name=price,proto3" json:"price,omitempty"`
+	ImageUrl      string `protobuf:"bytes,6,opt,name=image_url,json=imageUrl,proto3" json:"image_url,omitempty"`
+	ProductUrl    string `protobuf:"bytes,7,opt,name=product_url,json=productUrl,proto3" json:"product_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Product) Reset() {
+	*x = Product{}
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Product) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Product) ProtoMessage() {}
+
+func (x *Product) ProtoReflect() protoreflect.Message {
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Product.ProtoReflect.Descriptor instead.
+func (*Product) Descriptor() ([]byte, []int) {
+	return file_chronoflow_v1_chronoflow_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Product) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *Product) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *Product) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Product) GetQuantity() string {
+	if x != nil {
+		return x.Quantity
+	}
+	return ""
+}
+
+func (x *Product) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *Product) GetImageUrl() string {
+	if x != nil {
+		return x.ImageUrl
+	}
+	return ""
+}
+
+func (x *Product) GetProductUrl() string {
+	if x != nil {
+		return x.ProductUrl
+	}
+	return ""
+}
+
+// ProductChange holds the state of a product before and after a change.
+type ProductChange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Old           *Product               `protobuf:"bytes,1,opt,name=old,proto3" json:"old,omitempty"`
+	New           *Product               `protobuf:"bytes,2,opt,name=new,proto3" json:"new,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProductChange) Reset() {
+	*x = ProductChange{}
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProductChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProductChange) ProtoMessage() {}
+
+func (x *ProductChange) ProtoReflect() protoreflect.Message {
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProductChange.ProtoReflect.Descriptor instead.
+func (*ProductChange) Descriptor() ([]byte, []int) {
+	return file_chronoflow_v1_chronoflow_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ProductChange) GetOld() *Product {
+	if x != nil {
+		return x.Old
+	}
+	return nil
+}
+
+func (x *ProductChange) GetNew() *Product {
+	if x != nil {
+		return x.New
+	}
+	return nil
+}
+
+// Changes are the differences found by a single check.
+type Changes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Added         []*Product             `protobuf:"bytes,1,rep,name=added,proto3" json:"added,omitempty"`
+	Changed       []*ProductChange       `protobuf:"bytes,2,rep,name=changed,proto3" json:"changed,omitempty"`
+	Renamed       []*ProductChange       `protobuf:"bytes,3,rep,name=renamed,proto3" json:"renamed,omitempty"`
+	Removed       []*Product             `protobuf:"bytes,4,rep,name=removed,proto3" json:"removed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Changes) Reset() {
+	*x = Changes{}
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Changes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Changes) ProtoMessage() {}
+
+func (x *Changes) ProtoReflect() protoreflect.Message {
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Changes.ProtoReflect.Descriptor instead.
+func (*Changes) Descriptor() ([]byte, []int) {
+	return file_chronoflow_v1_chronoflow_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Changes) GetAdded() []*Product {
+	if x != nil {
+		return x.Added
+	}
+	return nil
+}
+
+func (x *Changes) GetChanged() []*ProductChange {
+	if x != nil {
+		return x.Changed
+	}
+	return nil
+}
+
+func (x *Changes) GetRenamed() []*ProductChange {
+	if x != nil {
+		return x.Renamed
+	}
+	return nil
+}
+
+func (x *Changes) GetRemoved() []*Product {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
+type ListProductsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only return products of this category, all categories if empty.
+	Category string `protobuf:"bytes,1,opt,name=category,proto3" json:"category,omitempty"`
+	// Only return products of this type, all types if empty.
+	Type          string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListProductsRequest) Reset() {
+	*x = ListProductsRequest{}
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProductsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProductsRequest) ProtoMessage() {}
+
+func (x *ListProductsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProductsRequest.ProtoReflect.Descriptor instead.
+func (*ListProductsRequest) Descriptor() ([]byte, []int) {
+	return file_chronoflow_v1_chronoflow_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListProductsRequest) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *ListProductsRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+type ListProductsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Products      []*Product             `protobuf:"bytes,1,rep,name=products,proto3" json:"products,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListProductsResponse) Reset() {
+	*x = ListProductsResponse{}
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProductsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProductsResponse) ProtoMessage() {}
+
+func (x *ListProductsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProductsResponse.ProtoReflect.Descriptor instead.
+func (*ListProductsResponse) Descriptor() ([]byte, []int) {
+	return file_chronoflow_v1_chronoflow_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListProductsResponse) GetProducts() []*Product {
+	if x != nil {
+		return x.Products
+	}
+	return nil
+}
+
+type CheckNowRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckNowRequest) Reset() {
+	*x = CheckNowRequest{}
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckNowRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckNowRequest) ProtoMessage() {}
+
+func (x *CheckNowRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckNowRequest.ProtoReflect.Descriptor instead.
+func (*CheckNowRequest) Descriptor() ([]byte, []int) {
+	return file_chronoflow_v1_chronoflow_proto_rawDescGZIP(), []int{5}
+}
+
+type CheckNowResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Changes       *Changes               `protobuf:"bytes,1,opt,name=changes,proto3" json:"changes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckNowResponse) Reset() {
+	*x = CheckNowResponse{}
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckNowResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckNowResponse) ProtoMessage() {}
+
+func (x *CheckNowResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckNowResponse.ProtoReflect.Descriptor instead.
+func (*CheckNowResponse) Descriptor() ([]byte, []int) {
+	return file_chronoflow_v1_chronoflow_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CheckNowResponse) GetChanges() *Changes {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+type SubscribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_chronoflow_v1_chronoflow_proto_rawDescGZIP(), []int{7}
+}
+
+// ChangeEvent is sent for every check that detected changes.
+type ChangeEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Identifier of the check, the same as run_id in the logs and change events.
+	RunId         string                 `protobuf:"bytes,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	DetectedAt    *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=detected_at,json=detectedAt,proto3" json:"detected_at,omitempty"`
+	Changes       *Changes               `protobuf:"bytes,3,opt,name=changes,proto3" json:"changes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangeEvent) Reset() {
+	*x = ChangeEvent{}
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeEvent) ProtoMessage() {}
+
+func (x *ChangeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeEvent.ProtoReflect.Descriptor instead.
+func (*ChangeEvent) Descriptor() ([]byte, []int) {
+	return file_chronoflow_v1_chronoflow_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ChangeEvent) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+func (x *ChangeEvent) GetDetectedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DetectedAt
+	}
+	return nil
+}
+
+func (x *ChangeEvent) GetChanges() *Changes {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+// RunEvent is published when a check starts and when it finishes.
+type RunEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Identifier of the check, the same as run_id in the logs and change events.
+	RunId  string                 `protobuf:"bytes,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	Status RunEvent_Status        `protobuf:"varint,2,opt,name=status,proto3,enum=chronoflow.v1.RunEvent_Status" json:"status,omitempty"`
+	At     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=at,proto3" json:"at,omitempty"`
+	// Number of detected changes, set when the check succeeded.
+	Changes int32 `protobuf:"varint,4,opt,name=changes,proto3" json:"changes,omitempty"`
+	// Failure reason, set when the check failed.
+	Error         string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunEvent) Reset() {
+	*x = RunEvent{}
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunEvent) ProtoMessage() {}
+
+func (x *RunEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_chronoflow_v1_chronoflow_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunEvent.ProtoReflect.Descriptor instead.
+func (*RunEvent) Descriptor() ([]byte, []int) {
+	return file_chronoflow_v1_chronoflow_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RunEvent) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+func (x *RunEvent) GetStatus() RunEvent_Status {
+	if x != nil {
+		return x.Status
+	}
+	return RunEvent_STATUS_UNSPECIFIED
+}
+
+func (x *RunEvent) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *RunEvent) GetChanges() int32 {
+	if x != nil {
+		return x.Changes
+	}
+	return 0
+}
+
+func (x *RunEvent) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_chronoflow_v1_chronoflow_proto protoreflect.FileDescriptor
+
+const file_chronoflow_v1_chronoflow_proto_rawDesc = "" +
+	"\n" +
+	"\x1echronoflow/v1/chronoflow.proto\x12\rchronoflow.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xbf\x01\n" +
+	"\aProduct\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12\x1a\n" +
+	"\bcategory\x18\x02 \x01(\tR\bcategory\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x1a\n" +
+	"\bquantity\x18\x04 \x01(\tR\bquantity\x12\x14\n" +
+	"\x05price\x18\x05 \x01(\tR\x05price\x12\x1b\n" +
+	"\timage_url\x18\x06 \x01(\tR\bimageUrl\x12\x1f\n" +
+	"\vproduct_url\x18\a \x01(\tR\n" +
+	"productUrl\"c\n" +
+	"\rProductChange\x12(\n" +
+	"\x03old\x18\x01 \x01(\v2\x16.chronoflow.v1.ProductR\x03old\x12(\n" +
+	"\x03new\x18\x02 \x01(\v2\x16.chronoflow.v1.ProductR\x03new\"\xd9\x01\n" +
+	"\aChanges\x12,\n" +
+	"\x05added\x18\x01 \x03(\v2\x16.chronoflow.v1.ProductR\x05added\x126\n" +
+	"\achanged\x18\x02 \x03(\v2\x1c.chronoflow.v1.ProductChangeR\achanged\x126\n" +
+	"\arenamed\x18\x03 \x03(\v2\x1c.chronoflow.v1.ProductChangeR\arenamed\x120\n" +
+	"\aremoved\x18\x04 \x03(\v2\x16.chronoflow.v1.ProductR\aremoved\"E\n" +
+	"\x13ListProductsRequest\x12\x1a\n" +
+	"\bcategory\x18\x01 \x01(\tR\bcategory\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\"J\n" +
+	"\x14ListProductsResponse\x122\n" +
+	"\bproducts\x18\x01 \x03(\v2\x16.chronoflow.v1.ProductR\bproducts\"\x11\n" +
+	"\x0fCheckNowRequest\"D\n" +
+	"\x10CheckNowResponse\x120\n" +
+	"\achanges\x18\x01 \x01(\v2\x16.chronoflow.v1.ChangesR\achanges\"\x12\n" +
+	"\x10SubscribeRequest\"\x93\x01\n" +
+	"\vChangeEvent\x12\x15\n" +
+	"\x06run_id\x18\x01 \x01(\tR\x05runId\x12;\n" +
+	"\vdetected_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"detectedAt\x120\n" +
+	"\achanges\x18\x03 \x01(\v2\x16.chronoflow.v1.ChangesR\achanges\"\x94\x02\n" +
+	"\bRunEvent\x12\x15\n" +
+	"\x06run_id\x18\x01 \x01(\tR\x05runId\x126\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x1e.chronoflow.v1.RunEvent.StatusR\x06status\x12*\n" +
+	"\x02at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x18\n" +
+	"\achanges\x18\x04 \x01(\x05R\achanges\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\"]\n" +
+	"\x06Status\x12\x16\n" +
+	"\x12STATUS_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eSTATUS_STARTED\x10\x01\x12\x14\n" +
+	"\x10STATUS_SUCCEEDED\x10\x02\x12\x11\n" +
+	"\rSTATUS_FAILED\x10\x032\x85\x02\n" +
+	"\x11ChronoFlowService\x12W\n" +
+	"\fListProducts\x12\".chronoflow.v1.ListProductsRequest\x1a#.chronoflow.v1.ListProductsResponse\x12K\n" +
+	"\bCheckNow\x12\x1e.chronoflow.v1.CheckNowRequest\x1a\x1f.chronoflow.v1.CheckNowResponse\x12J\n" +
+	"\tSubscribe\x12\x1f.chronoflow.v1.SubscribeRequest\x1a\x1a.chronoflow.v1.ChangeEvent0\x01BFZDgithub.com/Houeta/chrono-flow/internal/rpc/chronoflowv1;chronoflowv1b\x06proto3"
+
+var (
+	file_chronoflow_v1_chronoflow_proto_rawDescOnce sync.Once
+	file_chronoflow_v1_chronoflow_proto_rawDescData []byte
+)
+
+func file_chronoflow_v1_chronoflow_proto_rawDescGZIP() []byte {
+	file_chronoflow_v1_chronoflow_proto_rawDescOnce.Do(func() {
+		file_chronoflow_v1_chronoflow_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_chronoflow_v1_chronoflow_proto_rawDesc), len(file_chronoflow_v1_chronoflow_proto_rawDesc)))
+	})
+	return file_chronoflow_v1_chronoflow_proto_rawDescData
+}
+
+var file_chronoflow_v1_chronoflow_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_chronoflow_v1_chronoflow_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_chronoflow_v1_chronoflow_proto_goTypes = []any{
+	(RunEvent_Status)(0),          // 0: chronoflow.v1.RunEvent.Status
+	(*Product)(nil),               // 1: chronoflow.v1.Product
+	(*ProductChange)(nil),         // 2: chronoflow.v1.ProductChange
+	(*Changes)(nil),               // 3: chronoflow.v1.Changes
+	(*ListProductsRequest)(nil),   // 4: chronoflow.v1.ListProductsRequest
+	(*ListProductsResponse)(nil),  // 5: chronoflow.v1.ListProductsResponse
+	(*CheckNowRequest)(nil),       // 6: chronoflow.v1.CheckNowRequest
+	(*CheckNowResponse)(nil),      // 7: chronoflow.v1.CheckNowResponse
+	(*SubscribeRequest)(nil),      // 8: chronoflow.v1.SubscribeRequest
+	(*ChangeEvent)(nil),           // 9: chronoflow.v1.ChangeEvent
+	(*RunEvent)(nil),              // 10: chronoflow.v1.RunEvent
+	(*timestamppb.Timestamp)(nil), // 11: google.protobuf.Timestamp
+}
+var file_chronoflow_v1_chronoflow_proto_depIdxs = []int32{
+	1,  // 0: chronoflow.v1.ProductChange.old:type_name -> chronoflow.v1.Product
+	1,  // 1: chronoflow.v1.ProductChange.new:type_name -> chronoflow.v1.Product
+	1,  // 2: chronoflow.v1.Changes.added:type_name -> chronoflow.v1.Product
+	2,  // 3: chronoflow.v1.Changes.changed:type_name -> chronoflow.v1.ProductChange
+	2,  // 4: chronoflow.v1.Changes.renamed:type_name -> chronoflow.v1.ProductChange
+	1,  // 5: chronoflow.v1.Changes.removed:type_name -> chronoflow.v1.Product
+	1,  // 6: chronoflow.v1.ListProductsResponse.products:type_name -> chronoflow.v1.Product
+	3,  // 7: chronoflow.v1.CheckNowResponse.changes:type_name -> chronoflow.v1.Changes
+	11, // 8: chronoflow.v1.ChangeEvent.detected_at:type_name -> google.protobuf.Timestamp
+	3,  // 9: chronoflow.v1.ChangeEvent.changes:type_name -> chronoflow.v1.Changes
+	0,  // 10: chronoflow.v1.RunEvent.status:type_name -> chronoflow.v1.RunEvent.Status
+	11, // 11: chronoflow.v1.RunEvent.at:type_name -> google.protobuf.Timestamp
+	4,  // 12: chronoflow.v1.ChronoFlowService.ListProducts:input_type -> chronoflow.v1.ListProductsRequest
+	6,  // 13: chronoflow.v1.ChronoFlowService.CheckNow:input_type -> chronoflow.v1.CheckNowRequest
+	8,  // 14: chronoflow.v1.ChronoFlowService.Subscribe:input_type -> chronoflow.v1.SubscribeRequest
+	5,  // 15: chronoflow.v1.ChronoFlowService.ListProducts:output_type -> chronoflow.v1.ListProductsResponse
+	7,  // 16: chronoflow.v1.ChronoFlowService.CheckNow:output_type -> chronoflow.v1.CheckNowResponse
+	9,  // 17: chronoflow.v1.ChronoFlowService.Subscribe:output_type -> chronoflow.v1.ChangeEvent
+	15, // [15:18] is the sub-list for method output_type
+	12, // [12:15] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_chronoflow_v1_chronoflow_proto_init() }
+func file_chronoflow_v1_chronoflow_proto_init() {
+	if File_chronoflow_v1_chronoflow_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_chronoflow_v1_chronoflow_proto_rawDesc), len(file_chronoflow_v1_chronoflow_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_chronoflow_v1_chronoflow_proto_goTypes,
+		DependencyIndexes: file_chronoflow_v1_chronoflow_proto_depIdxs,
+		EnumInfos:         file_chronoflow_v1_chronoflow_proto_enumTypes,
+		MessageInfos:      file_chronoflow_v1_chronoflow_proto_msgTypes,
+	}.Build()
+	File_chronoflow_v1_chronoflow_proto = out.File
+	file_chronoflow_v1_chronoflow_proto_goTypes = nil
+	file_chronoflow_v1_chronoflow_proto_depIdxs = nil
+}
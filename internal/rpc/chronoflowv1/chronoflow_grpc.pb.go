@@ -0,0 +1,217 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: chronoflow/v1/chronoflow.proto
+
+package chronoflowv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ChronoFlowService_ListProducts_FullMethodName = "/chronoflow.v1.ChronoFlowService/ListProducts"
+	ChronoFlowService_CheckNow_FullMethodName     = "/chronoflow.v1.ChronoFlowService/CheckNow"
+	ChronoFlowService_Subscribe_FullMethodName    = "/chronoflow.v1.ChronoFlowService/Subscribe"
+)
+
+// ChronoFlowServiceClient is the client API for ChronoFlowService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ChronoFlowService lets other services read the monitored products, trigger checks and
+// receive the detected changes as they happen.
+type ChronoFlowServiceClient interface {
+	// ListProducts returns the products found on the target page by the last check.
+	ListProducts(ctx context.Context, in *ListProductsRequest, opts ...grpc.CallOption) (*ListProductsResponse, error)
+	// CheckNow checks the target page immediately instead of waiting for the next scheduled
+	// check and returns the detected changes. Subscribers and chats are notified as usual.
+	CheckNow(ctx context.Context, in *CheckNowRequest, opts ...grpc.CallOption) (*CheckNowResponse, error)
+	// Subscribe streams the changes detected by every following check, scheduled or not.
+	// A subscriber that can't keep up is disconnected with RESOURCE_EXHAUSTED.
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChangeEvent], error)
+}
+
+type chronoFlowServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewChronoFlowServiceClient(cc grpc.ClientConnInterface) ChronoFlowServiceClient {
+	return &chronoFlowServiceClient{cc}
+}
+
+func (c *chronoFlowServiceClient) ListProducts(ctx context.Context, in *ListProductsRequest, opts ...grpc.CallOption) (*ListProductsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListProductsResponse)
+	err := c.cc.Invoke(ctx, ChronoFlowService_ListProducts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chronoFlowServiceClient) CheckNow(ctx context.Context, in *CheckNowRequest, opts ...grpc.CallOption) (*CheckNowResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckNowResponse)
+	err := c.cc.Invoke(ctx, ChronoFlowService_CheckNow_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chronoFlowServiceClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChangeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ChronoFlowService_ServiceDesc.Streams[0], ChronoFlowService_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, ChangeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChronoFlowService_SubscribeClient = grpc.ServerStreamingClient[ChangeEvent]
+
+// ChronoFlowServiceServer is the server API for ChronoFlowService service.
+// All implementations must embed UnimplementedChronoFlowServiceServer
+// for forward compatibility.
+//
+// ChronoFlowService lets other services read the monitored products, trigger checks and
+// receive the detected changes as they happen.
+type ChronoFlowServiceServer interface {
+	// ListProducts returns the products found on the target page by the last check.
+	ListProducts(context.Context, *ListProductsRequest) (*ListProductsResponse, error)
+	// CheckNow checks the target page immediately instead of waiting for the next scheduled
+	// check and returns the detected changes. Subscribers and chats are notified as usual.
+	CheckNow(context.Context, *CheckNowRequest) (*CheckNowResponse, error)
+	// Subscribe streams the changes detected by every following check, scheduled or not.
+	// A subscriber that can't keep up is disconnected with RESOURCE_EXHAUSTED.
+	Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[ChangeEvent]) error
+	mustEmbedUnimplementedChronoFlowServiceServer()
+}
+
+// UnimplementedChronoFlowServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedChronoFlowServiceServer struct{}
+
+func (UnimplementedChronoFlowServiceServer) ListProducts(context.Context, *ListProductsRequest) (*ListProductsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProducts not implemented")
+}
+func (UnimplementedChronoFlowServiceServer) CheckNow(context.Context, *CheckNowRequest) (*CheckNowResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckNow not implemented")
+}
+func (UnimplementedChronoFlowServiceServer) Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[ChangeEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedChronoFlowServiceServer) mustEmbedUnimplementedChronoFlowServiceServer() {}
+func (UnimplementedChronoFlowServiceServer) testEmbeddedByValue()                           {}
+
+// UnsafeChronoFlowServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ChronoFlowServiceServer will
+// result in compilation errors.
+type UnsafeChronoFlowServiceServer interface {
+	mustEmbedUnimplementedChronoFlowServiceServer()
+}
+
+func RegisterChronoFlowServiceServer(s grpc.ServiceRegistrar, srv ChronoFlowServiceServer) {
+	// If the following call pancis, it indicates UnimplementedChronoFlowServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ChronoFlowService_ServiceDesc, srv)
+}
+
+func _ChronoFlowService_ListProducts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProductsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChronoFlowServiceServer).ListProducts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChronoFlowService_ListProducts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChronoFlowServiceServer).ListProducts(ctx, req.(*ListProductsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChronoFlowService_CheckNow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckNowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChronoFlowServiceServer).CheckNow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChronoFlowService_CheckNow_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChronoFlowServiceServer).CheckNow(ctx, req.(*CheckNowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChronoFlowService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChronoFlowServiceServer).Subscribe(m, &grpc.GenericServerStream[SubscribeRequest, ChangeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChronoFlowService_SubscribeServer = grpc.ServerStreamingServer[ChangeEvent]
+
+// ChronoFlowService_ServiceDesc is the grpc.ServiceDesc for ChronoFlowService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ChronoFlowService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chronoflow.v1.ChronoFlowService",
+	HandlerType: (*ChronoFlowServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListProducts",
+			Handler:    _ChronoFlowService_ListProducts_Handler,
+		},
+		{
+			MethodName: "CheckNow",
+			Handler:    _ChronoFlowService_CheckNow_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _ChronoFlowService_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "chronoflow/v1/chronoflow.proto",
+}
@@ -0,0 +1,64 @@
+package chronoflowv1
+
+import (
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// NewProduct converts a product into its protobuf message.
+func NewProduct(product models.Product) *Product {
+	return &Product{
+		Model:      product.Model,
+		Category:   product.Category,
+		Type:       product.Type,
+		Quantity:   product.Quantity,
+		Price:      product.Price,
+		ImageUrl:   product.ImageURL,
+		ProductUrl: product.ProductURL,
+	}
+}
+
+// NewChanges converts the changes of a check into their protobuf message.
+func NewChanges(changes *models.Changes) *Changes {
+	if changes == nil {
+		return &Changes{}
+	}
+
+	return &Changes{
+		Added:   newProducts(changes.Added),
+		Changed: newProductChanges(changes.Changed),
+		Renamed: newProductChanges(changes.Renamed),
+		Removed: newProducts(changes.Removed),
+	}
+}
+
+// NewChangeEvent returns the event of the changes detected by a check.
+func NewChangeEvent(runID string, detectedAt time.Time, changes *models.Changes) *ChangeEvent {
+	return &ChangeEvent{
+		RunId:      runID,
+		DetectedAt: timestamppb.New(detectedAt),
+		Changes:    NewChanges(changes),
+	}
+}
+
+// newProducts converts a list of products into protobuf messages.
+func newProducts(products []models.Product) []*Product {
+	result := make([]*Product, 0, len(products))
+	for _, product := range products {
+		result = append(result, NewProduct(product))
+	}
+
+	return result
+}
+
+// newProductChanges converts changed or renamed products into protobuf messages.
+func newProductChanges(changes []models.ChangeInfo) []*ProductChange {
+	result := make([]*ProductChange, 0, len(changes))
+	for _, change := range changes {
+		result = append(result, &ProductChange{Old: NewProduct(change.Old), New: NewProduct(change.New)})
+	}
+
+	return result
+}
@@ -0,0 +1,182 @@
+// Package rpc serves the gRPC API other backend services use to read products, trigger checks
+// and stream the detected changes.
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/auth"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+	"github.com/Houeta/chrono-flow/internal/rpc/chronoflowv1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// subscriberBuffer is the number of events a subscriber may lag behind before it's disconnected.
+const subscriberBuffer = 16
+
+// CheckFunc runs a check out of schedule and returns the detected changes.
+type CheckFunc func(ctx context.Context) (*models.Changes, error)
+
+// Server implements the ChronoFlowService gRPC service.
+type Server struct {
+	chronoflowv1.UnimplementedChronoFlowServiceServer
+
+	log   *slog.Logger
+	repo  sqlite.ProductRepository
+	check CheckFunc
+	// authenticator checks the token of every call, nil leaves the service open.
+	authenticator *auth.Authenticator
+
+	mu          sync.Mutex
+	subscribers map[chan *chronoflowv1.ChangeEvent]struct{}
+}
+
+// Option configures the Server.
+type Option func(*Server)
+
+// WithAuthenticator requires every call to carry an API token in the "authorization" metadata.
+// ListProducts needs the read:products scope, CheckNow the trigger:check scope and Subscribe
+// the read:changes scope.
+func WithAuthenticator(authenticator *auth.Authenticator) Option {
+	return func(s *Server) {
+		s.authenticator = authenticator
+	}
+}
+
+// NewServer creates the gRPC service reading products from repo and running checks with check.
+func NewServer(log *slog.Logger, repo sqlite.ProductRepository, check CheckFunc, opts ...Option) *Server {
+	server := &Server{
+		log:         log,
+		repo:        repo,
+		check:       check,
+		subscribers: make(map[chan *chronoflowv1.ChangeEvent]struct{}),
+	}
+	for _, opt := range opts {
+		opt(server)
+	}
+
+	return server
+}
+
+// ListProducts returns the products found by the last check, ordered by category and model.
+func (s *Server) ListProducts(
+	ctx context.Context,
+	req *chronoflowv1.ListProductsRequest,
+) (*chronoflowv1.ListProductsResponse, error) {
+	filter := models.ProductFilter{Category: req.GetCategory(), Type: req.GetType()}
+	page, err := s.repo.ListProducts(ctx, filter, models.SortByModel, 0, 0)
+	if err != nil {
+		s.log.ErrorContext(ctx, "failed to get products", "error", err)
+		return nil, status.Error(codes.Internal, "failed to get products")
+	}
+
+	resp := &chronoflowv1.ListProductsResponse{}
+	for _, product := range page.Products {
+		resp.Products = append(resp.Products, chronoflowv1.NewProduct(product))
+	}
+
+	return resp, nil
+}
+
+// CheckNow runs a check out of schedule.
+func (s *Server) CheckNow(
+	ctx context.Context,
+	_ *chronoflowv1.CheckNowRequest,
+) (*chronoflowv1.CheckNowResponse, error) {
+	changes, err := s.check(ctx)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, status.FromContextError(ctxErr).Err()
+		}
+		return nil, status.Errorf(codes.Unavailable, "check failed: %v", err)
+	}
+
+	return &chronoflowv1.CheckNowResponse{Changes: chronoflowv1.NewChanges(changes)}, nil
+}
+
+// Subscribe streams the changes published after the subscription until the client disconnects.
+func (s *Server) Subscribe(
+	_ *chronoflowv1.SubscribeRequest,
+	stream grpc.ServerStreamingServer[chronoflowv1.ChangeEvent],
+) error {
+	events := make(chan *chronoflowv1.ChangeEvent, subscriberBuffer)
+	s.mu.Lock()
+	s.subscribers[events] = struct{}{}
+	s.mu.Unlock()
+	defer s.unsubscribe(events)
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "subscriber fell behind")
+			}
+			if err := stream.Send(event); err != nil {
+				return fmt.Errorf("failed to send change event: %w", err)
+			}
+		}
+	}
+}
+
+// Publish sends the changes detected by a check to every subscriber. Subscribers that fell
+// behind are disconnected instead of blocking the check.
+func (s *Server) Publish(ctx context.Context, runID string, detectedAt time.Time, changes *models.Changes) {
+	event := chronoflowv1.NewChangeEvent(runID, detectedAt, changes)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for events := range s.subscribers {
+		select {
+		case events <- event:
+		default:
+			s.log.WarnContext(ctx, "Disconnecting a subscriber that fell behind", "run_id", runID)
+			delete(s.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+// unsubscribe removes the subscriber unless it was already disconnected by Publish.
+func (s *Server) unsubscribe(events chan *chronoflowv1.ChangeEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subscribers[events]; ok {
+		delete(s.subscribers, events)
+		close(events)
+	}
+}
+
+// Serve exposes the service on addr until the context is canceled.
+func (s *Server) Serve(ctx context.Context, addr string) error {
+	var listenConfig net.ListenConfig
+	listener, err := listenConfig.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	server := grpc.NewServer(s.ServerOptions()...)
+	chronoflowv1.RegisterChronoFlowServiceServer(server, s)
+
+	go func() {
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
+
+	s.log.InfoContext(ctx, "Serving gRPC API", "addr", addr)
+	if err = server.Serve(listener); err != nil {
+		return fmt.Errorf("gRPC server failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package rpc_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/auth"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/Houeta/chrono-flow/internal/rpc"
+	"github.com/Houeta/chrono-flow/internal/rpc/chronoflowv1"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestClient serves the server over an in-memory connection and returns a client for it.
+func newTestClient(t *testing.T, server *rpc.Server) chronoflowv1.ChronoFlowServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer(server.ServerOptions()...)
+	chronoflowv1.RegisterChronoFlowServiceServer(grpcServer, server)
+	go func() { _ = grpcServer.Serve(listener) }()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return chronoflowv1.NewChronoFlowServiceClient(conn)
+}
+
+func noCheck(context.Context) (*models.Changes, error) {
+	return &models.Changes{}, nil
+}
+
+func TestServer_ListProducts(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("filters products", func(t *testing.T) {
+		repo := mocks.NewProductRepository(t)
+		filter := models.ProductFilter{Category: "new", Type: "Diver"}
+		repo.On("ListProducts", mock.Anything, filter, models.SortByModel, 0, 0).Return(&models.ProductPage{
+			Products: []models.Product{
+				{Model: "A1", Category: "new", Type: "Diver", ProductURL: "https://example.com/a1"},
+			},
+			Total: 1,
+		}, nil)
+		client := newTestClient(t, rpc.NewServer(logger, repo, noCheck))
+
+		resp, err := client.ListProducts(t.Context(), &chronoflowv1.ListProductsRequest{Category: "new", Type: "Diver"})
+
+		require.NoError(t, err)
+		require.Len(t, resp.GetProducts(), 1)
+		assert.Equal(t, "A1", resp.GetProducts()[0].GetModel())
+		assert.Equal(t, "https://example.com/a1", resp.GetProducts()[0].GetProductUrl())
+	})
+
+	t.Run("no products yet", func(t *testing.T) {
+		repo := mocks.NewProductRepository(t)
+		repo.On("ListProducts", mock.Anything, models.ProductFilter{}, models.SortByModel, 0, 0).
+			Return(&models.ProductPage{}, nil)
+		client := newTestClient(t, rpc.NewServer(logger, repo, noCheck))
+
+		resp, err := client.ListProducts(t.Context(), &chronoflowv1.ListProductsRequest{})
+
+		require.NoError(t, err)
+		assert.Empty(t, resp.GetProducts())
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		repo := mocks.NewProductRepository(t)
+		repo.On("ListProducts", mock.Anything, mock.Anything, mock.Anything, 0, 0).Return(nil, assert.AnError)
+		client := newTestClient(t, rpc.NewServer(logger, repo, noCheck))
+
+		_, err := client.ListProducts(t.Context(), &chronoflowv1.ListProductsRequest{})
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+}
+
+func TestServer_CheckNow(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("returns the detected changes", func(t *testing.T) {
+		check := func(context.Context) (*models.Changes, error) {
+			return &models.Changes{
+				Added:   []models.Product{{Model: "A1"}},
+				Renamed: []models.ChangeInfo{{Old: models.Product{Model: "B1"}, New: models.Product{Model: "B1X"}}},
+			}, nil
+		}
+		client := newTestClient(t, rpc.NewServer(logger, mocks.NewProductRepository(t), check))
+
+		resp, err := client.CheckNow(t.Context(), &chronoflowv1.CheckNowRequest{})
+
+		require.NoError(t, err)
+		require.Len(t, resp.GetChanges().GetAdded(), 1)
+		assert.Equal(t, "A1", resp.GetChanges().GetAdded()[0].GetModel())
+		require.Len(t, resp.GetChanges().GetRenamed(), 1)
+		assert.Equal(t, "B1", resp.GetChanges().GetRenamed()[0].GetOld().GetModel())
+		assert.Equal(t, "B1X", resp.GetChanges().GetRenamed()[0].GetNew().GetModel())
+	})
+
+	t.Run("check failed", func(t *testing.T) {
+		check := func(context.Context) (*models.Changes, error) { return nil, assert.AnError }
+		client := newTestClient(t, rpc.NewServer(logger, mocks.NewProductRepository(t), check))
+
+		_, err := client.CheckNow(t.Context(), &chronoflowv1.CheckNowRequest{})
+
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
+
+func TestServer_Subscribe(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := rpc.NewServer(logger, mocks.NewProductRepository(t), noCheck)
+	client := newTestClient(t, server)
+	detectedAt := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	stream, err := client.Subscribe(t.Context(), &chronoflowv1.SubscribeRequest{})
+	require.NoError(t, err)
+
+	// The subscription is registered asynchronously, so publish until the first event arrives.
+	received := make(chan *chronoflowv1.ChangeEvent, 1)
+	go func() {
+		event, recvErr := stream.Recv()
+		if recvErr == nil {
+			received <- event
+		}
+	}()
+
+	changes := &models.Changes{Removed: []models.Product{{Model: "A1"}}}
+	var event *chronoflowv1.ChangeEvent
+	require.Eventually(t, func() bool {
+		server.Publish(t.Context(), "run-1", detectedAt, changes)
+		select {
+		case event = <-received:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, "run-1", event.GetRunId())
+	assert.True(t, detectedAt.Equal(event.GetDetectedAt().AsTime()))
+	require.Len(t, event.GetChanges().GetRemoved(), 1)
+	assert.Equal(t, "A1", event.GetChanges().GetRemoved()[0].GetModel())
+}
+
+func TestServer_Auth(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tokens := mocks.NewTokenRepository(t)
+	readOnly := &models.APIToken{ID: 1, Scopes: []string{auth.ScopeReadProducts}}
+	tokens.On("GetTokenByHash", mock.Anything, auth.HashToken("cf_read")).Return(readOnly, nil)
+	tokens.On("GetTokenByHash", mock.Anything, auth.HashToken("cf_revoked")).Return(nil, repository.ErrTokenNotFound)
+
+	repo := mocks.NewProductRepository(t)
+	repo.On("ListProducts", mock.Anything, mock.Anything, mock.Anything, 0, 0).Return(&models.ProductPage{}, nil)
+
+	server := rpc.NewServer(logger, repo, noCheck, rpc.WithAuthenticator(auth.NewAuthenticator(logger, tokens)))
+	client := newTestClient(t, server)
+	withToken := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(t.Context(), "authorization", "Bearer "+token)
+	}
+
+	_, err := client.ListProducts(t.Context(), &chronoflowv1.ListProductsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.ListProducts(withToken("cf_revoked"), &chronoflowv1.ListProductsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.ListProducts(withToken("cf_read"), &chronoflowv1.ListProductsRequest{})
+	require.NoError(t, err)
+
+	_, err = client.CheckNow(withToken("cf_read"), &chronoflowv1.CheckNowRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	stream, err := client.Subscribe(withToken("cf_read"), &chronoflowv1.SubscribeRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -0,0 +1,231 @@
+// Package rules evaluates the alert rules: conditions over a change and the price history of its product
+// written in the expression language of the compute package, e.g.
+// new.price < 0.8*avg(history, 30d) && product.type == "GPU".
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/compute"
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// ErrInvalidRule is returned for a rule with an invalid name or condition.
+var ErrInvalidRule = errors.New("invalid alert rule")
+
+// extraPrefix prefixes the extra fields of the product, read as numbers, e.g. extra.warranty.
+const extraPrefix = "extra."
+
+// scope declares the variables and the functions of the conditions. The numeric variables are listed
+// by numbers.
+//
+//nolint:gochecknoglobals // a read-only declaration of the language.
+var scope = compute.Scope{
+	Strings: []string{
+		"change.kind", "product.model", "product.type", "product.category", "old.model", "new.model",
+	},
+	Series:    []string{"history"},
+	Functions: []string{"avg", "min", "max"},
+}
+
+// numbers are the numeric variables of the conditions besides the extra fields.
+//
+//nolint:gochecknoglobals // a read-only declaration of the language.
+var numbers = []string{"old.price", "old.quantity", "new.price", "new.quantity"}
+
+// Variables describes the variables and the functions of the conditions.
+func Variables() string {
+	return "change.kind (added, removed, changed, renamed), product.model, product.type, product.category, " +
+		"old.model, new.model, old.price, old.quantity, new.price, new.quantity, extra.<field>, " +
+		"avg(history, 30d), min(history, 2w), max(history)"
+}
+
+// Rule is a compiled alert rule.
+type Rule struct {
+	// ChatID is the chat the rule alerts, zero for a rule of the deployment alerting every subscribed chat.
+	ChatID int64
+	Name   string
+	// Condition is the boolean expression a change matches.
+	Condition *compute.Expression
+}
+
+// Compile checks the name and compiles the condition of the rule. A name holds letters, digits, - and _.
+func Compile(rule models.AlertRule) (Rule, error) {
+	if !ValidName(rule.Name) {
+		return Rule{}, fmt.Errorf("%w: the name %q may only hold letters, digits, - and _", ErrInvalidRule, rule.Name)
+	}
+
+	condition, err := compute.ParseIn(rule.Expression, scope)
+	if err != nil {
+		return Rule{}, fmt.Errorf("%w %s: %w", ErrInvalidRule, rule.Name, err)
+	}
+	if !condition.IsBool() {
+		return Rule{}, fmt.Errorf("%w %s: the condition must be true or false", ErrInvalidRule, rule.Name)
+	}
+	for _, variable := range condition.Variables() {
+		if !slices.Contains(scope.Strings, variable) && !slices.Contains(numbers, variable) &&
+			(!strings.HasPrefix(variable, extraPrefix) || variable == extraPrefix) {
+			return Rule{}, fmt.Errorf("%w %s: unknown variable %s", ErrInvalidRule, rule.Name, variable)
+		}
+	}
+
+	return Rule{ChatID: rule.ChatID, Name: rule.Name, Condition: condition}, nil
+}
+
+// ValidName reports whether the name of a rule holds letters, digits, - and _ only.
+func ValidName(name string) bool {
+	return name != "" && !strings.ContainsFunc(name, func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '_' && r != '-'
+	})
+}
+
+// Change is a change the rules are evaluated against.
+type Change struct {
+	// Kind is the kind of the change, see models.KindAdded.
+	Kind string
+	// Old is the product before the change, nil if it was added.
+	Old *models.Product
+	// New is the product after the change, nil if it was removed.
+	New *models.Product
+	// History loads the history of the product, oldest first. It's only called for the rules calling
+	// a function of the history.
+	History func() []models.ChangeRecord
+}
+
+// Product returns the product after the change, the one before if it was removed.
+func (c Change) Product() models.Product {
+	if c.New != nil {
+		return *c.New
+	}
+
+	return *c.Old
+}
+
+// Changes flattens the changes for the rules, history loads the history of a product.
+func Changes(changes *models.Changes, history func(models.Product) []models.ChangeRecord) []Change {
+	var flat []Change
+	add := func(kind string, oldProduct, newProduct *models.Product) {
+		change := Change{Kind: kind, Old: oldProduct, New: newProduct}
+		change.History = func() []models.ChangeRecord { return history(change.Product()) }
+		flat = append(flat, change)
+	}
+	for _, p := range changes.Added {
+		add(models.KindAdded, nil, &p)
+	}
+	for _, change := range changes.Changed {
+		add(models.KindChanged, &change.Old, &change.New)
+	}
+	for _, change := range changes.Renamed {
+		add(models.KindRenamed, &change.Old, &change.New)
+	}
+	for _, p := range changes.Removed {
+		add(models.KindRemoved, &p, nil)
+	}
+
+	return flat
+}
+
+// Match reports whether the change detected now matches the rule. A condition reading a value the change
+// doesn't have, like the price of an added product before, doesn't match.
+func (r Rule) Match(change Change, now time.Time) bool {
+	_, matched, ok := r.Condition.EvalIn(&env{change: change, now: now})
+
+	return ok && matched
+}
+
+// env provides the values of a change to a condition.
+type env struct {
+	change  Change
+	now     time.Time
+	history []models.ChangeRecord
+	loaded  bool
+}
+
+func (e *env) Number(name string) (float64, bool) {
+	var raw string
+	if field, ok := strings.CutPrefix(name, extraPrefix); ok {
+		raw, _ = e.change.Product().Extra(field)
+	} else {
+		product := e.change.New
+		if strings.HasPrefix(name, "old.") {
+			product = e.change.Old
+		}
+		if product == nil {
+			return 0, false
+		}
+		raw = product.Quantity
+		if strings.HasSuffix(name, ".price") {
+			raw = product.Price
+		}
+	}
+	if strings.TrimSpace(raw) == "" {
+		return 0, false
+	}
+	number, err := models.ParsePrice(raw)
+
+	return number, err == nil
+}
+
+func (e *env) String(name string) (string, bool) {
+	product := e.change.Product()
+	switch name {
+	case "change.kind":
+		return e.change.Kind, true
+	case "product.model":
+		return product.Model, true
+	case "product.type":
+		return product.Type, true
+	case "product.category":
+		return product.Category, true
+	case "old.model":
+		return modelOf(e.change.Old)
+	default:
+		return modelOf(e.change.New)
+	}
+}
+
+func (e *env) Aggregate(function, _ string, period time.Duration) (float64, bool) {
+	if !e.loaded {
+		e.history, e.loaded = e.change.History(), true
+	}
+
+	// The prices of a removed product are the ones before it was removed.
+	change := models.ChangeInfo{Old: e.change.Product(), New: e.change.Product()}
+	if e.change.Old != nil && e.change.New != nil {
+		change = models.ChangeInfo{Old: *e.change.Old, New: *e.change.New}
+	}
+	var start time.Time
+	if period > 0 {
+		start = e.now.Add(-period)
+	}
+	prices := models.EarlierPrices(e.history, change, e.now, start)
+	if len(prices) == 0 {
+		return 0, false
+	}
+
+	switch function {
+	case "min":
+		return slices.Min(prices), true
+	case "max":
+		return slices.Max(prices), true
+	default:
+		var sum float64
+		for _, price := range prices {
+			sum += price
+		}
+		return sum / float64(len(prices)), true
+	}
+}
+
+// modelOf returns the model of the product, false if there's none.
+func modelOf(product *models.Product) (string, bool) {
+	if product == nil {
+		return "", false
+	}
+
+	return product.Model, true
+}
@@ -0,0 +1,110 @@
+package rules_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	t.Run("rule is compiled", func(t *testing.T) {
+		rule, err := rules.Compile(models.AlertRule{
+			ChatID: -100, Name: "cheap-gpu", Expression: `new.Price < 0.8*avg(history, 30d) && product.Type == "GPU"`,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(-100), rule.ChatID)
+		assert.Equal(t, "cheap-gpu", rule.Name)
+	})
+
+	testCases := []struct {
+		name string
+		rule models.AlertRule
+		err  string
+	}{
+		{name: "invalid name", rule: models.AlertRule{Name: "cheap gpu", Expression: "true"}, err: "may only hold"},
+		{name: "invalid condition", rule: models.AlertRule{Name: "a", Expression: "new.price <"}, err: "unexpected"},
+		{name: "not a condition", rule: models.AlertRule{Name: "a", Expression: "new.price * 2"}, err: "true or false"},
+		{name: "unknown variable", rule: models.AlertRule{Name: "a", Expression: "weight > 1"}, err: "unknown"},
+		{name: "unknown function", rule: models.AlertRule{Name: "a", Expression: "sum(history) > 1"}, err: "unknown"},
+	}
+	for _, tc := range testCases {
+		t.Run("error: "+tc.name, func(t *testing.T) {
+			_, err := rules.Compile(tc.rule)
+
+			require.ErrorIs(t, err, rules.ErrInvalidRule)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestRule_Match(t *testing.T) {
+	now := time.Now()
+	daysAgo := func(days int) time.Time { return now.Add(-time.Duration(days) * 24 * time.Hour) }
+	history := []models.ChangeRecord{
+		{DetectedAt: daysAgo(90), Kind: models.KindAdded, Price: "500"},
+		{DetectedAt: daysAgo(20), Kind: models.KindChanged, OldPrice: "500", Price: "1 000"},
+		{DetectedAt: daysAgo(10), Kind: models.KindChanged, OldPrice: "1 000", Price: "1 200"},
+	}
+	loads := 0
+	changes := rules.Changes(&models.Changes{
+		Added: []models.Product{{Model: "N1", Type: "GPU", Price: "100"}},
+		Changed: []models.ChangeInfo{{
+			Old: models.Product{Model: "A1", Type: "GPU", Price: "1 200", Quantity: "2"},
+			New: models.Product{
+				Model: "A1", Type: "GPU", Price: "799", Quantity: "2", Extras: map[string]string{"Warranty": "24"},
+			},
+		}},
+		Removed: []models.Product{{Model: "R1", Type: "CPU", Price: "300"}},
+	}, func(p models.Product) []models.ChangeRecord {
+		loads++
+		if p.Model == "A1" {
+			return history
+		}
+		return nil
+	})
+	require.Len(t, changes, 3)
+	added, changed, removed := changes[0], changes[1], changes[2]
+
+	testCases := []struct {
+		name    string
+		expr    string
+		change  rules.Change
+		matched bool
+	}{
+		{name: "drop below the average of a period", expr: `new.price < 0.9*avg(history, 30d) && product.type == "gpu"`,
+			change: changed, matched: true},
+		{name: "the period starts with the price in effect", expr: "min(history, 15d) == 1000", change: changed,
+			matched: true},
+		{name: "whole history", expr: "new.price > min(history)", change: changed, matched: true},
+		{name: "extra field", expr: "extra.warranty >= 24 && new.quantity == old.quantity", change: changed,
+			matched: true},
+		{name: "kind of the change", expr: `change.kind == "removed" && old.price > 0`, change: removed,
+			matched: true},
+		{name: "missing value doesn't match", expr: "old.price > 0 || true", change: added},
+		{name: "model after a removal is missing", expr: `new.model != ""`, change: removed},
+		{name: "other type", expr: `product.type == "GPU"`, change: removed},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := rules.Compile(models.AlertRule{Name: "rule", Expression: tc.expr})
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.matched, rule.Match(tc.change, now))
+		})
+	}
+
+	t.Run("history is only loaded by functions", func(t *testing.T) {
+		loads = 0
+		rule, err := rules.Compile(models.AlertRule{Name: "rule", Expression: "new.price > 1"})
+		require.NoError(t, err)
+
+		rule.Match(changed, now)
+
+		assert.Zero(t, loads)
+	})
+}
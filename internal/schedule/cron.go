@@ -0,0 +1,131 @@
+// Package schedule describes recurring time windows with cron expressions.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCron is returned for an expression that isn't a valid 5-field cron expression.
+var ErrInvalidCron = errors.New("invalid cron expression")
+
+// field is the set of values a cron field matches, indexed by value.
+type field []bool
+
+// Cron is a standard 5-field cron expression: minute, hour, day of month, month and day of week.
+// Fields accept "*", values, ranges, lists and steps, e.g. "*/15", "1-5" or "0,30".
+// Day of week 0 and 7 are both Sunday.
+type Cron struct {
+	minute, hour, dom, month, dow field
+	// domAny and dowAny are set when the day fields are "*". As in cron, a day matches both
+	// day fields when one of them is "*", and either of them when both are restricted.
+	domAny, dowAny bool
+}
+
+// ParseCron parses a 5-field cron expression.
+func ParseCron(expr string) (Cron, error) {
+	const fieldCount = 5
+
+	parts := strings.Fields(expr)
+	if len(parts) != fieldCount {
+		return Cron{}, fmt.Errorf("%w: expected %d fields in %q", ErrInvalidCron, fieldCount, expr)
+	}
+
+	var (
+		cron Cron
+		err  error
+	)
+	bounds := []struct {
+		target   *field
+		min, max int
+	}{
+		{&cron.minute, 0, 59},
+		{&cron.hour, 0, 23},
+		{&cron.dom, 1, 31},
+		{&cron.month, 1, 12},
+		{&cron.dow, 0, 7},
+	}
+	for idx, bound := range bounds {
+		if *bound.target, err = parseField(parts[idx], bound.min, bound.max); err != nil {
+			return Cron{}, fmt.Errorf("%w: %q: %w", ErrInvalidCron, expr, err)
+		}
+	}
+
+	// Sunday can be written both as 0 and 7.
+	cron.dow[0] = cron.dow[0] || cron.dow[7]
+	cron.domAny = parts[2] == "*"
+	cron.dowAny = parts[4] == "*"
+
+	return cron, nil
+}
+
+// parseField parses a comma separated list of cron ranges within [minValue, maxValue].
+func parseField(raw string, minValue, maxValue int) (field, error) {
+	matches := make(field, maxValue+1)
+	for _, part := range strings.Split(raw, ",") {
+		start, end, step, err := parseRange(part, minValue, maxValue)
+		if err != nil {
+			return nil, err
+		}
+		for value := start; value <= end; value += step {
+			matches[value] = true
+		}
+	}
+
+	return matches, nil
+}
+
+// parseRange parses "*", "N", "N-M" with an optional "/step" suffix.
+func parseRange(raw string, minValue, maxValue int) (int, int, int, error) {
+	rangePart, stepPart, hasStep := strings.Cut(raw, "/")
+	step := 1
+	if hasStep {
+		var err error
+		if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+			return 0, 0, 0, fmt.Errorf("invalid step %q", stepPart)
+		}
+	}
+
+	if rangePart == "*" {
+		return minValue, maxValue, step, nil
+	}
+
+	startPart, endPart, isRange := strings.Cut(rangePart, "-")
+	start, err := strconv.Atoi(startPart)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid value %q", startPart)
+	}
+	end := start
+	if isRange {
+		if end, err = strconv.Atoi(endPart); err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid value %q", endPart)
+		}
+	} else if hasStep {
+		// "N/step" runs from N to the end of the range, as in cron.
+		end = maxValue
+	}
+
+	if start < minValue || end > maxValue || start > end {
+		return 0, 0, 0, fmt.Errorf("range %q is outside of %d-%d", rangePart, minValue, maxValue)
+	}
+
+	return start, end, step, nil
+}
+
+// Matches reports whether the minute of t matches the expression.
+func (c Cron) Matches(t time.Time) bool {
+	if !c.minute[t.Minute()] || !c.hour[t.Hour()] || !c.month[int(t.Month())] {
+		return false
+	}
+
+	domMatches := c.dom[t.Day()]
+	dowMatches := c.dow[int(t.Weekday())]
+	if c.domAny || c.dowAny {
+		return domMatches && dowMatches
+	}
+
+	return domMatches || dowMatches
+}
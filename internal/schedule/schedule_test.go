@@ -0,0 +1,117 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/schedule"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron(t *testing.T) {
+	// 2025-07-07 is a Monday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, 7, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		name     string
+		expr     string
+		matching []time.Time
+		other    []time.Time
+	}{
+		{
+			name:     "nightly",
+			expr:     "0 2 * * *",
+			matching: []time.Time{at(7, 2, 0), at(13, 2, 0)},
+			other:    []time.Time{at(7, 2, 1), at(7, 3, 0)},
+		},
+		{
+			name:     "steps and lists",
+			expr:     "*/15 8,20 * * *",
+			matching: []time.Time{at(7, 8, 0), at(7, 8, 45), at(7, 20, 30)},
+			other:    []time.Time{at(7, 8, 10), at(7, 9, 0)},
+		},
+		{
+			name:     "weekdays, Sunday as 7",
+			expr:     "30 4 * * 1-5,7",
+			matching: []time.Time{at(7, 4, 30), at(11, 4, 30), at(13, 4, 30)},
+			other:    []time.Time{at(12, 4, 30)},
+		},
+		{
+			name:     "restricted day fields match either of them",
+			expr:     "0 0 1 * 1",
+			matching: []time.Time{at(1, 0, 0), at(7, 0, 0)},
+			other:    []time.Time{at(8, 0, 0)},
+		},
+		{
+			name:     "value with a step runs to the end of the range",
+			expr:     "50/5 * * * *",
+			matching: []time.Time{at(7, 1, 50), at(7, 1, 55)},
+			other:    []time.Time{at(7, 1, 0), at(7, 1, 45)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cron, err := schedule.ParseCron(tc.expr)
+			require.NoError(t, err)
+
+			for _, moment := range tc.matching {
+				assert.True(t, cron.Matches(moment), moment)
+			}
+			for _, moment := range tc.other {
+				assert.False(t, cron.Matches(moment), moment)
+			}
+		})
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	invalid := []string{
+		"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *",
+	}
+	for _, expr := range invalid {
+		_, err := schedule.ParseCron(expr)
+		require.ErrorIs(t, err, schedule.ErrInvalidCron, expr)
+	}
+}
+
+func TestParseWindows(t *testing.T) {
+	windows, err := schedule.ParseWindows(" 0 2 * * *  1h ; 30 4 * * 0 30m;")
+	require.NoError(t, err)
+	require.Len(t, windows, 2)
+	assert.Equal(t, "0 2 * * * 1h", windows[0].Spec)
+	assert.Equal(t, time.Hour, windows[0].Duration)
+	assert.Equal(t, 30*time.Minute, windows[1].Duration)
+
+	windows, err = schedule.ParseWindows("")
+	require.NoError(t, err)
+	assert.Empty(t, windows)
+
+	for _, raw := range []string{"0 2 * * *", "0 2 * * * soon", "0 2 * * * 30s", "0 2 * * * 200h", "0 25 * * * 1h"} {
+		_, err = schedule.ParseWindows(raw)
+		require.ErrorIs(t, err, schedule.ErrInvalidWindow, raw)
+	}
+}
+
+func TestActive(t *testing.T) {
+	windows, err := schedule.ParseWindows("0 2 * * * 1h;30 2 * * * 1h")
+	require.NoError(t, err)
+	day := time.Date(2025, 7, 7, 0, 0, 0, 0, time.UTC)
+
+	_, active := schedule.Active(windows, day.Add(time.Hour+59*time.Minute))
+	assert.False(t, active, "before the window")
+
+	until, active := schedule.Active(windows, day.Add(2*time.Hour+10*time.Minute))
+	assert.True(t, active)
+	assert.Equal(t, day.Add(3*time.Hour), until)
+
+	until, active = schedule.Active(windows, day.Add(2*time.Hour+45*time.Minute))
+	assert.True(t, active, "both windows overlap")
+	assert.Equal(t, day.Add(3*time.Hour+30*time.Minute), until)
+
+	_, active = schedule.Active(windows, day.Add(3*time.Hour+30*time.Minute))
+	assert.False(t, active, "the end of a window is exclusive")
+}
@@ -0,0 +1,93 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxWindowDuration is the longest window, it bounds the lookback of Active.
+const MaxWindowDuration = 7 * 24 * time.Hour
+
+// ErrInvalidWindow is returned for a window that can't be parsed.
+var ErrInvalidWindow = errors.New("invalid window")
+
+// Window is a recurring period that starts whenever the cron expression matches and lasts Duration.
+type Window struct {
+	Start    Cron
+	Duration time.Duration
+	// Spec is the expression the window was parsed from.
+	Spec string
+}
+
+// ParseWindow parses a window in the "<cron expression> <duration>" format,
+// e.g. "0 2 * * * 1h" for an hour starting at 02:00 every night.
+func ParseWindow(spec string) (Window, error) {
+	spec = strings.Join(strings.Fields(spec), " ")
+	idx := strings.LastIndex(spec, " ")
+	if idx < 0 {
+		return Window{}, fmt.Errorf("%w: expected a cron expression and a duration in %q", ErrInvalidWindow, spec)
+	}
+
+	start, err := ParseCron(spec[:idx])
+	if err != nil {
+		return Window{}, fmt.Errorf("%w: %w", ErrInvalidWindow, err)
+	}
+
+	duration, err := time.ParseDuration(spec[idx+1:])
+	if err != nil || duration < time.Minute || duration > MaxWindowDuration {
+		return Window{}, fmt.Errorf("%w: duration %q must be between 1m and %s", ErrInvalidWindow,
+			spec[idx+1:], MaxWindowDuration)
+	}
+
+	return Window{Start: start, Duration: duration, Spec: spec}, nil
+}
+
+// ParseWindows parses windows separated by semicolons, an empty string has no windows.
+func ParseWindows(raw string) ([]Window, error) {
+	var windows []Window
+	for _, spec := range strings.Split(raw, ";") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		window, err := ParseWindow(spec)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, window)
+	}
+
+	return windows, nil
+}
+
+// ActiveUntil reports whether t falls into the window and returns the end of the occurrence t is in.
+// Occurrences start at whole minutes in the location of t.
+func (w Window) ActiveUntil(t time.Time) (time.Time, bool) {
+	minute := t.Truncate(time.Minute)
+	for start := minute; t.Sub(start) < w.Duration; start = start.Add(-time.Minute) {
+		if w.Start.Matches(start) {
+			return start.Add(w.Duration), true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Active reports whether t falls into any of the windows and returns the latest end among them.
+func Active(windows []Window, t time.Time) (time.Time, bool) {
+	var (
+		until  time.Time
+		active bool
+	)
+	for _, window := range windows {
+		if end, ok := window.ActiveUntil(t); ok {
+			active = true
+			if end.After(until) {
+				until = end
+			}
+		}
+	}
+
+	return until, active
+}
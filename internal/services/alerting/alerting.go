@@ -0,0 +1,94 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// AdminNotifier delivers operational messages to administrators.
+type AdminNotifier interface {
+	NotifyAdmins(ctx context.Context, text string)
+}
+
+// Alerter tracks consecutive check failures of a target and alerts administrators
+// once an outage is confirmed, and again when the target recovers.
+// It isn't safe for concurrent use, the scheduler loop is its only user.
+type Alerter struct {
+	log       *slog.Logger
+	notifier  AdminNotifier
+	target    string
+	threshold int
+	now       func() time.Time
+	// classify names the class of an error in the alerts, nil leaves it out.
+	classify func(error) string
+
+	failures int
+	since    time.Time
+	alerted  bool
+}
+
+// Option configures optional Alerter behavior.
+type Option func(*Alerter)
+
+// WithClassifier names the class of the last error in the alerts, e.g. with checker.ErrorClass, so the
+// admins see at a glance whether the page can't be fetched or parsed.
+func WithClassifier(classify func(error) string) Option {
+	return func(a *Alerter) {
+		a.classify = classify
+	}
+}
+
+// NewAlerter creates an Alerter which alerts after threshold consecutive failures of the target.
+// A threshold of zero or less disables alerts.
+func NewAlerter(log *slog.Logger, notifier AdminNotifier, target string, threshold int, opts ...Option) *Alerter {
+	a := &Alerter{log: log, notifier: notifier, target: target, threshold: threshold, now: time.Now}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// Failure records a failed check and alerts administrators when the threshold is reached.
+func (a *Alerter) Failure(ctx context.Context, err error) {
+	if a.failures == 0 {
+		a.since = a.now()
+	}
+	a.failures++
+
+	if a.alerted || a.threshold <= 0 || a.failures < a.threshold {
+		return
+	}
+
+	a.alerted = true
+	a.log.WarnContext(ctx, "Alerting admins about failing checks", "target", a.target, "failures", a.failures)
+	lastError := "Last error"
+	if a.classify != nil {
+		lastError += " (" + a.classify(err) + ")"
+	}
+	a.notifier.NotifyAdmins(ctx, fmt.Sprintf(
+		"🚨 Checks of %s are failing\nFailed checks in a row: %d\nOutage duration: %s\n%s: %v",
+		a.target, a.failures, a.outage(), lastError, err,
+	))
+}
+
+// Success records a successful check and sends a recovery notice if an alert was sent.
+func (a *Alerter) Success(ctx context.Context) {
+	if a.alerted {
+		a.log.InfoContext(ctx, "Notifying admins about recovery", "target", a.target, "failures", a.failures)
+		a.notifier.NotifyAdmins(ctx, fmt.Sprintf(
+			"✅ Checks of %s recovered after %s (%d failed checks)",
+			a.target, a.outage(), a.failures,
+		))
+	}
+
+	a.failures = 0
+	a.alerted = false
+}
+
+// outage returns the time since the first failure of the current outage.
+func (a *Alerter) outage() time.Duration {
+	return a.now().Sub(a.since).Round(time.Second)
+}
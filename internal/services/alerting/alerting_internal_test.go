@@ -0,0 +1,95 @@
+package alerting
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingNotifier records every admin notification.
+type recordingNotifier struct {
+	sent []string
+}
+
+func (r *recordingNotifier) NotifyAdmins(_ context.Context, text string) {
+	r.sent = append(r.sent, text)
+}
+
+func TestAlerter(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("alerts once after threshold and notifies recovery", func(t *testing.T) {
+		t.Parallel()
+
+		notifier := &recordingNotifier{}
+		alerter := NewAlerter(logger, notifier, "https://example.com", 3)
+		current := start
+		alerter.now = func() time.Time { return current }
+
+		for range 5 {
+			alerter.Failure(t.Context(), assert.AnError)
+			current = current.Add(10 * time.Minute)
+		}
+
+		require.Len(t, notifier.sent, 1)
+		assert.Contains(t, notifier.sent[0], "Checks of https://example.com are failing")
+		assert.Contains(t, notifier.sent[0], "Failed checks in a row: 3")
+		assert.Contains(t, notifier.sent[0], "Outage duration: 20m0s")
+		assert.Contains(t, notifier.sent[0], assert.AnError.Error())
+
+		alerter.Success(t.Context())
+		require.Len(t, notifier.sent, 2)
+		assert.Contains(t, notifier.sent[1], "recovered after 50m0s (5 failed checks)")
+
+		// Subsequent successes are silent.
+		alerter.Success(t.Context())
+		assert.Len(t, notifier.sent, 2)
+	})
+
+	t.Run("failures below threshold are not reported", func(t *testing.T) {
+		t.Parallel()
+
+		notifier := &recordingNotifier{}
+		alerter := NewAlerter(logger, notifier, "target", 3)
+
+		alerter.Failure(t.Context(), assert.AnError)
+		alerter.Failure(t.Context(), assert.AnError)
+		alerter.Success(t.Context())
+		alerter.Failure(t.Context(), assert.AnError)
+
+		assert.Empty(t, notifier.sent)
+	})
+
+	t.Run("zero threshold disables alerts", func(t *testing.T) {
+		t.Parallel()
+
+		notifier := &recordingNotifier{}
+		alerter := NewAlerter(logger, notifier, "target", 0)
+
+		for range 10 {
+			alerter.Failure(t.Context(), assert.AnError)
+		}
+
+		assert.Empty(t, notifier.sent)
+	})
+	t.Run("alert names the class of the last error", func(t *testing.T) {
+		t.Parallel()
+
+		notifier := &recordingNotifier{}
+		classify := func(error) string { return "fetch" }
+		alerter := NewAlerter(logger, notifier, "target", 1, WithClassifier(classify))
+
+		alerter.Failure(t.Context(), assert.AnError)
+
+		require.Len(t, notifier.sent, 1)
+		assert.Contains(t, notifier.sent[0], "Last error (fetch): "+assert.AnError.Error())
+	})
+}
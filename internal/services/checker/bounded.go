@@ -0,0 +1,183 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/parser"
+	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+)
+
+// WithBoundedMemory checks the page product by product: the products are streamed from the page,
+// compared with the stored ones by indexed lookups and written in place of them within a single
+// transaction. Only the page and the changes are held in memory, whatever the size of the catalog.
+func WithBoundedMemory(repo sqlite.StreamingStateRepository, streamParser parser.StreamParser) Option {
+	return func(c *Checker) {
+		c.streamRepo = repo
+		c.streamParser = streamParser
+	}
+}
+
+// checkBounded detects the changes of the fetched page with the given hash product by product.
+func (c *Checker) checkBounded(
+	ctx context.Context,
+	log *slog.Logger,
+	body []byte,
+	pageHash string,
+) (checkResult, error) {
+	const opn = "checker.CheckForUpdates"
+
+	oldPageHash, err := c.streamRepo.GetPageHash(ctx)
+	if err != nil && !errors.Is(err, repository.ErrStateNotFound) {
+		return checkResult{}, fmt.Errorf("%s: failed to get old page hash: %w", opn, err)
+	}
+	if err == nil && oldPageHash == pageHash {
+		log.InfoContext(ctx, "Page hash has not changed. No updates.")
+		return checkResult{changes: &models.Changes{}}, nil
+	}
+	log.InfoContext(ctx, "Page hash differs or first run. Starting analysis product by product...")
+
+	update, err := c.streamRepo.BeginStateUpdate(ctx)
+	if err != nil {
+		return checkResult{}, fmt.Errorf("%s: failed to begin state update: %w", opn, err)
+	}
+	defer update.Rollback() //nolint:errcheck // Rollback after a successful commit does nothing.
+
+	changes, skipped, err := c.diffProducts(ctx, log, body, update)
+	if err != nil {
+		return checkResult{}, fmt.Errorf("%s: %w", opn, err)
+	}
+	logChanges(ctx, log, changes)
+	notify, err := c.preNotify(ctx, log, changes)
+	if err != nil {
+		return checkResult{}, fmt.Errorf("%s: %w", opn, err)
+	}
+
+	if entry := c.outboxEntry(ctx, changes); entry != nil && notify {
+		if err = update.Enqueue(ctx, entry); err != nil {
+			return checkResult{}, fmt.Errorf("%s: failed to queue changes: %w", opn, err)
+		}
+	}
+	if err = update.Commit(ctx, pageHash); err != nil {
+		return checkResult{}, fmt.Errorf("%s: failed to update state in repository: %w", opn, err)
+	}
+	log.InfoContext(ctx, "Successfully updated state in repository", "skipped", skipped)
+	result := checkResult{changes: &models.Changes{}, productsChanged: changes.HasChanges(), skipped: skipped}
+	if notify {
+		result.changes = changes
+	}
+
+	return result, nil
+}
+
+// diffProducts streams the products of the page into the state update and collects the changes.
+// Like parse, it drops the rows failing validation and names the variants of a repeated model. It also
+// returns the number of products the update skipped, their changes are left out.
+func (c *Checker) diffProducts(
+	ctx context.Context,
+	log *slog.Logger,
+	body []byte,
+	update sqlite.StateUpdate,
+) (*models.Changes, int, error) {
+	var report models.ParseReport
+	var changes models.Changes
+	stored, skipped := 0, 0
+
+	// The products listed before on the page are the ones already put in the update.
+	listed := func(key productKey) (models.Product, bool, error) {
+		seen, err := update.Seen(ctx, key.category, key.model)
+		if err != nil || !seen {
+			return models.Product{}, false, err
+		}
+		product, err := update.Product(ctx, key.category, key.model)
+		if err != nil || product == nil {
+			return models.Product{}, false, err
+		}
+		return *product, true, nil
+	}
+
+	// put stores the product in the update unless it repeats a listed one, and collects its change.
+	put := func(p models.Product) error {
+		product, keep, repeated, err := variantOf(p, listed)
+		if err != nil {
+			return err
+		}
+		if repeated {
+			report.Duplicates++
+		}
+		if !keep {
+			return nil
+		}
+
+		old, err := update.Product(ctx, product.Category, product.Model)
+		if err != nil {
+			return err
+		}
+		stored++
+		reason, err := update.Put(ctx, product)
+		if err != nil {
+			return err
+		}
+		// The skipped product keeps its stored version, its change is detected again once the page changes.
+		if reason != "" {
+			log.WarnContext(ctx, "Product was skipped by the state update", "model", product.Model,
+				"category", product.Category, "reason", reason)
+			skipped++
+			return nil
+		}
+
+		switch {
+		case old == nil:
+			changes.Added = append(changes.Added, product)
+		case product.Updated(*old):
+			changes.Changed = append(changes.Changed, models.ChangeInfo{Old: *old, New: product})
+		}
+		return nil
+	}
+
+	err := c.streamParser.StreamTableResponse(ctx, bytes.NewReader(body), func(p models.Product) error {
+		if err := p.Validate(); err != nil {
+			log.DebugContext(ctx, "Skipping invalid row", "model", p.Model, "category", p.Category, "error", err)
+			report.Invalid++
+			return nil
+		}
+		report.Valid++
+
+		kept, err := c.hooks.PostParse(ctx, []models.Product{p})
+		if err != nil {
+			return err //nolint:wrapcheck // the hook error is wrapped below.
+		}
+		for _, product := range kept {
+			if err = put(product); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse products from new response: %w", err)
+	}
+	if err = c.checkReport(ctx, log, report); err != nil {
+		return nil, 0, err
+	}
+	// The hooks can't drop all the products, see postParse.
+	if stored == 0 {
+		return nil, 0, ErrParseEmpty
+	}
+	log.InfoContext(ctx, "Successfully parsed products", "count", stored)
+
+	if changes.Removed, err = update.RemoveUnseen(ctx); err != nil {
+		return nil, 0, fmt.Errorf("failed to remove missing products: %w", err)
+	}
+	if c.fuzzyThreshold > 0 && len(changes.Removed) > 0 && len(changes.Added) > 0 {
+		changes.Renamed, changes.Removed, changes.Added = matchRenamed(changes.Removed, changes.Added, c.fuzzyThreshold)
+	}
+
+	return &changes, skipped, nil
+}
@@ -8,18 +8,154 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"time"
 
+	"github.com/Houeta/chrono-flow/internal/events"
 	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/normalize"
 	"github.com/Houeta/chrono-flow/internal/parser"
+	"github.com/Houeta/chrono-flow/internal/pipeline"
 	"github.com/Houeta/chrono-flow/internal/repository"
 	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
 )
 
+// ErrParseEmpty is returned when the page was parsed but no products were found,
+// which almost always means the page is broken rather than the catalog being empty.
+var ErrParseEmpty = errors.New("no products found on the page")
+
+// ErrTooManyInvalidRows is returned when the share of rows failing validation exceeds the limit,
+// the state isn't updated in that case to avoid reporting a broken page as mass changes.
+var ErrTooManyInvalidRows = errors.New("too many invalid rows on the page")
+
 // Checker is an orchestrator that performs a full verification cycle.
 type Checker struct {
 	log    *slog.Logger
 	parser parser.HTMLParser
 	repo   sqlite.StateRepository
+
+	// fuzzyThreshold is the minimal model similarity for pairing a removed and an added
+	// product into a rename. Zero disables fuzzy matching.
+	fuzzyThreshold float64
+
+	// maxInvalidRatio is the maximal share of invalid rows (0..1) a page may contain to be accepted.
+	maxInvalidRatio float64
+
+	// fetchObserver is notified about every fetch of the target page.
+	fetchObserver FetchObserver
+
+	// parseObserver is notified about the quality of every parsed page.
+	parseObserver ParseObserver
+
+	// runObserver is notified about the fingerprints of every successful check.
+	runObserver RunObserver
+
+	// failureObserver is notified about the class of every failed check.
+	failureObserver FailureObserver
+
+	// streamRepo and streamParser check the page product by product, see WithBoundedMemory.
+	streamRepo   sqlite.StreamingStateRepository
+	streamParser parser.StreamParser
+
+	// outbox queues the detected changes with the state, see WithOutbox.
+	outbox bool
+
+	// normalizer rewrites the fetched page before it's hashed and parsed, nil uses it as it's fetched.
+	normalizer *normalize.Normalizer
+
+	// assertions are the sanity checks of the fetched page, see WithPageAssertions.
+	assertions models.PageAssertions
+
+	// hooks are the callbacks taking part in the checks, see WithHooks.
+	hooks *pipeline.Hooks
+
+	// pageDiffs keeps the diffs of the pages of the last pageDiffRuns runs, see WithPageDiffs.
+	pageDiffs    sqlite.PageDiffRepository
+	pageDiffRuns int
+}
+
+// FetchObserver is called after every fetch of the target page with its latency and error, if any.
+type FetchObserver func(ctx context.Context, latency time.Duration, err error)
+
+// ParseObserver is called after every parse of the target page with the row validation report.
+type ParseObserver func(ctx context.Context, report models.ParseReport)
+
+// RunObserver is called after every successful check with the fingerprints of the page and its changes.
+type RunObserver func(ctx context.Context, run models.RunRecord)
+
+// FailureObserver is called after every failed check with the class of its error, see ErrorClass.
+type FailureObserver func(ctx context.Context, class string, err error)
+
+// Option configures optional Checker behavior.
+type Option func(*Checker)
+
+// WithFuzzyThreshold enables fuzzy matching of renamed models with the given similarity threshold (0..1].
+func WithFuzzyThreshold(threshold float64) Option {
+	return func(c *Checker) {
+		c.fuzzyThreshold = threshold
+	}
+}
+
+// WithFetchObserver registers an observer notified about every fetch of the target page.
+func WithFetchObserver(observer FetchObserver) Option {
+	return func(c *Checker) {
+		c.fetchObserver = observer
+	}
+}
+
+// WithMaxInvalidRatio aborts the check when the share of invalid rows exceeds ratio (0..1).
+func WithMaxInvalidRatio(ratio float64) Option {
+	return func(c *Checker) {
+		c.maxInvalidRatio = ratio
+	}
+}
+
+// WithParseObserver registers an observer notified about the quality of every parsed page.
+func WithParseObserver(observer ParseObserver) Option {
+	return func(c *Checker) {
+		c.parseObserver = observer
+	}
+}
+
+// WithRunObserver registers an observer notified about the fingerprints of every successful check.
+// The run ID of the record comes from the context, see events.WithRunID.
+func WithRunObserver(observer RunObserver) Option {
+	return func(c *Checker) {
+		c.runObserver = observer
+	}
+}
+
+// WithFailureObserver registers an observer notified about the class of every failed check.
+func WithFailureObserver(observer FailureObserver) Option {
+	return func(c *Checker) {
+		c.failureObserver = observer
+	}
+}
+
+// WithOutbox queues the detected changes for delivery in the transaction saving the state, so a process
+// stopping right after the check doesn't lose them. The run ID of the entry comes from the context,
+// see events.WithRunID.
+func WithOutbox() Option {
+	return func(c *Checker) {
+		c.outbox = true
+	}
+}
+
+// WithNormalizer normalizes the fetched page before it's hashed and parsed, so markup churn that doesn't
+// change the products, e.g. scripts with session tokens, doesn't defeat the comparison of the page hashes.
+// A nil normalizer uses the page as it's fetched.
+func WithNormalizer(normalizer *normalize.Normalizer) Option {
+	return func(c *Checker) {
+		c.normalizer = normalizer
+	}
+}
+
+// WithPageAssertions checks the fetched page before its products are compared with the stored ones. A page
+// failing them, e.g. a maintenance page served with 200 OK, fails the check with models.ErrPageAssertionFailed
+// and leaves the state as it is, the caller is expected to skip the run rather than to count it as a failure.
+func WithPageAssertions(assertions models.PageAssertions) Option {
+	return func(c *Checker) {
+		c.assertions = assertions
+	}
 }
 
 type Interface interface {
@@ -28,48 +164,118 @@ type Interface interface {
 }
 
 // NewChecker creates a new Checker instance.
-func NewChecker(log *slog.Logger, parser parser.HTMLParser, repo sqlite.StateRepository) *Checker {
-	return &Checker{log: log, parser: parser, repo: repo}
+func NewChecker(log *slog.Logger, parser parser.HTMLParser, repo sqlite.StateRepository, opts ...Option) *Checker {
+	c := &Checker{
+		log:             log,
+		parser:          parser,
+		repo:            repo,
+		fetchObserver:   func(context.Context, time.Duration, error) {},
+		parseObserver:   func(context.Context, models.ParseReport) {},
+		runObserver:     func(context.Context, models.RunRecord) {},
+		failureObserver: func(context.Context, string, error) {},
+		maxInvalidRatio: 1,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
-// CheckForUpdates performs the full change checking algorithm.
+// CheckForUpdates performs the full change checking algorithm. The class of its error is told by
+// ErrorClass.
 func (c *Checker) CheckForUpdates(ctx context.Context) (*models.Changes, error) {
+	changes, err := c.checkForUpdates(ctx)
+	if err != nil {
+		c.failureObserver(ctx, ErrorClass(err), err)
+	}
+
+	return changes, err
+}
+
+// checkForUpdates fetches the page and detects its changes, see CheckForUpdates.
+func (c *Checker) checkForUpdates(ctx context.Context) (*models.Changes, error) {
 	const opn = "checker.CheckForUpdates"
 	log := c.log.With("op", opn)
 
 	// 1. Retrieving HTML and calculating a new hash
 	log.InfoContext(ctx, "Fetching HTML page to check for updates")
-	resp, err := c.parser.GetHTMLResponse(ctx)
+	body, err := c.fetch(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("%s: failed to get html response: %w", opn, err)
+		return nil, fmt.Errorf("%s: %w", opn, err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("%s: failed to read response body: %w", opn, err)
+	if err = c.assertions.CheckContent(body); err != nil {
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+	if c.normalizer != nil {
+		if body, err = c.normalizer.Normalize(body); err != nil {
+			return nil, fmt.Errorf("%s: %w", opn, err)
+		}
 	}
 
 	newPageHash := calculateHash(body)
 	log.DebugContext(ctx, "Calculated new page hash", "hash", newPageHash)
 
+	var result checkResult
+	if c.streamRepo != nil {
+		result, err = c.checkBounded(ctx, log, body, newPageHash)
+	} else {
+		result, err = c.checkInMemory(ctx, log, body, newPageHash)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.pageDiffs != nil {
+		c.recordPageDiff(ctx, log, body, newPageHash, result.productsChanged)
+	}
+
+	c.runObserver(ctx, models.RunRecord{
+		RunID:     events.RunIDFromContext(ctx),
+		CheckedAt: time.Now(),
+		PageHash:  newPageHash,
+		DiffHash:  result.changes.Fingerprint(),
+		Skipped:   result.skipped,
+	})
+
+	return result.changes, nil
+}
+
+// checkResult is the outcome of the analysis of a fetched page.
+type checkResult struct {
+	// changes are the changes to notify, empty if a pre-notify hook vetoed them.
+	changes *models.Changes
+	// productsChanged is set if products changed, whether their changes are notified or not.
+	productsChanged bool
+	// skipped is the number of products the state update failed to store, see models.State.Skipped.
+	skipped int
+}
+
+// checkInMemory detects the changes of the fetched page with the given hash against the stored state.
+func (c *Checker) checkInMemory(
+	ctx context.Context,
+	log *slog.Logger,
+	body []byte,
+	newPageHash string,
+) (checkResult, error) {
+	const opn = "checker.CheckForUpdates"
+
 	// 2. Getting the old state from the database
 	oldState, err := c.repo.GetState(ctx)
 	if err != nil && !errors.Is(err, repository.ErrStateNotFound) {
-		return nil, fmt.Errorf("%s: failed to get old state: %w", opn, err)
+		return checkResult{}, fmt.Errorf("%s: failed to get old state: %w", opn, err)
 	}
 
 	// 3. Hash comparison
 	if err == nil && oldState.PageHash == newPageHash {
 		log.InfoContext(ctx, "Page hash has not changed. No updates.")
-		return &models.Changes{}, nil
+		return checkResult{changes: &models.Changes{}}, nil
 	}
 	log.InfoContext(ctx, "Page hash differs or first run. Starting full analysis...")
 
 	// 4. Full page parsing
-	newProducts, err := c.parser.ParseTableResponse(ctx, io.NopCloser(bytes.NewReader(body)))
+	newProducts, err := c.parse(ctx, log, body)
 	if err != nil {
-		return nil, fmt.Errorf("%s: failed to parse products from new response: %w", opn, err)
+		return checkResult{}, fmt.Errorf("%s: %w", opn, err)
 	}
 	log.InfoContext(ctx, "Successfully parsed products", "count", len(newProducts))
 
@@ -78,7 +284,116 @@ func (c *Checker) CheckForUpdates(ctx context.Context) (*models.Changes, error)
 	if oldState != nil {
 		oldProducts = oldState.Products
 	}
-	changes := detectChanges(oldProducts, newProducts)
+	changes := detectChanges(oldProducts, newProducts, c.fuzzyThreshold)
+	logChanges(ctx, log, &changes)
+	notify, err := c.preNotify(ctx, log, &changes)
+	if err != nil {
+		return checkResult{}, fmt.Errorf("%s: %w", opn, err)
+	}
+
+	// 6. Updating the database and returning the result
+	newState := &models.State{
+		PageHash: newPageHash,
+		Products: newProducts,
+	}
+	if notify {
+		newState.Outbox = c.outboxEntry(ctx, &changes)
+	}
+
+	if err = c.repo.UpdateState(ctx, newState); err != nil {
+		return checkResult{}, fmt.Errorf("%s: failed to update state in repository: %w", opn, err)
+	}
+	// The skipped products keep their stored version, their changes are detected again once the page changes.
+	for _, skipped := range newState.Skipped {
+		log.WarnContext(ctx, "Product was skipped by the state update", "model", skipped.Product.Model,
+			"category", skipped.Product.Category, "reason", skipped.Reason)
+	}
+	log.InfoContext(ctx, "Successfully updated state in repository", "skipped", len(newState.Skipped))
+	result := checkResult{
+		changes:         &models.Changes{},
+		productsChanged: changes.HasChanges(),
+		skipped:         len(newState.Skipped),
+	}
+	if notify {
+		result.changes = newState.WithoutSkipped(&changes)
+	}
+
+	return result, nil
+}
+
+// outboxEntry returns the entry queuing the changes of the run carried by the context, nil if the
+// changes aren't queued or there are none.
+func (c *Checker) outboxEntry(ctx context.Context, changes *models.Changes) *models.OutboxEntry {
+	if !c.outbox || !changes.HasChanges() {
+		return nil
+	}
+
+	return &models.OutboxEntry{RunID: events.RunIDFromContext(ctx), DetectedAt: time.Now(), Changes: changes}
+}
+
+// Probe fetches the target page without parsing it or touching the stored state. It keeps
+// tracking the availability of the target while its content can't be trusted.
+func (c *Checker) Probe(ctx context.Context) error {
+	if _, err := c.fetch(ctx); err != nil {
+		return fmt.Errorf("checker.Probe: %w", err)
+	}
+
+	return nil
+}
+
+// parse extracts the products from the page body and drops the rows failing validation.
+// It fails if the page has no valid products or too many invalid rows.
+func (c *Checker) parse(ctx context.Context, log *slog.Logger, body []byte) ([]models.Product, error) {
+	parsed, err := c.parser.ParseTableResponse(ctx, io.NopCloser(bytes.NewReader(body)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse products from new response: %w", err)
+	}
+
+	var report models.ParseReport
+	products := make([]models.Product, 0, len(parsed))
+	for _, p := range parsed {
+		if err = p.Validate(); err != nil {
+			log.DebugContext(ctx, "Skipping invalid row", "model", p.Model, "category", p.Category, "error", err)
+			report.Invalid++
+			continue
+		}
+		report.Valid++
+		products = append(products, p)
+	}
+	products, report.Duplicates = dedupeProducts(products)
+	if err = c.checkReport(ctx, log, report); err != nil {
+		return nil, err
+	}
+
+	return c.postParse(ctx, products)
+}
+
+// checkReport notifies the parse observer about the quality of the parsed page. It fails if the page
+// has fewer rows than asserted, no valid products or too many invalid rows.
+func (c *Checker) checkReport(ctx context.Context, log *slog.Logger, report models.ParseReport) error {
+	c.parseObserver(ctx, report)
+	if err := c.assertions.CheckRows(report.Total()); err != nil {
+		return err //nolint:wrapcheck // the check wraps the error.
+	}
+
+	if report.Invalid > 0 {
+		log.WarnContext(ctx, "Page contains invalid rows", "valid", report.Valid, "invalid", report.Invalid)
+	}
+	if report.Duplicates > 0 {
+		log.WarnContext(ctx, "Page lists some models more than once", "duplicates", report.Duplicates)
+	}
+	if report.InvalidRatio() > c.maxInvalidRatio {
+		return fmt.Errorf("%w: %d of %d", ErrTooManyInvalidRows, report.Invalid, report.Total())
+	}
+	if report.Valid == 0 {
+		return ErrParseEmpty
+	}
+
+	return nil
+}
+
+// logChanges logs the number of detected changes by kind.
+func logChanges(ctx context.Context, log *slog.Logger, changes *models.Changes) {
 	log.InfoContext(
 		ctx,
 		"Change detection complete",
@@ -88,20 +403,37 @@ func (c *Checker) CheckForUpdates(ctx context.Context) (*models.Changes, error)
 		len(changes.Removed),
 		"changed",
 		len(changes.Changed),
+		"renamed",
+		len(changes.Renamed),
 	)
+}
 
-	// 6. Updating the database and returning the result
-	newState := &models.State{
-		PageHash: newPageHash,
-		Products: newProducts,
+// fetch downloads the target page and reports the outcome to the fetch observer.
+func (c *Checker) fetch(ctx context.Context) ([]byte, error) {
+	start := time.Now()
+	body, err := c.download(ctx)
+	// A fetch vetoed by a hook says nothing about the availability of the target.
+	if !errors.Is(err, pipeline.ErrVeto) {
+		c.fetchObserver(ctx, time.Since(start), err)
 	}
 
-	if err = c.repo.UpdateState(ctx, newState); err != nil {
-		return nil, fmt.Errorf("%s: failed to update state in repository: %w", opn, err)
+	return body, err
+}
+
+// download returns the body of the target page.
+func (c *Checker) download(ctx context.Context) ([]byte, error) {
+	resp, err := c.parser.GetHTMLResponse(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get html response: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read response body: %w", parser.ErrFetchFailed, err)
 	}
-	log.InfoContext(ctx, "Successfully updated state in repository")
 
-	return &changes, nil
+	return body, nil
 }
 
 // calculateHash calculates the SHA256 hash for a slice of bytes.
@@ -109,26 +441,93 @@ func calculateHash(data []byte) string {
 	return fmt.Sprintf("%x", sha256.Sum256(data))
 }
 
+// productKey identifies a product on the page: the same model may be listed in several tables.
+type productKey struct {
+	category string
+	model    string
+}
+
+// keyOf returns the key a product is compared by between checks.
+func keyOf(p models.Product) productKey {
+	return productKey{category: p.Category, model: p.Model}
+}
+
+// dedupeProducts makes every product identifiable by its category and model, see variantOf.
+// It returns the number of rows repeating a listed model.
+func dedupeProducts(products []models.Product) ([]models.Product, int) {
+	listed := make(map[productKey]models.Product, len(products))
+	lookup := func(key productKey) (models.Product, bool, error) {
+		p, found := listed[key]
+		return p, found, nil
+	}
+	unique := make([]models.Product, 0, len(products))
+	duplicates := 0
+
+	for _, p := range products {
+		variant, keep, repeated, _ := variantOf(p, lookup) // the lookup never fails
+		if repeated {
+			duplicates++
+		}
+		if keep {
+			listed[keyOf(variant)] = variant
+			unique = append(unique, variant)
+		}
+	}
+
+	return unique, duplicates
+}
+
+// listedFunc returns the product already listed on the page under the key, if any.
+type listedFunc func(key productKey) (models.Product, bool, error)
+
+// variantOf names a product after the rows of the page listed before it. A product with a new model
+// is kept as is and a row repeating a listed one entirely is dropped. Variants listing the same model
+// with other details get the first free " #2", " #3"... suffix, so they are matched between checks
+// as long as the page keeps its order. It reports whether the product is kept and whether the row
+// repeats a listed model.
+func variantOf(p models.Product, listed listedFunc) (models.Product, bool, bool, error) {
+	variant := p
+	for n := 1; ; n++ {
+		if n > 1 {
+			variant.Model = fmt.Sprintf("%s #%d", p.Model, n)
+		}
+
+		stored, found, err := listed(keyOf(variant))
+		if err != nil {
+			return models.Product{}, false, false, err
+		}
+		if !found {
+			return variant, true, n > 1, nil
+		}
+		if stored.Model = p.Model; stored.Equal(p) {
+			return models.Product{}, false, true, nil
+		}
+	}
+}
+
 // detectChanges compares two product lists and finds the difference.
-func detectChanges(oldProducts, newProducts []models.Product) models.Changes {
-	oldMap := make(map[string]models.Product, len(oldProducts))
+// Products are matched by their category and model, so a model moving between tables is reported as
+// removed from one and added to the other.
+// If fuzzyThreshold is positive, removed and added products with similar models are reported as renamed.
+func detectChanges(oldProducts, newProducts []models.Product, fuzzyThreshold float64) models.Changes {
+	oldMap := make(map[productKey]models.Product, len(oldProducts))
 	for _, p := range oldProducts {
-		oldMap[p.Model] = p
+		oldMap[keyOf(p)] = p
 	}
 
-	newMap := make(map[string]models.Product, len(newProducts))
+	newMap := make(map[productKey]models.Product, len(newProducts))
 	for _, p := range newProducts {
-		newMap[p.Model] = p
+		newMap[keyOf(p)] = p
 	}
 
 	var changes models.Changes
-	for newModel, newProduct := range newMap {
-		oldProduct, found := oldMap[newModel]
+	for newKey, newProduct := range newMap {
+		oldProduct, found := oldMap[newKey]
 		if found {
-			if newProduct.Price != oldProduct.Price || newProduct.Quantity != oldProduct.Quantity {
+			if newProduct.Updated(oldProduct) {
 				changes.Changed = append(changes.Changed, models.ChangeInfo{Old: oldProduct, New: newProduct})
 			}
-			delete(oldMap, newModel)
+			delete(oldMap, newKey)
 		} else {
 			changes.Added = append(changes.Added, newProduct)
 		}
@@ -137,5 +536,10 @@ func detectChanges(oldProducts, newProducts []models.Product) models.Changes {
 	for _, removedProduct := range oldMap {
 		changes.Removed = append(changes.Removed, removedProduct)
 	}
+
+	if fuzzyThreshold > 0 && len(changes.Removed) > 0 && len(changes.Added) > 0 {
+		changes.Renamed, changes.Removed, changes.Added = matchRenamed(changes.Removed, changes.Added, fuzzyThreshold)
+	}
+
 	return changes
 }
@@ -2,18 +2,30 @@ package checker_test
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
+	"database/sql"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Houeta/chrono-flow/internal/events"
 	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/normalize"
+	"github.com/Houeta/chrono-flow/internal/parser"
+	"github.com/Houeta/chrono-flow/internal/pipeline"
 	"github.com/Houeta/chrono-flow/internal/repository"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
 	"github.com/Houeta/chrono-flow/internal/services/checker"
 	"github.com/Houeta/chrono-flow/test/mocks"
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -68,6 +80,32 @@ func TestChecker_CheckForUpdates(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "Skipped product: Its change is left out",
+			setupMocks: func(mParser *mocks.HTMLParser, mRepo *mocks.StateRepository) {
+				mockHTTPResponse := &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader([]byte(`<html><body>new content</body></html>`))),
+				}
+				mParser.On("GetHTMLResponse", ctx).Return(mockHTTPResponse, nil).Once()
+				mRepo.On("GetState", ctx).Return(oldState, nil).Once()
+
+				newProducts := []models.Product{product1New, product3}
+				mParser.On("ParseTableResponse", ctx, mock.Anything).Return(newProducts, nil).Once()
+
+				mRepo.On("UpdateState", ctx, mock.AnythingOfType("*models.State")).
+					Run(func(args mock.Arguments) {
+						state, _ := args.Get(1).(*models.State)
+						state.Skipped = []models.SkippedProduct{{Product: product1New, Reason: "insert failed"}}
+					}).
+					Return(nil).Once()
+			},
+			expectedChanges: &models.Changes{
+				Added:   []models.Product{product3},
+				Removed: []models.Product{product2},
+			},
+			expectError: false,
+		},
 		{
 			name: "No change: The page hash has not changed.",
 			setupMocks: func(mParser *mocks.HTMLParser, mRepo *mocks.StateRepository) {
@@ -173,6 +211,23 @@ func TestChecker_CheckForUpdates(t *testing.T) {
 			expectedChanges: nil,
 			expectError:     true,
 		},
+		{
+			name: "Error: parser returns no products",
+			setupMocks: func(mParser *mocks.HTMLParser, mRepo *mocks.StateRepository) {
+				newHTML := `<html><body>maintenance</body></html>`
+				mockHTTPResponse := &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader([]byte(newHTML))),
+				}
+				mParser.On("GetHTMLResponse", ctx).Return(mockHTTPResponse, nil).Once()
+
+				mRepo.On("GetState", ctx).Return(oldState, nil).Once()
+
+				mParser.On("ParseTableResponse", ctx, mock.Anything).Return([]models.Product{}, nil).Once()
+			},
+			expectedChanges: nil,
+			expectError:     true,
+		},
 		{
 			name: "Error: failed to read response body",
 			setupMocks: func(mParser *mocks.HTMLParser, _ *mocks.StateRepository) {
@@ -208,3 +263,944 @@ func TestChecker_CheckForUpdates(t *testing.T) {
 		})
 	}
 }
+
+func TestChecker_CheckForUpdates_FuzzyMatching(t *testing.T) {
+	ctx := t.Context()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	oldState := &models.State{
+		PageHash: "hash_old",
+		Products: []models.Product{
+			{Model: "Casio GA-2100-1A", Price: "100"},
+			{Model: "Seiko SRPD55", Price: "300"},
+		},
+	}
+	newProducts := []models.Product{
+		{Model: "Casio GA-2100-1A1", Price: "110"},
+		{Model: "Orient Bambino", Price: "200"},
+	}
+
+	testCases := []struct {
+		name            string
+		opts            []checker.Option
+		expectedChanges *models.Changes
+	}{
+		{
+			name: "Disabled: rename shows up as removed and added",
+			opts: nil,
+			expectedChanges: &models.Changes{
+				Added:   newProducts,
+				Removed: oldState.Products,
+			},
+		},
+		{
+			name: "Enabled: similar models are paired as renamed",
+			opts: []checker.Option{checker.WithFuzzyThreshold(0.85)},
+			expectedChanges: &models.Changes{
+				Added:   []models.Product{newProducts[1]},
+				Removed: []models.Product{oldState.Products[1]},
+				Renamed: []models.ChangeInfo{{Old: oldState.Products[0], New: newProducts[0]}},
+			},
+		},
+		{
+			name: "Enabled: threshold too strict to pair anything",
+			opts: []checker.Option{checker.WithFuzzyThreshold(0.99)},
+			expectedChanges: &models.Changes{
+				Added:   newProducts,
+				Removed: oldState.Products,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockParser := mocks.NewHTMLParser(t)
+			mockRepo := mocks.NewStateRepository(t)
+
+			mockHTTPResponse := &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`<html><body>renamed</body></html>`)),
+			}
+			mockParser.On("GetHTMLResponse", ctx).Return(mockHTTPResponse, nil).Once()
+			mockRepo.On("GetState", ctx).Return(oldState, nil).Once()
+			mockParser.On("ParseTableResponse", ctx, mock.Anything).Return(newProducts, nil).Once()
+			mockRepo.On("UpdateState", ctx, mock.AnythingOfType("*models.State")).Return(nil).Once()
+
+			changes, err := checker.NewChecker(logger, mockParser, mockRepo, tc.opts...).CheckForUpdates(ctx)
+
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tc.expectedChanges.Added, changes.Added)
+			assert.ElementsMatch(t, tc.expectedChanges.Removed, changes.Removed)
+			assert.ElementsMatch(t, tc.expectedChanges.Renamed, changes.Renamed)
+			assert.Empty(t, changes.Changed)
+		})
+	}
+}
+
+func TestChecker_CheckForUpdates_Categories(t *testing.T) {
+	ctx := t.Context()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	newStock := models.Product{Model: "Seiko SRPD55", Price: "300", Category: "new"}
+	usedOld := models.Product{Model: "Seiko SRPD55", Price: "200", Category: "used"}
+	usedNew := models.Product{Model: "Seiko SRPD55", Price: "180", Category: "used"}
+	movedOld := models.Product{Model: "Casio GA-2100-1A", Price: "100", Category: "new"}
+	movedNew := models.Product{Model: "Casio GA-2100-1A1", Price: "100", Category: "incoming"}
+
+	mockParser := mocks.NewHTMLParser(t)
+	mockRepo := mocks.NewStateRepository(t)
+
+	mockHTTPResponse := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewBufferString(`<html><body>tables</body></html>`)),
+	}
+	mockParser.On("GetHTMLResponse", ctx).Return(mockHTTPResponse, nil).Once()
+	mockRepo.On("GetState", ctx).Return(&models.State{
+		PageHash: "hash_old",
+		Products: []models.Product{newStock, usedOld, movedOld},
+	}, nil).Once()
+	mockParser.On("ParseTableResponse", ctx, mock.Anything).
+		Return([]models.Product{newStock, usedNew, movedNew}, nil).Once()
+	mockRepo.On("UpdateState", ctx, mock.AnythingOfType("*models.State")).Return(nil).Once()
+
+	changes, err := checker.NewChecker(logger, mockParser, mockRepo, checker.WithFuzzyThreshold(0.85)).
+		CheckForUpdates(ctx)
+
+	// The same model in different tables is tracked separately, and renames never cross tables.
+	require.NoError(t, err)
+	assert.Equal(t, []models.ChangeInfo{{Old: usedOld, New: usedNew}}, changes.Changed)
+	assert.Equal(t, []models.Product{movedNew}, changes.Added)
+	assert.Equal(t, []models.Product{movedOld}, changes.Removed)
+	assert.Empty(t, changes.Renamed)
+}
+
+func TestChecker_CheckForUpdates_Outbox(t *testing.T) {
+	ctx := events.WithRunID(t.Context(), "run-1")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	oldState := &models.State{PageHash: "hash_old", Products: []models.Product{{Model: "A1", Price: "100"}}}
+	check := func(t *testing.T, products []models.Product, opts ...checker.Option) *models.State {
+		t.Helper()
+
+		mockParser := mocks.NewHTMLParser(t)
+		mockRepo := mocks.NewStateRepository(t)
+		mockParser.On("GetHTMLResponse", ctx).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(`<html><body>new</body></html>`)),
+		}, nil).Once()
+		mockRepo.On("GetState", ctx).Return(oldState, nil).Once()
+		mockParser.On("ParseTableResponse", ctx, mock.Anything).Return(products, nil).Once()
+		var saved *models.State
+		mockRepo.On("UpdateState", ctx, mock.MatchedBy(func(state *models.State) bool {
+			saved = state
+			return true
+		})).Return(nil).Once()
+
+		_, err := checker.NewChecker(logger, mockParser, mockRepo, opts...).CheckForUpdates(ctx)
+		require.NoError(t, err)
+
+		return saved
+	}
+
+	t.Run("changes are queued with the state of the run", func(t *testing.T) {
+		state := check(t, []models.Product{{Model: "A1", Price: "90"}}, checker.WithOutbox())
+
+		require.NotNil(t, state.Outbox)
+		assert.Equal(t, "run-1", state.Outbox.RunID)
+		assert.Len(t, state.Outbox.Changes.Changed, 1)
+	})
+
+	t.Run("nothing is queued without changes", func(t *testing.T) {
+		state := check(t, oldState.Products, checker.WithOutbox())
+
+		assert.Nil(t, state.Outbox)
+	})
+
+	t.Run("nothing is queued without the outbox", func(t *testing.T) {
+		state := check(t, []models.Product{{Model: "A1", Price: "90"}})
+
+		assert.Nil(t, state.Outbox)
+	})
+}
+
+func TestChecker_CheckForUpdates_RunObserver(t *testing.T) {
+	ctx := events.WithRunID(t.Context(), "run-1")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	body := `<html><body>page</body></html>`
+	pageHash := fmt.Sprintf("%x", sha256.Sum256([]byte(body)))
+	check := func(t *testing.T, mockRepo *mocks.StateRepository, mockParser *mocks.HTMLParser) []models.RunRecord {
+		t.Helper()
+
+		mockParser.On("GetHTMLResponse", ctx).
+			Return(&http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(body))}, nil).
+			Once()
+		var runs []models.RunRecord
+		observer := func(_ context.Context, run models.RunRecord) { runs = append(runs, run) }
+
+		_, err := checker.NewChecker(logger, mockParser, mockRepo, checker.WithRunObserver(observer)).
+			CheckForUpdates(ctx)
+		require.NoError(t, err)
+
+		return runs
+	}
+
+	t.Run("run with changes", func(t *testing.T) {
+		mockParser := mocks.NewHTMLParser(t)
+		mockRepo := mocks.NewStateRepository(t)
+		mockRepo.On("GetState", ctx).Return(nil, repository.ErrStateNotFound).Once()
+		mockParser.On("ParseTableResponse", ctx, mock.Anything).
+			Return([]models.Product{{Model: "A1", Price: "100"}}, nil).Once()
+		mockRepo.On("UpdateState", ctx, mock.AnythingOfType("*models.State")).Return(nil).Once()
+
+		runs := check(t, mockRepo, mockParser)
+
+		require.Len(t, runs, 1)
+		assert.Equal(t, "run-1", runs[0].RunID)
+		assert.Equal(t, pageHash, runs[0].PageHash)
+		changes := &models.Changes{Added: []models.Product{{Model: "A1", Price: "100"}}}
+		assert.Equal(t, changes.Fingerprint(), runs[0].DiffHash)
+		assert.NotEmpty(t, runs[0].DiffHash)
+	})
+
+	t.Run("run without changes", func(t *testing.T) {
+		mockParser := mocks.NewHTMLParser(t)
+		mockRepo := mocks.NewStateRepository(t)
+		mockRepo.On("GetState", ctx).Return(&models.State{PageHash: pageHash}, nil).Once()
+
+		runs := check(t, mockRepo, mockParser)
+
+		require.Len(t, runs, 1)
+		assert.Equal(t, pageHash, runs[0].PageHash)
+		assert.Empty(t, runs[0].DiffHash)
+	})
+
+	t.Run("run with skipped products", func(t *testing.T) {
+		mockParser := mocks.NewHTMLParser(t)
+		mockRepo := mocks.NewStateRepository(t)
+		mockRepo.On("GetState", ctx).Return(nil, repository.ErrStateNotFound).Once()
+		mockParser.On("ParseTableResponse", ctx, mock.Anything).
+			Return([]models.Product{{Model: "A1", Price: "100"}, {Model: "B2", Price: "200"}}, nil).Once()
+		mockRepo.On("UpdateState", ctx, mock.AnythingOfType("*models.State")).
+			Run(func(args mock.Arguments) {
+				state, _ := args.Get(1).(*models.State)
+				state.Skipped = []models.SkippedProduct{{Product: state.Products[1], Reason: "insert failed"}}
+			}).
+			Return(nil).Once()
+
+		runs := check(t, mockRepo, mockParser)
+
+		require.Len(t, runs, 1)
+		assert.Equal(t, 1, runs[0].Skipped)
+	})
+}
+
+func TestChecker_CheckForUpdates_FetchObserver(t *testing.T) {
+	ctx := t.Context()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("successful fetch", func(t *testing.T) {
+		mockParser := mocks.NewHTMLParser(t)
+		mockRepo := mocks.NewStateRepository(t)
+
+		body := `<html><body>same</body></html>`
+		mockHTTPResponse := &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(body))}
+		mockParser.On("GetHTMLResponse", ctx).Return(mockHTTPResponse, nil).Once()
+		mockRepo.On("GetState", ctx).
+			Return(&models.State{PageHash: fmt.Sprintf("%x", sha256.Sum256([]byte(body)))}, nil).Once()
+
+		var observed []error
+		observer := func(_ context.Context, _ time.Duration, err error) { observed = append(observed, err) }
+		updateChecker := checker.NewChecker(logger, mockParser, mockRepo, checker.WithFetchObserver(observer))
+
+		_, err := updateChecker.CheckForUpdates(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, []error{nil}, observed)
+	})
+
+	t.Run("failed fetch", func(t *testing.T) {
+		mockParser := mocks.NewHTMLParser(t)
+		mockParser.On("GetHTMLResponse", ctx).Return(nil, assert.AnError).Once()
+
+		var observed []error
+		observer := func(_ context.Context, _ time.Duration, err error) { observed = append(observed, err) }
+		updateChecker := checker.NewChecker(
+			logger, mockParser, mocks.NewStateRepository(t), checker.WithFetchObserver(observer),
+		)
+
+		_, err := updateChecker.CheckForUpdates(ctx)
+
+		require.ErrorIs(t, err, assert.AnError)
+		require.Len(t, observed, 1)
+		require.ErrorIs(t, observed[0], assert.AnError)
+	})
+}
+
+func TestChecker_Probe(t *testing.T) {
+	ctx := t.Context()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("fetches the page without touching the state", func(t *testing.T) {
+		mockParser := mocks.NewHTMLParser(t)
+		// The repository mock fails the test on any call.
+		mockRepo := mocks.NewStateRepository(t)
+		body := `<html><body>rebuilding catalog</body></html>`
+		mockHTTPResponse := &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(body))}
+		mockParser.On("GetHTMLResponse", ctx).Return(mockHTTPResponse, nil).Once()
+
+		var observed []error
+		observer := func(_ context.Context, _ time.Duration, err error) { observed = append(observed, err) }
+		updateChecker := checker.NewChecker(logger, mockParser, mockRepo, checker.WithFetchObserver(observer))
+
+		require.NoError(t, updateChecker.Probe(ctx))
+		assert.Equal(t, []error{nil}, observed)
+	})
+
+	t.Run("failed fetch", func(t *testing.T) {
+		mockParser := mocks.NewHTMLParser(t)
+		mockParser.On("GetHTMLResponse", ctx).Return(nil, assert.AnError).Once()
+		updateChecker := checker.NewChecker(logger, mockParser, mocks.NewStateRepository(t))
+
+		require.ErrorIs(t, updateChecker.Probe(ctx), assert.AnError)
+	})
+}
+
+func TestChecker_CheckForUpdates_RowValidation(t *testing.T) {
+	ctx := t.Context()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	valid := models.Product{Model: "A1", Price: "100"}
+	parsed := []models.Product{
+		valid,
+		{Model: "", Price: "100"},
+		{Model: "B2", Price: "on request"},
+	}
+
+	setup := func(t *testing.T) (*mocks.HTMLParser, *mocks.StateRepository) {
+		t.Helper()
+
+		mockParser := mocks.NewHTMLParser(t)
+		mockRepo := mocks.NewStateRepository(t)
+		mockHTTPResponse := &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(`<html><body>rows</body></html>`)),
+		}
+		mockParser.On("GetHTMLResponse", ctx).Return(mockHTTPResponse, nil).Once()
+		mockRepo.On("GetState", ctx).Return(nil, repository.ErrStateNotFound).Once()
+		mockParser.On("ParseTableResponse", ctx, mock.Anything).Return(parsed, nil).Once()
+
+		return mockParser, mockRepo
+	}
+
+	t.Run("invalid rows are dropped and reported", func(t *testing.T) {
+		mockParser, mockRepo := setup(t)
+		mockRepo.On("UpdateState", ctx, &models.State{
+			PageHash: fmt.Sprintf("%x", sha256.Sum256([]byte(`<html><body>rows</body></html>`))),
+			Products: []models.Product{valid},
+		}).Return(nil).Once()
+
+		var reports []models.ParseReport
+		observer := func(_ context.Context, report models.ParseReport) { reports = append(reports, report) }
+		updateChecker := checker.NewChecker(logger, mockParser, mockRepo, checker.WithParseObserver(observer))
+
+		changes, err := updateChecker.CheckForUpdates(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.Product{valid}, changes.Added)
+		assert.Equal(t, []models.ParseReport{{Valid: 1, Invalid: 2}}, reports)
+	})
+
+	t.Run("state is kept when the invalid ratio exceeds the limit", func(t *testing.T) {
+		mockParser, mockRepo := setup(t)
+		updateChecker := checker.NewChecker(logger, mockParser, mockRepo, checker.WithMaxInvalidRatio(0.5))
+
+		changes, err := updateChecker.CheckForUpdates(ctx)
+
+		require.ErrorIs(t, err, checker.ErrTooManyInvalidRows)
+		assert.Nil(t, changes)
+		mockRepo.AssertNotCalled(t, "UpdateState", mock.Anything, mock.Anything)
+	})
+}
+
+func TestChecker_CheckForUpdates_PageAssertions(t *testing.T) {
+	ctx := t.Context()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	products := []models.Product{{Model: "A1", Price: "100"}, {Model: "B2", Price: "200"}}
+
+	setup := func(t *testing.T, page string) (*mocks.HTMLParser, *mocks.StateRepository) {
+		t.Helper()
+
+		mockParser := mocks.NewHTMLParser(t)
+		mockRepo := mocks.NewStateRepository(t)
+		mockParser.On("GetHTMLResponse", ctx).
+			Return(&http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(page))}, nil).
+			Once()
+
+		return mockParser, mockRepo
+	}
+
+	t.Run("a page passing the assertions is checked", func(t *testing.T) {
+		mockParser, mockRepo := setup(t, `<h1>Price list</h1><table>rows</table>`)
+		mockRepo.On("GetState", ctx).Return(nil, repository.ErrStateNotFound).Once()
+		mockParser.On("ParseTableResponse", ctx, mock.Anything).Return(products, nil).Once()
+		mockRepo.On("UpdateState", ctx, mock.AnythingOfType("*models.State")).Return(nil).Once()
+		assertions := models.PageAssertions{
+			Contains: []string{"PRICE LIST"}, NotContains: []string{"maintenance mode"}, MinRows: 2,
+		}
+
+		changes, err := checker.NewChecker(logger, mockParser, mockRepo, checker.WithPageAssertions(assertions)).
+			CheckForUpdates(ctx)
+
+		require.NoError(t, err)
+		assert.ElementsMatch(t, products, changes.Added)
+	})
+
+	t.Run("a page without the marker is skipped", func(t *testing.T) {
+		mockParser, mockRepo := setup(t, `<h1>Sign in</h1>`)
+		assertions := models.PageAssertions{Contains: []string{"Price list"}}
+
+		changes, err := checker.NewChecker(logger, mockParser, mockRepo, checker.WithPageAssertions(assertions)).
+			CheckForUpdates(ctx)
+
+		require.ErrorIs(t, err, models.ErrPageAssertionFailed)
+		require.ErrorContains(t, err, `doesn't contain "Price list"`)
+		assert.Nil(t, changes)
+		mockRepo.AssertNotCalled(t, "GetState", mock.Anything)
+	})
+
+	t.Run("a maintenance page is skipped", func(t *testing.T) {
+		mockParser, mockRepo := setup(t, `<h1>Price list</h1><p>The shop is in Maintenance Mode</p>`)
+		assertions := models.PageAssertions{NotContains: []string{"maintenance mode"}}
+
+		_, err := checker.NewChecker(logger, mockParser, mockRepo, checker.WithPageAssertions(assertions)).
+			CheckForUpdates(ctx)
+
+		require.ErrorIs(t, err, models.ErrPageAssertionFailed)
+		mockParser.AssertNotCalled(t, "ParseTableResponse", mock.Anything, mock.Anything)
+	})
+
+	t.Run("state is kept when the page has fewer rows", func(t *testing.T) {
+		mockParser, mockRepo := setup(t, `<table>rows</table>`)
+		mockRepo.On("GetState", ctx).Return(nil, repository.ErrStateNotFound).Once()
+		mockParser.On("ParseTableResponse", ctx, mock.Anything).Return(products, nil).Once()
+		assertions := models.PageAssertions{MinRows: 3}
+
+		changes, err := checker.NewChecker(logger, mockParser, mockRepo, checker.WithPageAssertions(assertions)).
+			CheckForUpdates(ctx)
+
+		require.ErrorIs(t, err, models.ErrPageAssertionFailed)
+		assert.Nil(t, changes)
+		mockRepo.AssertNotCalled(t, "UpdateState", mock.Anything, mock.Anything)
+	})
+}
+
+func TestChecker_CheckForUpdates_Duplicates(t *testing.T) {
+	ctx := t.Context()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	black := models.Product{Model: "Casio GA-2100", Type: "black", Price: "100"}
+	white := models.Product{Model: "Casio GA-2100", Type: "white", Price: "110"}
+	other := models.Product{Model: "Casio GA-2100", Price: "100", Category: "used"}
+
+	mockParser := mocks.NewHTMLParser(t)
+	mockRepo := mocks.NewStateRepository(t)
+	mockHTTPResponse := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewBufferString(`<html><body>variants</body></html>`)),
+	}
+	mockParser.On("GetHTMLResponse", ctx).Return(mockHTTPResponse, nil).Once()
+	mockRepo.On("GetState", ctx).Return(nil, repository.ErrStateNotFound).Once()
+	mockParser.On("ParseTableResponse", ctx, mock.Anything).
+		Return([]models.Product{black, white, black, other}, nil).Once()
+
+	whiteVariant := white
+	whiteVariant.Model = "Casio GA-2100 #2"
+	wantProducts := []models.Product{black, whiteVariant, other}
+	mockRepo.On("UpdateState", ctx, mock.MatchedBy(func(state *models.State) bool {
+		return assert.ObjectsAreEqual(wantProducts, state.Products)
+	})).Return(nil).Once()
+
+	var reports []models.ParseReport
+	observer := func(_ context.Context, report models.ParseReport) { reports = append(reports, report) }
+	changes, err := checker.NewChecker(logger, mockParser, mockRepo, checker.WithParseObserver(observer)).
+		CheckForUpdates(ctx)
+
+	// Repeated rows are dropped, variants get a discriminator and the same model in another table is kept.
+	require.NoError(t, err)
+	assert.ElementsMatch(t, wantProducts, changes.Added)
+	assert.Equal(t, []models.ParseReport{{Valid: 4, Duplicates: 2}}, reports)
+}
+
+func TestChecker_CheckForUpdates_BoundedMemory(t *testing.T) {
+	ctx := t.Context()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	repo, err := sqlite.NewRepository(ctx, logger, dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = repo.Close() })
+
+	pageParser := parser.NewParser(logger, "", parser.WithStreaming())
+	row := func(model, typ, price string) string {
+		return "<tr><td>" + model + "</td><td>" + typ + "</td><td>1</td><td></td><td>" + price + "</td></tr>"
+	}
+	page := func(rows ...string) string {
+		return `<table class="table-bordered"><tbody>` + strings.Join(rows, "") + `</tbody></table>`
+	}
+	check := func(t *testing.T, body string, opts ...checker.Option) (*models.Changes, []models.ParseReport, error) {
+		t.Helper()
+
+		fetcher := mocks.NewHTMLParser(t)
+		fetcher.On("GetHTMLResponse", ctx).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil).Once()
+
+		var reports []models.ParseReport
+		opts = append(opts,
+			checker.WithBoundedMemory(repo, pageParser),
+			checker.WithParseObserver(func(_ context.Context, report models.ParseReport) {
+				reports = append(reports, report)
+			}),
+		)
+		changes, err := checker.NewChecker(logger, fetcher, repo, opts...).CheckForUpdates(ctx)
+
+		return changes, reports, err
+	}
+
+	a1 := models.Product{Model: "A1", Type: "Diver", Quantity: "1", Price: "100"}
+	b2 := models.Product{Model: "B2", Type: "Dress", Quantity: "1", Price: "200"}
+	b2Variant := models.Product{Model: "B2 #2", Type: "Pilot", Quantity: "1", Price: "210"}
+	first := page(row("A1", "Diver", "100"), row("B2", "Dress", "200"), row("B2", "Pilot", "210"),
+		row("B2", "Dress", "200"), row("", "Diver", "1"))
+
+	t.Run("first check adds every product", func(t *testing.T) {
+		changes, reports, err := check(t, first)
+
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []models.Product{a1, b2, b2Variant}, changes.Added)
+		assert.Equal(t, []models.ParseReport{{Valid: 4, Invalid: 1, Duplicates: 2}}, reports)
+
+		state, err := repo.GetState(ctx)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []models.Product{a1, b2, b2Variant}, state.Products)
+	})
+
+	t.Run("same page has no changes", func(t *testing.T) {
+		changes, reports, err := check(t, first)
+
+		require.NoError(t, err)
+		assert.Equal(t, &models.Changes{}, changes)
+		assert.Empty(t, reports, "the page isn't parsed again")
+	})
+
+	t.Run("changes are detected against the stored products", func(t *testing.T) {
+		changes, _, err := check(t, page(
+			row("A1", "Diver", "90"), row("B2", "Dress", "200"), row("C3", "Racing", "300"),
+		))
+
+		require.NoError(t, err)
+		a1Cheaper := a1
+		a1Cheaper.Price = "90"
+		assert.Equal(t, []models.ChangeInfo{{Old: a1, New: a1Cheaper}}, changes.Changed)
+		assert.Equal(t, []models.Product{{Model: "C3", Type: "Racing", Quantity: "1", Price: "300"}}, changes.Added)
+		assert.Equal(t, []models.Product{b2Variant}, changes.Removed)
+	})
+
+	t.Run("changes are queued with the state", func(t *testing.T) {
+		changes, _, err := check(t, page(row("A1", "Diver", "90"), row("C3", "Racing", "300")), checker.WithOutbox())
+
+		require.NoError(t, err)
+		require.Len(t, changes.Removed, 1)
+		entries, err := repo.GetPendingChanges(ctx)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, changes, entries[0].Changes)
+	})
+
+	t.Run("state is kept when the invalid ratio exceeds the limit", func(t *testing.T) {
+		before, err := repo.GetState(ctx)
+		require.NoError(t, err)
+
+		_, _, err = check(t, page(row("D4", "Diver", "400"), row("", "", "")), checker.WithMaxInvalidRatio(0.2))
+
+		require.ErrorIs(t, err, checker.ErrTooManyInvalidRows)
+		after, err := repo.GetState(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, before, after)
+	})
+
+	t.Run("a product failing to store keeps its stored version", func(t *testing.T) {
+		raw, err := sql.Open("sqlite3", dbPath)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = raw.Close() })
+		_, err = raw.ExecContext(ctx, `CREATE TRIGGER fail_c3 BEFORE UPDATE ON products WHEN new.model = 'C3'
+			BEGIN SELECT RAISE(ABORT, 'malformed row'); END`)
+		require.NoError(t, err)
+		var runs []models.RunRecord
+		observer := func(_ context.Context, run models.RunRecord) { runs = append(runs, run) }
+
+		changes, _, err := check(t, page(row("A1", "Diver", "80"), row("C3", "Racing", "250")),
+			checker.WithRunObserver(observer))
+
+		require.NoError(t, err)
+		require.Len(t, changes.Changed, 1, "the change of the skipped product is left out")
+		assert.Equal(t, "A1", changes.Changed[0].New.Model)
+		assert.Empty(t, changes.Removed)
+		require.Len(t, runs, 1)
+		assert.Equal(t, 1, runs[0].Skipped)
+		state, err := repo.GetState(ctx)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []models.Product{
+			{Model: "A1", Type: "Diver", Quantity: "1", Price: "80"},
+			{Model: "C3", Type: "Racing", Quantity: "1", Price: "300"},
+		}, state.Products)
+	})
+}
+
+func TestChecker_CheckForUpdates_ExtraFields(t *testing.T) {
+	ctx := t.Context()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	page := func(warranty string) string {
+		return `<table class="table-bordered"><thead><tr><th>Model</th><th>Price</th><th>Warranty</th></tr></thead>
+			<tbody><tr><td>A1</td><td>100</td><td>` + warranty + `</td></tr></tbody></table>`
+	}
+	product := func(warranty string) models.Product {
+		return models.Product{Model: "A1", Price: "100", Extras: map[string]string{"warranty": warranty}}
+	}
+
+	for _, bounded := range []bool{false, true} {
+		t.Run(fmt.Sprintf("bounded memory %t", bounded), func(t *testing.T) {
+			repo, err := sqlite.NewRepository(ctx, logger, filepath.Join(t.TempDir(), "test.db"))
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = repo.Close() })
+			pageParser := parser.NewParser(logger, server.URL,
+				parser.WithExtraFields(map[string][]string{"warranty": nil}), parser.WithStreaming())
+			var opts []checker.Option
+			if bounded {
+				opts = append(opts, checker.WithBoundedMemory(repo, pageParser))
+			}
+			check := checker.NewChecker(logger, pageParser, repo, opts...)
+
+			body = page("1 year")
+			changes, err := check.CheckForUpdates(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []models.Product{product("1 year")}, changes.Added)
+
+			body = page("2 years")
+			changes, err = check.CheckForUpdates(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []models.ChangeInfo{{Old: product("1 year"), New: product("2 years")}}, changes.Changed,
+				"a change of an extra field alone is detected")
+
+			state, err := repo.GetState(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []models.Product{product("2 years")}, state.Products)
+		})
+	}
+}
+
+func TestChecker_CheckForUpdates_Normalizer(t *testing.T) {
+	ctx := t.Context()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	normalizer, err := normalize.New([]string{normalize.StepScripts}, "")
+	require.NoError(t, err)
+	page := func(token string) string {
+		return `<html><head><script>var token = "` + token + `";</script></head><body>products</body></html>`
+	}
+	normalized, err := normalizer.Normalize([]byte(page("abc")))
+	require.NoError(t, err)
+
+	// Arrange
+	mockParser := mocks.NewHTMLParser(t)
+	mockRepo := mocks.NewStateRepository(t)
+	mockParser.On("GetHTMLResponse", ctx).
+		Return(&http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(page("xyz")))}, nil).
+		Once()
+	mockRepo.On("GetState", ctx).
+		Return(&models.State{PageHash: fmt.Sprintf("%x", sha256.Sum256(normalized))}, nil).Once()
+
+	// Act
+	changes, err := checker.NewChecker(logger, mockParser, mockRepo, checker.WithNormalizer(normalizer)).
+		CheckForUpdates(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, &models.Changes{}, changes, "the script churn doesn't change the page hash")
+}
+
+func TestChecker_CheckForUpdates_PageDiffs(t *testing.T) {
+	ctx := events.WithRunID(t.Context(), "run-2")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	oldPage := `<html><body><p>token 1</p><table></table></body></html>`
+	newPage := `<html><body><p>token 2</p><table></table></body></html>`
+	oldHash := fmt.Sprintf("%x", sha256.Sum256([]byte(oldPage)))
+	newHash := fmt.Sprintf("%x", sha256.Sum256([]byte(newPage)))
+	products := []models.Product{{Model: "A1", Price: "100"}}
+	check := func(
+		t *testing.T,
+		mockDiffs *mocks.PageDiffRepository,
+		keep int,
+		newProducts []models.Product,
+		opts ...checker.Option,
+	) {
+		t.Helper()
+
+		mockParser := mocks.NewHTMLParser(t)
+		mockRepo := mocks.NewStateRepository(t)
+		mockParser.On("GetHTMLResponse", ctx).
+			Return(&http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(newPage))}, nil).
+			Once()
+		mockRepo.On("GetState", ctx).Return(&models.State{PageHash: oldHash, Products: products}, nil).Once()
+		mockParser.On("ParseTableResponse", ctx, mock.Anything).Return(newProducts, nil).Once()
+		mockRepo.On("UpdateState", ctx, mock.AnythingOfType("*models.State")).Return(nil).Once()
+
+		opts = append(opts, checker.WithPageDiffs(mockDiffs, keep))
+		_, err := checker.NewChecker(logger, mockParser, mockRepo, opts...).CheckForUpdates(ctx)
+		require.NoError(t, err)
+	}
+
+	t.Run("page changed without product changes", func(t *testing.T) {
+		mockDiffs := mocks.NewPageDiffRepository(t)
+		mockDiffs.On("SwapPage", ctx, newHash, []byte(newPage)).Return([]byte(oldPage), nil).Once()
+		var stored models.PageDiff
+		mockDiffs.On("RecordPageDiff", ctx, mock.AnythingOfType("models.PageDiff"), 5).
+			Run(func(args mock.Arguments) { stored, _ = args.Get(1).(models.PageDiff) }).
+			Return(nil).Once()
+
+		check(t, mockDiffs, 5, products)
+
+		assert.Equal(t, "run-2", stored.RunID)
+		assert.Equal(t, oldHash, stored.OldHash)
+		assert.Equal(t, newHash, stored.NewHash)
+		assert.Contains(t, stored.Diff, "-<p>token 1</p>")
+		assert.Contains(t, stored.Diff, "+<p>token 2</p>")
+		assert.NotContains(t, stored.Diff, "-<table>", "the unchanged lines aren't changes")
+	})
+
+	t.Run("products changed", func(t *testing.T) {
+		mockDiffs := mocks.NewPageDiffRepository(t)
+		mockDiffs.On("SwapPage", ctx, newHash, []byte(newPage)).Return([]byte(oldPage), nil).Once()
+
+		check(t, mockDiffs, 5, []models.Product{{Model: "A1", Price: "120"}})
+	})
+
+	t.Run("products changed with their notification vetoed", func(t *testing.T) {
+		mockDiffs := mocks.NewPageDiffRepository(t)
+		mockDiffs.On("SwapPage", ctx, newHash, []byte(newPage)).Return([]byte(oldPage), nil).Once()
+		hooks := pipeline.New()
+		hooks.OnPreNotify(func(context.Context, *models.Changes) error { return pipeline.ErrVeto })
+
+		check(t, mockDiffs, 5, []models.Product{{Model: "A1", Price: "120"}}, checker.WithHooks(hooks))
+	})
+
+	t.Run("storing the page fails", func(t *testing.T) {
+		mockDiffs := mocks.NewPageDiffRepository(t)
+		mockDiffs.On("SwapPage", ctx, newHash, []byte(newPage)).Return(nil, assert.AnError).Once()
+
+		check(t, mockDiffs, 5, products)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		check(t, mocks.NewPageDiffRepository(t), 0, products)
+	})
+}
+
+func TestChecker_CheckForUpdates_Hooks(t *testing.T) {
+	ctx := events.WithRunID(t.Context(), "run-1")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	oldState := &models.State{PageHash: "hash_old", Products: []models.Product{{Model: "A1", Price: "100"}}}
+	parsed := []models.Product{{Model: "A1", Price: "90"}, {Model: "B2", Price: "200"}}
+	check := func(t *testing.T, hooks *pipeline.Hooks) (*models.Changes, *models.State, error) {
+		t.Helper()
+
+		mockParser := mocks.NewHTMLParser(t)
+		mockRepo := mocks.NewStateRepository(t)
+		mockParser.On("GetHTMLResponse", ctx).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(`<html><body>new</body></html>`)),
+		}, nil).Once()
+		mockRepo.On("GetState", ctx).Return(oldState, nil).Once()
+		mockParser.On("ParseTableResponse", ctx, mock.Anything).Return(parsed, nil).Once()
+		var saved *models.State
+		mockRepo.On("UpdateState", ctx, mock.MatchedBy(func(state *models.State) bool {
+			saved = state
+			return true
+		})).Return(nil).Maybe()
+
+		changes, err := checker.NewChecker(
+			logger, mockParser, mockRepo, checker.WithOutbox(), checker.WithHooks(hooks),
+		).CheckForUpdates(ctx)
+
+		return changes, saved, err
+	}
+
+	t.Run("post-parse hook drops products", func(t *testing.T) {
+		hooks := pipeline.New()
+		hooks.OnPostParse(func(_ context.Context, products []models.Product) ([]models.Product, error) {
+			return products[:1], nil
+		})
+
+		changes, state, err := check(t, hooks)
+		require.NoError(t, err)
+
+		assert.Equal(t, []models.Product{{Model: "A1", Price: "90"}}, state.Products)
+		assert.Empty(t, changes.Added)
+		assert.Len(t, changes.Changed, 1)
+	})
+
+	t.Run("pre-notify hook enriches the changes", func(t *testing.T) {
+		hooks := pipeline.New()
+		hooks.OnPreNotify(func(_ context.Context, changes *models.Changes) error {
+			changes.Added[0].Extras = map[string]string{"source": "hook"}
+			return nil
+		})
+
+		changes, state, err := check(t, hooks)
+		require.NoError(t, err)
+
+		assert.Equal(t, "hook", changes.Added[0].Extras["source"])
+		require.NotNil(t, state.Outbox)
+		assert.Equal(t, "hook", state.Outbox.Changes.Added[0].Extras["source"])
+	})
+
+	t.Run("pre-notify hook cancels the notification", func(t *testing.T) {
+		hooks := pipeline.New()
+		hooks.OnPreNotify(func(context.Context, *models.Changes) error { return pipeline.ErrVeto })
+
+		changes, state, err := check(t, hooks)
+		require.NoError(t, err)
+
+		assert.False(t, changes.HasChanges())
+		assert.Nil(t, state.Outbox, "the changes aren't queued")
+		assert.Len(t, state.Products, 2, "the changes are stored")
+	})
+
+	t.Run("post-parse hook vetoes the check", func(t *testing.T) {
+		hooks := pipeline.New()
+		hooks.OnPostParse(func(context.Context, []models.Product) ([]models.Product, error) {
+			return nil, pipeline.ErrVeto
+		})
+
+		_, state, err := check(t, hooks)
+
+		require.ErrorIs(t, err, pipeline.ErrVeto)
+		assert.Nil(t, state, "the state isn't updated")
+	})
+}
+
+func TestChecker_CheckForUpdates_PreFetchVeto(t *testing.T) {
+	ctx := t.Context()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Arrange
+	mockParser := mocks.NewHTMLParser(t)
+	mockParser.On("GetHTMLResponse", ctx).Return(nil, fmt.Errorf("request: %w", pipeline.ErrVeto)).Once()
+	observed := false
+	observer := func(context.Context, time.Duration, error) { observed = true }
+
+	// Act
+	_, err := checker.NewChecker(logger, mockParser, mocks.NewStateRepository(t), checker.WithFetchObserver(observer)).
+		CheckForUpdates(ctx)
+
+	// Assert
+	require.ErrorIs(t, err, pipeline.ErrVeto)
+	assert.False(t, observed, "a vetoed fetch isn't observed as a failure of the target")
+}
+
+func TestChecker_CheckForUpdates_FailureObserver(t *testing.T) {
+	ctx := t.Context()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	testCases := []struct {
+		name       string
+		setupMocks func(mParser *mocks.HTMLParser, mRepo *mocks.StateRepository)
+		class      string
+	}{
+		{
+			name: "fetch",
+			setupMocks: func(mParser *mocks.HTMLParser, _ *mocks.StateRepository) {
+				mParser.On("GetHTMLResponse", ctx).
+					Return(nil, fmt.Errorf("%w: %w", parser.ErrFetchFailed, assert.AnError)).Once()
+			},
+			class: checker.ClassFetch,
+		},
+		{
+			name: "fetch: body read",
+			setupMocks: func(mParser *mocks.HTMLParser, _ *mocks.StateRepository) {
+				mockHTTPResponse := &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(errReader(0))}
+				mParser.On("GetHTMLResponse", ctx).Return(mockHTTPResponse, nil).Once()
+			},
+			class: checker.ClassFetch,
+		},
+		{
+			name: "parse",
+			setupMocks: func(mParser *mocks.HTMLParser, mRepo *mocks.StateRepository) {
+				mockHTTPResponse := &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString("new")),
+				}
+				mParser.On("GetHTMLResponse", ctx).Return(mockHTTPResponse, nil).Once()
+				mRepo.On("GetState", ctx).Return(nil, repository.ErrStateNotFound).Once()
+				mParser.On("ParseTableResponse", ctx, mock.Anything).Return([]models.Product{}, nil).Once()
+			},
+			class: checker.ClassParse,
+		},
+		{
+			name: "state",
+			setupMocks: func(mParser *mocks.HTMLParser, mRepo *mocks.StateRepository) {
+				mockHTTPResponse := &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString("new")),
+				}
+				mParser.On("GetHTMLResponse", ctx).Return(mockHTTPResponse, nil).Once()
+				mRepo.On("GetState", ctx).
+					Return(nil, fmt.Errorf("%w: database is locked", repository.ErrStateConflict)).Once()
+			},
+			class: checker.ClassState,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			mockParser := mocks.NewHTMLParser(t)
+			mockRepo := mocks.NewStateRepository(t)
+			tc.setupMocks(mockParser, mockRepo)
+			var classes []string
+			observer := func(_ context.Context, class string, _ error) { classes = append(classes, class) }
+			updateChecker := checker.NewChecker(logger, mockParser, mockRepo, checker.WithFailureObserver(observer))
+
+			// Act
+			_, err := updateChecker.CheckForUpdates(ctx)
+
+			// Assert
+			require.Error(t, err)
+			assert.Equal(t, tc.class, checker.ErrorClass(err))
+			assert.Equal(t, []string{tc.class}, classes)
+		})
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	t.Parallel()
+
+	for err, class := range map[error]string{
+		nil: "",
+		fmt.Errorf("check: %w", pipeline.ErrVeto):              checker.ClassVeto,
+		fmt.Errorf("check: %w", models.ErrPageAssertionFailed): checker.ClassAssertion,
+		fmt.Errorf("get: %w", parser.ErrFetchFailed):           checker.ClassFetch,
+		fmt.Errorf("parse: %w", checker.ErrParseEmpty):         checker.ClassParse,
+		fmt.Errorf("parse: %w", checker.ErrTooManyInvalidRows): checker.ClassParse,
+		fmt.Errorf("update: %w", repository.ErrStateConflict):  checker.ClassState,
+		fmt.Errorf("get: %w", repository.ErrQueryTimeout):      checker.ClassState,
+		assert.AnError: checker.ClassOther,
+	} {
+		assert.Equal(t, class, checker.ErrorClass(err), "%v", err)
+	}
+}
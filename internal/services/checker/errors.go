@@ -0,0 +1,49 @@
+package checker
+
+import (
+	"errors"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/parser"
+	"github.com/Houeta/chrono-flow/internal/pipeline"
+	"github.com/Houeta/chrono-flow/internal/repository"
+)
+
+// Classes of the errors of the checks, see ErrorClass.
+const (
+	// ClassFetch is a failure to fetch the document with the products, see parser.ErrFetchFailed.
+	ClassFetch = "fetch"
+	// ClassParse is a page without valid products or with too many invalid rows.
+	ClassParse = "parse"
+	// ClassAssertion is a page failing the assertions of its target, see WithPageAssertions.
+	ClassAssertion = "assertion"
+	// ClassState is a failure to access the stored state: another connection held the database or the
+	// query timed out.
+	ClassState = "state"
+	// ClassVeto is a check canceled by a pipeline hook.
+	ClassVeto = "veto"
+	// ClassOther is any other error.
+	ClassOther = "other"
+)
+
+// ErrorClass returns the class of an error of CheckForUpdates, empty for nil, so the callers can branch
+// on it rather than on its text, e.g. to label the metrics of the failures or to tell the admins what
+// failed.
+func ErrorClass(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, pipeline.ErrVeto):
+		return ClassVeto
+	case errors.Is(err, models.ErrPageAssertionFailed):
+		return ClassAssertion
+	case errors.Is(err, parser.ErrFetchFailed):
+		return ClassFetch
+	case errors.Is(err, ErrParseEmpty), errors.Is(err, ErrTooManyInvalidRows):
+		return ClassParse
+	case errors.Is(err, repository.ErrStateConflict), errors.Is(err, repository.ErrQueryTimeout):
+		return ClassState
+	default:
+		return ClassOther
+	}
+}
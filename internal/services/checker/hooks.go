@@ -0,0 +1,51 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/pipeline"
+)
+
+// WithHooks calls the post-parse and pre-notify callbacks of the hooks in the checks, the pre-fetch ones
+// are called by the transport of the parser, see pipeline.Transport.
+func WithHooks(hooks *pipeline.Hooks) Option {
+	return func(c *Checker) {
+		c.hooks = hooks
+	}
+}
+
+// postParse passes the valid products through the post-parse hooks. Unlike the products of a broken page,
+// the ones the hooks drop are reported as removed, but the hooks can't drop all of them.
+func (c *Checker) postParse(ctx context.Context, products []models.Product) ([]models.Product, error) {
+	products, err := c.hooks.PostParse(ctx, products)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the hook error is wrapped by the check.
+	}
+	if len(products) == 0 {
+		return nil, ErrParseEmpty
+	}
+
+	return products, nil
+}
+
+// preNotify passes the detected changes through the pre-notify hooks, it reports false if a hook vetoed
+// their notification.
+func (c *Checker) preNotify(ctx context.Context, log *slog.Logger, changes *models.Changes) (bool, error) {
+	if !changes.HasChanges() {
+		return true, nil
+	}
+
+	err := c.hooks.PreNotify(ctx, changes)
+	if errors.Is(err, pipeline.ErrVeto) {
+		log.InfoContext(ctx, "Notification of the changes canceled by a hook", "error", err)
+		return false, nil
+	}
+	if err != nil {
+		return false, err //nolint:wrapcheck // the hook error is wrapped by the check.
+	}
+
+	return true, nil
+}
@@ -0,0 +1,90 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/events"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const (
+	// pageDiffContext is the number of unchanged lines shown around the changed ones.
+	pageDiffContext = 3
+	// maxPageDiffSize caps a stored diff, a page rendered differently as a whole isn't worth more.
+	maxPageDiffSize = 1 << 20
+)
+
+// WithPageDiffs keeps the last page fetched and, when the hash of the page changes while no product
+// changes, stores the unified diff of the pages for debugging the churn defeating the hash comparison.
+// The diffs of the last keep such runs are retained, zero disables them.
+func WithPageDiffs(repo sqlite.PageDiffRepository, keep int) Option {
+	return func(c *Checker) {
+		if keep > 0 {
+			c.pageDiffs = repo
+			c.pageDiffRuns = keep
+		}
+	}
+}
+
+// recordPageDiff stores the page as the last one fetched and the diff against the previous one if it
+// changed without a product changing, notified or not. The diffs are only for debugging, so failing to
+// store them is logged and doesn't fail the check.
+func (c *Checker) recordPageDiff(
+	ctx context.Context,
+	log *slog.Logger,
+	body []byte,
+	pageHash string,
+	productsChanged bool,
+) {
+	previous, err := c.pageDiffs.SwapPage(ctx, pageHash, body)
+	if err != nil {
+		log.WarnContext(ctx, "Failed to store the page for the diffs", "error", err)
+		return
+	}
+	if previous == nil || productsChanged {
+		return
+	}
+
+	oldHash := calculateHash(previous)
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        pageLines(previous),
+		B:        pageLines(body),
+		FromFile: oldHash,
+		ToFile:   pageHash,
+		Context:  pageDiffContext,
+	})
+	if err != nil {
+		log.WarnContext(ctx, "Failed to diff the pages", "error", err)
+		return
+	}
+	if len(diff) > maxPageDiffSize {
+		diff = strings.ToValidUTF8(diff[:maxPageDiffSize], "") + "\n... diff truncated\n"
+	}
+
+	err = c.pageDiffs.RecordPageDiff(ctx, models.PageDiff{
+		RunID:     events.RunIDFromContext(ctx),
+		CheckedAt: time.Now(),
+		OldHash:   oldHash,
+		NewHash:   pageHash,
+		Diff:      diff,
+	}, c.pageDiffRuns)
+	if err != nil {
+		log.WarnContext(ctx, "Failed to store the page diff", "error", err)
+		return
+	}
+	log.InfoContext(ctx, "Page changed without product changes, stored its diff", "size", len(diff))
+}
+
+// pageLines splits the page into lines for the diff, breaking the lines between adjacent tags, as pages
+// are often served on a single line.
+func pageLines(page []byte) []string {
+	page = bytes.ReplaceAll(page, []byte("><"), []byte(">\n<"))
+
+	return difflib.SplitLines(string(page))
+}
@@ -0,0 +1,103 @@
+package checker
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// normalizeModel lowercases the model and collapses all whitespace runs into single spaces.
+func normalizeModel(model string) string {
+	return strings.Join(strings.Fields(strings.ToLower(model)), " ")
+}
+
+// similarity returns the normalized Levenshtein similarity of two model strings in the range [0, 1].
+func similarity(a, b string) float64 {
+	ra := []rune(normalizeModel(a))
+	rb := []rune(normalizeModel(b))
+
+	maxLen := max(len(ra), len(rb))
+	if maxLen == 0 {
+		return 1
+	}
+
+	return 1 - float64(levenshtein(ra, rb))/float64(maxLen)
+}
+
+// levenshtein calculates the edit distance between two rune slices.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// matchRenamed pairs removed and added products of the same category whose models are at least
+// threshold similar.
+// Pairs are chosen greedily starting from the most similar ones, so every product is used at most once.
+// It returns the renamed pairs together with the products that were left unmatched.
+func matchRenamed(
+	removed, added []models.Product,
+	threshold float64,
+) ([]models.ChangeInfo, []models.Product, []models.Product) {
+	type candidate struct {
+		oldIdx, newIdx int
+		score          float64
+	}
+
+	var candidates []candidate
+	for i, oldProduct := range removed {
+		for j, newProduct := range added {
+			if oldProduct.Category != newProduct.Category {
+				continue
+			}
+			if score := similarity(oldProduct.Model, newProduct.Model); score >= threshold {
+				candidates = append(candidates, candidate{oldIdx: i, newIdx: j, score: score})
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	usedOld := make(map[int]bool, len(removed))
+	usedNew := make(map[int]bool, len(added))
+	var renamed []models.ChangeInfo
+	for _, c := range candidates {
+		if usedOld[c.oldIdx] || usedNew[c.newIdx] {
+			continue
+		}
+		usedOld[c.oldIdx] = true
+		usedNew[c.newIdx] = true
+		renamed = append(renamed, models.ChangeInfo{Old: removed[c.oldIdx], New: added[c.newIdx]})
+	}
+
+	var restRemoved, restAdded []models.Product
+	for i, p := range removed {
+		if !usedOld[i] {
+			restRemoved = append(restRemoved, p)
+		}
+	}
+	for j, p := range added {
+		if !usedNew[j] {
+			restAdded = append(restAdded, p)
+		}
+	}
+
+	return renamed, restRemoved, restAdded
+}
@@ -0,0 +1,160 @@
+// Package enrich annotates the detected changes with data of an external service before they're
+// notified, e.g. the internal SKU, the margin or the buyer in charge of a product. The changes are posted
+// to a webhook and the annotations it returns are merged into the extra fields of the products, which
+// notification templates render with {{extra . "sku"}}.
+package enrich
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+)
+
+// ErrUnexpectedStatus is returned when the webhook answers with a status other than 2xx.
+var ErrUnexpectedStatus = errors.New("unexpected status of the enrichment webhook")
+
+// maxResponseSize limits the response of the webhook read, a larger one is cut off and fails to decode.
+const maxResponseSize = 1 << 20
+
+// Annotation holds the fields the webhook adds to a product, identified by its table and model.
+type Annotation struct {
+	Category string            `json:"category"`
+	Model    string            `json:"model"`
+	Fields   map[string]string `json:"fields"`
+}
+
+// Response is the body of a webhook response.
+type Response struct {
+	Annotations []Annotation `json:"annotations"`
+}
+
+// productKey identifies a product across the tables of the page.
+type productKey struct {
+	category string
+	model    string
+}
+
+// Enricher posts the detected changes to the webhook and merges the returned annotations into them. It
+// fails open: when the webhook fails, times out or answers with an invalid body, the changes are notified
+// as they are.
+type Enricher struct {
+	log    *slog.Logger
+	url    string
+	client *http.Client
+}
+
+// New creates an Enricher posting the changes to the URL, a request is given up after the timeout.
+func New(log *slog.Logger, url string, timeout time.Duration) *Enricher {
+	return &Enricher{
+		log:    log,
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Enrich posts the changes to the webhook and merges the annotations it returns into the extra fields
+// of the changed products, the fields the page provides aren't overwritten. A changed or renamed product
+// is annotated before and after the change alike, so the annotations don't show up as changes. It never
+// returns an error, so it can be registered as a pre-notify hook of the pipeline.
+func (e *Enricher) Enrich(ctx context.Context, changes *models.Changes) error {
+	if changes == nil || !changes.HasChanges() {
+		return nil
+	}
+
+	annotations, err := e.fetch(ctx, changes)
+	if err != nil {
+		e.log.WarnContext(ctx, "Failed to enrich changes, notifying them as they are",
+			"op", "enrich.Enrich", "error", err)
+		return nil
+	}
+
+	merge(changes, annotations)
+
+	return nil
+}
+
+// fetch posts the changes to the webhook and returns the annotations by product.
+func (e *Enricher) fetch(ctx context.Context, changes *models.Changes) (map[productKey]map[string]string, error) {
+	payload, err := json.Marshal(changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode changes: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("invalid enrichment URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post changes: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("%w: %s", ErrUnexpectedStatus, resp.Status)
+	}
+
+	var response Response
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	annotations := make(map[productKey]map[string]string, len(response.Annotations))
+	for _, annotation := range response.Annotations {
+		key := productKey{category: annotation.Category, model: annotation.Model}
+		if annotations[key] == nil {
+			annotations[key] = make(map[string]string, len(annotation.Fields))
+		}
+		maps.Copy(annotations[key], annotation.Fields)
+	}
+
+	return annotations, nil
+}
+
+// merge adds the annotations to the products of the changes.
+func merge(changes *models.Changes, annotations map[productKey]map[string]string) {
+	annotate := func(product *models.Product, fields map[string]string) {
+		if len(fields) == 0 {
+			return
+		}
+		// The extras may be shared with the stored state, so they're copied before they're modified.
+		extras := maps.Clone(product.Extras)
+		if extras == nil {
+			extras = make(map[string]string, len(fields))
+		}
+		for field, value := range fields {
+			if _, ok := extras[field]; !ok && value != "" {
+				extras[field] = value
+			}
+		}
+		product.Extras = extras
+	}
+	fieldsOf := func(product models.Product) map[string]string {
+		return annotations[productKey{category: product.Category, model: product.Model}]
+	}
+
+	for i := range changes.Added {
+		annotate(&changes.Added[i], fieldsOf(changes.Added[i]))
+	}
+	for i := range changes.Removed {
+		annotate(&changes.Removed[i], fieldsOf(changes.Removed[i]))
+	}
+	for _, infos := range [][]models.ChangeInfo{changes.Changed, changes.Renamed} {
+		for i := range infos {
+			fields := fieldsOf(infos[i].New)
+			annotate(&infos[i].Old, fields)
+			annotate(&infos[i].New, fields)
+		}
+	}
+}
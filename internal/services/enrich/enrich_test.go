@@ -0,0 +1,116 @@
+package enrich_test
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/services/enrich"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newChanges() *models.Changes {
+	return &models.Changes{
+		Added: []models.Product{{Model: "A1", Category: "watches", Extras: map[string]string{"warranty": "2y"}}},
+		Changed: []models.ChangeInfo{{
+			Old: models.Product{Model: "B2", Price: "100"},
+			New: models.Product{Model: "B2", Price: "90"},
+		}},
+	}
+}
+
+func TestEnricher_Enrich(t *testing.T) {
+	// Arrange
+	var received models.Changes
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		_, _ = w.Write([]byte(`{"annotations": [
+			{"category": "watches", "model": "A1", "fields": {"sku": "SKU-1", "warranty": "5y"}},
+			{"model": "B2", "fields": {"buyer": "anna", "margin": "12%"}},
+			{"model": "A1", "fields": {"sku": "other table"}}
+		]}`))
+	}))
+	t.Cleanup(server.Close)
+	enricher := enrich.New(slog.New(slog.NewTextHandler(io.Discard, nil)), server.URL, time.Second)
+	changes := newChanges()
+	extras := changes.Added[0].Extras
+
+	// Act
+	err := enricher.Enrich(t.Context(), changes)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, *newChanges(), received, "the changes are posted as they were detected")
+	assert.Equal(t, map[string]string{"warranty": "2y", "sku": "SKU-1"}, changes.Added[0].Extras,
+		"the fields of the page aren't overwritten")
+	assert.Equal(t, map[string]string{"warranty": "2y"}, extras, "the extras of the product aren't modified")
+	assert.Equal(t, map[string]string{"buyer": "anna", "margin": "12%"}, changes.Changed[0].New.Extras)
+	assert.Equal(t, changes.Changed[0].New.Extras, changes.Changed[0].Old.Extras,
+		"the annotations aren't notified as changes")
+}
+
+func TestEnricher_Enrich_FailOpen(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{
+			name:    "error status",
+			handler: func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusBadGateway) },
+		},
+		{
+			name:    "invalid body",
+			handler: func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("<html>")) },
+		},
+		{
+			name: "timeout",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Second):
+				}
+				_, _ = w.Write([]byte(`{"annotations": [{"model": "B2", "fields": {"sku": "late"}}]}`))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			server := httptest.NewServer(tt.handler)
+			t.Cleanup(server.Close)
+			enricher := enrich.New(slog.New(slog.NewTextHandler(io.Discard, nil)), server.URL, 50*time.Millisecond)
+			changes := newChanges()
+
+			// Act
+			err := enricher.Enrich(t.Context(), changes)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, newChanges(), changes, "the changes are notified as they are")
+		})
+	}
+}
+
+func TestEnricher_Enrich_NoChanges(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("the webhook must not be called without changes")
+	}))
+	t.Cleanup(server.Close)
+	enricher := enrich.New(slog.New(slog.NewTextHandler(io.Discard, nil)), server.URL, time.Second)
+
+	// Act
+	err := enricher.Enrich(t.Context(), &models.Changes{})
+
+	// Assert
+	require.NoError(t, err)
+}
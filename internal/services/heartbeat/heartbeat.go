@@ -0,0 +1,195 @@
+// Package heartbeat implements a dead-man's switch: administrators are alerted when no check of the
+// target succeeded for too long, whatever the reason. Failing checks are already reported by the
+// alerting package, the switch also covers checks that don't run at all, e.g. a crash loop or a hung
+// scheduler loop.
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+	"github.com/Houeta/chrono-flow/internal/services/alerting"
+)
+
+// pingTimeout limits a ping of the external monitor, a slow monitor must not delay the checks.
+const pingTimeout = 10 * time.Second
+
+// Monitor keeps the time of the last successful check in the repository, so the silence is measured
+// across restarts, and alerts administrators once it exceeds the timeout. Successful checks are also
+// reported to an external monitor, e.g. healthchecks.io, which notices when the whole host is down.
+// Its methods may be called on a nil Monitor, which does nothing.
+type Monitor struct {
+	log      *slog.Logger
+	repo     sqlite.HeartbeatRepository
+	notifier alerting.AdminNotifier
+	target   string
+	timeout  time.Duration
+	pingURL  string
+	client   *http.Client
+	now      func() time.Time
+
+	// mu serializes the updates of the stored heartbeat by the scheduler loop and Run.
+	mu sync.Mutex
+}
+
+// Option configures optional Monitor behavior.
+type Option func(*Monitor)
+
+// WithPingURL requests the URL after every successful check.
+func WithPingURL(url string) Option {
+	return func(m *Monitor) {
+		m.pingURL = url
+	}
+}
+
+// NewMonitor creates a Monitor which alerts when no check of the target succeeded for the timeout.
+// A timeout of zero or less disables alerts, the external monitor is still pinged.
+func NewMonitor(
+	log *slog.Logger,
+	repo sqlite.HeartbeatRepository,
+	notifier alerting.AdminNotifier,
+	target string,
+	timeout time.Duration,
+	opts ...Option,
+) *Monitor {
+	m := &Monitor{
+		log:      log,
+		repo:     repo,
+		notifier: notifier,
+		target:   target,
+		timeout:  timeout,
+		client:   &http.Client{Timeout: pingTimeout},
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Run checks the heartbeat on start and then every interval until the context is canceled. It must
+// run apart from the scheduler loop, so a hung loop is noticed too.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	if m == nil || m.timeout <= 0 {
+		return
+	}
+
+	m.Check(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.Check(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Check alerts administrators if no check succeeded for the timeout and they weren't alerted yet.
+// The first check of a new deployment starts the switch.
+func (m *Monitor) Check(ctx context.Context) {
+	if m == nil || m.timeout <= 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	heartbeat, err := m.repo.GetHeartbeat(ctx)
+	if err != nil {
+		m.log.ErrorContext(ctx, "failed to get heartbeat", "op", "heartbeat.Check", "error", err)
+		return
+	}
+	if heartbeat == nil {
+		m.save(ctx, models.Heartbeat{LastSuccess: m.now()})
+		return
+	}
+
+	silence := m.now().Sub(heartbeat.LastSuccess)
+	if heartbeat.Alerted || silence < m.timeout {
+		return
+	}
+
+	m.log.WarnContext(ctx, "Alerting admins about missing heartbeat", "target", m.target, "silence", silence)
+	m.notifier.NotifyAdmins(ctx, fmt.Sprintf(
+		"💀 No successful check of %s for %s\nLast success: %s\n"+
+			"The checker may be crash looping, hung or unable to reach the target.",
+		m.target, silence.Round(time.Minute), heartbeat.LastSuccess.UTC().Format(time.DateTime+" MST"),
+	))
+	heartbeat.Alerted = true
+	m.save(ctx, *heartbeat)
+}
+
+// Success records a successful check, tells administrators the heartbeat is back if they were
+// alerted, and pings the external monitor.
+func (m *Monitor) Success(ctx context.Context) {
+	if m == nil {
+		return
+	}
+
+	m.beat(ctx)
+	m.ping(ctx)
+}
+
+// beat stores the time of a successful check.
+func (m *Monitor) beat(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	heartbeat, err := m.repo.GetHeartbeat(ctx)
+	if err != nil {
+		m.log.ErrorContext(ctx, "failed to get heartbeat", "op", "heartbeat.Success", "error", err)
+	}
+	now := m.now()
+	if heartbeat != nil && heartbeat.Alerted {
+		silence := now.Sub(heartbeat.LastSuccess).Round(time.Minute)
+		m.log.InfoContext(ctx, "Notifying admins about restored heartbeat", "target", m.target, "silence", silence)
+		m.notifier.NotifyAdmins(ctx, fmt.Sprintf(
+			"💓 Checks of %s succeed again after %s of silence", m.target, silence,
+		))
+	}
+
+	m.save(ctx, models.Heartbeat{LastSuccess: now})
+}
+
+// save stores the heartbeat, a failure is logged since it must never fail the check itself.
+func (m *Monitor) save(ctx context.Context, heartbeat models.Heartbeat) {
+	if err := m.repo.SaveHeartbeat(ctx, heartbeat); err != nil {
+		m.log.ErrorContext(ctx, "failed to save heartbeat", "op", "heartbeat.save", "error", err)
+	}
+}
+
+// ping requests the URL of the external monitor, failures are logged.
+func (m *Monitor) ping(ctx context.Context) {
+	if m.pingURL == "" {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.pingURL, nil)
+	if err != nil {
+		m.log.ErrorContext(ctx, "invalid heartbeat ping URL", "error", err)
+		return
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		m.log.WarnContext(ctx, "failed to ping heartbeat monitor", "error", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		m.log.WarnContext(ctx, "heartbeat monitor rejected the ping", "status", resp.StatusCode)
+	}
+}
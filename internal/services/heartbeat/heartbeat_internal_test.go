@@ -0,0 +1,175 @@
+package heartbeat
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingNotifier records every admin notification.
+type recordingNotifier struct {
+	sent []string
+}
+
+func (r *recordingNotifier) NotifyAdmins(_ context.Context, text string) {
+	r.sent = append(r.sent, text)
+}
+
+func TestMonitor_Check(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	newMonitor := func(t *testing.T, repo *mocks.HeartbeatRepository, notifier *recordingNotifier) *Monitor {
+		t.Helper()
+
+		monitor := NewMonitor(logger, repo, notifier, "https://example.com", time.Hour)
+		monitor.now = func() time.Time { return now }
+		return monitor
+	}
+
+	t.Run("alerts once the silence exceeds the timeout", func(t *testing.T) {
+		t.Parallel()
+
+		notifier := &recordingNotifier{}
+		mockRepo := mocks.NewHeartbeatRepository(t)
+		mockRepo.On("GetHeartbeat", mock.Anything).
+			Return(&models.Heartbeat{LastSuccess: now.Add(-90 * time.Minute)}, nil).Once()
+		mockRepo.On("SaveHeartbeat", mock.Anything, models.Heartbeat{
+			LastSuccess: now.Add(-90 * time.Minute), Alerted: true,
+		}).Return(nil).Once()
+
+		newMonitor(t, mockRepo, notifier).Check(t.Context())
+
+		require.Len(t, notifier.sent, 1)
+		assert.Contains(t, notifier.sent[0], "No successful check of https://example.com for 1h30m0s")
+		assert.Contains(t, notifier.sent[0], "Last success: 2025-01-01 10:30:00 UTC")
+	})
+
+	t.Run("admins are alerted only once", func(t *testing.T) {
+		t.Parallel()
+
+		notifier := &recordingNotifier{}
+		mockRepo := mocks.NewHeartbeatRepository(t)
+		mockRepo.On("GetHeartbeat", mock.Anything).
+			Return(&models.Heartbeat{LastSuccess: now.Add(-5 * time.Hour), Alerted: true}, nil).Once()
+
+		newMonitor(t, mockRepo, notifier).Check(t.Context())
+
+		assert.Empty(t, notifier.sent)
+	})
+
+	t.Run("silence within the timeout is fine", func(t *testing.T) {
+		t.Parallel()
+
+		notifier := &recordingNotifier{}
+		mockRepo := mocks.NewHeartbeatRepository(t)
+		mockRepo.On("GetHeartbeat", mock.Anything).
+			Return(&models.Heartbeat{LastSuccess: now.Add(-59 * time.Minute)}, nil).Once()
+
+		newMonitor(t, mockRepo, notifier).Check(t.Context())
+
+		assert.Empty(t, notifier.sent)
+	})
+
+	t.Run("first start starts the switch", func(t *testing.T) {
+		t.Parallel()
+
+		notifier := &recordingNotifier{}
+		mockRepo := mocks.NewHeartbeatRepository(t)
+		mockRepo.On("GetHeartbeat", mock.Anything).Return(nil, nil).Once()
+		mockRepo.On("SaveHeartbeat", mock.Anything, models.Heartbeat{LastSuccess: now}).Return(nil).Once()
+
+		newMonitor(t, mockRepo, notifier).Check(t.Context())
+
+		assert.Empty(t, notifier.sent)
+	})
+
+	t.Run("repository failure is logged", func(t *testing.T) {
+		t.Parallel()
+
+		notifier := &recordingNotifier{}
+		mockRepo := mocks.NewHeartbeatRepository(t)
+		mockRepo.On("GetHeartbeat", mock.Anything).Return(nil, assert.AnError).Once()
+
+		newMonitor(t, mockRepo, notifier).Check(t.Context())
+
+		assert.Empty(t, notifier.sent)
+	})
+
+	t.Run("zero timeout disables alerts", func(t *testing.T) {
+		t.Parallel()
+
+		monitor := NewMonitor(logger, mocks.NewHeartbeatRepository(t), &recordingNotifier{}, "target", 0)
+
+		monitor.Check(t.Context())
+		monitor.Run(t.Context(), time.Minute)
+	})
+}
+
+func TestMonitor_Success(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("recovery is reported and the monitor pinged", func(t *testing.T) {
+		t.Parallel()
+
+		var pings atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			pings.Add(1)
+			w.WriteHeader(http.StatusOK)
+		}))
+		t.Cleanup(server.Close)
+
+		notifier := &recordingNotifier{}
+		mockRepo := mocks.NewHeartbeatRepository(t)
+		mockRepo.On("GetHeartbeat", mock.Anything).
+			Return(&models.Heartbeat{LastSuccess: now.Add(-3 * time.Hour), Alerted: true}, nil).Once()
+		mockRepo.On("SaveHeartbeat", mock.Anything, models.Heartbeat{LastSuccess: now}).Return(nil).Once()
+		monitor := NewMonitor(logger, mockRepo, notifier, "target", time.Hour, WithPingURL(server.URL))
+		monitor.now = func() time.Time { return now }
+
+		monitor.Success(t.Context())
+
+		require.Len(t, notifier.sent, 1)
+		assert.Equal(t, "💓 Checks of target succeed again after 3h0m0s of silence", notifier.sent[0])
+		assert.Equal(t, int32(1), pings.Load())
+	})
+
+	t.Run("beat is stored even if the ping fails", func(t *testing.T) {
+		t.Parallel()
+
+		notifier := &recordingNotifier{}
+		mockRepo := mocks.NewHeartbeatRepository(t)
+		mockRepo.On("GetHeartbeat", mock.Anything).Return(&models.Heartbeat{LastSuccess: now}, nil).Once()
+		mockRepo.On("SaveHeartbeat", mock.Anything, mock.Anything).Return(nil).Once()
+		monitor := NewMonitor(logger, mockRepo, notifier, "target", time.Hour, WithPingURL("http://127.0.0.1:0"))
+
+		monitor.Success(t.Context())
+
+		assert.Empty(t, notifier.sent)
+	})
+
+	t.Run("nil monitor does nothing", func(t *testing.T) {
+		t.Parallel()
+
+		var monitor *Monitor
+
+		monitor.Success(t.Context())
+		monitor.Check(t.Context())
+		monitor.Run(t.Context(), time.Minute)
+	})
+}
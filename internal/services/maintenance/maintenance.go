@@ -0,0 +1,83 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/metrics"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+)
+
+// Maintainer keeps the database compact on long-running deployments.
+type Maintainer struct {
+	log     *slog.Logger
+	repo    sqlite.MaintenanceRepository
+	metrics *metrics.Metrics
+
+	// retention is how long history records are kept, zero keeps them forever.
+	retention time.Duration
+	now       func() time.Time
+}
+
+// Option configures optional Maintainer behavior.
+type Option func(*Maintainer)
+
+// WithRetention prunes history records older than retention on every run.
+func WithRetention(retention time.Duration) Option {
+	return func(m *Maintainer) {
+		m.retention = retention
+	}
+}
+
+// NewMaintainer creates a new Maintainer instance.
+func NewMaintainer(
+	log *slog.Logger,
+	repo sqlite.MaintenanceRepository,
+	metrics *metrics.Metrics,
+	opts ...Option,
+) *Maintainer {
+	m := &Maintainer{log: log, repo: repo, metrics: metrics, now: time.Now}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Run performs a single maintenance pass and reports the reclaimed space.
+func (m *Maintainer) Run(ctx context.Context) (*models.MaintenanceReport, error) {
+	const opn = "maintenance.Run"
+	log := m.log.With("op", opn)
+
+	log.InfoContext(ctx, "Running database maintenance")
+	if m.retention > 0 {
+		pruned, err := m.repo.PruneHistory(ctx, m.now().Add(-m.retention))
+		if err != nil {
+			m.metrics.MaintenanceRuns.WithLabelValues("error").Inc()
+			return nil, fmt.Errorf("%s: %w", opn, err)
+		}
+		m.metrics.PrunedRecords.Add(float64(pruned))
+		log.InfoContext(ctx, "Pruned expired history", "records", pruned, "retention", m.retention)
+	}
+
+	report, err := m.repo.Vacuum(ctx)
+	if err != nil {
+		m.metrics.MaintenanceRuns.WithLabelValues("error").Inc()
+		return nil, fmt.Errorf("%s: %w", opn, err)
+	}
+
+	m.metrics.MaintenanceRuns.WithLabelValues("success").Inc()
+	m.metrics.DatabaseSize.Set(float64(report.SizeAfter))
+	m.metrics.ReclaimedBytes.Add(float64(report.Reclaimed()))
+
+	log.InfoContext(ctx, "Database maintenance completed",
+		"size_before", report.SizeBefore,
+		"size_after", report.SizeAfter,
+		"reclaimed", report.Reclaimed(),
+	)
+
+	return report, nil
+}
@@ -0,0 +1,89 @@
+package maintenance_test
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/metrics"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/services/maintenance"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintainer_Run(t *testing.T) {
+	ctx := t.Context()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		appMetrics := metrics.New()
+		mockRepo := mocks.NewMaintenanceRepository(t)
+		mockRepo.On("Vacuum", mock.Anything).
+			Return(&models.MaintenanceReport{SizeBefore: 8192, SizeAfter: 4096}, nil).Once()
+		maintainer := maintenance.NewMaintainer(logger, mockRepo, appMetrics)
+
+		// Act
+		report, err := maintainer.Run(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, int64(4096), report.Reclaimed())
+		assert.InDelta(t, 4096, testutil.ToFloat64(appMetrics.ReclaimedBytes), 0)
+		assert.InDelta(t, 4096, testutil.ToFloat64(appMetrics.DatabaseSize), 0)
+		assert.InDelta(t, 1, testutil.ToFloat64(appMetrics.MaintenanceRuns.WithLabelValues("success")), 0)
+	})
+
+	t.Run("prunes expired history", func(t *testing.T) {
+		// Arrange
+		appMetrics := metrics.New()
+		mockRepo := mocks.NewMaintenanceRepository(t)
+		mockRepo.On("PruneHistory", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+			return time.Since(before) > 29*24*time.Hour
+		})).Return(int64(12), nil).Once()
+		mockRepo.On("Vacuum", mock.Anything).Return(&models.MaintenanceReport{}, nil).Once()
+		maintainer := maintenance.NewMaintainer(logger, mockRepo, appMetrics, maintenance.WithRetention(30*24*time.Hour))
+
+		// Act
+		_, err := maintainer.Run(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.InDelta(t, 12, testutil.ToFloat64(appMetrics.PrunedRecords), 0)
+	})
+
+	t.Run("error: pruning fails", func(t *testing.T) {
+		// Arrange
+		appMetrics := metrics.New()
+		mockRepo := mocks.NewMaintenanceRepository(t)
+		mockRepo.On("PruneHistory", mock.Anything, mock.Anything).Return(int64(0), assert.AnError).Once()
+		maintainer := maintenance.NewMaintainer(logger, mockRepo, appMetrics, maintenance.WithRetention(time.Hour))
+
+		// Act
+		_, err := maintainer.Run(ctx)
+
+		// Assert
+		require.ErrorIs(t, err, assert.AnError)
+		assert.InDelta(t, 1, testutil.ToFloat64(appMetrics.MaintenanceRuns.WithLabelValues("error")), 0)
+	})
+
+	t.Run("error: vacuum fails", func(t *testing.T) {
+		// Arrange
+		appMetrics := metrics.New()
+		mockRepo := mocks.NewMaintenanceRepository(t)
+		mockRepo.On("Vacuum", mock.Anything).Return(nil, assert.AnError).Once()
+		maintainer := maintenance.NewMaintainer(logger, mockRepo, appMetrics)
+
+		// Act
+		_, err := maintainer.Run(ctx)
+
+		// Assert
+		require.ErrorIs(t, err, assert.AnError)
+		assert.InDelta(t, 1, testutil.ToFloat64(appMetrics.MaintenanceRuns.WithLabelValues("error")), 0)
+	})
+}
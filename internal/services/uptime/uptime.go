@@ -0,0 +1,68 @@
+package uptime
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/metrics"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/repository/sqlite"
+)
+
+// Tracker records the availability of the target page, the quality of its parsed rows and the
+// fingerprints of its checks over time.
+type Tracker struct {
+	log     *slog.Logger
+	repo    sqlite.UptimeRepository
+	metrics *metrics.Metrics
+	now     func() time.Time
+}
+
+// NewTracker creates a new Tracker instance.
+func NewTracker(log *slog.Logger, repo sqlite.UptimeRepository, metrics *metrics.Metrics) *Tracker {
+	return &Tracker{log: log, repo: repo, metrics: metrics, now: time.Now}
+}
+
+// Observe records the outcome of a fetch, it matches checker.FetchObserver.
+// A failure to store the record is logged, it must never fail the check itself.
+func (t *Tracker) Observe(ctx context.Context, latency time.Duration, fetchErr error) {
+	record := models.FetchRecord{
+		FetchedAt: t.now().Add(-latency),
+		Latency:   latency,
+		Success:   fetchErr == nil,
+	}
+
+	result := "success"
+	if fetchErr != nil {
+		result = "error"
+		record.Error = fetchErr.Error()
+	}
+	t.metrics.Fetches.WithLabelValues(result).Inc()
+	t.metrics.FetchDuration.Observe(latency.Seconds())
+
+	if err := t.repo.RecordFetch(ctx, record); err != nil {
+		t.log.ErrorContext(ctx, "failed to record fetch", "op", "uptime.Observe", "error", err)
+	}
+}
+
+// ObserveParse exports the row validation report of a parsed page, it matches checker.ParseObserver.
+func (t *Tracker) ObserveParse(_ context.Context, report models.ParseReport) {
+	t.metrics.ParsedRows.WithLabelValues("valid").Add(float64(report.Valid))
+	t.metrics.ParsedRows.WithLabelValues("invalid").Add(float64(report.Invalid))
+	t.metrics.ParsedRows.WithLabelValues("duplicate").Add(float64(report.Duplicates))
+	t.metrics.InvalidRowRatio.Set(report.InvalidRatio())
+}
+
+// ObserveFailure counts a failed check by the class of its error, it matches checker.FailureObserver.
+func (t *Tracker) ObserveFailure(_ context.Context, class string, _ error) {
+	t.metrics.CheckFailures.WithLabelValues(class).Inc()
+}
+
+// ObserveRun records the fingerprints of a successful check, it matches checker.RunObserver.
+// A failure to store the record is logged, it must never fail the check itself.
+func (t *Tracker) ObserveRun(ctx context.Context, run models.RunRecord) {
+	if err := t.repo.RecordRun(ctx, run); err != nil {
+		t.log.ErrorContext(ctx, "failed to record run", "op", "uptime.ObserveRun", "error", err)
+	}
+}
@@ -0,0 +1,110 @@
+package uptime_test
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Houeta/chrono-flow/internal/metrics"
+	"github.com/Houeta/chrono-flow/internal/models"
+	"github.com/Houeta/chrono-flow/internal/services/uptime"
+	"github.com/Houeta/chrono-flow/test/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTracker_Observe(t *testing.T) {
+	ctx := t.Context()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("successful fetch", func(t *testing.T) {
+		// Arrange
+		appMetrics := metrics.New()
+		mockRepo := mocks.NewUptimeRepository(t)
+		mockRepo.On("RecordFetch", mock.Anything, mock.MatchedBy(func(record models.FetchRecord) bool {
+			return record.Success && record.Latency == 800*time.Millisecond && record.Error == ""
+		})).Return(nil).Once()
+		tracker := uptime.NewTracker(logger, mockRepo, appMetrics)
+
+		// Act
+		tracker.Observe(ctx, 800*time.Millisecond, nil)
+
+		// Assert
+		assert.InDelta(t, 1, testutil.ToFloat64(appMetrics.Fetches.WithLabelValues("success")), 0)
+	})
+
+	t.Run("failed fetch is recorded even if storing fails", func(t *testing.T) {
+		// Arrange
+		appMetrics := metrics.New()
+		mockRepo := mocks.NewUptimeRepository(t)
+		mockRepo.On("RecordFetch", mock.Anything, mock.MatchedBy(func(record models.FetchRecord) bool {
+			return !record.Success && record.Error == assert.AnError.Error()
+		})).Return(assert.AnError).Once()
+		tracker := uptime.NewTracker(logger, mockRepo, appMetrics)
+
+		// Act
+		tracker.Observe(ctx, time.Second, assert.AnError)
+
+		// Assert
+		assert.InDelta(t, 1, testutil.ToFloat64(appMetrics.Fetches.WithLabelValues("error")), 0)
+	})
+}
+
+func TestTracker_ObserveParse(t *testing.T) {
+	// Arrange
+	appMetrics := metrics.New()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tracker := uptime.NewTracker(logger, mocks.NewUptimeRepository(t), appMetrics)
+
+	// Act
+	tracker.ObserveParse(t.Context(), models.ParseReport{Valid: 3, Invalid: 1, Duplicates: 1})
+
+	// Assert
+	assert.InDelta(t, 3, testutil.ToFloat64(appMetrics.ParsedRows.WithLabelValues("valid")), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(appMetrics.ParsedRows.WithLabelValues("invalid")), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(appMetrics.ParsedRows.WithLabelValues("duplicate")), 0)
+	assert.InDelta(t, 0.25, testutil.ToFloat64(appMetrics.InvalidRowRatio), 0)
+}
+
+func TestTracker_ObserveFailure(t *testing.T) {
+	// Arrange
+	appMetrics := metrics.New()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tracker := uptime.NewTracker(logger, mocks.NewUptimeRepository(t), appMetrics)
+
+	// Act
+	tracker.ObserveFailure(t.Context(), "fetch", assert.AnError)
+	tracker.ObserveFailure(t.Context(), "fetch", assert.AnError)
+	tracker.ObserveFailure(t.Context(), "state", assert.AnError)
+
+	// Assert
+	assert.InDelta(t, 2, testutil.ToFloat64(appMetrics.CheckFailures.WithLabelValues("fetch")), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(appMetrics.CheckFailures.WithLabelValues("state")), 0)
+}
+
+func TestTracker_ObserveRun(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	run := models.RunRecord{RunID: "run", CheckedAt: time.Now(), PageHash: "page", DiffHash: "diff"}
+
+	t.Run("run is recorded", func(t *testing.T) {
+		// Arrange
+		mockRepo := mocks.NewUptimeRepository(t)
+		mockRepo.On("RecordRun", mock.Anything, run).Return(nil).Once()
+		tracker := uptime.NewTracker(logger, mockRepo, metrics.New())
+
+		// Act & Assert
+		tracker.ObserveRun(t.Context(), run)
+	})
+
+	t.Run("repository failure is logged", func(t *testing.T) {
+		// Arrange
+		mockRepo := mocks.NewUptimeRepository(t)
+		mockRepo.On("RecordRun", mock.Anything, run).Return(assert.AnError).Once()
+		tracker := uptime.NewTracker(logger, mockRepo, metrics.New())
+
+		// Act & Assert
+		tracker.ObserveRun(t.Context(), run)
+	})
+}
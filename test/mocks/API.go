@@ -12,6 +12,66 @@ type API struct {
 	mock.Mock
 }
 
+// ChatByID provides a mock function with given fields: id
+func (_m *API) ChatByID(id int64) (*telebot.Chat, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChatByID")
+	}
+
+	var r0 *telebot.Chat
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64) (*telebot.Chat, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(int64) *telebot.Chat); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*telebot.Chat)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChatMemberOf provides a mock function with given fields: chat, user
+func (_m *API) ChatMemberOf(chat telebot.Recipient, user telebot.Recipient) (*telebot.ChatMember, error) {
+	ret := _m.Called(chat, user)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChatMemberOf")
+	}
+
+	var r0 *telebot.ChatMember
+	var r1 error
+	if rf, ok := ret.Get(0).(func(telebot.Recipient, telebot.Recipient) (*telebot.ChatMember, error)); ok {
+		return rf(chat, user)
+	}
+	if rf, ok := ret.Get(0).(func(telebot.Recipient, telebot.Recipient) *telebot.ChatMember); ok {
+		r0 = rf(chat, user)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*telebot.ChatMember)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(telebot.Recipient, telebot.Recipient) error); ok {
+		r1 = rf(chat, user)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Handle provides a mock function with given fields: endpoint, h, m
 func (_m *API) Handle(endpoint interface{}, h telebot.HandlerFunc, m ...telebot.MiddlewareFunc) {
 	_va := make([]interface{}, len(m))
@@ -62,6 +122,27 @@ func (_m *API) NewContext(u telebot.Update) telebot.Context {
 	return r0
 }
 
+// Pin provides a mock function with given fields: msg, opts
+func (_m *API) Pin(msg telebot.Editable, opts ...interface{}) error {
+	var _ca []interface{}
+	_ca = append(_ca, msg)
+	_ca = append(_ca, opts...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Pin")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(telebot.Editable, ...interface{}) error); ok {
+		r0 = rf(msg, opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Send provides a mock function with given fields: to, what, opts
 func (_m *API) Send(to telebot.Recipient, what interface{}, opts ...interface{}) (*telebot.Message, error) {
 	var _ca []interface{}
@@ -95,6 +176,59 @@ func (_m *API) Send(to telebot.Recipient, what interface{}, opts ...interface{})
 	return r0, r1
 }
 
+// SendAlbum provides a mock function with given fields: to, a, opts
+func (_m *API) SendAlbum(to telebot.Recipient, a telebot.Album, opts ...interface{}) ([]telebot.Message, error) {
+	var _ca []interface{}
+	_ca = append(_ca, to, a)
+	_ca = append(_ca, opts...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SendAlbum")
+	}
+
+	var r0 []telebot.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(telebot.Recipient, telebot.Album, ...interface{}) ([]telebot.Message, error)); ok {
+		return rf(to, a, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(telebot.Recipient, telebot.Album, ...interface{}) []telebot.Message); ok {
+		r0 = rf(to, a, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]telebot.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(telebot.Recipient, telebot.Album, ...interface{}) error); ok {
+		r1 = rf(to, a, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetCommands provides a mock function with given fields: opts
+func (_m *API) SetCommands(opts ...interface{}) error {
+	var _ca []interface{}
+	_ca = append(_ca, opts...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetCommands")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(...interface{}) error); ok {
+		r0 = rf(opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Start provides a mock function with no fields
 func (_m *API) Start() {
 	_m.Called()
@@ -105,6 +239,31 @@ func (_m *API) Stop() {
 	_m.Called()
 }
 
+// Unpin provides a mock function with given fields: chat, messageID
+func (_m *API) Unpin(chat telebot.Recipient, messageID ...int) error {
+	_va := make([]interface{}, len(messageID))
+	for _i := range messageID {
+		_va[_i] = messageID[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, chat)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Unpin")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(telebot.Recipient, ...int) error); ok {
+		r0 = rf(chat, messageID...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewAPI creates a new instance of API. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewAPI(t interface {